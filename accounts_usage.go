@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"lyrics-api-go/services/providers/ttml"
+	"lyrics-api-go/stats"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// accountUsageWindows are the selectable report windows
+var accountUsageWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+const (
+	// Below this many upstream requests per account the shares are mostly noise
+	minRequestsPerAccountForBalance = 10
+
+	overusedShareFactor  = 1.5 // Share above fair share × this is flagged overused
+	underusedShareFactor = 0.5 // Share below fair share × this is flagged underused
+	rateLimitedWarnRatio = 0.1 // Flag accounts with more than 10% of attempts rate limited
+)
+
+// accountUsageRow is one account in the /accounts/usage report
+type accountUsageRow struct {
+	Name string `json:"name"`
+	stats.AccountAttemptCounts
+	SuccessRate       float64 `json:"success_rate"` // Percent of attempts not rate limited or failed
+	Share             float64 `json:"share"`        // Percent of all upstream attempts in the window
+	LifetimeSuccesses int64   `json:"lifetime_successes"`
+	State             string  `json:"state,omitempty"`
+}
+
+// accountUsageReport is the /accounts/usage response
+type accountUsageReport struct {
+	Window      string                     `json:"window"`
+	Accounts    []accountUsageRow          `json:"accounts"`
+	Totals      stats.AccountAttemptCounts `json:"totals"`
+	FairShare   float64                    `json:"fair_share"`      // Percent each account would carry if perfectly balanced
+	Imbalance   float64                    `json:"imbalance_score"` // Coefficient of variation of requests (0 = perfectly even)
+	Suggestions []string                   `json:"suggestions"`
+}
+
+// buildAccountUsageReport computes shares, the imbalance score and rebalance suggestions
+// for the active accounts. Accounts seen in stats but no longer configured are left out.
+func buildAccountUsageReport(window string, names []string, attempts map[string]stats.AccountAttemptCounts, lifetime map[string]int64) accountUsageReport {
+	report := accountUsageReport{Window: window, Accounts: []accountUsageRow{}, Suggestions: []string{}}
+	if len(names) == 0 {
+		report.Suggestions = append(report.Suggestions, "No active accounts configured")
+		return report
+	}
+
+	for _, name := range names {
+		counts := attempts[name]
+		report.Totals.Requests += counts.Requests
+		report.Totals.RateLimited += counts.RateLimited
+		report.Totals.Errors += counts.Errors
+		report.Totals.Retries += counts.Retries
+		report.Accounts = append(report.Accounts, accountUsageRow{
+			Name:                 name,
+			AccountAttemptCounts: counts,
+			LifetimeSuccesses:    lifetime[name],
+		})
+	}
+
+	n := float64(len(names))
+	report.FairShare = round2(100 / n)
+	mean := float64(report.Totals.Requests) / n
+	var variance float64
+	for i := range report.Accounts {
+		row := &report.Accounts[i]
+		if row.Requests > 0 {
+			row.SuccessRate = round2(float64(row.Requests-row.RateLimited-row.Errors) / float64(row.Requests) * 100)
+		}
+		if report.Totals.Requests > 0 {
+			row.Share = round2(float64(row.Requests) / float64(report.Totals.Requests) * 100)
+		}
+		variance += (float64(row.Requests) - mean) * (float64(row.Requests) - mean)
+	}
+	if mean > 0 {
+		report.Imbalance = round2(math.Sqrt(variance/n) / mean)
+	}
+
+	sort.SliceStable(report.Accounts, func(i, j int) bool {
+		return report.Accounts[i].Requests > report.Accounts[j].Requests
+	})
+
+	if report.Totals.Requests < int64(len(names))*minRequestsPerAccountForBalance {
+		report.Suggestions = append(report.Suggestions, fmt.Sprintf(
+			"Only %d upstream requests in the last %s; too few to judge balance", report.Totals.Requests, window))
+		return report
+	}
+
+	fairShare := 100 / n
+	for _, row := range report.Accounts {
+		switch {
+		case row.Share > fairShare*overusedShareFactor:
+			msg := fmt.Sprintf("%s carries %.1f%% of upstream requests (fair share %.1f%%)", row.Name, row.Share, fairShare)
+			if report.Totals.Retries > 0 && row.Retries*2 > row.Requests {
+				msg += fmt.Sprintf("; %d of its %d requests were retries, so it is absorbing other accounts' failures", row.Retries, row.Requests)
+			}
+			report.Suggestions = append(report.Suggestions, msg)
+		case row.Share < fairShare*underusedShareFactor:
+			report.Suggestions = append(report.Suggestions, fmt.Sprintf(
+				"%s carries only %.1f%% of upstream requests; check whether it is quarantined, disabled or expiring", row.Name, row.Share))
+		}
+		if row.Requests > 0 && float64(row.RateLimited)/float64(row.Requests) > rateLimitedWarnRatio {
+			report.Suggestions = append(report.Suggestions, fmt.Sprintf(
+				"%s was rate limited on %d of %d requests; consider adding accounts to spread load", row.Name, row.RateLimited, row.Requests))
+		}
+	}
+	if len(report.Suggestions) == 0 {
+		report.Suggestions = append(report.Suggestions, "Load is evenly spread across accounts")
+	}
+	return report
+}
+
+// accountUsageHandler reports per-account upstream usage over a window: requests,
+// 429s, errors, retries, success rate and share of traffic, with an imbalance score
+// and rebalance suggestions.
+//
+// Query params:
+//   - window: 1h, 6h, 24h (default) or 7d
+func accountUsageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "24h"
+	}
+	duration, ok := accountUsageWindows[window]
+	if !ok {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "window must be one of 1h, 6h, 24h, 7d",
+		})
+		return
+	}
+
+	accounts, err := conf().GetTTMLAccounts()
+	if err != nil {
+		Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	names := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		names = append(names, acc.Name)
+	}
+
+	s := stats.Get()
+	report := buildAccountUsageReport(window, names, s.AccountAttempts(duration), s.AccountUsageSnapshot())
+	for i := range report.Accounts {
+		report.Accounts[i].State = ttml.GetAccountState(report.Accounts[i].Name)
+	}
+	Respond(w, r).JSON(report)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildAccountUsageReport_Skewed(t *testing.T) {
+	attempts := map[string]stats.AccountAttemptCounts{
+		"Billie": {Requests: 80, RateLimited: 20, Retries: 50},
+		"Dua":    {Requests: 15},
+		"Olivia": {Requests: 5},
+	}
+	report := buildAccountUsageReport("24h", []string{"Olivia", "Dua", "Billie"}, attempts, map[string]int64{"Billie": 1000})
+
+	if report.Totals.Requests != 100 {
+		t.Fatalf("Expected 100 total requests, got %d", report.Totals.Requests)
+	}
+	if report.Accounts[0].Name != "Billie" || report.Accounts[0].Share != 80 || report.Accounts[0].SuccessRate != 75 {
+		t.Errorf("Unexpected top row: %+v", report.Accounts[0])
+	}
+	if report.Accounts[0].LifetimeSuccesses != 1000 {
+		t.Errorf("Expected lifetime successes carried through, got %d", report.Accounts[0].LifetimeSuccesses)
+	}
+	if report.Imbalance < 1 {
+		t.Errorf("Expected a high imbalance score, got %v", report.Imbalance)
+	}
+
+	joined := strings.Join(report.Suggestions, "\n")
+	for _, want := range []string{"Billie carries 80.0%", "retries", "Olivia carries only", "rate limited on 20 of 80"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Expected suggestion containing %q, got:\n%s", want, joined)
+		}
+	}
+}
+
+func TestBuildAccountUsageReport_BalancedAndQuiet(t *testing.T) {
+	balanced := buildAccountUsageReport("1h", []string{"A", "B"}, map[string]stats.AccountAttemptCounts{
+		"A": {Requests: 50}, "B": {Requests: 50},
+	}, nil)
+	if balanced.Imbalance != 0 || balanced.Suggestions[0] != "Load is evenly spread across accounts" {
+		t.Errorf("Expected an even report, got %v / %v", balanced.Imbalance, balanced.Suggestions)
+	}
+
+	quiet := buildAccountUsageReport("1h", []string{"A", "B"}, map[string]stats.AccountAttemptCounts{"A": {Requests: 3}}, nil)
+	if len(quiet.Suggestions) != 1 || !strings.Contains(quiet.Suggestions[0], "too few") {
+		t.Errorf("Expected a low-traffic note, got %v", quiet.Suggestions)
+	}
+}
+
+func TestAccountUsageHandler(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	rr := httptest.NewRecorder()
+	accountUsageHandler(rr, httptest.NewRequest("GET", "/accounts/usage", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", rr.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/accounts/usage?window=2w", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	accountUsageHandler(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown window, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/accounts/usage?window=7d", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	accountUsageHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var report accountUsageReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if report.Window != "7d" {
+		t.Errorf("Expected window 7d, got %q", report.Window)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lyrics-api-go/jobs"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// Kinds of async admin jobs. Only one job of each kind runs at a time.
+const (
+	jobKindMigrate    = "migrate"
+	jobKindAnalyze    = "analyze"
+	jobKindDedupe     = "dedupe"
+	jobKindBulkDelete = "bulk_delete"
+	jobKindVerify     = "verify"
+	jobKindWarmup     = "warmup"
+	jobKindBackfill   = "backfill"
+	jobKindReindex    = "reindex"
+)
+
+// jobManager tracks every long-running admin operation
+var jobManager = jobs.NewManager(jobs.Options{
+	Retention:   time.Duration(conf().Configuration.JobRetentionHours) * time.Hour,
+	MaxFinished: conf().Configuration.JobMaxFinished,
+	Checkpoints: cacheCheckpointStore{},
+})
+
+// checkpointKeyPrefix namespaces job checkpoints in the meta bucket
+const checkpointKeyPrefix = "checkpoint:"
+
+// cacheCheckpointStore keeps job checkpoints in the cache DB's meta bucket, so a job
+// interrupted by a restart resumes with the data it was working on
+type cacheCheckpointStore struct{}
+
+func (cacheCheckpointStore) LoadCheckpoint(key string) (string, bool) {
+	if persistentCache == nil {
+		return "", false
+	}
+	value, ok := persistentCache.GetFromBucket(metaBucket, checkpointKeyPrefix+key)
+	return string(value), ok
+}
+
+func (cacheCheckpointStore) SaveCheckpoint(key, cursor string) error {
+	if persistentCache == nil {
+		return nil
+	}
+	if err := persistentCache.CreateBucket(metaBucket); err != nil {
+		return err
+	}
+	return persistentCache.SetInBucket(metaBucket, checkpointKeyPrefix+key, []byte(cursor))
+}
+
+func (cacheCheckpointStore) DeleteCheckpoint(key string) error {
+	if persistentCache == nil {
+		return nil
+	}
+	return persistentCache.DeleteFromBucket(metaBucket, checkpointKeyPrefix+key)
+}
+
+// startJob starts an async job and writes the response: 202 with the job ID and a
+// status URL, or 409 if a job of the same kind is still running.
+func startJob(w http.ResponseWriter, r *http.Request, kind string, params map[string]interface{}, statusPath, message string, run jobs.RunFunc) (jobs.Job, bool) {
+	job, err := jobManager.Start(kind, params, run)
+	var running *jobs.RunningError
+	if errors.As(err, &running) {
+		Respond(w, r).Error(http.StatusConflict, map[string]interface{}{
+			"error":  fmt.Sprintf("A %s job is already in progress", kind),
+			"job_id": running.ID,
+		})
+		return job, false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    message,
+		"job_id":     job.ID,
+		"status_url": fmt.Sprintf("%s?job_id=%s", statusPath, job.ID),
+	})
+	return job, true
+}
+
+// writeJobStatus serves a per-kind status endpoint: one job by ?job_id, or every
+// job of the kind. extra is merged into the list response.
+func writeJobStatus(w http.ResponseWriter, r *http.Request, kind string, extra map[string]interface{}) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	jobID := r.URL.Query().Get("job_id")
+	if jobID == "" {
+		response := map[string]interface{}{
+			"jobs": jobManager.List(kind),
+		}
+		for k, v := range extra {
+			response[k] = v
+		}
+		Respond(w, r).JSON(response)
+		return
+	}
+
+	job, ok := jobManager.Get(jobID)
+	if !ok || job.Kind != kind {
+		Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+			"error": "Job not found",
+		})
+		return
+	}
+	Respond(w, r).JSON(job)
+}
+
+// jobsHandler lists async admin jobs of every kind, newest first.
+//
+// Query params:
+//   - kind: Only jobs of this kind (migrate, analyze, dedupe, bulk_delete, verify, warmup, backfill)
+//   - status: Only jobs in this state (pending, running, completed, failed, cancelled)
+func jobsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status := jobs.Status(r.URL.Query().Get("status"))
+	list := jobManager.List(r.URL.Query().Get("kind"))
+	if status != "" {
+		filtered := list[:0]
+		for _, job := range list {
+			if job.Status == status {
+				filtered = append(filtered, job)
+			}
+		}
+		list = filtered
+	}
+	Respond(w, r).JSON(map[string]interface{}{
+		"jobs":  list,
+		"count": len(list),
+	})
+}
+
+// jobHandler returns one job of any kind
+func jobHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	job, ok := jobManager.Get(mux.Vars(r)["id"])
+	if !ok {
+		Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+			"error": "Job not found",
+		})
+		return
+	}
+	Respond(w, r).JSON(job)
+}
+
+// cancelJobHandler asks a pending or running job to stop. The job stops at its next
+// check, keeping the partial result and any checkpoint, and ends up "cancelled";
+// starting the same operation again resumes from the checkpoint.
+func cancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	switch err := jobManager.Cancel(id); {
+	case errors.Is(err, jobs.ErrNotFound):
+		Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+			"error": "Job not found",
+		})
+		return
+	case errors.Is(err, jobs.ErrFinished):
+		job, _ := jobManager.Get(id)
+		Respond(w, r).Error(http.StatusConflict, map[string]interface{}{
+			"error":  "Job already finished",
+			"status": job.Status,
+		})
+		return
+	}
+
+	log.Infof("%s Cancellation requested for job %s", logcolors.LogJobs, id)
+	job, _ := jobManager.Get(id)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Cancellation requested",
+		"job":     job,
+	})
+}
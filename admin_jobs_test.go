@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"lyrics-api-go/jobs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// waitForStartedJob reads the job ID from a 202 response and waits for the job to finish
+func waitForStartedJob(t *testing.T, rr *httptest.ResponseRecorder) jobs.Job {
+	t.Helper()
+	var started struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &started); err != nil || started.JobID == "" {
+		t.Fatalf("No job_id in response: %s", rr.Body.String())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := jobManager.Wait(ctx, started.JobID)
+	if err != nil {
+		t.Fatalf("Job %s did not finish: %v", started.JobID, err)
+	}
+	return job
+}
+
+func TestStartJob_ConflictWhileRunning(t *testing.T) {
+	release := make(chan struct{})
+	run := func(task *jobs.Task) (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+
+	rr := httptest.NewRecorder()
+	first, ok := startJob(rr, httptest.NewRequest(http.MethodGet, "/x", nil), "test_conflict", nil, "/x/status", "Started", run)
+	if !ok || rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	if _, ok := startJob(rr, httptest.NewRequest(http.MethodGet, "/x", nil), "test_conflict", nil, "/x/status", "Started", run); ok {
+		t.Fatal("Second job of the same kind must not start")
+	}
+	var conflict map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &conflict)
+	if rr.Code != http.StatusConflict || conflict["job_id"] != first.ID {
+		t.Errorf("Expected 409 naming %s, got %d: %s", first.ID, rr.Code, rr.Body.String())
+	}
+
+	close(release)
+	jobManager.Wait(context.Background(), first.ID)
+}
+
+func TestJobsHandler(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	started, err := jobManager.Start("test_list", map[string]interface{}{"n": 1}, func(task *jobs.Task) (interface{}, error) {
+		return map[string]int{"done": 1}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobManager.Wait(context.Background(), started.ID)
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs?kind=test_list&status=completed", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr := httptest.NewRecorder()
+	jobsHandler(rr, req)
+
+	var list struct {
+		Jobs  []jobs.Job `json:"jobs"`
+		Count int        `json:"count"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if list.Count != 1 || list.Jobs[0].ID != started.ID {
+		t.Fatalf("Expected the finished test job, got %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs/"+started.ID, nil)
+	req.Header.Set("Authorization", "test-token")
+	req = mux.SetURLVars(req, map[string]string{"id": started.ID})
+	rr = httptest.NewRecorder()
+	jobHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a known job, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs/nope", nil)
+	req.Header.Set("Authorization", "test-token")
+	req = mux.SetURLVars(req, map[string]string{"id": "nope"})
+	rr = httptest.NewRecorder()
+	jobHandler(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown job, got %d", rr.Code)
+	}
+
+	// Per-kind status endpoints only see their own kind
+	req = httptest.NewRequest(http.MethodGet, "/cache/analyze/status?job_id="+started.ID, nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	getAnalysisStatus(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a job of another kind, got %d", rr.Code)
+	}
+}
+
+func TestCancelJobHandler(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	running := make(chan struct{})
+	started, _ := jobManager.Start("test_cancel", nil, func(task *jobs.Task) (interface{}, error) {
+		close(running)
+		<-task.Context().Done()
+		return nil, task.Context().Err()
+	})
+	<-running
+
+	cancel := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/jobs/"+id+"/cancel", nil)
+		req.Header.Set("Authorization", "test-token")
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		rr := httptest.NewRecorder()
+		cancelJobHandler(rr, req)
+		return rr
+	}
+
+	if rr := cancel(started.ID); rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	job, _ := jobManager.Wait(context.Background(), started.ID)
+	if job.Status != jobs.StatusCancelled {
+		t.Errorf("Expected cancelled, got %s", job.Status)
+	}
+
+	if rr := cancel(started.ID); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a finished job, got %d", rr.Code)
+	}
+	if rr := cancel("missing"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown job, got %d", rr.Code)
+	}
+}
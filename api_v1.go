@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// apiV1Prefix serves every endpoint with the standard JSON envelope (see apiEnvelope)
+// and snake_case field names. Unprefixed paths keep their legacy shapes.
+const apiV1Prefix = "/v1"
+
+// apiV1Middleware strips the /v1 prefix before anything else sees the request, so
+// routing, API key protection, rate limits and stats all apply as on the legacy
+// path, then wraps the JSON response in the envelope. Non-JSON responses (TTML,
+// LRC, CSV, pprof) and HEAD requests pass through unchanged.
+func apiV1Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, ok := strings.CutPrefix(r.URL.Path, apiV1Prefix)
+		if !ok || (path != "" && !strings.HasPrefix(path, "/")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if path == "" {
+			path = "/"
+		}
+
+		r = r.Clone(r.Context())
+		r.URL.Path = path
+		r.URL.RawPath = ""
+		if r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ew := &envelopeWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish()
+	})
+}
+
+// envelopeWriter buffers JSON responses (and plain-text errors from http.Error) so
+// they can be rewritten as an envelope; anything else is written straight through
+type envelopeWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	buffering   bool
+	buf         bytes.Buffer
+}
+
+func (e *envelopeWriter) WriteHeader(code int) {
+	if e.wroteHeader {
+		return
+	}
+	e.wroteHeader = true
+	e.statusCode = code
+
+	contentType := e.Header().Get("Content-Type")
+	e.buffering = contentType == "" || strings.HasPrefix(contentType, "application/json") ||
+		(code >= http.StatusBadRequest && strings.HasPrefix(contentType, "text/plain"))
+	if !e.buffering {
+		e.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (e *envelopeWriter) Write(b []byte) (int, error) {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	if e.buffering {
+		return e.buf.Write(b)
+	}
+	return e.ResponseWriter.Write(b)
+}
+
+// finish writes the buffered response, enveloped when it is JSON or an error
+func (e *envelopeWriter) finish() {
+	if !e.wroteHeader || !e.buffering {
+		return
+	}
+
+	var payload interface{}
+	decoder := json.NewDecoder(bytes.NewReader(e.buf.Bytes()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		if e.statusCode < http.StatusBadRequest {
+			// Not JSON after all (e.g. a handler that didn't set Content-Type)
+			e.ResponseWriter.WriteHeader(e.statusCode)
+			e.ResponseWriter.Write(e.buf.Bytes())
+			return
+		}
+		payload = e.buf.String()
+	}
+
+	body, err := json.Marshal(newAPIEnvelope(e.statusCode, e.Header(), payload))
+	if err != nil {
+		e.ResponseWriter.WriteHeader(e.statusCode)
+		e.ResponseWriter.Write(e.buf.Bytes())
+		return
+	}
+	e.Header().Set("Content-Type", "application/json")
+	e.Header().Set("Content-Length", strconv.Itoa(len(body)+1))
+	e.ResponseWriter.WriteHeader(e.statusCode)
+	e.ResponseWriter.Write(append(body, '\n'))
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveV1(t *testing.T, method, target string) (*httptest.ResponseRecorder, apiEnvelope) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	apiV1Middleware(newTestRouter()).ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+	var env apiEnvelope
+	if err := json.Unmarshal(rr.Body.Bytes(), &env); err != nil {
+		t.Fatalf("%s: response is not an envelope: %v (%s)", target, err, rr.Body.String())
+	}
+	return rr, env
+}
+
+func TestAPIV1_WrapsLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	setCachedLyrics(buildNormalizedCacheKey("Cached Song", "Artist", "", ""), testTTML, 0, 0, "", false)
+
+	rr, env := serveV1(t, http.MethodGet, "/v1/getLyrics?s=Cached+Song&a=Artist")
+	if rr.Code != http.StatusOK || env.Error != nil {
+		t.Fatalf("Expected 200 without error, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data, _ := env.Data.(map[string]interface{})
+	if data["ttml"] != testTTML {
+		t.Errorf("Expected the TTML under data, got %v", env.Data)
+	}
+	if env.Meta.APIVersion != "v1" || env.Meta.Status != http.StatusOK || env.Meta.CacheStatus != "HIT" {
+		t.Errorf("Unexpected meta: %+v", env.Meta)
+	}
+
+	// The legacy path keeps its shape
+	legacy := httptest.NewRecorder()
+	apiV1Middleware(newTestRouter()).ServeHTTP(legacy, httptest.NewRequest(http.MethodGet, "/getLyrics?s=Cached+Song&a=Artist", nil))
+	if strings.Contains(legacy.Body.String(), `"meta"`) {
+		t.Errorf("Legacy path should not be enveloped: %s", legacy.Body.String())
+	}
+}
+
+func TestAPIV1_WrapsErrors(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	// JSON error body: "error" becomes the message, the rest details
+	rr, env := serveV1(t, http.MethodPost, "/v1/stats")
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected 405, got %d", rr.Code)
+	}
+	if env.Error == nil || env.Data != nil || env.Error.Status != rr.Code || env.Error.Message != "Method POST not allowed" || env.Error.Details["allowed"] == nil {
+		t.Errorf("Unexpected error envelope: %+v", env)
+	}
+
+	// Plain-text http.Error
+	rr, env = serveV1(t, http.MethodGet, "/v1/stats")
+	if rr.Code != http.StatusUnauthorized || env.Error == nil || env.Error.Message != "Unauthorized" {
+		t.Errorf("Expected an Unauthorized envelope, got %d %+v", rr.Code, env.Error)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestAPIV1_PassesThroughNonJSON(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	setCachedLyrics(buildNormalizedCacheKey("Cached Song", "Artist", "", ""), testTTML, 0, 0, "", false)
+
+	rr := httptest.NewRecorder()
+	apiV1Middleware(newTestRouter()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/getLyrics?s=Cached+Song&a=Artist&format=lrc", nil))
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"data"`) {
+		t.Errorf("LRC should pass through, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Paths that only share the prefix are not v1
+	rr = httptest.NewRecorder()
+	apiV1Middleware(newTestRouter()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1x/getLyrics", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for /v1x, got %d", rr.Code)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"trackId":              "track_id",
+		"isRTL":                "is_rtl",
+		"startTimeMs":          "start_time_ms",
+		"retry_after":          "retry_after",
+		"ttml":                 "ttml",
+		"Hello":                "Hello",
+		"zh-Hant":              "zh-Hant",
+		"ttml_lyrics:song art": "ttml_lyrics:song art",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	nested := snakeCaseKeys(map[string]interface{}{
+		"lines":   []interface{}{map[string]interface{}{"startTimeMs": "0", "isBackground": true}},
+		"tenants": map[string]interface{}{"partnerApp": 3},
+	}).(map[string]interface{})
+	line := nested["lines"].([]interface{})[0].(map[string]interface{})
+	if _, ok := line["start_time_ms"]; !ok {
+		t.Errorf("Nested keys not converted: %v", nested)
+	}
+	if _, ok := line["is_background"]; !ok {
+		t.Errorf("Struct field names not converted: %v", nested)
+	}
+	// Keys that are data rather than field names keep their spelling
+	if _, ok := nested["tenants"].(map[string]interface{})["partnerApp"]; !ok {
+		t.Errorf("Data keys renamed: %v", nested)
+	}
+}
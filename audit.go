@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/middleware"
+	"lyrics-api-go/services/notifier"
+	"lyrics-api-go/stats"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	auditDefaultLimit = 100
+	auditMaxLimit     = 1000
+)
+
+// audited wraps an admin handler so every accepted call is written to the audit log.
+// Calls rejected with 401 are not recorded (the handler did nothing).
+func audited(action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := middleware.NewResponseRecorder(w)
+		next(rec, r)
+		if rec.StatusCode == http.StatusUnauthorized {
+			return
+		}
+		recordAudit(stats.AuditEntry{
+			Action:   action,
+			Role:     auditRole(r),
+			RemoteIP: remoteIP(r),
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			Params:   auditParams(r),
+			Status:   rec.StatusCode,
+		})
+	}
+}
+
+// recordAudit appends an entry to the audit log. Failures are logged, never surfaced:
+// the audited operation has already happened by the time we get here.
+func recordAudit(entry stats.AuditEntry) {
+	if statsStore == nil {
+		return
+	}
+	entry, err := statsStore.AppendAudit(entry)
+	if err != nil {
+		log.Errorf("%s Failed to record %s: %v", logcolors.LogAudit, entry.Action, err)
+		return
+	}
+	log.Infof("%s #%d %s by %s (%s)", logcolors.LogAudit, entry.ID, entry.Action, entry.Role, entry.RemoteIP)
+}
+
+// auditRole reports which credential authorized the request
+func auditRole(r *http.Request) string {
+	if r.Header.Get("Authorization") == conf().Configuration.CacheAccessToken {
+		return stats.AuditRoleAdmin
+	}
+	if authenticated, _ := r.Context().Value(apiKeyAuthenticatedKey).(bool); authenticated {
+		return stats.AuditRoleAPIKey
+	}
+	return "anonymous"
+}
+
+// remoteIP strips the port from RemoteAddr (the same address the rate limiter keys on)
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// auditParams flattens the query string (first value per key)
+func auditParams(r *http.Request) map[string]string {
+	query := r.URL.Query()
+	if len(query) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(query))
+	for key, values := range query {
+		params[key] = values[0]
+	}
+	return params
+}
+
+// auditEventRecorder writes internal admin-relevant events (account disable/enable)
+// to the audit log so they show up next to operator actions.
+type auditEventRecorder struct{}
+
+func (auditEventRecorder) Name() string { return "audit" }
+
+func (auditEventRecorder) HandleEvent(event *notifier.Event) {
+	params := make(map[string]string, len(event.Data))
+	for key, value := range event.Data {
+		if s, ok := value.(string); ok {
+			params[key] = s
+		}
+	}
+	action := "account.disable"
+	if event.Type == notifier.EventAccountEnabled {
+		action = "account.enable"
+	}
+	recordAudit(stats.AuditEntry{
+		Timestamp: event.Timestamp,
+		Action:    action,
+		Role:      stats.AuditRoleSystem,
+		Params:    params,
+	})
+}
+
+// auditLogHandler returns audit entries, newest first.
+//
+// Query params:
+//   - action: exact action or dotted prefix (e.g. "cache" matches "cache.clear")
+//   - role: admin, api_key or system
+//   - ip: remote IP
+//   - since, until: unix seconds
+//   - limit: max entries (default 100, max 1000)
+func auditLogHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if statsStore == nil {
+		Respond(w, r).Error(http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "Audit log is not available",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := stats.AuditFilter{
+		Action:   query.Get("action"),
+		Role:     query.Get("role"),
+		RemoteIP: query.Get("ip"),
+		Limit:    auditDefaultLimit,
+	}
+	for param, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+		secs, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": param + " must be a unix timestamp",
+			})
+			return
+		}
+		*dst = time.Unix(secs, 0)
+	}
+	if raw := query.Get("limit"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			filter.Limit = min(n, auditMaxLimit)
+		}
+	}
+
+	entries, err := statsStore.QueryAudit(filter)
+	if err != nil {
+		Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":    statsStore.AuditCount(),
+		"returned": len(entries),
+		"entries":  entries,
+	})
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// setupTestAuditLog points statsStore at a temporary DB for the duration of the test
+func setupTestAuditLog(t *testing.T) {
+	t.Helper()
+	store, err := stats.NewStore(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("Failed to create stats store: %v", err)
+	}
+	saved := statsStore
+	statsStore = store
+	t.Cleanup(func() {
+		statsStore = saved
+		store.Close()
+	})
+}
+
+func TestAudited_RecordsAcceptedCalls(t *testing.T) {
+	setupTestAuditLog(t)
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	handler := audited("cache.clear", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	// Rejected call is not recorded
+	req := httptest.NewRequest("POST", "/cache/clear", nil)
+	handler(httptest.NewRecorder(), req)
+	if got := statsStore.AuditCount(); got != 0 {
+		t.Fatalf("Expected unauthorized call to be skipped, got %d entries", got)
+	}
+
+	req = httptest.NewRequest("POST", "/cache/clear?dry_run=true", nil)
+	req.Header.Set("Authorization", "test-token")
+	req.RemoteAddr = "192.0.2.7:51234"
+	handler(httptest.NewRecorder(), req)
+
+	entries, err := statsStore.QueryAudit(stats.AuditFilter{})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d (%v)", len(entries), err)
+	}
+	e := entries[0]
+	if e.Action != "cache.clear" || e.Role != stats.AuditRoleAdmin || e.RemoteIP != "192.0.2.7" ||
+		e.Status != http.StatusAccepted || e.Params["dry_run"] != "true" {
+		t.Errorf("Unexpected entry: %+v", e)
+	}
+}
+
+func TestAuditLogHandler(t *testing.T) {
+	setupTestAuditLog(t)
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	statsStore.AppendAudit(stats.AuditEntry{Action: "cache.clear", Role: stats.AuditRoleAdmin})
+	statsStore.AppendAudit(stats.AuditEntry{Action: "circuit_breaker.reset", Role: stats.AuditRoleAdmin})
+	statsStore.AppendAudit(stats.AuditEntry{Action: "account.disable", Role: stats.AuditRoleSystem})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		auditLogHandler(rr, httptest.NewRequest("GET", "/audit", nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Invalid since", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/audit?since=yesterday", nil)
+		req.Header.Set("Authorization", "test-token")
+		rr := httptest.NewRecorder()
+		auditLogHandler(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Filter by role", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/audit?role=admin&limit=1", nil)
+		req.Header.Set("Authorization", "test-token")
+		rr := httptest.NewRecorder()
+		auditLogHandler(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rr.Code)
+		}
+
+		var body struct {
+			Total    int                `json:"total"`
+			Returned int                `json:"returned"`
+			Entries  []stats.AuditEntry `json:"entries"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode: %v", err)
+		}
+		if body.Total != 3 || body.Returned != 1 || body.Entries[0].Action != "circuit_breaker.reset" {
+			t.Errorf("Unexpected response: %+v", body)
+		}
+	})
+}
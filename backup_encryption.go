@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/logcolors"
+	"net/url"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// loadBackupEncryptionKey returns the backup key from BACKUP_ENCRYPTION_KEY or
+// BACKUP_ENCRYPTION_KEY_URI, or nil when backups aren't encrypted. The URI names
+// where the base64 key lives: file:///run/secrets/backup-key (a secret mounted by
+// the platform or a KMS agent) or env://VAR.
+func loadBackupEncryptionKey() ([]byte, error) {
+	encoded := conf().Configuration.BackupEncryptionKey
+	if uri := conf().Configuration.BackupEncryptionKeyURI; uri != "" {
+		if encoded != "" {
+			return nil, fmt.Errorf("set only one of BACKUP_ENCRYPTION_KEY and BACKUP_ENCRYPTION_KEY_URI")
+		}
+		parsed, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BACKUP_ENCRYPTION_KEY_URI: %v", err)
+		}
+		switch parsed.Scheme {
+		case "file":
+			data, err := os.ReadFile(parsed.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read backup key: %v", err)
+			}
+			encoded = string(data)
+		case "env":
+			encoded = os.Getenv(parsed.Host)
+			if encoded == "" {
+				return nil, fmt.Errorf("backup key variable %s is empty", parsed.Host)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported BACKUP_ENCRYPTION_KEY_URI scheme %q (use file:// or env://)", parsed.Scheme)
+		}
+	}
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("backup key is not valid base64: %v", err)
+	}
+	if len(key) != cache.BackupKeySize {
+		return nil, fmt.Errorf("backup key must be %d bytes (openssl rand -base64 %d), got %d", cache.BackupKeySize, cache.BackupKeySize, len(key))
+	}
+	return key, nil
+}
+
+// configureBackupEncryption sets up encryption of cache backups at startup. A key
+// that is configured but unusable fails startup rather than writing plaintext backups.
+func configureBackupEncryption(pc *cache.PersistentCache) error {
+	key, err := loadBackupEncryptionKey()
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return nil
+	}
+	if err := pc.SetBackupKey(key); err != nil {
+		return err
+	}
+	log.Infof("%s Cache backups are encrypted (AES-256-GCM)", logcolors.LogCacheBackup)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadBackupEncryptionKey(t *testing.T) {
+	original := conf().Configuration
+	defer func() { conf().Configuration = original }()
+
+	key := bytes.Repeat([]byte{3}, 32)
+	encoded := base64.StdEncoding.EncodeToString(key)
+	keyFile := filepath.Join(t.TempDir(), "backup-key")
+	os.WriteFile(keyFile, []byte(encoded+"\n"), 0600)
+	t.Setenv("TEST_BACKUP_KEY", encoded)
+
+	tests := []struct {
+		name    string
+		key     string
+		uri     string
+		want    []byte
+		wantErr bool
+	}{
+		{"off", "", "", nil, false},
+		{"env key", encoded, "", key, false},
+		{"file URI", "", "file://" + keyFile, key, false},
+		{"env URI", "", "env://TEST_BACKUP_KEY", key, false},
+		{"both", encoded, "env://TEST_BACKUP_KEY", nil, true},
+		{"unsupported scheme", "", "awskms://alias/backups", nil, true},
+		{"missing file", "", "file:///nonexistent/backup-key", nil, true},
+		{"empty variable", "", "env://TEST_BACKUP_KEY_UNSET", nil, true},
+		{"not base64", "not-base64!", "", nil, true},
+		{"short key", base64.StdEncoding.EncodeToString([]byte("short")), "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf().Configuration.BackupEncryptionKey = tt.key
+			conf().Configuration.BackupEncryptionKeyURI = tt.uri
+			got, err := loadBackupEncryptionKey()
+			if (err != nil) != tt.wantErr || !bytes.Equal(got, tt.want) {
+				t.Errorf("loadBackupEncryptionKey() = %x, %v; want %x, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
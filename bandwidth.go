@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/middleware"
+	"lyrics-api-go/stats"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Response bytes are counted per endpoint, API key and cache status, in /stats
+// (since the last reset) and in a daily rollup in the stats DB (/stats/bandwidth),
+// so egress can be attributed to the frontends and payloads that cause it.
+
+// bandwidthEndpoint names the route a request matched: its path template, with the
+// /v1 prefix kept. Unmatched paths share one label so probes can't add rows.
+func bandwidthEndpoint(router *mux.Router, r *http.Request) string {
+	path, v1 := strings.CutPrefix(r.URL.Path, apiV1Prefix)
+	if !v1 || (path != "" && !strings.HasPrefix(path, "/")) {
+		path, v1 = r.URL.Path, false
+	}
+	match := &mux.RouteMatch{}
+	probe := r.Clone(r.Context())
+	probe.URL.Path = path
+	if !router.Match(probe, match) || match.Route == nil {
+		return "other"
+	}
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return "other"
+	}
+	if v1 {
+		return apiV1Prefix + template
+	}
+	return template
+}
+
+// bandwidthKeyLabel names the caller's API key without revealing it: the tenant of
+// an API_KEY_TENANTS key, "api_key" for API_KEY, "anonymous" without a key and
+// "invalid" for anything else
+func bandwidthKeyLabel(r *http.Request) string {
+	apiKey := r.Header.Get("X-API-Key")
+	switch {
+	case apiKey == "":
+		return "anonymous"
+	case apiKeyTenants()[apiKey] != "":
+		return "tenant:" + apiKeyTenants()[apiKey]
+	case conf().Configuration.APIKey != "" && apiKey == conf().Configuration.APIKey:
+		return "api_key"
+	}
+	return "invalid"
+}
+
+// bandwidthMiddleware counts the response bytes of every request. It wraps the
+// whole handler chain, so /v1 envelopes and error responses are counted as sent.
+func bandwidthMiddleware(router *mux.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := middleware.NewResponseRecorder(w)
+		next.ServeHTTP(rec, r)
+		stats.Get().RecordBandwidth(bandwidthEndpoint(router, r), bandwidthKeyLabel(r), rec.Header().Get("X-Cache-Status"), int64(rec.BodySize))
+	})
+}
+
+// statsBandwidthHandler returns the daily bandwidth rollup: requests and response
+// bytes per day, endpoint, API key and cache status.
+//
+// Query params:
+//   - from, to: YYYY-MM-DD, inclusive (default: the last 7 days, UTC)
+func statsBandwidthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if statsStore == nil {
+		Respond(w, r).Error(http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "Bandwidth rollup is not available",
+		})
+		return
+	}
+
+	fromDay, toDay, err := parseExportDays(r)
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	rows, err := statsStore.ExportBandwidth(fromDay, toDay)
+	if err != nil {
+		Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var total stats.BandwidthCount
+	for _, row := range rows {
+		total.Requests += row.Requests
+		total.Bytes += row.Bytes
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":  fromDay,
+		"to":    toDay,
+		"total": total,
+		"count": len(rows),
+		"rows":  rows,
+	})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBandwidthEndpoint(t *testing.T) {
+	router := newTestRouter()
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{http.MethodGet, "/getLyrics?s=x", "/getLyrics"},
+		{http.MethodGet, "/v1/getLyrics?s=x", "/v1/getLyrics"},
+		{http.MethodGet, "/stats/archives/20260101T000000.000000000Z", "/stats/archives/{id}"},
+		{http.MethodGet, "/wp-login.php", "other"},
+		{http.MethodGet, "/v1beta/getLyrics", "other"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := bandwidthEndpoint(router, r); got != tt.want {
+			t.Errorf("bandwidthEndpoint(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBandwidthMiddleware(t *testing.T) {
+	stats.Get().Reset(time.Now())
+	withTenants(t, "ext-key:extension", 0)
+	conf().Configuration.APIKey = "main-key"
+
+	router := newTestRouter()
+	handler := bandwidthMiddleware(router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Cache-Status", "HIT")
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+
+	for _, apiKey := range []string{"", "ext-key", "ext-key", "main-key", "stolen-key"} {
+		r := httptest.NewRequest(http.MethodGet, "/getLyrics?s=x", nil)
+		if apiKey != "" {
+			r.Header.Set("X-API-Key", apiKey)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	usage := stats.Get().BandwidthUsage()
+	if usage.Total != (stats.BandwidthCount{Requests: 5, Bytes: 500}) {
+		t.Errorf("Total = %+v", usage.Total)
+	}
+	want := map[string]int64{"anonymous": 100, "tenant:extension": 200, "api_key": 100, "invalid": 100}
+	for label, bytes := range want {
+		if got := usage.ByAPIKey[label].Bytes; got != bytes {
+			t.Errorf("ByAPIKey[%s] = %d bytes, want %d", label, got, bytes)
+		}
+	}
+	if len(usage.ByAPIKey) != len(want) {
+		t.Errorf("Unexpected key labels %v", usage.ByAPIKey)
+	}
+	if got := usage.ByCacheStatus["HIT"].Bytes; got != 500 {
+		t.Errorf("ByCacheStatus[HIT] = %d bytes", got)
+	}
+}
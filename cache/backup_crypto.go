@@ -0,0 +1,244 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Encrypted backups (see SetBackupKey) are the database file sealed with AES-256-GCM
+// in chunks, so neither side holds the whole file in memory:
+//
+//	magic | chunk...
+//	chunk = final flag (1) | nonce (12) | ciphertext length (4, big endian) | ciphertext
+//
+// Each chunk's additional data is the magic, its index and its final flag, so
+// reordered, dropped or truncated chunks fail to decrypt like tampered ones.
+// Backups keep their .db name; the magic tells the two formats apart.
+
+// encryptedBackupMagic starts every encrypted backup
+var encryptedBackupMagic = []byte("LYRBAK\x00\x01")
+
+// backupChunkSize is the plaintext size of a chunk (a var for tests)
+var backupChunkSize = 1 << 20
+
+// maxSealedChunk bounds the chunk length read from a backup, so a damaged length
+// can't make decryption allocate gigabytes
+const maxSealedChunk = 64 << 20
+
+// BackupKeySize is the size of a backup encryption key (AES-256)
+const BackupKeySize = 32
+
+// ErrBackupKeyMissing is returned when reading an encrypted backup without a key
+var ErrBackupKeyMissing = errors.New("backup is encrypted but no backup encryption key is configured")
+
+// ErrBackupDecrypt is returned for an encrypted backup that doesn't decrypt with the
+// configured key: another key, or a damaged or truncated file
+var ErrBackupDecrypt = errors.New("failed to decrypt backup (wrong key or damaged file)")
+
+// SetBackupKey enables encryption of new backups with an AES-256 key; nil disables
+// it. Call it before serving requests. Encrypted backups are decrypted on restore
+// and diff with the same key, whatever the setting when they were taken.
+func (pc *PersistentCache) SetBackupKey(key []byte) error {
+	if key == nil {
+		pc.backupAEAD = nil
+		return nil
+	}
+	if len(key) != BackupKeySize {
+		return fmt.Errorf("backup encryption key must be %d bytes, got %d", BackupKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	pc.backupAEAD = aead
+	return nil
+}
+
+// BackupEncryptionEnabled reports whether new backups are encrypted
+func (pc *PersistentCache) BackupEncryptionEnabled() bool {
+	return pc.backupAEAD != nil
+}
+
+// IsEncryptedBackup reports whether the file at path is an encrypted backup
+func IsEncryptedBackup(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	header := make([]byte, len(encryptedBackupMagic))
+	if _, err := io.ReadFull(f, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return bytes.Equal(header, encryptedBackupMagic), nil
+}
+
+// chunkAdditionalData binds a chunk to its position in the backup
+func chunkAdditionalData(index uint64, final bool) []byte {
+	ad := make([]byte, len(encryptedBackupMagic)+9)
+	copy(ad, encryptedBackupMagic)
+	binary.BigEndian.PutUint64(ad[len(encryptedBackupMagic):], index)
+	if final {
+		ad[len(ad)-1] = 1
+	}
+	return ad
+}
+
+// encryptBackup seals src into dst
+func encryptBackup(aead cipher.AEAD, src io.Reader, dst io.Writer) error {
+	if _, err := dst.Write(encryptedBackupMagic); err != nil {
+		return err
+	}
+	reader := bufio.NewReaderSize(src, backupChunkSize)
+	plaintext := make([]byte, backupChunkSize)
+	nonce := make([]byte, aead.NonceSize())
+	var header [17]byte
+	for index := uint64(0); ; index++ {
+		n, err := io.ReadFull(reader, plaintext)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		_, peekErr := reader.Peek(1)
+		final := errors.Is(peekErr, io.EOF)
+		if peekErr != nil && !final {
+			return peekErr
+		}
+
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		sealed := aead.Seal(nil, nonce, plaintext[:n], chunkAdditionalData(index, final))
+		header[0] = 0
+		if final {
+			header[0] = 1
+		}
+		copy(header[1:13], nonce)
+		binary.BigEndian.PutUint32(header[13:], uint32(len(sealed)))
+		if _, err := dst.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// decryptBackup opens an encrypted backup from src into dst
+func decryptBackup(aead cipher.AEAD, src io.Reader, dst io.Writer) error {
+	reader := bufio.NewReader(src)
+	magic := make([]byte, len(encryptedBackupMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || !bytes.Equal(magic, encryptedBackupMagic) {
+		return fmt.Errorf("%w: not an encrypted backup", ErrBackupDecrypt)
+	}
+	var header [17]byte
+	for index := uint64(0); ; index++ {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			return fmt.Errorf("%w: truncated at chunk %d", ErrBackupDecrypt, index)
+		}
+		final := header[0] == 1
+		length := int(binary.BigEndian.Uint32(header[13:]))
+		if length > maxSealedChunk {
+			return fmt.Errorf("%w: chunk %d too large", ErrBackupDecrypt, index)
+		}
+		sealed := make([]byte, length)
+		if _, err := io.ReadFull(reader, sealed); err != nil {
+			return fmt.Errorf("%w: truncated at chunk %d", ErrBackupDecrypt, index)
+		}
+		plaintext, err := aead.Open(nil, header[1:13], sealed, chunkAdditionalData(index, final))
+		if err != nil {
+			return fmt.Errorf("%w: chunk %d", ErrBackupDecrypt, index)
+		}
+		if _, err := dst.Write(plaintext); err != nil {
+			return err
+		}
+		if final {
+			if _, err := reader.Peek(1); !errors.Is(err, io.EOF) {
+				return fmt.Errorf("%w: data after the final chunk", ErrBackupDecrypt)
+			}
+			return nil
+		}
+	}
+}
+
+// writeBackupFile copies the database file at src to the backup dst, encrypted when
+// a backup key is set
+func (pc *PersistentCache) writeBackupFile(src, dst string) error {
+	if pc.backupAEAD == nil {
+		return copyFile(src, dst)
+	}
+	return transformFile(src, dst, func(r io.Reader, w io.Writer) error {
+		return encryptBackup(pc.backupAEAD, r, w)
+	})
+}
+
+// openBackupFile returns a plaintext path for the backup at path: the backup itself,
+// or a decrypted copy next to tmpBase that the returned cleanup removes
+func (pc *PersistentCache) openBackupFile(path, tmpBase string) (string, func(), error) {
+	encrypted, err := IsEncryptedBackup(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if !encrypted {
+		return path, func() {}, nil
+	}
+	if pc.backupAEAD == nil {
+		return "", nil, ErrBackupKeyMissing
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(tmpBase), filepath.Base(tmpBase)+".decrypted-*")
+	if err != nil {
+		return "", nil, err
+	}
+	tmp.Close()
+	cleanup := func() { os.Remove(tmp.Name()) }
+	err = transformFile(path, tmp.Name(), func(r io.Reader, w io.Writer) error {
+		return decryptBackup(pc.backupAEAD, r, w)
+	})
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+// transformFile writes src through fn to dst and syncs it
+func transformFile(src, dst string, fn func(io.Reader, io.Writer) error) error {
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	destFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer destFile.Close()
+
+	writer := bufio.NewWriter(destFile)
+	if err := fn(sourceFile, writer); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return destFile.Sync()
+}
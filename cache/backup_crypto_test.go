@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testBackupKey(fill byte) []byte {
+	return bytes.Repeat([]byte{fill}, BackupKeySize)
+}
+
+func testAEAD(t *testing.T, key []byte) cipher.AEAD {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aead
+}
+
+func TestEncryptBackup_RoundTrip(t *testing.T) {
+	original := backupChunkSize
+	backupChunkSize = 16
+	defer func() { backupChunkSize = original }()
+	aead := testAEAD(t, testBackupKey(1))
+
+	for _, size := range []int{0, 5, 16, 40} {
+		plaintext := bytes.Repeat([]byte("x"), size)
+		var sealed bytes.Buffer
+		if err := encryptBackup(aead, bytes.NewReader(plaintext), &sealed); err != nil {
+			t.Fatalf("size %d: encrypt: %v", size, err)
+		}
+		if size > 0 && bytes.Contains(sealed.Bytes(), plaintext) {
+			t.Errorf("size %d: plaintext visible in the backup", size)
+		}
+		var opened bytes.Buffer
+		if err := decryptBackup(aead, bytes.NewReader(sealed.Bytes()), &opened); err != nil {
+			t.Fatalf("size %d: decrypt: %v", size, err)
+		}
+		if !bytes.Equal(opened.Bytes(), plaintext) {
+			t.Errorf("size %d: round trip returned %d bytes", size, opened.Len())
+		}
+	}
+}
+
+func TestDecryptBackup_RejectsTampering(t *testing.T) {
+	original := backupChunkSize
+	backupChunkSize = 16
+	defer func() { backupChunkSize = original }()
+	aead := testAEAD(t, testBackupKey(1))
+
+	var sealed bytes.Buffer
+	if err := encryptBackup(aead, strings.NewReader(strings.Repeat("lyrics ", 10)), &sealed); err != nil {
+		t.Fatal(err)
+	}
+	data := sealed.Bytes()
+	firstChunk := len(encryptedBackupMagic) + 17 + 16 + aead.Overhead()
+
+	flipped := bytes.Clone(data)
+	flipped[len(flipped)-1] ^= 1
+	tests := map[string]struct {
+		data []byte
+		aead cipher.AEAD
+	}{
+		"wrong key":          {data, testAEAD(t, testBackupKey(2))},
+		"flipped bit":        {flipped, aead},
+		"truncated":          {data[:firstChunk], aead},
+		"trailing data":      {append(bytes.Clone(data), 0), aead},
+		"dropped chunk":      {append(bytes.Clone(data[:len(encryptedBackupMagic)]), data[firstChunk:]...), aead},
+		"not encrypted file": {[]byte("plain bolt file"), aead},
+	}
+	for name, tt := range tests {
+		var out bytes.Buffer
+		if err := decryptBackup(tt.aead, bytes.NewReader(tt.data), &out); !errors.Is(err, ErrBackupDecrypt) {
+			t.Errorf("%s: err = %v, want ErrBackupDecrypt", name, err)
+		}
+	}
+}
+
+func TestEncryptedBackup_RestoreAndDiff(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+	if err := cache.SetBackupKey(testBackupKey(7)); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Set("original_key", "original_value")
+	backupPath, err := cache.Backup()
+	if err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+	cache.WaitForPreload()
+	backupFileName := filepath.Base(backupPath)
+
+	raw, _ := os.ReadFile(backupPath)
+	if bytes.Contains(raw, []byte("original_value")) {
+		t.Error("Backup holds the value in plaintext")
+	}
+	backups, err := cache.ListBackups()
+	if err != nil || len(backups) != 1 || !backups[0].Encrypted {
+		t.Fatalf("ListBackups() = %+v, %v; want one encrypted backup", backups, err)
+	}
+
+	cache.Set("original_key", "modified_value")
+	diff, err := cache.DiffSnapshots(backupFileName, LiveSnapshot, 10)
+	if err != nil || diff.Changed != 1 {
+		t.Fatalf("DiffSnapshots() = %+v, %v", diff, err)
+	}
+
+	// Without the key the backup can't be read, and the live cache is left alone
+	cache.SetBackupKey(nil)
+	if err := cache.RestoreFromBackup(backupFileName); !errors.Is(err, ErrBackupKeyMissing) {
+		t.Fatalf("Restore without key: err = %v", err)
+	}
+	cache.SetBackupKey(testBackupKey(8))
+	if err := cache.RestoreFromBackup(backupFileName); !errors.Is(err, ErrBackupDecrypt) {
+		t.Fatalf("Restore with wrong key: err = %v", err)
+	}
+	if val, _ := cache.Get("original_key"); val != "modified_value" {
+		t.Fatalf("Failed restore changed the cache: %q", val)
+	}
+
+	cache.SetBackupKey(testBackupKey(7))
+	if err := cache.RestoreFromBackup(backupFileName); err != nil {
+		t.Fatalf("Failed to restore from backup: %v", err)
+	}
+	cache.WaitForPreload()
+	if val, _ := cache.Get("original_key"); val != "original_value" {
+		t.Errorf("Expected original_value after restore, got %q", val)
+	}
+
+	// No decrypted copies are left behind
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(backupPath), "*decrypted*"))
+	dbLeftovers, _ := filepath.Glob(cache.dbPath + ".decrypted*")
+	if len(leftovers)+len(dbLeftovers) != 0 {
+		t.Errorf("Decrypted copies left behind: %v %v", leftovers, dbLeftovers)
+	}
+}
+
+func TestSetBackupKey_InvalidSize(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+	if err := cache.SetBackupKey([]byte("short")); err == nil {
+		t.Error("Expected an error for a short key")
+	}
+	if cache.BackupEncryptionEnabled() {
+		t.Error("Invalid key enabled encryption")
+	}
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Namespaces in a Breakdown
+const (
+	NamespaceLyrics   = "lyrics"
+	NamespaceAlias    = "alias"
+	NamespaceNegative = "negative"
+	NamespaceOther    = "other"
+)
+
+// aliasProbeBytes is the largest stored entry decoded to check for an alias. Aliases
+// are a short JSON pointer, so bigger entries are lyrics and are never decompressed.
+const aliasProbeBytes = 1024
+
+// KeyStats is a key count and the bytes those keys take as stored (keys + values)
+type KeyStats struct {
+	Keys  int64 `json:"keys"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Breakdown splits the cache by namespace (lyrics, alias, negative, other) and by
+// originating provider. It is computed during ReconcileCounters, so it is as fresh
+// as the last reconcile rather than live like Counts.
+type Breakdown struct {
+	ByNamespace map[string]KeyStats `json:"by_namespace"`
+	ByProvider  map[string]KeyStats `json:"by_provider"`
+	ComputedAt  time.Time           `json:"computed_at"`
+}
+
+func newBreakdown() *Breakdown {
+	return &Breakdown{
+		ByNamespace: make(map[string]KeyStats),
+		ByProvider:  make(map[string]KeyStats),
+	}
+}
+
+// add counts one stored entry
+func (bd *Breakdown) add(key, raw []byte) {
+	size := int64(len(key) + len(raw))
+	namespace := namespaceOf(string(key), raw)
+	ns := bd.ByNamespace[namespace]
+	ns.Keys++
+	ns.Bytes += size
+	bd.ByNamespace[namespace] = ns
+
+	provider := providerOf(string(key))
+	p := bd.ByProvider[provider]
+	p.Keys++
+	p.Bytes += size
+	bd.ByProvider[provider] = p
+}
+
+// Breakdown returns the breakdown from the last ReconcileCounters, or nil before
+// the first one
+func (pc *PersistentCache) Breakdown() *Breakdown {
+	return pc.breakdown.Load()
+}
+
+// namespaceOf classifies a stored entry. Alias entries are small lyrics entries
+// whose value is a pointer ("aliasOf") to the key holding the TTML.
+func namespaceOf(key string, raw []byte) string {
+	switch {
+	case strings.HasPrefix(key, NegativePrefix):
+		return NamespaceNegative
+	case !strings.Contains(key, ":"):
+		return NamespaceOther
+	case len(raw) <= aliasProbeBytes && isAliasEntry(raw):
+		return NamespaceAlias
+	}
+	return NamespaceLyrics
+}
+
+// isAliasEntry decodes a small stored entry and reports whether it is an alias
+func isAliasEntry(raw []byte) bool {
+	var entry CacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return false
+	}
+	value, err := entry.Decode()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(value, `"aliasOf":"`)
+}
+
+// providerOf returns the provider a key belongs to: the part of its prefix before
+// the first underscore ("ttml_lyrics:" and "ttml_track:" are both ttml). Negative
+// keys count toward the provider they were looked up with.
+func providerOf(key string) string {
+	key = strings.TrimPrefix(key, NegativePrefix)
+	idx := strings.IndexByte(key, ':')
+	if idx <= 0 {
+		return "unknown"
+	}
+	prefix := key[:idx]
+	if u := strings.IndexByte(prefix, '_'); u > 0 {
+		prefix = prefix[:u]
+	}
+	return prefix
+}
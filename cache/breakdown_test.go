@@ -0,0 +1,42 @@
+package cache
+
+import "testing"
+
+func TestReconcileCounters_Breakdown(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, true)
+	defer cleanup()
+
+	if cache.Breakdown() != nil {
+		t.Fatal("Expected no breakdown before the first reconcile")
+	}
+
+	cache.Set("ttml_track:123", `{"ttml":"<tt>lyrics</tt>","trackDurationMs":1000}`)
+	cache.Set("ttml_lyrics:hello adele", `{"ttml":"","trackDurationMs":1000,"aliasOf":"ttml_track:123"}`)
+	cache.Set("kugou_lyrics:hello adele", `{"ttml":"<tt>kugou</tt>","trackDurationMs":1000}`)
+	cache.Set("no_lyrics:ttml_lyrics:missing", `{"reason":"not found","timestamp":1}`)
+	cache.Set("no_lyrics:kugou_lyrics:missing", `{"reason":"not found","timestamp":1}`)
+
+	if err := cache.ReconcileCounters(); err != nil {
+		t.Fatal(err)
+	}
+	bd := cache.Breakdown()
+	if bd == nil {
+		t.Fatal("Expected a breakdown after reconcile")
+	}
+
+	wantNamespaces := map[string]int64{NamespaceLyrics: 2, NamespaceAlias: 1, NamespaceNegative: 2}
+	for ns, want := range wantNamespaces {
+		if got := bd.ByNamespace[ns]; got.Keys != want || got.Bytes <= 0 {
+			t.Errorf("ByNamespace[%s] = %+v, want %d keys", ns, got, want)
+		}
+	}
+	wantProviders := map[string]int64{"ttml": 3, "kugou": 2}
+	for provider, want := range wantProviders {
+		if got := bd.ByProvider[provider]; got.Keys != want {
+			t.Errorf("ByProvider[%s] = %+v, want %d keys", provider, got, want)
+		}
+	}
+	if bd.ComputedAt.IsZero() {
+		t.Error("Expected ComputedAt to be set")
+	}
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Cache entries are split by class: lyrics (and aliases) in the cache bucket,
+// negative entries in NegativeBucket. Get, Set and Delete pick the bucket from the
+// key, and Range, RangeKeys and Stats cover both, so callers still see one cache
+// while clearing and counting can target a class without prefix-filtering.
+const (
+	// NegativeBucket holds negative cache entries ("no_lyrics:" keys)
+	NegativeBucket = "negative"
+
+	// MetaBucket holds cache-wide bookkeeping and incidental keys that aren't
+	// cache entries (migration versions, job checkpoints, old token keys)
+	MetaBucket = "meta"
+
+	// NegativePrefix starts every negative cache key
+	NegativePrefix = "no_lyrics:"
+)
+
+// entryBuckets are the buckets holding cache entries, in Range order
+var entryBuckets = []string{bucketName, NegativeBucket}
+
+// entryBucket returns the bucket a key is written to
+func entryBucket(key string) string {
+	if strings.HasPrefix(key, NegativePrefix) {
+		return NegativeBucket
+	}
+	return bucketName
+}
+
+// findEntry returns the bucket holding key and its stored bytes. Negative entries
+// written before the split stay in the cache bucket until the split migration
+// moves them, so they are looked up there too. When the key is missing, the
+// bucket it would be written to is returned with nil data.
+func findEntry(tx *bolt.Tx, key string) (*bolt.Bucket, []byte) {
+	b := tx.Bucket([]byte(entryBucket(key)))
+	if b != nil {
+		if data := b.Get([]byte(key)); data != nil {
+			return b, data
+		}
+	}
+	if legacy := legacyEntryBucket(tx, key); legacy != nil {
+		return legacy, legacy.Get([]byte(key))
+	}
+	return b, nil
+}
+
+// legacyEntryBucket returns the cache bucket if it still holds a negative key
+// from before the split
+func legacyEntryBucket(tx *bolt.Tx, key string) *bolt.Bucket {
+	if entryBucket(key) == bucketName {
+		return nil
+	}
+	b := tx.Bucket([]byte(bucketName))
+	if b == nil || b.Get([]byte(key)) == nil {
+		return nil
+	}
+	return b
+}
+
+// isLegacyKey reports whether a key in the cache bucket belongs elsewhere: a
+// negative entry from before the split, or an incidental key with no prefix
+func isLegacyKey(key []byte) bool {
+	return bytes.HasPrefix(key, []byte(NegativePrefix)) || bytes.IndexByte(key, ':') < 0
+}
+
+// legacyDestination returns the bucket a legacy key moves to
+func legacyDestination(key string) string {
+	if strings.HasPrefix(key, NegativePrefix) {
+		return NegativeBucket
+	}
+	return MetaBucket
+}
+
+// LegacyKeys returns the keys in the cache bucket that the split moves out:
+// negative entries and incidental keys without a prefix
+func (pc *PersistentCache) LegacyKeys() []string {
+	var keys []string
+	pc.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if isLegacyKey(k) {
+				keys = append(keys, string(k))
+			}
+		}
+		return nil
+	})
+	return keys
+}
+
+// MoveLegacyKeys moves the given keys out of the cache bucket in one transaction:
+// negative entries into NegativeBucket (bytes unchanged, so they stay readable),
+// incidental keys into MetaBucket. A key already present at the destination keeps
+// the newer copy there. Keys that aren't legacy or no longer exist are skipped.
+// Returns how many keys moved.
+func (pc *PersistentCache) MoveLegacyKeys(keys []string) (int, error) {
+	moved := 0
+	err := pc.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		counters := tx.Bucket([]byte(countersBucket))
+		if counters == nil {
+			return fmt.Errorf("counters bucket not found")
+		}
+		for _, key := range keys {
+			data := b.Get([]byte(key))
+			if data == nil || !isLegacyKey([]byte(key)) {
+				continue
+			}
+			dest := legacyDestination(key)
+			d, err := tx.CreateBucketIfNotExists([]byte(dest))
+			if err != nil {
+				return err
+			}
+			if d.Get([]byte(key)) == nil {
+				if err := d.Put([]byte(key), append([]byte(nil), data...)); err != nil {
+					return err
+				}
+			}
+			if err := b.Delete([]byte(key)); err != nil {
+				return err
+			}
+			// Negative entries are still cache entries; meta keys no longer count
+			if dest == MetaBucket {
+				if err := adjustCounter(counters, prefixOf(key), -1); err != nil {
+					return err
+				}
+			}
+			moved++
+		}
+		return nil
+	})
+	return moved, err
+}
+
+// ClearNegative removes every negative entry, including ones not yet moved out of
+// the cache bucket, and returns how many were removed. Lyrics are untouched.
+func (pc *PersistentCache) ClearNegative() (int, error) {
+	removed := 0
+	err := pc.db.Update(func(tx *bolt.Tx) error {
+		if n := tx.Bucket([]byte(NegativeBucket)); n != nil {
+			removed += n.Stats().KeyN
+			if err := tx.DeleteBucket([]byte(NegativeBucket)); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.CreateBucket([]byte(NegativeBucket)); err != nil {
+			return err
+		}
+
+		if b := tx.Bucket([]byte(bucketName)); b != nil {
+			c := b.Cursor()
+			prefix := []byte(NegativePrefix)
+			for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+				removed++
+			}
+		}
+
+		counters := tx.Bucket([]byte(countersBucket))
+		if counters == nil {
+			return fmt.Errorf("counters bucket not found")
+		}
+		return counters.Delete([]byte(prefixOf(NegativePrefix)))
+	})
+	return removed, err
+}
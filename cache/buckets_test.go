@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// putLegacy writes a valid entry straight into the cache bucket, where keys of
+// every class lived before the split
+func putLegacy(t *testing.T, cache *PersistentCache, key, value string) {
+	t.Helper()
+	data, err := json.Marshal(CacheEntry{Value: value, Checksum: entryChecksum(value)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	putRaw(t, cache, key, data)
+	cache.ReconcileCounters()
+}
+
+func TestNegativeEntries_OwnBucket(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	cache.Set("ttml_lyrics:song", "lyrics")
+	cache.Set("no_lyrics:ttml_lyrics:missing", "negative")
+
+	if _, ok := cache.GetFromBucket(NegativeBucket, "no_lyrics:ttml_lyrics:missing"); !ok {
+		t.Error("Negative entry should be stored in the negative bucket")
+	}
+	if _, ok := cache.GetFromBucket(bucketName, "no_lyrics:ttml_lyrics:missing"); ok {
+		t.Error("Negative entry should not be in the cache bucket")
+	}
+	if numKeys, _ := cache.Stats(); numKeys != 2 {
+		t.Errorf("Stats should count both buckets, got %d", numKeys)
+	}
+	var keys []string
+	cache.RangeKeys("", func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 2 {
+		t.Errorf("RangeKeys should cover both buckets, got %v", keys)
+	}
+
+	removed, err := cache.ClearNegative()
+	if err != nil || removed != 1 {
+		t.Fatalf("ClearNegative = %d, %v; want 1", removed, err)
+	}
+	if _, ok := cache.Get("ttml_lyrics:song"); !ok {
+		t.Error("ClearNegative must leave lyrics alone")
+	}
+	if counts := cache.Counts(); counts["negative"] != 0 || counts["ttml"] != 1 {
+		t.Errorf("Unexpected counts after ClearNegative: %v", counts)
+	}
+}
+
+func TestLegacyKeys_FoundUntilMoved(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	cache.Set("ttml_lyrics:song", "lyrics")
+	putLegacy(t, cache, "no_lyrics:ttml_lyrics:old", "old negative")
+	putLegacy(t, cache, "accessToken", "token")
+
+	// Before the migration, old negative entries are still served
+	if v, ok := cache.Get("no_lyrics:ttml_lyrics:old"); !ok || v != "old negative" {
+		t.Fatalf("Expected legacy negative entry to be found, got %q (%v)", v, ok)
+	}
+
+	keys := cache.LegacyKeys()
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 legacy keys, got %v", keys)
+	}
+	moved, err := cache.MoveLegacyKeys(append(keys, "ttml_lyrics:song"))
+	if err != nil || moved != 2 {
+		t.Fatalf("MoveLegacyKeys = %d, %v; want 2", moved, err)
+	}
+
+	if v, ok := cache.Get("no_lyrics:ttml_lyrics:old"); !ok || v != "old negative" {
+		t.Errorf("Moved negative entry should still be readable, got %q (%v)", v, ok)
+	}
+	if _, ok := cache.GetFromBucket(MetaBucket, "accessToken"); !ok {
+		t.Error("Unprefixed key should be in the meta bucket")
+	}
+	if _, ok := cache.Get("ttml_lyrics:song"); !ok {
+		t.Error("Lyrics must not be moved")
+	}
+	if len(cache.LegacyKeys()) != 0 {
+		t.Error("No legacy keys should remain")
+	}
+	if counts := cache.Counts(); counts["negative"] != 1 || counts["unknown"] != 0 || counts["ttml"] != 1 {
+		t.Errorf("Unexpected counts after move: %v", counts)
+	}
+}
+
+func TestSet_RewritesLegacyNegativeInNewBucket(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	putLegacy(t, cache, "no_lyrics:ttml_lyrics:old", "old")
+	if err := cache.Set("no_lyrics:ttml_lyrics:old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.GetFromBucket(bucketName, "no_lyrics:ttml_lyrics:old"); ok {
+		t.Error("Rewriting a legacy negative entry should remove it from the cache bucket")
+	}
+	if v, _ := cache.Get("no_lyrics:ttml_lyrics:old"); v != "new" {
+		t.Errorf("Expected the new value, got %q", v)
+	}
+	if got := cache.Counts()["negative"]; got != 1 {
+		t.Errorf("Expected negative=1, got %d", got)
+	}
+
+	if err := cache.Delete("no_lyrics:ttml_lyrics:old"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("no_lyrics:ttml_lyrics:old"); ok {
+		t.Error("Expected entry deleted")
+	}
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// LiveSnapshot names the live database as a diff source
+const LiveSnapshot = "live"
+
+// SnapshotDiff reports how the cache entries (lyrics and negative) differ between
+// two snapshots.
+// Counts are exact; the key lists are capped at the requested sample limit.
+type SnapshotDiff struct {
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	FromKeys    int      `json:"fromKeys"`
+	ToKeys      int      `json:"toKeys"`
+	Added       int      `json:"added"`
+	Removed     int      `json:"removed"`
+	Changed     int      `json:"changed"`
+	Unchanged   int      `json:"unchanged"`
+	AddedKeys   []string `json:"addedKeys"`
+	RemovedKeys []string `json:"removedKeys"`
+	ChangedKeys []string `json:"changedKeys"`
+	Truncated   bool     `json:"truncated"`
+}
+
+// DiffSnapshots compares the entry buckets of two snapshots. Each side is a backup
+// file name from ListBackups or LiveSnapshot. Added keys exist only in "to",
+// removed keys only in "from"; so diffing a backup (from) against live (to) shows
+// what restoring that backup would lose (added) and bring back (removed).
+//
+// The live side is read inside a single bolt read transaction, which is a
+// copy-on-write snapshot: concurrent writes don't affect the result. Each pair of
+// buckets is walked in key order with cursors, so memory stays flat on large
+// databases.
+func (pc *PersistentCache) DiffSnapshots(from, to string, sampleLimit int) (*SnapshotDiff, error) {
+	diff := &SnapshotDiff{
+		From:        from,
+		To:          to,
+		AddedKeys:   []string{},
+		RemovedKeys: []string{},
+		ChangedKeys: []string{},
+	}
+
+	err := pc.viewSnapshot(from, func(fromTx *bolt.Tx) error {
+		return pc.viewSnapshot(to, func(toTx *bolt.Tx) error {
+			for _, name := range entryBuckets {
+				walkBucketDiff(fromTx.Bucket([]byte(name)), toTx.Bucket([]byte(name)), diff, sampleLimit)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// viewSnapshot runs fn in a read transaction on the named snapshot. Backups are
+// opened read-only so a diff never modifies or locks them for writing; encrypted
+// ones are decrypted to a temporary copy first.
+func (pc *PersistentCache) viewSnapshot(name string, fn func(tx *bolt.Tx) error) error {
+	db := pc.db
+	if name != LiveSnapshot {
+		path, err := pc.resolveBackupPath(name)
+		if err != nil {
+			return err
+		}
+		path, cleanup, err := pc.openBackupFile(path, path)
+		if err != nil {
+			return fmt.Errorf("failed to read backup %s: %w", name, err)
+		}
+		defer cleanup()
+		db, err = bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: 5 * time.Second})
+		if err != nil {
+			return fmt.Errorf("failed to open backup %s: %v", name, err)
+		}
+		defer db.Close()
+	}
+
+	return db.View(fn)
+}
+
+// walkBucketDiff merges two key-ordered cursors. A nil bucket counts as empty.
+func walkBucketDiff(fromBucket, toBucket *bolt.Bucket, diff *SnapshotDiff, sampleLimit int) {
+	var fc, tc *bolt.Cursor
+	var fk, fv, tk, tv []byte
+	if fromBucket != nil {
+		fc = fromBucket.Cursor()
+		fk, fv = fc.First()
+	}
+	if toBucket != nil {
+		tc = toBucket.Cursor()
+		tk, tv = tc.First()
+	}
+
+	sample := func(keys *[]string, key []byte) {
+		if len(*keys) < sampleLimit {
+			*keys = append(*keys, string(key))
+		} else {
+			diff.Truncated = true
+		}
+	}
+
+	for fk != nil || tk != nil {
+		cmp := 0
+		switch {
+		case fk == nil:
+			cmp = 1
+		case tk == nil:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(fk, tk)
+		}
+
+		switch {
+		case cmp < 0:
+			diff.Removed++
+			diff.FromKeys++
+			sample(&diff.RemovedKeys, fk)
+			fk, fv = fc.Next()
+		case cmp > 0:
+			diff.Added++
+			diff.ToKeys++
+			sample(&diff.AddedKeys, tk)
+			tk, tv = tc.Next()
+		default:
+			diff.FromKeys++
+			diff.ToKeys++
+			if sameEntryContent(fv, tv) {
+				diff.Unchanged++
+			} else {
+				diff.Changed++
+				sample(&diff.ChangedKeys, fk)
+			}
+			fk, fv = fc.Next()
+			tk, tv = tc.Next()
+		}
+	}
+}
+
+// sameEntryContent compares two stored entries. Raw bytes are checked first; when
+// they differ the values are decoded so a compression toggle alone is not a change.
+func sameEntryContent(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var ea, eb CacheEntry
+	if json.Unmarshal(a, &ea) != nil || json.Unmarshal(b, &eb) != nil {
+		return false
+	}
+	return decodedValue(ea) == decodedValue(eb)
+}
+
+// decodedValue returns the entry's decoded value, or the value as stored if it
+// doesn't decode
+func decodedValue(entry CacheEntry) string {
+	if value, err := entry.Decode(); err == nil {
+		return value
+	}
+	return entry.Value
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"lyrics-api-go/utils"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffSnapshots_BackupVsLive(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	cache.Set("kept", "same")
+	cache.Set("changed", "before")
+	cache.Set("removed", "gone soon")
+	cache.Set("no_lyrics:kept", "not found")
+
+	backupPath, err := cache.Backup()
+	if err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+	cache.WaitForPreload()
+	backupFileName := filepath.Base(backupPath)
+
+	cache.Set("changed", "after")
+	cache.Delete("removed")
+	cache.Set("added_1", "new")
+	cache.Set("no_lyrics:added", "not found")
+
+	diff, err := cache.DiffSnapshots(backupFileName, LiveSnapshot, 1)
+	if err != nil {
+		t.Fatalf("DiffSnapshots failed: %v", err)
+	}
+
+	// Negative entries live in their own bucket and are diffed too
+	if diff.FromKeys != 4 || diff.ToKeys != 5 {
+		t.Errorf("Key counts = %d/%d, want 4/5", diff.FromKeys, diff.ToKeys)
+	}
+	if diff.Added != 2 || diff.Removed != 1 || diff.Changed != 1 || diff.Unchanged != 2 {
+		t.Errorf("Unexpected diff counts: %+v", diff)
+	}
+	if len(diff.AddedKeys) != 1 || !diff.Truncated {
+		t.Errorf("Expected added keys capped at 1 with truncated flag, got %v (truncated=%v)", diff.AddedKeys, diff.Truncated)
+	}
+	if len(diff.RemovedKeys) != 1 || diff.RemovedKeys[0] != "removed" {
+		t.Errorf("RemovedKeys = %v, want [removed]", diff.RemovedKeys)
+	}
+	if len(diff.ChangedKeys) != 1 || diff.ChangedKeys[0] != "changed" {
+		t.Errorf("ChangedKeys = %v, want [changed]", diff.ChangedKeys)
+	}
+
+	// Diffing a backup against itself reports nothing
+	same, err := cache.DiffSnapshots(backupFileName, backupFileName, 10)
+	if err != nil {
+		t.Fatalf("DiffSnapshots failed: %v", err)
+	}
+	if same.Added+same.Removed+same.Changed != 0 || same.Unchanged != 4 {
+		t.Errorf("Expected identical snapshots, got %+v", same)
+	}
+}
+
+func TestDiffSnapshots_IgnoresCompressionToggle(t *testing.T) {
+	if !sameEntryContent([]byte(`{"value":"hello"}`), []byte(`{"value":"hello"}`)) {
+		t.Error("Identical entries should match")
+	}
+
+	compressed, err := utils.CompressString("hello")
+	if err != nil {
+		t.Fatalf("CompressString failed: %v", err)
+	}
+	if !sameEntryContent([]byte(`{"value":"hello"}`), []byte(`{"value":"`+compressed+`"}`)) {
+		t.Error("Compressed and plain entries with the same content should match")
+	}
+	if sameEntryContent([]byte(`{"value":"hello"}`), []byte(`{"value":"world"}`)) {
+		t.Error("Different values should not match")
+	}
+}
+
+func TestDiffSnapshots_InvalidBackup(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	for _, name := range []string{"missing.db", "../escape.db", "not-a-db.txt"} {
+		if _, err := cache.DiffSnapshots(name, LiveSnapshot, 10); err == nil {
+			t.Errorf("Expected error for backup %q", name)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	bbolterrors "go.etcd.io/bbolt/errors"
+)
+
+// LockWait is how opening the database waits for its file lock. Without a timeout
+// bolt.Open blocks forever while another process holds the file, so a second
+// instance (or a tool with cache.db open) would hang startup without a log line.
+type LockWait struct {
+	Timeout  time.Duration // Per attempt
+	Attempts int
+	Backoff  time.Duration // Before the second attempt, doubled after each one
+
+	// OnContention is called after each attempt that timed out on the lock
+	OnContention func(path string, attempt, attempts int)
+}
+
+var (
+	lockWaitMu sync.RWMutex
+	lockWait   = LockWait{Timeout: 10 * time.Second, Attempts: 3, Backoff: 2 * time.Second}
+)
+
+// SetLockWait configures how NewPersistentCache and restores wait for the file lock
+func SetLockWait(wait LockWait) {
+	lockWaitMu.Lock()
+	defer lockWaitMu.Unlock()
+	lockWait = wait
+}
+
+// ErrDatabaseLocked is returned when the file lock is still held after every attempt
+var ErrDatabaseLocked = errors.New("database file is locked by another process")
+
+// openDB opens a bolt database, retrying with backoff while another process holds
+// its lock. Other errors are returned right away.
+func openDB(path string) (*bolt.DB, error) {
+	lockWaitMu.RLock()
+	wait := lockWait
+	lockWaitMu.RUnlock()
+
+	attempts := max(wait.Attempts, 1)
+	backoff := wait.Backoff
+	for attempt := 1; ; attempt++ {
+		db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: wait.Timeout})
+		if err == nil {
+			if attempt > 1 {
+				cacheLog.Infof("%s Got the lock on %s after %d attempts", logcolors.LogCacheInit, path, attempt)
+			}
+			return db, nil
+		}
+		if !errors.Is(err, bbolterrors.ErrTimeout) {
+			return nil, err
+		}
+
+		cacheLog.Warnf("%s %s is locked by another process (attempt %d/%d, waited %v). Is another instance still running or a tool holding the file open?",
+			logcolors.LogCacheInit, path, attempt, attempts, wait.Timeout)
+		if wait.OnContention != nil {
+			wait.OnContention(path, attempt, attempts)
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("%w: %s (gave up after %d attempts of %v; stop the other instance or tool using it, or point CACHE_DB_PATH elsewhere)",
+				ErrDatabaseLocked, path, attempts, wait.Timeout)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenDB_LockContention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locked.db")
+	holder, err := openDB(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	var contentions []int
+	SetLockWait(LockWait{
+		Timeout:  20 * time.Millisecond,
+		Attempts: 2,
+		Backoff:  10 * time.Millisecond,
+		OnContention: func(p string, attempt, attempts int) {
+			if p != path || attempts != 2 {
+				t.Errorf("Unexpected contention callback: %s %d/%d", p, attempt, attempts)
+			}
+			contentions = append(contentions, attempt)
+		},
+	})
+	defer SetLockWait(LockWait{Timeout: 10 * time.Second, Attempts: 3, Backoff: 2 * time.Second})
+
+	if _, err := openDB(path); !errors.Is(err, ErrDatabaseLocked) {
+		t.Fatalf("Expected ErrDatabaseLocked, got %v", err)
+	}
+	if len(contentions) != 2 {
+		t.Errorf("Expected 2 contention callbacks, got %v", contentions)
+	}
+
+	// Once the holder closes, the next open gets the lock
+	holder.Close()
+	db, err := openDB(path)
+	if err != nil {
+		t.Fatalf("Expected open to succeed after the lock was released, got %v", err)
+	}
+	db.Close()
+}
@@ -1,25 +1,36 @@
 package cache
 
 import (
+	"bytes"
+	"crypto/cipher"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/logging"
 	"lyrics-api-go/utils"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	log "github.com/sirupsen/logrus"
 	bolt "go.etcd.io/bbolt"
 	bbolterrors "go.etcd.io/bbolt/errors"
 )
 
+// cacheLog is tunable separately via PUT /log-level?component=cache
+var cacheLog = logging.For(logging.ComponentCache)
+
 const bucketName = "cache"
 const countersBucket = "counters"
 
+// QuarantineBucket holds corrupt entries moved out of the cache for inspection
+const QuarantineBucket = "quarantine"
+
 // PersistentCache wraps BoltDB for persistent storage
 // Note: No in-memory cache layer - BoltDB uses mmap so OS handles caching
 type PersistentCache struct {
@@ -27,11 +38,74 @@ type PersistentCache struct {
 	dbPath             string
 	backupPath         string
 	compressionEnabled bool
+
+	corruptEntries atomic.Int64
+	onCorruption   atomic.Pointer[func(key, reason string)]
+
+	// Read-only mode (see readonly.go)
+	readOnly      atomic.Bool
+	skippedWrites atomic.Int64
+
+	// Namespace/provider sizes from the last reconcile (see breakdown.go)
+	breakdown atomic.Pointer[Breakdown]
+
+	// Encryption of new backups, nil when off (see backup_crypto.go)
+	backupAEAD cipher.AEAD
 }
 
+// Value encodings, recorded in each entry so reads never depend on the current
+// compression setting: toggling FF_CACHE_COMPRESSION only changes new writes
+const (
+	CodecNone = "none" // Value stored as-is
+	CodecGzip = "gzip" // Base64 gzip (utils.CompressString)
+)
+
+// ErrUnknownCodec is returned for entries written with a codec this build doesn't
+// know (by a newer version, after a rollback); they are misses, not corruption
+var ErrUnknownCodec = errors.New("unknown cache entry codec")
+
 // CacheEntry represents a cached value (can be compressed)
 type CacheEntry struct {
-	Value string `json:"value"`
+	Value    string `json:"value"`
+	Checksum string `json:"crc,omitempty"`   // CRC-32C of Value as stored; empty on entries written before checksums
+	Codec    string `json:"codec,omitempty"` // How Value is encoded; empty on entries written before codecs were recorded
+}
+
+// ValueCodec returns how the entry's value is encoded. Entries without a codec are
+// sniffed: base64 gzip always starts with "H4sI", which TTML and JSON never do.
+func (e CacheEntry) ValueCodec() string {
+	if e.Codec != "" {
+		return e.Codec
+	}
+	if IsCompressed(e.Value) {
+		return CodecGzip
+	}
+	return CodecNone
+}
+
+// Decode returns the value as it was passed to Set
+func (e CacheEntry) Decode() (string, error) {
+	switch codec := e.ValueCodec(); codec {
+	case CodecNone:
+		return e.Value, nil
+	case CodecGzip:
+		return utils.DecompressString(e.Value)
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnknownCodec, codec)
+	}
+}
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// entryChecksum returns the checksum stored alongside a value
+func entryChecksum(value string) string {
+	return fmt.Sprintf("%08x", crc32.Checksum([]byte(value), crcTable))
+}
+
+// Verify reports whether the entry's value matches its checksum. Entries without a
+// checksum (written before checksums existed) can't be checked and pass.
+func (e CacheEntry) Verify() bool {
+	return e.Checksum == "" || e.Checksum == entryChecksum(e.Value)
 }
 
 // NewPersistentCache creates a new persistent cache
@@ -41,9 +115,9 @@ func NewPersistentCache(dbPath string, backupPath string, compressionEnabled boo
 
 	// Check if directory exists
 	if info, err := os.Stat(dir); err == nil {
-		log.Infof("%s Directory %s exists (IsDir: %v)", logcolors.LogCacheInit, dir, info.IsDir())
+		cacheLog.Infof("%s Directory %s exists (IsDir: %v)", logcolors.LogCacheInit, dir, info.IsDir())
 	} else {
-		log.Infof("%s Directory %s does not exist, creating...", logcolors.LogCacheInit, dir)
+		cacheLog.Infof("%s Directory %s does not exist, creating...", logcolors.LogCacheInit, dir)
 	}
 
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -54,24 +128,28 @@ func NewPersistentCache(dbPath string, backupPath string, compressionEnabled boo
 	if err := os.MkdirAll(backupPath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create backup directory: %v", err)
 	}
-	log.Infof("%s Backup directory set to: %s", logcolors.LogCacheInit, backupPath)
+	cacheLog.Infof("%s Backup directory set to: %s", logcolors.LogCacheInit, backupPath)
 
 	// Check if database file already exists
 	if info, err := os.Stat(dbPath); err == nil {
-		log.Infof("%s Found existing database file at: %s (size: %d bytes)", logcolors.LogCacheInit, dbPath, info.Size())
+		cacheLog.Infof("%s Found existing database file at: %s (size: %d bytes)", logcolors.LogCacheInit, dbPath, info.Size())
 	} else {
-		log.Infof("%s Creating new database file at: %s", logcolors.LogCacheInit, dbPath)
+		cacheLog.Infof("%s Creating new database file at: %s", logcolors.LogCacheInit, dbPath)
 	}
 
-	db, err := bolt.Open(dbPath, 0600, nil)
+	db, err := openDB(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open cache database: %v", err)
+		return nil, fmt.Errorf("failed to open cache database: %w", err)
 	}
 
-	// Create bucket if it doesn't exist
+	// Create entry buckets if they don't exist
 	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(bucketName))
-		return err
+		for _, name := range entryBuckets {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		db.Close()
@@ -94,7 +172,7 @@ func NewPersistentCache(dbPath string, backupPath string, compressionEnabled boo
 		compressionEnabled: compressionEnabled,
 	}
 
-	log.Infof("%s Persistent cache initialized at %s (compression: %v)", logcolors.LogCache, dbPath, compressionEnabled)
+	cacheLog.Infof("%s Persistent cache initialized at %s (compression: %v)", logcolors.LogCache, dbPath, compressionEnabled)
 	return pc, nil
 }
 
@@ -110,70 +188,152 @@ func (pc *PersistentCache) WaitForPreload() {
 	// No-op: nothing to wait for
 }
 
-// Get retrieves a value from cache
-// Returns decompressed value if compression is enabled
+// Get retrieves a value from cache, decoded according to the entry's codec whatever
+// the current compression setting. A corrupt entry (checksum mismatch, undecodable
+// JSON or a gzip blob that won't decompress) is deleted and reported as a miss; see
+// SetCorruptionHandler.
 func (pc *PersistentCache) Get(key string) (string, bool) {
-	var value string
+	var entry CacheEntry
+	var corruption string
 	err := pc.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucketName))
+		b, data := findEntry(tx, key)
 		if b == nil {
 			return fmt.Errorf("bucket not found")
 		}
-
-		data := b.Get([]byte(key))
 		if data == nil {
 			return fmt.Errorf("key not found")
 		}
+		entry, corruption = parseEntry(data)
+		return nil
+	})
+	if err != nil {
+		return "", false
+	}
+	return pc.decodeEntry(key, entry, corruption)
+}
 
-		var entry CacheEntry
-		if err := json.Unmarshal(data, &entry); err != nil {
-			return err
+// GetMany looks up several keys in one read transaction and returns the values
+// found, decoded like Get. Corrupt entries are handled (and left out) as in Get.
+func (pc *PersistentCache) GetMany(keys []string) map[string]string {
+	entries := make(map[string]CacheEntry, len(keys))
+	corrupt := make(map[string]string)
+	pc.db.View(func(tx *bolt.Tx) error {
+		for _, key := range keys {
+			if _, data := findEntry(tx, key); data != nil {
+				entries[key], corrupt[key] = parseEntry(data)
+			}
 		}
-
-		value = entry.Value
 		return nil
 	})
 
-	if err != nil {
-		return "", false
+	values := make(map[string]string, len(entries))
+	for key, entry := range entries {
+		if value, ok := pc.decodeEntry(key, entry, corrupt[key]); ok {
+			values[key] = value
+		}
 	}
+	return values
+}
 
-	// Decompress if needed
-	if pc.compressionEnabled {
-		decompressed, err := utils.DecompressString(value)
-		if err != nil {
-			log.Errorf("%s Error decompressing cache value for key %s: %v", logcolors.LogCache, key, err)
-			return "", false
+// GetEntry returns a key's entry as stored, with the value still encoded. Unlike
+// Get it doesn't act on corruption; ok is false when the key is missing or its
+// entry doesn't parse.
+func (pc *PersistentCache) GetEntry(key string) (entry CacheEntry, ok bool) {
+	pc.db.View(func(tx *bolt.Tx) error {
+		if _, data := findEntry(tx, key); data != nil {
+			ok = json.Unmarshal(data, &entry) == nil
 		}
-		return decompressed, true
+		return nil
+	})
+	return entry, ok
+}
+
+// parseEntry decodes a stored entry and checks its checksum. corruption names the
+// problem when the entry can't be used.
+func parseEntry(data []byte) (entry CacheEntry, corruption string) {
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, "invalid entry JSON"
+	}
+	if !entry.Verify() {
+		return entry, "checksum mismatch"
 	}
+	return entry, ""
+}
 
+// decodeEntry returns the value of an entry read by parseEntry. Must be called
+// outside a transaction: corrupt entries are deleted.
+func (pc *PersistentCache) decodeEntry(key string, entry CacheEntry, corruption string) (string, bool) {
+	if corruption != "" {
+		pc.handleCorruption(key, corruption)
+		return "", false
+	}
+	value, err := entry.Decode()
+	if err != nil {
+		if errors.Is(err, ErrUnknownCodec) {
+			cacheLog.Warnf("%s Cannot read cache value for key %s: %v", logcolors.LogCache, key, err)
+		} else {
+			pc.handleCorruption(key, fmt.Sprintf("decompress failed: %v", err))
+		}
+		return "", false
+	}
 	return value, true
 }
 
+// IsCompressed reports whether a stored value is a base64 gzip blob
+func IsCompressed(value string) bool {
+	return strings.HasPrefix(value, "H4sI")
+}
+
+// SetCorruptionHandler registers fn to be called (after the entry is deleted) for
+// every corrupt entry Get finds
+func (pc *PersistentCache) SetCorruptionHandler(fn func(key, reason string)) {
+	pc.onCorruption.Store(&fn)
+}
+
+// CorruptEntries returns how many corrupt entries have been found and deleted
+func (pc *PersistentCache) CorruptEntries() int64 {
+	return pc.corruptEntries.Load()
+}
+
+// handleCorruption drops a corrupt entry so the next request refetches it
+func (pc *PersistentCache) handleCorruption(key, reason string) {
+	cacheLog.Warnf("%s Corrupt cache entry %s (%s), deleting", logcolors.LogCache, key, reason)
+	pc.corruptEntries.Add(1)
+	if err := pc.Delete(key); err != nil {
+		cacheLog.Errorf("%s Failed to delete corrupt entry %s: %v", logcolors.LogCache, key, err)
+	}
+	if fn := pc.onCorruption.Load(); fn != nil {
+		(*fn)(key, reason)
+	}
+}
+
 // Set stores a value in cache
-// Compresses value with BestCompression if compression is enabled
+// Compresses value with BestCompression if compression is enabled, and records
+// the codec used so the entry stays readable if the setting changes
 func (pc *PersistentCache) Set(key, value string) error {
-	var finalValue string
-	var err error
+	if pc.refuseWrite() {
+		return ErrReadOnly
+	}
+	finalValue, codec := value, CodecNone
 
 	// Compress if enabled (uses BestCompression level)
 	if pc.compressionEnabled {
-		finalValue, err = utils.CompressString(value)
+		compressed, err := utils.CompressString(value)
 		if err != nil {
-			log.Errorf("%s Error compressing cache value for key %s: %v", logcolors.LogCache, key, err)
+			cacheLog.Errorf("%s Error compressing cache value for key %s: %v", logcolors.LogCache, key, err)
 			return err
 		}
-	} else {
-		finalValue = value
+		finalValue, codec = compressed, CodecGzip
 	}
 
 	entry := CacheEntry{
-		Value: finalValue,
+		Value:    finalValue,
+		Checksum: entryChecksum(finalValue),
+		Codec:    codec,
 	}
 
 	return pc.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucketName))
+		b := tx.Bucket([]byte(entryBucket(key)))
 		if b == nil {
 			return fmt.Errorf("bucket not found")
 		}
@@ -188,6 +348,13 @@ func (pc *PersistentCache) Set(key, value string) error {
 		}
 
 		isNew := b.Get([]byte(key)) == nil
+		// Rewriting a negative entry from before the split moves it
+		if legacy := legacyEntryBucket(tx, key); legacy != nil {
+			if err := legacy.Delete([]byte(key)); err != nil {
+				return err
+			}
+			isNew = false
+		}
 		if err := b.Put([]byte(key), data); err != nil {
 			return err
 		}
@@ -201,7 +368,7 @@ func (pc *PersistentCache) Set(key, value string) error {
 // Delete removes a key from cache
 func (pc *PersistentCache) Delete(key string) error {
 	return pc.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucketName))
+		b, data := findEntry(tx, key)
 		if b == nil {
 			return fmt.Errorf("bucket not found")
 		}
@@ -210,7 +377,7 @@ func (pc *PersistentCache) Delete(key string) error {
 			return fmt.Errorf("counters bucket not found")
 		}
 
-		existed := b.Get([]byte(key)) != nil
+		existed := data != nil
 		if err := b.Delete([]byte(key)); err != nil {
 			return err
 		}
@@ -221,15 +388,17 @@ func (pc *PersistentCache) Delete(key string) error {
 	})
 }
 
-// Clear removes all entries from cache and resets per-prefix counters in the
-// same transaction so counts stay consistent with the wiped cache bucket.
+// Clear removes all entries (lyrics and negative) from cache and resets per-prefix
+// counters in the same transaction so counts stay consistent with the wiped buckets.
 func (pc *PersistentCache) Clear() error {
 	return pc.db.Update(func(tx *bolt.Tx) error {
-		if err := tx.DeleteBucket([]byte(bucketName)); err != nil {
-			return err
-		}
-		if _, err := tx.CreateBucket([]byte(bucketName)); err != nil {
-			return err
+		for _, name := range entryBuckets {
+			if err := tx.DeleteBucket([]byte(name)); err != nil && err != bbolterrors.ErrBucketNotFound {
+				return err
+			}
+			if _, err := tx.CreateBucket([]byte(name)); err != nil {
+				return err
+			}
 		}
 		if err := tx.DeleteBucket([]byte(countersBucket)); err != nil && err != bbolterrors.ErrBucketNotFound {
 			return err
@@ -241,43 +410,117 @@ func (pc *PersistentCache) Clear() error {
 	})
 }
 
-// Range iterates over all cache entries
+// Range iterates over all cache entries, lyrics first, then negative entries
 func (pc *PersistentCache) Range(fn func(key string, entry CacheEntry) bool) {
-	pc.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucketName))
+	pc.RangeRaw(func(key string, raw []byte) bool {
+		var entry CacheEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return true // Skip invalid entries
+		}
+		return fn(key, entry)
+	})
+}
+
+// RangeRaw iterates over all cache entries exactly as stored, including ones Range
+// skips because they don't decode. raw is only valid within the callback.
+func (pc *PersistentCache) RangeRaw(fn func(key string, raw []byte) bool) {
+	stopped := false
+	for _, name := range entryBuckets {
+		pc.RangeBucket(name, func(k, v []byte) bool {
+			if !fn(string(k), v) {
+				stopped = true
+			}
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+// QuarantinedEntry is a corrupt cache entry kept in QuarantineBucket
+type QuarantinedEntry struct {
+	Raw           []byte `json:"raw"` // Stored bytes exactly as found
+	Reason        string `json:"reason"`
+	QuarantinedAt int64  `json:"quarantined_at"`
+}
+
+// Quarantine moves an entry from the cache bucket into QuarantineBucket (under the
+// same key) in one transaction, so it stops being served but can still be inspected.
+func (pc *PersistentCache) Quarantine(key, reason string) error {
+	return pc.db.Update(func(tx *bolt.Tx) error {
+		b, data := findEntry(tx, key)
 		if b == nil {
-			return nil
+			return fmt.Errorf("bucket not found")
+		}
+		counters := tx.Bucket([]byte(countersBucket))
+		if counters == nil {
+			return fmt.Errorf("counters bucket not found")
+		}
+		if data == nil {
+			return fmt.Errorf("key not found")
 		}
 
-		return b.ForEach(func(k, v []byte) error {
-			var entry CacheEntry
-			if err := json.Unmarshal(v, &entry); err != nil {
-				return nil // Skip invalid entries
+		record, err := json.Marshal(QuarantinedEntry{
+			Raw:           data,
+			Reason:        reason,
+			QuarantinedAt: time.Now().Unix(),
+		})
+		if err != nil {
+			return err
+		}
+		q, err := tx.CreateBucketIfNotExists([]byte(QuarantineBucket))
+		if err != nil {
+			return err
+		}
+		if err := q.Put([]byte(key), record); err != nil {
+			return err
+		}
+		if err := b.Delete([]byte(key)); err != nil {
+			return err
+		}
+		return adjustCounter(counters, prefixOf(key), -1)
+	})
+}
+
+// RangeKeys iterates over cache keys that start with prefix, without decoding
+// values. Much cheaper than Range when only key names matter. Keys are in order
+// within each entry bucket (lyrics, then negative).
+func (pc *PersistentCache) RangeKeys(prefix string, fn func(key string) bool) {
+	pc.db.View(func(tx *bolt.Tx) error {
+		p := []byte(prefix)
+		for _, name := range entryBuckets {
+			b := tx.Bucket([]byte(name))
+			if b == nil {
+				continue
 			}
-			if !fn(string(k), entry) {
-				return fmt.Errorf("iteration stopped")
+			c := b.Cursor()
+			for k, _ := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, _ = c.Next() {
+				if !fn(string(k)) {
+					return nil
+				}
 			}
-			return nil
-		})
+		}
+		return nil
 	})
 }
 
-// Stats returns cache statistics: the number of keys in the bucket and the
+// Stats returns cache statistics: the number of keys in the entry buckets and the
 // on-disk size of the database file in KB. Uses bbolt's BucketStats (page-tree
 // walk) for the count instead of ForEach so it stays fast on multi-GB DBs.
 func (pc *PersistentCache) Stats() (numKeys int, sizeInKB int) {
 	if err := pc.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucketName))
-		if b == nil {
-			return nil
+		for _, name := range entryBuckets {
+			if b := tx.Bucket([]byte(name)); b != nil {
+				numKeys += b.Stats().KeyN
+			}
 		}
-		numKeys = b.Stats().KeyN
 		return nil
 	}); err != nil {
-		log.Errorf("%s Failed to read bucket stats: %v", logcolors.LogCache, err)
+		cacheLog.Errorf("%s Failed to read bucket stats: %v", logcolors.LogCache, err)
 	}
 	if info, err := os.Stat(pc.dbPath); err != nil {
-		log.Errorf("%s Failed to stat database file %s: %v", logcolors.LogCache, pc.dbPath, err)
+		cacheLog.Errorf("%s Failed to stat database file %s: %v", logcolors.LogCache, pc.dbPath, err)
 	} else {
 		sizeInKB = int(info.Size() / 1024)
 	}
@@ -310,28 +553,35 @@ func (pc *PersistentCache) Counts() map[string]int64 {
 			return nil
 		})
 	}); err != nil {
-		log.Errorf("%s Failed to read counters: %v", logcolors.LogCache, err)
+		cacheLog.Errorf("%s Failed to read counters: %v", logcolors.LogCache, err)
 	}
 	return counts
 }
 
-// ReconcileCounters walks the entire cache bucket, recomputes the per-prefix
-// counts, and atomically replaces the counters bucket contents. Expensive: cost
+// ReconcileCounters walks the entry buckets, recomputes the per-prefix
+// counts and the Breakdown, and atomically replaces the counters bucket contents. Expensive: cost
 // scales with leaf-page count of the cache bucket (multi-minute on multi-GB
 // DBs). Safe to call concurrently with Set/Delete: the swap happens in one txn.
 // Note: any Set/Delete deltas applied between the scan and the swap will be
 // overwritten by the snapshot. The next reconcile run self-corrects.
 func (pc *PersistentCache) ReconcileCounters() error {
 	fresh := make(map[string]int64)
+	breakdown := newBreakdown()
 	if err := pc.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucketName))
-		if b == nil {
-			return nil
+		for _, name := range entryBuckets {
+			b := tx.Bucket([]byte(name))
+			if b == nil {
+				continue
+			}
+			if err := b.ForEach(func(k, v []byte) error {
+				fresh[prefixOf(string(k))]++
+				breakdown.add(k, v)
+				return nil
+			}); err != nil {
+				return err
+			}
 		}
-		return b.ForEach(func(k, _ []byte) error {
-			fresh[prefixOf(string(k))]++
-			return nil
-		})
+		return nil
 	}); err != nil {
 		return fmt.Errorf("reconcile: scan failed: %w", err)
 	}
@@ -355,6 +605,8 @@ func (pc *PersistentCache) ReconcileCounters() error {
 	}); err != nil {
 		return fmt.Errorf("reconcile: swap failed: %w", err)
 	}
+	breakdown.ComputedAt = time.Now()
+	pc.breakdown.Store(breakdown)
 	return nil
 }
 
@@ -374,15 +626,15 @@ func adjustCounter(b *bolt.Bucket, name string, delta int64) error {
 	return b.Put([]byte(name), buf[:])
 }
 
-// Backup creates a backup of the cache database file
-// Returns the backup file path
+// Backup creates a backup of the cache database file, encrypted when a backup key
+// is set. Returns the backup file path
 func (pc *PersistentCache) Backup() (string, error) {
 	// Generate backup filename with timestamp
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
 	backupFileName := fmt.Sprintf("cache_backup_%s.db", timestamp)
 	backupFilePath := filepath.Join(pc.backupPath, backupFileName)
 
-	log.Infof("%s Creating backup at: %s", logcolors.LogCacheBackup, backupFilePath)
+	cacheLog.Infof("%s Creating backup at: %s", logcolors.LogCacheBackup, backupFilePath)
 
 	// Close the database temporarily to ensure all data is flushed
 	if err := pc.db.Close(); err != nil {
@@ -390,7 +642,7 @@ func (pc *PersistentCache) Backup() (string, error) {
 	}
 
 	// Copy the database file to backup location
-	if err := copyFile(pc.dbPath, backupFilePath); err != nil {
+	if err := pc.writeBackupFile(pc.dbPath, backupFilePath); err != nil {
 		// Try to reopen the database even if backup failed
 		pc.reopenDatabase()
 		return "", fmt.Errorf("failed to copy database file: %v", err)
@@ -401,7 +653,7 @@ func (pc *PersistentCache) Backup() (string, error) {
 		return "", fmt.Errorf("failed to reopen database after backup: %v", err)
 	}
 
-	log.Infof("%s Backup created successfully: %s", logcolors.LogCacheBackup, backupFilePath)
+	cacheLog.Infof("%s Backup created successfully: %s", logcolors.LogCacheBackup, backupFilePath)
 	return backupFilePath, nil
 }
 
@@ -418,15 +670,15 @@ func (pc *PersistentCache) BackupAndClear() (string, error) {
 		return backupPath, fmt.Errorf("backup created but failed to clear cache: %v", err)
 	}
 
-	log.Infof("%s Cache cleared successfully (backup: %s)", logcolors.LogCacheClear, backupPath)
+	cacheLog.Infof("%s Cache cleared successfully (backup: %s)", logcolors.LogCacheClear, backupPath)
 	return backupPath, nil
 }
 
 // reopenDatabase reopens the database connection
 func (pc *PersistentCache) reopenDatabase() error {
-	db, err := bolt.Open(pc.dbPath, 0600, nil)
+	db, err := openDB(pc.dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to reopen database: %v", err)
+		return fmt.Errorf("failed to reopen database: %w", err)
 	}
 	pc.db = db
 	return nil
@@ -502,6 +754,9 @@ func (pc *PersistentCache) GetFromBucket(bucket, key string) ([]byte, bool) {
 // SetInBucket stores a raw value in a named bucket.
 // Unlike Set, this does NOT wrap in CacheEntry JSON or compress — caller handles format.
 func (pc *PersistentCache) SetInBucket(bucket, key string, value []byte) error {
+	if pc.refuseWrite() {
+		return ErrReadOnly
+	}
 	return pc.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(bucket))
 		if b == nil {
@@ -511,6 +766,26 @@ func (pc *PersistentCache) SetInBucket(bucket, key string, value []byte) error {
 	})
 }
 
+// SetManyInBucket stores several raw values in a named bucket in one transaction
+// (one fsync instead of one per key). Values are stored as-is, like SetInBucket.
+func (pc *PersistentCache) SetManyInBucket(bucket string, values map[string][]byte) error {
+	if pc.refuseWrite() {
+		return ErrReadOnly
+	}
+	return pc.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("bucket %q not found", bucket)
+		}
+		for key, value := range values {
+			if err := b.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // DeleteFromBucket removes a key from a named bucket.
 func (pc *PersistentCache) DeleteFromBucket(bucket, key string) error {
 	return pc.db.Update(func(tx *bolt.Tx) error {
@@ -522,6 +797,28 @@ func (pc *PersistentCache) DeleteFromBucket(bucket, key string) error {
 	})
 }
 
+// TrimBucket deletes the first keys of a named bucket (in key order) until at most
+// keep remain, walking a cursor from the start. Returns the number deleted.
+func (pc *PersistentCache) TrimBucket(bucket string, keep int) (int, error) {
+	deleted := 0
+	err := pc.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("bucket %q not found", bucket)
+		}
+		excess := b.Stats().KeyN - keep
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && deleted < excess; k, _ = c.First() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
+
 // RangeBucket streams over every key/value in the named bucket.
 // The callback returns false to stop iteration early (useful for bounded sampling).
 // Returned k/v slices are valid only within the callback — copy if you need to retain them.
@@ -576,6 +873,7 @@ type BackupInfo struct {
 	FilePath  string    `json:"filePath"`
 	Size      int64     `json:"sizeBytes"`
 	CreatedAt time.Time `json:"createdAt"`
+	Encrypted bool      `json:"encrypted"`
 }
 
 // ListBackups returns a list of all available backup files
@@ -602,15 +900,22 @@ func (pc *PersistentCache) ListBackups() ([]BackupInfo, error) {
 
 		info, err := entry.Info()
 		if err != nil {
-			log.Warnf("%s Failed to get info for %s: %v", logcolors.LogCacheBackups, entry.Name(), err)
+			cacheLog.Warnf("%s Failed to get info for %s: %v", logcolors.LogCacheBackups, entry.Name(), err)
 			continue
 		}
 
+		filePath := filepath.Join(pc.backupPath, entry.Name())
+		encrypted, err := IsEncryptedBackup(filePath)
+		if err != nil {
+			cacheLog.Warnf("%s Failed to read %s: %v", logcolors.LogCacheBackups, entry.Name(), err)
+		}
+
 		backups = append(backups, BackupInfo{
 			FileName:  entry.Name(),
-			FilePath:  filepath.Join(pc.backupPath, entry.Name()),
+			FilePath:  filePath,
 			Size:      info.Size(),
 			CreatedAt: info.ModTime(),
+			Encrypted: encrypted,
 		})
 	}
 
@@ -618,34 +923,21 @@ func (pc *PersistentCache) ListBackups() ([]BackupInfo, error) {
 }
 
 // RestoreFromBackup replaces the current cache database with a backup
-// This will close the current database, replace the file, and reopen it
+// This will close the current database, replace the file, and reopen it.
+// Encrypted backups are decrypted first, so a wrong key leaves the cache untouched.
 func (pc *PersistentCache) RestoreFromBackup(backupFileName string) error {
-	// Validate it's a .db file
-	if filepath.Ext(backupFileName) != ".db" {
-		return fmt.Errorf("invalid backup file: must be a .db file")
+	backupFilePath, err := pc.resolveBackupPath(backupFileName)
+	if err != nil {
+		return err
 	}
 
-	backupFilePath := filepath.Join(pc.backupPath, backupFileName)
+	cacheLog.Infof("%s Starting restore from backup: %s", logcolors.LogCacheRestore, backupFileName)
 
-	// Validate path traversal: ensure resolved path is within backup directory
-	absBackupPath, err := filepath.Abs(backupFilePath)
-	if err != nil {
-		return fmt.Errorf("invalid backup path: %v", err)
-	}
-	absBackupDir, err := filepath.Abs(pc.backupPath)
+	backupFilePath, cleanup, err := pc.openBackupFile(backupFilePath, pc.dbPath)
 	if err != nil {
-		return fmt.Errorf("invalid backup directory: %v", err)
-	}
-	if !strings.HasPrefix(absBackupPath, absBackupDir+string(os.PathSeparator)) {
-		return fmt.Errorf("invalid backup file: path traversal detected")
-	}
-
-	// Validate backup file exists
-	if _, err := os.Stat(backupFilePath); os.IsNotExist(err) {
-		return fmt.Errorf("backup file not found: %s", backupFileName)
+		return fmt.Errorf("failed to read backup: %w", err)
 	}
-
-	log.Infof("%s Starting restore from backup: %s", logcolors.LogCacheRestore, backupFileName)
+	defer cleanup()
 
 	// Close the current database
 	if err := pc.db.Close(); err != nil {
@@ -676,15 +968,16 @@ func (pc *PersistentCache) RestoreFromBackup(backupFileName string) error {
 		return fmt.Errorf("failed to reopen database after restore: %v", err)
 	}
 
-	log.Infof("%s Successfully restored from backup: %s", logcolors.LogCacheRestore, backupFileName)
+	cacheLog.Infof("%s Successfully restored from backup: %s", logcolors.LogCacheRestore, backupFileName)
 	return nil
 }
 
-// DeleteBackup deletes a specific backup file
-func (pc *PersistentCache) DeleteBackup(backupFileName string) error {
+// resolveBackupPath validates a backup file name (.db, inside the backup directory,
+// exists) and returns its full path
+func (pc *PersistentCache) resolveBackupPath(backupFileName string) (string, error) {
 	// Validate it's a .db file
 	if filepath.Ext(backupFileName) != ".db" {
-		return fmt.Errorf("invalid backup file: must be a .db file")
+		return "", fmt.Errorf("invalid backup file: must be a .db file")
 	}
 
 	backupFilePath := filepath.Join(pc.backupPath, backupFileName)
@@ -692,25 +985,35 @@ func (pc *PersistentCache) DeleteBackup(backupFileName string) error {
 	// Validate path traversal: ensure resolved path is within backup directory
 	absBackupPath, err := filepath.Abs(backupFilePath)
 	if err != nil {
-		return fmt.Errorf("invalid backup path: %v", err)
+		return "", fmt.Errorf("invalid backup path: %v", err)
 	}
 	absBackupDir, err := filepath.Abs(pc.backupPath)
 	if err != nil {
-		return fmt.Errorf("invalid backup directory: %v", err)
+		return "", fmt.Errorf("invalid backup directory: %v", err)
 	}
 	if !strings.HasPrefix(absBackupPath, absBackupDir+string(os.PathSeparator)) {
-		return fmt.Errorf("invalid backup file: path traversal detected")
+		return "", fmt.Errorf("invalid backup file: path traversal detected")
 	}
 
 	// Validate backup file exists
 	if _, err := os.Stat(backupFilePath); os.IsNotExist(err) {
-		return fmt.Errorf("backup file not found: %s", backupFileName)
+		return "", fmt.Errorf("backup file not found: %s", backupFileName)
+	}
+
+	return backupFilePath, nil
+}
+
+// DeleteBackup deletes a specific backup file
+func (pc *PersistentCache) DeleteBackup(backupFileName string) error {
+	backupFilePath, err := pc.resolveBackupPath(backupFileName)
+	if err != nil {
+		return err
 	}
 
 	if err := os.Remove(backupFilePath); err != nil {
 		return fmt.Errorf("failed to delete backup: %v", err)
 	}
 
-	log.Infof("%s Deleted backup: %s", logcolors.LogCacheBackup, backupFileName)
+	cacheLog.Infof("%s Deleted backup: %s", logcolors.LogCacheBackup, backupFileName)
 	return nil
 }
@@ -2,17 +2,21 @@ package cache
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	log "github.com/sirupsen/logrus"
 	bolt "go.etcd.io/bbolt"
 )
 
 // setupTestCache creates a temporary cache for testing
-func setupTestCache(t *testing.T, compression bool) (*PersistentCache, string, func()) {
+func setupTestCache(t testing.TB, compression bool) (*PersistentCache, string, func()) {
 	t.Helper()
 
 	tmpDir := t.TempDir()
@@ -243,6 +247,33 @@ func TestRange(t *testing.T) {
 	}
 }
 
+func TestRangeKeys(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	for _, k := range []string{"ttml_lyrics:b", "ttml_lyrics:a", "no_lyrics:ttml_lyrics:a", "ttml_lyricz"} {
+		cache.Set(k, "v")
+	}
+
+	var keys []string
+	cache.RangeKeys("ttml_lyrics:", func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 2 || keys[0] != "ttml_lyrics:a" || keys[1] != "ttml_lyrics:b" {
+		t.Errorf("Expected prefixed keys in order, got %v", keys)
+	}
+
+	count := 0
+	cache.RangeKeys("", func(key string) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("Expected iteration to stop after first key, got %d", count)
+	}
+}
+
 func TestBackup(t *testing.T) {
 	cache, tmpDir, cleanup := setupTestCache(t, false)
 	defer cleanup()
@@ -793,3 +824,284 @@ func TestReconcileCounters_WipesStaleCounters(t *testing.T) {
 		t.Errorf("after reconcile: expected ttml=1 (wiped from 999), got %d", got)
 	}
 }
+
+// putRaw overwrites a cache entry's stored bytes directly
+func putRaw(t *testing.T, cache *PersistentCache, key string, data []byte) {
+	t.Helper()
+	if err := cache.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucketName)).Put([]byte(key), data)
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGet_ChecksumMismatchDeletesEntry(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, true)
+	defer cleanup()
+
+	var reported []string
+	cache.SetCorruptionHandler(func(key, reason string) {
+		reported = append(reported, key+": "+reason)
+	})
+
+	if err := cache.Set("ttml_lyrics:song", "lyrics"); err != nil {
+		t.Fatal(err)
+	}
+	var stored CacheEntry
+	cache.Range(func(key string, entry CacheEntry) bool {
+		stored = entry
+		return false
+	})
+	if stored.Checksum == "" || !stored.Verify() {
+		t.Fatalf("Expected a valid checksum on write, got %+v", stored)
+	}
+
+	// Flip one character of the stored value, keeping the old checksum
+	stored.Value = "X" + stored.Value[1:]
+	data, _ := json.Marshal(stored)
+	putRaw(t, cache, "ttml_lyrics:song", data)
+
+	if _, ok := cache.Get("ttml_lyrics:song"); ok {
+		t.Fatal("Corrupt entry must be a miss")
+	}
+	if len(reported) != 1 || reported[0] != "ttml_lyrics:song: checksum mismatch" {
+		t.Errorf("Unexpected corruption reports: %v", reported)
+	}
+	if cache.CorruptEntries() != 1 {
+		t.Errorf("CorruptEntries = %d, want 1", cache.CorruptEntries())
+	}
+	if cache.Counts()["ttml_lyrics"] != 0 {
+		t.Error("Corrupt entry should be deleted (and uncounted)")
+	}
+}
+
+func TestGet_BrokenGzipWithoutChecksumIsCorrupt(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, true)
+	defer cleanup()
+
+	// Entries written before checksums have none; a truncated gzip blob is still caught
+	putRaw(t, cache, "legacy", []byte(`{"value":"H4sIAAAAAAAA"}`))
+	if _, ok := cache.Get("legacy"); ok {
+		t.Fatal("Undecompressable entry must be a miss")
+	}
+	if cache.CorruptEntries() != 1 {
+		t.Errorf("CorruptEntries = %d, want 1", cache.CorruptEntries())
+	}
+
+	// Plain text stored before compression was turned on is read as-is
+	putRaw(t, cache, "plain", []byte(`{"value":"not compressed"}`))
+	if value, ok := cache.Get("plain"); !ok || value != "not compressed" {
+		t.Errorf("Uncompressed legacy entry: Get = %q, %v", value, ok)
+	}
+	if cache.CorruptEntries() != 1 {
+		t.Error("Uncompressed legacy entry must not be treated as corrupt")
+	}
+}
+
+func TestGet_ReadsByEntryCodecNotSetting(t *testing.T) {
+	dir := t.TempDir()
+	dbPath, backupPath := filepath.Join(dir, "cache.db"), filepath.Join(dir, "backups")
+
+	compressed, err := NewPersistentCache(dbPath, backupPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed.Set("ttml_lyrics:gzip", "<tt>written compressed</tt>")
+	compressed.Close()
+
+	plain, err := NewPersistentCache(dbPath, backupPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain.Set("ttml_lyrics:none", "<tt>written plain</tt>")
+	plain.Close()
+
+	// Toggle back on: both entries stay readable, neither is decoded twice
+	reopened, err := NewPersistentCache(dbPath, backupPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if value, ok := reopened.Get("ttml_lyrics:gzip"); !ok || value != "<tt>written compressed</tt>" {
+		t.Errorf("gzip entry: Get = %q, %v", value, ok)
+	}
+	if value, ok := reopened.Get("ttml_lyrics:none"); !ok || value != "<tt>written plain</tt>" {
+		t.Errorf("plain entry: Get = %q, %v", value, ok)
+	}
+	if reopened.CorruptEntries() != 0 {
+		t.Errorf("CorruptEntries = %d, want 0", reopened.CorruptEntries())
+	}
+}
+
+func TestGet_UnknownCodecIsMissNotCorruption(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	putRaw(t, cache, "ttml_lyrics:future", []byte(`{"value":"KLUv/QBY","codec":"zstd"}`))
+	if _, ok := cache.Get("ttml_lyrics:future"); ok {
+		t.Fatal("Entry with an unknown codec must be a miss")
+	}
+	if cache.CorruptEntries() != 0 {
+		t.Error("Entry with an unknown codec must not be deleted as corrupt")
+	}
+	if _, ok := cache.GetFromBucket("cache", "ttml_lyrics:future"); !ok {
+		t.Error("Entry with an unknown codec should be kept")
+	}
+}
+
+func TestQuarantine_MovesEntryOutOfCache(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	putRaw(t, cache, "ttml_lyrics:bad", []byte("not json"))
+	if err := cache.ReconcileCounters(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Quarantine("ttml_lyrics:bad", "invalid entry JSON"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.GetFromBucket(bucketName, "ttml_lyrics:bad"); ok {
+		t.Error("Quarantined entry must be removed from the cache bucket")
+	}
+	if got := cache.Counts()["ttml"]; got != 0 {
+		t.Errorf("ttml counter = %d, want 0", got)
+	}
+
+	raw, ok := cache.GetFromBucket(QuarantineBucket, "ttml_lyrics:bad")
+	if !ok {
+		t.Fatal("Expected entry in the quarantine bucket")
+	}
+	var q QuarantinedEntry
+	if err := json.Unmarshal(raw, &q); err != nil {
+		t.Fatal(err)
+	}
+	if string(q.Raw) != "not json" || q.Reason != "invalid entry JSON" || q.QuarantinedAt == 0 {
+		t.Errorf("Unexpected quarantine record: %+v", q)
+	}
+
+	if err := cache.Quarantine("ttml_lyrics:missing", "x"); err == nil {
+		t.Error("Expected an error for a missing key")
+	}
+}
+
+// benchmarkLyricsValue is a cached lyrics payload about the size of a word-timed track
+func benchmarkLyricsValue() string {
+	var b strings.Builder
+	b.WriteString(`{"ttml":"<tt xmlns=\"http://www.w3.org/ns/ttml\"><body><div>`)
+	for i := 0; i < 600; i++ {
+		fmt.Fprintf(&b, `<p begin=\"%d.000\"><span>line %d of the lyrics</span></p>`, i, i)
+	}
+	b.WriteString(`</div></body></tt>","trackDurationMs":200000}`)
+	return b.String()
+}
+
+func benchmarkCacheSet(b *testing.B, compression bool) {
+	quietLogs(b)
+	cache, _, cleanup := setupTestCache(b, compression)
+	defer cleanup()
+	value := benchmarkLyricsValue()
+	b.SetBytes(int64(len(value)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := cache.Set(fmt.Sprintf("ttml_lyrics:bench %d", i%100), value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkCacheGet(b *testing.B, compression bool) {
+	quietLogs(b)
+	cache, _, cleanup := setupTestCache(b, compression)
+	defer cleanup()
+	value := benchmarkLyricsValue()
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("ttml_lyrics:bench %d", i), value)
+	}
+	b.SetBytes(int64(len(value)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, ok := cache.Get(fmt.Sprintf("ttml_lyrics:bench %d", i%100)); !ok {
+			b.Fatal("cached value missing")
+		}
+	}
+}
+
+func BenchmarkPersistentCacheSet(b *testing.B)            { benchmarkCacheSet(b, false) }
+func BenchmarkPersistentCacheSet_Compressed(b *testing.B) { benchmarkCacheSet(b, true) }
+func BenchmarkPersistentCacheGet(b *testing.B)            { benchmarkCacheGet(b, false) }
+func BenchmarkPersistentCacheGet_Compressed(b *testing.B) { benchmarkCacheGet(b, true) }
+
+// quietLogs discards log output for the rest of a benchmark, so log lines don't
+// split the result lines the bench gate parses
+func quietLogs(tb testing.TB) {
+	out := log.StandardLogger().Out
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(out) })
+}
+
+func TestGetMany(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, true)
+	defer cleanup()
+
+	cache.Set("ttml_lyrics:a", "value a")
+	cache.Set("ttml_lyrics:b", "value b")
+	putRaw(t, cache, "ttml_lyrics:bad", []byte("not json"))
+
+	got := cache.GetMany([]string{"ttml_lyrics:a", "ttml_lyrics:missing", "ttml_lyrics:b", "ttml_lyrics:bad"})
+	if len(got) != 2 || got["ttml_lyrics:a"] != "value a" || got["ttml_lyrics:b"] != "value b" {
+		t.Errorf("GetMany = %v", got)
+	}
+	if cache.CorruptEntries() != 1 {
+		t.Errorf("CorruptEntries = %d, want 1 (corrupt entries are handled as in Get)", cache.CorruptEntries())
+	}
+}
+
+func TestGetEntry(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, true)
+	defer cleanup()
+
+	cache.Set("ttml_lyrics:a", strings.Repeat("value a ", 100))
+	putRaw(t, cache, "ttml_lyrics:bad", []byte("not json"))
+
+	entry, ok := cache.GetEntry("ttml_lyrics:a")
+	if !ok || entry.ValueCodec() != CodecGzip || len(entry.Value) >= 800 {
+		t.Errorf("Expected the compressed entry as stored, got %+v, %v", entry, ok)
+	}
+	if _, ok := cache.GetEntry("ttml_lyrics:missing"); ok {
+		t.Error("Expected a missing key to report false")
+	}
+	if _, ok := cache.GetEntry("ttml_lyrics:bad"); ok || cache.CorruptEntries() != 0 {
+		t.Error("Expected an unparseable entry to report false without being handled as corrupt")
+	}
+}
+
+func TestTrimBucket(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	cache.CreateBucket("changes")
+	for _, key := range []string{"3", "1", "4", "2", "5"} {
+		cache.SetInBucket("changes", key, []byte(key))
+	}
+
+	deleted, err := cache.TrimBucket("changes", 2)
+	if err != nil || deleted != 3 {
+		t.Fatalf("TrimBucket() = %d, %v, want 3 deleted", deleted, err)
+	}
+	var kept []string
+	cache.RangeBucket("changes", func(k, v []byte) bool {
+		kept = append(kept, string(k))
+		return true
+	})
+	if strings.Join(kept, ",") != "4,5" {
+		t.Errorf("Expected the last keys kept, got %v", kept)
+	}
+	if deleted, _ := cache.TrimBucket("changes", 2); deleted != 0 {
+		t.Errorf("Expected nothing to trim, got %d", deleted)
+	}
+}
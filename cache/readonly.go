@@ -0,0 +1,31 @@
+package cache
+
+import "errors"
+
+// ErrReadOnly is returned by writes while the cache is in read-only mode
+var ErrReadOnly = errors.New("cache is read-only")
+
+// SetReadOnly switches read-only mode, e.g. when the volume is almost full. Reads
+// and deletes keep working; Set, SetInBucket and SetManyInBucket return ErrReadOnly.
+func (pc *PersistentCache) SetReadOnly(readOnly bool) {
+	pc.readOnly.Store(readOnly)
+}
+
+// IsReadOnly reports whether writes are currently refused
+func (pc *PersistentCache) IsReadOnly() bool {
+	return pc.readOnly.Load()
+}
+
+// SkippedWrites returns how many writes were refused in read-only mode
+func (pc *PersistentCache) SkippedWrites() int64 {
+	return pc.skippedWrites.Load()
+}
+
+// refuseWrite counts and refuses a write in read-only mode
+func (pc *PersistentCache) refuseWrite() bool {
+	if !pc.readOnly.Load() {
+		return false
+	}
+	pc.skippedWrites.Add(1)
+	return true
+}
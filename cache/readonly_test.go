@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadOnly_RefusesWritesButServesReads(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	cache.Set("ttml_lyrics:song", "lyrics")
+	cache.CreateBucket("meta")
+	cache.SetReadOnly(true)
+
+	if err := cache.Set("ttml_lyrics:other", "lyrics"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Set = %v, want ErrReadOnly", err)
+	}
+	if err := cache.SetInBucket("meta", "k", []byte("v")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SetInBucket = %v, want ErrReadOnly", err)
+	}
+	if value, ok := cache.Get("ttml_lyrics:song"); !ok || value != "lyrics" {
+		t.Errorf("Expected reads to keep working, got %q, %v", value, ok)
+	}
+	if err := cache.Delete("ttml_lyrics:song"); err != nil {
+		t.Errorf("Deletes free space and should be allowed, got %v", err)
+	}
+	if got := cache.SkippedWrites(); got != 2 {
+		t.Errorf("SkippedWrites = %d, want 2", got)
+	}
+
+	cache.SetReadOnly(false)
+	if err := cache.Set("ttml_lyrics:other", "lyrics"); err != nil {
+		t.Errorf("Set after leaving read-only mode: %v", err)
+	}
+}
@@ -6,8 +6,6 @@ import (
 	"time"
 
 	"lyrics-api-go/logcolors"
-
-	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -54,7 +52,7 @@ func (sc *StatsCache) Refresh() {
 
 	start := time.Now()
 	if err := sc.cache.ReconcileCounters(); err != nil {
-		log.Errorf("%s Reconcile failed: %v", logcolors.LogCache, err)
+		cacheLog.Errorf("%s Reconcile failed: %v", logcolors.LogCache, err)
 		prev := sc.value.Load()
 		sc.value.Store(&CachedStats{
 			Status:           StatsStatusError,
@@ -75,12 +73,12 @@ func (sc *StatsCache) Refresh() {
 // then re-reconciles every interval. Stops when stop is closed.
 func (sc *StatsCache) StartBackgroundRefresh(interval time.Duration, stop <-chan struct{}) {
 	go func() {
-		log.Infof("%s Seeding counters (reconcile cadence: %s)", logcolors.LogCache, interval)
+		cacheLog.Infof("%s Seeding counters (reconcile cadence: %s)", logcolors.LogCache, interval)
 		sc.Refresh()
 		if snap := sc.Get(); snap.Status == StatsStatusReady {
-			log.Infof("%s Counter seed complete (took %dms)", logcolors.LogCache, snap.LastDurationMs)
+			cacheLog.Infof("%s Counter seed complete (took %dms)", logcolors.LogCache, snap.LastDurationMs)
 		} else {
-			log.Errorf("%s Counter seed FAILED (status=%s): %s", logcolors.LogCache, snap.Status, snap.LastError)
+			cacheLog.Errorf("%s Counter seed FAILED (status=%s): %s", logcolors.LogCache, snap.Status, snap.LastError)
 		}
 
 		ticker := time.NewTicker(interval)
@@ -90,9 +88,9 @@ func (sc *StatsCache) StartBackgroundRefresh(interval time.Duration, stop <-chan
 			case <-ticker.C:
 				sc.Refresh()
 				if snap := sc.Get(); snap.Status == StatsStatusReady {
-					log.Infof("%s Counters reconciled (took %dms)", logcolors.LogCache, snap.LastDurationMs)
+					cacheLog.Infof("%s Counters reconciled (took %dms)", logcolors.LogCache, snap.LastDurationMs)
 				} else {
-					log.Errorf("%s Counter reconcile FAILED (status=%s): %s", logcolors.LogCache, snap.Status, snap.LastError)
+					cacheLog.Errorf("%s Counter reconcile FAILED (status=%s): %s", logcolors.LogCache, snap.Status, snap.LastError)
 				}
 			case <-stop:
 				return
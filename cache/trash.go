@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// TrashBucket holds deleted cache entries (under their original key) until they
+// are restored or purged
+const TrashBucket = "trash"
+
+// ErrLiveEntry is returned when restoring over a key that has a live cache entry
+var ErrLiveEntry = errors.New("key has a live cache entry")
+
+// TrashedEntry is a deleted cache entry kept in TrashBucket
+type TrashedEntry struct {
+	Raw       []byte `json:"raw"` // Entry exactly as stored in the cache bucket
+	Reason    string `json:"reason"`
+	DeletedAt int64  `json:"deleted_at"`
+}
+
+// Trash moves an entry from the cache bucket into TrashBucket in one transaction.
+// A missing key is not an error, so Trash can stand in for Delete. Trashing a key
+// that is already in the trash replaces the older copy. deletedAt is what
+// PurgeTrash's cutoff is compared against.
+func (pc *PersistentCache) Trash(key, reason string, deletedAt time.Time) error {
+	return pc.db.Update(func(tx *bolt.Tx) error {
+		b, data := findEntry(tx, key)
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		counters := tx.Bucket([]byte(countersBucket))
+		if counters == nil {
+			return fmt.Errorf("counters bucket not found")
+		}
+		if data == nil {
+			return nil
+		}
+
+		record, err := json.Marshal(TrashedEntry{
+			Raw:       data,
+			Reason:    reason,
+			DeletedAt: deletedAt.Unix(),
+		})
+		if err != nil {
+			return err
+		}
+		t, err := tx.CreateBucketIfNotExists([]byte(TrashBucket))
+		if err != nil {
+			return err
+		}
+		if err := t.Put([]byte(key), record); err != nil {
+			return err
+		}
+		if err := b.Delete([]byte(key)); err != nil {
+			return err
+		}
+		return adjustCounter(counters, prefixOf(key), -1)
+	})
+}
+
+// RestoreFromTrash moves a trashed entry back into the cache bucket. It fails with
+// ErrLiveEntry if the key has been cached again since, unless overwrite is set.
+func (pc *PersistentCache) RestoreFromTrash(key string, overwrite bool) error {
+	return pc.db.Update(func(tx *bolt.Tx) error {
+		t := tx.Bucket([]byte(TrashBucket))
+		if t == nil {
+			return fmt.Errorf("key not found")
+		}
+		data := t.Get([]byte(key))
+		if data == nil {
+			return fmt.Errorf("key not found")
+		}
+		var trashed TrashedEntry
+		if err := json.Unmarshal(data, &trashed); err != nil {
+			return fmt.Errorf("invalid trash record: %w", err)
+		}
+
+		b, live := findEntry(tx, key)
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		counters := tx.Bucket([]byte(countersBucket))
+		if counters == nil {
+			return fmt.Errorf("counters bucket not found")
+		}
+		existed := live != nil
+		if existed && !overwrite {
+			return ErrLiveEntry
+		}
+
+		if err := b.Put([]byte(key), trashed.Raw); err != nil {
+			return err
+		}
+		if err := t.Delete([]byte(key)); err != nil {
+			return err
+		}
+		if !existed {
+			return adjustCounter(counters, prefixOf(key), 1)
+		}
+		return nil
+	})
+}
+
+// PurgeTrash permanently deletes trashed entries deleted before cutoff and returns
+// how many went. A zero cutoff purges everything.
+func (pc *PersistentCache) PurgeTrash(cutoff time.Time) (int, error) {
+	purged := 0
+	err := pc.db.Update(func(tx *bolt.Tx) error {
+		t := tx.Bucket([]byte(TrashBucket))
+		if t == nil {
+			return nil
+		}
+		var expired [][]byte
+		t.ForEach(func(k, v []byte) error {
+			var trashed TrashedEntry
+			if cutoff.IsZero() || json.Unmarshal(v, &trashed) != nil || trashed.DeletedAt < cutoff.Unix() {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			if err := t.Delete(k); err != nil {
+				return err
+			}
+		}
+		purged = len(expired)
+		return nil
+	})
+	return purged, err
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTrash_RestoreRoundTrip(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	cache.Set("ttml_lyrics:song", "lyrics")
+	if err := cache.Trash("ttml_lyrics:song", "bulk_delete", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("ttml_lyrics:song"); ok {
+		t.Error("Trashed entry must not be served")
+	}
+	if got := cache.Counts()["ttml"]; got != 0 {
+		t.Errorf("ttml counter = %d, want 0", got)
+	}
+	if err := cache.Trash("ttml_lyrics:missing", "x", time.Now()); err != nil {
+		t.Errorf("Trashing a missing key should be a no-op, got %v", err)
+	}
+
+	if err := cache.RestoreFromTrash("ttml_lyrics:song", false); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := cache.Get("ttml_lyrics:song"); !ok || value != "lyrics" {
+		t.Errorf("Expected the restored entry, got %q, %v", value, ok)
+	}
+	if got := cache.Counts()["ttml"]; got != 1 {
+		t.Errorf("ttml counter = %d, want 1", got)
+	}
+	if _, ok := cache.GetFromBucket(TrashBucket, "ttml_lyrics:song"); ok {
+		t.Error("Restored entry must leave the trash")
+	}
+}
+
+func TestRestoreFromTrash_LiveEntry(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	cache.Set("ttml_lyrics:song", "old")
+	cache.Trash("ttml_lyrics:song", "manual", time.Now())
+	cache.Set("ttml_lyrics:song", "new")
+
+	if err := cache.RestoreFromTrash("ttml_lyrics:song", false); !errors.Is(err, ErrLiveEntry) {
+		t.Fatalf("Expected ErrLiveEntry, got %v", err)
+	}
+	if err := cache.RestoreFromTrash("ttml_lyrics:song", true); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := cache.Get("ttml_lyrics:song"); value != "old" {
+		t.Errorf("Overwrite should restore the trashed value, got %q", value)
+	}
+	if got := cache.Counts()["ttml"]; got != 1 {
+		t.Errorf("ttml counter = %d, want 1", got)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t, false)
+	defer cleanup()
+
+	cache.Set("ttml_lyrics:a", "a")
+	cache.Set("ttml_lyrics:b", "b")
+	deletedAt := time.Unix(1_700_000_000, 0)
+	cache.Trash("ttml_lyrics:a", "manual", deletedAt)
+	cache.Trash("ttml_lyrics:b", "manual", deletedAt.Add(2*time.Hour))
+
+	if n, err := cache.PurgeTrash(deletedAt); err != nil || n != 0 {
+		t.Errorf("Nothing was deleted before the cutoff, purged %d (%v)", n, err)
+	}
+	if n, err := cache.PurgeTrash(deletedAt.Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("Expected the older entry purged, got %d (%v)", n, err)
+	}
+	if err := cache.RestoreFromTrash("ttml_lyrics:a", false); err == nil {
+		t.Error("Purged entry must not be restorable")
+	}
+	if _, ok := cache.GetFromBucket(TrashBucket, "ttml_lyrics:b"); !ok {
+		t.Error("Entry deleted after the cutoff must stay in the trash")
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/jobs"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/utils"
+	"net/http"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	analysisTopLargest    = 20
+	analysisTopDuplicates = 20
+)
+
+// sizeBins are the upper bounds (exclusive) for the stored-size histogram
+var sizeBins = []struct {
+	label string
+	max   int
+}{
+	{"<1KB", 1 << 10},
+	{"1-4KB", 4 << 10},
+	{"4-16KB", 16 << 10},
+	{"16-64KB", 64 << 10},
+	{"64-256KB", 256 << 10},
+	{">=256KB", -1},
+}
+
+// compressionBins are the upper bounds (exclusive) for the stored/decompressed ratio histogram
+var compressionBins = []struct {
+	label string
+	max   float64
+}{
+	{"<10%", 0.10},
+	{"10-20%", 0.20},
+	{"20-30%", 0.30},
+	{"30-50%", 0.50},
+	{"50-100%", 1.00},
+	{">=100%", -1},
+}
+
+// analyzeCacheHandler starts an async cache analysis job.
+// Returns immediately with a job ID. Use /cache/analyze/status?job_id=xxx to check progress.
+func analyzeCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	job, ok := startJob(w, r, jobKindAnalyze, nil, "/cache/analyze/status", "Analysis started", runCacheAnalysis)
+	if ok {
+		log.Infof("%s Started async cache analysis job %s", logcolors.LogCache, job.ID)
+	}
+}
+
+// runCacheAnalysis is the /cache/analyze job
+func runCacheAnalysis(t *jobs.Task) (interface{}, error) {
+	// Live key count is cheap and gives the progress denominator up front
+	totalKeys, _ := persistentCache.Stats()
+	result := analyzeCache(t.Context(), func(processed int) {
+		t.SetProgress(processed, totalKeys)
+	})
+	if err := t.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	log.Infof("%s Analysis job %s complete: %d keys, %d legacy, %d duplicate groups (%d bytes reclaimable)",
+		logcolors.LogCache, t.ID(), result.TotalKeys, result.LegacyFormatKeys, result.Duplicates.Groups, result.Duplicates.ReclaimableBytes)
+	return result, nil
+}
+
+// analyzeCache scans every cache entry once and builds size/compression histograms,
+// the largest entries, legacy key counts and near-duplicate groups.
+// onProgress (optional) is called periodically with the number of processed keys.
+// The scan stops early once ctx is cancelled.
+func analyzeCache(ctx context.Context, onProgress func(processed int)) *CacheAnalysis {
+	result := &CacheAnalysis{
+		KeysByPrefix:         make(map[string]int),
+		SizeHistogram:        make([]HistogramBin, len(sizeBins)),
+		CompressionHistogram: make([]HistogramBin, len(compressionBins)),
+	}
+	for i, b := range sizeBins {
+		result.SizeHistogram[i].Label = b.label
+	}
+	for i, b := range compressionBins {
+		result.CompressionHistogram[i].Label = b.label
+	}
+
+	largest := &largestEntries{}
+	lyricsSizes := make(map[string]int)
+
+	persistentCache.Range(func(key string, entry cache.CacheEntry) bool {
+		size := len(entry.Value)
+		result.TotalKeys++
+		result.TotalRawBytes += int64(size)
+		largest.offer(CacheKeySize{Key: key, Bytes: size})
+
+		prefix := "unknown"
+		if idx := strings.IndexByte(key, ':'); idx > 0 {
+			prefix = key[:idx]
+		}
+		result.KeysByPrefix[prefix]++
+
+		// Stored-size histogram
+		for i, b := range sizeBins {
+			if b.max < 0 || size < b.max {
+				result.SizeHistogram[i].Count++
+				result.SizeHistogram[i].Bytes += int64(size)
+				break
+			}
+		}
+
+		// Compression ratio histogram (by the entry's codec)
+		value, decompressedSize := entry.Value, size
+		if entry.ValueCodec() == cache.CodecGzip {
+			var err error
+			if value, err = entry.Decode(); err == nil {
+				decompressedSize = len(value)
+			}
+		} else {
+			result.Uncompressed++
+		}
+		result.TotalDecompressedBytes += int64(decompressedSize)
+		ratio := 1.0
+		if decompressedSize > 0 {
+			ratio = float64(size) / float64(decompressedSize)
+		}
+		for i, b := range compressionBins {
+			if b.max < 0 || ratio < b.max {
+				result.CompressionHistogram[i].Count++
+				result.CompressionHistogram[i].Bytes += int64(size)
+				break
+			}
+		}
+
+		// Legacy format: ttml_lyrics key that the migration would rewrite
+		if strings.HasPrefix(key, "ttml_lyrics:") && normalizeLyricsCacheKey(key) != key {
+			result.LegacyFormatKeys++
+		}
+
+		// Positive lyrics entries are candidates for duplicate detection. Aliases
+		// already share one blob, so they aren't duplicates.
+		if strings.HasSuffix(prefix, "_lyrics") && prefix != "no_lyrics" && !isLyricsAlias(value) {
+			lyricsSizes[key] = size
+		}
+
+		if onProgress != nil && result.TotalKeys%1000 == 0 {
+			onProgress(result.TotalKeys)
+		}
+		return ctx.Err() == nil
+	})
+
+	result.Largest = largest.sorted()
+
+	result.Duplicates = findNearDuplicates(lyricsSizes, lyricsTrackIdentities(lyricsSizes))
+
+	if onProgress != nil {
+		onProgress(result.TotalKeys)
+	}
+	return result
+}
+
+// largestEntries is a min-heap of the analysisTopLargest largest entries seen
+type largestEntries []CacheKeySize
+
+func (h largestEntries) Len() int           { return len(h) }
+func (h largestEntries) Less(i, j int) bool { return h[i].Bytes < h[j].Bytes }
+func (h largestEntries) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *largestEntries) Push(x any)        { *h = append(*h, x.(CacheKeySize)) }
+func (h *largestEntries) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// offer keeps e if it is among the largest entries seen so far
+func (h *largestEntries) offer(e CacheKeySize) {
+	if h.Len() < analysisTopLargest {
+		heap.Push(h, e)
+	} else if e.Bytes > (*h)[0].Bytes {
+		(*h)[0] = e
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted returns the kept entries, largest first
+func (h *largestEntries) sorted() []CacheKeySize {
+	entries := append([]CacheKeySize(nil), *h...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Bytes > entries[j].Bytes })
+	return entries
+}
+
+// isLyricsAlias reports whether a lyrics value is an alias entry (see resolveCacheAlias)
+func isLyricsAlias(value string) bool {
+	var lyrics struct {
+		AliasOf string `json:"aliasOf"`
+	}
+	return json.Unmarshal([]byte(value), &lyrics) == nil && lyrics.AliasOf != ""
+}
+
+// normalizeLyricsCacheKey applies the same normalization as /cache/migrate to a ttml_lyrics key
+func normalizeLyricsCacheKey(key string) string {
+	query := strings.TrimPrefix(key, "ttml_lyrics:")
+	normalizedQuery := strings.ToLower(strings.TrimSpace(query))
+	for strings.Contains(normalizedQuery, "  ") {
+		normalizedQuery = strings.ReplaceAll(normalizedQuery, "  ", " ")
+	}
+	return "ttml_lyrics:" + normalizedQuery
+}
+
+// lyricsTrackIdentities returns the track identity of every key in sizes that has
+// song metadata: normalized title, artist and duration (to the second). Keys
+// without metadata have no identity and are never reported as duplicates.
+func lyricsTrackIdentities(sizes map[string]int) map[string]string {
+	identities := make(map[string]string)
+	persistentCache.RangeBucket(metadataBucket, func(k, v []byte) bool {
+		key := string(k)
+		if _, cached := sizes[key]; !cached {
+			return true
+		}
+		raw, err := utils.DecompressString(string(v))
+		if err != nil {
+			raw = string(v)
+		}
+		var meta SongMetadata
+		if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+			return true
+		}
+		title, artist := normalizeTrackField(meta.TrackName), normalizeTrackField(meta.ArtistName)
+		if title == "" || artist == "" {
+			return true
+		}
+		identities[key] = fmt.Sprintf("%s - %s (%ds)", title, artist, (meta.DurationMs+500)/1000)
+		return true
+	})
+	return identities
+}
+
+// normalizeTrackField lowercases a title or artist and collapses its whitespace
+func normalizeTrackField(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// findNearDuplicates groups keys with the same track identity (see
+// lyricsTrackIdentities): query variants that resolved to the same song. Keys
+// without an identity are skipped. Reclaimable space assumes the largest entry
+// of each group is kept.
+func findNearDuplicates(sizes map[string]int, identities map[string]string) DuplicateSummary {
+	groups := make(map[string][]CacheKeySize)
+	for key, size := range sizes {
+		if track, ok := identities[key]; ok {
+			groups[track] = append(groups[track], CacheKeySize{Key: key, Bytes: size})
+		}
+	}
+
+	summary := DuplicateSummary{TopGroups: []DuplicateGroup{}}
+	for track, keys := range groups {
+		if len(keys) < 2 {
+			continue
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].Bytes != keys[j].Bytes {
+				return keys[i].Bytes > keys[j].Bytes
+			}
+			return keys[i].Key < keys[j].Key
+		})
+		var reclaimable int64
+		for _, k := range keys[1:] {
+			reclaimable += int64(k.Bytes)
+		}
+		summary.Groups++
+		summary.Keys += len(keys)
+		summary.ReclaimableBytes += reclaimable
+		summary.TopGroups = append(summary.TopGroups, DuplicateGroup{
+			Track:            track,
+			BaseKey:          keys[0].Key,
+			Keys:             keys,
+			ReclaimableBytes: reclaimable,
+		})
+	}
+
+	sort.Slice(summary.TopGroups, func(i, j int) bool {
+		if summary.TopGroups[i].ReclaimableBytes != summary.TopGroups[j].ReclaimableBytes {
+			return summary.TopGroups[i].ReclaimableBytes > summary.TopGroups[j].ReclaimableBytes
+		}
+		return summary.TopGroups[i].Track < summary.TopGroups[j].Track
+	})
+	if len(summary.TopGroups) > analysisTopDuplicates {
+		summary.TopGroups = summary.TopGroups[:analysisTopDuplicates]
+	}
+	return summary
+}
+
+// getAnalysisStatus returns the status of a cache analysis job
+func getAnalysisStatus(w http.ResponseWriter, r *http.Request) {
+	writeJobStatus(w, r, jobKindAnalyze, nil)
+}
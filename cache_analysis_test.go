@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"lyrics-api-go/jobs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeCache(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	persistentCache.Set("ttml_lyrics:hello adele", `{"ttml":"`+strings.Repeat("a", 100)+`"}`)
+	persistentCache.Set("ttml_lyrics:hello adele 25", `{"ttml":"`+strings.Repeat("b", 50)+`"}`)
+	persistentCache.Set("ttml_lyrics:hello adele 25 295s", `{"ttml":"`+strings.Repeat("c", 20)+`"}`)
+	persistentCache.Set("ttml_lyrics:Someone Like You Adele ", `{"ttml":"x"}`)
+	persistentCache.Set("kugou_lyrics:hello adele [295s]", `{"ttml":"y"}`)
+	persistentCache.Set("no_lyrics:ttml_lyrics:missing song", `{"reason":"none"}`)
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:hello adele", TrackName: "Hello", ArtistName: "Adele", DurationMs: 295493})
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:hello adele 25", TrackName: "Hello ", ArtistName: "ADELE", DurationMs: 295200})
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:hello adele 25 295s", TrackName: "hello", ArtistName: "Adele", DurationMs: 295000})
+	// Query keys aliasing one track blob already share its lyrics: not duplicates
+	persistentCache.Set("ttml_lyrics:hello adele live", `{"aliasOf":"ttml_track:1"}`)
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:hello adele live", TrackName: "Hello", ArtistName: "Adele", DurationMs: 295000})
+
+	result := analyzeCache(context.Background(), nil)
+
+	if result.TotalKeys != 7 {
+		t.Errorf("TotalKeys = %d, want 7", result.TotalKeys)
+	}
+	if result.KeysByPrefix["ttml_lyrics"] != 5 || result.KeysByPrefix["no_lyrics"] != 1 || result.KeysByPrefix["kugou_lyrics"] != 1 {
+		t.Errorf("Unexpected KeysByPrefix: %v", result.KeysByPrefix)
+	}
+	if result.LegacyFormatKeys != 1 {
+		t.Errorf("LegacyFormatKeys = %d, want 1", result.LegacyFormatKeys)
+	}
+	if result.Uncompressed != 7 {
+		t.Errorf("Uncompressed = %d, want 7 (test cache has compression disabled)", result.Uncompressed)
+	}
+	if result.SizeHistogram[0].Count != 7 {
+		t.Errorf("Expected all entries in the <1KB bin, got %+v", result.SizeHistogram)
+	}
+	if len(result.Largest) == 0 || result.Largest[0].Key != "ttml_lyrics:hello adele" {
+		t.Errorf("Expected largest entry to be the 100-byte lyrics, got %+v", result.Largest)
+	}
+
+	if result.Duplicates.Groups != 1 || result.Duplicates.Keys != 3 {
+		t.Fatalf("Expected 1 duplicate group with 3 keys, got %+v", result.Duplicates)
+	}
+	group := result.Duplicates.TopGroups[0]
+	if group.Track != "hello - adele (295s)" {
+		t.Errorf("Track = %q, want hello - adele (295s)", group.Track)
+	}
+	if group.BaseKey != "ttml_lyrics:hello adele" || group.Keys[0].Key != "ttml_lyrics:hello adele" {
+		t.Errorf("Expected largest key first, got %q", group.Keys[0].Key)
+	}
+	expectedReclaimable := int64(group.Keys[1].Bytes + group.Keys[2].Bytes)
+	if group.ReclaimableBytes != expectedReclaimable {
+		t.Errorf("ReclaimableBytes = %d, want %d", group.ReclaimableBytes, expectedReclaimable)
+	}
+}
+
+func TestLargestEntries_KeepsTopN(t *testing.T) {
+	largest := &largestEntries{}
+	for i := range analysisTopLargest * 3 {
+		// Sizes in scrambled order
+		largest.offer(CacheKeySize{Key: fmt.Sprint(i), Bytes: (i * 37) % (analysisTopLargest * 3)})
+	}
+	sorted := largest.sorted()
+	if len(sorted) != analysisTopLargest {
+		t.Fatalf("Expected %d entries, got %d", analysisTopLargest, len(sorted))
+	}
+	for i, e := range sorted {
+		if want := analysisTopLargest*3 - 1 - i; e.Bytes != want {
+			t.Fatalf("sorted[%d].Bytes = %d, want %d", i, e.Bytes, want)
+		}
+	}
+}
+
+func TestFindNearDuplicates_ComparesTrackIdentity(t *testing.T) {
+	// Sharing a key prefix isn't enough: different songs, or the same song at a
+	// different duration (live, remix), are separate tracks
+	summary := findNearDuplicates(map[string]int{
+		"ttml_lyrics:love story taylor":          10,
+		"ttml_lyrics:love story taylor live":     10,
+		"ttml_lyrics:love story taylor swift":    20,
+		"ttml_lyrics:love story taylor fearless": 30,
+		"ttml_lyrics:love":                       10,
+	}, map[string]string{
+		"ttml_lyrics:love story taylor":          "love story - taylor swift (236s)",
+		"ttml_lyrics:love story taylor live":     "love story - taylor swift (251s)",
+		"ttml_lyrics:love story taylor swift":    "love story - taylor swift (236s)",
+		"ttml_lyrics:love story taylor fearless": "love story - taylor swift (236s)",
+	})
+	if summary.Groups != 1 || summary.Keys != 3 {
+		t.Fatalf("Expected 1 group with 3 keys, got %+v", summary)
+	}
+	if group := summary.TopGroups[0]; group.BaseKey != "ttml_lyrics:love story taylor fearless" || group.ReclaimableBytes != 30 {
+		t.Errorf("Unexpected group: %+v", group)
+	}
+}
+
+func TestAnalyzeCacheHandler_Async(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	persistentCache.Set("ttml_lyrics:song artist", `{"ttml":"x"}`)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/cache/analyze", nil)
+	r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+	analyzeCacheHandler(w, r)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+
+	job := waitForStartedJob(t, w)
+	if job.Status != jobs.StatusCompleted || job.Kind != jobKindAnalyze {
+		t.Fatalf("Unexpected job: %+v", job)
+	}
+	if result, ok := job.Result.(*CacheAnalysis); !ok || result.TotalKeys != 1 {
+		t.Errorf("Unexpected analysis result: %+v", job.Result)
+	}
+}
+
+func TestAnalyzeCacheHandler_Unauthorized(t *testing.T) {
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/cache/analyze", nil)
+	r.Header.Set("Authorization", "wrong")
+	analyzeCacheHandler(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
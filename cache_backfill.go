@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/jobs"
+	"lyrics-api-go/logcolors"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// keyDurationSuffix matches the " {duration}s" a lookup with duration= adds to a key
+var keyDurationSuffix = regexp.MustCompile(` (\d+)s$`)
+
+// CacheBackfillResult is the result of a metadata backfill job
+type CacheBackfillResult struct {
+	DryRun              bool `json:"dry_run"`
+	Scanned             int  `json:"scanned"`
+	Legacy              int  `json:"legacy"`                // Plain TTML entries found
+	Backfilled          int  `json:"backfilled"`            // Rewritten with metadata (would be, on a dry run)
+	DurationFromContent int  `json:"duration_from_content"` // Duration taken from the TTML body
+	DurationFromKey     int  `json:"duration_from_key"`     // Duration taken from the key's duration suffix
+	DurationUnknown     int  `json:"duration_unknown"`
+	Skipped             int  `json:"skipped"` // Changed by a lookup while the job ran
+}
+
+// backfillCacheHandler starts an async job that rewrites legacy plain-TTML lyrics
+// entries in the CachedLyrics format. Language and RTL are detected from the TTML,
+// and the duration is read from the TTML body or the key, so no upstream call is
+// made. Entries keep a zero score since the match score was never stored.
+//
+// Query params:
+//   - dry_run=true: Count what would be backfilled without writing
+//
+// Returns immediately with a job ID. Use /cache/backfill/status?job_id=xxx to check progress.
+func backfillCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	params := map[string]interface{}{"dry_run": dryRun}
+	job, ok := startJob(w, r, jobKindBackfill, params, "/cache/backfill/status", "Backfill started", func(t *jobs.Task) (interface{}, error) {
+		return runCacheBackfill(t, dryRun)
+	})
+	if ok {
+		log.Infof("%s Started async cache backfill job %s (dry run: %v)", logcolors.LogCache, job.ID, dryRun)
+	}
+}
+
+// getBackfillStatus returns the status of a backfill job
+func getBackfillStatus(w http.ResponseWriter, r *http.Request) {
+	writeJobStatus(w, r, jobKindBackfill, nil)
+}
+
+// runCacheBackfill is the /cache/backfill job. Backfilled entries are no longer
+// legacy, so a re-run after an interruption picks up where it stopped.
+func runCacheBackfill(t *jobs.Task, dryRun bool) (interface{}, error) {
+	result := CacheBackfillResult{DryRun: dryRun}
+	keys := listKeysWithPrefix("ttml_lyrics:")
+
+	t.SetStep("backfill")
+	for i, key := range keys {
+		if t.Cancelled() {
+			return result, t.Context().Err()
+		}
+		result.Scanned++
+		t.SetProgress(i+1, len(keys))
+
+		raw, ok := persistentCache.Get(key)
+		if !ok || !isLegacyLyricsEntry(raw) {
+			continue
+		}
+		result.Legacy++
+
+		durationMs := ttml.ContentDurationMs(raw)
+		switch {
+		case durationMs > 0:
+			result.DurationFromContent++
+		case keyDurationSuffix.MatchString(key):
+			secs, _ := strconv.Atoi(keyDurationSuffix.FindStringSubmatch(key)[1])
+			durationMs = secs * 1000
+			result.DurationFromKey++
+		default:
+			result.DurationUnknown++
+		}
+		if dryRun {
+			result.Backfilled++
+			continue
+		}
+
+		// A lookup may have refreshed the entry since it was read
+		if current, ok := persistentCache.Get(key); !ok || current != raw {
+			result.Skipped++
+			continue
+		}
+		language, isRTL := ttml.DetectLanguage(raw)
+		setCachedLyrics(key, raw, durationMs, 0, language, isRTL)
+		result.Backfilled++
+	}
+
+	log.Infof("%s Backfill job %s complete: %d keys, %d legacy, %d backfilled, %d skipped (dry run: %v)",
+		logcolors.LogCache, t.ID(), result.Scanned, result.Legacy, result.Backfilled, result.Skipped, dryRun)
+	return result, nil
+}
+
+// isLegacyLyricsEntry reports whether a stored lyrics value is the old plain-TTML
+// format rather than a CachedLyrics (or alias) JSON object
+func isLegacyLyricsEntry(value string) bool {
+	var cached CachedLyrics
+	if err := json.Unmarshal([]byte(value), &cached); err == nil {
+		return false
+	}
+	return validateTTMLStructure(value) == nil
+}
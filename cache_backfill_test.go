@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"lyrics-api-go/jobs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runBackfillJob(t *testing.T, dryRun bool) CacheBackfillResult {
+	t.Helper()
+	m := jobs.NewManager(jobs.Options{})
+	started, err := m.Start(jobKindBackfill, nil, func(task *jobs.Task) (interface{}, error) {
+		return runCacheBackfill(task, dryRun)
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, started.ID)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if job.Status != jobs.StatusCompleted {
+		t.Fatalf("Expected completed job, got %s (%s)", job.Status, job.Error)
+	}
+	return job.Result.(CacheBackfillResult)
+}
+
+func TestRunCacheBackfill(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	withBodyDur := strings.Replace(formatTestTTML, "<body>", `<body dur="3:05.500">`, 1)
+	bodyKey := buildNormalizedCacheKey("With Dur", "Artist", "", "")
+	suffixKey := buildNormalizedCacheKey("Key Dur", "Artist", "", "200")
+	unknownKey := buildNormalizedCacheKey("No Dur", "Artist", "", "")
+	currentKey := buildNormalizedCacheKey("Current", "Artist", "", "")
+	persistentCache.Set(bodyKey, withBodyDur)
+	persistentCache.Set(suffixKey, formatTestTTML)
+	persistentCache.Set(unknownKey, formatTestTTML)
+	setCachedLyrics(currentKey, formatTestTTML, 123000, 0.9, "en", false)
+	setNegativeCache(buildNormalizedCacheKey("Missing", "Artist", "", ""), "no track found", "", false)
+
+	dry := runBackfillJob(t, true)
+	if dry.Legacy != 3 || dry.Backfilled != 3 {
+		t.Errorf("Dry run = %+v", dry)
+	}
+	if raw, _ := persistentCache.Get(bodyKey); raw != withBodyDur {
+		t.Error("Dry run should not rewrite entries")
+	}
+
+	result := runBackfillJob(t, false)
+	want := CacheBackfillResult{Scanned: result.Scanned, Legacy: 3, Backfilled: 3, DurationFromContent: 1, DurationFromKey: 1, DurationUnknown: 1}
+	if result != want {
+		t.Errorf("Result = %+v, want %+v", result, want)
+	}
+
+	for key, wantMs := range map[string]int{bodyKey: 185500, suffixKey: 200000, unknownKey: 0} {
+		raw, _ := persistentCache.Get(key)
+		if isLegacyLyricsEntry(raw) {
+			t.Errorf("%s is still legacy", key)
+			continue
+		}
+		cached, ok := getCachedLyrics(key)
+		if !ok || cached.TrackDurationMs != wantMs || cached.Language != "en" || cached.TTML != formatTestTTML && key != bodyKey {
+			t.Errorf("%s = %+v, want duration %d", key, cached, wantMs)
+		}
+	}
+	if cached, _ := getCachedLyrics(currentKey); cached.TrackDurationMs != 123000 || cached.Score != 0.9 {
+		t.Errorf("Current entry changed: %+v", cached)
+	}
+
+	// Backfilled entries are not picked up again
+	if again := runBackfillJob(t, false); again.Legacy != 0 {
+		t.Errorf("Second run = %+v", again)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"lyrics-api-go/jobs"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// bulkDeleteSampleSize is how many matching keys a dry run lists
+const bulkDeleteSampleSize = 20
+
+// keyMatchesFilter applies the /cache/keys filters: prefix is case-sensitive,
+// contains is case-insensitive. Empty filters match everything.
+func keyMatchesFilter(key, prefix, contains string) bool {
+	if prefix != "" && !strings.HasPrefix(key, prefix) {
+		return false
+	}
+	if contains != "" && !strings.Contains(strings.ToLower(key), strings.ToLower(contains)) {
+		return false
+	}
+	return true
+}
+
+// bulkDeleteTokenTTL is how long a dry run's confirm token can be used
+const bulkDeleteTokenTTL = 10 * time.Minute
+
+// bulkDeletePreview is what a dry run matched, kept under its confirm token until
+// the delete uses it or it expires
+type bulkDeletePreview struct {
+	prefix    string
+	contains  string
+	matched   int
+	expiresAt time.Time
+}
+
+var (
+	bulkDeletePreviewsMu sync.Mutex
+	bulkDeletePreviews   = make(map[string]bulkDeletePreview)
+)
+
+// newBulkDeleteToken records a dry run and returns its confirm token. The real
+// delete must echo it back, so nobody purges keys with a filter they haven't
+// previewed.
+func newBulkDeleteToken(prefix, contains string, matched int) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	bulkDeletePreviewsMu.Lock()
+	defer bulkDeletePreviewsMu.Unlock()
+	now := clk.Now()
+	for t, preview := range bulkDeletePreviews {
+		if now.After(preview.expiresAt) {
+			delete(bulkDeletePreviews, t)
+		}
+	}
+	bulkDeletePreviews[token] = bulkDeletePreview{prefix: prefix, contains: contains, matched: matched, expiresAt: now.Add(bulkDeleteTokenTTL)}
+	return token, nil
+}
+
+// takeBulkDeletePreview returns the unexpired dry run a token was issued for and
+// invalidates the token: each one allows a single delete. Taking it (rather than
+// reading it) keeps two concurrent deletes from both using the same token.
+func takeBulkDeletePreview(token string) (bulkDeletePreview, bool) {
+	bulkDeletePreviewsMu.Lock()
+	defer bulkDeletePreviewsMu.Unlock()
+	preview, ok := bulkDeletePreviews[token]
+	delete(bulkDeletePreviews, token)
+	if !ok || clk.Now().After(preview.expiresAt) {
+		return bulkDeletePreview{}, false
+	}
+	return preview, true
+}
+
+// returnBulkDeletePreview puts back a token whose delete didn't start, so a 409
+// doesn't force a new dry run
+func returnBulkDeletePreview(token string, preview bulkDeletePreview) {
+	bulkDeletePreviewsMu.Lock()
+	defer bulkDeletePreviewsMu.Unlock()
+	bulkDeletePreviews[token] = preview
+}
+
+// rangeBulkDeleteKeys calls fn for every key matching a filter. It only reads key
+// names, so entries that no longer decode can still be matched and deleted.
+func rangeBulkDeleteKeys(prefix, contains string, fn func(key string)) {
+	persistentCache.RangeKeys(prefix, func(key string) bool {
+		if keyMatchesFilter(key, prefix, contains) {
+			fn(key)
+		}
+		return true
+	})
+}
+
+// matchBulkDelete counts the keys matching a filter, with up to sampleSize of them
+func matchBulkDelete(prefix, contains string, sampleSize int) (int, []string) {
+	matched := 0
+	sample := []string{}
+	rangeBulkDeleteKeys(prefix, contains, func(key string) {
+		matched++
+		if len(sample) < sampleSize {
+			sample = append(sample, key)
+		}
+	})
+	return matched, sample
+}
+
+// bulkDeleteHandler deletes every cache key matching prefix/contains (same filters as /cache/keys).
+//
+// Query params:
+//   - prefix, contains: Key filters (at least one is required - use /cache/clear to drop everything)
+//   - dry_run=true: List matches and return a confirm token without deleting (runs synchronously)
+//   - confirm: Token from the dry run, required for the real delete. It is valid for
+//     bulkDeleteTokenTTL and only while the filter still matches as many keys as it did.
+//     Starting the delete uses it up; a rejected request leaves it valid.
+//
+// Returns immediately with a job ID. Use /cache/keys/delete/status?job_id=xxx to check progress.
+func bulkDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	contains := r.URL.Query().Get("contains")
+	if prefix == "" && contains == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "Provide 'prefix' and/or 'contains'. Use /cache/clear to delete every key.",
+		})
+		return
+	}
+
+	if r.URL.Query().Get("dry_run") == "true" {
+		matched, sample := matchBulkDelete(prefix, contains, bulkDeleteSampleSize)
+		token, err := newBulkDeleteToken(prefix, contains, matched)
+		if err != nil {
+			http.Error(w, "Failed to generate a confirm token", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"dry_run":       true,
+			"matched":       matched,
+			"sample_keys":   sample,
+			"confirm_token": token,
+			"expires_in":    int(bulkDeleteTokenTTL.Seconds()),
+			"message":       "Re-run without dry_run and with confirm=" + token + " to delete these keys",
+		})
+		return
+	}
+
+	// The token is only used up once the job starts: a rejected request can retry it
+	token := r.URL.Query().Get("confirm")
+	preview, ok := takeBulkDeletePreview(token)
+	if ok && (preview.prefix != prefix || preview.contains != contains) {
+		returnBulkDeletePreview(token, preview)
+		ok = false
+	}
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "Missing, used, expired or wrong 'confirm' token. Run with dry_run=true first to preview matches and get a token for this filter.",
+		})
+		return
+	}
+	if matched, _ := matchBulkDelete(prefix, contains, 0); matched != preview.matched {
+		returnBulkDeletePreview(token, preview)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": fmt.Sprintf("The filter matches %d keys now but matched %d in the dry run. Run the dry run again to review the new matches.", matched, preview.matched),
+		})
+		return
+	}
+
+	params := map[string]interface{}{
+		"prefix":   prefix,
+		"contains": contains,
+	}
+	job, ok := startJob(w, r, jobKindBulkDelete, params, "/cache/keys/delete/status", "Bulk delete started", func(t *jobs.Task) (interface{}, error) {
+		return runBulkDelete(t, prefix, contains)
+	})
+	if !ok {
+		returnBulkDeletePreview(token, preview)
+		return
+	}
+	log.Infof("%s Started bulk delete job %s (prefix=%q, contains=%q)", logcolors.LogCacheClear, job.ID, prefix, contains)
+}
+
+// runBulkDelete is the /cache/keys/delete job: it collects matching keys, then deletes
+// them while reporting progress. A cancelled delete resumes by re-running it with the
+// same filter, since deleted keys no longer match.
+func runBulkDelete(t *jobs.Task, prefix, contains string) (interface{}, error) {
+	// Collect first: deleting while ranging over the bucket would invalidate the cursor
+	var keys []string
+	rangeBulkDeleteKeys(prefix, contains, func(key string) {
+		keys = append(keys, key)
+	})
+
+	result := BulkDeleteResult{Matched: len(keys)}
+	for i, key := range keys {
+		if t.Cancelled() {
+			return result, t.Context().Err()
+		}
+		if err := deleteCacheEntry(key, trashReasonBulkDelete); err != nil {
+			log.Warnf("%s Failed to delete key %s: %v", logcolors.LogCacheClear, key, err)
+			result.Failed++
+		} else {
+			result.Deleted++
+		}
+
+		if (i+1)%100 == 0 || i+1 == len(keys) {
+			t.SetProgress(i+1, len(keys))
+		}
+	}
+
+	log.Infof("%s Bulk delete job %s complete: %d matched, %d deleted, %d failed",
+		logcolors.LogCacheClear, t.ID(), result.Matched, result.Deleted, result.Failed)
+	return result, nil
+}
+
+// getBulkDeleteStatus returns the status of a bulk delete job
+func getBulkDeleteStatus(w http.ResponseWriter, r *http.Request) {
+	writeJobStatus(w, r, jobKindBulkDelete, nil)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"lyrics-api-go/internal/clocktest"
+	"lyrics-api-go/jobs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKeyMatchesFilter(t *testing.T) {
+	tests := []struct {
+		key, prefix, contains string
+		expected              bool
+	}{
+		{"kugou_lyrics:hello adele", "kugou_lyrics:", "", true},
+		{"ttml_lyrics:hello adele", "kugou_lyrics:", "", false},
+		{"ttml_lyrics:Hello Adele", "", "hello", true},
+		{"ttml_lyrics:hello adele", "ttml_lyrics:", "richie", false},
+		{"anything", "", "", true},
+	}
+	for _, tt := range tests {
+		if got := keyMatchesFilter(tt.key, tt.prefix, tt.contains); got != tt.expected {
+			t.Errorf("keyMatchesFilter(%q, %q, %q) = %v, want %v", tt.key, tt.prefix, tt.contains, got, tt.expected)
+		}
+	}
+}
+
+func TestBulkDeleteHandler_RequiresFilterAndToken(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	persistentCache.Set("kugou_lyrics:hello adele [295s]", `{"ttml":"x"}`)
+
+	// No filter
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/cache/keys/delete", nil)
+	r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+	bulkDeleteHandler(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("no filter: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	// Wrong token
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/cache/keys/delete?prefix=kugou_lyrics:&confirm=nope", nil)
+	r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+	bulkDeleteHandler(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("wrong token: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if _, ok := persistentCache.Get("kugou_lyrics:hello adele [295s]"); !ok {
+		t.Error("Key should not be deleted without a valid confirm token")
+	}
+}
+
+func TestBulkDeleteHandler_DryRunThenDelete(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	persistentCache.Set("kugou_lyrics:hello adele [295s]", `{"ttml":"x"}`)
+	persistentCache.Set("kugou_lyrics:someone like you adele", `{"ttml":"y"}`)
+	persistentCache.Set("ttml_lyrics:hello adele", `{"ttml":"z"}`)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/cache/keys/delete?prefix=kugou_lyrics:&dry_run=true", nil)
+	r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+	bulkDeleteHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("dry run: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var preview struct {
+		Matched      int      `json:"matched"`
+		SampleKeys   []string `json:"sample_keys"`
+		ConfirmToken string   `json:"confirm_token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&preview); err != nil {
+		t.Fatalf("Failed to decode dry run: %v", err)
+	}
+	if preview.Matched != 2 || len(preview.SampleKeys) != 2 || preview.ConfirmToken == "" {
+		t.Fatalf("Unexpected dry run result: %+v", preview)
+	}
+	if _, ok := persistentCache.Get("kugou_lyrics:hello adele [295s]"); !ok {
+		t.Fatal("Dry run must not delete keys")
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/cache/keys/delete?prefix=kugou_lyrics:&confirm="+preview.ConfirmToken, nil)
+	r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+	bulkDeleteHandler(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("delete: status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+
+	job := waitForStartedJob(t, w)
+	result, _ := job.Result.(BulkDeleteResult)
+	if job.Status != jobs.StatusCompleted || result.Matched != 2 || result.Deleted != 2 || result.Failed != 0 {
+		t.Errorf("Unexpected job: %+v", job)
+	}
+	if _, ok := persistentCache.Get("kugou_lyrics:hello adele [295s]"); ok {
+		t.Error("Matching key should be deleted")
+	}
+	if _, ok := persistentCache.Get("ttml_lyrics:hello adele"); !ok {
+		t.Error("Non-matching key should be kept")
+	}
+
+	// The token allows one delete
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/cache/keys/delete?prefix=kugou_lyrics:&confirm="+preview.ConfirmToken, nil)
+	r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+	bulkDeleteHandler(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("reused token: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestBulkDeleteHandler_TokenBoundToPreview(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	origClk := clk
+	clk = fake
+	defer func() { clk = origClk }()
+
+	persistentCache.Set("kugou_lyrics:hello adele", `{"ttml":"x"}`)
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, target, nil)
+		r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+		bulkDeleteHandler(w, r)
+		return w
+	}
+	dryRun := func(filter string) string {
+		var preview struct {
+			ConfirmToken string `json:"confirm_token"`
+		}
+		json.NewDecoder(serve("/cache/keys/delete?dry_run=true&" + filter).Body).Decode(&preview)
+		return preview.ConfirmToken
+	}
+
+	// Another filter's token
+	token := dryRun("prefix=kugou_lyrics:")
+	if w := serve("/cache/keys/delete?prefix=kugou&confirm=" + token); w.Code != http.StatusBadRequest {
+		t.Errorf("other filter: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	// Expired token
+	token = dryRun("prefix=kugou_lyrics:")
+	fake.Advance(bulkDeleteTokenTTL + time.Second)
+	if w := serve("/cache/keys/delete?prefix=kugou_lyrics:&confirm=" + token); w.Code != http.StatusBadRequest {
+		t.Errorf("expired token: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	// More keys match than were previewed
+	token = dryRun("prefix=kugou_lyrics:")
+	persistentCache.Set("kugou_lyrics:someone like you adele", `{"ttml":"y"}`)
+	if w := serve("/cache/keys/delete?prefix=kugou_lyrics:&confirm=" + token); w.Code != http.StatusConflict {
+		t.Errorf("changed matches: status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if _, ok := persistentCache.Get("kugou_lyrics:hello adele"); !ok {
+		t.Error("Keys must not be deleted when the matches changed")
+	}
+
+	// A rejected delete leaves the token usable once the matches are back to the preview
+	persistentCache.Delete("kugou_lyrics:someone like you adele")
+	w := serve("/cache/keys/delete?prefix=kugou_lyrics:&confirm=" + token)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("retried token: status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	waitForStartedJob(t, w)
+}
+
+func TestBulkDeleteHandler_DeletesUndecodableEntries(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	persistentCache.Set("kugou_lyrics:hello adele", `{"ttml":"x"}`)
+	persistentCache.SetInBucket("cache", "kugou_lyrics:bad", []byte("garbage"))
+
+	matched, _ := matchBulkDelete("kugou_lyrics:", "", 0)
+	if matched != 2 {
+		t.Fatalf("matched = %d, want 2 (undecodable entries included)", matched)
+	}
+
+	m := jobs.NewManager(jobs.Options{})
+	started, _ := m.Start(jobKindBulkDelete, nil, func(task *jobs.Task) (interface{}, error) {
+		return runBulkDelete(task, "kugou_lyrics:", "")
+	})
+	job, err := m.Wait(context.Background(), started.ID)
+	if err != nil || job.Status != jobs.StatusCompleted {
+		t.Fatalf("Unexpected job: %+v (%v)", job, err)
+	}
+	if result := job.Result.(BulkDeleteResult); result.Matched != 2 || result.Deleted != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if matched, _ := matchBulkDelete("kugou_lyrics:", "", 0); matched != 0 {
+		t.Errorf("%d keys left after the delete, want 0", matched)
+	}
+}
+
+func TestBulkDeleteHandler_Unauthorized(t *testing.T) {
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/cache/keys/delete?prefix=x&dry_run=true", nil)
+	r.Header.Set("Authorization", "wrong")
+	bulkDeleteHandler(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"lyrics-api-go/middleware"
+	"lyrics-api-go/stats"
+	"net/http"
+	"strconv"
+)
+
+// cacheBypass is what an admin asked /getLyrics to skip, for retesting tracks
+// whose upstream mapping was fixed without clearing their entries first
+type cacheBypass struct {
+	noNegative bool // no_negative=true: ignore "no lyrics" entries and recent-attempt markers
+	skipCache  bool // skip_cache=true: also ignore cached lyrics and stale fallbacks (fresh upstream fetch)
+}
+
+// active reports whether anything is bypassed
+func (b cacheBypass) active() bool {
+	return b.noNegative || b.skipCache
+}
+
+// errCacheBypassUnauthorized rejects no_negative/skip_cache without the admin token
+var errCacheBypassUnauthorized = errors.New("no_negative and skip_cache require the admin Authorization header")
+
+// parseCacheBypass reads no_negative and skip_cache. Both are admin-only; skip_cache
+// implies no_negative, since a fresh fetch must not be answered from a negative entry.
+func parseCacheBypass(r *http.Request) (cacheBypass, error) {
+	var bypass cacheBypass
+	for _, param := range []struct {
+		name  string
+		value *bool
+	}{{"no_negative", &bypass.noNegative}, {"skip_cache", &bypass.skipCache}} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return cacheBypass{}, fmt.Errorf("invalid %s %q: use true or false", param.name, value)
+		}
+		*param.value = enabled
+	}
+	bypass.noNegative = bypass.noNegative || bypass.skipCache
+
+	if bypass.active() && (conf().Configuration.CacheAccessToken == "" || r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken) {
+		return cacheBypass{}, errCacheBypassUnauthorized
+	}
+	return bypass, nil
+}
+
+// auditCacheBypass wraps w so the bypassing request is written to the audit log
+// ("lyrics.cache_bypass") with its final status once the returned func runs
+func auditCacheBypass(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	rec := middleware.NewResponseRecorder(w)
+	return rec, func() {
+		recordAudit(stats.AuditEntry{
+			Action:   "lyrics.cache_bypass",
+			Role:     auditRole(r),
+			RemoteIP: remoteIP(r),
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			Params:   auditParams(r),
+			Status:   rec.StatusCode,
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCacheBypass(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	tests := []struct {
+		name    string
+		query   string
+		admin   bool
+		want    cacheBypass
+		wantErr bool
+	}{
+		{"none", "", false, cacheBypass{}, false},
+		{"false needs no token", "no_negative=false", false, cacheBypass{}, false},
+		{"no_negative", "no_negative=true", true, cacheBypass{noNegative: true}, false},
+		{"skip_cache implies no_negative", "skip_cache=1", true, cacheBypass{noNegative: true, skipCache: true}, false},
+		{"not admin", "no_negative=true", false, cacheBypass{}, true},
+		{"invalid", "skip_cache=yes", true, cacheBypass{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/getLyrics?s=x&"+tt.query, nil)
+			if tt.admin {
+				r.Header.Set("Authorization", "test-token")
+			}
+			got, err := parseCacheBypass(r)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseCacheBypass() = %+v, %v; want %+v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetLyrics_CacheBypass(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	setupTestAuditLog(t)
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+	// Uncached lookups answer 503 instead of going upstream
+	originalCacheOnly := conf().FeatureFlags.CacheOnlyMode
+	conf().FeatureFlags.CacheOnlyMode = true
+	defer func() { conf().FeatureFlags.CacheOnlyMode = originalCacheOnly }()
+
+	setNegativeCache(buildNormalizedCacheKey("Missing", "Artist", "", ""), "no track found", "", false)
+	setCachedLyrics(buildNormalizedCacheKey("Cached", "Artist", "", ""), testTTML, 0, 0.9, "", false)
+
+	tests := []struct {
+		name        string
+		query       string
+		admin       bool
+		wantStatus  int
+		wantCache   string
+		wantAudited bool
+	}{
+		{"negative hit", "s=Missing&a=Artist", false, http.StatusNotFound, "NEGATIVE_HIT", false},
+		{"no_negative without token", "s=Missing&a=Artist&no_negative=true", false, http.StatusUnauthorized, "", false},
+		{"no_negative", "s=Missing&a=Artist&no_negative=true", true, http.StatusServiceUnavailable, "MISS", true},
+		{"no_negative keeps hits", "s=Cached&a=Artist&no_negative=true", true, http.StatusOK, "HIT", true},
+		{"skip_cache", "s=Cached&a=Artist&skip_cache=true", true, http.StatusServiceUnavailable, "MISS", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := statsStore.AuditCount()
+			r := httptest.NewRequest(http.MethodGet, "/getLyrics?"+tt.query, nil)
+			if tt.admin {
+				r.Header.Set("Authorization", "test-token")
+			}
+			rr := httptest.NewRecorder()
+			getLyrics(rr, r)
+			if rr.Code != tt.wantStatus || rr.Header().Get("X-Cache-Status") != tt.wantCache {
+				t.Fatalf("Got %d %q, want %d %q: %s", rr.Code, rr.Header().Get("X-Cache-Status"), tt.wantStatus, tt.wantCache, rr.Body.String())
+			}
+			if audited := statsStore.AuditCount() > before; audited != tt.wantAudited {
+				t.Fatalf("audited = %v, want %v", audited, tt.wantAudited)
+			}
+			if !tt.wantAudited {
+				return
+			}
+			entries, err := statsStore.QueryAudit(stats.AuditFilter{Action: "lyrics.cache_bypass", Limit: 1})
+			if err != nil || len(entries) != 1 {
+				t.Fatalf("QueryAudit() = %v, %v", entries, err)
+			}
+			if e := entries[0]; e.Role != stats.AuditRoleAdmin || e.Status != tt.wantStatus || e.Params["s"] == "" {
+				t.Errorf("Unexpected audit entry %+v", e)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/jobs"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/utils"
+	"net/http"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// resolveCacheAlias follows an alias entry to its canonical key. Aliases never chain:
+// if the canonical entry is missing or is itself an alias, the lookup is a miss.
+// The alias keeps its own match score; the TTML comes from the canonical entry.
+func resolveCacheAlias(aliasKey string, alias *CachedLyrics) (*CachedLyrics, bool) {
+	raw, ok := persistentCache.Get(alias.AliasOf)
+	if !ok {
+		log.Warnf("%s Alias %s points to missing canonical key %s", logcolors.LogCacheLyrics, aliasKey, alias.AliasOf)
+		return nil, false
+	}
+
+	var canonical CachedLyrics
+	if err := json.Unmarshal([]byte(raw), &canonical); err != nil || canonical.TTML == "" {
+		return nil, false
+	}
+
+	resolved := canonical
+	if alias.Score != 0 {
+		resolved.Score = alias.Score
+	}
+	if resolved.StoredAt == 0 {
+		resolved.StoredAt = alias.StoredAt
+	}
+	return &resolved, true
+}
+
+// dedupeCacheHandler turns cache entries that point at the same upstream track into
+// lightweight aliases of the track's ttml_track: blob. Track identity comes from the metadata
+// bucket (AppleTrackID). Only byte-identical TTML is aliased.
+//
+// Query params:
+//   - dry_run=true: Report what would change without writing (runs synchronously)
+//
+// Returns immediately with a job ID. Use /cache/dedupe/status?job_id=xxx to check progress.
+func dedupeCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.URL.Query().Get("dry_run") == "true" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(runCacheDedupe(context.Background(), true, nil))
+		return
+	}
+
+	job, ok := startJob(w, r, jobKindDedupe, nil, "/cache/dedupe/status", "Dedupe started", runCacheDedupeJob)
+	if ok {
+		log.Infof("%s Started async cache dedupe job %s", logcolors.LogCache, job.ID)
+	}
+}
+
+// runCacheDedupeJob is the /cache/dedupe job
+func runCacheDedupeJob(t *jobs.Task) (interface{}, error) {
+	result := runCacheDedupe(t.Context(), false, t.SetProgress)
+	if err := t.Context().Err(); err != nil {
+		return result, err
+	}
+
+	log.Infof("%s Dedupe job %s complete: %d groups, %d aliased, %d mismatched, %d failed, %d bytes saved",
+		logcolors.LogCache, t.ID(), result.TrackGroups, result.Aliased, result.ContentMismatch, result.Failed, result.BytesSaved)
+	return result, nil
+}
+
+// runCacheDedupe groups lyrics keys by upstream track ID and aliases duplicates to
+// the track's lyrics blob (see dedupeTrackGroup).
+// It stops between groups once ctx is cancelled; a re-run skips groups already aliased.
+func runCacheDedupe(ctx context.Context, dryRun bool, onProgress func(processed, total int)) *DedupeResult {
+	result := &DedupeResult{DryRun: dryRun}
+
+	// Stored sizes of lyrics entries (raw, as on disk)
+	sizes := make(map[string]int)
+	persistentCache.Range(func(key string, entry cache.CacheEntry) bool {
+		if strings.HasPrefix(key, "ttml_lyrics:") {
+			sizes[key] = len(entry.Value)
+		}
+		return true
+	})
+
+	// Group cache keys by track ID from metadata
+	byTrack := make(map[string][]string)
+	persistentCache.RangeBucket(metadataBucket, func(k, v []byte) bool {
+		key := string(k)
+		if _, cached := sizes[key]; !cached {
+			return true
+		}
+		raw, err := utils.DecompressString(string(v))
+		if err != nil {
+			raw = string(v)
+		}
+		var meta SongMetadata
+		if err := json.Unmarshal([]byte(raw), &meta); err != nil || meta.AppleTrackID == "" {
+			return true
+		}
+		byTrack[meta.AppleTrackID] = append(byTrack[meta.AppleTrackID], key)
+		return true
+	})
+
+	var trackIDs []string
+	for trackID, keys := range byTrack {
+		if len(keys) > 1 {
+			sort.Strings(keys)
+			trackIDs = append(trackIDs, trackID)
+		}
+	}
+	sort.Strings(trackIDs)
+	result.TrackGroups = len(trackIDs)
+
+	for i, trackID := range trackIDs {
+		keys := byTrack[trackID]
+		if ctx.Err() != nil {
+			break
+		}
+		for _, key := range keys {
+			result.BytesBefore += int64(sizes[key])
+		}
+		dedupeTrackGroup(trackID, keys, sizes, dryRun, result)
+		if onProgress != nil {
+			onProgress(i+1, len(trackIDs))
+		}
+	}
+
+	result.BytesSaved = result.BytesBefore - result.BytesAfter
+	return result
+}
+
+// dedupeTrackGroup points every key in a same-track group at the track's lyrics
+// blob (ttml_track:<id>), the layout setCachedLyricsForTrack writes, so a later
+// re-fetch or deletion of any one query key leaves the others resolving. When the
+// track has no blob yet, the largest entry's TTML becomes it. Keys whose TTML
+// differs from the blob's are left untouched.
+func dedupeTrackGroup(trackID string, keys []string, sizes map[string]int, dryRun bool, result *DedupeResult) {
+	entries := make(map[string]*CachedLyrics, len(keys))
+	sourceKey := ""
+	for _, key := range keys {
+		raw, ok := persistentCache.Get(key)
+		if !ok {
+			continue
+		}
+		var entry CachedLyrics
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			entry = CachedLyrics{TTML: raw} // Old plain-TTML format
+		}
+		entries[key] = &entry
+		if entry.AliasOf == "" && entry.TTML != "" && entry.TTML != NoLyricsSentinel &&
+			(sourceKey == "" || sizes[key] > sizes[sourceKey]) {
+			sourceKey = key
+		}
+	}
+
+	trackKey := trackLyricsKey(trackID)
+	ttml, blobSize := "", 0
+	if raw, ok := persistentCache.Get(trackKey); ok {
+		var blob CachedLyrics
+		if err := json.Unmarshal([]byte(raw), &blob); err == nil && blob.TTML != "" && blob.TTML != NoLyricsSentinel {
+			ttml, blobSize = blob.TTML, storedSize(raw)
+			result.BytesBefore += int64(blobSize)
+		}
+	}
+	if ttml == "" {
+		if sourceKey == "" {
+			for _, key := range keys {
+				result.BytesAfter += int64(sizes[key])
+			}
+			return
+		}
+		source := entries[sourceKey]
+		ttml = source.TTML
+		data, err := json.Marshal(CachedLyrics{
+			TTML:            ttml,
+			TrackDurationMs: source.TrackDurationMs,
+			Language:        source.Language,
+			IsRTL:           source.IsRTL,
+			ContentHash:     lyricsContentHash(ttml),
+			StoredAt:        cmp.Or(source.StoredAt, clk.Now().Unix()),
+		})
+		if err == nil && !dryRun {
+			err = persistentCache.Set(trackKey, string(data))
+		}
+		if err != nil {
+			log.Warnf("%s Failed to write track lyrics %s: %v", logcolors.LogCache, trackKey, err)
+			result.Failed++
+			for _, key := range keys {
+				result.BytesAfter += int64(sizes[key])
+			}
+			return
+		}
+		blobSize = storedSize(string(data))
+	}
+	result.BytesAfter += int64(blobSize)
+
+	for _, key := range keys {
+		entry, ok := entries[key]
+		if !ok {
+			continue
+		}
+		if entry.AliasOf == trackKey {
+			result.AlreadyAliased++
+			result.BytesAfter += int64(sizes[key])
+			continue
+		}
+
+		var write func(key, alias string) error
+		if entry.AliasOf != "" {
+			// An alias of another query key (earlier dedupe runs); repoint it unless
+			// that key now holds different lyrics. The alias has no content to trash.
+			if resolved, ok := resolveCacheAlias(key, entry); ok && resolved.TTML != ttml {
+				result.ContentMismatch++
+				result.BytesAfter += int64(sizes[key])
+				continue
+			}
+			write = func(key, alias string) error { return persistentCache.Set(key, alias) }
+		} else {
+			if entry.TTML != ttml {
+				result.ContentMismatch++
+				result.BytesAfter += int64(sizes[key])
+				continue
+			}
+			write = aliasTrashing
+		}
+
+		alias := CachedLyrics{
+			AliasOf:         trackKey,
+			TrackDurationMs: entry.TrackDurationMs,
+			Score:           entry.Score,
+			Language:        entry.Language,
+			IsRTL:           entry.IsRTL,
+			StoredAt:        entry.StoredAt,
+		}
+		data, err := json.Marshal(alias)
+		if err != nil {
+			result.Failed++
+			result.BytesAfter += int64(sizes[key])
+			continue
+		}
+		if !dryRun {
+			// A full copy goes to the trash first, so a bad dedupe can be undone
+			if err := write(key, string(data)); err != nil {
+				log.Warnf("%s Failed to alias %s -> %s: %v", logcolors.LogCache, key, trackKey, err)
+				result.Failed++
+				result.BytesAfter += int64(sizes[key])
+				continue
+			}
+		}
+		result.Aliased++
+		result.BytesAfter += int64(storedSize(string(data)))
+	}
+}
+
+// aliasTrashing replaces key with an alias entry, keeping the replaced entry in the
+// trash (when enabled). If the alias can't be written the entry is restored.
+func aliasTrashing(key, alias string) error {
+	if err := deleteCacheEntry(key, trashReasonDedupe); err != nil {
+		return err
+	}
+	if err := persistentCache.Set(key, alias); err != nil {
+		if trashRetention() > 0 {
+			persistentCache.RestoreFromTrash(key, false)
+		}
+		return err
+	}
+	return nil
+}
+
+// storedSize returns the size a value takes in the cache bucket (after compression, if enabled)
+func storedSize(value string) int {
+	if conf().FeatureFlags.CacheCompression {
+		if compressed, err := utils.CompressString(value); err == nil {
+			return len(compressed)
+		}
+	}
+	return len(value)
+}
+
+// getDedupeStatus returns the status of a deduplication job
+func getDedupeStatus(w http.ResponseWriter, r *http.Request) {
+	writeJobStatus(w, r, jobKindDedupe, nil)
+}
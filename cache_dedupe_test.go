@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunCacheDedupe_AliasesSameTrack(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	ttmlContent := "<tt><body><div>" + strings.Repeat("<p>Same lyrics</p>", 20) + "</div></body></tt>"
+	setCachedLyrics("ttml_lyrics:hello adele", ttmlContent, 295000, 0.9, "en", false)
+	setCachedLyrics("ttml_lyrics:hello adele 25", ttmlContent, 295000, 0.95, "en", false)
+	setCachedLyrics("ttml_lyrics:hello adele 295s", ttmlContent, 295000, 1.0, "en", false)
+	setCachedLyrics("ttml_lyrics:hello adele live", "<tt>different</tt>", 300000, 0.8, "en", false)
+	for _, key := range []string{"ttml_lyrics:hello adele", "ttml_lyrics:hello adele 25", "ttml_lyrics:hello adele 295s", "ttml_lyrics:hello adele live"} {
+		setSongMetadata(&SongMetadata{CacheKey: key, AppleTrackID: "123", TrackName: "Hello", ArtistName: "Adele"})
+	}
+	setCachedLyrics("ttml_lyrics:other song", ttmlContent, 1000, 1, "en", false)
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:other song", AppleTrackID: "456"})
+
+	// Dry run reports without writing
+	preview := runCacheDedupe(context.Background(), true, nil)
+	if preview.TrackGroups != 1 || preview.Aliased != 3 || preview.ContentMismatch != 1 {
+		t.Fatalf("Unexpected dry run result: %+v", preview)
+	}
+	raw, _ := persistentCache.Get("ttml_lyrics:hello adele 25")
+	var untouched CachedLyrics
+	json.Unmarshal([]byte(raw), &untouched)
+	if untouched.AliasOf != "" {
+		t.Fatal("Dry run must not write alias entries")
+	}
+
+	result := runCacheDedupe(context.Background(), false, nil)
+	if result.Aliased != 3 || result.Failed != 0 {
+		t.Fatalf("Unexpected dedupe result: %+v", result)
+	}
+	if result.BytesSaved <= 0 {
+		t.Errorf("Expected positive bytes saved, got %d", result.BytesSaved)
+	}
+
+	// Aliased keys point at the track blob, still resolve to the full lyrics and keep their own score
+	aliasCount := 0
+	for _, key := range []string{"ttml_lyrics:hello adele", "ttml_lyrics:hello adele 25", "ttml_lyrics:hello adele 295s"} {
+		raw, _ := persistentCache.Get(key)
+		var stored CachedLyrics
+		json.Unmarshal([]byte(raw), &stored)
+		if stored.AliasOf == trackLyricsKey("123") {
+			aliasCount++
+		}
+
+		cached, ok := getCachedLyrics(key)
+		if !ok || cached.TTML != ttmlContent {
+			t.Errorf("Expected %s to resolve to the canonical TTML", key)
+		}
+	}
+	if aliasCount != 3 {
+		t.Errorf("Expected 3 aliases of the track blob, got %d", aliasCount)
+	}
+
+	// Second run finds nothing new
+	again := runCacheDedupe(context.Background(), false, nil)
+	if again.Aliased != 0 || again.AlreadyAliased != 3 {
+		t.Errorf("Expected idempotent second run, got %+v", again)
+	}
+
+	// Re-fetching or deleting one query key leaves the others resolving
+	setCachedLyricsForTrack("ttml_lyrics:hello adele", "123", ttmlContent, 295000, 0.9, "en", false)
+	persistentCache.Delete("ttml_lyrics:hello adele 25")
+	if cached, ok := getCachedLyrics("ttml_lyrics:hello adele 295s"); !ok || cached.TTML != ttmlContent {
+		t.Error("Expected the remaining alias to still resolve")
+	}
+}
+
+func TestRunCacheDedupe_RepointsQueryKeyAliases(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	// An alias of a query key, as earlier dedupe runs wrote them
+	ttmlContent := "<tt><body><div><p>Same lyrics</p></div></body></tt>"
+	setCachedLyricsForTrack("ttml_lyrics:hello adele", "123", ttmlContent, 295000, 0.9, "en", false)
+	data, _ := json.Marshal(CachedLyrics{AliasOf: "ttml_lyrics:gone", Score: 0.7})
+	persistentCache.Set("ttml_lyrics:hello adele 25", string(data))
+	for _, key := range []string{"ttml_lyrics:hello adele", "ttml_lyrics:hello adele 25"} {
+		setSongMetadata(&SongMetadata{CacheKey: key, AppleTrackID: "123", TrackName: "Hello", ArtistName: "Adele"})
+	}
+
+	result := runCacheDedupe(context.Background(), false, nil)
+	if result.Aliased != 1 || result.AlreadyAliased != 1 {
+		t.Fatalf("Unexpected dedupe result: %+v", result)
+	}
+	cached, ok := getCachedLyrics("ttml_lyrics:hello adele 25")
+	if !ok || cached.TTML != ttmlContent || cached.Score != 0.7 {
+		t.Errorf("Expected the repointed alias to resolve with its own score, got %+v, %v", cached, ok)
+	}
+}
+
+func TestGetCachedLyrics_DanglingAliasIsMiss(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	data, _ := json.Marshal(CachedLyrics{AliasOf: "ttml_lyrics:gone"})
+	persistentCache.Set("ttml_lyrics:alias", string(data))
+
+	if _, ok := getCachedLyrics("ttml_lyrics:alias"); ok {
+		t.Error("Expected alias to a missing canonical key to be a cache miss")
+	}
+}
+
+func TestDedupeCacheHandler_DryRun(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/cache/dedupe?dry_run=true", nil)
+	dedupeCacheHandler(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var result DedupeResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !result.DryRun {
+		t.Error("Expected dry_run to be true")
+	}
+}
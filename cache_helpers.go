@@ -2,12 +2,17 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"lyrics-api-go/cache"
+	"lyrics-api-go/jobs"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/lyricsquery"
+	"lyrics-api-go/services/providers/ttml"
 	"net/http"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -21,13 +26,22 @@ func getCachedLyrics(key string) (*CachedLyrics, bool) {
 	if !ok {
 		return nil, false
 	}
+	return parseCachedLyrics(key, cached)
+}
 
+// parseCachedLyrics decodes a lyrics entry's value as read from the cache
+func parseCachedLyrics(key, cached string) (*CachedLyrics, bool) {
 	// Try to parse as JSON format
 	var cachedLyrics CachedLyrics
 	if err := json.Unmarshal([]byte(cached), &cachedLyrics); err == nil && cachedLyrics.TTML != "" {
 		return &cachedLyrics, true
 	}
 
+	// Alias entry (track lookups and /cache/dedupe) - resolve one hop to the canonical blob
+	if cachedLyrics.AliasOf != "" {
+		return resolveCacheAlias(key, &cachedLyrics)
+	}
+
 	// Fallback to old format (plain TTML string) - no metadata available
 	return &CachedLyrics{TTML: cached}, true
 }
@@ -66,7 +80,7 @@ func getCachedLyricsWithDurationTolerance(songName, artistName, albumName, durat
 	}
 
 	// Get delta from config (in ms), convert to seconds
-	deltaMs := conf.Configuration.DurationMatchDeltaMs
+	deltaMs := conf().Configuration.DurationMatchDeltaMs
 	deltaSec := deltaMs / 1000
 	if deltaSec < 1 {
 		deltaSec = 1 // Minimum 1 second tolerance
@@ -118,6 +132,101 @@ func getCachedLyricsWithDurationTolerance(songName, artistName, albumName, durat
 	return nil, exactKey, false
 }
 
+// lookupCachedLyrics finds cached lyrics for a query: by the exact key when the
+// request overrides PREFER_EXPLICIT or comes from a tenant, otherwise with duration
+// tolerance and the duration-less fallback
+func lookupCachedLyrics(songName, artistName, albumName, durationStr, exactKey string) (*CachedLyrics, string, bool) {
+	if exactKey != "" {
+		cached, ok := getCachedLyrics(exactKey)
+		return cached, exactKey, ok
+	}
+	cached, foundKey, ok := getCachedLyricsWithDurationTolerance(songName, artistName, albumName, durationStr)
+	if !ok {
+		cached, foundKey, ok = getDurationlessCachedLyrics(songName, artistName, albumName, durationStr)
+	}
+	return cached, foundKey, ok
+}
+
+// lookupNegativeCache is lookupCachedLyrics for the negative cache
+func lookupNegativeCache(songName, artistName, albumName, durationStr, exactKey string) (string, bool) {
+	if exactKey != "" {
+		return getNegativeCache(exactKey)
+	}
+	reason, _, found := getNegativeCacheWithDurationTolerance(songName, artistName, albumName, durationStr)
+	return reason, found
+}
+
+// durationToleranceMs is how far apart two track durations can be and still count as
+// the same edit (DURATION_MATCH_DELTA_MS, at least one second)
+func durationToleranceMs() int {
+	return max(conf().Configuration.DurationMatchDeltaMs, 1000)
+}
+
+// getDurationlessCachedLyrics answers a request that has a duration from the same
+// query cached without one, but only when the cached track's duration agrees. A
+// mismatch (or an unknown cached duration) means the entry may be a different edit,
+// so the caller refetches with duration filtering instead.
+func getDurationlessCachedLyrics(songName, artistName, albumName, durationStr string) (*CachedLyrics, string, bool) {
+	var durationSec int
+	if durationStr == "" {
+		return nil, "", false
+	}
+	if _, err := fmt.Sscanf(durationStr, "%d", &durationSec); err != nil {
+		return nil, "", false
+	}
+
+	key := buildNormalizedCacheKey(songName, artistName, albumName, "")
+	cached, ok := getCachedLyrics(key)
+	if !ok || cached.TrackDurationMs == 0 || cached.TTML == NoLyricsSentinel {
+		return nil, key, false
+	}
+	diff := cached.TrackDurationMs - durationSec*1000
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > durationToleranceMs() {
+		log.Infof("%s Entry cached without duration is %dms, request is %ss: refetching with duration",
+			logcolors.LogCacheLyrics, cached.TrackDurationMs, durationStr)
+		return nil, key, false
+	}
+	return cached, key, true
+}
+
+// splitDurationlessEntry runs after a refetch with duration. If the query's entry
+// cached without a duration turns out to be a different track (its duration
+// mismatches the one just fetched), it is also stored under a key scoped to its own
+// duration, so both edits stay reachable by duration. Existing keys are left alone.
+func splitDurationlessEntry(songName, artistName, albumName string, fetchedDurationMs int) {
+	key := buildNormalizedCacheKey(songName, artistName, albumName, "")
+	cached, ok := getCachedLyrics(key)
+	if !ok || cached.TrackDurationMs == 0 || fetchedDurationMs == 0 {
+		return
+	}
+	diff := cached.TrackDurationMs - fetchedDurationMs
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff <= durationToleranceMs() {
+		return
+	}
+
+	scopedKey := buildNormalizedCacheKey(songName, artistName, albumName, fmt.Sprintf("%d", cached.TrackDurationMs/1000))
+	if _, exists := persistentCache.Get(scopedKey); exists {
+		return
+	}
+	// Copy the stored value as-is: usually an alias of the track blob, so no TTML is duplicated
+	raw, ok := persistentCache.Get(key)
+	if !ok {
+		return
+	}
+	if err := persistentCache.Set(scopedKey, raw); err != nil {
+		log.Errorf("%s Error storing %s: %v", logcolors.LogCacheLyrics, scopedKey, err)
+		return
+	}
+	log.Infof("%s Entry cached without duration is a different edit (%dms vs %dms), also stored as %s",
+		logcolors.LogCacheLyrics, cached.TrackDurationMs, fetchedDurationMs, scopedKey)
+}
+
 // getNegativeCacheWithDurationTolerance checks negative cache with fuzzy duration matching.
 // Similar to getCachedLyricsWithDurationTolerance but for negative cache entries.
 func getNegativeCacheWithDurationTolerance(songName, artistName, albumName, durationStr string) (string, string, bool) {
@@ -149,7 +258,7 @@ func getNegativeCacheWithDurationTolerance(songName, artistName, albumName, dura
 	}
 
 	// Get delta from config (in ms), convert to seconds
-	deltaMs := conf.Configuration.DurationMatchDeltaMs
+	deltaMs := conf().Configuration.DurationMatchDeltaMs
 	deltaSec := deltaMs / 1000
 	if deltaSec < 1 {
 		deltaSec = 1
@@ -189,6 +298,7 @@ func setCachedLyrics(key, lyrics string, trackDurationMs int, score float64, lan
 		Score:           score,
 		Language:        language,
 		IsRTL:           isRTL,
+		StoredAt:        clk.Now().Unix(),
 	}
 	data, err := json.Marshal(cachedLyrics)
 	if err != nil {
@@ -196,8 +306,13 @@ func setCachedLyrics(key, lyrics string, trackDurationMs int, score float64, lan
 		return
 	}
 	if err := persistentCache.Set(key, string(data)); err != nil {
-		log.Errorf("%s Error setting cache value: %v", logcolors.LogCacheLyrics, err)
+		// Read-only mode (disk almost full) skips writes by design, see disk_monitor.go
+		if !errors.Is(err, cache.ErrReadOnly) {
+			log.Errorf("%s Error setting cache value: %v", logcolors.LogCacheLyrics, err)
+		}
+		return
 	}
+	indexCachedLyrics(key, lyrics)
 }
 
 // Negative cache operations
@@ -207,7 +322,7 @@ func setCachedLyrics(key, lyrics string, trackDurationMs int, score float64, lan
 // (re-checks are cheap: search only, no lyrics fetch needed).
 // Falls back to the default TTL when hasTimeSyncedLyrics was absent from the API response.
 func getNegativeCacheTTLSeconds(entry NegativeCacheEntry) int64 {
-	defaultTTL := int64(conf.Configuration.NegativeCacheTTLInDays * 24 * 60 * 60)
+	defaultTTL := int64(conf().Configuration.NegativeCacheTTLInDays * 24 * 60 * 60)
 
 	// Only use graduated TTL when hasTimeSyncedLyrics was present in the API response
 	if !entry.HasTimeSyncedLyricsKnown {
@@ -226,10 +341,10 @@ func getNegativeCacheTTLSeconds(entry NegativeCacheEntry) int64 {
 	// Compare calendar days, not raw elapsed duration. Truncating elapsed-hours to
 	// int days is time-of-day sensitive: e.g. "released 4 days ago" at 2am UTC
 	// yields 3 when floored, which drops the entry into the wrong tier.
-	now := time.Now().UTC()
+	now := clk.Now().UTC()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 	daysSinceRelease := int(today.Sub(rd).Hours() / 24)
-	threshold := conf.Configuration.NewSongThresholdDays
+	threshold := conf().Configuration.NewSongThresholdDays
 
 	if daysSinceRelease >= threshold {
 		return defaultTTL
@@ -265,9 +380,9 @@ func getNegativeCache(key string) (string, bool) {
 	// Check if entry has expired using graduated TTL
 	ttlSeconds := getNegativeCacheTTLSeconds(entry)
 	expirationTime := entry.Timestamp + ttlSeconds
-	if time.Now().Unix() > expirationTime {
+	if clk.Now().Unix() > expirationTime {
 		// Expired - delete and return not found
-		ageDays := (time.Now().Unix() - entry.Timestamp) / (24 * 60 * 60)
+		ageDays := (clk.Now().Unix() - entry.Timestamp) / (24 * 60 * 60)
 		log.Infof("%s TTL expired for key: %s (age: %dd, reason was: %s)", logcolors.LogCacheNegative, key, ageDays, entry.Reason)
 		persistentCache.Delete(negativeKey)
 		return "", false
@@ -281,7 +396,7 @@ func setNegativeCache(key, reason, releaseDate string, hasTimeSyncedLyricsKnown
 	negativeKey := "no_lyrics:" + key
 	entry := NegativeCacheEntry{
 		Reason:                   reason,
-		Timestamp:                time.Now().Unix(),
+		Timestamp:                clk.Now().Unix(),
 		ReleaseDate:              releaseDate,
 		HasTimeSyncedLyricsKnown: hasTimeSyncedLyricsKnown,
 	}
@@ -291,7 +406,10 @@ func setNegativeCache(key, reason, releaseDate string, hasTimeSyncedLyricsKnown
 		return
 	}
 	if err := persistentCache.Set(negativeKey, string(data)); err != nil {
-		log.Errorf("%s Error setting negative cache: %v", logcolors.LogCacheNegative, err)
+		if !errors.Is(err, cache.ErrReadOnly) {
+			log.Errorf("%s Error setting negative cache: %v", logcolors.LogCacheNegative, err)
+		}
+		return
 	}
 	log.Infof("%s Cached 'no lyrics' for key: %s (reason: %s)", logcolors.LogCacheNegative, key, reason)
 }
@@ -310,6 +428,10 @@ func shouldNegativeCache(err error) bool {
 		return false
 	}
 	errStr := err.Error()
+	// A response we couldn't read says nothing about the track
+	if strings.Contains(errStr, ttml.SchemaDriftPrefix) {
+		return false
+	}
 	// Permanent errors - cache these
 	permanentErrors := []string{
 		"no track found",           // "no track found for query:" (singular)
@@ -395,7 +517,7 @@ func findMatchingCacheKeys(songName, artistName, albumName, durationStr string)
 	if durationStr != "" {
 		var durationSec int
 		if _, err := fmt.Sscanf(durationStr, "%d", &durationSec); err == nil {
-			deltaMs := conf.Configuration.DurationMatchDeltaMs
+			deltaMs := conf().Configuration.DurationMatchDeltaMs
 			deltaSec := deltaMs / 1000
 			if deltaSec < 1 {
 				deltaSec = 1
@@ -416,22 +538,86 @@ func findMatchingCacheKeys(songName, artistName, albumName, durationStr string)
 	return keys
 }
 
+// findStaleFallback returns the first cached entry among the fallback keys of a
+// query, for serving stale lyrics while upstream is failing. All keys are read in
+// one transaction, since this runs after the upstream has already been slow.
+// Entries older than MAX_STALE_AGE_DAYS are skipped.
+func findStaleFallback(songName, artistName, albumName, durationStr, originalKey string) (*CachedLyrics, string, bool) {
+	keys := buildFallbackCacheKeys(songName, artistName, albumName, durationStr, originalKey)
+	if len(keys) == 0 {
+		return nil, "", false
+	}
+	values := persistentCache.GetMany(keys)
+	for _, key := range keys {
+		if value, found := values[key]; found {
+			if cached, ok := parseCachedLyrics(key, value); ok {
+				if maxAge := maxStaleAge(); maxAge > 0 && cached.StoredAt > 0 && cacheEntryAge(cached) > maxAge {
+					log.Debugf("%s Not serving %s as stale: stored %v ago, beyond MAX_STALE_AGE_DAYS",
+						logcolors.LogCacheLyrics, key, cacheEntryAge(cached).Round(time.Second))
+					continue
+				}
+				return cached, key, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// maxStaleAge is how old an entry may be to still be served as stale (0 = no limit)
+func maxStaleAge() time.Duration {
+	return time.Duration(conf().Configuration.MaxStaleAgeDays) * 24 * time.Hour
+}
+
+// cacheEntryAge is how long ago an entry was stored (0 when that isn't known)
+func cacheEntryAge(cached *CachedLyrics) time.Duration {
+	if cached.StoredAt <= 0 {
+		return 0
+	}
+	return clk.Now().Sub(time.Unix(cached.StoredAt, 0))
+}
+
+// staleLyricsBody is cachedLyricsBody for a STALE response: marked stale, with the
+// entry's age when it is known
+func staleLyricsBody(r *http.Request, cacheKey string, cached *CachedLyrics, durationStr string) map[string]interface{} {
+	body := cachedLyricsBody(r, cacheKey, cached, durationStr)
+	body["stale"] = true
+	if cached.StoredAt > 0 {
+		body["age_seconds"] = int64(cacheEntryAge(cached).Seconds())
+	}
+	return body
+}
+
 // buildFallbackCacheKeys returns a list of cache keys to try when the backend fails.
-// Keys are ordered from most specific to least specific, excluding the original key.
-// When duration is provided, fallback keys still include duration to maintain strict matching.
+// Keys are ordered from most specific to least specific, excluding the original key:
+// without album, then without duration, then without both, each followed by its
+// legacy-format key (the original key's legacy form is already tried on the cache
+// lookup before upstream). Dropping the duration is a last resort, so keys that keep it
+// come first.
 func buildFallbackCacheKeys(songName, artistName, albumName, durationStr, originalKey string) []string {
-	var keys []string
-
-	// Fallback: without album (if album was provided)
-	// Duration is preserved if it was in the original request
-	// Uses normalized format (no trailing spaces, lowercase)
+	type variant struct{ album, duration string }
+	var variants []variant
 	if albumName != "" {
-		normalizedNoAlbum := buildNormalizedCacheKey(songName, artistName, "", durationStr)
-		if normalizedNoAlbum != originalKey {
-			keys = append(keys, normalizedNoAlbum)
+		variants = append(variants, variant{"", durationStr})
+	}
+	if durationStr != "" {
+		variants = append(variants, variant{albumName, ""})
+		if albumName != "" {
+			variants = append(variants, variant{"", ""})
 		}
 	}
 
+	seen := map[string]bool{originalKey: true}
+	keys := []string{}
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, v := range variants {
+		add(buildNormalizedCacheKey(songName, artistName, v.album, v.duration))
+		add(buildLegacyCacheKey(songName, artistName, v.album, v.duration))
+	}
 	return keys
 }
 
@@ -465,6 +651,8 @@ func cacheHelp(w http.ResponseWriter, r *http.Request) {
 					"a, artist, artistName": "Artist name",
 					"al, album, albumName":  "Album name (optional)",
 					"d, duration":           "Duration in seconds (optional)",
+					"strip":                 "true to strip XML tags from the preview",
+					"lines":                 "Show the first N lyric lines (max 50) instead of a raw preview",
 				},
 				"response": "Shows normalized/legacy keys, whether found, TTML preview",
 			},
@@ -474,7 +662,9 @@ func cacheHelp(w http.ResponseWriter, r *http.Request) {
 				"auth":        "Authorization header required",
 				"description": "Get detailed info about a specific cache key",
 				"params": map[string]string{
-					"key": "The full cache key to inspect",
+					"key":   "The full cache key to inspect",
+					"strip": "true to strip XML tags from the preview",
+					"lines": "Show the first N lyric lines (max 50) instead of a raw preview",
 				},
 				"response": "Raw size, compression ratio, entry type, content preview",
 			},
@@ -491,10 +681,60 @@ func cacheHelp(w http.ResponseWriter, r *http.Request) {
 				"response": "List of matching keys with size and type info",
 			},
 			{
-				"path":        "/cache/backup",
+				"path":        "/cache/keys/delete",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Delete all keys matching a filter (async job). Preview with dry_run=true first to get the confirm token.",
+				"params": map[string]string{
+					"prefix":   "Filter keys by prefix (e.g., 'kugou_lyrics:')",
+					"contains": "Filter keys containing substring (case-insensitive)",
+					"dry_run":  "Set to 'true' to list matches and get the confirm token without deleting",
+					"confirm":  "Confirm token from the dry run (required to delete; single use, expires after 10 minutes, refused if the match count changed)",
+				},
+				"response": "Dry run: matched count, sample keys, confirm_token. Otherwise: job ID and status URL",
+			},
+			{
+				"path":        "/cache/keys/delete/status",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Check bulk delete job status",
+				"params": map[string]string{
+					"job_id": "Job ID (optional - omit to list all jobs)",
+				},
+				"response": "Job status, progress, and results",
+			},
+			{
+				"path":        "/jobs",
 				"method":      "GET",
 				"auth":        "Authorization header required",
-				"description": "Create a backup of the cache database",
+				"description": "List async admin jobs of every kind (migrate, analyze, dedupe, bulk_delete, verify, backfill, reindex), newest first",
+				"params": map[string]string{
+					"kind":   "Only jobs of this kind (optional)",
+					"status": "Only jobs in this state: pending, running, completed, failed, cancelled (optional)",
+				},
+				"response": "Jobs with params, progress, result and error",
+				"notes":    "Finished jobs are kept for JOB_RETENTION_HOURS (default 24), at most JOB_MAX_FINISHED (default 50).",
+			},
+			{
+				"path":        "/jobs/{id}",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Get one async admin job by ID",
+				"response":    "Job status, progress, and result",
+			},
+			{
+				"path":        "/jobs/{id}/cancel",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Cancel a pending or running job",
+				"response":    "202 with the job; 404 if unknown, 409 if already finished",
+				"notes":       "The job stops at its next check and keeps its partial result. Re-running the same operation resumes from its saved checkpoint.",
+			},
+			{
+				"path":        "/cache/backup",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Create a backup of the cache database (encrypted with BACKUP_ENCRYPTION_KEY when set)",
 				"response":    "Backup file path",
 			},
 			{
@@ -502,46 +742,192 @@ func cacheHelp(w http.ResponseWriter, r *http.Request) {
 				"method":      "GET",
 				"auth":        "Authorization header required",
 				"description": "List all available cache backups",
-				"response":    "Array of backup filenames",
+				"response":    "Backups with name, size, date and whether each is encrypted; encryption_enabled for new backups",
 			},
 			{
-				"path":        "/cache/restore",
+				"path":        "/cache/backups/diff",
 				"method":      "GET",
 				"auth":        "Authorization header required",
-				"description": "Restore cache from a backup",
+				"description": "Compare two snapshots (backups or live DB) before restoring",
+				"params": map[string]string{
+					"from":  "Backup filename (from /cache/backups) or 'live'",
+					"to":    "Backup filename or 'live' (default: live)",
+					"limit": "Max keys listed per category (default: 100, max: 1000)",
+				},
+				"response": "Added/removed/changed counts and sample keys. With from=<backup>&to=live, 'added' is what a restore would drop",
+			},
+			{
+				"path":        "/cache/restore",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Restore cache from a backup (encrypted backups are decrypted with the configured key)",
 				"params": map[string]string{
 					"backup": "Backup filename (from /cache/backups)",
 				},
 			},
 			{
 				"path":        "/cache/clear",
-				"method":      "GET",
+				"method":      "POST",
 				"auth":        "Authorization header required",
 				"description": "Clear the cache (creates automatic backup first)",
 				"response":    "Backup path of the cleared cache",
 			},
+			{
+				"path":        "/cache/clear/{provider}",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Clear one provider's entries (ttml, kugou, legacy) with their negative entries, or every negative entry (negative)",
+				"response":    "Number of keys deleted",
+				"notes":       "Provider clears go to the trash; negative drops the negative bucket outright.",
+			},
 			{
 				"path":        "/cache/migrate",
-				"method":      "GET",
+				"method":      "POST",
 				"auth":        "Authorization header required",
-				"description": "Migrate legacy cache keys to normalized format (async)",
+				"description": "Run pending versioned cache migrations in order (async)",
 				"params": map[string]string{
-					"dry_run":    "Preview changes without applying (default: false)",
+					"dry_run":    "Preview each pending migration without applying (default: false)",
 					"recompress": "Also recompress existing entries (default: false)",
 				},
 				"response": "Job ID for tracking progress",
-				"notes":    "Returns immediately. Use /cache/migrate/status to track progress.",
+				"notes":    "Returns immediately. Applied versions are recorded in the cache DB and not re-run. Use /cache/migrate/status to track progress.",
 			},
 			{
 				"path":        "/cache/migrate/status",
 				"method":      "GET",
 				"auth":        "Authorization header required",
-				"description": "Check migration job status",
+				"description": "Check migration job status; without job_id also lists registered migrations and whether each is applied",
 				"params": map[string]string{
 					"job_id": "Job ID from /cache/migrate (optional, lists all if omitted)",
 				},
 				"response": "Job status, progress percentage, results when complete",
 			},
+			{
+				"path":        "/cache/analyze",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Analyze cache contents (async): size and compression histograms, largest entries, legacy keys, near-duplicate keys",
+				"response":    "Job ID for tracking progress",
+				"notes":       "Returns immediately. Use /cache/analyze/status to fetch the report.",
+			},
+			{
+				"path":        "/cache/analyze/status",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Check analysis job status",
+				"params": map[string]string{
+					"job_id": "Job ID from /cache/analyze (optional, lists all if omitted)",
+				},
+				"response": "Job status, progress percentage, analysis report when complete",
+			},
+			{
+				"path":        "/cache/dedupe",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Turn entries cached under several keys for the same upstream track into aliases of the track's ttml_track: blob (async)",
+				"params": map[string]string{
+					"dry_run": "Preview changes without applying (default: false)",
+				},
+				"response": "Job ID for tracking progress (dry run returns the report directly)",
+				"notes":    "Track identity comes from stored metadata. Only identical TTML is aliased.",
+			},
+			{
+				"path":        "/cache/dedupe/status",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Check dedupe job status",
+				"params": map[string]string{
+					"job_id": "Job ID from /cache/dedupe (optional, lists all if omitted)",
+				},
+				"response": "Job status, progress percentage, space saved when complete",
+			},
+			{
+				"path":        "/cache/verify",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Start an async integrity scan of every cache entry (envelope, checksum, gzip, TTML structure, alias targets)",
+				"params": map[string]string{
+					"repair": "report (default), delete, or quarantine (move corrupt entries into the quarantine bucket)",
+				},
+				"response": "Job ID and status URL (202 Accepted)",
+				"notes":    "Set CACHE_VERIFY_ON_STARTUP to run the same job at startup. Legacy entries (no checksum, plain TTML, unknown codec) are counted but never repaired. Aliases whose target is missing or is itself an alias count as corrupt.",
+			},
+			{
+				"path":        "/cache/verify/status",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Check verify job status",
+				"params": map[string]string{
+					"job_id": "Job ID from /cache/verify (optional, lists all if omitted)",
+				},
+				"response": "Job status, progress, and healthy/legacy/corrupt counts when complete",
+			},
+			{
+				"path":        "/cache/backfill",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Start an async job that rewrites legacy plain-TTML lyrics entries with metadata: language and RTL detected from the TTML, duration from the TTML body or the key's duration suffix. No upstream calls.",
+				"params": map[string]string{
+					"dry_run": "true to only count the entries that would be backfilled",
+				},
+				"response": "Job ID and status URL (202 Accepted)",
+				"notes":    "Backfilled entries keep a zero score, as the original match score was never stored",
+			},
+			{
+				"path":        "/cache/backfill/status",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Check backfill job status",
+				"params": map[string]string{
+					"job_id": "Job ID from /cache/backfill (optional, lists all if omitted)",
+				},
+				"response": "Job status, progress, and legacy/backfilled counts when complete",
+			},
+			{
+				"path":        "/cache/quarantine",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "List corrupt entries quarantined by /cache/verify",
+				"params": map[string]string{
+					"limit": "Maximum entries to return (default 100)",
+					"raw":   "true to include the stored bytes of each entry",
+				},
+				"response": "Entries with key, reason, quarantine time and size",
+			},
+			{
+				"path":        "/cache/trash",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "List entries removed by bulk delete, provider clear, migrations, dedupe or track invalidation that can still be restored",
+				"params": map[string]string{
+					"prefix": "Only keys starting with this prefix (optional)",
+					"limit":  "Maximum entries to return (default 100)",
+				},
+				"response": "Entries with key, reason, deletion time, expiry and size",
+			},
+			{
+				"path":        "/cache/trash/restore",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Move trashed entries back into the cache",
+				"params": map[string]string{
+					"key":       "Restore one entry",
+					"prefix":    "Restore every trashed entry under the prefix (instead of key)",
+					"overwrite": "true to replace entries that have been cached again since",
+				},
+				"response": "Restored count, skipped keys (live entry exists) and failures",
+			},
+			{
+				"path":        "/cache/trash/purge",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Permanently delete trashed entries. Expired trash is also purged hourly",
+				"params": map[string]string{
+					"key": "Purge one entry (optional)",
+					"all": "true to empty the trash; otherwise only entries past TRASH_RETENTION_HOURS go",
+				},
+				"response": "Number of purged entries",
+			},
 			{
 				"path":        "/cache/dump",
 				"method":      "GET",
@@ -550,16 +936,285 @@ func cacheHelp(w http.ResponseWriter, r *http.Request) {
 				"response":    "Binary file (application/octet-stream)",
 				"notes":       "Uses BoltDB transaction snapshot — safe to call while the server is running",
 			},
+			{
+				"path":        "/cache/search",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Find cached tracks by lyric text",
+				"params": map[string]string{
+					"q":     "Words or phrase to find (common words like 'the' are ignored)",
+					"limit": "Max tracks (default: 20, max: 100)",
+				},
+				"response": "Matching keys with song/artist (when metadata exists) and up to 3 matching lines; tracks with the exact phrase on one line come first",
+				"notes":    "Entries are indexed as they are cached. Run POST /cache/search/reindex once to index older entries.",
+			},
+			{
+				"path":        "/cache/search/reindex",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Rebuild the lyrics search index from every cached entry (async)",
+				"response":    "Job ID for tracking progress (202), 409 if already running",
+			},
+			{
+				"path":        "/cache/search/reindex/status",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Check reindex job status",
+				"params": map[string]string{
+					"job_id": "Job ID from /cache/search/reindex (optional, lists all if omitted)",
+				},
+				"response": "Job status, progress percentage, entries indexed when complete",
+			},
+			{
+				"path":        "/audit",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Append-only log of admin and destructive operations (clear, restore, migrate, breaker reset, overrides, account disable)",
+				"params": map[string]string{
+					"action": "Exact action or dotted prefix (e.g. 'cache' matches 'cache.clear')",
+					"role":   "admin, api_key or system",
+					"ip":     "Remote IP",
+					"since":  "Unix timestamp (inclusive)",
+					"until":  "Unix timestamp (inclusive)",
+					"limit":  "Max entries (default: 100, max: 1000)",
+				},
+				"response": "Entries newest first with timestamp, action, role, remote IP, params and response status",
+				"notes":    "Stored in the stats DB so it survives cache clears and restores",
+			},
+			{
+				"path":        "/selftest",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Run the canary track through search, lyrics fetch, parse and checksum compare, bypassing the cache. Reports per-stage timing and errors.",
+				"params": map[string]string{
+					"s": "Song name (optional, overrides SELFTEST_SONG; skips the checksum compare)",
+					"a": "Artist name (optional, overrides SELFTEST_ARTIST)",
+				},
+				"response": "200 when every stage passes, 503 otherwise, 409 if a run is in progress",
+				"notes":    "Set SELFTEST_CHECKSUM to the checksum of a good run to detect content drift",
+			},
+			{
+				"path":        "/diagnose",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Inspect the running service (accounts, circuit breaker, bearer token, cache writes and corruption, free disk space, missing settings) and list the problems found with the endpoint or setting that fixes each",
+				"response":    "JSON with status (ok or the most urgent severity), problems [{severity, check, message, fix}] ordered critical, warning, info, and checked_at",
+			},
+			{
+				"path":        "/cache/track",
+				"method":      "GET, DELETE",
+				"auth":        "Authorization header required",
+				"description": "Inspect (GET) or invalidate (DELETE) the lyrics blob stored for an Apple Music track ID. DELETE also removes every query key whose metadata points at the track.",
+				"params": map[string]string{
+					"id": "Apple Music track ID (required)",
+				},
+				"notes": "Query keys are aliases of ttml_track:{id}, so different phrasings of a song share one blob",
+			},
+			{
+				"path":        "/cache/lyrics-changes",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Changes found when a refresh or re-fetch stored different lyrics for an already cached track, newest first, with the old and new content hash, the new revision and a diff summary (lines added and removed, timing changes, the first changed line)",
+				"params": map[string]string{
+					"track": "Only this Apple track ID (optional)",
+					"limit": "Maximum changes to return (default: 100)",
+				},
+				"notes": "Each change bumps lyricsRevision in the metadata of the track's query keys. The last 5000 changes are kept. NOTIFY_LYRICS_CHANGES=true also sends a lyrics_changed event.",
+			},
+			{
+				"path":        "/cache/video-alias",
+				"method":      "GET, DELETE",
+				"auth":        "Authorization header required",
+				"description": "Inspect (GET) or remove (DELETE) the entry a YouTube video ID resolved to. /getLyrics?v= serves that entry directly, skipping fuzzy matching",
+				"params": map[string]string{
+					"v": "YouTube video ID (required)",
+				},
+				"notes": "The first successful lookup sets the alias and later ones never overwrite it; DELETE it when that match was wrong",
+			},
+			{
+				"path":        "/cache/learned",
+				"method":      "GET, DELETE",
+				"auth":        "Authorization header required",
+				"description": "Learned aliases: queries whose search matched a track with at least LEARNED_ALIAS_MIN_SCORE. A cache miss for a learned query fetches that track's lyrics without searching. GET lists them (most used first), DELETE prunes",
+				"params": map[string]string{
+					"contains":    "GET: only queries containing this text",
+					"track_id":    "GET: only aliases to this track; DELETE: remove every alias to it",
+					"limit":       "GET: maximum aliases to return (default 100)",
+					"key":         "DELETE: remove one alias",
+					"unused_days": "DELETE: remove aliases not used for this many days",
+				},
+			},
+			{
+				"path":        "/accounts/usage",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Per-account upstream requests, 429s, errors, retries, success rate and share of traffic, with an imbalance score (0 = even) and rebalance suggestions",
+				"params": map[string]string{
+					"window": "1h, 6h, 24h (default) or 7d",
+				},
+			},
+			{
+				"path":        "/log-level",
+				"method":      "GET, PUT",
+				"auth":        "Authorization header required",
+				"description": "Show (GET) or change (PUT) log levels at runtime until restart. Components without an override follow the global level.",
+				"params": map[string]string{
+					"level":     "PUT: trace, debug, info, warn or error; 'reset' drops a component override",
+					"component": "PUT: parser, http or cache (omit for the global level)",
+				},
+			},
+			{
+				"path":        "/config/rate-limits",
+				"method":      "GET, PATCH, DELETE",
+				"auth":        "Authorization header required",
+				"description": "Show (GET) or change (PATCH) the rate limit tiers without a restart. PATCH takes a JSON body with any of normal_per_second, normal_burst, cached_per_second and cached_burst (1-1000 per second, 1-10000 burst); the override is stored and survives restarts until DELETE restores the configured values.",
+			},
+			{
+				"path":        "/debug/gc",
+				"method":      "GET, POST",
+				"auth":        "Authorization header required",
+				"description": "Heap stats, RSS and goroutine count. POST forces a GC, returns freed memory to the OS and reports the heap before and after.",
+			},
+			{
+				"path":        "/errors",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "The last REPLAY_CAPTURE_SIZE failed /getLyrics lookups, newest first, with query, error, error class, account used, circuit breaker state and timestamps, plus a count per class",
+				"params": map[string]string{
+					"class":   "Only these classes, comma-separated: not_found, no_accounts, circuit_open, rate_limited, auth, schema_drift, timeout, upstream (optional)",
+					"account": "Only lookups that used this account (optional)",
+					"limit":   "Maximum entries to return (default: all)",
+					"trace":   "true to include each lookup's trace (default: false)",
+				},
+				"notes": "Kept in memory, or across restarts with PERSIST_FAILED_LOOKUPS=true. POST /debug/replay?key=... runs one again.",
+			},
+			{
+				"path":        "/debug/replay",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "The last REPLAY_CAPTURE_SIZE /getLyrics requests whose upstream lookup failed, newest first, with their params, status, error and a trace of the lookup (account, search, candidate match, lyrics fetch)",
+				"params": map[string]string{
+					"key": "Only this captured lookup (optional)",
+				},
+			},
+			{
+				"path":        "/debug/replay",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Run a captured lookup again, skipping the cache, with a verbose trace that scores every search candidate",
+				"params": map[string]string{
+					"key": "Captured lookup as listed by GET /debug/replay (required)",
+				},
+				"response": "The original capture and the replay's status, cache status, matched track, error and trace",
+				"notes":    "Captures are kept in memory and lost on restart. Replays are not captured again.",
+			},
+			{
+				"path":        "/debug/pprof/",
+				"method":      "GET",
+				"auth":        "Authorization header required (closed when CACHE_ACCESS_TOKEN is unset)",
+				"description": "net/http/pprof: heap, allocs, goroutine, profile, trace, ... and /debug/vars (expvar)",
+				"notes":       "Fetch with the header (curl -H 'Authorization: ...' .../debug/pprof/heap > heap.pb.gz) and open with go tool pprof. PPROF_LISTEN_ADDR serves the same without auth on a local port.",
+			},
+			{
+				"path":        "/stats/export",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Anonymized usage dataset for offline coverage analysis: request counts per day, normalized query, provider, cache status and latency bucket. No IPs or user agents.",
+				"params": map[string]string{
+					"format":    "json (default) or csv",
+					"from":      "First day, YYYY-MM-DD (default: 6 days before 'to')",
+					"to":        "Last day, YYYY-MM-DD (default: today, UTC)",
+					"min_count": "Drop rows with fewer requests (default: 1)",
+				},
+				"notes": "Rows older than USAGE_RETENTION_DAYS are pruned",
+			},
+			{
+				"path":        "/stats/bandwidth",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Daily bandwidth rollup: requests and response bytes per day, endpoint (route template), API key label and cache status",
+				"params": map[string]string{
+					"from": "First day, YYYY-MM-DD (default: 6 days before 'to')",
+					"to":   "Last day, YYYY-MM-DD (default: today, UTC)",
+				},
+				"notes": "API keys are labeled, never listed: tenant:<name> for API_KEY_TENANTS keys, api_key, anonymous or invalid. Totals since the last reset are under bandwidth in /stats. Rows older than USAGE_RETENTION_DAYS are pruned",
+			},
+			{
+				"path":        "/stats/duration",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Histograms of |requested - matched| duration for successful matches and of the closest candidate's delta when the duration filter rejected every result, per provider",
+				"params": map[string]string{
+					"provider": "Only this provider (optional)",
+				},
+				"notes": "Use it to choose DURATION_MATCH_DELTA_MS: a rejection bucket's cumulative count is how many failures a delta up to its le_ms would have accepted. Counters reset on restart.",
+			},
+			{
+				"path":        "/stats/parse-warnings",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "Tracks whose fetched TTML could only be partly parsed (malformed paragraphs, bad timings), with warning counts per reason and in total",
+				"params": map[string]string{
+					"track": "Only this Apple track ID (optional)",
+					"limit": "Maximum tracks to return (default: 100), most recently seen first",
+				},
+				"notes": "format=lines, client=v2 and client=overlay responses for these tracks carry the lines that parsed plus a warnings array. Counters reset on restart; at most 1000 tracks are kept.",
+			},
+			{
+				"path":        "/stats/reset",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Archive the current /stats snapshot, then zero the counters to start a new measurement window",
+				"params": map[string]string{
+					"note": "Text stored with the archive, e.g. the config change being measured (optional)",
+				},
+				"response": "The archive ID, window start and archive time",
+				"notes":    "The usage and lookup datasets are not reset. Archives are kept for STATS_ARCHIVE_RETENTION_DAYS (default: 365).",
+			},
+			{
+				"path":        "/stats/salvage",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "List stats DBs that were corrupt at startup and moved aside to <STATS_DB_PATH>.corrupt-<timestamp>",
+				"response":    "File names with size and the time they were moved",
+			},
+			{
+				"path":        "/stats/salvage",
+				"method":      "POST",
+				"auth":        "Authorization header required",
+				"description": "Add the counters still readable from a moved-aside stats DB to the live stats",
+				"params": map[string]string{
+					"file": "File name as listed by GET /stats/salvage (optional when only one is listed)",
+				},
+				"response": "How the record was read (bolt or a raw scan of the file), its last save time and the totals added",
+				"notes":    "The file is renamed with a .salvaged suffix so it can't be added twice. Only the /stats counters are salvaged; audit, usage and bandwidth history in the file are not.",
+			},
+			{
+				"path":        "/stats/archives",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "List archived stats windows, newest first",
+				"response":    "Archive IDs with window start, archive time and note",
+			},
+			{
+				"path":        "/stats/archives/{id}",
+				"method":      "GET",
+				"auth":        "Authorization header required",
+				"description": "An archived stats window with its /stats snapshot",
+				"response":    "Archive info plus the snapshot as /stats returned it at the reset",
+			},
 		},
 		"cache_key_format": map[string]string{
 			"lyrics":   "ttml_lyrics:{song} {artist} [{album}] [{duration}s]",
 			"negative": "no_lyrics:ttml_lyrics:{song} {artist} ...",
+			"track":    "ttml_track:{apple_track_id}",
 		},
 		"notes": []string{
 			"All keys are normalized to lowercase with trimmed whitespace",
 			"Lyrics cache has no TTL - entries persist until manually cleared",
 			"Negative cache (no lyrics found) expires after 7 days by default",
 			"Cache uses gzip compression with BestCompression level",
+			"Destructive endpoints (POST/DELETE) accept an Idempotency-Key header: a retry with the same key within 24h gets the stored response (Idempotent-Replayed: true) instead of running again",
 		},
 	}
 
@@ -569,17 +1224,23 @@ func cacheHelp(w http.ResponseWriter, r *http.Request) {
 
 // cacheLookup checks if a song is cached and returns cache key info
 func cacheLookup(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	songName := r.URL.Query().Get("s") + r.URL.Query().Get("song") + r.URL.Query().Get("songName")
-	artistName := r.URL.Query().Get("a") + r.URL.Query().Get("artist") + r.URL.Query().Get("artistName")
-	albumName := r.URL.Query().Get("al") + r.URL.Query().Get("album") + r.URL.Query().Get("albumName")
-	durationStr := r.URL.Query().Get("d") + r.URL.Query().Get("duration")
+	q, err := lyricsquery.Parse(r.URL.Query())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	songName, artistName, albumName, durationStr := q.Song, q.Artist, q.Album, q.Duration
 
-	if songName == "" && artistName == "" {
+	if !q.HasTrack() {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -590,6 +1251,7 @@ func cacheLookup(w http.ResponseWriter, r *http.Request) {
 
 	normalizedKey := buildNormalizedCacheKey(songName, artistName, albumName, durationStr)
 	legacyKey := buildLegacyCacheKey(songName, artistName, albumName, durationStr)
+	previewOpts := parsePreviewOptions(r)
 
 	result := map[string]interface{}{
 		"query": map[string]string{
@@ -612,7 +1274,7 @@ func cacheLookup(w http.ResponseWriter, r *http.Request) {
 		result["language"] = cached.Language
 		result["isRTL"] = cached.IsRTL
 		result["ttml_length"] = len(cached.TTML)
-		result["ttml_preview"] = truncateString(cached.TTML, 200)
+		addTTMLPreview(result, cached.TTML, 200, previewOpts)
 	} else if cached, ok := getCachedLyrics(legacyKey); ok {
 		result["found"] = true
 		result["found_in"] = "legacy"
@@ -621,7 +1283,7 @@ func cacheLookup(w http.ResponseWriter, r *http.Request) {
 		result["language"] = cached.Language
 		result["isRTL"] = cached.IsRTL
 		result["ttml_length"] = len(cached.TTML)
-		result["ttml_preview"] = truncateString(cached.TTML, 200)
+		addTTMLPreview(result, cached.TTML, 200, previewOpts)
 		result["note"] = "Found in legacy key - run /cache/migrate to normalize"
 	} else {
 		result["found"] = false
@@ -642,7 +1304,7 @@ func cacheLookup(w http.ResponseWriter, r *http.Request) {
 
 // cacheDebug returns detailed info about a specific cache key
 func cacheDebug(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -657,6 +1319,7 @@ func cacheDebug(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	previewOpts := parsePreviewOptions(r)
 	result := map[string]interface{}{
 		"key": key,
 	}
@@ -697,7 +1360,11 @@ func cacheDebug(w http.ResponseWriter, r *http.Request) {
 			result["type"] = "lyrics"
 			result["track_duration_ms"] = cachedLyrics.TrackDurationMs
 			result["ttml_length"] = len(cachedLyrics.TTML)
-			result["ttml_preview"] = truncateString(cachedLyrics.TTML, 300)
+			addTTMLPreview(result, cachedLyrics.TTML, 300, previewOpts)
+		} else if err == nil && cachedLyrics.AliasOf != "" {
+			result["type"] = "alias"
+			result["alias_of"] = cachedLyrics.AliasOf
+			result["track_duration_ms"] = cachedLyrics.TrackDurationMs
 		} else if strings.HasPrefix(key, "no_lyrics:") {
 			// Try to parse as negative cache
 			var negEntry NegativeCacheEntry
@@ -719,7 +1386,7 @@ func cacheDebug(w http.ResponseWriter, r *http.Request) {
 
 // cacheKeys lists cache keys matching a pattern
 func cacheKeys(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -743,13 +1410,7 @@ func cacheKeys(w http.ResponseWriter, r *http.Request) {
 	persistentCache.Range(func(key string, entry cache.CacheEntry) bool {
 		total++
 
-		// Filter by prefix
-		if prefix != "" && !strings.HasPrefix(key, prefix) {
-			return true
-		}
-
-		// Filter by contains
-		if contains != "" && !strings.Contains(strings.ToLower(key), strings.ToLower(contains)) {
+		if !keyMatchesFilter(key, prefix, contains) {
 			return true
 		}
 
@@ -778,7 +1439,7 @@ func cacheKeys(w http.ResponseWriter, r *http.Request) {
 // cacheDump streams the raw BoltDB database file as a consistent snapshot.
 // Used by external services (e.g., reprise-api) to get a copy of the cache.
 func cacheDump(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -794,32 +1455,38 @@ func cacheDump(w http.ResponseWriter, r *http.Request) {
 	log.Infof("%s Cache dump streamed: %d bytes", logcolors.LogCache, n)
 }
 
-// truncateString truncates a string to maxLen and adds "..." if truncated
+// truncateString truncates a string to maxLen runes (not bytes, so multi-byte
+// characters are never split) and adds "..." if truncated. Invalid UTF-8 is replaced.
 func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "\uFFFD")
+	}
+	if utf8.RuneCountInString(s) <= maxLen {
 		return s
 	}
-	return s[:maxLen] + "..."
+
+	count := 0
+	for i := range s {
+		if count == maxLen {
+			return s[:i] + "..."
+		}
+		count++
+	}
+	return s
 }
 
 // Migration handler
 
-// generateJobID creates a unique job ID
-func generateJobID() string {
-	return fmt.Sprintf("mig_%d", time.Now().UnixNano())
-}
-
-// migrateCache migrates legacy cache keys to the new normalized format and re-compresses data.
-// Legacy format: "ttml_lyrics:{song} {artist} {album}" with trailing space when album is empty
-// New format: "ttml_lyrics:{song} {artist}" (lowercase, trimmed, no trailing spaces)
+// migrateCache runs pending cache migrations (see cacheMigrations) in version order.
+// Each completed migration is recorded in the meta bucket and skipped on later runs.
 //
 // Query params:
-//   - recompress=true: Also re-compress entries that don't need key migration (optimizes storage)
+//   - recompress=true: Also re-compress every entry with the current settings (optimizes storage)
 //   - dry_run=true: Preview changes without applying them (runs synchronously)
 //
 // Returns immediately with a job ID. Use /cache/migrate/status?job_id=xxx to check progress.
 func migrateCache(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -827,286 +1494,69 @@ func migrateCache(w http.ResponseWriter, r *http.Request) {
 	recompress := r.URL.Query().Get("recompress") == "true"
 	dryRun := r.URL.Query().Get("dry_run") == "true"
 
+	migrations := pendingMigrations()
+	if recompress {
+		migrations = append(migrations, recompressMigration)
+	}
+
 	// Dry run is synchronous (fast, just counts keys)
 	if dryRun {
-		runMigrationDryRun(w)
+		runMigrationDryRun(w, migrations)
 		return
 	}
 
-	// Check if a migration is already running
-	migrationJobs.RLock()
-	for _, job := range migrationJobs.jobs {
-		if job.Status == JobStatusRunning || job.Status == JobStatusPending {
-			migrationJobs.RUnlock()
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"error":  "A migration is already in progress",
-				"job_id": job.ID,
-			})
-			return
-		}
+	if len(migrations) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":    "No pending migrations",
+			"migrations": migrationStatusList(),
+		})
+		return
 	}
-	migrationJobs.RUnlock()
 
-	// Create new job
-	job := &MigrationJob{
-		ID:         generateJobID(),
-		Status:     JobStatusPending,
-		StartedAt:  time.Now().Unix(),
-		Recompress: recompress,
-		Progress:   MigrationProgress{},
+	names := make([]string, 0, len(migrations))
+	for _, m := range migrations {
+		names = append(names, m.Name)
 	}
-
-	// Store job
-	migrationJobs.Lock()
-	migrationJobs.jobs[job.ID] = job
-	migrationJobs.Unlock()
-
-	// Start migration in background
-	go runMigrationAsync(job)
-
-	log.Infof("%s Started async cache migration job %s (recompress=%v)", logcolors.LogCache, job.ID, recompress)
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":    "Migration started",
-		"job_id":     job.ID,
-		"status_url": fmt.Sprintf("/cache/migrate/status?job_id=%s", job.ID),
+	params := map[string]interface{}{
+		"recompress": recompress,
+		"migrations": names,
+	}
+	job, ok := startJob(w, r, jobKindMigrate, params, "/cache/migrate/status", "Migration started", func(t *jobs.Task) (interface{}, error) {
+		return runMigrations(t, migrations)
 	})
+	if ok {
+		log.Infof("%s Started async cache migration job %s (%s)", logcolors.LogCache, job.ID, strings.Join(names, ", "))
+	}
 }
 
-// runMigrationDryRun performs a dry run synchronously
-func runMigrationDryRun(w http.ResponseWriter) {
-	var skipped int
-	keysToDelete := make(map[string]bool)
-	keysToMigrate := make(map[string]string)
-	keysToRecompress := []string{}
-
-	persistentCache.Range(func(key string, entry cache.CacheEntry) bool {
-		if !strings.HasPrefix(key, "ttml_lyrics:") {
-			skipped++
-			return true
+// runMigrationDryRun reports what each migration would do, synchronously
+func runMigrationDryRun(w http.ResponseWriter, migrations []*cacheMigration) {
+	plans := make([]map[string]interface{}, 0, len(migrations))
+	for _, m := range migrations {
+		plan, err := m.DryRun()
+		if err != nil {
+			plan = map[string]interface{}{"error": err.Error()}
 		}
-
-		query := strings.TrimPrefix(key, "ttml_lyrics:")
-		normalizedQuery := strings.ToLower(strings.TrimSpace(query))
-		for strings.Contains(normalizedQuery, "  ") {
-			normalizedQuery = strings.ReplaceAll(normalizedQuery, "  ", " ")
-		}
-		normalizedKey := "ttml_lyrics:" + normalizedQuery
-
-		if normalizedKey != key {
-			if _, exists := persistentCache.Get(normalizedKey); !exists {
-				keysToMigrate[normalizedKey] = key
-			}
-			keysToDelete[key] = true
-		} else {
-			keysToRecompress = append(keysToRecompress, key)
+		plan["name"] = m.Name
+		if m.Version > 0 {
+			plan["version"] = m.Version
 		}
-		return true
-	})
+		plans = append(plans, plan)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":            "Dry run - no changes made",
-		"dry_run":            true,
-		"keys_to_migrate":    len(keysToMigrate),
-		"keys_to_delete":     len(keysToDelete),
-		"keys_to_recompress": len(keysToRecompress),
-		"skipped":            skipped,
-	})
-}
-
-// runMigrationAsync performs the actual migration in the background
-func runMigrationAsync(job *MigrationJob) {
-	// Update status to running
-	migrationJobs.Lock()
-	job.Status = JobStatusRunning
-	migrationJobs.Unlock()
-
-	defer func() {
-		if r := recover(); r != nil {
-			migrationJobs.Lock()
-			job.Status = JobStatusFailed
-			job.Error = fmt.Sprintf("panic: %v", r)
-			job.CompletedAt = time.Now().Unix()
-			migrationJobs.Unlock()
-			log.Errorf("%s Migration job %s panicked: %v", logcolors.LogCache, job.ID, r)
-		}
-	}()
-
-	var migrated, recompressed, skipped, failed int
-	var totalSavings int64
-	var migratedKeys []string
-	keysToDelete := make(map[string]bool)
-	keysToMigrate := make(map[string]string)
-	keysToRecompress := []string{}
-
-	// First pass: identify keys
-	var totalKeys int
-	persistentCache.Range(func(key string, entry cache.CacheEntry) bool {
-		totalKeys++
-		if !strings.HasPrefix(key, "ttml_lyrics:") {
-			skipped++
-			return true
-		}
-
-		query := strings.TrimPrefix(key, "ttml_lyrics:")
-		normalizedQuery := strings.ToLower(strings.TrimSpace(query))
-		for strings.Contains(normalizedQuery, "  ") {
-			normalizedQuery = strings.ReplaceAll(normalizedQuery, "  ", " ")
-		}
-		normalizedKey := "ttml_lyrics:" + normalizedQuery
-
-		if normalizedKey != key {
-			if _, exists := persistentCache.Get(normalizedKey); !exists {
-				keysToMigrate[normalizedKey] = key
-			}
-			keysToDelete[key] = true
-		} else if job.Recompress {
-			keysToRecompress = append(keysToRecompress, key)
-		}
-		return true
+		"message":    "Dry run - no changes made",
+		"dry_run":    true,
+		"migrations": plans,
 	})
-
-	// Calculate total work
-	totalWork := len(keysToMigrate) + len(keysToRecompress) + len(keysToDelete)
-	processedWork := 0
-
-	updateProgress := func() {
-		migrationJobs.Lock()
-		job.Progress.TotalKeys = totalWork
-		job.Progress.ProcessedKeys = processedWork
-		if totalWork > 0 {
-			job.Progress.Percent = (processedWork * 100) / totalWork
-		}
-		migrationJobs.Unlock()
-	}
-
-	updateProgress()
-
-	// Second pass: migrate keys
-	for normalizedKey, legacyKey := range keysToMigrate {
-		if value, ok := persistentCache.Get(legacyKey); ok {
-			if err := persistentCache.Set(normalizedKey, value); err != nil {
-				log.Warnf("%s Failed to migrate key %s -> %s: %v", logcolors.LogCache, legacyKey, normalizedKey, err)
-				failed++
-			} else {
-				migratedKeys = append(migratedKeys, fmt.Sprintf("%s -> %s", legacyKey, normalizedKey))
-				migrated++
-			}
-		}
-		processedWork++
-		updateProgress()
-	}
-
-	// Third pass: re-compress
-	if job.Recompress {
-		for _, key := range keysToRecompress {
-			if value, ok := persistentCache.Get(key); ok {
-				originalSize := 0
-				persistentCache.Range(func(k string, entry cache.CacheEntry) bool {
-					if k == key {
-						originalSize = len(entry.Value)
-						return false
-					}
-					return true
-				})
-
-				if err := persistentCache.Set(key, value); err != nil {
-					log.Warnf("%s Failed to recompress key %s: %v", logcolors.LogCache, key, err)
-					failed++
-				} else {
-					newSize := 0
-					persistentCache.Range(func(k string, entry cache.CacheEntry) bool {
-						if k == key {
-							newSize = len(entry.Value)
-							return false
-						}
-						return true
-					})
-					savings := originalSize - newSize
-					if savings > 0 {
-						totalSavings += int64(savings)
-						recompressed++
-					}
-				}
-			}
-			processedWork++
-			updateProgress()
-		}
-	}
-
-	// Fourth pass: delete legacy keys
-	deleted := 0
-	for legacyKey := range keysToDelete {
-		if err := persistentCache.Delete(legacyKey); err != nil {
-			log.Warnf("%s Failed to delete legacy key %s: %v", logcolors.LogCache, legacyKey, err)
-		} else {
-			deleted++
-		}
-		processedWork++
-		updateProgress()
-	}
-
-	// Store results
-	migrationJobs.Lock()
-	job.Status = JobStatusCompleted
-	job.CompletedAt = time.Now().Unix()
-	job.Result = &MigrationResult{
-		Migrated:     migrated,
-		Recompressed: recompressed,
-		Deleted:      deleted,
-		Skipped:      skipped,
-		Failed:       failed,
-		BytesSaved:   totalSavings,
-		MigratedKeys: migratedKeys,
-	}
-	migrationJobs.Unlock()
-
-	log.Infof("%s Migration job %s complete: %d migrated, %d recompressed, %d deleted, %d skipped, %d failed, %d bytes saved",
-		logcolors.LogCache, job.ID, migrated, recompressed, deleted, skipped, failed, totalSavings)
 }
 
-// getMigrationStatus returns the status of a migration job
+// getMigrationStatus returns the status of a migration job. Without job_id it lists
+// migration jobs and every registered migration with whether it has been applied.
 func getMigrationStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	jobID := r.URL.Query().Get("job_id")
-	if jobID == "" {
-		// Return all jobs
-		migrationJobs.RLock()
-		jobs := make([]*MigrationJob, 0, len(migrationJobs.jobs))
-		for _, job := range migrationJobs.jobs {
-			jobs = append(jobs, job)
-		}
-		migrationJobs.RUnlock()
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"jobs": jobs,
-		})
-		return
-	}
-
-	migrationJobs.RLock()
-	job, exists := migrationJobs.jobs[jobID]
-	migrationJobs.RUnlock()
-
-	if !exists {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"error": "Job not found",
-		})
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(job)
+	writeJobStatus(w, r, jobKindMigrate, map[string]interface{}{
+		"migrations": migrationStatusList(),
+	})
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"lyrics-api-go/services/notifier"
+	"lyrics-api-go/stats"
+	"sync"
+	"time"
+)
+
+// corruptionAlerter counts corrupt cache entries over a rolling window and publishes
+// one alert when the count reaches the threshold. It re-arms once the window has
+// emptied out, so a disk that keeps producing bad reads alerts once per window.
+type corruptionAlerter struct {
+	mu        sync.Mutex
+	seen      []time.Time
+	alerted   bool
+	threshold int
+	window    time.Duration
+}
+
+var cacheCorruption = &corruptionAlerter{
+	threshold: conf().Configuration.CacheCorruptionAlertCount,
+	window:    time.Duration(conf().Configuration.CacheCorruptionAlertMins) * time.Minute,
+}
+
+// onCacheCorruption is the persistent cache's corruption handler
+func onCacheCorruption(key, reason string) {
+	stats.Get().RecordCorruptEntry()
+	cacheCorruption.record(key, reason)
+}
+
+// record notes one corrupt entry and reports whether it triggered the alert
+func (a *corruptionAlerter) record(key, reason string) bool {
+	if a.threshold <= 0 {
+		return false
+	}
+	now := clk.Now()
+
+	a.mu.Lock()
+	cutoff := now.Add(-a.window)
+	kept := a.seen[:0]
+	for _, t := range a.seen {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	a.seen = append(kept, now)
+	if len(kept) == 0 {
+		a.alerted = false
+	}
+	fire := !a.alerted && len(a.seen) >= a.threshold
+	if fire {
+		a.alerted = true
+	}
+	count := len(a.seen)
+	a.mu.Unlock()
+
+	if fire {
+		notifier.PublishCacheCorruption(count, a.threshold, a.window, key, reason)
+	}
+	return fire
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"lyrics-api-go/internal/clocktest"
+	"lyrics-api-go/services/notifier"
+	"sync"
+	"testing"
+	"time"
+)
+
+type corruptionRecorder struct {
+	mu     sync.Mutex
+	events []*notifier.Event
+}
+
+func (r *corruptionRecorder) Name() string { return "corruption_recorder" }
+
+func (r *corruptionRecorder) HandleEvent(event *notifier.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func TestCorruptionAlerter_AlertsOncePerWindow(t *testing.T) {
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	origClk := clk
+	clk = fake
+	defer func() { clk = origClk }()
+
+	bus := notifier.GetEventBus()
+	rec := &corruptionRecorder{}
+	unsubscribe := bus.Register(rec, notifier.EventCacheCorruption)
+	defer unsubscribe()
+
+	a := &corruptionAlerter{threshold: 3, window: time.Hour}
+	fired := 0
+	for i := 0; i < 5; i++ {
+		if a.record("ttml_lyrics:k", "checksum mismatch") {
+			fired++
+		}
+		fake.Advance(time.Minute)
+	}
+	if fired != 1 {
+		t.Fatalf("Expected one alert for a burst, got %d", fired)
+	}
+
+	// Old entries age out; a fresh burst alerts again
+	fake.Advance(2 * time.Hour)
+	for i := 0; i < 3; i++ {
+		if a.record("ttml_lyrics:k", "checksum mismatch") {
+			fired++
+		}
+	}
+	if fired != 2 {
+		t.Errorf("Expected a second alert after the window emptied, got %d", fired)
+	}
+
+	bus.Drain()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.events) != 2 {
+		t.Errorf("Expected 2 published events, got %d", len(rec.events))
+	}
+}
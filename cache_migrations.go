@@ -0,0 +1,423 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/jobs"
+	"lyrics-api-go/logcolors"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// metaBucket holds cache-wide bookkeeping (applied migration versions). It lives in
+// the same DB file, so backups and restores carry the versions with the data.
+const metaBucket = cache.MetaBucket
+
+const migrationKeyPrefix = "migration:"
+
+// cacheMigration is one versioned change to the cache format. /cache/migrate runs
+// every migration whose version isn't recorded in the meta bucket, in version order,
+// and records it once it completes without failures.
+type cacheMigration struct {
+	Version     int
+	Name        string
+	Description string
+
+	// Detect counts the keys this migration would touch (read-only; shown in the
+	// status listing for migrations not yet applied)
+	Detect func() (int, error)
+
+	// DryRun describes what Apply would do without changing anything
+	DryRun func() (map[string]interface{}, error)
+
+	// Apply performs the migration, reporting progress on the task. It must stop
+	// when the task is cancelled and be safe to re-run after an interruption, either
+	// because the work is idempotent or by resuming from a task checkpoint.
+	Apply func(t *jobs.Task) (MigrationResult, error)
+}
+
+// appliedMigration is the meta bucket record of a completed migration
+type appliedMigration struct {
+	Version   int             `json:"version"`
+	Name      string          `json:"name"`
+	AppliedAt int64           `json:"applied_at"`
+	JobID     string          `json:"job_id,omitempty"`
+	Result    MigrationResult `json:"result"`
+}
+
+// cacheMigrations is the registry, in version order. Append new migrations with the
+// next version number; never renumber or remove one that has shipped.
+var cacheMigrations = []*cacheMigration{
+	{
+		Version:     1,
+		Name:        "normalize_legacy_keys",
+		Description: `Rewrite legacy "ttml_lyrics:{song} {artist} {album} " keys to the lowercase, trimmed, single-spaced form`,
+		Detect: func() (int, error) {
+			plan := planKeyNormalization()
+			return len(plan.toDelete), nil
+		},
+		DryRun: func() (map[string]interface{}, error) {
+			plan := planKeyNormalization()
+			return map[string]interface{}{
+				"keys_to_migrate": len(plan.toMigrate),
+				"keys_to_delete":  len(plan.toDelete),
+				"skipped":         plan.skipped,
+			}, nil
+		},
+		Apply: applyKeyNormalization,
+	},
+	{
+		Version:     2,
+		Name:        "split_buckets",
+		Description: `Move negative ("no_lyrics:") entries into the negative bucket and unprefixed incidental keys into the meta bucket`,
+		Detect: func() (int, error) {
+			return len(persistentCache.LegacyKeys()), nil
+		},
+		DryRun: func() (map[string]interface{}, error) {
+			negative, meta := 0, 0
+			for _, key := range persistentCache.LegacyKeys() {
+				if strings.HasPrefix(key, cache.NegativePrefix) {
+					negative++
+				} else {
+					meta++
+				}
+			}
+			return map[string]interface{}{
+				"negative_to_move": negative,
+				"meta_to_move":     meta,
+			}, nil
+		},
+		Apply: applyBucketSplit,
+	},
+}
+
+// recompressMigration re-encodes every entry with the current compression settings.
+// Entries record their codec, so this is never needed to keep them readable after
+// FF_CACHE_COMPRESSION changes; it only moves old entries to the new setting. It
+// isn't versioned: it changes no format, so it runs only when asked for
+// (recompress=true) and is never recorded.
+var recompressMigration = &cacheMigration{
+	Name:        recompressStep,
+	Description: "Re-compress existing entries with the current compression settings",
+	Detect: func() (int, error) {
+		return len(recompressKeys()), nil
+	},
+	DryRun: func() (map[string]interface{}, error) {
+		return map[string]interface{}{
+			"keys_to_recompress": len(recompressKeys()),
+		}, nil
+	},
+	Apply: applyRecompress,
+}
+
+// appliedMigrations returns the recorded migrations keyed by version
+func appliedMigrations() map[int]appliedMigration {
+	applied := make(map[int]appliedMigration)
+	persistentCache.RangeBucket(metaBucket, func(k, v []byte) bool {
+		if !strings.HasPrefix(string(k), migrationKeyPrefix) {
+			return true
+		}
+		var record appliedMigration
+		if err := json.Unmarshal(v, &record); err == nil {
+			applied[record.Version] = record
+		}
+		return true
+	})
+	return applied
+}
+
+// recordMigration marks a migration as applied
+func recordMigration(m *cacheMigration, jobID string, result MigrationResult) error {
+	if err := persistentCache.CreateBucket(metaBucket); err != nil {
+		return err
+	}
+	// Key lists can be huge; the job result keeps them, the record doesn't need to
+	result.MigratedKeys = nil
+	result.Applied = nil
+	data, err := json.Marshal(appliedMigration{
+		Version:   m.Version,
+		Name:      m.Name,
+		AppliedAt: time.Now().Unix(),
+		JobID:     jobID,
+		Result:    result,
+	})
+	if err != nil {
+		return err
+	}
+	return persistentCache.SetInBucket(metaBucket, fmt.Sprintf("%s%04d", migrationKeyPrefix, m.Version), data)
+}
+
+// pendingMigrations returns registered migrations not yet recorded, in version order
+func pendingMigrations() []*cacheMigration {
+	applied := appliedMigrations()
+	var pending []*cacheMigration
+	for _, m := range cacheMigrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Version < pending[j].Version })
+	return pending
+}
+
+// migrationStatusList describes every registered migration and whether it has run
+func migrationStatusList() []map[string]interface{} {
+	applied := appliedMigrations()
+	list := make([]map[string]interface{}, 0, len(cacheMigrations))
+	for _, m := range cacheMigrations {
+		entry := map[string]interface{}{
+			"version":     m.Version,
+			"name":        m.Name,
+			"description": m.Description,
+			"applied":     false,
+		}
+		if record, ok := applied[m.Version]; ok {
+			entry["applied"] = true
+			entry["applied_at"] = record.AppliedAt
+			entry["job_id"] = record.JobID
+		} else if n, err := m.Detect(); err == nil {
+			entry["pending_keys"] = n
+		}
+		list = append(list, entry)
+	}
+	return list
+}
+
+// runMigrations is the /cache/migrate job: it applies migrations in order. A migration
+// with failed keys is not recorded (so the next run retries it) and stops the job,
+// since later migrations may assume the earlier format.
+func runMigrations(t *jobs.Task, migrations []*cacheMigration) (interface{}, error) {
+	var total MigrationResult
+	for _, m := range migrations {
+		if t.Cancelled() {
+			return total, t.Context().Err()
+		}
+
+		t.SetStep(m.Name)
+		log.Infof("%s Migration job %s: running %s", logcolors.LogCache, t.ID(), m.Name)
+		result, err := m.Apply(t)
+		total.add(result)
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		if result.Failed > 0 {
+			return total, fmt.Errorf("%s: %d keys failed, not recorded as applied", m.Name, result.Failed)
+		}
+
+		if m.Version > 0 {
+			if err := recordMigration(m, t.ID(), result); err != nil {
+				return total, fmt.Errorf("%s: applied but failed to record version: %v", m.Name, err)
+			}
+		}
+		total.Applied = append(total.Applied, m.Name)
+		t.SetResult(total)
+	}
+
+	log.Infof("%s Migration job %s complete (%s): %d migrated, %d recompressed, %d deleted, %d skipped, %d failed, %d bytes saved",
+		logcolors.LogCache, t.ID(), strings.Join(total.Applied, ", "),
+		total.Migrated, total.Recompressed, total.Deleted, total.Skipped, total.Failed, total.BytesSaved)
+	return total, nil
+}
+
+// add accumulates another migration's counts into r
+func (r *MigrationResult) add(other MigrationResult) {
+	r.Migrated += other.Migrated
+	r.Recompressed += other.Recompressed
+	r.Deleted += other.Deleted
+	r.Skipped += other.Skipped
+	r.Failed += other.Failed
+	r.BytesSaved += other.BytesSaved
+	r.MigratedKeys = append(r.MigratedKeys, other.MigratedKeys...)
+	r.Applied = append(r.Applied, other.Applied...)
+}
+
+// =============================================================================
+// v1: normalize legacy keys
+// =============================================================================
+
+type keyNormalizationPlan struct {
+	toMigrate map[string]string // normalized key -> legacy key (only when normalized doesn't exist)
+	toDelete  map[string]bool   // legacy keys
+	skipped   int
+}
+
+// planKeyNormalization finds ttml_lyrics keys that aren't in normalized form.
+// Legacy format: "ttml_lyrics:{song} {artist} {album}" with trailing space when album is empty
+// New format: "ttml_lyrics:{song} {artist}" (lowercase, trimmed, no double spaces)
+func planKeyNormalization() keyNormalizationPlan {
+	plan := keyNormalizationPlan{
+		toMigrate: make(map[string]string),
+		toDelete:  make(map[string]bool),
+	}
+	persistentCache.Range(func(key string, entry cache.CacheEntry) bool {
+		if !strings.HasPrefix(key, "ttml_lyrics:") {
+			plan.skipped++
+			return true
+		}
+
+		if normalizedKey := normalizeLyricsCacheKey(key); normalizedKey != key {
+			if _, exists := persistentCache.Get(normalizedKey); !exists {
+				plan.toMigrate[normalizedKey] = key
+			}
+			plan.toDelete[key] = true
+		}
+		return true
+	})
+	return plan
+}
+
+// applyKeyNormalization needs no checkpoint: the plan is recomputed on every run, and
+// an interrupted run leaves only legacy keys still to copy or delete.
+func applyKeyNormalization(t *jobs.Task) (MigrationResult, error) {
+	plan := planKeyNormalization()
+	result := MigrationResult{Skipped: plan.skipped}
+	total := len(plan.toMigrate) + len(plan.toDelete)
+	done := 0
+	t.SetProgress(done, total)
+
+	for normalizedKey, legacyKey := range plan.toMigrate {
+		if t.Cancelled() {
+			return result, t.Context().Err()
+		}
+		if value, ok := persistentCache.Get(legacyKey); ok {
+			if err := persistentCache.Set(normalizedKey, value); err != nil {
+				log.Warnf("%s Failed to migrate key %s -> %s: %v", logcolors.LogCache, legacyKey, normalizedKey, err)
+				result.Failed++
+				// Keep the legacy key so the data isn't lost
+				delete(plan.toDelete, legacyKey)
+			} else {
+				result.MigratedKeys = append(result.MigratedKeys, fmt.Sprintf("%s -> %s", legacyKey, normalizedKey))
+				result.Migrated++
+			}
+		}
+		done++
+		t.SetProgress(done, total)
+	}
+
+	for legacyKey := range plan.toDelete {
+		if t.Cancelled() {
+			return result, t.Context().Err()
+		}
+		if err := deleteCacheEntry(legacyKey, trashReasonMigration); err != nil {
+			log.Warnf("%s Failed to delete legacy key %s: %v", logcolors.LogCache, legacyKey, err)
+			result.Failed++
+		} else {
+			result.Deleted++
+		}
+		done++
+		t.SetProgress(done, total)
+	}
+	return result, nil
+}
+
+// =============================================================================
+// v2: split negative entries and incidental keys out of the cache bucket
+// =============================================================================
+
+// bucketSplitBatch is how many keys applyBucketSplit moves per transaction
+const bucketSplitBatch = 500
+
+// applyBucketSplit needs no checkpoint: moved keys leave the cache bucket, so a
+// re-run only sees what is left. Until it runs, negative entries are still found
+// in the cache bucket.
+func applyBucketSplit(t *jobs.Task) (MigrationResult, error) {
+	var result MigrationResult
+	keys := persistentCache.LegacyKeys()
+	t.SetProgress(0, len(keys))
+
+	for start := 0; start < len(keys); start += bucketSplitBatch {
+		if t.Cancelled() {
+			return result, t.Context().Err()
+		}
+		batch := keys[start:min(start+bucketSplitBatch, len(keys))]
+		moved, err := persistentCache.MoveLegacyKeys(batch)
+		if err != nil {
+			log.Warnf("%s Failed to move %d keys out of the cache bucket: %v", logcolors.LogCache, len(batch), err)
+			result.Failed += len(batch)
+		} else {
+			result.Migrated += moved
+			result.Skipped += len(batch) - moved
+		}
+		t.SetProgress(start+len(batch), len(keys))
+	}
+	return result, nil
+}
+
+// =============================================================================
+// recompress (unversioned, on request)
+// =============================================================================
+
+const (
+	recompressStep = "recompress"
+
+	// recompressCheckpointEvery is how many keys recompress handles between checkpoints
+	recompressCheckpointEvery = 100
+)
+
+// recompressKeys returns the keys recompress rewrites, sorted: the query-keyed
+// lyrics entries and the track blobs most of them alias
+func recompressKeys() []string {
+	keys := append(listKeysWithPrefix("ttml_lyrics:"), listKeysWithPrefix(trackLyricsPrefix)...)
+	sort.Strings(keys)
+	return keys
+}
+
+// applyRecompress walks keys in order, checkpointing the last one done, so a re-run
+// after a cancel or crash skips what was already recompressed
+func applyRecompress(t *jobs.Task) (MigrationResult, error) {
+	var result MigrationResult
+	keys := recompressKeys()
+
+	start := 0
+	if after := t.Checkpoint(recompressStep); after != "" {
+		start = sort.Search(len(keys), func(i int) bool { return keys[i] > after })
+		log.Infof("%s Recompress resuming after %q (%d of %d keys already done)", logcolors.LogCache, after, start, len(keys))
+	}
+	t.SetProgress(start, len(keys))
+
+	for i := start; i < len(keys); i++ {
+		key := keys[i]
+		if t.Cancelled() {
+			if i > start {
+				t.SaveCheckpoint(recompressStep, keys[i-1])
+			}
+			return result, t.Context().Err()
+		}
+		if value, ok := persistentCache.Get(key); ok {
+			originalSize := storedEntrySize(key)
+			if err := persistentCache.Set(key, value); err != nil {
+				log.Warnf("%s Failed to recompress key %s: %v", logcolors.LogCache, key, err)
+				result.Failed++
+			} else if savings := originalSize - storedEntrySize(key); savings > 0 {
+				result.BytesSaved += int64(savings)
+				result.Recompressed++
+			}
+		}
+		t.SetProgress(i+1, len(keys))
+		if (i+1)%recompressCheckpointEvery == 0 {
+			t.SaveCheckpoint(recompressStep, key)
+		}
+	}
+	t.ClearCheckpoint(recompressStep)
+	return result, nil
+}
+
+// storedEntrySize returns the on-disk size of an existing cache entry's value
+func storedEntrySize(key string) int {
+	entry, _ := persistentCache.GetEntry(key)
+	return len(entry.Value)
+}
+
+// listKeysWithPrefix returns every cache key with the given prefix
+func listKeysWithPrefix(prefix string) []string {
+	var keys []string
+	persistentCache.RangeKeys(prefix, func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"lyrics-api-go/jobs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// runTestMigrations runs migrations as a job on a private manager and waits for it
+func runTestMigrations(t *testing.T, migrations []*cacheMigration) jobs.Job {
+	t.Helper()
+	m := jobs.NewManager(jobs.Options{})
+	started, err := m.Start(jobKindMigrate, nil, func(task *jobs.Task) (interface{}, error) {
+		return runMigrations(task, migrations)
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, started.ID)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	return job
+}
+
+func TestRunMigrations_NormalizesKeysAndRecordsVersion(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	persistentCache.Set("ttml_lyrics:Hello  Adele ", "legacy")
+	persistentCache.Set("ttml_lyrics:Shape of You Ed Sheeran", "legacy-dup")
+	persistentCache.Set("ttml_lyrics:shape of you ed sheeran", "current")
+	persistentCache.Set("ttml_lyrics:already normal", "ok")
+
+	pending := pendingMigrations()
+	if len(pending) != len(cacheMigrations) {
+		t.Fatalf("Expected all %d migrations pending on a fresh cache, got %d", len(cacheMigrations), len(pending))
+	}
+
+	job := runTestMigrations(t, pending)
+	if job.Status != jobs.StatusCompleted {
+		t.Fatalf("Expected completed job, got %s (%s)", job.Status, job.Error)
+	}
+	result := job.Result.(MigrationResult)
+	if result.Migrated != 1 || result.Deleted != 2 || result.Failed != 0 || len(result.Applied) != len(pending) {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if v, ok := persistentCache.Get("ttml_lyrics:hello adele"); !ok || v != "legacy" {
+		t.Errorf("Expected legacy entry under normalized key, got %q (%v)", v, ok)
+	}
+	if v, _ := persistentCache.Get("ttml_lyrics:shape of you ed sheeran"); v != "current" {
+		t.Errorf("Existing normalized entry must not be overwritten, got %q", v)
+	}
+	if _, ok := persistentCache.Get("ttml_lyrics:Hello  Adele "); ok {
+		t.Error("Expected legacy key to be deleted")
+	}
+
+	applied := appliedMigrations()
+	record, ok := applied[1]
+	if !ok || record.Name != "normalize_legacy_keys" || record.JobID != job.ID {
+		t.Fatalf("Expected v1 recorded as applied, got %+v", applied)
+	}
+	if len(pendingMigrations()) != 0 {
+		t.Error("Applied migrations must not be pending again")
+	}
+}
+
+func TestRunMigrations_FailureIsNotRecorded(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	ranSecond := false
+	migrations := []*cacheMigration{
+		{
+			Version: 90,
+			Name:    "broken",
+			Apply: func(task *jobs.Task) (MigrationResult, error) {
+				return MigrationResult{Failed: 1}, nil
+			},
+		},
+		{
+			Version: 91,
+			Name:    "after_broken",
+			Apply: func(task *jobs.Task) (MigrationResult, error) {
+				ranSecond = true
+				return MigrationResult{}, nil
+			},
+		},
+	}
+
+	job := runTestMigrations(t, migrations)
+	if job.Status != jobs.StatusFailed {
+		t.Fatalf("Expected failed job, got %s", job.Status)
+	}
+	if ranSecond {
+		t.Error("Later migrations must not run after a failure")
+	}
+	if _, ok := appliedMigrations()[90]; ok {
+		t.Error("A migration with failures must not be recorded")
+	}
+}
+
+func TestMigrateCache_DryRunAndStatus(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	persistentCache.Set("ttml_lyrics:Legacy Key ", "value")
+
+	req := httptest.NewRequest(http.MethodPost, "/cache/migrate?dry_run=true&recompress=true", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr := httptest.NewRecorder()
+	migrateCache(rr, req)
+
+	var dry struct {
+		DryRun     bool                     `json:"dry_run"`
+		Migrations []map[string]interface{} `json:"migrations"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &dry)
+	if !dry.DryRun || len(dry.Migrations) != len(cacheMigrations)+1 {
+		t.Fatalf("Expected a plan per pending migration plus recompress, got %s", rr.Body.String())
+	}
+	if dry.Migrations[0]["name"] != "normalize_legacy_keys" || dry.Migrations[0]["keys_to_migrate"] != float64(1) {
+		t.Errorf("Unexpected v1 plan: %v", dry.Migrations[0])
+	}
+	if _, ok := persistentCache.Get("ttml_lyrics:Legacy Key "); !ok {
+		t.Error("Dry run must not change the cache")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/cache/migrate", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	migrateCache(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	waitForStartedJob(t, rr)
+
+	req = httptest.NewRequest(http.MethodGet, "/cache/migrate/status", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	getMigrationStatus(rr, req)
+
+	var status struct {
+		Migrations []map[string]interface{} `json:"migrations"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &status)
+	if len(status.Migrations) != len(cacheMigrations) || status.Migrations[0]["applied"] != true {
+		t.Errorf("Expected v1 listed as applied, got %s", rr.Body.String())
+	}
+
+	// Nothing left to do: no job is started
+	req = httptest.NewRequest(http.MethodPost, "/cache/migrate", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	migrateCache(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 with no pending migrations, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestApplyRecompress_ResumesFromCheckpoint(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// Track blobs hold the lyrics that query keys alias, so they're recompressed too
+	for _, key := range []string{"ttml_lyrics:a", "ttml_lyrics:b", "ttml_lyrics:c", "ttml_lyrics:d", trackLyricsKey("1")} {
+		persistentCache.Set(key, "value")
+	}
+	cacheCheckpointStore{}.SaveCheckpoint(jobKindMigrate+":"+recompressStep, "ttml_lyrics:b")
+
+	m := jobs.NewManager(jobs.Options{Checkpoints: cacheCheckpointStore{}})
+	started, _ := m.Start(jobKindMigrate, nil, func(task *jobs.Task) (interface{}, error) {
+		return applyRecompress(task)
+	})
+	job, err := m.Wait(context.Background(), started.ID)
+	if err != nil || job.Status != jobs.StatusCompleted {
+		t.Fatalf("Unexpected job: %+v (%v)", job, err)
+	}
+	if job.Progress.ProcessedKeys != 5 || job.Progress.TotalKeys != 5 {
+		t.Errorf("Expected progress to count resumed keys as done, got %+v", job.Progress)
+	}
+	if _, ok := (cacheCheckpointStore{}).LoadCheckpoint(jobKindMigrate + ":" + recompressStep); ok {
+		t.Error("Checkpoint should be cleared after a completed run")
+	}
+}
+
+func TestRunMigrations_CancelStopsWithoutRecording(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	running := make(chan struct{})
+	migrations := []*cacheMigration{{
+		Version: 92,
+		Name:    "slow",
+		Apply: func(task *jobs.Task) (MigrationResult, error) {
+			close(running)
+			<-task.Context().Done()
+			return MigrationResult{Migrated: 1}, task.Context().Err()
+		},
+	}}
+
+	m := jobs.NewManager(jobs.Options{})
+	started, _ := m.Start(jobKindMigrate, nil, func(task *jobs.Task) (interface{}, error) {
+		return runMigrations(task, migrations)
+	})
+	<-running
+	m.Cancel(started.ID)
+
+	job, _ := m.Wait(context.Background(), started.ID)
+	if job.Status != jobs.StatusCancelled {
+		t.Fatalf("Expected cancelled job, got %s (%s)", job.Status, job.Error)
+	}
+	if result := job.Result.(MigrationResult); result.Migrated != 1 {
+		t.Errorf("Expected the partial result to be kept, got %+v", result)
+	}
+	if _, ok := appliedMigrations()[92]; ok {
+		t.Error("A cancelled migration must not be recorded")
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Why an entry went to the trash, as listed by /cache/trash
+const (
+	trashReasonProviderClear   = "provider_clear"
+	trashReasonBulkDelete      = "bulk_delete"
+	trashReasonMigration       = "migration"
+	trashReasonDedupe          = "dedupe"
+	trashReasonTrackInvalidate = "track_invalidate"
+)
+
+const (
+	// trashListLimit is the default number of entries /cache/trash returns
+	trashListLimit = 100
+
+	// trashPurgeInterval is how often expired trash is dropped
+	trashPurgeInterval = time.Hour
+)
+
+// trashRetention is how long deleted entries stay restorable (0 = deletes are permanent)
+func trashRetention() time.Duration {
+	return time.Duration(conf().Configuration.TrashRetentionHours) * time.Hour
+}
+
+// deleteCacheEntry removes a cache entry on behalf of an admin operation. With a
+// trash retention it is moved to the trash, where /cache/trash/restore can bring it
+// back; otherwise it is deleted outright.
+func deleteCacheEntry(key, reason string) error {
+	deleteFormatVariants(key)
+	if trashRetention() <= 0 {
+		return persistentCache.Delete(key)
+	}
+	return persistentCache.Trash(key, reason, clk.Now())
+}
+
+// startTrashPurger permanently drops trash past TRASH_RETENTION_HOURS, at startup
+// and then every hour
+func startTrashPurger() {
+	go func() {
+		for {
+			purgeExpiredTrash()
+			time.Sleep(trashPurgeInterval)
+		}
+	}()
+}
+
+func purgeExpiredTrash() {
+	retention := trashRetention()
+	if retention <= 0 {
+		return
+	}
+	purged, err := persistentCache.PurgeTrash(clk.Now().Add(-retention))
+	if err != nil {
+		log.Warnf("%s Failed to purge expired trash: %v", logcolors.LogCacheClear, err)
+		return
+	}
+	if purged > 0 {
+		log.Infof("%s Purged %d trashed entries older than %v", logcolors.LogCacheClear, purged, retention)
+	}
+}
+
+// trashHandler lists deleted entries that can still be restored.
+//
+// Query params:
+//   - prefix: Only keys starting with this prefix
+//   - limit: Maximum entries to return (default 100)
+func trashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	limit := trashListLimit
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	retention := trashRetention()
+
+	entries := []map[string]interface{}{}
+	total := 0
+	persistentCache.RangeBucket(cache.TrashBucket, func(k, v []byte) bool {
+		if !strings.HasPrefix(string(k), prefix) {
+			return true
+		}
+		total++
+		if len(entries) >= limit {
+			return true
+		}
+		var trashed cache.TrashedEntry
+		if err := json.Unmarshal(v, &trashed); err != nil {
+			return true
+		}
+		item := map[string]interface{}{
+			"key":        string(k),
+			"reason":     trashed.Reason,
+			"deleted_at": trashed.DeletedAt,
+			"bytes":      len(trashed.Raw),
+		}
+		if retention > 0 {
+			item["expires_at"] = time.Unix(trashed.DeletedAt, 0).Add(retention).Unix()
+		}
+		entries = append(entries, item)
+		return true
+	})
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"entries":         entries,
+		"count":           len(entries),
+		"total":           total,
+		"retention_hours": conf().Configuration.TrashRetentionHours,
+	})
+}
+
+// trashRestoreHandler moves trashed entries back into the cache.
+//
+// Query params:
+//   - key: Restore one entry, or
+//   - prefix: Restore every trashed entry under the prefix (e.g. after a bulk delete)
+//   - overwrite=true: Replace entries that have been cached again since
+func trashRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	keys, ok := trashTargetKeys(w, r)
+	if !ok {
+		return
+	}
+	overwrite := r.URL.Query().Get("overwrite") == "true"
+
+	restored := 0
+	skipped := []string{}
+	failed := 0
+	for _, key := range keys {
+		switch err := persistentCache.RestoreFromTrash(key, overwrite); {
+		case err == nil:
+			restored++
+		case errors.Is(err, cache.ErrLiveEntry):
+			skipped = append(skipped, key)
+		default:
+			log.Warnf("%s Failed to restore %s from trash: %v", logcolors.LogCacheClear, key, err)
+			failed++
+		}
+	}
+	if len(keys) == 1 && restored == 0 && failed == 1 {
+		Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+			"error": "Key not found in trash",
+			"key":   keys[0],
+		})
+		return
+	}
+
+	log.Infof("%s Restored %d entries from trash (%d skipped, %d failed)", logcolors.LogCacheClear, restored, len(skipped), failed)
+	resp := map[string]interface{}{
+		"restored": restored,
+		"skipped":  skipped,
+		"failed":   failed,
+	}
+	if len(skipped) > 0 {
+		resp["notes"] = "Skipped keys have a live cache entry; pass overwrite=true to replace it"
+	}
+	Respond(w, r).JSON(resp)
+}
+
+// trashPurgeHandler permanently deletes trashed entries.
+//
+// Query params:
+//   - key: Purge one entry, or
+//   - all=true: Empty the trash
+//   - (neither): Purge entries past the retention window
+func trashPurgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if key := r.URL.Query().Get("key"); key != "" {
+		if _, ok := persistentCache.GetFromBucket(cache.TrashBucket, key); !ok {
+			Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+				"error": "Key not found in trash",
+				"key":   key,
+			})
+			return
+		}
+		if err := persistentCache.DeleteFromBucket(cache.TrashBucket, key); err != nil {
+			Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+		Respond(w, r).JSON(map[string]interface{}{"purged": 1})
+		return
+	}
+
+	var cutoff time.Time
+	if r.URL.Query().Get("all") != "true" {
+		cutoff = clk.Now().Add(-trashRetention())
+	}
+	purged, err := persistentCache.PurgeTrash(cutoff)
+	if err != nil {
+		Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	log.Infof("%s Purged %d entries from trash", logcolors.LogCacheClear, purged)
+	Respond(w, r).JSON(map[string]interface{}{"purged": purged})
+}
+
+// trashTargetKeys returns the trashed keys a restore names by key or prefix
+func trashTargetKeys(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	key := r.URL.Query().Get("key")
+	prefix := r.URL.Query().Get("prefix")
+	if (key == "") == (prefix == "") {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "Pass either key or prefix",
+		})
+		return nil, false
+	}
+	if key != "" {
+		return []string{key}, true
+	}
+
+	var keys []string
+	persistentCache.RangeBucket(cache.TrashBucket, func(k, v []byte) bool {
+		if strings.HasPrefix(string(k), prefix) {
+			keys = append(keys, string(k))
+		}
+		return true
+	})
+	return keys, true
+}
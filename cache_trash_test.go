@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/internal/clocktest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withTrashRetention sets TRASH_RETENTION_HOURS for one test
+func withTrashRetention(t *testing.T, hours int) {
+	t.Helper()
+	orig := conf().Configuration.TrashRetentionHours
+	conf().Configuration.TrashRetentionHours = hours
+	t.Cleanup(func() { conf().Configuration.TrashRetentionHours = orig })
+}
+
+func serveTrash(t *testing.T, handler http.HandlerFunc, method, target string) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, target, nil)
+	r.Header.Set("Authorization", "test-token")
+	handler(w, r)
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body
+}
+
+func TestDeleteCacheEntry_TrashesWhenRetentionSet(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	withTrashRetention(t, 24)
+
+	persistentCache.Set("ttml_lyrics:hello adele", `{"ttml":"x"}`)
+	if err := deleteCacheEntry("ttml_lyrics:hello adele", trashReasonBulkDelete); err != nil {
+		t.Fatalf("deleteCacheEntry failed: %v", err)
+	}
+	if _, ok := persistentCache.Get("ttml_lyrics:hello adele"); ok {
+		t.Error("Entry should be gone from the cache")
+	}
+	if _, ok := persistentCache.GetFromBucket(cache.TrashBucket, "ttml_lyrics:hello adele"); !ok {
+		t.Error("Entry should be in the trash")
+	}
+
+	withTrashRetention(t, 0)
+	persistentCache.Set("ttml_lyrics:rolling adele", `{"ttml":"y"}`)
+	deleteCacheEntry("ttml_lyrics:rolling adele", trashReasonBulkDelete)
+	if _, ok := persistentCache.GetFromBucket(cache.TrashBucket, "ttml_lyrics:rolling adele"); ok {
+		t.Error("With retention 0 deletes must be permanent")
+	}
+}
+
+func TestTrashHandlers_ListRestorePurge(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	withTrashRetention(t, 24)
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	origClk := clk
+	clk = fake
+	defer func() { clk = origClk }()
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
+
+	persistentCache.Set("kugou_lyrics:hello adele", `{"ttml":"a"}`)
+	persistentCache.Set("kugou_lyrics:rolling adele", `{"ttml":"b"}`)
+	persistentCache.Set("ttml_lyrics:hello adele", `{"ttml":"c"}`)
+	for _, key := range []string{"kugou_lyrics:hello adele", "kugou_lyrics:rolling adele", "ttml_lyrics:hello adele"} {
+		deleteCacheEntry(key, trashReasonBulkDelete)
+	}
+
+	code, body := serveTrash(t, trashHandler, http.MethodGet, "/cache/trash?prefix=kugou_lyrics:")
+	if code != http.StatusOK || body["total"] != float64(2) {
+		t.Fatalf("list: status %d, body %v", code, body)
+	}
+	entry := body["entries"].([]interface{})[0].(map[string]interface{})
+	if entry["reason"] != trashReasonBulkDelete || entry["expires_at"] == nil {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+
+	// One of the keys has been cached again since the delete
+	persistentCache.Set("kugou_lyrics:rolling adele", `{"ttml":"new"}`)
+	code, body = serveTrash(t, trashRestoreHandler, http.MethodPost, "/cache/trash/restore?prefix=kugou_lyrics:")
+	if code != http.StatusOK || body["restored"] != float64(1) || len(body["skipped"].([]interface{})) != 1 {
+		t.Fatalf("restore: status %d, body %v", code, body)
+	}
+	if v, ok := persistentCache.Get("kugou_lyrics:hello adele"); !ok || v != `{"ttml":"a"}` {
+		t.Errorf("Restored entry = %q, %v", v, ok)
+	}
+	if v, _ := persistentCache.Get("kugou_lyrics:rolling adele"); v != `{"ttml":"new"}` {
+		t.Errorf("Live entry must not be overwritten without overwrite=true, got %q", v)
+	}
+
+	code, _ = serveTrash(t, trashRestoreHandler, http.MethodPost, "/cache/trash/restore?key=missing")
+	if code != http.StatusNotFound {
+		t.Errorf("restore missing key: status %d", code)
+	}
+
+	// Nothing has expired yet
+	code, body = serveTrash(t, trashPurgeHandler, http.MethodPost, "/cache/trash/purge")
+	if code != http.StatusOK || body["purged"] != float64(0) {
+		t.Errorf("purge expired: status %d, body %v", code, body)
+	}
+
+	// Past the retention only the entries trashed before it go
+	fake.Advance(23 * time.Hour)
+	persistentCache.Set("kugou_lyrics:skyfall adele", `{"ttml":"d"}`)
+	deleteCacheEntry("kugou_lyrics:skyfall adele", trashReasonBulkDelete)
+	fake.Advance(2 * time.Hour)
+	code, body = serveTrash(t, trashPurgeHandler, http.MethodPost, "/cache/trash/purge")
+	if code != http.StatusOK || body["purged"] != float64(2) {
+		t.Errorf("purge expired after the retention: status %d, body %v", code, body)
+	}
+	code, body = serveTrash(t, trashPurgeHandler, http.MethodPost, "/cache/trash/purge?all=true")
+	if code != http.StatusOK || body["purged"] != float64(1) {
+		t.Errorf("purge all: status %d, body %v", code, body)
+	}
+	if _, ok := persistentCache.GetFromBucket(cache.TrashBucket, "ttml_lyrics:hello adele"); ok {
+		t.Error("Trash should be empty after purge all")
+	}
+}
+
+func TestTrashHandlers_Unauthorized(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
+
+	for _, handler := range []http.HandlerFunc{trashHandler, trashRestoreHandler, trashPurgeHandler} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/cache/trash?all=true", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	}
+}
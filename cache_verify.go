@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/jobs"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// What /cache/verify does with the corrupt entries it finds
+const (
+	verifyRepairReport     = "report"
+	verifyRepairDelete     = "delete"
+	verifyRepairQuarantine = "quarantine"
+)
+
+const (
+	// verifySampleSize is how many corrupt keys a verify result lists
+	verifySampleSize = 50
+
+	// quarantineListLimit is the default number of entries /cache/quarantine returns
+	quarantineListLimit = 100
+)
+
+// entryHealth is the verdict on one stored cache entry
+type entryHealth int
+
+const (
+	entryHealthy entryHealth = iota
+	entryLegacy
+	entryCorrupt
+)
+
+// verifyCacheHandler starts an async job that checks every cache entry's envelope,
+// checksum, compression and (for TTML lyrics and track blobs) XML structure, and
+// that every alias resolves.
+//
+// Query params:
+//   - repair: report (default), delete, or quarantine (move corrupt entries into the
+//     quarantine bucket; see /cache/quarantine)
+//
+// Returns immediately with a job ID. Use /cache/verify/status?job_id=xxx to check progress.
+func verifyCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	repair := r.URL.Query().Get("repair")
+	if repair == "" {
+		repair = verifyRepairReport
+	}
+	if !validVerifyRepair(repair) {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": fmt.Sprintf("Invalid repair %q: use report, delete or quarantine", repair),
+		})
+		return
+	}
+
+	params := map[string]interface{}{"repair": repair}
+	job, ok := startJob(w, r, jobKindVerify, params, "/cache/verify/status", "Verification started", func(t *jobs.Task) (interface{}, error) {
+		return runCacheVerify(t, repair)
+	})
+	if ok {
+		log.Infof("%s Started async cache verify job %s (repair: %s)", logcolors.LogCache, job.ID, repair)
+	}
+}
+
+// getVerifyStatus returns the status of a verify job
+func getVerifyStatus(w http.ResponseWriter, r *http.Request) {
+	writeJobStatus(w, r, jobKindVerify, nil)
+}
+
+func validVerifyRepair(repair string) bool {
+	return repair == verifyRepairReport || repair == verifyRepairDelete || repair == verifyRepairQuarantine
+}
+
+// startStartupCacheVerify starts a verify job if CACHE_VERIFY_ON_STARTUP is set
+func startStartupCacheVerify(repair string) {
+	if repair == "" {
+		return
+	}
+	if !validVerifyRepair(repair) {
+		log.Warnf("%s Ignoring CACHE_VERIFY_ON_STARTUP %q: use report, delete or quarantine", logcolors.LogCache, repair)
+		return
+	}
+	job, err := jobManager.Start(jobKindVerify, map[string]interface{}{"repair": repair, "startup": true}, func(t *jobs.Task) (interface{}, error) {
+		return runCacheVerify(t, repair)
+	})
+	if err != nil {
+		log.Warnf("%s Startup cache verify not started: %v", logcolors.LogCache, err)
+		return
+	}
+	log.Infof("%s Started startup cache verify job %s (repair: %s)", logcolors.LogCache, job.ID, repair)
+}
+
+// runCacheVerify is the /cache/verify job. The scan runs in one read transaction, so
+// corrupt keys are collected first and repaired afterwards.
+func runCacheVerify(t *jobs.Task, repair string) (interface{}, error) {
+	result := CacheVerifyResult{
+		Repair:          repair,
+		LegacyByReason:  make(map[string]int),
+		CorruptByReason: make(map[string]int),
+		CorruptKeys:     []CorruptCacheKey{},
+	}
+	var corrupt []CorruptCacheKey
+	count := func(key string, health entryHealth, reason string) {
+		switch health {
+		case entryHealthy:
+			result.Healthy++
+		case entryLegacy:
+			result.Legacy++
+			result.LegacyByReason[reason]++
+		case entryCorrupt:
+			result.Corrupt++
+			result.CorruptByReason[reason]++
+			corrupt = append(corrupt, CorruptCacheKey{Key: key, Reason: reason})
+			if len(result.CorruptKeys) < verifySampleSize {
+				result.CorruptKeys = append(result.CorruptKeys, CorruptCacheKey{Key: key, Reason: reason})
+			}
+		}
+	}
+
+	// Aliases are counted once their targets are checked
+	type pendingAlias struct {
+		health entryHealth
+		reason string
+	}
+	aliases := make(map[string]string)
+	pending := make(map[string]pendingAlias)
+
+	t.SetStep("scan")
+	totalKeys, _ := persistentCache.Stats()
+	persistentCache.RangeRaw(func(key string, raw []byte) bool {
+		result.TotalKeys++
+		health, reason, aliasOf := verifyCacheEntry(key, raw)
+		if aliasOf != "" {
+			aliases[key] = aliasOf
+			pending[key] = pendingAlias{health, reason}
+		} else {
+			count(key, health, reason)
+		}
+		if result.TotalKeys%1000 == 0 {
+			t.SetProgress(result.TotalKeys, max(totalKeys, result.TotalKeys))
+		}
+		return !t.Cancelled()
+	})
+	if t.Cancelled() {
+		return result, t.Context().Err()
+	}
+
+	t.SetStep("aliases")
+	broken := checkAliasTargets(aliases)
+	for key, alias := range pending {
+		if reason, ok := broken[key]; ok {
+			count(key, entryCorrupt, reason)
+		} else {
+			count(key, alias.health, alias.reason)
+		}
+	}
+
+	if repair != verifyRepairReport && len(corrupt) > 0 {
+		t.SetStep(repair)
+		for i, entry := range corrupt {
+			if t.Cancelled() {
+				return result, t.Context().Err()
+			}
+			var err error
+			if repair == verifyRepairQuarantine {
+				err = persistentCache.Quarantine(entry.Key, entry.Reason)
+			} else {
+				err = persistentCache.Delete(entry.Key)
+			}
+			switch {
+			case err != nil:
+				log.Warnf("%s Failed to %s corrupt entry %s: %v", logcolors.LogCache, repair, entry.Key, err)
+				result.RepairFailed++
+			case repair == verifyRepairQuarantine:
+				result.Quarantined++
+			default:
+				result.Deleted++
+			}
+			t.SetProgress(i+1, len(corrupt))
+		}
+	}
+
+	log.Infof("%s Verify job %s complete: %d keys, %d healthy, %d legacy, %d corrupt (%d deleted, %d quarantined, %d failed)",
+		logcolors.LogCache, t.ID(), result.TotalKeys, result.Healthy, result.Legacy, result.Corrupt,
+		result.Deleted, result.Quarantined, result.RepairFailed)
+	return result, nil
+}
+
+// verifyCacheEntry checks one entry as stored. Corrupt entries can't be served;
+// legacy ones are readable (or fixable by /cache/migrate) but predate the current
+// format. reason is empty for healthy entries. For alias entries aliasOf is the
+// target, which the caller checks once the scan is done (see checkAliasTargets).
+func verifyCacheEntry(key string, raw []byte) (health entryHealth, reason, aliasOf string) {
+	var entry cache.CacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return entryCorrupt, "invalid entry JSON", ""
+	}
+	if !entry.Verify() {
+		return entryCorrupt, "checksum mismatch", ""
+	}
+
+	value, err := entry.Decode()
+	if errors.Is(err, cache.ErrUnknownCodec) {
+		return entryLegacy, "unknown codec", ""
+	}
+	if err != nil {
+		return entryCorrupt, "decompress failed", ""
+	}
+	legacy := ""
+
+	switch {
+	case strings.HasPrefix(key, "ttml_lyrics:"), strings.HasPrefix(key, trackLyricsPrefix):
+		var lyrics CachedLyrics
+		if err := json.Unmarshal([]byte(value), &lyrics); err != nil {
+			// Old plain-TTML format
+			if err := validateTTMLStructure(value); err != nil {
+				return entryCorrupt, "invalid TTML", ""
+			}
+			legacy = "plain TTML"
+			break
+		}
+		if lyrics.AliasOf != "" {
+			aliasOf = lyrics.AliasOf
+			break
+		}
+		if lyrics.TTML == NoLyricsSentinel {
+			break
+		}
+		if err := validateTTMLStructure(lyrics.TTML); err != nil {
+			return entryCorrupt, "invalid TTML", ""
+		}
+	case strings.HasPrefix(key, "no_lyrics:"):
+		var negative NegativeCacheEntry
+		if err := json.Unmarshal([]byte(value), &negative); err != nil {
+			return entryCorrupt, "invalid negative entry", ""
+		}
+	}
+
+	if legacy != "" {
+		return entryLegacy, legacy, aliasOf
+	}
+	if entry.Checksum == "" {
+		return entryLegacy, "no checksum", aliasOf
+	}
+	return entryHealthy, "", aliasOf
+}
+
+// checkAliasTargets reads the target of each alias found by a scan and returns why
+// the broken ones can't resolve: the target is missing (or unreadable), or is
+// itself an alias, which resolveCacheAlias refuses to follow. Targets are read
+// as stored, so a corrupt one is reported rather than deleted.
+func checkAliasTargets(aliases map[string]string) map[string]string {
+	problems := make(map[string]string) // target -> reason, "" when it resolves
+	broken := make(map[string]string)
+	for key, target := range aliases {
+		problem, checked := problems[target]
+		if !checked {
+			problem = aliasTargetProblem(target)
+			problems[target] = problem
+		}
+		if problem != "" {
+			broken[key] = problem
+		}
+	}
+	return broken
+}
+
+// aliasTargetProblem returns why an alias of target can't resolve, or ""
+func aliasTargetProblem(target string) string {
+	entry, ok := persistentCache.GetEntry(target)
+	if !ok {
+		return "dangling alias"
+	}
+	value, err := entry.Decode()
+	if err != nil || !entry.Verify() {
+		return "dangling alias"
+	}
+	var lyrics CachedLyrics
+	if err := json.Unmarshal([]byte(value), &lyrics); err != nil || lyrics.TTML == "" {
+		if lyrics.AliasOf != "" {
+			return "alias chain"
+		}
+		return "dangling alias"
+	}
+	return ""
+}
+
+// validateTTMLStructure checks that ttml is well-formed XML with a <tt> root
+func validateTTMLStructure(ttml string) error {
+	decoder := xml.NewDecoder(strings.NewReader(ttml))
+	root := ""
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok && root == "" {
+			root = start.Name.Local
+		}
+	}
+	if root != "tt" {
+		return fmt.Errorf("root element is %q, want tt", root)
+	}
+	return nil
+}
+
+// quarantineHandler lists entries moved to the quarantine bucket by /cache/verify.
+//
+// Query params:
+//   - limit: Maximum entries to return (default 100)
+//   - raw=true: Include the stored bytes of each entry
+func quarantineHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit := quarantineListLimit
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	includeRaw := r.URL.Query().Get("raw") == "true"
+
+	entries := []map[string]interface{}{}
+	total := 0
+	persistentCache.RangeBucket(cache.QuarantineBucket, func(k, v []byte) bool {
+		total++
+		if len(entries) >= limit {
+			return true
+		}
+		var q cache.QuarantinedEntry
+		if err := json.Unmarshal(v, &q); err != nil {
+			return true
+		}
+		item := map[string]interface{}{
+			"key":            string(k),
+			"reason":         q.Reason,
+			"quarantined_at": q.QuarantinedAt,
+			"bytes":          len(q.Raw),
+		}
+		if includeRaw {
+			item["raw"] = string(q.Raw)
+		}
+		entries = append(entries, item)
+		return true
+	})
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+		"total":   total,
+	})
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/jobs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testTTML = `<tt xmlns="http://www.w3.org/ns/ttml"><body><div><p begin="0s">Hello</p></div></body></tt>`
+
+func TestVerifyCacheEntry(t *testing.T) {
+	originalCompression := conf().FeatureFlags.CacheCompression
+	conf().FeatureFlags.CacheCompression = false
+	defer func() { conf().FeatureFlags.CacheCompression = originalCompression }()
+
+	lyrics, _ := json.Marshal(CachedLyrics{TTML: testTTML})
+	broken, _ := json.Marshal(CachedLyrics{TTML: "<tt><body>"})
+	alias, _ := json.Marshal(CachedLyrics{AliasOf: "ttml_lyrics:canonical"})
+	negative, _ := json.Marshal(NegativeCacheEntry{Reason: "not found", Timestamp: 1})
+
+	// envelope wraps value the way the cache stores it (CRC-32C checksum)
+	envelope := func(value string, checksum bool) []byte {
+		entry := cache.CacheEntry{Value: value}
+		if checksum {
+			entry.Checksum = fmt.Sprintf("%08x", crc32.Checksum([]byte(value), crc32.MakeTable(crc32.Castagnoli)))
+		}
+		data, _ := json.Marshal(entry)
+		return data
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		raw    []byte
+		health entryHealth
+		reason string
+	}{
+		{"healthy lyrics", "ttml_lyrics:a", envelope(string(lyrics), true), entryHealthy, ""},
+		{"alias", "ttml_lyrics:b", envelope(string(alias), true), entryHealthy, ""},
+		{"negative", "no_lyrics:c", envelope(string(negative), true), entryHealthy, ""},
+		{"no checksum", "ttml_lyrics:d", envelope(string(lyrics), false), entryLegacy, "no checksum"},
+		{"plain TTML", "ttml_lyrics:e", envelope(testTTML, true), entryLegacy, "plain TTML"},
+		{"not JSON", "ttml_lyrics:f", []byte("garbage"), entryCorrupt, "invalid entry JSON"},
+		{"checksum mismatch", "ttml_lyrics:g", []byte(`{"value":"abc","crc":"00000000"}`), entryCorrupt, "checksum mismatch"},
+		{"broken gzip", "ttml_lyrics:h", envelope("H4sIAAAAAAAA", false), entryCorrupt, "decompress failed"},
+		{"broken TTML", "ttml_lyrics:i", envelope(string(broken), true), entryCorrupt, "invalid TTML"},
+		{"healthy track blob", trackLyricsKey("1"), envelope(string(lyrics), true), entryHealthy, ""},
+		{"broken track blob", trackLyricsKey("2"), envelope(string(broken), true), entryCorrupt, "invalid TTML"},
+		{"broken negative", "no_lyrics:j", envelope("{", true), entryCorrupt, "invalid negative entry"},
+		{"unknown codec", "ttml_lyrics:k", []byte(`{"value":"KLUv/QBY","codec":"zstd"}`), entryLegacy, "unknown codec"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health, reason, aliasOf := verifyCacheEntry(tt.key, tt.raw)
+			if health != tt.health || reason != tt.reason {
+				t.Errorf("verifyCacheEntry = (%d, %q), want (%d, %q)", health, reason, tt.health, tt.reason)
+			}
+			if wantAlias := tt.name == "alias"; (aliasOf == "ttml_lyrics:canonical") != wantAlias {
+				t.Errorf("aliasOf = %q", aliasOf)
+			}
+		})
+	}
+}
+
+func TestRunCacheVerify_ChecksAliasTargets(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	setCachedLyricsForTrack("ttml_lyrics:good", "1", testTTML, 0, 0, "", false)
+	for key, target := range map[string]string{
+		"ttml_lyrics:dangling": trackLyricsKey("gone"),
+		"ttml_lyrics:chained":  "ttml_lyrics:good",
+	} {
+		data, _ := json.Marshal(CachedLyrics{AliasOf: target})
+		persistentCache.Set(key, string(data))
+	}
+
+	m := jobs.NewManager(jobs.Options{})
+	started, _ := m.Start(jobKindVerify, nil, func(task *jobs.Task) (interface{}, error) {
+		return runCacheVerify(task, verifyRepairReport)
+	})
+	job, err := m.Wait(context.Background(), started.ID)
+	if err != nil || job.Status != jobs.StatusCompleted {
+		t.Fatalf("Unexpected job: %+v (%v)", job, err)
+	}
+	result := job.Result.(CacheVerifyResult)
+	if result.TotalKeys != 4 || result.Healthy != 2 || result.Corrupt != 2 ||
+		result.CorruptByReason["dangling alias"] != 1 || result.CorruptByReason["alias chain"] != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestVerifyCacheHandler_Quarantine(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+	originalCompression := conf().FeatureFlags.CacheCompression
+	conf().FeatureFlags.CacheCompression = false
+	defer func() { conf().FeatureFlags.CacheCompression = originalCompression }()
+
+	setCachedLyrics("ttml_lyrics:good", testTTML, 0, 0, "", false)
+	persistentCache.SetInBucket("cache", "ttml_lyrics:bad", []byte("garbage"))
+
+	req := httptest.NewRequest(http.MethodPost, "/cache/verify?repair=quarantine", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr := httptest.NewRecorder()
+	verifyCacheHandler(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	job := waitForStartedJob(t, rr)
+	result := job.Result.(CacheVerifyResult)
+	if result.TotalKeys != 2 || result.Healthy != 1 || result.Corrupt != 1 || result.Quarantined != 1 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if _, ok := persistentCache.GetFromBucket("cache", "ttml_lyrics:bad"); ok {
+		t.Error("Corrupt entry must be moved out of the cache")
+	}
+	if _, ok := persistentCache.Get("ttml_lyrics:good"); !ok {
+		t.Error("Healthy entry must be kept")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/cache/quarantine", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	quarantineHandler(rr, req)
+	var list struct {
+		Entries []map[string]interface{} `json:"entries"`
+		Total   int                      `json:"total"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if list.Total != 1 || list.Entries[0]["key"] != "ttml_lyrics:bad" || list.Entries[0]["reason"] != "invalid entry JSON" {
+		t.Errorf("Unexpected quarantine listing: %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/cache/verify?repair=everything", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	verifyCacheHandler(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown repair mode, got %d", rr.Code)
+	}
+}
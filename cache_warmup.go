@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"lyrics-api-go/jobs"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/lyricsquery"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CacheWarmupResult is the result of a startup warmup job
+type CacheWarmupResult struct {
+	Lookups   int `json:"lookups"`    // Distinct lookups considered
+	FromFile  int `json:"from_file"`  // Lookups read from CACHE_WARMUP_FILE
+	FromStats int `json:"from_stats"` // Most requested lookups from the stats DB
+	Cached    int `json:"cached"`     // Already in the cache
+	Warmed    int `json:"warmed"`     // Fetched and cached
+	NoLyrics  int `json:"no_lyrics"`  // Upstream has no lyrics
+	Failed    int `json:"failed"`     // Errors, busy upstream, etc.
+}
+
+// startStartupCacheWarmup runs a warmup job when CACHE_WARMUP_ON_STARTUP is set, so
+// a fresh or restored cache serves its most requested lookups as hits sooner
+func startStartupCacheWarmup() {
+	if !conf().Configuration.CacheWarmupOnStartup {
+		return
+	}
+	fromFile, fromStats := loadWarmupLookups()
+	lookups := mergeWarmupLookups(fromFile, fromStats)
+	if len(lookups) == 0 {
+		log.Infof("%s Startup cache warmup: nothing to warm", logcolors.LogCache)
+		return
+	}
+
+	params := map[string]interface{}{"lookups": len(lookups), "startup": true}
+	job, err := jobManager.Start(jobKindWarmup, params, func(t *jobs.Task) (interface{}, error) {
+		result := CacheWarmupResult{FromFile: len(fromFile), FromStats: len(fromStats)}
+		return runCacheWarmup(t, lookups, result)
+	})
+	if err != nil {
+		log.Warnf("%s Startup cache warmup not started: %v", logcolors.LogCache, err)
+		return
+	}
+	log.Infof("%s Started startup cache warmup job %s (%d lookups)", logcolors.LogCache, job.ID, len(lookups))
+}
+
+// loadWarmupLookups reads CACHE_WARMUP_FILE and the CACHE_WARMUP_TOP_N most
+// requested lookups of the last CACHE_WARMUP_DAYS
+func loadWarmupLookups() (fromFile, fromStats []url.Values) {
+	if path := conf().Configuration.CacheWarmupFile; path != "" {
+		lookups, err := readWarmupFile(path)
+		if err != nil {
+			log.Warnf("%s Failed to read CACHE_WARMUP_FILE: %v", logcolors.LogCache, err)
+		}
+		fromFile = lookups
+	}
+
+	topN := conf().Configuration.CacheWarmupTopN
+	if topN <= 0 || statsStore == nil {
+		return fromFile, nil
+	}
+	from := time.Now().UTC().AddDate(0, 0, -(max(conf().Configuration.CacheWarmupDays, 1) - 1)).Format(time.DateOnly)
+	top, err := statsStore.TopLookups(from, topN)
+	if err != nil {
+		log.Warnf("%s Failed to read most requested lookups: %v", logcolors.LogCache, err)
+		return fromFile, nil
+	}
+	for _, entry := range top {
+		if lookup, ok := parseWarmupLookup(entry.Lookup); ok {
+			fromStats = append(fromStats, lookup)
+		}
+	}
+	return fromFile, fromStats
+}
+
+// readWarmupFile reads one lookup per line, as /getLyrics query strings. Blank
+// lines and lines starting with # are skipped.
+func readWarmupFile(path string) ([]url.Values, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lookups []url.Values
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		lookup, ok := parseWarmupLookup(text)
+		if !ok {
+			log.Warnf("%s Skipping CACHE_WARMUP_FILE line %d: song and artist are required", logcolors.LogCache, line)
+			continue
+		}
+		lookups = append(lookups, lookup)
+	}
+	return lookups, scanner.Err()
+}
+
+// parseWarmupLookup reads a /getLyrics query string into s/a/al/d params,
+// accepting the long param names too
+func parseWarmupLookup(raw string) (url.Values, bool) {
+	query, err := url.ParseQuery(strings.TrimPrefix(raw, "?"))
+	if err != nil {
+		return nil, false
+	}
+	q, err := lyricsquery.Parse(query)
+	if err != nil || !q.HasSongAndArtist() {
+		return nil, false
+	}
+	return q.Values(), true
+}
+
+// mergeWarmupLookups puts the listed lookups first and drops the ones that share
+// a cache key with an earlier lookup
+func mergeWarmupLookups(lists ...[]url.Values) []url.Values {
+	seen := make(map[string]bool)
+	var merged []url.Values
+	for _, list := range lists {
+		for _, lookup := range list {
+			key := buildNormalizedCacheKey(lookup.Get("s"), lookup.Get("a"), lookup.Get("al"), lookup.Get("d"))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, lookup)
+		}
+	}
+	return merged
+}
+
+// runCacheWarmup is the warmup job. Lookups already cached (within the duration
+// tolerance) are only counted; the rest go through lookupLyrics on the low-priority
+// lane, so warmup queues behind interactive traffic and respects the upstream limits.
+func runCacheWarmup(t *jobs.Task, lookups []url.Values, result CacheWarmupResult) (interface{}, error) {
+	result.Lookups = len(lookups)
+	t.SetStep("warm")
+	for i, lookup := range lookups {
+		if t.Cancelled() {
+			return result, t.Context().Err()
+		}
+		if _, _, ok := getCachedLyricsWithDurationTolerance(lookup.Get("s"), lookup.Get("a"), lookup.Get("al"), lookup.Get("d")); ok {
+			result.Cached++
+		} else {
+			outcome := lookupLyrics(t.Context(), lyricsLookup{
+				song:           lookup.Get("s"),
+				artist:         lookup.Get("a"),
+				album:          lookup.Get("al"),
+				duration:       lookup.Get("d"),
+				preferExplicit: conf().Configuration.PreferExplicit,
+				priority:       PriorityLow,
+			})
+			switch outcome.status {
+			case http.StatusOK:
+				result.Warmed++
+			case http.StatusNotFound:
+				result.NoLyrics++
+			default:
+				result.Failed++
+			}
+		}
+		t.SetProgress(i+1, len(lookups))
+	}
+
+	log.Infof("%s Warmup job %s complete: %d lookups, %d cached, %d warmed, %d without lyrics, %d failed",
+		logcolors.LogCache, t.ID(), result.Lookups, result.Cached, result.Warmed, result.NoLyrics, result.Failed)
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"lyrics-api-go/jobs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseWarmupLookup(t *testing.T) {
+	lookup, ok := parseWarmupLookup("?song=Hello&artist=Adele&album=25&duration=295&format=lrc")
+	if !ok || lookup.Encode() != "a=Adele&al=25&d=295&s=Hello" {
+		t.Errorf("parseWarmupLookup = %v, %v", lookup, ok)
+	}
+	for _, raw := range []string{"s=Hello", "a=Adele", "s=%zz&a=Adele"} {
+		if _, ok := parseWarmupLookup(raw); ok {
+			t.Errorf("parseWarmupLookup(%q) should fail", raw)
+		}
+	}
+}
+
+func TestReadWarmupFile_MergesWithStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "warmup.txt")
+	os.WriteFile(path, []byte("# top tracks\ns=Hello&a=Adele\n\ns=No Artist\nsong=Bohemian+Rhapsody&artist=Queen&d=354\n"), 0644)
+
+	fromFile, err := readWarmupFile(path)
+	if err != nil || len(fromFile) != 2 {
+		t.Fatalf("readWarmupFile = %v, %v", fromFile, err)
+	}
+
+	// Stats lookups that normalize to a listed cache key are dropped
+	fromStats := []url.Values{
+		{"s": {"hello"}, "a": {"ADELE"}},
+		{"s": {"Someone Like You"}, "a": {"Adele"}},
+	}
+	merged := mergeWarmupLookups(fromFile, fromStats)
+	if len(merged) != 3 || merged[0].Get("s") != "Hello" || merged[2].Get("s") != "Someone Like You" {
+		t.Errorf("Unexpected merge: %v", merged)
+	}
+}
+
+func TestRunCacheWarmup_CountsCachedAndNoLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	setCachedLyrics(buildNormalizedCacheKey("Hello", "Adele", "", "295"), formatTestTTML, 295000, 0.95, "en", false)
+	setNegativeCache(buildNormalizedCacheKey("Unknown", "Nobody", "", ""), "no track found", "", false)
+
+	lookups := []url.Values{
+		{"s": {"Hello"}, "a": {"Adele"}, "d": {"296"}}, // within the duration tolerance
+		{"s": {"Unknown"}, "a": {"Nobody"}},
+	}
+	m := jobs.NewManager(jobs.Options{})
+	started, err := m.Start(jobKindWarmup, nil, func(task *jobs.Task) (interface{}, error) {
+		return runCacheWarmup(task, lookups, CacheWarmupResult{FromFile: 2})
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, started.ID)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if job.Status != jobs.StatusCompleted {
+		t.Fatalf("Expected completed job, got %s (%s)", job.Status, job.Error)
+	}
+	result := job.Result.(CacheWarmupResult)
+	want := CacheWarmupResult{Lookups: 2, FromFile: 2, Cached: 1, NoLyrics: 1}
+	if result != want {
+		t.Errorf("Result = %+v, want %+v", result, want)
+	}
+}
@@ -2,6 +2,7 @@ package circuitbreaker
 
 import (
 	"errors"
+	"lyrics-api-go/clock"
 	"lyrics-api-go/logcolors"
 	"lyrics-api-go/services/notifier"
 	"sync"
@@ -36,6 +37,15 @@ var (
 	ErrCircuitOpen = errors.New("circuit breaker is open")
 )
 
+// ProbeStats tracks half-open test requests separately from regular failures
+type ProbeStats struct {
+	Attempts    int       `json:"attempts"`
+	Successes   int       `json:"successes"`
+	Failures    int       `json:"failures"`
+	LastProbeAt time.Time `json:"last_probe_at,omitempty"`
+	LastResult  string    `json:"last_result,omitempty"` // "success", "failure" or "timeout"
+}
+
 // CircuitBreaker implements the circuit breaker pattern
 type CircuitBreaker struct {
 	name            string
@@ -46,6 +56,8 @@ type CircuitBreaker struct {
 	halfOpenTimeout time.Duration // max time to wait in half-open state
 	lastFailureTime time.Time     // when circuit opened
 	halfOpenStart   time.Time     // when half-open state began
+	probes          ProbeStats
+	clock           clock.Clock
 	mu              sync.RWMutex
 }
 
@@ -55,6 +67,7 @@ type Config struct {
 	Threshold       int           // Number of consecutive failures before opening
 	Cooldown        time.Duration // How long to stay open before testing
 	HalfOpenTimeout time.Duration // Max time to wait in half-open state before resetting to open
+	Clock           clock.Clock   // Time source (nil = wall clock); tests inject a fake
 }
 
 // New creates a new circuit breaker
@@ -78,44 +91,57 @@ func New(cfg Config) *CircuitBreaker {
 		threshold:       cfg.Threshold,
 		cooldown:        cfg.Cooldown,
 		halfOpenTimeout: cfg.HalfOpenTimeout,
+		clock:           clock.OrReal(cfg.Clock),
 	}
 }
 
 // Allow checks if a request should be allowed
 // Returns true if the request can proceed, false if blocked
 func (cb *CircuitBreaker) Allow() bool {
+	allowed, _ := cb.AllowRequest()
+	return allowed
+}
+
+// AllowRequest is Allow that also reports whether the caller was granted the
+// half-open test request (probe). Probe callers should use their most reliable
+// upstream path, since the outcome decides whether the circuit closes.
+func (cb *CircuitBreaker) AllowRequest() (allowed bool, probe bool) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
 	switch cb.state {
 	case StateClosed:
-		return true
+		return true, false
 
 	case StateOpen:
 		// Check if cooldown has passed
-		if time.Since(cb.lastFailureTime) >= cb.cooldown {
+		if cb.clock.Since(cb.lastFailureTime) >= cb.cooldown {
 			cb.state = StateHalfOpen
-			cb.halfOpenStart = time.Now()
+			cb.halfOpenStart = cb.clock.Now()
+			cb.probes.Attempts++
+			cb.probes.LastProbeAt = cb.halfOpenStart
 			log.Infof("%s Cooldown passed, transitioning to HALF-OPEN", logcolors.CircuitBreakerPrefix(cb.name))
-			return true // Allow one test request
+			return true, true // Allow one test request
 		}
-		return false
+		return false, false
 
 	case StateHalfOpen:
 		// Check if half-open timeout has expired
-		if time.Since(cb.halfOpenStart) >= cb.halfOpenTimeout {
+		if cb.clock.Since(cb.halfOpenStart) >= cb.halfOpenTimeout {
 			// Test request timed out, reset to OPEN
 			cb.state = StateOpen
-			cb.lastFailureTime = time.Now()
+			cb.lastFailureTime = cb.clock.Now()
+			cb.probes.Failures++
+			cb.probes.LastResult = "timeout"
 			log.Warnf("%s Half-open timeout expired, transitioning back to OPEN", logcolors.CircuitBreakerPrefix(cb.name))
-			return false
+			return false, false
 		}
 		// Only allow one request at a time in half-open state
 		// The first request is already in progress, block others
-		return false
+		return false, false
 
 	default:
-		return true
+		return true, false
 	}
 }
 
@@ -128,6 +154,8 @@ func (cb *CircuitBreaker) RecordSuccess() {
 		// Test request succeeded, close the circuit
 		cb.state = StateClosed
 		cb.failures = 0
+		cb.probes.Successes++
+		cb.probes.LastResult = "success"
 		log.Infof("%s Test request succeeded, transitioning to CLOSED", logcolors.CircuitBreakerPrefix(cb.name))
 		// Emit recovery event
 		notifier.PublishCircuitBreakerRecovered(cb.name)
@@ -143,11 +171,13 @@ func (cb *CircuitBreaker) RecordFailure() {
 	defer cb.mu.Unlock()
 
 	cb.failures++
-	cb.lastFailureTime = time.Now()
+	cb.lastFailureTime = cb.clock.Now()
 
 	if cb.state == StateHalfOpen {
 		// Test request failed, back to open
 		cb.state = StateOpen
+		cb.probes.Failures++
+		cb.probes.LastResult = "failure"
 		log.Warnf("%s Test request failed, transitioning back to OPEN", logcolors.CircuitBreakerPrefix(cb.name))
 		// Emit circuit open event
 		notifier.PublishCircuitBreakerOpen(cb.name, cb.failures, cb.cooldown)
@@ -195,6 +225,13 @@ func (cb *CircuitBreaker) Stats() (state State, failures int, lastFailure time.T
 	return cb.state, cb.failures, cb.lastFailureTime
 }
 
+// Probes returns half-open test request statistics
+func (cb *CircuitBreaker) Probes() ProbeStats {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.probes
+}
+
 // Reset manually resets the circuit breaker to closed state
 func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
@@ -223,14 +260,14 @@ func (cb *CircuitBreaker) TimeUntilRetry() time.Duration {
 
 	switch cb.state {
 	case StateOpen:
-		elapsed := time.Since(cb.lastFailureTime)
+		elapsed := cb.clock.Since(cb.lastFailureTime)
 		if elapsed >= cb.cooldown {
 			return 0
 		}
 		return cb.cooldown - elapsed
 
 	case StateHalfOpen:
-		elapsed := time.Since(cb.halfOpenStart)
+		elapsed := cb.clock.Since(cb.halfOpenStart)
 		if elapsed >= cb.halfOpenTimeout {
 			return 0
 		}
@@ -254,3 +291,18 @@ func (cb *CircuitBreaker) Threshold() int {
 	defer cb.mu.RUnlock()
 	return cb.threshold
 }
+
+// SetThreshold changes the failure threshold at runtime (minimum 1). The current
+// failure count is kept: if it already meets a lowered threshold, the next failure
+// opens the circuit.
+func (cb *CircuitBreaker) SetThreshold(threshold int) {
+	threshold = max(threshold, 1)
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.threshold == threshold {
+		return
+	}
+	log.Infof("%s Threshold adjusted %d -> %d", logcolors.CircuitBreakerPrefix(cb.name), cb.threshold, threshold)
+	cb.threshold = threshold
+}
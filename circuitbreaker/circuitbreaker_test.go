@@ -1,6 +1,7 @@
 package circuitbreaker
 
 import (
+	"lyrics-api-go/internal/clocktest"
 	"testing"
 	"time"
 )
@@ -102,7 +103,8 @@ func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
 }
 
 func TestCircuitBreaker_TransitionsToHalfOpen(t *testing.T) {
-	cb := New(Config{Threshold: 2, Cooldown: 100 * time.Millisecond})
+	fake := clocktest.NewFake(time.Now())
+	cb := New(Config{Threshold: 2, Cooldown: 100 * time.Millisecond, Clock: fake})
 
 	// Trip the circuit
 	cb.RecordFailure()
@@ -113,7 +115,7 @@ func TestCircuitBreaker_TransitionsToHalfOpen(t *testing.T) {
 	}
 
 	// Wait for cooldown
-	time.Sleep(150 * time.Millisecond)
+	fake.Advance(150 * time.Millisecond)
 
 	// Next Allow() should transition to half-open and return true
 	if !cb.Allow() {
@@ -126,14 +128,15 @@ func TestCircuitBreaker_TransitionsToHalfOpen(t *testing.T) {
 }
 
 func TestCircuitBreaker_HalfOpenSuccess(t *testing.T) {
-	cb := New(Config{Threshold: 2, Cooldown: 50 * time.Millisecond})
+	fake := clocktest.NewFake(time.Now())
+	cb := New(Config{Threshold: 2, Cooldown: 50 * time.Millisecond, Clock: fake})
 
 	// Trip the circuit
 	cb.RecordFailure()
 	cb.RecordFailure()
 
 	// Wait for cooldown and transition to half-open
-	time.Sleep(60 * time.Millisecond)
+	fake.Advance(60 * time.Millisecond)
 	cb.Allow()
 
 	if cb.State() != StateHalfOpen {
@@ -153,14 +156,15 @@ func TestCircuitBreaker_HalfOpenSuccess(t *testing.T) {
 }
 
 func TestCircuitBreaker_HalfOpenFailure(t *testing.T) {
-	cb := New(Config{Threshold: 2, Cooldown: 50 * time.Millisecond})
+	fake := clocktest.NewFake(time.Now())
+	cb := New(Config{Threshold: 2, Cooldown: 50 * time.Millisecond, Clock: fake})
 
 	// Trip the circuit
 	cb.RecordFailure()
 	cb.RecordFailure()
 
 	// Wait for cooldown and transition to half-open
-	time.Sleep(60 * time.Millisecond)
+	fake.Advance(60 * time.Millisecond)
 	cb.Allow()
 
 	if cb.State() != StateHalfOpen {
@@ -175,15 +179,60 @@ func TestCircuitBreaker_HalfOpenFailure(t *testing.T) {
 	}
 }
 
+func TestCircuitBreaker_ProbeStats(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	cb := New(Config{Threshold: 1, Cooldown: time.Minute, HalfOpenTimeout: 10 * time.Second, Clock: fake})
+
+	cb.RecordFailure()
+
+	// Regular requests while open are not probes
+	if allowed, probe := cb.AllowRequest(); allowed || probe {
+		t.Fatalf("Expected blocked non-probe while OPEN, got allowed=%v probe=%v", allowed, probe)
+	}
+
+	// Probe fails
+	fake.Advance(time.Minute)
+	if allowed, probe := cb.AllowRequest(); !allowed || !probe {
+		t.Fatalf("Expected the half-open probe, got allowed=%v probe=%v", allowed, probe)
+	}
+	cb.RecordFailure()
+
+	// Probe times out
+	fake.Advance(time.Minute)
+	cb.AllowRequest()
+	fake.Advance(10 * time.Second)
+	cb.AllowRequest()
+	if got := cb.Probes().LastResult; got != "timeout" {
+		t.Errorf("Expected last result timeout, got %q", got)
+	}
+
+	// Probe succeeds
+	fake.Advance(time.Minute)
+	cb.AllowRequest()
+	cb.RecordSuccess()
+
+	probes := cb.Probes()
+	if probes.Attempts != 3 || probes.Failures != 2 || probes.Successes != 1 || probes.LastResult != "success" {
+		t.Errorf("Unexpected probe stats: %+v", probes)
+	}
+
+	// Failures while closed don't count as probes
+	cb.RecordFailure()
+	if cb.Probes().Failures != 2 {
+		t.Errorf("Regular failure counted as probe failure: %+v", cb.Probes())
+	}
+}
+
 func TestCircuitBreaker_HalfOpenBlocksMultipleRequests(t *testing.T) {
-	cb := New(Config{Threshold: 2, Cooldown: 50 * time.Millisecond})
+	fake := clocktest.NewFake(time.Now())
+	cb := New(Config{Threshold: 2, Cooldown: 50 * time.Millisecond, Clock: fake})
 
 	// Trip the circuit
 	cb.RecordFailure()
 	cb.RecordFailure()
 
 	// Wait for cooldown and transition to half-open
-	time.Sleep(60 * time.Millisecond)
+	fake.Advance(60 * time.Millisecond)
 
 	// First request should be allowed
 	if !cb.Allow() {
@@ -224,8 +273,34 @@ func TestCircuitBreaker_Reset(t *testing.T) {
 	}
 }
 
+func TestCircuitBreaker_SetThreshold(t *testing.T) {
+	cb := New(Config{Threshold: 6, Cooldown: time.Minute})
+
+	cb.RecordFailure()
+	cb.RecordFailure()
+
+	// Lowering the threshold keeps the failure count; the next failure trips
+	cb.SetThreshold(2)
+	if cb.Threshold() != 2 {
+		t.Fatalf("Expected threshold 2, got %d", cb.Threshold())
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("Expected CLOSED until the next failure, got %s", cb.State())
+	}
+	cb.RecordFailure()
+	if cb.State() != StateOpen {
+		t.Errorf("Expected OPEN after failure over lowered threshold, got %s", cb.State())
+	}
+
+	cb.SetThreshold(0)
+	if cb.Threshold() != 1 {
+		t.Errorf("Expected threshold clamped to 1, got %d", cb.Threshold())
+	}
+}
+
 func TestCircuitBreaker_TimeUntilRetry(t *testing.T) {
-	cb := New(Config{Threshold: 2, Cooldown: 100 * time.Millisecond})
+	fake := clocktest.NewFake(time.Now())
+	cb := New(Config{Threshold: 2, Cooldown: 100 * time.Millisecond, Clock: fake})
 
 	// Closed state should return 0
 	if cb.TimeUntilRetry() != 0 {
@@ -243,7 +318,7 @@ func TestCircuitBreaker_TimeUntilRetry(t *testing.T) {
 	}
 
 	// Wait for cooldown
-	time.Sleep(110 * time.Millisecond)
+	fake.Advance(110 * time.Millisecond)
 
 	// Should return 0 after cooldown
 	if cb.TimeUntilRetry() != 0 {
@@ -335,7 +410,8 @@ func TestCircuitBreaker_ConcurrentAccess(t *testing.T) {
 }
 
 func TestCircuitBreaker_IsHalfOpen(t *testing.T) {
-	cb := New(Config{Threshold: 2, Cooldown: 50 * time.Millisecond})
+	fake := clocktest.NewFake(time.Now())
+	cb := New(Config{Threshold: 2, Cooldown: 50 * time.Millisecond, Clock: fake})
 
 	// Should not be half-open initially
 	if cb.IsHalfOpen() {
@@ -352,7 +428,7 @@ func TestCircuitBreaker_IsHalfOpen(t *testing.T) {
 	}
 
 	// Wait for cooldown and transition to half-open
-	time.Sleep(60 * time.Millisecond)
+	fake.Advance(60 * time.Millisecond)
 	cb.Allow()
 
 	// Should be half-open now
@@ -368,10 +444,12 @@ func TestCircuitBreaker_IsHalfOpen(t *testing.T) {
 }
 
 func TestCircuitBreaker_HalfOpenTimeout(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
 	cb := New(Config{
 		Threshold:       2,
 		Cooldown:        50 * time.Millisecond,
 		HalfOpenTimeout: 100 * time.Millisecond,
+		Clock:           fake,
 	})
 
 	// Trip the circuit
@@ -383,7 +461,7 @@ func TestCircuitBreaker_HalfOpenTimeout(t *testing.T) {
 	}
 
 	// Wait for cooldown and transition to half-open
-	time.Sleep(60 * time.Millisecond)
+	fake.Advance(60 * time.Millisecond)
 
 	// First call should transition to HALF-OPEN and allow
 	if !cb.Allow() {
@@ -399,7 +477,7 @@ func TestCircuitBreaker_HalfOpenTimeout(t *testing.T) {
 	}
 
 	// Wait for half-open timeout to expire
-	time.Sleep(110 * time.Millisecond)
+	fake.Advance(110 * time.Millisecond)
 
 	// Next Allow() should detect timeout, reset to OPEN, and return false
 	if cb.Allow() {
@@ -411,10 +489,12 @@ func TestCircuitBreaker_HalfOpenTimeout(t *testing.T) {
 }
 
 func TestCircuitBreaker_HalfOpenTimeUntilRetry(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
 	cb := New(Config{
 		Threshold:       2,
 		Cooldown:        50 * time.Millisecond,
 		HalfOpenTimeout: 100 * time.Millisecond,
+		Clock:           fake,
 	})
 
 	// Trip the circuit
@@ -422,7 +502,7 @@ func TestCircuitBreaker_HalfOpenTimeUntilRetry(t *testing.T) {
 	cb.RecordFailure()
 
 	// Wait for cooldown and transition to half-open
-	time.Sleep(60 * time.Millisecond)
+	fake.Advance(60 * time.Millisecond)
 	cb.Allow()
 
 	if cb.State() != StateHalfOpen {
@@ -436,7 +516,7 @@ func TestCircuitBreaker_HalfOpenTimeUntilRetry(t *testing.T) {
 	}
 
 	// Wait for timeout to expire
-	time.Sleep(110 * time.Millisecond)
+	fake.Advance(110 * time.Millisecond)
 
 	// TimeUntilRetry should return 0 after timeout
 	if cb.TimeUntilRetry() != 0 {
@@ -445,10 +525,12 @@ func TestCircuitBreaker_HalfOpenTimeUntilRetry(t *testing.T) {
 }
 
 func TestCircuitBreaker_HalfOpenTransitionRecordsStart(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
 	cb := New(Config{
 		Threshold:       2,
 		Cooldown:        50 * time.Millisecond,
 		HalfOpenTimeout: 100 * time.Millisecond,
+		Clock:           fake,
 	})
 
 	// Initially halfOpenStart should be zero
@@ -466,33 +548,33 @@ func TestCircuitBreaker_HalfOpenTransitionRecordsStart(t *testing.T) {
 	}
 
 	// Wait for cooldown and transition to half-open
-	time.Sleep(60 * time.Millisecond)
-	beforeTransition := time.Now()
+	fake.Advance(60 * time.Millisecond)
 	cb.Allow()
-	afterTransition := time.Now()
 
 	// halfOpenStart should be set after transition
 	if cb.halfOpenStart.IsZero() {
 		t.Error("Expected halfOpenStart to be set after transition to HALF-OPEN")
 	}
 
-	// halfOpenStart should be between before and after transition
-	if cb.halfOpenStart.Before(beforeTransition) || cb.halfOpenStart.After(afterTransition) {
-		t.Errorf("halfOpenStart %v not between %v and %v", cb.halfOpenStart, beforeTransition, afterTransition)
+	// halfOpenStart should be the (frozen) time of the transition
+	if !cb.halfOpenStart.Equal(fake.Now()) {
+		t.Errorf("halfOpenStart %v, want %v", cb.halfOpenStart, fake.Now())
 	}
 }
 
 func TestCircuitBreaker_ResetClearsHalfOpenStart(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
 	cb := New(Config{
 		Threshold:       2,
 		Cooldown:        50 * time.Millisecond,
 		HalfOpenTimeout: 100 * time.Millisecond,
+		Clock:           fake,
 	})
 
 	// Trip the circuit and transition to half-open
 	cb.RecordFailure()
 	cb.RecordFailure()
-	time.Sleep(60 * time.Millisecond)
+	fake.Advance(60 * time.Millisecond)
 	cb.Allow()
 
 	if cb.halfOpenStart.IsZero() {
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/middleware"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Response features that can be gated on the client version (CLIENT_FEATURE_VERSIONS),
+// each with the lyrics body fields it adds. A client listed for a feature gets the
+// fields only from the configured version up, so an older release that chokes on
+// them keeps working; clients not listed, and requests that name no client, always
+// get them.
+var clientFeatureFields = map[string][]string{
+	"score":    {"score"},                                                        // Match score next to the TTML
+	"metadata": {"language", "isRTL", "trackDurationMs", "trackId", "trackName"}, // Match metadata next to the TTML
+}
+
+var invalidClientFeaturesOnce sync.Once
+
+// requestClient returns the client named in the request's User-Agent, if any
+func requestClient(r *http.Request) (middleware.ClientInfo, bool) {
+	client, ok := r.Context().Value(clientInfoKey).(middleware.ClientInfo)
+	return client, ok
+}
+
+// clientSupports reports whether the request's client may receive a feature
+func clientSupports(r *http.Request, feature string) bool {
+	client, ok := requestClient(r)
+	if !ok {
+		return true
+	}
+	gates, err := conf().GetClientFeatureVersions()
+	if err != nil {
+		invalidClientFeaturesOnce.Do(func() {
+			log.Warnf("%s Ignoring CLIENT_FEATURE_VERSIONS: %v", logcolors.LogConfig, err)
+		})
+		return true
+	}
+	minVersion, gated := gates[feature][client.Name]
+	if !gated {
+		return true
+	}
+	return client.Version != "" && middleware.CompareVersions(client.Version, minVersion) >= 0
+}
+
+// applyClientGates removes the body fields of features the request's client is
+// too old for
+func applyClientGates(r *http.Request, body map[string]interface{}) {
+	if _, ok := requestClient(r); !ok {
+		return
+	}
+	for feature, fields := range clientFeatureFields {
+		if clientSupports(r, feature) {
+			continue
+		}
+		for _, field := range fields {
+			delete(body, field)
+		}
+	}
+}
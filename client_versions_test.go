@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"lyrics-api-go/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func withClient(r *http.Request, name, version string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientInfoKey, middleware.ClientInfo{Name: name, Version: version}))
+}
+
+func TestClientSupports(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	originalGates := conf().Configuration.ClientFeatureVersions
+	conf().Configuration.ClientFeatureVersions = "score:betterlyrics/2.0.0"
+	defer func() { conf().Configuration.ClientFeatureVersions = originalGates }()
+
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics", nil)
+	tests := []struct {
+		name string
+		r    *http.Request
+		want bool
+	}{
+		{"no client", req, true},
+		{"old version", withClient(req, "betterlyrics", "1.9.4"), false},
+		{"minimum version", withClient(req, "betterlyrics", "2.0.0"), true},
+		{"newer version", withClient(req, "betterlyrics", "2.10"), true},
+		{"no version", withClient(req, "betterlyrics", ""), false},
+		{"other client", withClient(req, "curl", "1.0"), true},
+	}
+	for _, tt := range tests {
+		if got := clientSupports(tt.r, "score"); got != tt.want {
+			t.Errorf("%s: clientSupports = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRespondTTML_GatesScoreByClientVersion(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	originalGates := conf().Configuration.ClientFeatureVersions
+	conf().Configuration.ClientFeatureVersions = "score:betterlyrics/2.0.0"
+	defer func() { conf().Configuration.ClientFeatureVersions = originalGates }()
+
+	for _, tt := range []struct {
+		version   string
+		wantScore bool
+	}{{"1.5.0", false}, {"2.1.0", true}} {
+		req := withClient(httptest.NewRequest(http.MethodGet, "/getLyrics", nil), "betterlyrics", tt.version)
+		rr := httptest.NewRecorder()
+		respondTTML(Respond(rr, req), formatTTML, formatTestTTML, map[string]interface{}{
+			"ttml":  formatTestTTML,
+			"score": 0.9,
+		})
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if _, hasScore := body["score"]; hasScore != tt.wantScore {
+			t.Errorf("version %s: score present = %v, want %v", tt.version, hasScore, tt.wantScore)
+		}
+		if body["ttml"] != formatTestTTML {
+			t.Errorf("version %s: ttml missing", tt.version)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Package clock abstracts the current time so expiry logic (quarantine, circuit
+// breaker cooldowns, negative cache TTLs, token refresh) can be tested without sleeps.
+package clock
+
+import "time"
+
+// Clock tells the current time. Production code uses Real; tests inject
+// internal/clocktest.Fake and advance it explicitly.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Until(t time.Time) time.Duration
+}
+
+// Real is the wall clock
+type Real struct{}
+
+func (Real) Now() time.Time                  { return time.Now() }
+func (Real) Since(t time.Time) time.Duration { return time.Since(t) }
+func (Real) Until(t time.Time) time.Duration { return time.Until(t) }
+
+// OrReal returns c, or the wall clock when c is nil
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real{}
+	}
+	return c
+}
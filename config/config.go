@@ -3,30 +3,57 @@ package config
 import (
 	"fmt"
 	"lyrics-api-go/logcolors"
+	"regexp"
 	"strings"
+	"sync/atomic"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	log "github.com/sirupsen/logrus"
 )
 
-var conf = mustLoad()
+// current holds the active config; remote reloads (remote.go) swap it
+var current atomic.Pointer[Config]
+
+func init() {
+	c := mustLoad()
+	current.Store(&c)
+}
 
 type Config struct {
 	Configuration struct {
+		// Deployment profile (prod, selfhost) - see profile.go for the defaults each one applies
+		Profile string `envconfig:"PROFILE" default:"prod"`
+
 		// Provider Settings
-		DefaultProvider string `envconfig:"DEFAULT_PROVIDER" default:"ttml"` // Default lyrics provider (ttml, kugou, legacy)
+		DefaultProvider    string `envconfig:"DEFAULT_PROVIDER" default:"ttml"`                                  // Default lyrics provider (ttml, kugou, legacy)
+		ProviderOrderRules string `envconfig:"PROVIDER_ORDER_RULES" default:"cjk=kugou,ttml;default=ttml,kugou"` // Provider order for /auto/getLyrics by script or lang:xx locale hint, first match wins
 
 		// Rate Limiting
 		RateLimitPerSecond                 int    `envconfig:"RATE_LIMIT_PER_SECOND" default:"2"`
 		RateLimitBurstLimit                int    `envconfig:"RATE_LIMIT_BURST_LIMIT" default:"5"`
 		CachedRateLimitPerSecond           int    `envconfig:"CACHED_RATE_LIMIT_PER_SECOND" default:"10"`
 		CachedRateLimitBurstLimit          int    `envconfig:"CACHED_RATE_LIMIT_BURST_LIMIT" default:"20"`
+		PrefetchMaxConcurrent              int    `envconfig:"PREFETCH_MAX_CONCURRENT" default:"2"`
+		PrefetchQueueTimeoutSecs           int    `envconfig:"PREFETCH_QUEUE_TIMEOUT_SECS" default:"30"`
+		UpstreamMaxConcurrent              int    `envconfig:"UPSTREAM_MAX_CONCURRENT" default:"32"`        // Concurrent upstream lookups across all clients; beyond it requests queue (0 = unlimited)
+		UpstreamQueueTimeoutSecs           int    `envconfig:"UPSTREAM_QUEUE_TIMEOUT_SECS" default:"5"`     // How long a lookup queues for UPSTREAM_MAX_CONCURRENT before a 503 with Retry-After
+		PressureMaxGoroutines              int    `envconfig:"PRESSURE_MAX_GOROUTINES" default:"0"`         // Shed load (cache-only for unauthenticated clients) above this many goroutines (0 = off)
+		PressureMaxUpstreamInFlight        int    `envconfig:"PRESSURE_MAX_UPSTREAM_IN_FLIGHT" default:"0"` // Shed load above this many upstream lookups in progress (0 = off)
+		PressureMaxCoalescingWaiters       int    `envconfig:"PRESSURE_MAX_COALESCING_WAITERS" default:"0"` // Shed load above this many requests waiting on another's lookup (0 = off)
+		PressureMaxWriteQueue              int    `envconfig:"PRESSURE_MAX_WRITE_QUEUE" default:"0"`        // Shed load above this many background cache writes pending (0 = off)
 		CacheInvalidationIntervalInSeconds int    `envconfig:"CACHE_INVALIDATION_INTERVAL_IN_SECONDS" default:"3600"`
 		LyricsCacheTTLInSeconds            int    `envconfig:"LYRICS_CACHE_TTL_IN_SECONDS" default:"86400"`
 		CacheAccessToken                   string `envconfig:"CACHE_ACCESS_TOKEN" default:""`
 		APIKey                             string `envconfig:"API_KEY" default:""`
 		APIKeyRequired                     bool   `envconfig:"API_KEY_REQUIRED" default:"false"`
+		APIKeyClients                      string `envconfig:"API_KEY_CLIENTS" default:""`               // "key:client,..." - response shape for requests without client= (see transformers.go)
+		APIKeyTenants                      string `envconfig:"API_KEY_TENANTS" default:""`               // "key:tenant,..." - extra API keys, each bound to a tenant namespace with its own cache keys, quota and stats
+		TenantRateLimitPerSecond           int    `envconfig:"TENANT_RATE_LIMIT_PER_SECOND" default:"0"` // Requests per second allowed per tenant across all its users (0 = no quota)
+		TenantRateLimitBurst               int    `envconfig:"TENANT_RATE_LIMIT_BURST" default:"0"`      // Burst of the tenant quota (0 = same as the per-second rate)
+		ClientFeatureVersions              string `envconfig:"CLIENT_FEATURE_VERSIONS" default:""`       // "feature:client/version,..." - minimum client version (from User-Agent) for a response feature (see client_versions.go)
+		DeprecationSunset                  string `envconfig:"DEPRECATION_SUNSET" default:""`            // YYYY-MM-DD after which deprecated parameters and fields may stop working, sent with deprecation warnings
+		DeprecatedResponseFields           string `envconfig:"DEPRECATED_RESPONSE_FIELDS" default:""`    // Comma-separated response fields slated for removal; responses containing them carry a warning
 		BiniAPIKey                         string `envconfig:"BINI_API_KEY" default:""`
 		BiniAPIURL                         string `envconfig:"BINI_API_URL" default:"https://kansas.lyric-api.binimum.org/"`
 		BiniSecretKey                      string `envconfig:"BINI_SECRET_KEY" default:""`
@@ -44,12 +71,83 @@ type Config struct {
 		TTMLBaseURL                string  `envconfig:"TTML_BASE_URL" default:""`
 		TTMLSearchPath             string  `envconfig:"TTML_SEARCH_PATH" default:""`
 		TTMLLyricsPath             string  `envconfig:"TTML_LYRICS_PATH" default:""`
+		TTMLUserAgents             string  `envconfig:"TTML_USER_AGENTS" default:""` // "|"-separated User-Agent pool; each account always uses the same one (empty = built-in pool)
 		MinSimilarityScore         float64 `envconfig:"MIN_SIMILARITY_SCORE" default:"0.6"`
-		DurationMatchDeltaMs       int     `envconfig:"DURATION_MATCH_DELTA_MS" default:"2000"`      // Strict duration filter: reject tracks outside this delta (in ms)
-		NegativeCacheTTLInDays     int     `envconfig:"NEGATIVE_CACHE_TTL_DAYS" default:"7"`         // TTL for caching "no lyrics found" responses
-		NewSongThresholdDays       int     `envconfig:"NEW_SONG_THRESHOLD_DAYS" default:"30"`        // Songs within this window get graduated shorter negative cache TTL
-		CircuitBreakerThreshold    int     `envconfig:"CIRCUIT_BREAKER_THRESHOLD" default:"5"`       // Consecutive failures before circuit opens
-		CircuitBreakerCooldownSecs int     `envconfig:"CIRCUIT_BREAKER_COOLDOWN_SECS" default:"300"` // Seconds to wait before retrying (default: 5 minutes)
+		LearnedAliasMinScore       float64 `envconfig:"LEARNED_ALIAS_MIN_SCORE" default:"0.9"`        // Searches matching at least this well are remembered, and later misses for the query skip search (0 = off)
+		MinLyricsLinesPerMinute    float64 `envconfig:"MIN_LYRICS_LINES_PER_MINUTE" default:"2"`      // Fewer parsed lines per minute of track marks fetched lyrics as truncated: served, not cached (0 = off)
+		MinLyricsCoverageRatio     float64 `envconfig:"MIN_LYRICS_COVERAGE_RATIO" default:"0.5"`      // Synced lyrics ending before this share of the track are truncated: served, not cached (0 = off)
+		DurationMatchDeltaMs       int     `envconfig:"DURATION_MATCH_DELTA_MS" default:"2000"`       // Strict duration filter: reject tracks outside this delta (in ms)
+		PreferExplicit             bool    `envconfig:"PREFER_EXPLICIT" default:"true"`               // Pick the explicit release over the clean one when both match (override per request with explicit=)
+		LocaleStorefronts          bool    `envconfig:"LOCALE_STOREFRONTS" default:"true"`            // Prefer accounts in the storefront the request's Accept-Language points to
+		LyricsPostProcess          string  `envconfig:"LYRICS_POSTPROCESS" default:""`                // Steps applied to all served lyric text: whitespace, quotes, profanity (see postprocess/)
+		ProfanityWordlistPath      string  `envconfig:"PROFANITY_WORDLIST_PATH" default:""`           // Words masked by clean=true, one per line (empty = built-in list)
+		NegativeCacheTTLInDays     int     `envconfig:"NEGATIVE_CACHE_TTL_DAYS" default:"7"`          // TTL for caching "no lyrics found" responses
+		MaxStaleAgeDays            int     `envconfig:"MAX_STALE_AGE_DAYS" default:"0"`               // Entries stored longer ago than this aren't served as STALE fallbacks (0 = no limit)
+		NewSongThresholdDays       int     `envconfig:"NEW_SONG_THRESHOLD_DAYS" default:"30"`         // Songs within this window get graduated shorter negative cache TTL
+		CircuitBreakerThreshold    int     `envconfig:"CIRCUIT_BREAKER_THRESHOLD" default:"5"`        // Consecutive failures before circuit opens, per healthy account
+		CircuitBreakerCooldownSecs int     `envconfig:"CIRCUIT_BREAKER_COOLDOWN_SECS" default:"300"`  // Seconds to wait before retrying (default: 5 minutes)
+		UpstreamFixturesDir        string  `envconfig:"UPSTREAM_FIXTURES_DIR" default:"./fixtures"`   // Where /debug/recording writes sanitized upstream responses
+		ReplayCaptureSize          int     `envconfig:"REPLAY_CAPTURE_SIZE" default:"50"`             // Failed lookups kept for /debug/replay and /errors (0 = disabled)
+		PersistFailedLookups       bool    `envconfig:"PERSIST_FAILED_LOOKUPS" default:"false"`       // Keep the failed lookups in the cache DB across restarts
+		UsageRetentionDays         int     `envconfig:"USAGE_RETENTION_DAYS" default:"90"`            // Days of anonymized usage rows kept for /stats/export (0 = forever)
+		StatsArchiveRetentionDays  int     `envconfig:"STATS_ARCHIVE_RETENTION_DAYS" default:"365"`   // Days /stats/reset archives are kept (0 = forever)
+		TTMLSearchCacheTTLSecs     int     `envconfig:"TTML_SEARCH_CACHE_TTL_SECS" default:"600"`     // Reuse search results for the same query+storefront (0 = disabled)
+		AccountExpiringWindowHours int     `envconfig:"ACCOUNT_EXPIRING_WINDOW_HOURS" default:"24"`   // Accounts whose token expires within this window are used last
+		SelfTestSong               string  `envconfig:"SELFTEST_SONG" default:"Breathe (In the Air)"` // Canary query for /selftest
+		SelfTestArtist             string  `envconfig:"SELFTEST_ARTIST" default:"Pink Floyd"`         // Artist for the /selftest canary
+		SelfTestChecksum           string  `envconfig:"SELFTEST_CHECKSUM" default:""`                 // Expected sha256 of the canary's plain-text lyrics (empty = skip compare)
+		AlertWindowMinutes         int     `envconfig:"ALERT_WINDOW_MINUTES" default:"15"`            // Rolling window for the hit rate / upstream error rate alerts
+		AlertMinCacheHitRate       float64 `envconfig:"ALERT_MIN_CACHE_HIT_RATE" default:"50"`        // Percent; warn when the windowed cache hit rate drops below (0 = off)
+		AlertMaxUpstreamErrorRate  float64 `envconfig:"ALERT_MAX_UPSTREAM_ERROR_RATE" default:"20"`   // Percent; warn when the windowed upstream error rate exceeds (0 = off)
+		AlertMaxMalformedRate      float64 `envconfig:"ALERT_MAX_MALFORMED_RATE" default:"5"`         // Percent; alert when this share of upstream requests fails the schema checks (0 = off)
+		AlertMinSamples            int64   `envconfig:"ALERT_MIN_SAMPLES" default:"50"`               // Minimum lookups/requests in the window before a rate is judged
+		UpstreamMaxTTMLBytes       int64   `envconfig:"UPSTREAM_MAX_TTML_BYTES" default:"5242880"`    // Cap on a lyrics response body (5 MB)
+		UpstreamMaxSearchBytes     int64   `envconfig:"UPSTREAM_MAX_SEARCH_BYTES" default:"1048576"`  // Cap on a search response body (1 MB)
+		UpstreamMaxBodyBytes       int64   `envconfig:"UPSTREAM_MAX_BODY_BYTES" default:"10485760"`   // Cap on any other upstream body, e.g. the token source JS bundle (10 MB)
+		MaxLyricsResponseBytes     int64   `envconfig:"MAX_LYRICS_RESPONSE_BYTES" default:"0"`        // Cap on a /getLyrics JSON body; larger ones are downgraded or rejected (0 = no cap)
+		OversizeResponseAction     string  `envconfig:"OVERSIZE_RESPONSE_ACTION" default:"downgrade"` // Over the cap: "downgrade" drops syllable timing if that fits, else 413; "reject" always 413s
+		StorefrontInitWorkers      int     `envconfig:"STOREFRONT_INIT_WORKERS" default:"8"`          // Concurrent account storefront fetches at startup
+		StorefrontFetchTimeoutSecs int     `envconfig:"STOREFRONT_FETCH_TIMEOUT_SECS" default:"10"`   // Timeout for one account's storefront fetch
+		StorefrontRevalidateHours  int     `envconfig:"STOREFRONT_REVALIDATE_HOURS" default:"168"`    // Re-check each account's storefront this often (0 = never)
+		DisabledProbeMinutes       int     `envconfig:"DISABLED_PROBE_MINUTES" default:"60"`          // Canary-check disabled accounts this often (0 = they stay disabled until restart)
+		DisabledProbeSuccesses     int     `envconfig:"DISABLED_PROBE_SUCCESSES" default:"3"`         // Consecutive canary successes that re-enable a disabled account
+		LogLevel                   string  `envconfig:"LOG_LEVEL" default:"info"`                     // Global level, optionally with overrides: "info,parser=debug,cache=warn"
+		LogThrottle                string  `envconfig:"LOG_THROTTLE" default:"5"`                     // Hot-path lines per message per second, optionally per component: "5,lyrics=20,ttml=0" (0 = unthrottled)
+		GRPCPort                   string  `envconfig:"GRPC_PORT" default:""`                         // Serve the gRPC API (lyricspb/lyrics.proto) on this port, admin token required (empty = off)
+		PprofListenAddr            string  `envconfig:"PPROF_LISTEN_ADDR" default:""`                 // Serve pprof and expvar without auth on this address, e.g. 127.0.0.1:6060 (empty = admin router only)
+		TrashRetentionHours        int     `envconfig:"TRASH_RETENTION_HOURS" default:"168"`          // Entries deleted by admin operations stay restorable from /cache/trash this long (0 = delete permanently)
+		JobRetentionHours          int     `envconfig:"JOB_RETENTION_HOURS" default:"24"`             // Finished admin jobs (migrate, analyze, ...) stay listed this long (0 = forever)
+		JobMaxFinished             int     `envconfig:"JOB_MAX_FINISHED" default:"50"`                // Cap on finished admin jobs kept, oldest dropped first
+		CacheCorruptionAlertCount  int     `envconfig:"CACHE_CORRUPTION_ALERT_COUNT" default:"5"`     // Alert when this many corrupt entries are found within the window (0 = never)
+		CacheCorruptionAlertMins   int     `envconfig:"CACHE_CORRUPTION_ALERT_MINUTES" default:"60"`  // Rolling window for the corruption alert
+		NotifyLyricsChanges        bool    `envconfig:"NOTIFY_LYRICS_CHANGES" default:"false"`        // Send a lyrics_changed event when a re-fetch finds different lyrics for a cached track
+		DiskLowFreeMB              int     `envconfig:"DISK_LOW_FREE_MB" default:"1024"`              // Alert when the cache or backup volume has less free space (0 = off)
+		DiskReadOnlyFreeMB         int     `envconfig:"DISK_READONLY_FREE_MB" default:"256"`          // Stop cache writes (hits are still served) below this free space on the cache volume (0 = never)
+		CacheOpenTimeoutSecs       int     `envconfig:"CACHE_OPEN_TIMEOUT_SECS" default:"10"`         // Wait this long for the cache.db file lock per attempt (0 = forever)
+		CacheOpenAttempts          int     `envconfig:"CACHE_OPEN_ATTEMPTS" default:"3"`              // Attempts at the lock, with backoff, before startup fails
+		BackupEncryptionKey        string  `envconfig:"BACKUP_ENCRYPTION_KEY" default:""`             // Base64 32-byte AES-256 key; new cache backups are encrypted with it (empty = off)
+		BackupEncryptionKeyURI     string  `envconfig:"BACKUP_ENCRYPTION_KEY_URI" default:""`         // Read the key from file:///path or env://VAR instead (e.g. a KMS-decrypted secret mount)
+		RemoteConfigURL            string  `envconfig:"REMOTE_CONFIG_URL" default:""`                 // Poll this JSON document for reloadable settings, signed at <url>.sig (empty = off)
+		RemoteConfigSecret         string  `envconfig:"REMOTE_CONFIG_SECRET" default:""`              // HMAC-SHA256 key the document's signature must match
+		RemoteConfigPollSecs       int     `envconfig:"REMOTE_CONFIG_POLL_SECS" default:"60"`         // How often replicas check the document (with If-None-Match)
+		CacheVerifyOnStartup       string  `envconfig:"CACHE_VERIFY_ON_STARTUP" default:""`           // Run a /cache/verify job at startup: "report", "delete" or "quarantine" (empty = off)
+		CacheWarmupOnStartup       bool    `envconfig:"CACHE_WARMUP_ON_STARTUP" default:"false"`      // Fetch the most requested lookups missing from the cache in a background job at startup
+		CacheWarmupFile            string  `envconfig:"CACHE_WARMUP_FILE" default:""`                 // Extra lookups to warm, one query string per line (s=...&a=...&al=...&d=...)
+		CacheWarmupTopN            int     `envconfig:"CACHE_WARMUP_TOP_N" default:"500"`             // Most requested lookups from the stats DB to warm (0 = only CACHE_WARMUP_FILE)
+		CacheWarmupDays            int     `envconfig:"CACHE_WARMUP_DAYS" default:"7"`                // Days of lookup history ranked for CACHE_WARMUP_TOP_N
+		IdempotencyTTLHours        int     `envconfig:"IDEMPOTENCY_TTL_HOURS" default:"24"`           // How long Idempotency-Key results of destructive admin calls are replayed
+		RecentAttemptTTLSecs       int     `envconfig:"RECENT_ATTEMPT_TTL_SECS" default:"30"`         // After a transient upstream failure, answer 503 for the same query this long; persisted across restarts (0 = off)
+		StartupGraceSecs           int     `envconfig:"STARTUP_GRACE_SECS" default:"120"`             // Period after startup with the longer in-flight coalescing window
+		InFlightWaitTimeoutSecs    int     `envconfig:"INFLIGHT_WAIT_TIMEOUT_SECS" default:"0"`       // Duplicate requests wait this long for the in-flight lookup, then get 202 + Retry-After (0 = wait it out)
+		StartupCoalesceSecs        int     `envconfig:"STARTUP_COALESCE_SECS" default:"10"`           // How long a finished lookup answers duplicate queries during the grace period (1s otherwise)
+
+		// Track matching - see scoring.go
+		// Score weights for name/artist/album similarity (must sum to ~1.0)
+		ScoreWeightName   float64 `envconfig:"SCORE_WEIGHT_NAME" default:"0.5"`
+		ScoreWeightArtist float64 `envconfig:"SCORE_WEIGHT_ARTIST" default:"0.375"`
+		ScoreWeightAlbum  float64 `envconfig:"SCORE_WEIGHT_ALBUM" default:"0.125"`
+		// Per-field-combination overrides of MIN_SIMILARITY_SCORE, keyed by the supplied query fields (empty = MIN_SIMILARITY_SCORE everywhere)
+		MinScoreMatrix string `envconfig:"MIN_SCORE_MATRIX" default:""`
 
 		// Legacy Provider Configuration (Spotify-based)
 		LyricsUrl              string `envconfig:"LYRICS_URL" default:""`
@@ -72,6 +170,16 @@ type Config struct {
 		CacheOnlyMode    bool `envconfig:"FF_CACHE_ONLY_MODE" default:"false"`
 		PrettyLogs       bool `envconfig:"FF_PRETTY_LOGS" default:"false"`
 	}
+
+	// MIN_SCORE_MATRIX parsed when the config is loaded or reloaded (see parseSettings)
+	minScoreMatrixSrc string
+	minScoreMatrix    map[string]float64
+	minScoreMatrixErr error
+
+	// API_KEY_TENANTS parsed alongside it, so tenant lookups are a map read
+	apiKeyTenantsSrc string
+	apiKeyTenants    map[string]string
+	apiKeyTenantsErr error
 }
 
 // load loads the configuration from the environment.
@@ -81,11 +189,24 @@ func load() (Config, error) {
 		log.Warnf("%s Error loading env config: %v", logcolors.LogConfig, err)
 	}
 
+	applyProfileDefaults()
+
 	cfg := Config{}
 	err = envconfig.Process("", &cfg)
+	cfg.Configuration.Profile = ActiveProfile()
+	cfg.parseSettings()
 	return cfg, err
 }
 
+// parseSettings parses the settings that are read on every request, so they
+// aren't parsed again each time
+func (c *Config) parseSettings() {
+	c.minScoreMatrixSrc = c.Configuration.MinScoreMatrix
+	c.minScoreMatrix, c.minScoreMatrixErr = ParseMinScoreMatrix(c.minScoreMatrixSrc)
+	c.apiKeyTenantsSrc = c.Configuration.APIKeyTenants
+	c.apiKeyTenants, c.apiKeyTenantsErr = ParseAPIKeyTenants(c.apiKeyTenantsSrc)
+}
+
 func mustLoad() Config {
 	c, err := load()
 	if err != nil {
@@ -95,8 +216,20 @@ func mustLoad() Config {
 	return c
 }
 
+// Get returns a copy of the active config
 func Get() Config {
-	return conf
+	return *current.Load()
+}
+
+// Current returns the active config without copying it. It is shared with every
+// other reader, so treat it as read-only.
+func Current() *Config {
+	return current.Load()
+}
+
+// set makes c the active config
+func set(c Config) {
+	current.Store(&c)
 }
 
 // AccountNameMigrations maps old account names to new names.
@@ -118,6 +251,82 @@ var APIKeyProtectedPaths = []string{
 	"/override",
 }
 
+// GetAPIKeyClients parses API_KEY_CLIENTS ("key1:extension,key2:v2") into a map from
+// X-API-Key value to client name
+func (c *Config) GetAPIKeyClients() (map[string]string, error) {
+	clients := make(map[string]string)
+	if strings.TrimSpace(c.Configuration.APIKeyClients) == "" {
+		return clients, nil
+	}
+	for _, entry := range strings.Split(c.Configuration.APIKeyClients, ",") {
+		key, client, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		key, client = strings.TrimSpace(key), strings.ToLower(strings.TrimSpace(client))
+		if !ok || key == "" || client == "" {
+			return nil, fmt.Errorf("invalid entry in API_KEY_CLIENTS (expected key:client)")
+		}
+		clients[key] = client
+	}
+	return clients, nil
+}
+
+// tenantNamePattern is what a tenant name may contain; it becomes part of cache keys
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// GetAPIKeyTenants returns API_KEY_TENANTS as a map from X-API-Key value to tenant
+// name, as parsed when the config was loaded. The map is shared; don't modify it.
+func (c *Config) GetAPIKeyTenants() (map[string]string, error) {
+	if c.apiKeyTenants == nil && c.apiKeyTenantsErr == nil || c.apiKeyTenantsSrc != c.Configuration.APIKeyTenants {
+		// Not built by load, or changed since (tests)
+		return ParseAPIKeyTenants(c.Configuration.APIKeyTenants)
+	}
+	return c.apiKeyTenants, c.apiKeyTenantsErr
+}
+
+// ParseAPIKeyTenants parses API_KEY_TENANTS ("key1:extension,key2:partner") into a map
+// from X-API-Key value to tenant name. Names are lowercased and limited to letters,
+// digits, "-" and "_".
+func ParseAPIKeyTenants(s string) (map[string]string, error) {
+	tenants := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return tenants, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		key, tenant, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		key, tenant = strings.TrimSpace(key), strings.ToLower(strings.TrimSpace(tenant))
+		if !ok || key == "" || tenant == "" {
+			return nil, fmt.Errorf("invalid entry in API_KEY_TENANTS (expected key:tenant)")
+		}
+		if !tenantNamePattern.MatchString(tenant) {
+			return nil, fmt.Errorf("invalid tenant %q in API_KEY_TENANTS (use letters, digits, - and _)", tenant)
+		}
+		tenants[key] = tenant
+	}
+	return tenants, nil
+}
+
+// GetClientFeatureVersions parses CLIENT_FEATURE_VERSIONS ("score:betterlyrics/2.0.0")
+// into feature -> client -> minimum version. Client names are lowercased to match
+// the User-Agent parsing.
+func (c *Config) GetClientFeatureVersions() (map[string]map[string]string, error) {
+	gates := make(map[string]map[string]string)
+	if strings.TrimSpace(c.Configuration.ClientFeatureVersions) == "" {
+		return gates, nil
+	}
+	for _, entry := range strings.Split(c.Configuration.ClientFeatureVersions, ",") {
+		feature, clientVersion, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		client, version, hasVersion := strings.Cut(clientVersion, "/")
+		feature, client, version = strings.ToLower(strings.TrimSpace(feature)), strings.ToLower(strings.TrimSpace(client)), strings.TrimPrefix(strings.TrimSpace(version), "v")
+		if !ok || !hasVersion || feature == "" || client == "" || version == "" {
+			return nil, fmt.Errorf("invalid entry in CLIENT_FEATURE_VERSIONS (expected feature:client/version)")
+		}
+		if gates[feature] == nil {
+			gates[feature] = make(map[string]string)
+		}
+		gates[feature][client] = version
+	}
+	return gates, nil
+}
+
 // TTMLAccount represents a single TTML API account
 // Bearer token is now auto-scraped, only MUT is needed per account
 type TTMLAccount struct {
@@ -601,3 +601,143 @@ func TestTTMLAccount_OutOfServiceField(t *testing.T) {
 		t.Error("Expected OutOfService to be true")
 	}
 }
+
+func TestProfileSelfHostAppliesDefaults(t *testing.T) {
+	keys := []string{"PROFILE", "CACHE_DB_PATH", "CACHE_BACKUP_PATH", "STATS_DB_PATH", "FF_PRETTY_LOGS", "RATE_LIMIT_PER_SECOND", "RATE_LIMIT_BURST_LIMIT"}
+	for _, key := range keys {
+		original, set := os.LookupEnv(key)
+		os.Unsetenv(key)
+		defer func(key, original string, set bool) {
+			if set {
+				os.Setenv(key, original)
+			} else {
+				os.Unsetenv(key)
+			}
+		}(key, original, set)
+	}
+
+	os.Setenv("PROFILE", "SelfHost")
+	os.Setenv("RATE_LIMIT_PER_SECOND", "1") // explicit values win over profile defaults
+
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Configuration.Profile != ProfileSelfHost {
+		t.Errorf("Profile = %q, want %q", cfg.Configuration.Profile, ProfileSelfHost)
+	}
+	if got := os.Getenv("CACHE_DB_PATH"); got != "/data/cache.db" {
+		t.Errorf("CACHE_DB_PATH = %q, want /data/cache.db", got)
+	}
+	if !cfg.FeatureFlags.PrettyLogs {
+		t.Error("Expected selfhost profile to enable pretty logs")
+	}
+	if cfg.Configuration.RateLimitPerSecond != 1 {
+		t.Errorf("RateLimitPerSecond = %d, want explicit value 1", cfg.Configuration.RateLimitPerSecond)
+	}
+	if cfg.Configuration.RateLimitBurstLimit != 10 {
+		t.Errorf("RateLimitBurstLimit = %d, want profile default 10", cfg.Configuration.RateLimitBurstLimit)
+	}
+}
+
+func TestActiveProfile(t *testing.T) {
+	original, set := os.LookupEnv("PROFILE")
+	defer func() {
+		if set {
+			os.Setenv("PROFILE", original)
+		} else {
+			os.Unsetenv("PROFILE")
+		}
+	}()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", ProfileProd},
+		{"prod", ProfileProd},
+		{" selfhost ", ProfileSelfHost},
+		{"staging", ProfileProd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			os.Setenv("PROFILE", tt.value)
+			if got := ActiveProfile(); got != tt.expected {
+				t.Errorf("ActiveProfile() with PROFILE=%q = %q, want %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetAPIKeyClients(t *testing.T) {
+	c := &Config{}
+	c.Configuration.APIKeyClients = " key1:Extension , key2:v2"
+	clients, err := c.GetAPIKeyClients()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 2 || clients["key1"] != "extension" || clients["key2"] != "v2" {
+		t.Errorf("Unexpected clients: %v", clients)
+	}
+
+	c.Configuration.APIKeyClients = "key-without-client"
+	if _, err := c.GetAPIKeyClients(); err == nil {
+		t.Error("Expected an error for an entry without a client")
+	}
+}
+
+func TestGetAPIKeyTenants(t *testing.T) {
+	c := &Config{}
+	c.Configuration.APIKeyTenants = " key1:Extension , key2:partner-app"
+	tenants, err := c.GetAPIKeyTenants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tenants) != 2 || tenants["key1"] != "extension" || tenants["key2"] != "partner-app" {
+		t.Errorf("Unexpected tenants: %v", tenants)
+	}
+
+	for _, invalid := range []string{"key-without-tenant", "key:partner app", "key:partner]"} {
+		c.Configuration.APIKeyTenants = invalid
+		if _, err := c.GetAPIKeyTenants(); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestGetAPIKeyTenants_ParsedOnLoad(t *testing.T) {
+	t.Setenv("API_KEY_TENANTS", "key1:extension")
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.apiKeyTenants["key1"] != "extension" {
+		t.Errorf("Expected load to parse the tenants, got %v", cfg.apiKeyTenants)
+	}
+
+	cfg.Configuration.APIKeyTenants = "key2:partner"
+	if tenants, err := cfg.GetAPIKeyTenants(); err != nil || tenants["key2"] != "partner" {
+		t.Errorf("Expected a changed API_KEY_TENANTS to be parsed again, got %v, %v", tenants, err)
+	}
+}
+
+func TestGetClientFeatureVersions(t *testing.T) {
+	c := &Config{}
+	c.Configuration.ClientFeatureVersions = " score:BetterLyrics/v2.0.0 , score:mobile/1.4"
+	gates, err := c.GetClientFeatureVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gates["score"]["betterlyrics"] != "2.0.0" || gates["score"]["mobile"] != "1.4" {
+		t.Errorf("Unexpected gates: %v", gates)
+	}
+
+	for _, invalid := range []string{"score:betterlyrics", "betterlyrics/2.0.0", "score:/2.0.0"} {
+		c.Configuration.ClientFeatureVersions = invalid
+		if _, err := c.GetClientFeatureVersions(); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"lyrics-api-go/logcolors"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Config profiles bundle sane defaults for a deployment style so self-hosters
+// don't have to assemble the full env var matrix by hand. Profile defaults only
+// fill in variables that are unset - anything set explicitly (env or .env) wins.
+const (
+	ProfileProd     = "prod"
+	ProfileSelfHost = "selfhost"
+)
+
+// profileDefaults maps a profile name to the env vars it pre-populates.
+// The prod profile intentionally has no overrides: it keeps the struct tag defaults.
+var profileDefaults = map[string]map[string]string{
+	ProfileProd: {},
+	ProfileSelfHost: {
+		// Docker-friendly paths - mount a single volume at /data
+		"CACHE_DB_PATH":     "/data/cache.db",
+		"CACHE_BACKUP_PATH": "/data/backups",
+		"STATS_DB_PATH":     "/data/stats.db",
+		// Human-readable logs for `docker logs`
+		"FF_PRETTY_LOGS": "true",
+		// A single-user instance doesn't need production-grade throttling
+		"RATE_LIMIT_PER_SECOND":  "5",
+		"RATE_LIMIT_BURST_LIMIT": "10",
+	},
+}
+
+// ActiveProfile returns the normalized profile name from the PROFILE env var.
+// Unknown or empty values fall back to the prod profile.
+func ActiveProfile() string {
+	profile := strings.ToLower(strings.TrimSpace(os.Getenv("PROFILE")))
+	if _, ok := profileDefaults[profile]; !ok {
+		return ProfileProd
+	}
+	return profile
+}
+
+// ProfileNames returns the list of known profile names (sorted).
+func ProfileNames() []string {
+	names := make([]string, 0, len(profileDefaults))
+	for name := range profileDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfileDefaults sets env vars from the active profile that are not already set.
+// Returns the keys that were applied.
+func applyProfileDefaults() []string {
+	raw := strings.TrimSpace(os.Getenv("PROFILE"))
+	profile := ActiveProfile()
+	if raw != "" && !strings.EqualFold(raw, profile) {
+		log.Warnf("%s Unknown PROFILE %q, falling back to %q (known: %v)", logcolors.LogConfig, raw, profile, ProfileNames())
+	}
+
+	var applied []string
+	for key, value := range profileDefaults[profile] {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		os.Setenv(key, value)
+		applied = append(applied, key)
+	}
+	sort.Strings(applied)
+
+	if len(applied) > 0 {
+		log.Infof("%s Profile %q applied defaults for: %s", logcolors.LogConfig, profile, strings.Join(applied, ", "))
+	}
+	return applied
+}
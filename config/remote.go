@@ -0,0 +1,380 @@
+package config
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	log "github.com/sirupsen/logrus"
+)
+
+// Remote config lets every replica pick up the same settings from one URL (an
+// object in a bucket, a Railway shared file, anything Terraform can write) instead
+// of redeploying to change env vars. The document is a JSON object of env var names
+// to values plus a "version", e.g.
+// {"version": 12, "TTML_MEDIA_USER_TOKENS": "mut1,mut2", "CIRCUIT_BREAKER_THRESHOLD": 8}.
+// It is polled with If-None-Match, and each new version must be signed: "<url>.sig"
+// holds the hex HMAC-SHA256 of the document body under REMOTE_CONFIG_SECRET. The
+// version must go up with every published change; since it is signed, a replica
+// refuses a document whose version isn't above the one it applied, so an older
+// signed document written back to the bucket can't roll replicas back.
+// Remote values override the environment; a key removed from the document goes
+// back to its env value.
+
+// RemoteReloadableKeys are the settings a remote document may change. Everything
+// here is read at use time (or has a reload hook), so a change applies without a
+// restart; other keys in the document are ignored with a warning.
+var RemoteReloadableKeys = []string{
+	"TTML_MEDIA_USER_TOKENS",
+	"TTML_MEDIA_USER_TOKEN",
+	"TTML_USER_AGENTS",
+	"CIRCUIT_BREAKER_THRESHOLD",
+	"MIN_SIMILARITY_SCORE",
+	"MIN_SCORE_MATRIX",
+	"SCORE_WEIGHT_NAME",
+	"SCORE_WEIGHT_ARTIST",
+	"SCORE_WEIGHT_ALBUM",
+	"DURATION_MATCH_DELTA_MS",
+	"LEARNED_ALIAS_MIN_SCORE",
+	"MIN_LYRICS_LINES_PER_MINUTE",
+	"MIN_LYRICS_COVERAGE_RATIO",
+	"NEGATIVE_CACHE_TTL_DAYS",
+	"NEW_SONG_THRESHOLD_DAYS",
+	"PROVIDER_ORDER_RULES",
+	"DISK_LOW_FREE_MB",
+	"DISK_READONLY_FREE_MB",
+}
+
+// remoteMaxBytes caps a remote document
+const remoteMaxBytes = 1 << 20
+
+// RemoteStatus describes the remote config poller for /diagnose
+type RemoteStatus struct {
+	URL           string    `json:"url"`
+	ETag          string    `json:"etag,omitempty"`
+	Version       int64     `json:"version,omitempty"` // Version of the applied document
+	LastCheckedAt time.Time `json:"last_checked_at"`
+	LastAppliedAt time.Time `json:"last_applied_at,omitempty"`
+	AppliedKeys   []string  `json:"applied_keys,omitempty"` // Keys the current document sets
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+var (
+	remoteMu     sync.Mutex
+	remoteStatus *RemoteStatus
+	envOriginals = make(map[string]*string) // Key -> env value before the first override (nil = unset)
+
+	reloadMu    sync.RWMutex
+	reloadHooks []func(c Config, changed []string)
+)
+
+// OnReload registers fn to run after a remote document changed the config, with
+// the new config and the keys whose values changed
+func OnReload(fn func(c Config, changed []string)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
+// StartRemoteReload polls url every interval (and once right away) and applies
+// signed changes. Without a secret nothing is applied: an unsigned document could
+// swap the accounts of every replica.
+func StartRemoteReload(url, secret string, interval time.Duration) {
+	if url == "" {
+		return
+	}
+	if secret == "" {
+		log.Errorf("%s REMOTE_CONFIG_URL is set but REMOTE_CONFIG_SECRET is empty; remote config disabled", logcolors.LogConfig)
+		return
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	remoteMu.Lock()
+	remoteStatus = &RemoteStatus{URL: url}
+	remoteMu.Unlock()
+	log.Infof("%s Polling remote config %s every %v", logcolors.LogConfig, url, interval)
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	go func() {
+		for {
+			if err := pollRemote(client, url, secret); err != nil {
+				log.Warnf("%s Remote config: %v", logcolors.LogConfig, err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// RemoteConfigStatus returns the poller state, or nil when remote config is off
+func RemoteConfigStatus() *RemoteStatus {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+	if remoteStatus == nil {
+		return nil
+	}
+	status := *remoteStatus
+	status.AppliedKeys = slices.Clone(remoteStatus.AppliedKeys)
+	return &status
+}
+
+// pollRemote fetches the document once and applies it if it changed. Errors leave
+// the current config in place.
+func pollRemote(client *http.Client, url, secret string) (err error) {
+	remoteMu.Lock()
+	etag := remoteStatus.ETag
+	remoteMu.Unlock()
+	defer func() {
+		remoteMu.Lock()
+		remoteStatus.LastCheckedAt = time.Now()
+		remoteStatus.LastError = ""
+		if err != nil {
+			remoteStatus.LastError = err.Error()
+		}
+		remoteMu.Unlock()
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, remoteMaxBytes+1))
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+	if len(body) > remoteMaxBytes {
+		return fmt.Errorf("document is larger than %d bytes", remoteMaxBytes)
+	}
+
+	signature, err := fetchSignature(client, url+".sig")
+	if err != nil {
+		return err
+	}
+	if !verifySignature(body, signature, secret) {
+		return fmt.Errorf("signature mismatch, document ignored")
+	}
+
+	values, version, err := parseRemoteDocument(body)
+	if err != nil {
+		return err
+	}
+	remoteMu.Lock()
+	applied := remoteStatus.Version
+	remoteMu.Unlock()
+	if version <= applied {
+		return fmt.Errorf("document version %d is not newer than the applied version %d, ignored", version, applied)
+	}
+	changed, err := applyRemoteValues(values)
+	if err != nil {
+		return err
+	}
+
+	remoteMu.Lock()
+	remoteStatus.ETag = resp.Header.Get("ETag")
+	remoteStatus.Version = version
+	remoteStatus.LastAppliedAt = time.Now()
+	remoteStatus.AppliedKeys = sortedKeys(values)
+	remoteMu.Unlock()
+
+	if len(changed) > 0 {
+		log.Infof("%s Remote config applied, changed: %s", logcolors.LogConfig, strings.Join(changed, ", "))
+		runReloadHooks(Get(), changed)
+	}
+	return nil
+}
+
+// fetchSignature reads the hex signature stored next to the document
+func fetchSignature(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("signature fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("signature fetch returned status %d", resp.StatusCode)
+	}
+	sig, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("signature read failed: %w", err)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(sig)), "sha256="), nil
+}
+
+// SignRemoteConfig returns the signature verifySignature expects for body
+func SignRemoteConfig(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature checks a hex HMAC-SHA256 of body in constant time
+func verifySignature(body []byte, signature, secret string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	want, _ := hex.DecodeString(SignRemoteConfig(body, secret))
+	return hmac.Equal(got, want)
+}
+
+// parseRemoteDocument reads the JSON object into env var values and its version.
+// Numbers and booleans are accepted as well as strings; keys outside
+// RemoteReloadableKeys are dropped.
+func parseRemoteDocument(body []byte) (map[string]string, int64, error) {
+	var raw map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, 0, fmt.Errorf("invalid document: %w", err)
+	}
+
+	number, _ := raw["version"].(json.Number)
+	version, err := number.Int64()
+	if err != nil || version < 1 {
+		return nil, 0, fmt.Errorf("invalid document: version must be a positive integer")
+	}
+	delete(raw, "version")
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if !slices.Contains(RemoteReloadableKeys, key) {
+			log.Warnf("%s Remote config: ignoring %s (not reloadable)", logcolors.LogConfig, key)
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case json.Number:
+			values[key] = v.String()
+		case bool:
+			values[key] = fmt.Sprint(v)
+		default:
+			return nil, 0, fmt.Errorf("invalid document: %s must be a string, number or boolean", key)
+		}
+	}
+	return values, version, nil
+}
+
+// applyRemoteValues overlays values on the environment and reloads the config. If
+// the result doesn't parse, the environment is put back and nothing changes.
+// Returns the keys whose effective value changed.
+func applyRemoteValues(values map[string]string) ([]string, error) {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+
+	before := make(map[string]*string, len(RemoteReloadableKeys))
+	for _, key := range RemoteReloadableKeys {
+		before[key] = lookupEnv(key)
+	}
+	originalsBefore := make(map[string]*string, len(envOriginals))
+	for key, original := range envOriginals {
+		originalsBefore[key] = original
+	}
+
+	for _, key := range RemoteReloadableKeys {
+		value, inDocument := values[key]
+		if inDocument {
+			if _, saved := envOriginals[key]; !saved {
+				envOriginals[key] = lookupEnv(key)
+			}
+			os.Setenv(key, value)
+			continue
+		}
+		if original, saved := envOriginals[key]; saved {
+			restoreEnv(key, original)
+			delete(envOriginals, key)
+		}
+	}
+
+	cfg := Config{}
+	if err := envconfig.Process("", &cfg); err != nil {
+		for key, value := range before {
+			restoreEnv(key, value)
+		}
+		envOriginals = originalsBefore
+		return nil, fmt.Errorf("document rejected: %w", err)
+	}
+	cfg.Configuration.Profile = ActiveProfile()
+	cfg.parseSettings()
+
+	var changed []string
+	for _, key := range RemoteReloadableKeys {
+		if !equalEnv(lookupEnv(key), before[key]) {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) > 0 {
+		set(cfg)
+	}
+	return changed, nil
+}
+
+// lookupEnv returns an env var's value, or nil when it is unset
+func lookupEnv(key string) *string {
+	if value, set := os.LookupEnv(key); set {
+		return &value
+	}
+	return nil
+}
+
+func equalEnv(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// restoreEnv sets an env var back to a value from lookupEnv
+func restoreEnv(key string, original *string) {
+	if original == nil {
+		os.Unsetenv(key)
+		return
+	}
+	os.Setenv(key, *original)
+}
+
+func runReloadHooks(c Config, changed []string) {
+	reloadMu.RLock()
+	hooks := slices.Clone(reloadHooks)
+	reloadMu.RUnlock()
+	for _, hook := range hooks {
+		hook(c, changed)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
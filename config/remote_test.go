@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// remoteServer serves a config document at /config.json and its signature at
+// /config.json.sig, with the document's version as the ETag
+type remoteServer struct {
+	mu          sync.Mutex
+	body        string
+	sig         string
+	version     int
+	notModified int
+}
+
+func (s *remoteServer) set(body, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+	s.sig = SignRemoteConfig([]byte(body), secret)
+	s.version++
+}
+
+func (s *remoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.HasSuffix(r.URL.Path, ".sig") {
+		w.Write([]byte("sha256=" + s.sig + "\n"))
+		return
+	}
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Write([]byte(s.body))
+}
+
+func TestRemoteConfig_PollAndApply(t *testing.T) {
+	t.Setenv("CIRCUIT_BREAKER_THRESHOLD", "5")
+	t.Setenv("TTML_MEDIA_USER_TOKENS", "env_mut")
+	os.Unsetenv("PROVIDER_ORDER_RULES")
+	defer os.Unsetenv("PROVIDER_ORDER_RULES")
+
+	original := Get()
+	defer set(original)
+	defer func() {
+		envOriginals = make(map[string]*string)
+		remoteStatus = nil
+	}()
+
+	var hookChanged []string
+	OnReload(func(c Config, changed []string) { hookChanged = changed })
+	defer func() { reloadHooks = nil }()
+
+	const secret = "s3cret"
+	server := &remoteServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	url := ts.URL + "/config.json"
+	remoteStatus = &RemoteStatus{URL: url}
+
+	server.set(`{"version": 1, "TTML_MEDIA_USER_TOKENS": "mut_a,mut_b", "CIRCUIT_BREAKER_THRESHOLD": 8, "PROVIDER_ORDER_RULES": "default=ttml", "CACHE_DB_PATH": "/tmp/x"}`, secret)
+	if err := pollRemote(ts.Client(), url, secret); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	cfg := Get()
+	if cfg.Configuration.TTMLMediaUserTokens != "mut_a,mut_b" || cfg.Configuration.CircuitBreakerThreshold != 8 {
+		t.Errorf("Remote values not applied: %q %d", cfg.Configuration.TTMLMediaUserTokens, cfg.Configuration.CircuitBreakerThreshold)
+	}
+	if cfg.Configuration.CacheAccessToken != original.Configuration.CacheAccessToken {
+		t.Error("Settings outside the document should keep their env values")
+	}
+	want := []string{"TTML_MEDIA_USER_TOKENS", "CIRCUIT_BREAKER_THRESHOLD", "PROVIDER_ORDER_RULES"}
+	if !slices.Equal(hookChanged, want) {
+		t.Errorf("Hook changed = %v, want %v", hookChanged, want)
+	}
+	if os.Getenv("CACHE_DB_PATH") == "/tmp/x" {
+		t.Error("Keys that aren't reloadable must be ignored")
+	}
+
+	// Unchanged document: 304, nothing reapplied
+	hookChanged = nil
+	if err := pollRemote(ts.Client(), url, secret); err != nil || server.notModified != 1 || hookChanged != nil {
+		t.Errorf("Expected a 304 with no reload, got err=%v notModified=%d changed=%v", err, server.notModified, hookChanged)
+	}
+
+	// A document signed with another key is ignored
+	server.set(`{"version": 2, "CIRCUIT_BREAKER_THRESHOLD": 1}`, "wrong")
+	if err := pollRemote(ts.Client(), url, secret); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("Expected a signature error, got %v", err)
+	}
+	if RemoteConfigStatus().LastError == "" || Get().Configuration.CircuitBreakerThreshold != 8 {
+		t.Error("A rejected document must leave the config alone and report the error")
+	}
+
+	// A value that doesn't parse rejects the whole document
+	server.set(`{"version": 2, "CIRCUIT_BREAKER_THRESHOLD": "lots", "TTML_MEDIA_USER_TOKENS": "mut_c"}`, secret)
+	if err := pollRemote(ts.Client(), url, secret); err == nil {
+		t.Error("Expected an invalid value to be rejected")
+	}
+	if os.Getenv("TTML_MEDIA_USER_TOKENS") != "mut_a,mut_b" || Get().Configuration.TTMLMediaUserTokens != "mut_a,mut_b" {
+		t.Error("A rejected document must not change the environment")
+	}
+
+	// Keys dropped from the document go back to their env values
+	server.set(`{"version": 2, "CIRCUIT_BREAKER_THRESHOLD": 8}`, secret)
+	if err := pollRemote(ts.Client(), url, secret); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if got := Get().Configuration.TTMLMediaUserTokens; got != "env_mut" {
+		t.Errorf("Expected TTML_MEDIA_USER_TOKENS back to its env value, got %q", got)
+	}
+	if _, set := os.LookupEnv("PROVIDER_ORDER_RULES"); set {
+		t.Error("A key unset before the override should be unset again")
+	}
+	if status := RemoteConfigStatus(); status.LastError != "" || status.Version != 2 || !slices.Equal(status.AppliedKeys, []string{"CIRCUIT_BREAKER_THRESHOLD"}) {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	// An older signed document written back can't roll the config back
+	server.set(`{"version": 1, "TTML_MEDIA_USER_TOKENS": "mut_a,mut_b"}`, secret)
+	if err := pollRemote(ts.Client(), url, secret); err == nil || !strings.Contains(err.Error(), "not newer") {
+		t.Errorf("Expected an older version to be refused, got %v", err)
+	}
+	if got := Get().Configuration.TTMLMediaUserTokens; got != "env_mut" {
+		t.Errorf("An older version must not be applied, got %q", got)
+	}
+
+	// Neither can a document without a version
+	server.set(`{"TTML_MEDIA_USER_TOKENS": "mut_a,mut_b"}`, secret)
+	if err := pollRemote(ts.Client(), url, secret); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("Expected a document without a version to be refused, got %v", err)
+	}
+}
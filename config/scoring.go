@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// scoreWeightTolerance is how far the weight sum may drift from 1.0
+// (e.g. 0.33/0.33/0.33 is accepted)
+const scoreWeightTolerance = 0.02
+
+// ScoreWeights are the per-field weights used to score search results against
+// the requested song name, artist and album.
+type ScoreWeights struct {
+	Name   float64 `json:"name"`
+	Artist float64 `json:"artist"`
+	Album  float64 `json:"album"`
+}
+
+// DefaultScoreWeights is the weight set used when the configured one is invalid
+var DefaultScoreWeights = ScoreWeights{Name: 0.50, Artist: 0.375, Album: 0.125}
+
+// Validate checks that every weight is in [0, 1] and that they sum to ~1.0
+func (w ScoreWeights) Validate() error {
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{{"name", w.Name}, {"artist", w.Artist}, {"album", w.Album}} {
+		if math.IsNaN(f.value) || f.value < 0 || f.value > 1 {
+			return fmt.Errorf("%s weight %.3f must be between 0 and 1", f.name, f.value)
+		}
+	}
+	sum := w.Name + w.Artist + w.Album
+	if math.Abs(sum-1.0) > scoreWeightTolerance {
+		return fmt.Errorf("weights must sum to 1.0 (got %.3f)", sum)
+	}
+	return nil
+}
+
+// String formats the weights for score breakdown logs
+func (w ScoreWeights) String() string {
+	return fmt.Sprintf("name=%.3f artist=%.3f album=%.3f", w.Name, w.Artist, w.Album)
+}
+
+// ParseScoreWeights parses "name,artist,album" (e.g. "0.6,0.3,0.1") and validates the result
+func ParseScoreWeights(s string) (ScoreWeights, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return ScoreWeights{}, fmt.Errorf("expected 3 comma-separated weights (name,artist,album), got %d", len(parts))
+	}
+
+	values := make([]float64, 3)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return ScoreWeights{}, fmt.Errorf("invalid weight %q", part)
+		}
+		values[i] = v
+	}
+
+	w := ScoreWeights{Name: values[0], Artist: values[1], Album: values[2]}
+	return w, w.Validate()
+}
+
+// GetScoreWeights returns the configured scoring weights, validated.
+// All-zero weights mean "not configured" and yield the defaults.
+func (c *Config) GetScoreWeights() (ScoreWeights, error) {
+	w := ScoreWeights{
+		Name:   c.Configuration.ScoreWeightName,
+		Artist: c.Configuration.ScoreWeightArtist,
+		Album:  c.Configuration.ScoreWeightAlbum,
+	}
+	if w == (ScoreWeights{}) {
+		return DefaultScoreWeights, nil
+	}
+	return w, w.Validate()
+}
+
+// Query fields a minimum-score threshold can be conditioned on, in canonical order
+var scoreQueryFields = []string{"song", "artist", "album", "duration"}
+
+// QueryFieldsKey returns the canonical matrix key for the supplied query fields,
+// e.g. "song+artist+duration"
+func QueryFieldsKey(hasSong, hasArtist, hasAlbum, hasDuration bool) string {
+	var fields []string
+	for i, has := range []bool{hasSong, hasArtist, hasAlbum, hasDuration} {
+		if has {
+			fields = append(fields, scoreQueryFields[i])
+		}
+	}
+	return strings.Join(fields, "+")
+}
+
+// ParseMinScoreMatrix parses "song:0.45,song+artist+album:0.65" into a map keyed by
+// the canonical field combination. Fields within a key may be given in any order.
+func ParseMinScoreMatrix(s string) (map[string]float64, error) {
+	matrix := make(map[string]float64)
+	if strings.TrimSpace(s) == "" {
+		return matrix, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q (expected fields:score)", entry)
+		}
+
+		has := make(map[string]bool)
+		for _, field := range strings.Split(key, "+") {
+			field = strings.ToLower(strings.TrimSpace(field))
+			known := false
+			for _, f := range scoreQueryFields {
+				known = known || f == field
+			}
+			if !known {
+				return nil, fmt.Errorf("unknown field %q in %q (use %s)", field, entry, strings.Join(scoreQueryFields, ", "))
+			}
+			has[field] = true
+		}
+
+		score, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || score < 0 || score > 1 {
+			return nil, fmt.Errorf("invalid score %q in %q (expected 0-1)", value, entry)
+		}
+		matrix[QueryFieldsKey(has["song"], has["artist"], has["album"], has["duration"])] = score
+	}
+	return matrix, nil
+}
+
+// GetMinScoreMatrix returns the configured per-field-combination score thresholds,
+// as parsed when the config was loaded
+func (c *Config) GetMinScoreMatrix() (map[string]float64, error) {
+	if c.minScoreMatrix == nil && c.minScoreMatrixErr == nil || c.minScoreMatrixSrc != c.Configuration.MinScoreMatrix {
+		// Not built by load, or changed since (tests)
+		return ParseMinScoreMatrix(c.Configuration.MinScoreMatrix)
+	}
+	return c.minScoreMatrix, c.minScoreMatrixErr
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScoreWeightsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights ScoreWeights
+		wantErr string
+	}{
+		{"defaults", DefaultScoreWeights, ""},
+		{"thirds within tolerance", ScoreWeights{0.33, 0.33, 0.33}, ""},
+		{"album ignored", ScoreWeights{0.6, 0.4, 0}, ""},
+		{"sum too high", ScoreWeights{0.6, 0.4, 0.2}, "sum to 1.0"},
+		{"sum too low", ScoreWeights{0.4, 0.3, 0.1}, "sum to 1.0"},
+		{"negative", ScoreWeights{1.2, -0.2, 0}, "between 0 and 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.weights.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseScoreWeights(t *testing.T) {
+	w, err := ParseScoreWeights(" 0.6, 0.3 ,0.1")
+	if err != nil {
+		t.Fatalf("ParseScoreWeights failed: %v", err)
+	}
+	if w != (ScoreWeights{Name: 0.6, Artist: 0.3, Album: 0.1}) {
+		t.Errorf("Unexpected weights: %+v", w)
+	}
+
+	for _, bad := range []string{"", "0.5,0.5", "a,b,c", "0.5,0.5,0.5"} {
+		if _, err := ParseScoreWeights(bad); err == nil {
+			t.Errorf("ParseScoreWeights(%q) should fail", bad)
+		}
+	}
+}
+
+func TestGetScoreWeights(t *testing.T) {
+	var cfg Config
+	if w, err := cfg.GetScoreWeights(); err != nil || w != DefaultScoreWeights {
+		t.Errorf("Unset weights should yield defaults, got %+v, %v", w, err)
+	}
+
+	cfg.Configuration.ScoreWeightName = 0.5
+	cfg.Configuration.ScoreWeightArtist = 0.375
+	cfg.Configuration.ScoreWeightAlbum = 0.125
+	if w, err := cfg.GetScoreWeights(); err != nil || w != DefaultScoreWeights {
+		t.Errorf("GetScoreWeights() = %+v, %v", w, err)
+	}
+
+	cfg.Configuration.ScoreWeightAlbum = 0.5
+	if _, err := cfg.GetScoreWeights(); err == nil {
+		t.Error("Expected invalid configured weights to fail validation")
+	}
+}
+
+func TestQueryFieldsKey(t *testing.T) {
+	if got := QueryFieldsKey(true, true, false, true); got != "song+artist+duration" {
+		t.Errorf("QueryFieldsKey = %q", got)
+	}
+	if got := QueryFieldsKey(false, false, false, false); got != "" {
+		t.Errorf("QueryFieldsKey with no fields = %q, want empty", got)
+	}
+}
+
+func TestParseMinScoreMatrix(t *testing.T) {
+	matrix, err := ParseMinScoreMatrix("song:0.45, duration+artist+song : 0.55,Album+Song+Artist:0.7")
+	if err != nil {
+		t.Fatalf("ParseMinScoreMatrix failed: %v", err)
+	}
+	expected := map[string]float64{
+		"song":                 0.45,
+		"song+artist+duration": 0.55,
+		"song+artist+album":    0.7,
+	}
+	if len(matrix) != len(expected) {
+		t.Errorf("Expected %d entries, got %v", len(expected), matrix)
+	}
+	for key, score := range expected {
+		if matrix[key] != score {
+			t.Errorf("matrix[%q] = %v, want %v", key, matrix[key], score)
+		}
+	}
+
+	if matrix, err := ParseMinScoreMatrix(""); err != nil || len(matrix) != 0 {
+		t.Errorf("Empty matrix should parse to no entries, got %v, %v", matrix, err)
+	}
+
+	for _, bad := range []string{"song", "song:abc", "song:1.5", "title:0.5", "song+:0.5"} {
+		if _, err := ParseMinScoreMatrix(bad); err == nil {
+			t.Errorf("ParseMinScoreMatrix(%q) should fail", bad)
+		}
+	}
+}
+
+func TestGetMinScoreMatrix_ParsedOnLoad(t *testing.T) {
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if matrix, err := cfg.GetMinScoreMatrix(); err != nil || len(matrix) != 0 {
+		t.Errorf("The default matrix should be empty, got %v, %v", matrix, err)
+	}
+
+	t.Setenv("MIN_SCORE_MATRIX", "song:0.45")
+	cfg, err = load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.minScoreMatrix["song"] != 0.45 {
+		t.Errorf("Expected load to parse the matrix, got %v", cfg.minScoreMatrix)
+	}
+	if matrix, err := cfg.GetMinScoreMatrix(); err != nil || matrix["song"] != 0.45 {
+		t.Errorf("Expected the matrix parsed on load, got %v, %v", matrix, err)
+	}
+
+	t.Setenv("MIN_SCORE_MATRIX", "song")
+	cfg, _ = load()
+	if _, err := cfg.GetMinScoreMatrix(); err == nil {
+		t.Error("An invalid matrix should report its parse error")
+	}
+}
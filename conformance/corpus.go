@@ -0,0 +1,54 @@
+// Package conformance holds the canonical lyrics corpus served at /testdata: small
+// TTML documents covering each shape of lyrics the API returns, so clients (the
+// extension, mobile apps) can test their rendering against server output that
+// doesn't change with the upstream catalog.
+//
+// Fixtures are embedded in the binary. Changing one changes the expected output
+// of every client test built on it, so add new cases instead of editing old ones.
+package conformance
+
+import (
+	"embed"
+	"sort"
+)
+
+//go:embed corpus/*.ttml
+var corpusFS embed.FS
+
+// Case is one fixture in the corpus
+type Case struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// cases lists every fixture; each has a corpus/<name>.ttml file
+var cases = []Case{
+	{Name: "word-level", Description: "Word-synced lyrics with a split word (syllables) and two sections"},
+	{Name: "line-level", Description: "Line-synced lyrics without word timing"},
+	{Name: "unsynced", Description: "Plain lyrics without timing (timingType none)"},
+	{Name: "rtl", Description: "Right-to-left (Arabic) word-synced lyrics, with a mixed-direction line"},
+	{Name: "background-vocals", Description: "Background vocals after the main vocal and on a line of their own"},
+	{Name: "duet", Description: "Two singers alternating lines, then both together as a group agent"},
+}
+
+// Cases returns the fixtures, sorted by name
+func Cases() []Case {
+	sorted := make([]Case, len(cases))
+	copy(sorted, cases)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// Lookup returns the TTML of a fixture
+func Lookup(name string) (string, bool) {
+	for _, c := range cases {
+		if c.Name == name {
+			data, err := corpusFS.ReadFile("corpus/" + name + ".ttml")
+			if err != nil {
+				return "", false
+			}
+			return string(data), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,92 @@
+package conformance
+
+import (
+	"io/fs"
+	"lyrics-api-go/services/providers/ttml"
+	"strings"
+	"testing"
+)
+
+func TestCorpus_EveryCaseHasAFixture(t *testing.T) {
+	for _, c := range Cases() {
+		if _, ok := Lookup(c.Name); !ok {
+			t.Errorf("case %q has no corpus/%s.ttml", c.Name, c.Name)
+		}
+	}
+
+	files, err := fs.Glob(corpusFS, "corpus/*.ttml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(cases) {
+		t.Errorf("%d fixture files for %d cases; list every file in cases", len(files), len(cases))
+	}
+}
+
+func TestCorpus_UnknownCase(t *testing.T) {
+	if _, ok := Lookup("missing"); ok {
+		t.Error("Lookup found a case that doesn't exist")
+	}
+	if _, ok := Lookup("../corpus"); ok {
+		t.Error("Lookup accepted a path")
+	}
+}
+
+// The fixtures must keep exercising what their names say, or client conformance
+// tests built on them silently stop covering it
+func TestCorpus_FixturesParse(t *testing.T) {
+	for _, c := range Cases() {
+		t.Run(c.Name, func(t *testing.T) {
+			content, _ := Lookup(c.Name)
+			lines, timingType, err := ttml.ParseLines(content)
+			if err != nil {
+				t.Fatalf("ParseLines: %v", err)
+			}
+			if len(lines) == 0 {
+				t.Fatal("no lines")
+			}
+
+			switch c.Name {
+			case "word-level", "rtl", "background-vocals", "duet":
+				if timingType != "word" {
+					t.Errorf("timingType = %q, want word", timingType)
+				}
+			case "line-level":
+				if timingType != "line" {
+					t.Errorf("timingType = %q, want line", timingType)
+				}
+			case "unsynced":
+				if timingType != "none" {
+					t.Errorf("timingType = %q, want none", timingType)
+				}
+			}
+
+			switch c.Name {
+			case "rtl":
+				if _, isRTL := ttml.DetectLanguage(content); !isRTL {
+					t.Error("rtl fixture isn't detected as right-to-left")
+				}
+			case "background-vocals":
+				background := 0
+				for _, line := range lines {
+					background += len(line.BackgroundVocals)
+				}
+				if background < 2 {
+					t.Errorf("%d background vocal groups, want at least 2", background)
+				}
+			case "duet":
+				agents := make(map[string]bool)
+				for _, line := range lines {
+					agents[line.Agent] = true
+				}
+				if len(agents) < 3 || !agents["group:v1000"] {
+					t.Errorf("agents = %v, want two people and a group", agents)
+				}
+			case "word-level":
+				if !strings.Contains(content, "Hum</span><span") {
+					t.Error("word-level fixture lost its split word")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"strconv"
+)
+
+// parseExplicitParam reads the explicit= query parameter (true or false). override is
+// false when the parameter is absent or agrees with PREFER_EXPLICIT, in which case
+// the request shares the regular cache entries.
+func parseExplicitParam(r *http.Request) (preferExplicit, override bool, err error) {
+	return parseExplicitValue(r.URL.Query().Get("explicit"))
+}
+
+// parseExplicitValue is parseExplicitParam for the value of the parameter
+func parseExplicitValue(value string) (preferExplicit, override bool, err error) {
+	preferExplicit = conf().Configuration.PreferExplicit
+	if value == "" {
+		return preferExplicit, false, nil
+	}
+	requested, err := strconv.ParseBool(value)
+	if err != nil {
+		return preferExplicit, false, fmt.Errorf("invalid explicit %q: use true or false", value)
+	}
+	return requested, requested != preferExplicit, nil
+}
+
+// contentRatingCacheKey is the cache key for the non-default release of a query, so
+// explicit and clean lyrics never overwrite each other
+func contentRatingCacheKey(cacheKey string, preferExplicit bool) string {
+	if preferExplicit {
+		return cacheKey + " [" + ttml.ContentRatingExplicit + "]"
+	}
+	return cacheKey + " [" + ttml.ContentRatingClean + "]"
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseExplicitParam(t *testing.T) {
+	original := conf().Configuration.PreferExplicit
+	conf().Configuration.PreferExplicit = true
+	defer func() { conf().Configuration.PreferExplicit = original }()
+
+	tests := []struct {
+		query    string
+		prefer   bool
+		override bool
+		wantErr  bool
+	}{
+		{"", true, false, false},
+		{"explicit=true", true, false, false},
+		{"explicit=false", false, true, false},
+		{"explicit=0", false, true, false},
+		{"explicit=maybe", true, false, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/getLyrics?"+tt.query, nil)
+		prefer, override, err := parseExplicitParam(r)
+		if prefer != tt.prefer || override != tt.override || (err != nil) != tt.wantErr {
+			t.Errorf("%q: got (%v, %v, %v), want (%v, %v, err=%v)", tt.query, prefer, override, err, tt.prefer, tt.override, tt.wantErr)
+		}
+	}
+}
+
+func TestGetLyrics_ExplicitOverrideUsesOwnCacheKey(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	original := conf().Configuration.PreferExplicit
+	conf().Configuration.PreferExplicit = true
+	defer func() { conf().Configuration.PreferExplicit = original }()
+
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	setCachedLyrics(cacheKey, "<tt>explicit</tt>", 0, 0, "", false)
+	setCachedLyrics(contentRatingCacheKey(cacheKey, false), "<tt>clean</tt>", 0, 0, "", false)
+
+	get := func(query string) string {
+		rr := httptest.NewRecorder()
+		getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d: %s", query, rr.Code, rr.Body.String())
+		}
+		var body struct {
+			TTML string `json:"ttml"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &body)
+		return body.TTML
+	}
+
+	if got := get(""); got != "<tt>explicit</tt>" {
+		t.Errorf("Default request got %q", got)
+	}
+	if got := get("&explicit=true"); got != "<tt>explicit</tt>" {
+		t.Errorf("explicit=true matches the default and should share its entry, got %q", got)
+	}
+	if got := get("&explicit=false"); got != "<tt>clean</tt>" {
+		t.Errorf("explicit=false should read the clean entry, got %q", got)
+	}
+
+	// The override never falls back to the default release's fuzzy matches
+	rr := httptest.NewRecorder()
+	getLyrics(rr, httptest.NewRequest(http.MethodHead, "/getLyrics?s=song&a=artist&d=200&explicit=false", nil))
+	if got := rr.Header().Get("X-Cache-Status"); got != "MISS" {
+		t.Errorf("HEAD with an uncached override: X-Cache-Status = %q, want MISS", got)
+	}
+
+	rr = httptest.NewRecorder()
+	getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&explicit=maybe", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid explicit value, got %d", rr.Code)
+	}
+}
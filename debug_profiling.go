@@ -0,0 +1,117 @@
+package main
+
+import (
+	"expvar"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// profilingEndpoint is one net/http/pprof or expvar handler
+type profilingEndpoint struct {
+	path    string
+	handler http.Handler
+}
+
+// profilingIndexPath is the catch-all pprof path; it also serves the named
+// profiles (heap, goroutine, allocs, ...)
+const profilingIndexPath = "/debug/pprof/"
+
+// profilingHandlers lists the profiling endpoints. The index comes last so a
+// prefix-matching router tries the specific paths first.
+func profilingHandlers() []profilingEndpoint {
+	return []profilingEndpoint{
+		{"/debug/pprof/cmdline", http.HandlerFunc(pprof.Cmdline)},
+		{"/debug/pprof/profile", http.HandlerFunc(pprof.Profile)},
+		{"/debug/pprof/symbol", http.HandlerFunc(pprof.Symbol)},
+		{"/debug/pprof/trace", http.HandlerFunc(pprof.Trace)},
+		{"/debug/vars", expvar.Handler()},
+		{profilingIndexPath, http.HandlerFunc(pprof.Index)},
+	}
+}
+
+// adminOnly puts a profiling endpoint behind the admin token. Unlike the cache
+// endpoints it stays closed when CACHE_ACCESS_TOKEN is unset, since profiles
+// expose the command line and memory contents.
+func adminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := conf().Configuration.CacheAccessToken
+		if token == "" || r.Header.Get("Authorization") != token {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// startProfilingListener serves the profiling endpoints without auth on
+// PPROF_LISTEN_ADDR, meant for a localhost-only port (e.g. 127.0.0.1:6060)
+// reached through an SSH tunnel. Does nothing when unset.
+func startProfilingListener(addr string) {
+	if addr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	for _, endpoint := range profilingHandlers() {
+		mux.Handle(endpoint.path, endpoint.handler)
+	}
+	go func() {
+		log.Infof("%s Profiling endpoints listening on %s", logcolors.LogServer, addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Errorf("%s Profiling listener on %s stopped: %v", logcolors.LogServer, addr, err)
+		}
+	}()
+}
+
+// gcHandler reports heap stats. POST forces a garbage collection and returns the
+// heap before and after, which tells a leak (live memory) apart from garbage the
+// runtime hasn't returned to the OS yet.
+func gcHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		Respond(w, r).JSON(heapStats())
+		return
+	}
+
+	before := heapStats()
+	start := clk.Now()
+	// FreeOSMemory runs a full collection and then returns freed pages to the OS
+	debug.FreeOSMemory()
+	took := clk.Since(start)
+	after := heapStats()
+
+	log.Infof("%s Forced GC: heap %dMB -> %dMB, RSS %dMB -> %dMB (%v)", logcolors.LogMemory,
+		before["heap_alloc_mb"], after["heap_alloc_mb"], before["rss_mb"], after["rss_mb"], took)
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"before":  before,
+		"after":   after,
+		"took_ms": took.Milliseconds(),
+	})
+}
+
+// heapStats is a snapshot of the runtime memory stats worth comparing over time
+func heapStats() map[string]interface{} {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return map[string]interface{}{
+		"rss_mb":           getProcessRSS() / 1024 / 1024,
+		"heap_alloc_mb":    m.HeapAlloc / 1024 / 1024,
+		"heap_inuse_mb":    m.HeapInuse / 1024 / 1024,
+		"heap_idle_mb":     m.HeapIdle / 1024 / 1024,
+		"heap_released_mb": m.HeapReleased / 1024 / 1024,
+		"heap_objects":     m.HeapObjects,
+		"stack_inuse_mb":   m.StackInuse / 1024 / 1024,
+		"sys_mb":           m.Sys / 1024 / 1024,
+		"gc_cycles":        m.NumGC,
+		"goroutines":       runtime.NumGoroutine(),
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestProfilingRoutes_RequireAdminToken(t *testing.T) {
+	router := mux.NewRouter()
+	setupRoutes(router)
+
+	originalToken := conf().Configuration.CacheAccessToken
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	get := func(path, auth string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	conf().Configuration.CacheAccessToken = "test-token"
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/cmdline", "/debug/vars"} {
+		if code := get(path, ""); code != http.StatusUnauthorized {
+			t.Errorf("%s without token: expected 401, got %d", path, code)
+		}
+		if code := get(path, "test-token"); code != http.StatusOK {
+			t.Errorf("%s with token: expected 200, got %d", path, code)
+		}
+	}
+
+	// No configured token must not mean open profiling
+	conf().Configuration.CacheAccessToken = ""
+	if code := get("/debug/pprof/heap", ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 when CACHE_ACCESS_TOKEN is unset, got %d", code)
+	}
+}
+
+func TestGCHandler(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	rr := httptest.NewRecorder()
+	gcHandler(rr, httptest.NewRequest(http.MethodGet, "/debug/gc", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without token, got %d", rr.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/gc", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	gcHandler(rr, req)
+	var stats map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &stats)
+	if _, ok := stats["heap_alloc_mb"]; !ok || stats["goroutines"] == nil {
+		t.Errorf("Expected heap stats, got %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/debug/gc", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	gcHandler(rr, req)
+	var forced struct {
+		Before map[string]interface{} `json:"before"`
+		After  map[string]interface{} `json:"after"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &forced)
+	if rr.Code != http.StatusOK || forced.Before == nil || forced.After == nil {
+		t.Fatalf("Expected before/after stats, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if forced.After["gc_cycles"].(float64) <= forced.Before["gc_cycles"].(float64) {
+		t.Errorf("Expected a GC cycle to run: %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/lyricsquery"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Deprecation warnings tell clients what they still rely on that is going away:
+// each JSON response lists them under "warnings", and every response gets a
+// Deprecation header (and Sunset, once DEPRECATION_SUNSET is set). The extension
+// fleet updates slowly, so this is how it finds out before things break. Other
+// per-request warnings (duration_mismatch) go through the same collector so they
+// are written to the list at response time, whatever the format.
+
+// Reasons of a deprecation warning
+const (
+	deprecatedParam = "deprecated_param" // A query parameter alias with a shorter replacement
+	legacyCacheKey  = "legacy_cache_key" // Served from an entry stored under the old key format
+	deprecatedField = "deprecated_field" // A response field listed in DEPRECATED_RESPONSE_FIELDS
+)
+
+// deprecatedParams maps deprecated query parameters to their replacements: every
+// track param alias but the preferred one
+var deprecatedParams = func() map[string]string {
+	params := make(map[string]string)
+	for _, aliases := range [][]string{lyricsquery.SongParams, lyricsquery.ArtistParams, lyricsquery.AlbumParams, lyricsquery.DurationParams, lyricsquery.VideoIDParams} {
+		for _, alias := range aliases[1:] {
+			params[alias] = aliases[0]
+		}
+	}
+	return params
+}()
+
+// deprecationWarning is one entry of a response's warnings list. reason is shared
+// with the parse warnings of the parsed-line shapes, which use the same list.
+type deprecationWarning struct {
+	Reason  string `json:"reason"`
+	Name    string `json:"name"` // The parameter, cache key or field
+	Message string `json:"message"`
+	Sunset  string `json:"sunset,omitempty"` // Date (YYYY-MM-DD) after which it may stop working
+}
+
+// responseWarnings collects the warnings of one request
+type responseWarnings struct {
+	mu       sync.Mutex
+	warnings []deprecationWarning
+	others   []interface{} // Warnings that aren't deprecations; no Deprecation header
+}
+
+var invalidSunsetOnce sync.Once
+
+// withResponseWarnings returns r with a new warnings collector in its context, holding
+// a warning for each deprecated query parameter it uses
+func withResponseWarnings(r *http.Request) *http.Request {
+	d := &responseWarnings{}
+	var params []string
+	for param := range r.URL.Query() {
+		if _, ok := deprecatedParams[param]; ok {
+			params = append(params, param)
+		}
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		d.add(deprecatedParam, param, "Use "+deprecatedParams[param]+"= instead of "+param+"=")
+	}
+	return r.WithContext(context.WithValue(r.Context(), responseWarningsKey, d))
+}
+
+// requestWarnings returns the request's collector, or nil when it has none.
+// Every method is a no-op on nil.
+func requestWarnings(r *http.Request) *responseWarnings {
+	d, _ := r.Context().Value(responseWarningsKey).(*responseWarnings)
+	return d
+}
+
+// add records a warning once per reason and name
+func (d *responseWarnings) add(reason, name, message string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, w := range d.warnings {
+		if w.Reason == reason && w.Name == name {
+			return
+		}
+	}
+	d.warnings = append(d.warnings, deprecationWarning{
+		Reason:  reason,
+		Name:    name,
+		Message: message,
+		Sunset:  deprecationSunsetDate(),
+	})
+}
+
+// noteCacheKey warns when the entry a request was served from is stored under the
+// old key format (original casing, trailing space), which is no longer written
+// and won't be looked up after the sunset
+func (d *responseWarnings) noteCacheKey(key string) {
+	if !isLegacyCacheKey(key) {
+		return
+	}
+	d.add(legacyCacheKey, key, "Served from a cache entry in the legacy key format; it may be refetched after the sunset")
+}
+
+// addWarning records a warning that isn't a deprecation. It must be a comparable
+// value with a reason field.
+func (d *responseWarnings) addWarning(warning interface{}) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.others = append(d.others, warning)
+}
+
+// list returns the deprecation warnings recorded so far
+func (d *responseWarnings) list() []deprecationWarning {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]deprecationWarning(nil), d.warnings...)
+}
+
+// all returns every warning recorded so far, deprecations first
+func (d *responseWarnings) all() []interface{} {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	all := make([]interface{}, 0, len(d.warnings)+len(d.others))
+	for _, w := range d.warnings {
+		all = append(all, w)
+	}
+	return append(all, d.others...)
+}
+
+// isLegacyCacheKey reports whether a lyrics key was built by buildLegacyCacheKey:
+// normalized keys are lowercase, without trailing or doubled spaces
+func isLegacyCacheKey(key string) bool {
+	_, query, ok := strings.Cut(key, ":")
+	if !ok {
+		return false
+	}
+	return query != strings.ToLower(query) || strings.HasSuffix(query, " ") || strings.Contains(query, "  ")
+}
+
+// deprecatedResponseFields parses DEPRECATED_RESPONSE_FIELDS
+func deprecatedResponseFields() []string {
+	var fields []string
+	for _, field := range strings.Split(conf().Configuration.DeprecatedResponseFields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// deprecationSunsetDate returns DEPRECATION_SUNSET, or "" when unset or invalid
+func deprecationSunsetDate() string {
+	sunset := strings.TrimSpace(conf().Configuration.DeprecationSunset)
+	if sunset == "" {
+		return ""
+	}
+	if _, err := time.Parse(time.DateOnly, sunset); err != nil {
+		invalidSunsetOnce.Do(func() {
+			log.Warnf("%s Ignoring DEPRECATION_SUNSET %q: use YYYY-MM-DD", logcolors.LogConfig, sunset)
+		})
+		return ""
+	}
+	return sunset
+}
+
+// writeDeprecationHeaders sets Deprecation (and Sunset) when the request used
+// anything deprecated
+func writeDeprecationHeaders(w http.ResponseWriter, r *http.Request) {
+	if len(requestWarnings(r).list()) == 0 {
+		return
+	}
+	w.Header().Set("Deprecation", "true")
+	if sunset := deprecationSunsetDate(); sunset != "" {
+		date, _ := time.Parse(time.DateOnly, sunset)
+		w.Header().Set("Sunset", date.Format(http.TimeFormat))
+	}
+}
+
+// addResponseWarnings returns a JSON body with the request's warnings appended
+// to its warnings list, first recording warnings for the deprecated fields it
+// contains. Bodies that aren't JSON objects are returned as-is.
+func addResponseWarnings(r *http.Request, data interface{}) interface{} {
+	d := requestWarnings(r)
+	if d == nil {
+		return data
+	}
+
+	var body map[string]interface{}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		body = v
+	case json.RawMessage:
+		// Stored format=lines variants; only decoded when there is something to add
+		if len(d.all()) == 0 && len(deprecatedResponseFields()) == 0 {
+			return data
+		}
+		if err := json.Unmarshal(v, &body); err != nil {
+			return data
+		}
+	default:
+		return data
+	}
+
+	for _, field := range deprecatedResponseFields() {
+		if _, ok := body[field]; ok {
+			d.add(deprecatedField, field, "The "+field+" field will be removed from this response")
+		}
+	}
+	warnings := d.all()
+	if len(warnings) == 0 {
+		return data
+	}
+
+	var list []interface{}
+	switch existing := body["warnings"].(type) {
+	case []interface{}:
+		list = existing
+	case nil:
+	default:
+		// Typed lists (parse warnings) become generic so both kinds fit
+		raw, _ := json.Marshal(existing)
+		json.Unmarshal(raw, &list)
+	}
+	for _, item := range list {
+		if item == warnings[0] {
+			return body // Already added (body written twice)
+		}
+	}
+	list = append(list, warnings...)
+
+	result := make(map[string]interface{}, len(body)+1)
+	for k, v := range body {
+		result[k] = v
+	}
+	result["warnings"] = list
+	return result
+}
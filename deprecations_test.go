@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithDeprecations_Params(t *testing.T) {
+	r := withResponseWarnings(httptest.NewRequest(http.MethodGet, "/getLyrics?songName=x&a=y&duration=200", nil))
+	warnings := requestWarnings(r).list()
+	if len(warnings) != 2 || warnings[0].Name != "duration" || warnings[1].Name != "songName" {
+		t.Fatalf("Unexpected warnings: %+v", warnings)
+	}
+	if warnings[1].Reason != deprecatedParam || warnings[1].Message != "Use s= instead of songName=" {
+		t.Errorf("Unexpected warning: %+v", warnings[1])
+	}
+}
+
+func TestIsLegacyCacheKey(t *testing.T) {
+	tests := map[string]bool{
+		"ttml_lyrics:shape of you ed sheeran":        false,
+		"ttml_lyrics:shape of you ed sheeran 234s":   false,
+		"ttml_lyrics:Shape of You Ed Sheeran ":       true,
+		"ttml_lyrics:shape of you ed sheeran  234s":  true,
+		"ttml_lyrics:Shape of You Ed Sheeran Divide": true,
+	}
+	for key, want := range tests {
+		if got := isLegacyCacheKey(key); got != want {
+			t.Errorf("isLegacyCacheKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestAddDeprecationWarnings(t *testing.T) {
+	originalFields, originalSunset := conf().Configuration.DeprecatedResponseFields, conf().Configuration.DeprecationSunset
+	conf().Configuration.DeprecatedResponseFields = "score"
+	conf().Configuration.DeprecationSunset = "2027-01-31"
+	defer func() {
+		conf().Configuration.DeprecatedResponseFields, conf().Configuration.DeprecationSunset = originalFields, originalSunset
+	}()
+
+	// No collector: untouched
+	plain := map[string]interface{}{"ttml": "<tt/>", "score": 0.9}
+	if got := addResponseWarnings(httptest.NewRequest(http.MethodGet, "/", nil), plain); got.(map[string]interface{})["warnings"] != nil {
+		t.Error("Requests without a collector should get no warnings")
+	}
+
+	r := withResponseWarnings(httptest.NewRequest(http.MethodGet, "/getLyrics?song=x", nil))
+	body := addResponseWarnings(r, map[string]interface{}{"ttml": "<tt/>", "score": 0.9}).(map[string]interface{})
+	warnings := body["warnings"].([]interface{})
+	if len(warnings) != 2 {
+		t.Fatalf("Expected a param and a field warning, got %+v", warnings)
+	}
+	if w := warnings[1].(deprecationWarning); w.Reason != deprecatedField || w.Name != "score" || w.Sunset != "2027-01-31" {
+		t.Errorf("Unexpected field warning: %+v", w)
+	}
+
+	// Written twice (writeLyricsJSON falling back to JSON): not duplicated
+	again := addResponseWarnings(r, body).(map[string]interface{})
+	if len(again["warnings"].([]interface{})) != 2 {
+		t.Errorf("Warnings were added twice: %+v", again["warnings"])
+	}
+
+	// Parse warnings of the lines shapes share the list
+	lines := addResponseWarnings(r, map[string]interface{}{
+		"lines":    []interface{}{},
+		"warnings": []ttml.ParseWarning{{Reason: "invalid_timing", Count: 2}},
+	}).(map[string]interface{})
+	merged := lines["warnings"].([]interface{})
+	if len(merged) != 3 || merged[0].(map[string]interface{})["reason"] != "invalid_timing" {
+		t.Errorf("Unexpected merged warnings: %+v", merged)
+	}
+
+	// Stored format=lines variants are pre-encoded
+	raw := addResponseWarnings(r, json.RawMessage(`{"lines":[]}`))
+	if decoded, ok := raw.(map[string]interface{}); !ok || decoded["warnings"] == nil {
+		t.Errorf("Expected warnings on an encoded body, got %v", raw)
+	}
+}
+
+func TestGetLyrics_DeprecationWarnings(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	originalSunset := conf().Configuration.DeprecationSunset
+	conf().Configuration.DeprecationSunset = "2027-01-31"
+	defer func() { conf().Configuration.DeprecationSunset = originalSunset }()
+
+	setCachedLyrics(buildNormalizedCacheKey("Deprecated Song", "Artist", "", ""), testTTML, 0, 0, "", false)
+
+	rr := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?song=Deprecated+Song&artist=Artist", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Deprecation") != "true" || rr.Header().Get("Sunset") != "Sun, 31 Jan 2027 00:00:00 GMT" {
+		t.Errorf("Unexpected headers: Deprecation %q, Sunset %q", rr.Header().Get("Deprecation"), rr.Header().Get("Sunset"))
+	}
+	var body struct {
+		TTML     string               `json:"ttml"`
+		Warnings []deprecationWarning `json:"warnings"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.TTML == "" || len(body.Warnings) != 2 || body.Warnings[0].Name != "artist" || body.Warnings[1].Name != "song" {
+		t.Errorf("Unexpected body: %+v", body)
+	}
+
+	rr = httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=Deprecated+Song&a=Artist", nil))
+	if rr.Header().Get("Deprecation") != "" {
+		t.Error("Current parameters should not be flagged")
+	}
+	var current map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &current)
+	if _, ok := current["warnings"]; ok {
+		t.Errorf("Unexpected warnings: %v", current["warnings"])
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"lyrics-api-go/config"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severities of a /diagnose problem, most urgent first
+const (
+	severityCritical = "critical" // Lookups are failing or about to
+	severityWarning  = "warning"  // Degraded, or will become a problem without action
+	severityInfo     = "info"
+)
+
+var severityRank = map[string]int{severityCritical: 0, severityWarning: 1, severityInfo: 2}
+
+// diagnoseProbeKey is written and deleted to check that the cache accepts writes
+const diagnoseProbeKey = "diagnose:probe"
+
+// DiagnoseProblem is one detected problem and what to do about it
+type DiagnoseProblem struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+	Fix      string `json:"fix"`
+}
+
+// diagnose inspects the running service and returns its problems, most urgent first
+func diagnose() []DiagnoseProblem {
+	var problems []DiagnoseProblem
+	for _, check := range []func() []DiagnoseProblem{
+		diagnoseAccounts,
+		diagnoseCircuitBreaker,
+		diagnoseBearerToken,
+		diagnoseCache,
+		diagnoseDisk,
+		diagnoseRemoteConfig,
+		diagnoseSetup,
+	} {
+		problems = append(problems, check()...)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return severityRank[problems[i].Severity] < severityRank[problems[j].Severity]
+	})
+	return problems
+}
+
+func diagnoseAccounts() []DiagnoseProblem {
+	accounts, err := conf().GetTTMLAccounts()
+	if err != nil || len(accounts) == 0 {
+		return []DiagnoseProblem{{
+			Severity: severityCritical,
+			Check:    "accounts",
+			Message:  "No active TTML accounts are configured, every cache miss fails",
+			Fix:      "Set TTML_MEDIA_USER_TOKENS (see GET /setup/check) and restart",
+		}}
+	}
+
+	byState := make(map[string][]string)
+	for _, acc := range accounts {
+		state := ttml.GetAccountState(acc.Name)
+		byState[state] = append(byState[state], acc.Name)
+	}
+	disabled, quarantined := byState[ttml.AccountStateDisabled], byState[ttml.AccountStateQuarantined]
+
+	var problems []DiagnoseProblem
+	if len(disabled)+len(quarantined) == len(accounts) {
+		problems = append(problems, DiagnoseProblem{
+			Severity: severityCritical,
+			Check:    "accounts",
+			Message:  fmt.Sprintf("All %d accounts are out of rotation (%d disabled, %d quarantined)", len(accounts), len(disabled), len(quarantined)),
+			Fix:      "Quarantines lift on their own once the rate limit window passes. For disabled accounts, log in again and update TTML_MEDIA_USER_TOKENS; GET /health/mut shows each account's last canary error",
+		})
+	} else if len(disabled) > 0 {
+		problems = append(problems, DiagnoseProblem{
+			Severity: severityWarning,
+			Check:    "accounts",
+			Message:  "Disabled after failing the canary check: " + strings.Join(disabled, ", "),
+			Fix:      "Their MUT is likely stale: log in again and update TTML_MEDIA_USER_TOKENS. The probe re-enables them after DISABLED_PROBE_SUCCESSES canary successes in a row (GET /health/mut shows probe_streak)",
+		})
+	}
+	if expiring := byState[ttml.AccountStateExpiring]; len(expiring) > 0 {
+		problems = append(problems, DiagnoseProblem{
+			Severity: severityWarning,
+			Check:    "account_expiry",
+			Message:  "Tokens expiring within ACCOUNT_EXPIRING_WINDOW_HOURS: " + strings.Join(expiring, ", "),
+			Fix:      "Replace these MUTs in TTML_MEDIA_USER_TOKENS before they expire; GET /health (with the admin token) shows remaining_hours",
+		})
+	}
+	return problems
+}
+
+func diagnoseCircuitBreaker() []DiagnoseProblem {
+	state, failures, retryIn := ttml.GetCircuitBreakerStats()
+	if state != "OPEN" {
+		return nil
+	}
+	return []DiagnoseProblem{{
+		Severity: severityCritical,
+		Check:    "circuit_breaker",
+		Message:  fmt.Sprintf("Circuit breaker is open after %d failures, cache misses fail fast for %v", failures, retryIn.Round(time.Second)),
+		Fix:      "Check GET /circuit-breaker and the upstream error rate in GET /stats. Once upstream recovers, POST /circuit-breaker/reset closes it without waiting for CIRCUIT_BREAKER_COOLDOWN_SECS",
+	}}
+}
+
+func diagnoseBearerToken() []DiagnoseProblem {
+	expiry, remaining, _ := ttml.GetTokenStatus()
+	switch {
+	case expiry.IsZero():
+		return []DiagnoseProblem{{
+			Severity: severityWarning,
+			Check:    "bearer_token",
+			Message:  "The shared bearer token has not been fetched yet",
+			Fix:      "It is scraped on the first cache miss. If misses fail with token errors, check TTML_TOKEN_SOURCE_URL",
+		}}
+	case remaining <= 0:
+		return []DiagnoseProblem{{
+			Severity: severityCritical,
+			Check:    "bearer_token",
+			Message:  "The shared bearer token expired at " + expiry.Format(time.RFC3339) + " and was not refreshed",
+			Fix:      "Check that TTML_TOKEN_SOURCE_URL still serves the token (the page layout may have changed) and look for token errors in the logs",
+		}}
+	}
+	return nil
+}
+
+func diagnoseCache() []DiagnoseProblem {
+	var problems []DiagnoseProblem
+	err := persistentCache.CreateBucket(metaBucket)
+	if err == nil {
+		err = persistentCache.SetInBucket(metaBucket, diagnoseProbeKey, []byte(time.Now().Format(time.RFC3339)))
+	}
+	if err != nil {
+		problems = append(problems, DiagnoseProblem{
+			Severity: severityCritical,
+			Check:    "cache_writable",
+			Message:  "The cache rejects writes: " + err.Error(),
+			Fix:      "Check the volume and permissions of CACHE_DB_PATH. If the file is damaged, POST /cache/restore with a backup from GET /cache/backups",
+		})
+	} else {
+		persistentCache.DeleteFromBucket(metaBucket, diagnoseProbeKey)
+	}
+	if corrupt := persistentCache.CorruptEntries(); corrupt > 0 {
+		problems = append(problems, DiagnoseProblem{
+			Severity: severityWarning,
+			Check:    "cache_corruption",
+			Message:  fmt.Sprintf("%d corrupt cache entries were found on read since startup", corrupt),
+			Fix:      "Run POST /cache/verify?repair=quarantine to find the rest, then inspect GET /cache/quarantine",
+		})
+	}
+	if !persistentCache.IsPreloadComplete() {
+		problems = append(problems, DiagnoseProblem{
+			Severity: severityInfo,
+			Check:    "cache_preload",
+			Message:  "The cache is still loading",
+			Fix:      "Wait for the preload to finish; GET /health reports cache_ready",
+		})
+	}
+	return problems
+}
+
+func diagnoseDisk() []DiagnoseProblem {
+	var problems []DiagnoseProblem
+	if persistentCache.IsReadOnly() {
+		problems = append(problems, DiagnoseProblem{
+			Severity: severityCritical,
+			Check:    "cache_read_only",
+			Message:  "The cache volume is almost full, so the cache is read-only: hits are served but misses are not cached",
+			Fix:      "POST /cache/trash/purge, delete old backups listed in GET /cache/backups or grow the volume. Writes resume once free space is back above DISK_LOW_FREE_MB",
+		})
+	}
+	dirs := monitoredDiskDirs()
+	for _, role := range []string{diskRoleCache, diskRoleBackups} {
+		status, ok := measureDisk(dirs[role])
+		if !ok || !status.Low {
+			continue
+		}
+		problems = append(problems, DiagnoseProblem{
+			Severity: severityWarning,
+			Check:    "disk_space",
+			Message:  fmt.Sprintf("%d MB (%.1f%%) free on the %s volume (%s), below DISK_LOW_FREE_MB", status.FreeMB, status.FreePercent, role, dirs[role]),
+			Fix:      "POST /cache/trash/purge, delete old backups listed in GET /cache/backups (CACHE_BACKUP_PATH), or grow the volume",
+		})
+	}
+	return problems
+}
+
+// diagnoseRemoteConfig reports a remote config document that can't be fetched or
+// applied; replicas keep their last good settings meanwhile
+func diagnoseRemoteConfig() []DiagnoseProblem {
+	status := config.RemoteConfigStatus()
+	if status == nil || status.LastError == "" {
+		return nil
+	}
+	return []DiagnoseProblem{{
+		Severity: severityWarning,
+		Check:    "remote_config",
+		Message:  "Remote config not applied: " + status.LastError,
+		Fix:      "Check REMOTE_CONFIG_URL is reachable, that <url>.sig is the HMAC-SHA256 of the current document under REMOTE_CONFIG_SECRET, that its version is above the applied one, and that every value parses",
+	}}
+}
+
+// diagnoseSetup reports missing settings from /setup/check
+func diagnoseSetup() []DiagnoseProblem {
+	var problems []DiagnoseProblem
+	report := checkSetup(*conf(), startupStorageCheck())
+	for _, issue := range report.Missing {
+		if issue.Setting == "TTML_MEDIA_USER_TOKENS" {
+			continue // Covered by diagnoseAccounts
+		}
+		problems = append(problems, DiagnoseProblem{
+			Severity: severityCritical,
+			Check:    "setup",
+			Message:  issue.Setting + ": " + issue.Message,
+			Fix:      issue.Hint,
+		})
+	}
+	return problems
+}
+
+// diagnoseHandler lists the problems detected in the running service, most urgent
+// first, each with the admin endpoint or setting that fixes it
+func diagnoseHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	problems := diagnose()
+	status := "ok"
+	if len(problems) > 0 {
+		status = problems[0].Severity
+	}
+	if problems == nil {
+		problems = []DiagnoseProblem{}
+	}
+	Respond(w, r).JSON(map[string]interface{}{
+		"status":     status,
+		"problems":   problems,
+		"checked_at": time.Now().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiagnoseHandler(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	originalToken := conf().Configuration.CacheAccessToken
+	originalMUT, originalMUTs := conf().Configuration.TTMLMediaUserToken, conf().Configuration.TTMLMediaUserTokens
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() {
+		conf().Configuration.CacheAccessToken = originalToken
+		conf().Configuration.TTMLMediaUserToken, conf().Configuration.TTMLMediaUserTokens = originalMUT, originalMUTs
+		// The provider reads the same config, so its pool may now hold diagnose_mut
+		ttml.ReloadAccounts()
+	}()
+
+	diagnoseChecks := func() (string, map[string]DiagnoseProblem) {
+		req := httptest.NewRequest(http.MethodGet, "/diagnose", nil)
+		req.Header.Set("Authorization", "test-token")
+		rr := httptest.NewRecorder()
+		diagnoseHandler(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Status   string            `json:"status"`
+			Problems []DiagnoseProblem `json:"problems"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		checks := make(map[string]DiagnoseProblem)
+		for i, p := range resp.Problems {
+			if i > 0 && severityRank[p.Severity] < severityRank[resp.Problems[i-1].Severity] {
+				t.Errorf("Problems not ordered by severity: %+v", resp.Problems)
+			}
+			if p.Fix == "" {
+				t.Errorf("Problem %s has no fix", p.Check)
+			}
+			checks[p.Check] = p
+		}
+		return resp.Status, checks
+	}
+
+	conf().Configuration.TTMLMediaUserToken, conf().Configuration.TTMLMediaUserTokens = "", ""
+	status, checks := diagnoseChecks()
+	if status != severityCritical || checks["accounts"].Severity != severityCritical {
+		t.Errorf("Expected a critical accounts problem, got %s %+v", status, checks)
+	}
+	if _, ok := checks["cache_writable"]; ok {
+		t.Error("A writable cache should not be reported")
+	}
+
+	conf().Configuration.TTMLMediaUserToken = "diagnose_mut"
+	persistentCache.Close()
+	_, checks = diagnoseChecks()
+	if _, ok := checks["accounts"]; ok {
+		t.Errorf("A healthy account should not be reported, got %+v", checks["accounts"])
+	}
+	if checks["cache_writable"].Severity != severityCritical {
+		t.Errorf("Expected a critical cache_writable problem for a closed cache, got %+v", checks)
+	}
+
+	rr := httptest.NewRecorder()
+	diagnoseHandler(rr, httptest.NewRequest(http.MethodGet, "/diagnose", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", rr.Code)
+	}
+}
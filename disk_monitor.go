@@ -0,0 +1,147 @@
+package main
+
+import (
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/notifier"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// diskMonitorInterval is how often free space is checked
+const diskMonitorInterval = time.Minute
+
+// Directories whose volumes are monitored
+const (
+	diskRoleCache   = "cache"
+	diskRoleBackups = "backups"
+)
+
+// DiskStatus is the free space of one monitored volume, shown in /health
+type DiskStatus struct {
+	FreeMB      uint64  `json:"free_mb"`
+	TotalMB     uint64  `json:"total_mb"`
+	FreePercent float64 `json:"free_percent"`
+	Low         bool    `json:"low"` // Below DISK_LOW_FREE_MB
+}
+
+var (
+	diskMu       sync.Mutex
+	diskDirs     map[string]string // role -> directory
+	diskStatuses map[string]DiskStatus
+	diskLowSent  = make(map[string]bool) // Low alert already sent for a role
+)
+
+// startDiskMonitor checks the volumes of the cache and backup directories every
+// minute, alerting when free space is low and switching the cache to read-only
+// when its volume is almost full
+func startDiskMonitor(cachePath, backupPath string) {
+	diskMu.Lock()
+	diskDirs = map[string]string{
+		diskRoleCache:   filepath.Dir(cachePath),
+		diskRoleBackups: backupPath,
+	}
+	diskMu.Unlock()
+
+	go func() {
+		for {
+			checkDiskSpace()
+			time.Sleep(diskMonitorInterval)
+		}
+	}()
+	log.Infof("%s Disk monitor started (low: %d MB, read-only: %d MB)",
+		logcolors.LogCache, conf().Configuration.DiskLowFreeMB, conf().Configuration.DiskReadOnlyFreeMB)
+}
+
+// diskUsageFunc measures a volume (replaced in tests)
+var diskUsageFunc = diskUsage
+
+// measureDisk returns the free space of the volume holding dir. A directory that
+// doesn't exist yet (backups are created on first use) is measured at its parent.
+func measureDisk(dir string) (DiskStatus, bool) {
+	if _, err := os.Stat(dir); err != nil {
+		dir = filepath.Dir(filepath.Clean(dir))
+	}
+	free, total, err := diskUsageFunc(dir)
+	if err != nil || total == 0 {
+		return DiskStatus{}, false
+	}
+	status := DiskStatus{
+		FreeMB:      free >> 20,
+		TotalMB:     total >> 20,
+		FreePercent: float64(free) / float64(total) * 100,
+	}
+	if low := conf().Configuration.DiskLowFreeMB; low > 0 && status.FreeMB < uint64(low) {
+		status.Low = true
+	}
+	return status, true
+}
+
+// checkDiskSpace measures every monitored volume, sends a low space alert once per
+// crossing, and enters or leaves read-only mode for the cache volume
+func checkDiskSpace() {
+	diskMu.Lock()
+	defer diskMu.Unlock()
+
+	statuses := make(map[string]DiskStatus, len(diskDirs))
+	for role, dir := range diskDirs {
+		status, ok := measureDisk(dir)
+		if !ok {
+			continue
+		}
+		statuses[role] = status
+
+		if status.Low && !diskLowSent[role] {
+			log.Warnf("%s Only %d MB free on the %s volume (%s)", logcolors.LogCache, status.FreeMB, role, dir)
+			notifier.PublishDiskSpaceLow(role, dir, status.FreeMB, uint64(conf().Configuration.DiskLowFreeMB))
+		}
+		diskLowSent[role] = status.Low
+	}
+	diskStatuses = statuses
+
+	cacheStatus, ok := statuses[diskRoleCache]
+	if !ok {
+		return
+	}
+	readOnlyMB := uint64(max(conf().Configuration.DiskReadOnlyFreeMB, 0))
+	switch {
+	case !persistentCache.IsReadOnly() && cacheStatus.FreeMB < readOnlyMB:
+		persistentCache.SetReadOnly(true)
+		log.Errorf("%s Only %d MB free on the cache volume, cache is now read-only", logcolors.LogCache, cacheStatus.FreeMB)
+		notifier.PublishCacheReadOnly(cacheStatus.FreeMB, readOnlyMB)
+	case persistentCache.IsReadOnly() && !cacheStatus.Low && cacheStatus.FreeMB >= readOnlyMB:
+		// Leave read-only mode only once the volume is out of the low range, so a
+		// few freed megabytes don't make it flap
+		persistentCache.SetReadOnly(false)
+		log.Infof("%s %d MB free on the cache volume, cache is writable again", logcolors.LogCache, cacheStatus.FreeMB)
+		notifier.PublishCacheWritable(cacheStatus.FreeMB)
+	}
+}
+
+// monitoredDiskDirs returns the monitored directories by role, from the settings
+// when the monitor hasn't started
+func monitoredDiskDirs() map[string]string {
+	diskMu.Lock()
+	defer diskMu.Unlock()
+	if diskDirs != nil {
+		return diskDirs
+	}
+	return map[string]string{
+		diskRoleCache:   filepath.Dir(getEnvOrDefault("CACHE_DB_PATH", "./cache.db")),
+		diskRoleBackups: getEnvOrDefault("CACHE_BACKUP_PATH", "./backups"),
+	}
+}
+
+// diskStatusSnapshot returns the last measurement of each monitored volume
+func diskStatusSnapshot() map[string]DiskStatus {
+	diskMu.Lock()
+	defer diskMu.Unlock()
+	snapshot := make(map[string]DiskStatus, len(diskStatuses))
+	for role, status := range diskStatuses {
+		snapshot[role] = status
+	}
+	return snapshot
+}
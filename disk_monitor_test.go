@@ -0,0 +1,80 @@
+package main
+
+import (
+	"lyrics-api-go/services/notifier"
+	"testing"
+)
+
+func TestCheckDiskSpace_ReadOnlyMode(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	initMetadataBuckets()
+
+	originalLow, originalReadOnly := conf().Configuration.DiskLowFreeMB, conf().Configuration.DiskReadOnlyFreeMB
+	conf().Configuration.DiskLowFreeMB, conf().Configuration.DiskReadOnlyFreeMB = 1024, 256
+	freeMB := uint64(100)
+	originalUsage := diskUsageFunc
+	diskUsageFunc = func(dir string) (uint64, uint64, error) { return freeMB << 20, 10240 << 20, nil }
+	diskMu.Lock()
+	originalDirs := diskDirs
+	diskDirs = map[string]string{diskRoleCache: t.TempDir(), diskRoleBackups: t.TempDir()}
+	diskMu.Unlock()
+	defer func() {
+		conf().Configuration.DiskLowFreeMB, conf().Configuration.DiskReadOnlyFreeMB = originalLow, originalReadOnly
+		diskUsageFunc = originalUsage
+		diskMu.Lock()
+		diskDirs = originalDirs
+		diskLowSent = make(map[string]bool)
+		diskMu.Unlock()
+	}()
+
+	bus := notifier.GetEventBus()
+	rec := &corruptionRecorder{}
+	unsubscribe := bus.Register(rec, notifier.EventDiskSpaceLow, notifier.EventCacheReadOnly, notifier.EventCacheWritable)
+	defer unsubscribe()
+
+	cachedKey := buildNormalizedCacheKey("cached", "artist", "", "")
+	setCachedLyrics(cachedKey, testTTML, 0, 0, "", false)
+
+	checkDiskSpace()
+	if !persistentCache.IsReadOnly() {
+		t.Fatal("Expected read-only mode below DISK_READONLY_FREE_MB")
+	}
+	if status := diskStatusSnapshot()[diskRoleCache]; !status.Low || status.FreeMB != 100 {
+		t.Errorf("Unexpected cache volume status %+v", status)
+	}
+	checkDiskSpace() // Still low: no repeated alerts
+
+	// Hits are served, new entries are skipped
+	if _, ok := getCachedLyrics(cachedKey); !ok {
+		t.Error("Expected cached lyrics to be served in read-only mode")
+	}
+	newKey := buildNormalizedCacheKey("new", "artist", "", "")
+	setCachedLyrics(newKey, testTTML, 0, 0, "", false)
+	if _, ok := getCachedLyrics(newKey); ok {
+		t.Error("Expected writes to be skipped in read-only mode")
+	}
+
+	// Above the read-only threshold but still low: stays read-only
+	freeMB = 512
+	checkDiskSpace()
+	if !persistentCache.IsReadOnly() {
+		t.Error("Expected read-only mode until free space is above DISK_LOW_FREE_MB")
+	}
+	freeMB = 2048
+	checkDiskSpace()
+	if persistentCache.IsReadOnly() {
+		t.Error("Expected the cache to be writable again")
+	}
+
+	bus.Drain()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	counts := make(map[notifier.EventType]int)
+	for _, event := range rec.events {
+		counts[event.Type]++
+	}
+	if counts[notifier.EventDiskSpaceLow] != 2 || counts[notifier.EventCacheReadOnly] != 1 || counts[notifier.EventCacheWritable] != 1 {
+		t.Errorf("Unexpected events %v", counts)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+// diskUsage is not implemented on this platform; disk checks are skipped
+func diskUsage(dir string) (free, total uint64, err error) {
+	return 0, 0, errors.New("disk usage is not supported on this platform")
+}
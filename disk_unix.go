@@ -0,0 +1,15 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// diskUsage returns the free (available to this user) and total bytes of the
+// filesystem holding dir
+func diskUsage(dir string) (free, total uint64, err error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(dir, &fs); err != nil {
+		return 0, 0, err
+	}
+	return uint64(fs.Bavail) * uint64(fs.Bsize), uint64(fs.Blocks) * uint64(fs.Bsize), nil
+}
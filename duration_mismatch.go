@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"lyrics-api-go/services/providers/ttml"
+	"lyrics-api-go/stats"
+	"net/http"
+	"strconv"
+)
+
+// durationMismatchWarning is added to the warnings of a cache hit whose stored
+// track length is further from the requested duration than DURATION_MATCH_DELTA_MS:
+// the entry may hold another edit of the song (radio edit, extended mix), which
+// happens when the lookup fell back to a key without the duration
+type durationMismatchWarning struct {
+	Reason          string `json:"reason"` // Always "duration_mismatch"
+	Message         string `json:"message"`
+	RequestedMs     int    `json:"requestedMs"`
+	TrackDurationMs int    `json:"trackDurationMs"`
+}
+
+// cachedLyricsBody is lyricsBody for lyrics served from the cache. When the request
+// gave a duration (d, in seconds) and the entry has a stored track length, the
+// delta is recorded in stats and a mismatch beyond the match delta is added to the
+// request's warnings.
+func cachedLyricsBody(r *http.Request, cacheKey string, cached *CachedLyrics, durationStr string) map[string]interface{} {
+	body := withCachedMatchedTrack(lyricsBody(cached), cacheKey)
+	requestedSec, err := strconv.Atoi(durationStr)
+	if err != nil || requestedSec <= 0 || cached.TrackDurationMs <= 0 {
+		return body
+	}
+
+	requestedMs := requestedSec * 1000
+	deltaMs := cached.TrackDurationMs - requestedMs
+	deltaMs = max(deltaMs, -deltaMs)
+	// Durations are requested in whole seconds, so the lookup never matches closer than 1s
+	mismatch := deltaMs > max(conf().Configuration.DurationMatchDeltaMs, 1000)
+	stats.Get().RecordCacheHitDuration(ttml.ProviderName, deltaMs, mismatch)
+	if mismatch {
+		requestWarnings(r).addWarning(durationMismatchWarning{
+			Reason:          "duration_mismatch",
+			Message:         fmt.Sprintf("The cached track is %.1fs long, %.1fs off the requested duration", float64(cached.TrackDurationMs)/1000, float64(deltaMs)/1000),
+			RequestedMs:     requestedMs,
+			TrackDurationMs: cached.TrackDurationMs,
+		})
+	}
+	return body
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetLyrics_DurationMismatchWarning(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// Stored under the requested duration, but the track is 40s shorter (another edit)
+	setCachedLyrics(buildNormalizedCacheKey("Edit Song", "Artist", "", "240"), testTTML, 200000, 0.9, "en", false)
+	setCachedLyrics(buildNormalizedCacheKey("Edit Song", "Artist", "", "201"), testTTML, 200000, 0.9, "en", false)
+
+	tests := []struct {
+		name     string
+		query    string
+		mismatch bool
+	}{
+		{"beyond the delta", "d=240", true},
+		{"beyond the delta, lines", "d=240&format=lines", true},
+		{"within the delta", "d=201", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := stats.Get().DurationMismatches.Load()
+			rr := httptest.NewRecorder()
+			newTestRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=Edit+Song&a=Artist&"+tt.query, nil))
+			if rr.Code != http.StatusOK || rr.Header().Get("X-Cache-Status") != "HIT" {
+				t.Fatalf("Expected a 200 HIT, got %d %q", rr.Code, rr.Header().Get("X-Cache-Status"))
+			}
+
+			var body struct {
+				Warnings []map[string]interface{} `json:"warnings"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			var warning map[string]interface{}
+			for _, w := range body.Warnings {
+				if w["reason"] == "duration_mismatch" {
+					warning = w
+				}
+			}
+			if (warning != nil) != tt.mismatch {
+				t.Fatalf("duration_mismatch warning = %v, want %v", warning, tt.mismatch)
+			}
+			if tt.mismatch && (warning["requestedMs"] != float64(240000) || warning["trackDurationMs"] != float64(200000)) {
+				t.Errorf("Unexpected warning %v", warning)
+			}
+			if tt.mismatch && rr.Header().Get("Deprecation") != "" {
+				t.Error("A duration mismatch is not a deprecation")
+			}
+
+			want := int64(0)
+			if tt.mismatch {
+				want = 1
+			}
+			if got := stats.Get().DurationMismatches.Load() - before; got != want {
+				t.Errorf("DurationMismatches grew by %d, want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// compactExcludedFields are dropped by compact=true: per-syllable timing, which
+// makes up most of a parsed-lines payload
+var compactExcludedFields = []string{"lines.syllables", "lines.backgroundVocals"}
+
+// fieldPathPattern matches one fields= entry (dot-separated JSON field names)
+var fieldPathPattern = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+
+// fieldTree is a set of JSON field paths. A nil subtree stands for the whole field.
+// Arrays are transparent: "lines.words" applies to every element of lines.
+type fieldTree map[string]fieldTree
+
+func buildFieldTree(paths []string) fieldTree {
+	tree := fieldTree{}
+	for _, path := range paths {
+		node := tree
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			child, seen := node[part]
+			if seen && child == nil {
+				break // An ancestor is already kept whole
+			}
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if !seen {
+				child = fieldTree{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// lookup finds the subtree for a JSON key, also accepting its snake_case name so
+// the same fields= works under /v1
+func (t fieldTree) lookup(key string) (fieldTree, bool) {
+	if sub, ok := t[key]; ok {
+		return sub, true
+	}
+	sub, ok := t[snakeCase(key)]
+	return sub, ok
+}
+
+// pick keeps only the fields in the tree
+func (t fieldTree) pick(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		picked := make(map[string]interface{}, len(t))
+		for key, item := range value {
+			if sub, ok := t.lookup(key); ok {
+				if sub == nil {
+					picked[key] = item
+				} else {
+					picked[key] = sub.pick(item)
+				}
+			}
+		}
+		return picked
+	case []interface{}:
+		for i := range value {
+			value[i] = t.pick(value[i])
+		}
+	}
+	return v
+}
+
+// drop removes the fields in the tree
+func (t fieldTree) drop(v interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if sub, ok := t.lookup(key); ok {
+				if sub == nil {
+					delete(value, key)
+				} else {
+					sub.drop(item)
+				}
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			t.drop(item)
+		}
+	}
+}
+
+// fieldFilter is the sparse fieldset a request asked for
+type fieldFilter struct {
+	include fieldTree // nil = every field
+	exclude fieldTree
+}
+
+// parseFieldFilter reads fields= (comma-separated paths such as lines.words) and
+// compact=true. Returns nil when the response is sent whole.
+func parseFieldFilter(r *http.Request) (*fieldFilter, error) {
+	query := r.URL.Query()
+	filter := &fieldFilter{}
+
+	if raw := strings.TrimSpace(query.Get("fields")); raw != "" {
+		var paths []string
+		for _, path := range strings.Split(raw, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			if !fieldPathPattern.MatchString(path) {
+				return nil, fmt.Errorf("invalid field %q in fields (use dot-separated names, e.g. lines.words)", path)
+			}
+			paths = append(paths, path)
+		}
+		if len(paths) > 0 {
+			filter.include = buildFieldTree(paths)
+		}
+	}
+	if raw := query.Get("compact"); raw != "" {
+		compact, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("compact must be true or false")
+		}
+		if compact {
+			filter.exclude = buildFieldTree(compactExcludedFields)
+		}
+	}
+
+	if filter.include == nil && filter.exclude == nil {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// apply returns data with only the requested fields. Data that doesn't round-trip
+// through JSON is returned unchanged.
+func (f *fieldFilter) apply(data interface{}) interface{} {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return data
+	}
+	if f.include != nil {
+		value = f.include.pick(value)
+	}
+	if f.exclude != nil {
+		f.exclude.drop(value)
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFieldFilter(t *testing.T) {
+	data := map[string]interface{}{
+		"timingType": "Word",
+		"lines": []map[string]interface{}{
+			{"words": "Hello", "startTimeMs": "1000", "syllables": []string{"Hel", "lo"}},
+			{"words": "World", "startTimeMs": "2000", "syllables": []string{"World"}},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"no filter", "", ""},
+		{"sparse fieldset", "fields=lines.words,lines.startTimeMs",
+			`{"lines":[{"startTimeMs":"1000","words":"Hello"},{"startTimeMs":"2000","words":"World"}]}`},
+		{"whole field wins", "fields=lines.words,lines,timingType",
+			`{"lines":[{"startTimeMs":"1000","syllables":["Hel","lo"],"words":"Hello"},{"startTimeMs":"2000","syllables":["World"],"words":"World"}],"timingType":"Word"}`},
+		{"snake_case names", "fields=lines.start_time_ms",
+			`{"lines":[{"startTimeMs":"1000"},{"startTimeMs":"2000"}]}`},
+		{"compact", "compact=true",
+			`{"lines":[{"startTimeMs":"1000","words":"Hello"},{"startTimeMs":"2000","words":"World"}],"timingType":"Word"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := parseFieldFilter(httptest.NewRequest(http.MethodGet, "/getLyrics?"+tt.query, nil))
+			if err != nil {
+				t.Fatalf("parseFieldFilter: %v", err)
+			}
+			if tt.want == "" {
+				if filter != nil {
+					t.Errorf("Expected no filter, got %+v", filter)
+				}
+				return
+			}
+			got, _ := json.Marshal(filter.apply(data))
+			if string(got) != tt.want {
+				t.Errorf("apply = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	for _, query := range []string{"fields=lines..words", "fields=lines[0]", "compact=maybe"} {
+		if _, err := parseFieldFilter(httptest.NewRequest(http.MethodGet, "/getLyrics?"+query, nil)); err == nil {
+			t.Errorf("Expected an error for %s", query)
+		}
+	}
+}
+
+func TestGetLyrics_Fields(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	setCachedLyrics(buildNormalizedCacheKey("song", "artist", "", ""), formatTestTTML, 0, 0, "", false)
+
+	rr := httptest.NewRecorder()
+	getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format=lines&fields=lines.words", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Body.String(); got != `{"lines":[{"words":"First line"},{"words":"Second line"}]}`+"\n" {
+		t.Errorf("body = %s", got)
+	}
+
+	rr = httptest.NewRecorder()
+	getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&fields=a%20b", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid field, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/stats"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// formatVariantsBucket holds cached lyrics already converted to a derived format
+// (lrc, text, lines), so hot tracks aren't re-parsed on every request. A variant
+// is keyed by its parent cache key plus the format ("<key>#lrc").
+const formatVariantsBucket = "format_variants"
+
+// FormatVariant is one precomputed conversion of a cached TTML entry
+type FormatVariant struct {
+	Content    string `json:"content"`
+	ParentHash string `json:"parentHash"` // Hash of the TTML it was converted from
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+// initFormatVariantsBucket creates the format variants bucket.
+// Called during server startup after persistentCache is initialized.
+func initFormatVariantsBucket() {
+	if err := persistentCache.CreateBucket(formatVariantsBucket); err != nil {
+		log.Errorf("%s Failed to create format variants bucket: %v", logcolors.LogCache, err)
+	}
+}
+
+// formatVariantKey is the sibling key of a cache entry for a format
+func formatVariantKey(cacheKey, format string) string {
+	return cacheKey + "#" + format
+}
+
+// ttmlHash ties a variant to the TTML it was converted from
+func ttmlHash(ttmlContent string) string {
+	sum := sha256.Sum256([]byte(ttmlContent))
+	return hex.EncodeToString(sum[:8])
+}
+
+// getFormatVariant returns the stored conversion of an entry. A variant whose parent
+// was rewritten since (hash mismatch) is treated as missing.
+func getFormatVariant(cacheKey, format, ttmlContent string) (string, bool) {
+	raw, ok := metadataGet(formatVariantsBucket, formatVariantKey(cacheKey, format))
+	if !ok {
+		return "", false
+	}
+	var variant FormatVariant
+	if err := json.Unmarshal([]byte(raw), &variant); err != nil || variant.ParentHash != ttmlHash(ttmlContent) {
+		return "", false
+	}
+	return variant.Content, true
+}
+
+func setFormatVariant(cacheKey, format, ttmlContent, content string) {
+	data, err := json.Marshal(FormatVariant{
+		Content:    content,
+		ParentHash: ttmlHash(ttmlContent),
+		CreatedAt:  time.Now().Unix(),
+	})
+	if err != nil {
+		return
+	}
+	if err := metadataSet(formatVariantsBucket, formatVariantKey(cacheKey, format), string(data)); err != nil {
+		log.Errorf("%s Error setting %s variant of %s: %v", logcolors.LogCache, format, cacheKey, err)
+	}
+}
+
+// deleteFormatVariants drops every variant of a deleted entry. Variants of a
+// rewritten entry are left in place: their hash no longer matches, so the next
+// request converts the new TTML and overwrites them.
+func deleteFormatVariants(cacheKey string) {
+	for _, format := range supportedLyricsFormats {
+		if format == formatTTML {
+			continue
+		}
+		persistentCache.DeleteFromBucket(formatVariantsBucket, formatVariantKey(cacheKey, format))
+	}
+}
+
+// respondCachedTTML is respondTTML for lyrics read from the cache entry at cacheKey.
+// Derived formats are served from the entry's stored variant, converting and
+// storing it on the first request. Variants are converted from the TTML after
+// LYRICS_POSTPROCESS, so changing the steps invalidates them; clean=true output
+// is never stored.
+func respondCachedTTML(resp *APIResponse, format, cacheKey, ttmlContent string, body map[string]interface{}) {
+	if _, derived := formatConversionErrors[format]; !derived || cacheKey == "" || requestsClean(resp.r) {
+		respondTTML(resp, format, ttmlContent, body)
+		return
+	}
+	requestTiming(resp.r).startSerialize()
+	setMatchedTrackHeaders(resp.w, body)
+	applyClientGates(resp.r, body)
+	ttmlContent = postProcessTTML(resp.r, ttmlContent, body)
+	if content, ok := getFormatVariant(cacheKey, format, ttmlContent); ok {
+		stats.Get().RecordFormatVariant(format, true)
+		writeLyricsFormat(resp, format, content)
+		return
+	}
+	stats.Get().RecordFormatVariant(format, false)
+
+	content, err := convertLyricsFormat(format, ttmlContent, body)
+	if err != nil {
+		resp.Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": formatConversionErrors[format] + err.Error(),
+		})
+		return
+	}
+	setFormatVariant(cacheKey, format, ttmlContent, content)
+	writeLyricsFormat(resp, format, content)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetLyrics_StoresFormatVariants(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	initFormatVariantsBucket()
+
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	setCachedLyrics(cacheKey, formatTestTTML, 0, 0, "", false)
+
+	get := func() string {
+		rr := httptest.NewRecorder()
+		getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format=lrc", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		return rr.Body.String()
+	}
+	before := stats.Get().FormatVariants()["lrc"]
+
+	first := get()
+	if _, ok := getFormatVariant(cacheKey, formatLRC, formatTestTTML); !ok {
+		t.Fatal("Expected the LRC variant to be stored after the first request")
+	}
+	if second := get(); second != first {
+		t.Errorf("Variant body = %q, want %q", second, first)
+	}
+	after := stats.Get().FormatVariants()["lrc"]
+	if after.Hits-before.Hits != 1 || after.Misses-before.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %+v (before %+v)", after, before)
+	}
+
+	// Rewriting the parent invalidates the variant
+	setCachedLyrics(cacheKey, strings.Replace(formatTestTTML, "First line", "New line", 1), 0, 0, "", false)
+	if body := get(); !strings.Contains(body, "New line") {
+		t.Errorf("Expected the rewritten lyrics, got %q", body)
+	}
+
+	// Deleting the parent drops its variants
+	if err := deleteCacheEntry(cacheKey, trashReasonBulkDelete); err != nil {
+		t.Fatalf("deleteCacheEntry: %v", err)
+	}
+	if _, ok := metadataGet(formatVariantsBucket, formatVariantKey(cacheKey, formatLRC)); ok {
+		t.Error("Expected the variant to be deleted with its parent")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"strings"
+)
+
+// Output formats for /getLyrics (selected via the format query parameter)
+const (
+	formatTTML  = "ttml"  // Default: JSON body with the raw TTML
+	formatText  = "text"  // Plain lyric text, one line per row, with [Section] headers
+	formatLRC   = "lrc"   // Line-synced LRC with "# Section" comments at section boundaries
+	formatLines = "lines" // JSON body with parsed lines (timing, agent, section)
+)
+
+// supportedLyricsFormats lists the accepted values for the format parameter
+var supportedLyricsFormats = []string{formatTTML, formatText, formatLRC, formatLines}
+
+// parseLyricsFormat reads the format query parameter, defaulting to TTML
+func parseLyricsFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		return formatTTML, nil
+	}
+	for _, f := range supportedLyricsFormats {
+		if format == f {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(supportedLyricsFormats, ", "))
+}
+
+// formatContentType returns the Content-Type respondTTML uses for a format
+func formatContentType(format string) string {
+	if format == formatText || format == formatLRC {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// respondTTML writes lyrics in the requested format. For the default format the
+// JSON body is written as-is, or reshaped by the request's client transformer (see
+// transformers.go); other formats are derived from ttmlContent.
+func respondTTML(resp *APIResponse, format, ttmlContent string, body map[string]interface{}) {
+	requestTiming(resp.r).startSerialize()
+	setMatchedTrackHeaders(resp.w, body)
+	applyClientGates(resp.r, body)
+	ttmlContent = postProcessTTML(resp.r, ttmlContent, body)
+	switch format {
+	case formatText, formatLRC, formatLines:
+		content, err := convertLyricsFormat(format, ttmlContent, body)
+		if err != nil {
+			resp.Error(http.StatusInternalServerError, map[string]interface{}{
+				"error": formatConversionErrors[format] + err.Error(),
+			})
+			return
+		}
+		writeLyricsFormat(resp, format, content)
+	default:
+		// Client-specific shapes (client= or API_KEY_CLIENTS) only replace the default body
+		if client := responseClient(resp.r); client != "" {
+			if transform := lookupResponseTransformer(client); transform != nil {
+				payload, err := transform(ttmlContent, body)
+				if err != nil {
+					resp.Error(http.StatusInternalServerError, map[string]interface{}{
+						"error": "Failed to build " + client + " response: " + err.Error(),
+					})
+					return
+				}
+				writeLyricsJSON(resp, payload)
+				return
+			}
+		}
+		writeLyricsJSON(resp, body)
+	}
+}
+
+// lyricsBody is the default /getLyrics JSON body: the TTML and what is known about
+// its match, the same whether it was just fetched or served from the cache.
+// Entries cached before metadata was stored have only the TTML; their language is
+// detected from it, and score and duration are left out.
+func lyricsBody(lyrics *CachedLyrics) map[string]interface{} {
+	body := map[string]interface{}{"ttml": lyrics.TTML}
+	if lyrics.Score > 0 {
+		body["score"] = lyrics.Score
+	}
+	language, isRTL := lyrics.Language, lyrics.IsRTL
+	if language == "" {
+		language, isRTL = ttml.DetectLanguage(lyrics.TTML)
+	}
+	if language != "" {
+		body["language"] = language
+		body["isRTL"] = isRTL
+	}
+	if lyrics.TrackDurationMs > 0 {
+		body["trackDurationMs"] = lyrics.TrackDurationMs
+	}
+	return body
+}
+
+// formatConversionErrors prefixes conversion errors in the 500 response
+var formatConversionErrors = map[string]string{
+	formatText:  "Failed to convert lyrics to text: ",
+	formatLRC:   "Failed to convert lyrics to LRC: ",
+	formatLines: "Failed to parse lyrics: ",
+}
+
+// convertLyricsFormat renders TTML in a derived format: the text body for text and
+// lrc, the encoded JSON body for lines
+func convertLyricsFormat(format, ttmlContent string, body map[string]interface{}) (string, error) {
+	switch format {
+	case formatText:
+		return ttml.ToPlainText(ttmlContent)
+	case formatLRC:
+		return ttml.ToLRC(ttmlContent)
+	case formatLines:
+		linesBody, err := parsedLinesBody(ttmlContent, body)
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(linesBody)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("format %q is not derived from TTML", format)
+}
+
+// writeLyricsFormat writes the output of convertLyricsFormat
+func writeLyricsFormat(resp *APIResponse, format, content string) {
+	if format == formatLines {
+		writeLyricsJSON(resp, json.RawMessage(content))
+		return
+	}
+	resp.Text(content)
+}
+
+// countSingers returns the inferred number of individual singers (groups excluded)
+func countSingers(vocalists []ttml.Vocalist) int {
+	count := 0
+	for _, v := range vocalists {
+		if v.Type != "group" {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const formatTestTTML = `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" timing="Line">
+  <body>
+    <div songPart="Verse"><p begin="0:00:01.000" end="0:00:03.000">First line</p></div>
+    <div songPart="Chorus"><p begin="0:00:03.000" end="0:00:05.000">Second line</p></div>
+  </body>
+</tt>`
+
+func TestParseLyricsFormat(t *testing.T) {
+	tests := []struct {
+		query       string
+		expected    string
+		expectError bool
+	}{
+		{"", formatTTML, false},
+		{"format=ttml", formatTTML, false},
+		{"format=TEXT", formatText, false},
+		{"format=xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/getLyrics?"+tt.query, nil)
+			got, err := parseLyricsFormat(r)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseLyricsFormat() error = %v, expectError %v", err, tt.expectError)
+			}
+			if got != tt.expected {
+				t.Errorf("parseLyricsFormat() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetLyrics_FormatText(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	setCachedLyrics(cacheKey, formatTestTTML, 0, 0, "", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format=text", nil)
+	rr := httptest.NewRecorder()
+	getLyrics(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", got)
+	}
+	if got := rr.Header().Get("X-Cache-Status"); got != "HIT" {
+		t.Errorf("X-Cache-Status = %q, want HIT", got)
+	}
+
+	expected := "[Verse]\nFirst line\n\n[Chorus]\nSecond line\n"
+	if rr.Body.String() != expected {
+		t.Errorf("body = %q, want %q", rr.Body.String(), expected)
+	}
+}
+
+func TestGetLyrics_FormatDefaultIsJSON(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	setCachedLyrics(cacheKey, formatTestTTML, 0, 0, "", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist", nil)
+	rr := httptest.NewRecorder()
+	getLyrics(rr, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body["ttml"] != formatTestTTML {
+		t.Errorf("Expected raw TTML in response body")
+	}
+}
+
+func TestGetLyrics_UnsupportedFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format=xml", nil)
+	rr := httptest.NewRecorder()
+	getLyrics(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", rr.Code)
+	}
+}
+
+func TestGetLyrics_FormatLinesIncludesSection(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	setCachedLyrics(cacheKey, formatTestTTML, 0, 0, "", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format=lines", nil)
+	rr := httptest.NewRecorder()
+	getLyrics(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var body struct {
+		Lines []struct {
+			Words   string `json:"words"`
+			Section string `json:"section"`
+		} `json:"lines"`
+		TimingType string `json:"timingType"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(body.Lines))
+	}
+	if body.Lines[0].Section != "Verse" || body.Lines[1].Section != "Chorus" {
+		t.Errorf("Unexpected sections: %+v", body.Lines)
+	}
+}
+
+func TestGetLyrics_FormatLRC(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	setCachedLyrics(cacheKey, formatTestTTML, 0, 0, "", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format=lrc", nil)
+	rr := httptest.NewRecorder()
+	getLyrics(rr, req)
+
+	expected := "# Verse\n[00:01.00]First line\n# Chorus\n[00:03.00]Second line\n"
+	if rr.Body.String() != expected {
+		t.Errorf("body = %q, want %q", rr.Body.String(), expected)
+	}
+}
+
+func TestGetLyrics_FormatLinesVocalists(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	duet := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" timing="Line">
+  <head><metadata><ttm:agent type="person" xml:id="v1"/><ttm:agent type="person" xml:id="v2"/></metadata></head>
+  <body>
+    <div>
+      <p begin="0:00:01.000" end="0:00:02.000" ttm:agent="v1">Me</p>
+      <p begin="0:00:02.000" end="0:00:03.000" ttm:agent="v2">You</p>
+    </div>
+  </body>
+</tt>`
+	cacheKey := buildNormalizedCacheKey("duet", "artist", "", "")
+	setCachedLyrics(cacheKey, duet, 0, 0, "", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=duet&a=artist&format=lines", nil)
+	rr := httptest.NewRecorder()
+	getLyrics(rr, req)
+
+	var body struct {
+		Lines []struct {
+			VocalistIndex *int `json:"vocalistIndex"`
+		} `json:"lines"`
+		Vocalists struct {
+			Count  int `json:"count"`
+			Agents []struct {
+				ID   string `json:"id"`
+				Side string `json:"side"`
+			} `json:"agents"`
+		} `json:"vocalists"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if body.Vocalists.Count != 2 {
+		t.Errorf("vocalists.count = %d, want 2", body.Vocalists.Count)
+	}
+	if len(body.Vocalists.Agents) != 2 || body.Vocalists.Agents[0].Side != "left" || body.Vocalists.Agents[1].Side != "right" {
+		t.Errorf("Unexpected vocalist agents: %+v", body.Vocalists.Agents)
+	}
+	if len(body.Lines) != 2 || body.Lines[1].VocalistIndex == nil || *body.Lines[1].VocalistIndex != 1 {
+		t.Errorf("Expected second line to reference vocalist 1")
+	}
+}
+
+func TestLyricsBody(t *testing.T) {
+	body := lyricsBody(&CachedLyrics{TTML: formatTestTTML, TrackDurationMs: 215000, Score: 0.93, Language: "he", IsRTL: true})
+	if body["score"] != 0.93 || body["language"] != "he" || body["isRTL"] != true || body["trackDurationMs"] != 215000 {
+		t.Errorf("Unexpected body: %v", body)
+	}
+
+	// Plain-TTML entries from before metadata was stored: language from the TTML only
+	legacy := lyricsBody(&CachedLyrics{TTML: `<tt xmlns="http://www.w3.org/ns/ttml" xml:lang="ar"><body><div><p begin="0s">مرحبا</p></div></body></tt>`})
+	if legacy["language"] != "ar" || legacy["isRTL"] != true {
+		t.Errorf("Expected the language detected from the TTML, got %v", legacy)
+	}
+	for _, field := range []string{"score", "trackDurationMs"} {
+		if _, ok := legacy[field]; ok {
+			t.Errorf("Unknown %s should be left out", field)
+		}
+	}
+}
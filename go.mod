@@ -11,6 +11,13 @@ require (
 	github.com/sirupsen/logrus v1.9.3
 	go.etcd.io/bbolt v1.4.3
 	golang.org/x/time v0.5.0
+	google.golang.org/grpc v1.72.2
+	google.golang.org/protobuf v1.36.5
 )
 
-require golang.org/x/sys v0.29.0 // indirect
+require (
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250218202821-56aae31c358a // indirect
+)
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/lyricspb"
+	"lyrics-api-go/middleware"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"lyrics-api-go/stats"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// grpcBatchMaxRequests caps one BatchGetLyrics call
+	grpcBatchMaxRequests = 100
+
+	// grpcBatchConcurrency is how many lookups of a batch run at once
+	grpcBatchConcurrency = 4
+)
+
+// lyricsGRPCServer serves lyricspb.Lyrics through the same lookup and search code
+// as the HTTP handlers, so caching, in-flight deduplication and negative caching
+// behave exactly as they do over HTTP. Callers authenticate with the admin token,
+// so the per-IP rate limits and load shedding (which exempts the token) don't
+// apply; tenants, tenant quotas and API_KEY_REQUIRED do, via x-api-key metadata.
+type lyricsGRPCServer struct {
+	lyricspb.UnimplementedLyricsServer
+}
+
+// startGRPCServer serves the gRPC API on GRPC_PORT. Does nothing when unset.
+func startGRPCServer(port string) {
+	if port == "" {
+		return
+	}
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Errorf("%s gRPC server not started: %v", logcolors.LogServer, err)
+		return
+	}
+	server := newGRPCServer()
+	go func() {
+		log.Infof("%s gRPC listening on port %s", logcolors.LogServer, port)
+		if err := server.Serve(listener); err != nil {
+			log.Errorf("%s gRPC server stopped: %v", logcolors.LogServer, err)
+		}
+	}()
+}
+
+func newGRPCServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := grpcAuthorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := grpcAuthorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	lyricspb.RegisterLyricsServer(server, &lyricsGRPCServer{})
+	return server
+}
+
+// grpcAuthorize requires the admin token in the "authorization" metadata. Like the
+// profiling endpoints, the port stays closed when CACHE_ACCESS_TOKEN is unset.
+func grpcAuthorize(ctx context.Context) error {
+	token := conf().Configuration.CacheAccessToken
+	if token == "" || grpcAuthorization(ctx) != token {
+		return status.Error(codes.Unauthenticated, "Unauthorized")
+	}
+	return nil
+}
+
+func grpcAuthorization(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return grpcMetadata(md, "authorization")
+}
+
+func (s *lyricsGRPCServer) GetLyrics(ctx context.Context, req *lyricspb.LyricsRequest) (*lyricspb.LyricsResponse, error) {
+	return grpcGetLyrics(ctx, req)
+}
+
+func (s *lyricsGRPCServer) BatchGetLyrics(ctx context.Context, req *lyricspb.BatchGetLyricsRequest) (*lyricspb.BatchGetLyricsResponse, error) {
+	if len(req.GetRequests()) > grpcBatchMaxRequests {
+		return nil, status.Errorf(codes.InvalidArgument, "At most %d requests per batch", grpcBatchMaxRequests)
+	}
+
+	results := make([]*lyricspb.BatchLyricsResult, len(req.GetRequests()))
+	slots := make(chan struct{}, grpcBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range req.GetRequests() {
+		wg.Add(1)
+		go func(i int, item *lyricspb.LyricsRequest) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			result := &lyricspb.BatchLyricsResult{Request: item}
+			lyrics, err := grpcGetLyrics(ctx, item)
+			if err != nil {
+				st := status.Convert(err)
+				result.Code = int32(st.Code())
+				result.Error = st.Message()
+			} else {
+				result.Lyrics = lyrics
+			}
+			results[i] = result
+		}(i, item)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	return &lyricspb.BatchGetLyricsResponse{Results: results}, nil
+}
+
+func (s *lyricsGRPCServer) SearchTrack(ctx context.Context, req *lyricspb.SearchTrackRequest) (*lyricspb.SearchTrackResponse, error) {
+	query := strings.TrimSpace(req.GetQuery())
+	if len(tokenizeLyrics(query)) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "q must contain at least one searchable word")
+	}
+	limit := searchDefaultLimit
+	if req.GetLimit() > 0 {
+		limit = min(int(req.GetLimit()), searchMaxLimit)
+	}
+
+	resp := &lyricspb.SearchTrackResponse{}
+	for _, hit := range searchCachedLyrics(query, limit) {
+		resp.Results = append(resp.Results, &lyricspb.TrackMatch{
+			Key:         hit.Key,
+			Song:        hit.Song,
+			Artist:      hit.Artist,
+			PhraseMatch: hit.PhraseMatch,
+			Snippets:    hit.Snippets,
+		})
+	}
+	return resp, nil
+}
+
+func (s *lyricsGRPCServer) StreamLyricsLines(req *lyricspb.LyricsRequest, stream grpc.ServerStreamingServer[lyricspb.LyricsLine]) error {
+	lyrics, err := grpcGetLyrics(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	lines, timingType, err := ttml.ParseLines(lyrics.GetTtml())
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to parse lyrics: %v", err)
+	}
+
+	for _, line := range lines {
+		msg := &lyricspb.LyricsLine{
+			StartMs:    parseMs(line.StartTimeMs),
+			EndMs:      parseMs(line.EndTimeMs),
+			Text:       line.Words,
+			Agent:      line.Agent,
+			Section:    line.Section,
+			Background: line.IsBackground,
+			TimingType: timingType,
+		}
+		for _, syllable := range line.Syllables {
+			msg.Syllables = append(msg.Syllables, &lyricspb.Syllable{
+				Text:       syllable.Text,
+				StartMs:    parseMs(syllable.StartTime),
+				EndMs:      parseMs(syllable.EndTime),
+				Background: syllable.IsBackground,
+			})
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grpcGetLyrics runs one lookup through lookupLyrics, as GET /getLyrics does
+func grpcGetLyrics(ctx context.Context, req *lyricspb.LyricsRequest) (*lyricspb.LyricsResponse, error) {
+	lookup, err := grpcLookup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if lookup.tenant != "" {
+		if quota := tenantQuota(); quota != nil && !quota.GetLimiter(lookup.tenant).Normal.Allow() {
+			log.Warnf("%s Tenant %s exceeded its quota", logcolors.LogRateLimit, lookup.tenant)
+			stats.Get().RecordTenantRequest(lookup.tenant, "", http.StatusTooManyRequests)
+			return nil, status.Error(codes.ResourceExhausted, "Tenant quota exceeded")
+		}
+	}
+
+	outcome := lookupLyrics(ctx, lookup)
+	if lookup.tenant != "" {
+		stats.Get().RecordTenantRequest(lookup.tenant, outcome.cacheStatus, outcome.status)
+	}
+	if outcome.lyrics == nil {
+		return nil, status.Error(grpcCode(outcome.status), grpcMessage(outcome))
+	}
+	resp := &lyricspb.LyricsResponse{
+		Ttml:        outcome.lyrics.TTML,
+		Score:       outcome.lyrics.Score,
+		CacheStatus: outcome.cacheStatus,
+	}
+	// Same stale marker and age as staleLyricsBody
+	if outcome.stale {
+		resp.Stale = true
+		if outcome.lyrics.StoredAt > 0 {
+			resp.AgeSeconds = int64(cacheEntryAge(outcome.lyrics).Seconds())
+		}
+	}
+	return resp, nil
+}
+
+// grpcLookup builds the lookup of a gRPC request, applying what the HTTP middleware
+// applies to /getLyrics: the client version count, the tenant of the x-api-key and
+// API_KEY_REQUIRED's cache-first rule
+func grpcLookup(ctx context.Context, req *lyricspb.LyricsRequest) (lyricsLookup, error) {
+	song, artist := strings.TrimSpace(req.GetSong()), strings.TrimSpace(req.GetArtist())
+	if song == "" && artist == "" {
+		return lyricsLookup{}, status.Error(codes.InvalidArgument, "Song name or artist name not provided")
+	}
+	explicit := ""
+	if req.Explicit != nil {
+		explicit = strconv.FormatBool(req.GetExplicit())
+	}
+	preferExplicit, ratingOverride, err := parseExplicitValue(explicit)
+	if err != nil {
+		return lyricsLookup{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if client, ok := middleware.ParseClientUserAgent(strings.Join(md.Get("user-agent"), " ")); ok {
+		stats.Get().RecordClientVersion(client.Name, client.Version)
+	}
+	apiKey := grpcMetadata(md, "x-api-key")
+	lookup := lyricsLookup{
+		song:           song,
+		artist:         artist,
+		album:          strings.TrimSpace(req.GetAlbum()),
+		preferExplicit: preferExplicit,
+		ratingOverride: ratingOverride,
+		priority:       parsePriority(grpcMetadata(md, "x-request-priority")),
+	}
+	if apiKey != "" {
+		lookup.tenant = apiKeyTenants()[apiKey]
+	}
+	if req.GetDurationSecs() > 0 {
+		lookup.duration = strconv.Itoa(int(req.GetDurationSecs()))
+	}
+	if conf().Configuration.APIKeyRequired && !isValidAPIKey(apiKey) {
+		lookup.apiKeyRequired = true
+		lookup.apiKeyInvalid = apiKey != ""
+	}
+	return lookup, nil
+}
+
+// grpcMetadata returns the first value of a metadata key, or ""
+func grpcMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcCode maps the HTTP status of a lookup outcome to a gRPC code
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusAccepted, http.StatusServiceUnavailable:
+		// Still in flight or held back: retry later
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// grpcMessage is the error text of a lookup outcome, with Retry-After appended for
+// retryable answers
+func grpcMessage(outcome lyricsOutcome) string {
+	message := cmp.Or(outcome.errorMessage(), http.StatusText(outcome.status))
+	if outcome.retryAfter > 0 {
+		message += fmt.Sprintf(" (retry after %ds)", outcome.retryAfter)
+	}
+	return message
+}
+
+// parseMs converts the string milliseconds of parsed lines
+func parseMs(ms string) int64 {
+	v, _ := strconv.ParseInt(ms, 10, 64)
+	return v
+}
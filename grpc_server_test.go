@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"lyrics-api-go/internal/clocktest"
+	"lyrics-api-go/lyricspb"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startTestGRPCServer serves the gRPC API over an in-memory listener
+func startTestGRPCServer(t *testing.T) lyricspb.LyricsClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := newGRPCServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return lyricspb.NewLyricsClient(conn)
+}
+
+func TestGRPCServer(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+	// Uncached lookups answer 503 instead of going upstream
+	originalCacheOnly := conf().FeatureFlags.CacheOnlyMode
+	conf().FeatureFlags.CacheOnlyMode = true
+	defer func() { conf().FeatureFlags.CacheOnlyMode = originalCacheOnly }()
+
+	setCachedLyrics(buildNormalizedCacheKey("song", "artist", "", ""), formatTestTTML, 0, 0.9, "", false)
+
+	client := startTestGRPCServer(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "test-token")
+	hello := &lyricspb.LyricsRequest{Song: "song", Artist: "artist"}
+
+	if _, err := client.GetLyrics(context.Background(), hello); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a token, got %v", err)
+	}
+
+	lyrics, err := client.GetLyrics(ctx, hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lyrics.GetTtml() != formatTestTTML || lyrics.GetCacheStatus() != "HIT" {
+		t.Errorf("Expected the cached TTML as a HIT, got %q (%s)", lyrics.GetTtml(), lyrics.GetCacheStatus())
+	}
+
+	batch, err := client.BatchGetLyrics(ctx, &lyricspb.BatchGetLyricsRequest{Requests: []*lyricspb.LyricsRequest{
+		hello,
+		{Song: "missing", Artist: "artist"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := batch.GetResults()
+	if len(results) != 2 || results[0].GetLyrics() == nil || results[1].GetCode() != int32(codes.Unavailable) {
+		t.Errorf("Expected one hit and one Unavailable, got %v", results)
+	}
+
+	search, err := client.SearchTrack(ctx, &lyricspb.SearchTrackRequest{Query: "first line"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(search.GetResults()) != 1 || search.GetResults()[0].GetKey() != "ttml_lyrics:song artist" {
+		t.Errorf("Expected the cached track from SearchTrack, got %v", search.GetResults())
+	}
+	if _, err := client.SearchTrack(ctx, &lyricspb.SearchTrackRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an empty query, got %v", err)
+	}
+
+	stream, err := client.StreamLyricsLines(ctx, hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []*lyricspb.LyricsLine
+	for {
+		line, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 || lines[0].GetText() == "" {
+		t.Errorf("Expected streamed lines, got %v", lines)
+	}
+}
+
+func TestGRPCServer_StaleLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	initRecentAttemptsBucket()
+
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	origClk := clk
+	clk = fake
+	defer func() { clk = origClk }()
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+	originalTTL := conf().Configuration.RecentAttemptTTLSecs
+	conf().Configuration.RecentAttemptTTLSecs = 30
+	defer func() { conf().Configuration.RecentAttemptTTLSecs = originalTTL }()
+
+	// A failed attempt for the album key serves the entry without the album as STALE
+	setCachedLyrics(buildNormalizedCacheKey("song", "artist", "", ""), testTTML, 0, 0, "", false)
+	markAttempt(buildNormalizedCacheKey("song", "artist", "album", ""), "circuit breaker is open")
+	fake.Advance(5 * time.Second)
+
+	client := startTestGRPCServer(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "test-token")
+	lyrics, err := client.GetLyrics(ctx, &lyricspb.LyricsRequest{Song: "song", Artist: "artist", Album: "album"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lyrics.GetCacheStatus() != "STALE" || !lyrics.GetStale() || lyrics.GetAgeSeconds() != 5 {
+		t.Errorf("Expected a STALE response with stale and age_seconds 5, got %v", lyrics)
+	}
+}
+
+func TestGRPCCode(t *testing.T) {
+	tests := map[int]codes.Code{
+		200: codes.OK,
+		202: codes.Unavailable,
+		400: codes.InvalidArgument,
+		404: codes.NotFound,
+		429: codes.ResourceExhausted,
+		503: codes.Unavailable,
+		500: codes.Internal,
+	}
+	for httpStatus, want := range tests {
+		if got := grpcCode(httpStatus); got != want {
+			t.Errorf("grpcCode(%d) = %v, want %v", httpStatus, got, want)
+		}
+	}
+}
+
+func TestGRPCServer_TenantsAndAPIKeys(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	withTenants(t, "ext-key:extension", 0)
+	conf().Configuration.CacheAccessToken = "test-token"
+	conf().Configuration.APIKey = "shared-key"
+	conf().Configuration.APIKeyRequired = true
+	originalCacheOnly := conf().FeatureFlags.CacheOnlyMode
+	conf().FeatureFlags.CacheOnlyMode = true
+	defer func() { conf().FeatureFlags.CacheOnlyMode = originalCacheOnly }()
+
+	setCachedLyrics(tenantCacheKey(buildNormalizedCacheKey("own", "artist", "", ""), "extension"), formatTestTTML, 0, 0.9, "", false)
+
+	client := startTestGRPCServer(t)
+	withKey := func(apiKey string) context.Context {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "test-token")
+		if apiKey != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", apiKey)
+		}
+		return ctx
+	}
+	own := &lyricspb.LyricsRequest{Song: "own", Artist: "artist"}
+
+	if lyrics, err := client.GetLyrics(withKey("ext-key"), own); err != nil || lyrics.GetCacheStatus() != "HIT" {
+		t.Errorf("Expected the tenant's own entry as a HIT, got %v, %v", lyrics, err)
+	}
+	// Without the tenant key the lookup misses the tenant namespace; without any
+	// valid key API_KEY_REQUIRED refuses the miss
+	if _, err := client.GetLyrics(withKey("shared-key"), own); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable for the shared namespace, got %v", err)
+	}
+	if _, err := client.GetLyrics(withKey(""), own); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without an API key, got %v", err)
+	}
+	if _, err := client.GetLyrics(withKey("nope"), own); status.Code(err) != codes.Unauthenticated || status.Convert(err).Message() != "Invalid API key" {
+		t.Errorf("Expected Unauthenticated for an invalid API key, got %v", err)
+	}
+	if _, err := client.GetLyrics(withKey("ext-key"), &lyricspb.LyricsRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a song or artist, got %v", err)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"lyrics-api-go/cache"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/logging"
+	"lyrics-api-go/lyricsquery"
 	"lyrics-api-go/services/bini"
 	"lyrics-api-go/services/notifier"
 	"lyrics-api-go/services/providers"
@@ -28,266 +31,205 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func getLyrics(w http.ResponseWriter, r *http.Request) {
-	songName := r.URL.Query().Get("s") + r.URL.Query().Get("song") + r.URL.Query().Get("songName")
-	artistName := r.URL.Query().Get("a") + r.URL.Query().Get("artist") + r.URL.Query().Get("artistName")
-	albumName := r.URL.Query().Get("al") + r.URL.Query().Get("album") + r.URL.Query().Get("albumName")
-	durationStr := r.URL.Query().Get("d") + r.URL.Query().Get("duration")
-	videoID := r.URL.Query().Get("videoId") + r.URL.Query().Get("v")
+// lyricsLog throttles the per-request cache hit/miss lines of the lyrics handlers
+// (LOG_THROTTLE, component "lyrics")
+var lyricsLog = logging.Throttled(logging.ComponentLyrics)
 
-	if songName == "" && artistName == "" {
-		http.Error(w, "Song name or artist name not provided", http.StatusUnprocessableEntity)
+func getLyrics(w http.ResponseWriter, r *http.Request) {
+	r, timing := withServerTiming(r)
+	r = withResponseWarnings(r)
+	q, err := lyricsquery.Parse(r.URL.Query())
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
 		return
 	}
+	songName, artistName, albumName, durationStr, videoID := q.Song, q.Artist, q.Album, q.Duration, q.VideoID
 
-	// Use normalized cache key for consistent cache hits regardless of input casing/whitespace
-	cacheKey := buildNormalizedCacheKey(songName, artistName, albumName, durationStr)
-
-	// For logging, use a clean query string
-	query := strings.ToLower(strings.TrimSpace(songName)) + " " + strings.ToLower(strings.TrimSpace(artistName))
-
-	// Check if we're in cache-only mode (rate limit tier 2)
-	cacheOnlyMode, _ := r.Context().Value(cacheOnlyModeKey).(bool)
-
-	// Check if API key was required but not provided (cache-first mode)
-	apiKeyRequired, _ := r.Context().Value(apiKeyRequiredForFreshKey).(bool)
-	apiKeyInvalid, _ := r.Context().Value(apiKeyInvalidKey).(bool)
-
-	// Check cache first with fuzzy duration matching (handles normalized + legacy keys)
-	// This allows cache hits when duration differs by up to DURATION_MATCH_DELTA_MS (default 2s)
-	if cached, foundKey, ok := getCachedLyricsWithDurationTolerance(songName, artistName, albumName, durationStr); ok {
-		// Check for no-lyrics sentinel — return 404 as if no lyrics exist
-		if cached.TTML == NoLyricsSentinel {
-			stats.Get().RecordCacheHit()
-			log.Infof("%s No-lyrics marker found for: %s", logcolors.LogCacheLyrics, query)
-			Respond(w, r).SetCacheStatus("HIT").Error(http.StatusNotFound, map[string]interface{}{
-				"error": "No lyrics available for this track",
-			})
-			return
-		}
-		stats.Get().RecordCacheHit()
-		if foundKey != cacheKey {
-			log.Infof("%s Found cached TTML via fuzzy duration match: %s", logcolors.LogCacheLyrics, foundKey)
-		} else {
-			log.Infof("%s Found cached TTML", logcolors.LogCacheLyrics)
-		}
-		// Associate videoId on cache hits too
-		if videoID != "" {
-			go addVideoID(foundKey, videoID)
-		}
-		Respond(w, r).SetCacheStatus("HIT").JSON(map[string]interface{}{
-			"ttml": cached.TTML,
-		})
+	if !q.HasTrack() && videoID == "" {
+		http.Error(w, "Song name or artist name not provided", http.StatusUnprocessableEntity)
 		return
 	}
 
-	// Check negative cache with fuzzy duration matching
-	if reason, _, found := getNegativeCacheWithDurationTolerance(songName, artistName, albumName, durationStr); found {
-		stats.Get().RecordNegativeCacheHit()
-		log.Infof("%s Returning cached 'no lyrics' response for: %s", logcolors.LogCacheNegative, query)
-		Respond(w, r).SetCacheStatus("NEGATIVE_HIT").Error(http.StatusNotFound, map[string]interface{}{
-			"error": reason,
+	format, err := parseLyricsFormat(r)
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
 		})
 		return
 	}
-
-	// If API key is required for fresh fetch but not provided/invalid, return 401
-	// This allows cache hits to be served without API key
-	if apiKeyRequired {
-		stats.Get().RecordCacheMiss()
-		if apiKeyInvalid {
-			log.Warnf("%s Invalid API key for uncached query: %s", logcolors.LogAPIKey, query)
-			Respond(w, r).SetCacheStatus("MISS").Error(http.StatusUnauthorized, map[string]interface{}{
-				"error":   "Invalid API key",
-				"message": "The provided API key is not valid",
-			})
-		} else {
-			log.Warnf("%s API key required for uncached query: %s", logcolors.LogAPIKey, query)
-			Respond(w, r).SetCacheStatus("MISS").Error(http.StatusUnauthorized, map[string]interface{}{
-				"error":   "API key required",
-				"message": "Uncached queries require a valid API key via X-API-Key header",
-			})
-		}
+	if _, err := parseResponseClient(r); err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
 		return
 	}
-
-	// If in cache-only mode (rate limit tier 2) and no cache found, return 429
-	if cacheOnlyMode {
-		stats.Get().RecordCacheMiss()
-		stats.Get().RecordRateLimit("exceeded")
-		log.Warnf("%s Cache-only mode but no cache found for: %s", logcolors.LogCacheLyrics, query)
-		w.Header().Set("Retry-After", "60")
-		Respond(w, r).SetCacheStatus("MISS").Error(http.StatusTooManyRequests, map[string]interface{}{
-			"error":   "Rate limit exceeded. This request requires cached data, but no cache is available for this query.",
-			"message": "Please try again later or reduce your request rate.",
+	if _, err := parseFieldFilter(r); err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
 		})
 		return
 	}
-
-	// If FF_CACHE_ONLY_MODE is enabled and no cache found, return 503
-	if conf.FeatureFlags.CacheOnlyMode {
-		stats.Get().RecordCacheMiss()
-		log.Warnf("%s FF_CACHE_ONLY_MODE enabled, no cache for: %s", logcolors.LogCacheLyrics, query)
-		Respond(w, r).SetCacheStatus("MISS").Error(http.StatusServiceUnavailable, map[string]interface{}{
-			"error": "Service running in cache-only mode. No cached lyrics available for this query.",
+	if _, err := parseCleanParam(r); err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
 		})
 		return
 	}
-
-	inFlight, loaded := inFlightReqs.LoadOrStore(cacheKey, &InFlightRequest{})
-	req := inFlight.(*InFlightRequest)
-
-	if loaded {
-		log.Infof("%s Waiting for in-flight request to complete", logcolors.LogCacheLyrics)
-		req.wg.Wait()
-
-		if req.err != nil {
-			Respond(w, r).SetCacheStatus("MISS").Error(http.StatusInternalServerError, map[string]interface{}{
-				"error": req.err.Error(),
-			})
-			return
+	bypass, err := parseCacheBypass(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errCacheBypassUnauthorized) {
+			status = http.StatusUnauthorized
 		}
-
-		Respond(w, r).SetCacheStatus("HIT").JSON(map[string]interface{}{
-			"ttml":  req.result,
-			"score": req.score,
+		Respond(w, r).Error(status, map[string]interface{}{
+			"error": err.Error(),
 		})
 		return
 	}
-
-	req.wg.Add(1)
-	defer func() {
-		req.wg.Done()
-		time.AfterFunc(1*time.Second, func() {
-			inFlightReqs.Delete(cacheKey)
-		})
-	}()
-
-	// Parse duration from seconds to milliseconds
-	var durationMs int
-	if durationStr != "" {
-		fmt.Sscanf(durationStr, "%d", &durationMs)
-		durationMs = durationMs * 1000 // Convert seconds to milliseconds
-	}
-
-	ttmlString, trackDurationMs, score, trackMeta, err := ttml.FetchTTMLLyrics(songName, artistName, albumName, durationMs)
-
-	req.err = err
-	if err == nil {
-		req.result = ttmlString
-		req.score = score
+	if bypass.active() {
+		var audit func()
+		w, audit = auditCacheBypass(w, r)
+		defer audit()
 	}
 
+	// explicit= differing from PREFER_EXPLICIT gets its own cache key (exact match only)
+	preferExplicit, ratingOverride, err := parseExplicitParam(r)
 	if err != nil {
-		log.Errorf("%s Error fetching TTML: %v", logcolors.LogLyrics, err)
-
-		// Try fallback cache keys before returning error
-		fallbackKeys := buildFallbackCacheKeys(songName, artistName, albumName, durationStr, cacheKey)
-		for _, fallbackKey := range fallbackKeys {
-			if cached, ok := getCachedLyrics(fallbackKey); ok {
-				stats.Get().RecordStaleCacheHit()
-				log.Warnf("%s Backend failed, serving stale cache from key: %s", logcolors.LogCacheLyrics, fallbackKey)
-				Respond(w, r).SetCacheStatus("STALE").JSON(map[string]interface{}{
-					"ttml": cached.TTML,
-				})
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	cacheOnlyMode, _ := r.Context().Value(cacheOnlyModeKey).(bool)
+	apiKeyRequired, _ := r.Context().Value(apiKeyRequiredForFreshKey).(bool)
+	apiKeyInvalid, _ := r.Context().Value(apiKeyInvalidKey).(bool)
+	lookup := lyricsLookup{
+		song:           songName,
+		artist:         artistName,
+		album:          albumName,
+		duration:       durationStr,
+		videoID:        videoID,
+		preferExplicit: preferExplicit,
+		ratingOverride: ratingOverride,
+		tenant:         requestTenant(r),
+		bypass:         bypass,
+		priority:       requestPriority(r),
+		suggest:        r.URL.Query().Get("suggest") == "true",
+		apiKeyRequired: apiKeyRequired,
+		apiKeyInvalid:  apiKeyInvalid,
+		cacheOnly:      cacheOnlyMode,
+		shedding:       requestShedding(r),
+		hints:          matchHintsFrom(r),
+		capture:        lookupCaptureFrom(r),
+		timing:         timing,
+		warnings:       requestWarnings(r),
+	}
+	exactKey := lookup.exactKey()
+
+	// A video resolved before goes straight to the entry it resolved to. Aliases
+	// point at the default release, so explicit= overrides match by name instead.
+	if videoID != "" && exactKey == "" && !bypass.skipCache {
+		aliasStart := time.Now()
+		cached, aliasKey, ok := lookupVideoAlias(videoID)
+		timing.since("cache", aliasStart)
+		if ok && cached.TTML != NoLyricsSentinel {
+			stats.Get().RecordCacheHit()
+			if r.Method == http.MethodHead {
+				Respond(w, r).SetCacheStatus("HIT").Head(http.StatusOK, formatContentType(format))
 				return
 			}
+			lyricsLog.Infof("cache_hit_video", "%s Found cached TTML via video alias %s: %s", logcolors.LogCacheLyrics, videoID, aliasKey)
+			respondCachedTTML(Respond(w, r).SetCacheStatus("HIT"), format, aliasKey, cached.TTML, cachedLyricsBody(r, aliasKey, cached, durationStr))
+			return
 		}
+	}
+	if songName == "" && artistName == "" {
+		http.Error(w, "Song name or artist name not provided (no lyrics known for this videoId yet)", http.StatusUnprocessableEntity)
+		return
+	}
 
-		// Cache permanent "no lyrics" errors to avoid repeated API calls
-		isPermanentError := shouldNegativeCache(err)
-		if isPermanentError {
-			releaseDate := ""
-			hasTimeSyncedLyricsKnown := false
-			if trackMeta != nil {
-				releaseDate = trackMeta.ReleaseDate
-				hasTimeSyncedLyricsKnown = trackMeta.HasTimeSyncedLyrics != nil
-			}
-			setNegativeCache(cacheKey, err.Error(), releaseDate, hasTimeSyncedLyricsKnown)
-		}
-
-		// No fallback found (or skipped due to duration), return the error
-		stats.Get().RecordCacheMiss()
-		// Return 404 for permanent "not found" errors, 500 for transient errors
-		if isPermanentError {
-			Respond(w, r).SetCacheStatus("MISS").Error(http.StatusNotFound, map[string]interface{}{
-				"error": err.Error(),
-			})
-		} else {
-			Respond(w, r).SetCacheStatus("MISS").Error(http.StatusInternalServerError, map[string]interface{}{
-				"error": err.Error(),
-			})
-		}
+	if r.Method == http.MethodHead {
+		headLyrics(w, r, format, songName, artistName, albumName, durationStr, exactKey)
 		return
 	}
 
-	if ttmlString == "" {
-		stats.Get().RecordCacheMiss()
-		log.Warnf("No TTML found for: %s", query)
-		// Cache this negative result to avoid repeated API calls
-		releaseDate := ""
-		hasTimeSyncedLyricsKnown := false
-		if trackMeta != nil {
-			releaseDate = trackMeta.ReleaseDate
-			hasTimeSyncedLyricsKnown = trackMeta.HasTimeSyncedLyrics != nil
-		}
-		setNegativeCache(cacheKey, "Lyrics not available for this track", releaseDate, hasTimeSyncedLyricsKnown)
-		Respond(w, r).SetCacheStatus("MISS").Error(http.StatusNotFound, map[string]interface{}{
-			"error": "Lyrics not available for this track",
-		})
+	// Experimental scoring weights (admin only) - never touches the cache
+	if weightsParam := r.URL.Query().Get("weights"); weightsParam != "" {
+		getLyricsWithWeights(w, r, format, weightsParam, songName, artistName, albumName, durationStr)
 		return
 	}
 
-	stats.Get().RecordCacheMiss()
-	log.Infof("%s Caching TTML for: %s (trackDuration: %dms)", logcolors.LogCacheLyrics, query, trackDurationMs)
-	language, isRTL := ttml.DetectLanguage(ttmlString)
-	setCachedLyrics(cacheKey, ttmlString, trackDurationMs, score, language, isRTL)
+	respondLyrics(w, r, format, lookup, lookupLyrics(r.Context(), lookup))
+}
 
-	go bini.PostLyrics(trackMeta.Name, trackMeta.ArtistName, trackMeta.AlbumName, trackDurationMs, ttmlString, trackMeta.ISRC)
+// respondLyrics writes the outcome of a /getLyrics lookup in the requested format
+func respondLyrics(w http.ResponseWriter, r *http.Request, format string, lookup lyricsLookup, outcome lyricsOutcome) {
+	if outcome.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(outcome.retryAfter))
+	}
+	if outcome.status == http.StatusAccepted {
+		w.Header().Set("X-Inflight", "true")
+	}
+	resp := Respond(w, r).SetCacheStatus(outcome.cacheStatus)
+	switch {
+	case outcome.lyrics == nil:
+		resp.Error(outcome.status, outcome.body)
+	case outcome.stale:
+		respondTTML(resp, format, outcome.lyrics.TTML, staleLyricsBody(r, outcome.cacheKey, outcome.lyrics, lookup.duration))
+	case outcome.cacheKey != "":
+		respondCachedTTML(resp, format, outcome.cacheKey, outcome.lyrics.TTML, cachedLyricsBody(r, outcome.cacheKey, outcome.lyrics, lookup.duration))
+	default:
+		respondTTML(resp, format, outcome.lyrics.TTML, withMatchedTrack(lyricsBody(outcome.lyrics), outcome.trackID, outcome.trackName))
+	}
+}
 
-	// Store metadata and videoId first, then trigger proxy revalidation (which queries metadata).
-	// All writes happen in the same goroutine before revalidation to avoid race conditions.
-	if trackMeta != nil {
-		go func() {
-			meta := &SongMetadata{
-				CacheKey:      cacheKey,
-				AppleTrackID:  trackMeta.TrackID,
-				ISRC:          trackMeta.ISRC,
-				TrackName:     trackMeta.Name,
-				ArtistName:    trackMeta.ArtistName,
-				AlbumName:     trackMeta.AlbumName,
-				DurationMs:    trackDurationMs,
-				ReleaseDate:   trackMeta.ReleaseDate,
-				RawAttributes: trackMeta.RawAttributes,
-			}
-			if videoID != "" {
-				meta.VideoIDs = []string{videoID}
-			}
-			setSongMetadata(meta)
-			proxy.RevalidateAllForSong(trackMeta.Name, trackMeta.ArtistName, trackMeta.AlbumName, trackDurationMs/1000, getAllVideoIDsForSong)
-		}()
-	} else if videoID != "" {
-		go addVideoID(cacheKey, videoID)
+// headLyrics answers HEAD /getLyrics from the cache alone: the status and headers a
+// GET would get, with no body and never an upstream fetch. A miss is 200 with
+// X-Cache-Status: MISS, since a GET may still find lyrics.
+func headLyrics(w http.ResponseWriter, r *http.Request, format, songName, artistName, albumName, durationStr, exactKey string) {
+	cached, _, ok := lookupCachedLyrics(songName, artistName, albumName, durationStr, exactKey)
+	if ok {
+		if cached.TTML == NoLyricsSentinel {
+			Respond(w, r).SetCacheStatus("HIT").Head(http.StatusNotFound, "application/json")
+			return
+		}
+		Respond(w, r).SetCacheStatus("HIT").Head(http.StatusOK, formatContentType(format))
+		return
 	}
 
-	Respond(w, r).SetCacheStatus("MISS").JSON(map[string]interface{}{
-		"ttml":  ttmlString,
-		"score": score,
-	})
+	if _, found := lookupNegativeCache(songName, artistName, albumName, durationStr, exactKey); found {
+		Respond(w, r).SetCacheStatus("NEGATIVE_HIT").Head(http.StatusNotFound, "application/json")
+		return
+	}
+
+	Respond(w, r).SetCacheStatus("MISS").Head(http.StatusOK, formatContentType(format))
 }
 
 // getLyricsWithProvider returns a handler for a specific provider
 func getLyricsWithProvider(providerName string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		songName := r.URL.Query().Get("s") + r.URL.Query().Get("song") + r.URL.Query().Get("songName")
-		artistName := r.URL.Query().Get("a") + r.URL.Query().Get("artist") + r.URL.Query().Get("artistName")
-		albumName := r.URL.Query().Get("al") + r.URL.Query().Get("album") + r.URL.Query().Get("albumName")
-		durationStr := r.URL.Query().Get("d") + r.URL.Query().Get("duration")
+		r = withResponseWarnings(r)
+		q, err := lyricsquery.Parse(r.URL.Query())
+		if err != nil {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+		songName, artistName, albumName, durationStr := q.Song, q.Artist, q.Album, q.Duration
 
-		if songName == "" && artistName == "" {
+		if !q.HasTrack() {
 			http.Error(w, "Song name or artist name not provided", http.StatusUnprocessableEntity)
 			return
 		}
+		if _, err := parseCleanParam(r); err != nil {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
 
 		// Get the provider
 		provider, err := providers.Get(providerName)
@@ -302,6 +244,9 @@ func getLyricsWithProvider(providerName string) http.HandlerFunc {
 
 		// Build cache key with provider prefix
 		cacheKey := buildProviderCacheKey(provider.CacheKeyPrefix(), songName, artistName, albumName, durationStr)
+		if tenant := requestTenant(r); tenant != "" {
+			cacheKey = tenantCacheKey(cacheKey, tenant)
+		}
 		query := strings.ToLower(strings.TrimSpace(songName)) + " " + strings.ToLower(strings.TrimSpace(artistName))
 
 		// Check rate limit context
@@ -314,16 +259,16 @@ func getLyricsWithProvider(providerName string) http.HandlerFunc {
 			// Check for no-lyrics sentinel — return 404 as if no lyrics exist
 			if cached.TTML == NoLyricsSentinel {
 				stats.Get().RecordCacheHit()
-				log.Infof("%s [%s] No-lyrics marker found", logcolors.LogCacheLyrics, providerName)
+				lyricsLog.Infof("provider_cache_hit_sentinel", "%s [%s] No-lyrics marker found", logcolors.LogCacheLyrics, providerName)
 				Respond(w, r).SetProvider(providerName).SetCacheStatus("HIT").Error(http.StatusNotFound, map[string]interface{}{
 					"error": "No lyrics available for this track",
 				})
 				return
 			}
 			stats.Get().RecordCacheHit()
-			log.Infof("%s [%s] Found cached lyrics", logcolors.LogCacheLyrics, providerName)
+			lyricsLog.Infof("provider_cache_hit", "%s [%s] Found cached lyrics", logcolors.LogCacheLyrics, providerName)
 			Respond(w, r).SetProvider(providerName).SetCacheStatus("HIT").JSON(map[string]interface{}{
-				"lyrics":   cached.TTML,
+				"lyrics":   postProcessRawLyrics(r, cached.TTML),
 				"provider": providerName,
 			})
 			return
@@ -332,7 +277,7 @@ func getLyricsWithProvider(providerName string) http.HandlerFunc {
 		// Check negative cache (uses same key format as positive cache, getNegativeCache adds "no_lyrics:" prefix)
 		if reason, found := getNegativeCache(cacheKey); found {
 			stats.Get().RecordNegativeCacheHit()
-			log.Infof("%s [%s] Returning cached 'no lyrics' response", logcolors.LogCacheNegative, providerName)
+			lyricsLog.Infof("provider_negative_hit", "%s [%s] Returning cached 'no lyrics' response", logcolors.LogCacheNegative, providerName)
 			Respond(w, r).SetProvider(providerName).SetCacheStatus("NEGATIVE_HIT").Error(http.StatusNotFound, map[string]interface{}{
 				"error":    reason,
 				"provider": providerName,
@@ -366,16 +311,21 @@ func getLyricsWithProvider(providerName string) http.HandlerFunc {
 			stats.Get().RecordCacheMiss()
 			stats.Get().RecordRateLimit("exceeded")
 			log.Warnf("%s [%s] Cache-only mode but no cache found for: %s", logcolors.LogCacheLyrics, providerName, query)
-			w.Header().Set("Retry-After", "60")
-			Respond(w, r).SetProvider(providerName).SetCacheStatus("MISS").Error(http.StatusTooManyRequests, map[string]interface{}{
+			Respond(w, r).SetProvider(providerName).SetCacheStatus("MISS").RateLimited("normal", 60, map[string]interface{}{
 				"error":    "Rate limit exceeded. No cached data available.",
 				"provider": providerName,
 			})
 			return
 		}
 
+		// Shedding load: unauthenticated clients only get cached lyrics
+		if respondShedding(w, r, providerName) {
+			stats.Get().RecordCacheMiss()
+			return
+		}
+
 		// If FF_CACHE_ONLY_MODE is enabled and no cache found, return 503
-		if conf.FeatureFlags.CacheOnlyMode {
+		if conf().FeatureFlags.CacheOnlyMode {
 			stats.Get().RecordCacheMiss()
 			log.Warnf("%s [%s] FF_CACHE_ONLY_MODE enabled, no cache for: %s", logcolors.LogCacheLyrics, providerName, query)
 			Respond(w, r).SetProvider(providerName).SetCacheStatus("MISS").Error(http.StatusServiceUnavailable, map[string]interface{}{
@@ -385,14 +335,31 @@ func getLyricsWithProvider(providerName string) http.HandlerFunc {
 			return
 		}
 
+		release, ok := acquireUpstreamSlot(w, r, providerName)
+		if !ok {
+			return
+		}
+		defer release()
+
 		// In-flight request deduplication
-		inFlight, loaded := inFlightReqs.LoadOrStore(cacheKey, &InFlightRequest{})
+		inFlight, loaded := inFlightReqs.LoadOrStore(cacheKey, newInFlightRequest())
 		req := inFlight.(*InFlightRequest)
 
 		if loaded {
-			log.Infof("%s [%s] Waiting for in-flight request", logcolors.LogCacheLyrics, providerName)
-			req.wg.Wait()
+			lyricsLog.Infof("provider_in_flight_wait", "%s [%s] Waiting for in-flight request", logcolors.LogCacheLyrics, providerName)
+			if !req.wait(inFlightWaitTimeout()) {
+				stats.Get().RecordCacheMiss()
+				respondInFlightPending(Respond(w, r).SetProvider(providerName), w, map[string]interface{}{
+					"provider": providerName,
+				})
+				return
+			}
 
+			if errors.Is(req.err, errUpstreamBusy) {
+				stats.Get().RecordCacheMiss()
+				respondUpstreamBusy(w, r, providerName, errUpstreamBusy, getUpstreamLimiter().retryAfterSecs())
+				return
+			}
 			if req.err != nil {
 				Respond(w, r).SetProvider(providerName).SetCacheStatus("MISS").Error(http.StatusInternalServerError, map[string]interface{}{
 					"error":    req.err.Error(),
@@ -402,20 +369,27 @@ func getLyricsWithProvider(providerName string) http.HandlerFunc {
 			}
 
 			Respond(w, r).SetProvider(providerName).SetCacheStatus("HIT").JSON(map[string]interface{}{
-				"lyrics":   req.result,
+				"lyrics":   postProcessRawLyrics(r, req.result),
 				"provider": providerName,
 			})
 			return
 		}
 
-		req.wg.Add(1)
 		defer func() {
-			req.wg.Done()
-			time.AfterFunc(1*time.Second, func() {
+			req.finish()
+			time.AfterFunc(inFlightLinger(), func() {
 				inFlightReqs.Delete(cacheKey)
 			})
 		}()
 
+		releaseUpstream, ok := acquireGlobalUpstreamSlot(w, r, providerName)
+		if !ok {
+			stats.Get().RecordCacheMiss()
+			req.err = errUpstreamBusy
+			return
+		}
+		defer releaseUpstream()
+
 		// Parse duration
 		var durationMs int
 		if durationStr != "" {
@@ -478,7 +452,7 @@ func getLyricsWithProvider(providerName string) http.HandlerFunc {
 		setCachedLyrics(cacheKey, result.RawLyrics, result.TrackDurationMs, result.Score, result.Language, result.IsRTL)
 
 		Respond(w, r).SetProvider(providerName).SetCacheStatus("MISS").JSON(map[string]interface{}{
-			"lyrics":   result.RawLyrics,
+			"lyrics":   postProcessRawLyrics(r, result.RawLyrics),
 			"provider": providerName,
 		})
 	}
@@ -497,7 +471,7 @@ func buildProviderCacheKey(prefix, song, artist, album, duration string) string
 }
 
 func getStats(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -514,7 +488,7 @@ func getStats(w http.ResponseWriter, r *http.Request) {
 		total += n
 	}
 	sizeKB := persistentCache.SizeKB()
-	snapshot["cache_storage"] = map[string]interface{}{
+	storage := map[string]interface{}{
 		"keys_total":         total,
 		"keys_by_provider":   counts,
 		"size_kb":            sizeKB,
@@ -523,6 +497,27 @@ func getStats(w http.ResponseWriter, r *http.Request) {
 		"last_reconciled_at": cs.LastReconciledAt,
 		"last_duration_ms":   cs.LastDurationMs,
 	}
+	// Keys and stored bytes per namespace and provider, as of the last reconcile
+	if breakdown := persistentCache.Breakdown(); breakdown != nil {
+		storage["by_namespace"] = breakdown.ByNamespace
+		storage["by_provider"] = breakdown.ByProvider
+		storage["breakdown_computed_at"] = breakdown.ComputedAt
+	}
+	snapshot["cache_storage"] = storage
+
+	snapshot["priority_lanes"] = getPriorityLane().stats()
+	// Rate limit tiers as configured and as in effect (PATCH /config/rate-limits)
+	if rateLimiting, ok := snapshot["rate_limiting"].(map[string]interface{}); ok {
+		for k, v := range rateLimitsStatus() {
+			rateLimiting[k] = v
+		}
+	}
+	snapshot["upstream_limit"] = getUpstreamLimiter().stats()
+	snapshot["pressure"] = pressure.stats()
+	if tenants := s.TenantUsage(); len(tenants) > 0 {
+		snapshot["tenants"] = tenants
+	}
+	snapshot["bandwidth"] = s.BandwidthUsage()
 
 	// Add circuit breaker status
 	cbState, failures, cooldownRemaining := ttml.GetCircuitBreakerStats()
@@ -532,6 +527,12 @@ func getStats(w http.ResponseWriter, r *http.Request) {
 		"cooldown_remaining": cooldownRemaining.String(),
 	}
 
+	// Search result cache (upstream searches saved by duration/album variants)
+	snapshot["search_cache"] = ttml.GetSearchCacheStats()
+
+	// Requests by client and version, from the User-Agent
+	snapshot["client_versions"] = s.ClientVersions()
+
 	// Include user agent stats if requested via ?by=user_agent
 	if r.URL.Query().Get("by") == "user_agent" {
 		snapshot["user_agents"] = s.UserAgentSnapshot()
@@ -560,7 +561,7 @@ func getCacheDump(w http.ResponseWriter, r *http.Request) {
 }
 
 func backupCache(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -586,7 +587,7 @@ func backupCache(w http.ResponseWriter, r *http.Request) {
 }
 
 func clearCache(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -613,7 +614,7 @@ func clearCache(w http.ResponseWriter, r *http.Request) {
 }
 
 func clearProviderCache(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -621,6 +622,25 @@ func clearProviderCache(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	providerName := vars["provider"]
 
+	// Negative entries have their own bucket, dropped in one transaction
+	if providerName == "negative" {
+		removed, err := persistentCache.ClearNegative()
+		if err != nil {
+			log.Errorf("%s Failed to clear negative cache: %v", logcolors.LogCacheClear, err)
+			Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+		log.Infof("%s Cleared %d negative cache entries", logcolors.LogCacheClear, removed)
+		Respond(w, r).JSON(map[string]interface{}{
+			"message":      "Cleared negative cache",
+			"provider":     providerName,
+			"keys_deleted": removed,
+		})
+		return
+	}
+
 	// Map provider name to cache prefix
 	prefixMap := map[string]string{
 		"ttml":   "ttml_lyrics:",
@@ -634,7 +654,7 @@ func clearProviderCache(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"error":           fmt.Sprintf("Unknown provider: %s", providerName),
-			"valid_providers": []string{"ttml", "kugou", "legacy"},
+			"valid_providers": []string{"ttml", "kugou", "legacy", "negative"},
 		})
 		return
 	}
@@ -646,12 +666,15 @@ func clearProviderCache(w http.ResponseWriter, r *http.Request) {
 	persistentCache.Range(func(key string, entry cache.CacheEntry) bool {
 		if strings.HasPrefix(key, prefix) || strings.HasPrefix(key, "no_lyrics:"+prefix) {
 			keysToDelete = append(keysToDelete, key)
+		} else if providerName == "ttml" && strings.HasPrefix(key, trackLyricsPrefix) {
+			// Track blobs behind the ttml query keys
+			keysToDelete = append(keysToDelete, key)
 		}
 		return true
 	})
 
 	for _, key := range keysToDelete {
-		if err := persistentCache.Delete(key); err != nil {
+		if err := deleteCacheEntry(key, trashReasonProviderClear); err != nil {
 			log.Warnf("%s Failed to delete key %s: %v", logcolors.LogCacheClear, key, err)
 		} else {
 			keysDeleted++
@@ -668,7 +691,7 @@ func clearProviderCache(w http.ResponseWriter, r *http.Request) {
 }
 
 func listBackups(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -688,11 +711,55 @@ func listBackups(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"count":   len(backups),
 		"backups": backups,
+		// Whether new backups are encrypted (BACKUP_ENCRYPTION_KEY)
+		"encryption_enabled": persistentCache.BackupEncryptionEnabled(),
 	})
 }
 
+// diffBackups compares two cache snapshots so restore decisions aren't blind.
+// from is a backup file name; to defaults to the live database ("live").
+func diffBackups(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = cache.LiveSnapshot
+	}
+	if from == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "Missing 'from' query parameter. Use /cache/backups to list available backups, or 'live' for the current database.",
+		})
+		return
+	}
+
+	limit := 100
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l >= 0 {
+		limit = min(l, 1000)
+	}
+
+	diff, err := persistentCache.DiffSnapshots(from, to, limit)
+	if err != nil {
+		log.Errorf("%s Failed to diff %s against %s: %v", logcolors.LogCacheBackups, from, to, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": fmt.Sprintf("Failed to diff snapshots: %v", err),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
 func restoreCache(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -747,8 +814,8 @@ func getHealthStatus(w http.ResponseWriter, r *http.Request) {
 
 	// Get account info - use GetAllTTMLAccounts for total count (backward compat)
 	// and GetTTMLAccounts for active count
-	allAccounts, allAccErr := conf.GetAllTTMLAccounts()
-	activeAccounts, _ := conf.GetTTMLAccounts()
+	allAccounts, allAccErr := conf().GetAllTTMLAccounts()
+	activeAccounts, _ := conf().GetTTMLAccounts()
 
 	totalAccountCount := 0
 	activeAccountCount := 0
@@ -776,6 +843,16 @@ func getHealthStatus(w http.ResponseWriter, r *http.Request) {
 		health["circuit_breaker_retry_in"] = cbTimeUntilRetry.String()
 	}
 
+	// Free space on the cache and backup volumes; an almost full cache volume
+	// turns the cache read-only (hits served, misses not cached)
+	if disks := diskStatusSnapshot(); len(disks) > 0 {
+		health["disk"] = disks
+	}
+	if persistentCache.IsReadOnly() {
+		health["status"] = "degraded"
+		health["cache_read_only"] = true
+	}
+
 	// If no active accounts configured, mark as unhealthy
 	if activeAccountCount == 0 {
 		health["status"] = "unhealthy"
@@ -787,7 +864,7 @@ func getHealthStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// If authenticated, include detailed token status
-	if r.Header.Get("Authorization") == conf.Configuration.CacheAccessToken && conf.Configuration.CacheAccessToken != "" {
+	if r.Header.Get("Authorization") == conf().Configuration.CacheAccessToken && conf().Configuration.CacheAccessToken != "" {
 		var tokenStatuses []map[string]interface{}
 		overallHealthy := true
 
@@ -842,6 +919,15 @@ func getHealthStatus(w http.ResponseWriter, r *http.Request) {
 				tokenStatus["note"] = "health check not yet run"
 			}
 
+			// MUTs that are JWTs carry an expiry; close to it the account is used last
+			if expiry, ok := ttml.GetAccountTokenExpiry(acc.Name); ok {
+				tokenStatus["expires"] = expiry.Format("2006-01-02 15:04:05")
+				tokenStatus["remaining_hours"] = int(time.Until(expiry).Hours())
+				if tokenStatus["status"] != "unhealthy" && ttml.IsAccountExpiring(acc.Name) {
+					tokenStatus["status"] = ttml.AccountStateExpiring
+				}
+			}
+
 			tokenStatuses = append(tokenStatuses, tokenStatus)
 		}
 
@@ -860,7 +946,7 @@ func getHealthStatus(w http.ResponseWriter, r *http.Request) {
 // handleMUTHealth handles the /health/mut endpoint for MUT health status
 func handleMUTHealth(w http.ResponseWriter, r *http.Request) {
 	// Requires auth token
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken || conf.Configuration.CacheAccessToken == "" {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken || conf().Configuration.CacheAccessToken == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -871,6 +957,7 @@ func handleMUTHealth(w http.ResponseWriter, r *http.Request) {
 		response := make(map[string]interface{})
 		for _, status := range results {
 			response[status.AccountName] = map[string]interface{}{
+				"state":        ttml.GetAccountState(status.AccountName),
 				"healthy":      status.Healthy,
 				"last_checked": status.LastChecked.Format(time.RFC3339),
 				"last_error":   status.LastError,
@@ -885,38 +972,47 @@ func handleMUTHealth(w http.ResponseWriter, r *http.Request) {
 	statuses := ttml.GetHealthStatuses()
 	response := make(map[string]interface{})
 	for name, status := range statuses {
-		response[name] = map[string]interface{}{
+		entry := map[string]interface{}{
+			"state":        ttml.GetAccountState(name),
 			"healthy":      status.Healthy,
 			"last_checked": status.LastChecked.Format(time.RFC3339),
 			"last_error":   status.LastError,
 		}
+		if status.ProbeStreak > 0 {
+			entry["probe_streak"] = status.ProbeStreak
+		}
+		response[name] = entry
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
 func getCircuitBreakerStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
 	state, failures, timeUntilRetry := ttml.GetCircuitBreakerStats()
+	baseThreshold, effectiveThreshold := ttml.GetCircuitBreakerThresholds()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"state":            state,
 		"failures":         failures,
 		"time_until_retry": timeUntilRetry.String(),
+		"probes":           ttml.GetCircuitBreakerProbeStats(),
 		"config": map[string]interface{}{
-			"threshold":    conf.Configuration.CircuitBreakerThreshold,
-			"cooldown_sec": conf.Configuration.CircuitBreakerCooldownSecs,
+			"threshold":           conf().Configuration.CircuitBreakerThreshold,
+			"base_threshold":      baseThreshold,
+			"effective_threshold": effectiveThreshold,
+			"cooldown_sec":        conf().Configuration.CircuitBreakerCooldownSecs,
 		},
 	})
 }
 
 func resetCircuitBreaker(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -930,7 +1026,7 @@ func resetCircuitBreaker(w http.ResponseWriter, r *http.Request) {
 }
 
 func simulateCircuitBreakerFailure(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -947,8 +1043,42 @@ func simulateCircuitBreakerFailure(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// upstreamRecordingHandler toggles recording of sanitized upstream search/lyrics
+// responses to UPSTREAM_FIXTURES_DIR. The fixtures feed ttml.NewReplayTransport in tests.
+//
+// GET returns the current status; POST with ?enabled=true|false starts or stops recording.
+func upstreamRecordingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		Respond(w, r).JSON(ttml.GetRecordingStatus())
+		return
+	}
+
+	switch r.URL.Query().Get("enabled") {
+	case "true":
+		status, err := ttml.StartRecording(conf().Configuration.UpstreamFixturesDir)
+		if err != nil {
+			Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+		Respond(w, r).JSON(status)
+	case "false":
+		Respond(w, r).JSON(ttml.StopRecording())
+	default:
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "enabled must be true or false",
+		})
+	}
+}
+
 func testNotifications(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -973,8 +1103,8 @@ func testNotifications(w http.ResponseWriter, r *http.Request) {
 	var tokenInfo string
 	var tokenDetails map[string]interface{}
 
-	allAccounts, allAccErr := conf.GetAllTTMLAccounts()
-	activeAccounts, _ := conf.GetTTMLAccounts()
+	allAccounts, allAccErr := conf().GetAllTTMLAccounts()
+	activeAccounts, _ := conf().GetTTMLAccounts()
 
 	if allAccErr != nil || len(allAccounts) == 0 {
 		tokenInfo = "Status:               Not configured\n" +
@@ -1117,15 +1247,19 @@ func overrideHandler(w http.ResponseWriter, r *http.Request) {
 
 	// 2. Parse params
 	trackID := r.URL.Query().Get("id")
-	songName := r.URL.Query().Get("s") + r.URL.Query().Get("song") + r.URL.Query().Get("songName")
-	artistName := r.URL.Query().Get("a") + r.URL.Query().Get("artist") + r.URL.Query().Get("artistName")
-	albumName := r.URL.Query().Get("al") + r.URL.Query().Get("album") + r.URL.Query().Get("albumName")
-	durationStr := r.URL.Query().Get("d") + r.URL.Query().Get("duration")
+	q, err := lyricsquery.Parse(r.URL.Query())
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	songName, artistName, albumName, durationStr := q.Song, q.Artist, q.Album, q.Duration
 	dryRun := r.URL.Query().Get("dry_run") == "true"
 	noLyrics := r.URL.Query().Get("no_lyrics") == "true"
 
 	// 3. Validate required params
-	if songName == "" || artistName == "" {
+	if !q.HasSongAndArtist() {
 		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
 			"error": "song (s) and artist (a) parameters are required",
 		})
@@ -1262,17 +1396,23 @@ func helpHandler(w http.ResponseWriter, r *http.Request) {
 		"help": "Lyrics API with multiple provider support",
 		"docs": "https://lyrics-api-docs.boidu.dev",
 		"endpoints": map[string]string{
-			"/getLyrics":        "Default provider (TTML)",
+			"/getLyrics":        "Default provider (TTML). HEAD returns the status and X-Cache-Status from the cache only, without a body or upstream fetch. Responses carry a Server-Timing header (cache, search, fetch, serialize)",
 			"/ttml/getLyrics":   "TTML provider (word-level timing)",
 			"/kugou/getLyrics":  "Kugou provider (line-level timing)",
 			"/legacy/getLyrics": "Legacy Spotify-based provider",
+			"/auto/getLyrics":   "Tries providers in an order picked by the query's script or the locale param (PROVIDER_ORDER_RULES), e.g. Kugou first for CJK titles",
+			"/testdata":         "Conformance corpus: fixed lyrics fixtures (word-level, line-level, unsynced, RTL, background vocals, duet) at /testdata/{case}, rendered like /getLyrics in every format, for client tests",
+			"/v1/...":           "Any endpoint under /v1 returns JSON as {data, error, meta} with snake_case field names; unprefixed paths keep their legacy shapes",
 		},
 		"parameters": map[string]string{
 			"s, song, songName":     "Song name (required)",
 			"a, artist, artistName": "Artist name (required)",
 			"al, album, albumName":  "Album name (optional, improves matching)",
 			"d, duration":           "Duration in seconds (optional, improves matching)",
-			"videoId, v":            "YouTube video ID (optional, associates video with song for proxy revalidation)",
+			"videoId, v":            "YouTube video ID (optional). Once a video has resolved, later requests with it are served from that entry, and s/a may be omitted",
+			"format":                "Response format for /getLyrics: ttml (default), text (plain lyric sheet), lrc (LRC with section comments) or lines (parsed JSON lines with section labels)",
+			"fields":                "Comma-separated JSON fields to keep, e.g. lines.words,lines.startTimeMs (optional, JSON responses only)",
+			"compact":               "true drops syllable timing (lines.syllables, lines.backgroundVocals) from JSON responses (optional)",
 		},
 		"example": "/getLyrics?s=Shape%20of%20You&a=Ed%20Sheeran",
 		"notes":   "The API uses provider-specific matching algorithms. Providing more parameters improves accuracy.",
@@ -1293,12 +1433,16 @@ func revalidateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. Parse params (same as getLyrics)
-	songName := r.URL.Query().Get("s") + r.URL.Query().Get("song") + r.URL.Query().Get("songName")
-	artistName := r.URL.Query().Get("a") + r.URL.Query().Get("artist") + r.URL.Query().Get("artistName")
-	albumName := r.URL.Query().Get("al") + r.URL.Query().Get("album") + r.URL.Query().Get("albumName")
-	durationStr := r.URL.Query().Get("d") + r.URL.Query().Get("duration")
+	q, err := lyricsquery.Parse(r.URL.Query())
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	songName, artistName, albumName, durationStr := q.Song, q.Artist, q.Album, q.Duration
 
-	if songName == "" || artistName == "" {
+	if !q.HasSongAndArtist() {
 		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
 			"error": "song (s) and artist (a) parameters are required",
 		})
@@ -1359,7 +1503,7 @@ func revalidateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Infof("%s Revalidating cache for: %s %s", logcolors.LogRevalidate, songName, artistName)
-	ttmlString, trackDurationMs, score, trackMeta, err := ttml.FetchTTMLLyrics(songName, artistName, albumName, durationMs)
+	ttmlString, trackDurationMs, score, trackMeta, err := ttml.FetchTTMLLyricsFresh(songName, artistName, albumName, durationMs)
 
 	if err != nil {
 		log.Warnf("%s Revalidation fetch failed: %v", logcolors.LogRevalidate, err)
@@ -1380,6 +1524,17 @@ func revalidateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Truncated lyrics must not replace what is cached
+	if reason, complete := checkLyricsCompleteness(ttmlString, trackDurationMs); !complete {
+		reportDegradedLyrics(trackMeta, usedKey, reason)
+		Respond(w, r).JSON(map[string]interface{}{
+			"error":    "fetched lyrics look truncated: " + reason,
+			"updated":  false,
+			"cacheKey": usedKey,
+		})
+		return
+	}
+
 	// 7. Compare hashes (if from negative cache or no-lyrics sentinel, always treat as updated)
 	newHash := md5.Sum([]byte(ttmlString))
 	updated := wasInNegativeCache || wasNoLyricsSentinel || oldHash != newHash
@@ -1391,22 +1546,23 @@ func revalidateHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		// Update cache with fresh content
 		language, isRTL := ttml.DetectLanguage(ttmlString)
-		setCachedLyrics(usedKey, ttmlString, trackDurationMs, score, language, isRTL)
+		setCachedLyricsForTrack(usedKey, trackMeta.TrackID, ttmlString, trackDurationMs, score, language, isRTL)
 		go bini.PostLyrics(trackMeta.Name, trackMeta.ArtistName, trackMeta.AlbumName, trackDurationMs, ttmlString, trackMeta.ISRC)
-		go func() {
+		goWrite(func() {
 			// Update metadata before proxy revalidation (which queries metadata for videoIds)
 			setSongMetadata(&SongMetadata{
-				CacheKey:     usedKey,
-				AppleTrackID: trackMeta.TrackID,
-				ISRC:         trackMeta.ISRC,
-				TrackName:    trackMeta.Name,
-				ArtistName:   trackMeta.ArtistName,
-				AlbumName:    trackMeta.AlbumName,
-				DurationMs:   trackDurationMs,
-				ReleaseDate:  trackMeta.ReleaseDate,
+				CacheKey:      usedKey,
+				AppleTrackID:  trackMeta.TrackID,
+				ISRC:          trackMeta.ISRC,
+				TrackName:     trackMeta.Name,
+				ArtistName:    trackMeta.ArtistName,
+				AlbumName:     trackMeta.AlbumName,
+				DurationMs:    trackDurationMs,
+				ReleaseDate:   trackMeta.ReleaseDate,
+				ContentRating: trackMeta.ContentRating,
 			})
 			proxy.RevalidateAllForSong(trackMeta.Name, trackMeta.ArtistName, trackMeta.AlbumName, trackDurationMs/1000, getAllVideoIDsForSong)
-		}()
+		})
 		log.Infof("%s Content changed, cache updated for: %s", logcolors.LogRevalidate, usedKey)
 	} else {
 		log.Infof("%s Content unchanged for: %s", logcolors.LogRevalidate, usedKey)
@@ -1424,9 +1580,9 @@ func revalidateHandler(w http.ResponseWriter, r *http.Request) {
 // Protected by CACHE_ACCESS_TOKEN.
 func videoMapImportHandler(w http.ResponseWriter, r *http.Request) {
 	// Require auth
-	if conf.Configuration.CacheAccessToken != "" {
+	if conf().Configuration.CacheAccessToken != "" {
 		token := r.Header.Get("Authorization")
-		if token != conf.Configuration.CacheAccessToken {
+		if token != conf().Configuration.CacheAccessToken {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
@@ -1530,20 +1686,24 @@ func enrichMetadata(meta *SongMetadata) map[string]interface{} {
 // Each result is enriched with parsed Apple Music attributes and lyrics-cache status.
 // Protected by CACHE_ACCESS_TOKEN.
 func metadataLookupHandler(w http.ResponseWriter, r *http.Request) {
-	if conf.Configuration.CacheAccessToken != "" {
+	if conf().Configuration.CacheAccessToken != "" {
 		token := r.Header.Get("Authorization")
-		if token != conf.Configuration.CacheAccessToken {
+		if token != conf().Configuration.CacheAccessToken {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 	}
 
+	q, err := lyricsquery.Parse(r.URL.Query())
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
 	videoID := r.URL.Query().Get("videoId")
 	isrc := r.URL.Query().Get("isrc")
-	songName := r.URL.Query().Get("s") + r.URL.Query().Get("song")
-	artistName := r.URL.Query().Get("a") + r.URL.Query().Get("artist")
-	albumName := r.URL.Query().Get("al") + r.URL.Query().Get("album")
-	durationStr := r.URL.Query().Get("d") + r.URL.Query().Get("duration")
+	songName, artistName, albumName, durationStr := q.Song, q.Artist, q.Album, q.Duration
 
 	// Lookup by videoId
 	if videoID != "" {
@@ -1650,8 +1810,8 @@ const (
 // metadataStatsRichParseCap; counters are unbounded.
 // Protected by CACHE_ACCESS_TOKEN.
 func metadataStatsHandler(w http.ResponseWriter, r *http.Request) {
-	if conf.Configuration.CacheAccessToken == "" ||
-		r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if conf().Configuration.CacheAccessToken == "" ||
+		r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -1682,8 +1842,8 @@ func metadataStatsHandler(w http.ResponseWriter, r *http.Request) {
 // with parsed rawAttributes + lyrics-cache status (same shape as /metadata results).
 // Bounded by metadataSampleMaxN. Protected by CACHE_ACCESS_TOKEN.
 func metadataSampleHandler(w http.ResponseWriter, r *http.Request) {
-	if conf.Configuration.CacheAccessToken == "" ||
-		r.Header.Get("Authorization") != conf.Configuration.CacheAccessToken {
+	if conf().Configuration.CacheAccessToken == "" ||
+		r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
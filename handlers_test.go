@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 )
 
@@ -66,3 +67,157 @@ func TestGetCacheDump_Returns410(t *testing.T) {
 		}
 	})
 }
+
+func TestUpstreamRecordingHandler(t *testing.T) {
+	savedDir := conf().Configuration.UpstreamFixturesDir
+	conf().Configuration.UpstreamFixturesDir = t.TempDir()
+	defer func() { conf().Configuration.UpstreamFixturesDir = savedDir }()
+
+	t.Run("unauthorized", func(t *testing.T) {
+		savedToken := conf().Configuration.CacheAccessToken
+		conf().Configuration.CacheAccessToken = "test-token"
+		defer func() { conf().Configuration.CacheAccessToken = savedToken }()
+
+		w := httptest.NewRecorder()
+		upstreamRecordingHandler(w, httptest.NewRequest(http.MethodPost, "/debug/recording?enabled=true", nil))
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("invalid toggle", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		upstreamRecordingHandler(w, httptest.NewRequest(http.MethodPost, "/debug/recording?enabled=maybe", nil))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("enable, status, disable", func(t *testing.T) {
+		for _, step := range []struct {
+			method  string
+			query   string
+			enabled bool
+		}{
+			{http.MethodPost, "?enabled=true", true},
+			{http.MethodGet, "", true},
+			{http.MethodPost, "?enabled=false", false},
+			{http.MethodGet, "", false},
+		} {
+			w := httptest.NewRecorder()
+			upstreamRecordingHandler(w, httptest.NewRequest(step.method, "/debug/recording"+step.query, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s %s: status = %d, want %d", step.method, step.query, w.Code, http.StatusOK)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body["enabled"] != step.enabled {
+				t.Errorf("%s %s: enabled = %v, want %v", step.method, step.query, body["enabled"], step.enabled)
+			}
+		}
+	})
+}
+
+func TestDiffBackups(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	persistentCache.Set("ttml_lyrics:hello adele", "before")
+	backupPath, err := persistentCache.Backup()
+	if err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+	persistentCache.Set("ttml_lyrics:hello adele", "after")
+	persistentCache.Set("ttml_lyrics:new song", "x")
+
+	t.Run("missing from", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/cache/backups/diff", nil)
+		r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+		diffBackups(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("backup against live", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/cache/backups/diff?from="+filepath.Base(backupPath), nil)
+		r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+		diffBackups(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		var diff struct {
+			To      string `json:"to"`
+			Added   int    `json:"added"`
+			Changed int    `json:"changed"`
+		}
+		json.NewDecoder(w.Body).Decode(&diff)
+		if diff.To != "live" || diff.Added != 1 || diff.Changed != 1 {
+			t.Errorf("Unexpected diff: %+v", diff)
+		}
+	})
+
+	t.Run("unknown backup", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/cache/backups/diff?from=missing.db", nil)
+		r.Header.Set("Authorization", conf().Configuration.CacheAccessToken)
+		diffBackups(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestGetLyrics_CacheHitIncludesMatchMetadata(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	setCachedLyrics(buildNormalizedCacheKey("Hit Song", "Artist", "", ""), testTTML, 201000, 0.87, "en", false)
+
+	rr := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=Hit+Song&a=Artist", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Cache-Status") != "HIT" {
+		t.Fatalf("Expected a 200 HIT, got %d %q", rr.Code, rr.Header().Get("X-Cache-Status"))
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["score"] != 0.87 || body["language"] != "en" || body["isRTL"] != false || body["trackDurationMs"] != float64(201000) {
+		t.Errorf("Cache hit should carry the stored match metadata, got %v", body)
+	}
+}
+
+func TestGetLyrics_QueryAliases(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+	setCachedLyrics(buildNormalizedCacheKey("Hello", "Adele", "", "295"), formatTestTTML, 295000, 0.9, "", false)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"s=Hello&a=Adele&d=295", http.StatusOK},
+		{"songName=Hello&artist=Adele&duration=295.4", http.StatusOK},
+		{"s=Hello&song=Someone+Like+You&a=Adele&d=295", http.StatusOK}, // First alias wins
+		{"s=Hello&a=Adele&d=3m15s", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		getLyrics(w, httptest.NewRequest(http.MethodGet, "/getLyrics?"+tt.query, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.query, w.Code, tt.want)
+		}
+		if tt.want == http.StatusOK && w.Header().Get("X-Cache-Status") != "HIT" {
+			t.Errorf("%s: X-Cache-Status = %q, want HIT", tt.query, w.Header().Get("X-Cache-Status"))
+		}
+	}
+}
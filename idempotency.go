@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/stats"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// idempotencyKeyHeader carries the client-chosen key of a destructive admin call
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyInFlight holds the record IDs of requests still executing, so a retry
+// that races the original gets 409 instead of running the operation a second time
+var idempotencyInFlight sync.Map
+
+// idempotent wraps a destructive admin handler so a retried request carrying the
+// same Idempotency-Key gets the stored response instead of running again. Records
+// are scoped to the Authorization header and kept for IDEMPOTENCY_TTL_HOURS.
+// Requests without the header run as usual.
+//
+// Not stored (a retry runs again): 401 responses, where nothing happened, and 5xx
+// responses, which may be transient.
+func idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || statsStore == nil {
+			next(w, r)
+			return
+		}
+
+		id := idempotencyRecordID(r, key)
+		fingerprint := idempotencyFingerprint(r)
+		ttl := time.Duration(conf().Configuration.IdempotencyTTLHours) * time.Hour
+
+		if replayIdempotent(w, r, id, fingerprint, ttl) {
+			return
+		}
+
+		if _, running := idempotencyInFlight.LoadOrStore(id, struct{}{}); running {
+			Respond(w, r).Error(http.StatusConflict, map[string]interface{}{
+				"error": "A request with this Idempotency-Key is still in progress",
+			})
+			return
+		}
+		defer idempotencyInFlight.Delete(id)
+
+		// The original may have stored its response and released the slot between
+		// the check above and taking the slot
+		if replayIdempotent(w, r, id, fingerprint, ttl) {
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		if rec.status == http.StatusUnauthorized || rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		err := statsStore.PutIdempotency(id, stats.IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+			CreatedAt:   clk.Now(),
+		})
+		if err != nil {
+			log.Errorf("%s Failed to store result of %s: %v", logcolors.LogIdempotency, fingerprint, err)
+		}
+		if _, err := statsStore.PruneIdempotency(clk.Now().Add(-ttl)); err != nil {
+			log.Warnf("%s %v", logcolors.LogIdempotency, err)
+		}
+	}
+}
+
+// replayIdempotent answers from the stored record of id when there is one younger
+// than ttl: its response, or 422 when the key was used for a different request.
+// It reports whether it answered.
+func replayIdempotent(w http.ResponseWriter, r *http.Request, id, fingerprint string, ttl time.Duration) bool {
+	record, ok := statsStore.GetIdempotency(id)
+	if !ok || clk.Now().Sub(record.CreatedAt) >= ttl {
+		return false
+	}
+	if record.Fingerprint != fingerprint {
+		Respond(w, r).Error(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":    "Idempotency-Key was already used for a different request",
+			"original": record.Fingerprint,
+		})
+		return true
+	}
+	log.Infof("%s Replaying stored response for %s", logcolors.LogIdempotency, fingerprint)
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+	return true
+}
+
+// idempotencyRecordID scopes a key to the credential that sent it, so one caller
+// can't read back another's stored response by guessing their key
+func idempotencyRecordID(r *http.Request, key string) string {
+	sum := sha256.Sum256([]byte(r.Header.Get("Authorization") + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// idempotencyFingerprint identifies the request a key was first used for
+func idempotencyFingerprint(r *http.Request) string {
+	fingerprint := r.Method + " " + r.URL.Path
+	if query := r.URL.Query().Encode(); query != "" {
+		fingerprint += "?" + query
+	}
+	return fingerprint
+}
+
+// idempotencyRecorder passes a response through while keeping a copy of it
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"lyrics-api-go/internal/clocktest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdempotent_ReplaysStoredResponse(t *testing.T) {
+	setupTestAuditLog(t) // Idempotency records live in the same stats store
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	origClk := clk
+	clk = fake
+	defer func() { clk = origClk }()
+
+	runs := 0
+	handler := idempotent(func(w http.ResponseWriter, r *http.Request) {
+		runs++
+		Respond(w, r).JSON(map[string]interface{}{"cleared": runs})
+	})
+
+	call := func(target, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.Header.Set("Authorization", "test-token")
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	first := call("/cache/clear", "k1")
+	replay := call("/cache/clear", "k1")
+	if runs != 1 {
+		t.Fatalf("Expected the handler to run once, ran %d times", runs)
+	}
+	if replay.Body.String() != first.Body.String() || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected the stored response, got %q (replayed=%q)", replay.Body.String(), replay.Header().Get("Idempotent-Replayed"))
+	}
+
+	if rr := call("/cache/clear/kugou", "k1"); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a key reused on another request, got %d", rr.Code)
+	}
+
+	call("/cache/clear", "")
+	if runs != 2 {
+		t.Errorf("Requests without a key must always run, ran %d times", runs)
+	}
+
+	fake.Advance(25 * time.Hour)
+	call("/cache/clear", "k1")
+	if runs != 3 {
+		t.Errorf("Expired key must run again, ran %d times", runs)
+	}
+}
+
+func TestIdempotent_DoesNotStoreFailures(t *testing.T) {
+	setupTestAuditLog(t)
+
+	status := http.StatusUnauthorized
+	runs := 0
+	handler := idempotent(func(w http.ResponseWriter, r *http.Request) {
+		runs++
+		w.WriteHeader(status)
+	})
+
+	for _, code := range []int{http.StatusUnauthorized, http.StatusInternalServerError, http.StatusOK, http.StatusOK} {
+		status = code
+		req := httptest.NewRequest(http.MethodPost, "/cache/restore?backup=x", nil)
+		req.Header.Set(idempotencyKeyHeader, "k2")
+		handler(httptest.NewRecorder(), req)
+	}
+	if runs != 3 {
+		t.Errorf("Expected 401 and 5xx to be retried and the 200 replayed (3 runs), got %d", runs)
+	}
+}
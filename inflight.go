@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// newInFlightRequest returns an in-flight entry whose waiters block until finish
+func newInFlightRequest() *InFlightRequest {
+	return &InFlightRequest{done: make(chan struct{})}
+}
+
+// finish releases the waiters; the leader calls it once its result fields are set
+func (req *InFlightRequest) finish() {
+	close(req.done)
+}
+
+// wait blocks until the leader finishes or timeout passes, reporting whether the
+// result is ready. A timeout of 0 waits for as long as the upstream call takes.
+func (req *InFlightRequest) wait(timeout time.Duration) bool {
+	coalescingWaiters.Add(1)
+	defer coalescingWaiters.Add(-1)
+	if timeout <= 0 {
+		<-req.done
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-req.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// inFlightWaitTimeout is how long a duplicate request waits on the leader before
+// being told to poll (0 = wait it out)
+func inFlightWaitTimeout() time.Duration {
+	return time.Duration(conf().Configuration.InFlightWaitTimeoutSecs) * time.Second
+}
+
+// respondInFlightPending answers a waiter that gave up on a slow upstream lookup with
+// 202, Retry-After and X-Inflight, so the client polls instead of holding the
+// connection. The leader's result is cached (or lingers in flight) for the retry.
+func respondInFlightPending(resp *APIResponse, w http.ResponseWriter, body map[string]interface{}) {
+	retryAfter := inFlightPendingBody(body)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	w.Header().Set("X-Inflight", "true")
+	resp.SetCacheStatus("MISS").Error(http.StatusAccepted, body)
+}
+
+// inFlightPendingBody fills in the body of a pending answer and returns its Retry-After
+func inFlightPendingBody(body map[string]interface{}) (retryAfter int) {
+	retryAfter = max(conf().Configuration.InFlightWaitTimeoutSecs/2, 1)
+	body["status"] = "pending"
+	body["message"] = "Lookup for this track is still in progress, retry shortly"
+	body["retry_after"] = retryAfter
+	return retryAfter
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInFlightRequest_Wait(t *testing.T) {
+	req := newInFlightRequest()
+	if req.wait(10 * time.Millisecond) {
+		t.Fatal("wait must time out while the leader is running")
+	}
+	req.finish()
+	if !req.wait(10*time.Millisecond) || !req.wait(0) {
+		t.Error("wait must return at once after finish")
+	}
+}
+
+func TestGetLyrics_InFlightWaitTimeout(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	originalTimeout := conf().Configuration.InFlightWaitTimeoutSecs
+	conf().Configuration.InFlightWaitTimeoutSecs = 1
+	defer func() { conf().Configuration.InFlightWaitTimeoutSecs = originalTimeout }()
+
+	// A leader that is still waiting on upstream
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	leader := newInFlightRequest()
+	inFlightReqs.Store(cacheKey, leader)
+	defer inFlightReqs.Delete(cacheKey)
+
+	rr := httptest.NewRecorder()
+	getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist", nil))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202 after the wait timeout, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("X-Inflight") != "true" || rr.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected X-Inflight and Retry-After headers, got %v", rr.Header())
+	}
+	var body map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	if body["status"] != "pending" {
+		t.Errorf("Expected a pending status, got %s", rr.Body.String())
+	}
+
+	// The poll after the leader finished gets its result
+	leader.result = testTTML
+	leader.finish()
+	rr = httptest.NewRecorder()
+	getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Inflight") != "" {
+		t.Errorf("Expected the leader's result, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,46 @@
+// Package clocktest provides a manually driven clock.Clock for tests.
+package clocktest
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a clock that only moves when told to. Safe for concurrent use.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake returns a fake clock frozen at start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) Until(t time.Time) time.Duration {
+	return t.Sub(f.Now())
+}
+
+// Advance moves the clock forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+// Set jumps the clock to t (which may be in the past)
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	f.now = t
+	f.mu.Unlock()
+}
@@ -0,0 +1,392 @@
+// Package jobs runs long-running admin operations (cache migration, analysis, dedupe,
+// bulk delete, verification) in the background and tracks their status, progress and result.
+//
+// Every job gets an ID, a kind and a context that Cancel signals. Only one job of a
+// kind runs at a time. Finished jobs are kept for a retention period so their results
+// can still be fetched, then dropped.
+//
+// Jobs that can't simply be re-run from scratch save a checkpoint (a cursor such as
+// the last processed key) as they go. Checkpoints outlive the job, and the process
+// when the store is persistent, so the next job of the same kind resumes after a
+// cancel or crash.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"lyrics-api-go/clock"
+	"lyrics-api-go/logcolors"
+)
+
+// Status is the lifecycle state of a job
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Finished reports whether the job has stopped for good
+func (s Status) Finished() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
+// Progress tracks how far a job has got
+type Progress struct {
+	Current       string `json:"current,omitempty"` // Current step, for jobs with several
+	TotalKeys     int    `json:"total_keys"`
+	ProcessedKeys int    `json:"processed_keys"`
+	Percent       int    `json:"percent"`
+}
+
+// Job is a snapshot of one job's state
+type Job struct {
+	ID          string                 `json:"id"`
+	Kind        string                 `json:"kind"`
+	Status      Status                 `json:"status"`
+	StartedAt   int64                  `json:"started_at"`
+	CompletedAt int64                  `json:"completed_at,omitempty"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+	Progress    Progress               `json:"progress"`
+	Result      interface{}            `json:"result,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	Checkpoint  string                 `json:"checkpoint,omitempty"` // Last saved resume cursor
+}
+
+// CheckpointStore persists job checkpoints by key
+type CheckpointStore interface {
+	LoadCheckpoint(key string) (string, bool)
+	SaveCheckpoint(key, cursor string) error
+	DeleteCheckpoint(key string) error
+}
+
+var (
+	// ErrNotFound is returned for unknown (or expired) job IDs
+	ErrNotFound = errors.New("job not found")
+
+	// ErrFinished is returned when cancelling a job that has already stopped
+	ErrFinished = errors.New("job already finished")
+)
+
+// RunningError is returned by Start when a job of the same kind is still active
+type RunningError struct {
+	Kind string
+	ID   string
+}
+
+func (e *RunningError) Error() string {
+	return fmt.Sprintf("a %s job is already in progress (%s)", e.Kind, e.ID)
+}
+
+// RunFunc does a job's work. It should stop early when Context is cancelled; the
+// returned value becomes the job's result (it is kept even when err is non-nil,
+// so partial results stay visible).
+type RunFunc func(t *Task) (interface{}, error)
+
+// Task is a running job's handle on its own state
+type Task struct {
+	m     *Manager
+	entry *entry
+}
+
+type entry struct {
+	job    Job
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager tracks jobs. The zero value is not usable; use NewManager.
+type Manager struct {
+	mu          sync.RWMutex
+	jobs        map[string]*entry
+	retention   time.Duration
+	maxFinished int
+	clock       clock.Clock
+	checkpoints CheckpointStore
+	seq         atomic.Uint64
+}
+
+// Options configure a Manager
+type Options struct {
+	Retention   time.Duration   // How long finished jobs are kept (0 = forever)
+	MaxFinished int             // Cap on finished jobs kept, oldest dropped first (0 = no cap)
+	Clock       clock.Clock     // Defaults to the wall clock
+	Checkpoints CheckpointStore // Where checkpoints are kept (nil = checkpoints disabled)
+}
+
+// NewManager creates an empty job manager
+func NewManager(opts Options) *Manager {
+	return &Manager{
+		jobs:        make(map[string]*entry),
+		retention:   opts.Retention,
+		maxFinished: opts.MaxFinished,
+		clock:       clock.OrReal(opts.Clock),
+		checkpoints: opts.Checkpoints,
+	}
+}
+
+// Start registers a job and runs it in the background. It fails with *RunningError
+// if a job of the same kind is pending or running.
+func (m *Manager) Start(kind string, params map[string]interface{}, run RunFunc) (Job, error) {
+	m.mu.Lock()
+	m.pruneLocked()
+	for _, e := range m.jobs {
+		if e.job.Kind == kind && !e.job.Status.Finished() {
+			m.mu.Unlock()
+			return Job{}, &RunningError{Kind: kind, ID: e.job.ID}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &entry{
+		job: Job{
+			ID:        fmt.Sprintf("%s_%d_%d", kind, m.clock.Now().UnixNano(), m.seq.Add(1)),
+			Kind:      kind,
+			Status:    StatusPending,
+			StartedAt: m.clock.Now().Unix(),
+			Params:    params,
+		},
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.jobs[e.job.ID] = e
+	snapshot := e.job
+	m.mu.Unlock()
+
+	go m.run(e, run)
+	return snapshot, nil
+}
+
+func (m *Manager) run(e *entry, run RunFunc) {
+	defer close(e.done)
+	defer e.cancel()
+
+	m.mu.Lock()
+	if e.ctx.Err() != nil {
+		// Cancelled before it started
+		m.mu.Unlock()
+		return
+	}
+	e.job.Status = StatusRunning
+	m.mu.Unlock()
+
+	var result interface{}
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+				log.Errorf("%s %s job %s panicked: %v", logcolors.LogJobs, e.job.Kind, e.job.ID, r)
+			}
+		}()
+		result, err = run(&Task{m: m, entry: e})
+	}()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if result != nil {
+		e.job.Result = result
+	}
+	e.job.CompletedAt = m.clock.Now().Unix()
+	switch {
+	case e.ctx.Err() != nil && (err == nil || errors.Is(err, context.Canceled)):
+		log.Infof("%s %s job %s cancelled", logcolors.LogJobs, e.job.Kind, e.job.ID)
+		e.job.Status = StatusCancelled
+	case err != nil:
+		e.job.Status = StatusFailed
+		e.job.Error = err.Error()
+	default:
+		e.job.Status = StatusCompleted
+		e.job.Progress.Percent = 100
+	}
+}
+
+// Get returns a snapshot of a job
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return e.job, true
+}
+
+// List returns snapshots of all jobs of a kind (every kind if empty), newest first
+func (m *Manager) List(kind string) []Job {
+	m.mu.Lock()
+	m.pruneLocked()
+	list := make([]Job, 0, len(m.jobs))
+	for _, e := range m.jobs {
+		if kind == "" || e.job.Kind == kind {
+			list = append(list, e.job)
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].StartedAt != list[j].StartedAt {
+			return list[i].StartedAt > list[j].StartedAt
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list
+}
+
+// Cancel signals a job's context. The job stops at its next check and ends up
+// cancelled; a job that hasn't started yet never runs.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if e.job.Status.Finished() {
+		return ErrFinished
+	}
+	e.cancel()
+	if e.job.Status == StatusPending {
+		e.job.Status = StatusCancelled
+		e.job.CompletedAt = m.clock.Now().Unix()
+	}
+	return nil
+}
+
+// Wait blocks until a job finishes (or ctx is done) and returns its final state
+func (m *Manager) Wait(ctx context.Context, id string) (Job, error) {
+	m.mu.RLock()
+	e, ok := m.jobs[id]
+	m.mu.RUnlock()
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+	job, _ := m.Get(id)
+	return job, nil
+}
+
+// pruneLocked drops finished jobs past the retention period, then the oldest
+// finished jobs beyond the cap
+func (m *Manager) pruneLocked() {
+	now := m.clock.Now().Unix()
+	var finished []*entry
+	for id, e := range m.jobs {
+		if !e.job.Status.Finished() {
+			continue
+		}
+		if m.retention > 0 && now-e.job.CompletedAt > int64(m.retention/time.Second) {
+			delete(m.jobs, id)
+			continue
+		}
+		finished = append(finished, e)
+	}
+	if m.maxFinished <= 0 || len(finished) <= m.maxFinished {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].job.CompletedAt < finished[j].job.CompletedAt })
+	for _, e := range finished[:len(finished)-m.maxFinished] {
+		delete(m.jobs, e.job.ID)
+	}
+}
+
+// ID returns the job's ID
+func (t *Task) ID() string {
+	return t.entry.job.ID
+}
+
+// Context is cancelled when the job is cancelled
+func (t *Task) Context() context.Context {
+	return t.entry.ctx
+}
+
+// Cancelled reports whether the job has been asked to stop
+func (t *Task) Cancelled() bool {
+	return t.entry.ctx.Err() != nil
+}
+
+// SetProgress records how many of total units are done
+func (t *Task) SetProgress(done, total int) {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	p := &t.entry.job.Progress
+	p.TotalKeys = total
+	p.ProcessedKeys = done
+	if total > 0 {
+		p.Percent = min(done*100/total, 100)
+	}
+}
+
+// SetStep starts a new named step, resetting the progress counters
+func (t *Task) SetStep(name string) {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	t.entry.job.Progress = Progress{Current: name}
+}
+
+// SetResult publishes an intermediate result while the job runs
+func (t *Task) SetResult(result interface{}) {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	t.entry.job.Result = result
+}
+
+// Checkpoint returns the saved resume cursor for a named step of this job's kind,
+// or "" to start from the beginning
+func (t *Task) Checkpoint(step string) string {
+	if t.m.checkpoints == nil {
+		return ""
+	}
+	cursor, _ := t.m.checkpoints.LoadCheckpoint(t.checkpointKey(step))
+	return cursor
+}
+
+// SaveCheckpoint records how far a step has got. Save after work is durable, not
+// before: a resumed job skips everything up to and including the cursor.
+func (t *Task) SaveCheckpoint(step, cursor string) {
+	t.m.mu.Lock()
+	t.entry.job.Checkpoint = cursor
+	t.m.mu.Unlock()
+
+	if t.m.checkpoints == nil {
+		return
+	}
+	if err := t.m.checkpoints.SaveCheckpoint(t.checkpointKey(step), cursor); err != nil {
+		log.Warnf("%s Failed to save checkpoint for %s job %s: %v", logcolors.LogJobs, t.entry.job.Kind, t.entry.job.ID, err)
+	}
+}
+
+// ClearCheckpoint forgets a step's checkpoint once the step has finished
+func (t *Task) ClearCheckpoint(step string) {
+	t.m.mu.Lock()
+	t.entry.job.Checkpoint = ""
+	t.m.mu.Unlock()
+
+	if t.m.checkpoints == nil {
+		return
+	}
+	if err := t.m.checkpoints.DeleteCheckpoint(t.checkpointKey(step)); err != nil {
+		log.Warnf("%s Failed to clear checkpoint for %s job %s: %v", logcolors.LogJobs, t.entry.job.Kind, t.entry.job.ID, err)
+	}
+}
+
+func (t *Task) checkpointKey(step string) string {
+	return t.entry.job.Kind + ":" + step
+}
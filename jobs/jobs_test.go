@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lyrics-api-go/internal/clocktest"
+)
+
+func wait(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("Wait(%s): %v", id, err)
+	}
+	return job
+}
+
+func TestManager_Lifecycle(t *testing.T) {
+	m := NewManager(Options{})
+
+	started, err := m.Start("scan", map[string]interface{}{"prefix": "x"}, func(task *Task) (interface{}, error) {
+		task.SetStep("counting")
+		task.SetProgress(5, 10)
+		return "done", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Status != StatusPending || started.Kind != "scan" {
+		t.Errorf("Unexpected start snapshot: %+v", started)
+	}
+
+	job := wait(t, m, started.ID)
+	if job.Status != StatusCompleted || job.Result != "done" || job.CompletedAt == 0 {
+		t.Errorf("Unexpected final state: %+v", job)
+	}
+	if job.Progress.Current != "counting" || job.Progress.Percent != 100 {
+		t.Errorf("Unexpected progress: %+v", job.Progress)
+	}
+}
+
+func TestManager_FailureKeepsPartialResult(t *testing.T) {
+	m := NewManager(Options{})
+	started, _ := m.Start("scan", nil, func(task *Task) (interface{}, error) {
+		return 3, errors.New("disk full")
+	})
+
+	job := wait(t, m, started.ID)
+	if job.Status != StatusFailed || job.Error != "disk full" || job.Result != 3 {
+		t.Errorf("Unexpected final state: %+v", job)
+	}
+}
+
+func TestManager_PanicFailsJob(t *testing.T) {
+	m := NewManager(Options{})
+	started, _ := m.Start("scan", nil, func(task *Task) (interface{}, error) {
+		panic("boom")
+	})
+
+	job := wait(t, m, started.ID)
+	if job.Status != StatusFailed || job.Error != "panic: boom" {
+		t.Errorf("Unexpected final state: %+v", job)
+	}
+}
+
+func TestManager_OneJobPerKind(t *testing.T) {
+	m := NewManager(Options{})
+	release := make(chan struct{})
+	first, _ := m.Start("scan", nil, func(task *Task) (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	_, err := m.Start("scan", nil, func(task *Task) (interface{}, error) { return nil, nil })
+	var running *RunningError
+	if !errors.As(err, &running) || running.ID != first.ID {
+		t.Fatalf("Expected RunningError for %s, got %v", first.ID, err)
+	}
+
+	other, err := m.Start("other", nil, func(task *Task) (interface{}, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("Jobs of different kinds must run concurrently: %v", err)
+	}
+	wait(t, m, other.ID)
+
+	close(release)
+	wait(t, m, first.ID)
+}
+
+func TestManager_Cancel(t *testing.T) {
+	m := NewManager(Options{})
+	running := make(chan struct{})
+	started, _ := m.Start("scan", nil, func(task *Task) (interface{}, error) {
+		close(running)
+		<-task.Context().Done()
+		return "partial", task.Context().Err()
+	})
+	<-running
+
+	if err := m.Cancel(started.ID); err != nil {
+		t.Fatal(err)
+	}
+	job := wait(t, m, started.ID)
+	if job.Status != StatusCancelled || job.Error != "" || job.Result != "partial" {
+		t.Errorf("Unexpected final state: %+v", job)
+	}
+
+	if err := m.Cancel(started.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("Expected ErrFinished, got %v", err)
+	}
+	if err := m.Cancel("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestManager_Retention(t *testing.T) {
+	clk := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	m := NewManager(Options{Retention: time.Hour, MaxFinished: 2, Clock: clk})
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		started, _ := m.Start("scan", nil, func(task *Task) (interface{}, error) { return nil, nil })
+		wait(t, m, started.ID)
+		ids = append(ids, started.ID)
+		clk.Advance(time.Minute)
+	}
+
+	list := m.List("")
+	if len(list) != 2 || list[0].ID != ids[2] || list[1].ID != ids[1] {
+		t.Fatalf("Expected the two newest jobs, newest first, got %+v", list)
+	}
+
+	clk.Advance(2 * time.Hour)
+	if list := m.List("scan"); len(list) != 0 {
+		t.Errorf("Expected finished jobs to expire, got %d", len(list))
+	}
+}
+
+type memStore map[string]string
+
+func (s memStore) LoadCheckpoint(key string) (string, bool) { v, ok := s[key]; return v, ok }
+func (s memStore) SaveCheckpoint(key, cursor string) error  { s[key] = cursor; return nil }
+func (s memStore) DeleteCheckpoint(key string) error        { delete(s, key); return nil }
+
+func TestTask_Checkpoints(t *testing.T) {
+	store := memStore{}
+	m := NewManager(Options{Checkpoints: store})
+
+	started, _ := m.Start("scan", nil, func(task *Task) (interface{}, error) {
+		task.SaveCheckpoint("keys", "k42")
+		return nil, errors.New("crashed")
+	})
+	job := wait(t, m, started.ID)
+	if job.Checkpoint != "k42" || store["scan:keys"] != "k42" {
+		t.Fatalf("Expected checkpoint saved, got job %q store %v", job.Checkpoint, store)
+	}
+
+	var resumedFrom string
+	started, _ = m.Start("scan", nil, func(task *Task) (interface{}, error) {
+		resumedFrom = task.Checkpoint("keys")
+		task.ClearCheckpoint("keys")
+		return nil, nil
+	})
+	wait(t, m, started.ID)
+	if resumedFrom != "k42" {
+		t.Errorf("Expected the next job to resume from k42, got %q", resumedFrom)
+	}
+	if _, ok := store["scan:keys"]; ok {
+		t.Error("Expected checkpoint cleared")
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/logcolors"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// learnedAliasesBucket maps normalized queries (cache key without the duration) to
+// the Apple track a confident search resolved them to. A cache miss for a known
+// query fetches that track's lyrics directly instead of searching again, so the
+// table works like a self-building /override.
+const learnedAliasesBucket = "learned_aliases"
+
+// learnedAliasListLimit is the default number of aliases /cache/learned returns
+const learnedAliasListLimit = 100
+
+// learnedAliasMu serializes read-modify-writes of learned aliases (learning and hit
+// counting), so concurrent lookups of one query don't lose updates
+var learnedAliasMu sync.Mutex
+
+// LearnedAlias is the track a query resolved to
+type LearnedAlias struct {
+	Track      ttml.TrackMeta `json:"track"` // Without RawAttributes
+	DurationMs int            `json:"durationMs"`
+	Score      float64        `json:"score"`
+	Hits       int            `json:"hits"` // Lookups served without a search
+	CreatedAt  int64          `json:"createdAt"`
+	LastUsedAt int64          `json:"lastUsedAt,omitempty"`
+}
+
+// initLearnedAliasesBucket creates the learned aliases bucket.
+// Called during server startup after persistentCache is initialized.
+func initLearnedAliasesBucket() {
+	if err := persistentCache.CreateBucket(learnedAliasesBucket); err != nil {
+		log.Errorf("%s Failed to create learned aliases bucket: %v", logcolors.LogCache, err)
+	}
+}
+
+// learnedAliasMinScore is the match score a search needs before its result is
+// learned (0 = aliases are neither learned nor used)
+func learnedAliasMinScore() float64 {
+	return conf().Configuration.LearnedAliasMinScore
+}
+
+// learnedAliasKey is the query part of a lookup. The duration is left out so all
+// duration variants share the alias; it is checked against the track instead.
+func learnedAliasKey(songName, artistName, albumName string) string {
+	return buildNormalizedCacheKey(songName, artistName, albumName, "")
+}
+
+func getLearnedAlias(key string) (*LearnedAlias, bool) {
+	data, ok := persistentCache.GetFromBucket(learnedAliasesBucket, key)
+	if !ok {
+		return nil, false
+	}
+	var alias LearnedAlias
+	if err := json.Unmarshal(data, &alias); err != nil || alias.Track.TrackID == "" {
+		return nil, false
+	}
+	return &alias, true
+}
+
+func setLearnedAlias(key string, alias *LearnedAlias) {
+	data, err := json.Marshal(alias)
+	if err != nil {
+		return
+	}
+	if err := persistentCache.SetInBucket(learnedAliasesBucket, key, data); err != nil {
+		log.Errorf("%s Error setting learned alias %s: %v", logcolors.LogCache, key, err)
+	}
+}
+
+// learnAlias records a confident match. An existing alias for another track is
+// kept: the first confident answer wins until it is pruned. Relearning the same
+// track refreshes it but keeps its hits and creation time.
+func learnAlias(key string, trackMeta *ttml.TrackMeta, durationMs int, score float64) {
+	if trackMeta == nil || trackMeta.TrackID == "" || score < learnedAliasMinScore() {
+		return
+	}
+	track := *trackMeta
+	track.RawAttributes = ""
+	alias := &LearnedAlias{
+		Track:      track,
+		DurationMs: durationMs,
+		Score:      score,
+		CreatedAt:  time.Now().Unix(),
+	}
+
+	learnedAliasMu.Lock()
+	defer learnedAliasMu.Unlock()
+	if existing, ok := getLearnedAlias(key); ok {
+		if existing.Track.TrackID != trackMeta.TrackID {
+			return
+		}
+		alias.Hits, alias.CreatedAt, alias.LastUsedAt = existing.Hits, existing.CreatedAt, existing.LastUsedAt
+	}
+	setLearnedAlias(key, alias)
+}
+
+// recordLearnedAliasHit counts a lookup served by a learned alias. Runs in the
+// background (goWrite); a hit on an alias that was since pruned or relearned for
+// another track is dropped.
+func recordLearnedAliasHit(key, trackID string, usedAt int64) {
+	learnedAliasMu.Lock()
+	defer learnedAliasMu.Unlock()
+	alias, ok := getLearnedAlias(key)
+	if !ok || alias.Track.TrackID != trackID {
+		return
+	}
+	alias.Hits++
+	alias.LastUsedAt = max(alias.LastUsedAt, usedAt)
+	setLearnedAlias(key, alias)
+}
+
+// fetchTTMLWithLearnedAliases is ttml.FetchTTMLLyricsWithHints behind the learned
+// aliases: a known query skips the search, and a confident search is learned.
+// learn is false for lookups whose result isn't the query's usual answer (explicit=).
+func fetchTTMLWithLearnedAliases(songName, artistName, albumName string, durationMs int, preferExplicit, learn bool, hints ttml.MatchHints) (string, int, float64, *ttml.TrackMeta, error) {
+	key := learnedAliasKey(songName, artistName, albumName)
+	learn = learn && learnedAliasMinScore() > 0
+
+	if learn {
+		if alias, ok := getLearnedAlias(key); ok && learnedAliasFits(alias, durationMs) {
+			lyrics, err := learnedAliasLyrics(alias)
+			if err == nil {
+				trackID, usedAt := alias.Track.TrackID, time.Now().Unix()
+				goWrite(func() { recordLearnedAliasHit(key, trackID, usedAt) })
+				lyricsLog.Infof("learned_alias_hit", "%s Using learned track %s for: %s", logcolors.LogCacheLyrics, alias.Track.TrackID, key)
+				hints.Trace.Add("learned_alias", "Using learned track %s (%s), search skipped", alias.Track.TrackID, alias.Track.Name)
+				track := alias.Track
+				return lyrics, alias.DurationMs, alias.Score, &track, nil
+			}
+			log.Warnf("%s Learned track %s failed (%v), searching instead", logcolors.LogCacheLyrics, alias.Track.TrackID, err)
+			hints.Trace.Add("learned_alias", "Learned track %s failed (%v), searching instead", alias.Track.TrackID, err)
+		}
+	}
+
+	lyrics, trackDurationMs, score, trackMeta, err := ttml.FetchTTMLLyricsWithHints(songName, artistName, albumName, durationMs, preferExplicit, hints)
+	if learn && err == nil {
+		learnAlias(key, trackMeta, trackDurationMs, score)
+	}
+	return lyrics, trackDurationMs, score, trackMeta, err
+}
+
+// learnedAliasFits reports whether the requested duration (if any) is within the
+// duration filter of the learned track
+func learnedAliasFits(alias *LearnedAlias, durationMs int) bool {
+	if durationMs <= 0 || alias.DurationMs <= 0 {
+		return true
+	}
+	diff := alias.DurationMs - durationMs
+	return max(diff, -diff) <= conf().Configuration.DurationMatchDeltaMs
+}
+
+// learnedAliasLyrics returns the lyrics of a learned track, from the track cache
+// when another query already stored them
+func learnedAliasLyrics(alias *LearnedAlias) (string, error) {
+	if lyrics, ok := getTrackLyrics(alias.Track.TrackID); ok {
+		return lyrics, nil
+	}
+	return ttml.FetchLyricsByTrackID(alias.Track.TrackID)
+}
+
+// learnedAliasesHandler lists (GET) or prunes (DELETE) learned aliases.
+//
+// GET query params:
+//   - contains: Only queries containing this text
+//   - track_id: Only aliases to this Apple track ID
+//   - limit: Maximum aliases to return (default 100), most used first
+//
+// DELETE query params (one of):
+//   - key: Remove one alias (as listed by GET)
+//   - track_id: Remove every alias to a track, e.g. one that was learned wrongly
+//   - unused_days: Remove aliases not used for this many days
+func learnedAliasesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		pruneLearnedAliases(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	contains := strings.ToLower(query.Get("contains"))
+	trackID := query.Get("track_id")
+	limit := learnedAliasListLimit
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+
+	type entry struct {
+		Key string `json:"key"`
+		LearnedAlias
+	}
+	entries := []entry{}
+	total := 0
+	persistentCache.RangeBucket(learnedAliasesBucket, func(k, v []byte) bool {
+		total++
+		var alias LearnedAlias
+		if json.Unmarshal(v, &alias) != nil {
+			return true
+		}
+		if contains != "" && !strings.Contains(string(k), contains) {
+			return true
+		}
+		if trackID != "" && alias.Track.TrackID != trackID {
+			return true
+		}
+		entries = append(entries, entry{string(k), alias})
+		return true
+	})
+	matched := len(entries)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Hits > entries[j].Hits })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"aliases":   entries,
+		"count":     len(entries),
+		"matched":   matched,
+		"total":     total,
+		"min_score": learnedAliasMinScore(),
+	})
+}
+
+func pruneLearnedAliases(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := query.Get("key")
+	trackID := query.Get("track_id")
+	unusedDays, _ := strconv.Atoi(query.Get("unused_days"))
+	if key == "" && trackID == "" && unusedDays <= 0 {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "Pass key, track_id or unused_days",
+		})
+		return
+	}
+
+	var keys []string
+	if key != "" {
+		if _, ok := persistentCache.GetFromBucket(learnedAliasesBucket, key); !ok {
+			Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+				"error": "No learned alias for this key",
+				"key":   key,
+			})
+			return
+		}
+		keys = []string{key}
+	} else {
+		cutoff := time.Now().AddDate(0, 0, -unusedDays).Unix()
+		persistentCache.RangeBucket(learnedAliasesBucket, func(k, v []byte) bool {
+			var alias LearnedAlias
+			if json.Unmarshal(v, &alias) != nil {
+				keys = append(keys, string(k))
+				return true
+			}
+			if trackID != "" && alias.Track.TrackID != trackID {
+				return true
+			}
+			if unusedDays > 0 && max(alias.LastUsedAt, alias.CreatedAt) >= cutoff {
+				return true
+			}
+			keys = append(keys, string(k))
+			return true
+		})
+	}
+
+	deleted := 0
+	for _, k := range keys {
+		if err := persistentCache.DeleteFromBucket(learnedAliasesBucket, k); err != nil {
+			log.Warnf("%s Failed to delete learned alias %s: %v", logcolors.LogCacheClear, k, err)
+			continue
+		}
+		deleted++
+	}
+	log.Infof("%s Pruned %d learned aliases", logcolors.LogCacheClear, deleted)
+	Respond(w, r).JSON(map[string]interface{}{"deleted": deleted})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func setupLearnedAliases(t *testing.T) func() {
+	t.Helper()
+	cleanup := setupTestEnvironment(t)
+	initLearnedAliasesBucket()
+	orig := conf().Configuration.LearnedAliasMinScore
+	conf().Configuration.LearnedAliasMinScore = 0.9
+	return func() {
+		conf().Configuration.LearnedAliasMinScore = orig
+		cleanup()
+	}
+}
+
+func TestLearnAlias_ThresholdAndFirstWins(t *testing.T) {
+	cleanup := setupLearnedAliases(t)
+	defer cleanup()
+
+	key := learnedAliasKey("Hello", "Adele", "")
+	learnAlias(key, &ttml.TrackMeta{TrackID: "1"}, 295000, 0.7)
+	if _, ok := getLearnedAlias(key); ok {
+		t.Fatal("A match below LEARNED_ALIAS_MIN_SCORE must not be learned")
+	}
+
+	learnAlias(key, &ttml.TrackMeta{TrackID: "1", RawAttributes: "{}"}, 295000, 0.95)
+	learnAlias(key, &ttml.TrackMeta{TrackID: "2"}, 295000, 0.99)
+	alias, ok := getLearnedAlias(key)
+	if !ok || alias.Track.TrackID != "1" || alias.Track.RawAttributes != "" {
+		t.Errorf("Expected the first track without raw attributes, got %+v", alias)
+	}
+}
+
+func TestLearnAlias_RelearningKeepsHits(t *testing.T) {
+	cleanup := setupLearnedAliases(t)
+	defer cleanup()
+
+	key := learnedAliasKey("Hello", "Adele", "")
+	learnAlias(key, &ttml.TrackMeta{TrackID: "1"}, 295000, 0.92)
+	created, _ := getLearnedAlias(key)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recordLearnedAliasHit(key, "1", 1000)
+		}()
+	}
+	wg.Wait()
+	recordLearnedAliasHit(key, "2", 2000) // Another track's hit is dropped
+
+	learnAlias(key, &ttml.TrackMeta{TrackID: "1", Name: "Hello"}, 295500, 0.97)
+	alias, _ := getLearnedAlias(key)
+	if alias.Hits != 20 || alias.LastUsedAt != 1000 || alias.CreatedAt != created.CreatedAt {
+		t.Errorf("Expected 20 hits and the creation time kept, got %+v", alias)
+	}
+	if alias.Score != 0.97 || alias.Track.Name != "Hello" {
+		t.Errorf("Expected the relearned match to refresh the alias, got %+v", alias)
+	}
+}
+
+func TestFetchTTMLWithLearnedAliases_SkipsSearch(t *testing.T) {
+	cleanup := setupLearnedAliases(t)
+	defer cleanup()
+
+	setCachedLyricsForTrack(buildNormalizedCacheKey("Hello", "Adele", "", "295"), "1051394215", formatTestTTML, 295000, 0.95, "", false)
+	key := learnedAliasKey("hello ", "ADELE", "")
+	learnAlias(key, &ttml.TrackMeta{TrackID: "1051394215", Name: "Hello"}, 295000, 0.95)
+
+	// No accounts are configured, so anything reaching the search fails
+	lyrics, durationMs, _, meta, err := fetchTTMLWithLearnedAliases("Hello", "Adele", "", 296000, true, true, ttml.MatchHints{})
+	if err != nil || lyrics != formatTestTTML || durationMs != 295000 || meta.TrackID != "1051394215" {
+		t.Fatalf("Expected the learned track's lyrics, got %q, %d, %+v, %v", lyrics, durationMs, meta, err)
+	}
+	waitForPendingWrites(t)
+	if alias, _ := getLearnedAlias(key); alias.Hits != 1 || alias.LastUsedAt == 0 {
+		t.Errorf("Use should be recorded, got %+v", alias)
+	}
+
+	if _, _, _, _, err := fetchTTMLWithLearnedAliases("Hello", "Adele", "", 360000, true, true, ttml.MatchHints{}); err == nil {
+		t.Error("A duration outside the filter must not use the alias")
+	}
+	if _, _, _, _, err := fetchTTMLWithLearnedAliases("Hello", "Adele", "", 0, false, false, ttml.MatchHints{}); err == nil {
+		t.Error("explicit= overrides must not use the alias")
+	}
+}
+
+func TestLearnedAliasesHandler(t *testing.T) {
+	cleanup := setupLearnedAliases(t)
+	defer cleanup()
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
+
+	learnAlias(learnedAliasKey("Hello", "Adele", ""), &ttml.TrackMeta{TrackID: "1"}, 0, 0.95)
+	learnAlias(learnedAliasKey("Hello", "Lionel Richie", ""), &ttml.TrackMeta{TrackID: "2"}, 0, 0.95)
+	learnAlias(learnedAliasKey("Rolling in the Deep", "Adele", ""), &ttml.TrackMeta{TrackID: "2"}, 0, 0.95)
+
+	serve := func(method, target string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, target, nil)
+		r.Header.Set("Authorization", "test-token")
+		learnedAliasesHandler(w, r)
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	if code, body := serve(http.MethodGet, "/cache/learned?contains=adele"); code != http.StatusOK || body["matched"] != float64(2) || body["total"] != float64(3) {
+		t.Errorf("list: status %d, body %v", code, body)
+	}
+	if code, _ := serve(http.MethodDelete, "/cache/learned"); code != http.StatusBadRequest {
+		t.Errorf("prune without filter: status %d", code)
+	}
+	if code, body := serve(http.MethodDelete, "/cache/learned?track_id=2"); code != http.StatusOK || body["deleted"] != float64(2) {
+		t.Errorf("prune by track: status %d, body %v", code, body)
+	}
+	if code, body := serve(http.MethodDelete, "/cache/learned?unused_days=1"); code != http.StatusOK || body["deleted"] != float64(0) {
+		t.Errorf("Fresh aliases must survive unused_days, status %d, body %v", code, body)
+	}
+	if code, _ := serve(http.MethodDelete, "/cache/learned?key="+url.QueryEscape(learnedAliasKey("Hello", "Adele", ""))); code != http.StatusOK {
+		t.Errorf("prune by key: status %d", code)
+	}
+	if _, body := serve(http.MethodGet, "/cache/learned"); body["total"] != float64(0) {
+		t.Errorf("Expected no aliases left, got %v", body)
+	}
+}
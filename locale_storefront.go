@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Without a storefront from the client, the one its Accept-Language points to is
+// preferred: a lookup then runs on an account in that storefront when one is
+// available, so regional catalogs match without client changes.
+
+// languageStorefronts maps a language without a region to the storefront of its
+// main market. Languages spoken across many markets (en) are left out.
+var languageStorefronts = map[string]string{
+	"ar": "sa", "cs": "cz", "da": "dk", "de": "de", "el": "gr", "es": "es",
+	"fi": "fi", "fil": "ph", "fr": "fr", "he": "il", "hi": "in", "hu": "hu",
+	"id": "id", "it": "it", "ja": "jp", "ko": "kr", "ms": "my", "nb": "no",
+	"nl": "nl", "nn": "no", "no": "no", "pl": "pl", "pt": "br", "ro": "ro",
+	"ru": "ru", "sv": "se", "ta": "in", "te": "in", "th": "th", "tl": "ph",
+	"tr": "tr", "uk": "ua", "vi": "vn", "zh": "cn",
+}
+
+// storefrontFromAcceptLanguage returns the storefront for the most preferred
+// language of an Accept-Language header that maps to one: the tag's region
+// (de-AT -> at), or else languageStorefronts. Empty when nothing maps.
+func storefrontFromAcceptLanguage(header string) string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag == "" || tag == "*" || q <= 0 {
+			continue
+		}
+		languages = append(languages, language{tag: strings.ToLower(tag), q: q})
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].q > languages[j].q })
+
+	for _, lang := range languages {
+		subtags := strings.FieldsFunc(lang.tag, func(r rune) bool { return r == '-' || r == '_' })
+		if len(subtags) == 0 {
+			continue
+		}
+		// The region is the first two-letter subtag after the language (zh-hant-tw -> tw)
+		for _, subtag := range subtags[1:] {
+			if len(subtag) == 2 && isASCIILetters(subtag) {
+				return subtag
+			}
+		}
+		if storefront, ok := languageStorefronts[subtags[0]]; ok {
+			return storefront
+		}
+	}
+	return ""
+}
+
+// isASCIILetters reports whether s is only a-z (s is lowercased)
+func isASCIILetters(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// preferredStorefront returns the storefront a request's lookup should prefer, or
+// empty when LOCALE_STOREFRONTS is off or its Accept-Language doesn't map to one
+func preferredStorefront(r *http.Request) string {
+	if !conf().Configuration.LocaleStorefronts {
+		return ""
+	}
+	return storefrontFromAcceptLanguage(r.Header.Get("Accept-Language"))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStorefrontFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"en-US,en;q=0.9", "us"},
+		{"de-AT", "at"},
+		{"ja", "jp"},
+		{"en;q=0.8, ko;q=0.9", "kr"},
+		{"zh-Hant-TW", "tw"},
+		{"zh-Hans", "cn"},
+		{"pt_BR", "br"},
+		{"en, fr;q=0.5", "fr"},
+		{"es-419", "es"},
+		{"en, *;q=0.1", ""},
+		{"ja;q=0, it", "it"},
+		{"ja;q=abc", ""},
+	}
+	for _, tt := range tests {
+		if got := storefrontFromAcceptLanguage(tt.header); got != tt.want {
+			t.Errorf("storefrontFromAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestMatchHintsFrom_PrefersLocaleStorefront(t *testing.T) {
+	original := conf().Configuration.LocaleStorefronts
+	defer func() { conf().Configuration.LocaleStorefronts = original }()
+	conf().Configuration.LocaleStorefronts = true
+
+	r := httptest.NewRequest("GET", "/getLyrics?s=x&a=y", nil)
+	r.Header.Set("Accept-Language", "ja-JP,ja;q=0.9")
+	if got := matchHintsFrom(r).Storefront; got != "jp" {
+		t.Errorf("Storefront = %q, want jp", got)
+	}
+
+	conf().Configuration.LocaleStorefronts = false
+	if got := matchHintsFrom(r).Storefront; got != "" {
+		t.Errorf("Storefront = %q with LOCALE_STOREFRONTS off, want none", got)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/logging"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// logLevelHandler reports (GET) or changes (PUT) log levels at runtime. Changes
+// last until restart; LOG_LEVEL sets the startup levels.
+//
+// Query params (PUT):
+//   - level: trace, debug, info, warn or error; "reset" drops a component override
+//   - component: parser, http, cache, lyrics or ttml (omit to change the global level)
+func logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		component := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("component")))
+		levelName := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("level")))
+		if levelName == "" {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": "level parameter is required",
+			})
+			return
+		}
+
+		if levelName == "reset" && component == "" {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": "level=reset requires a component",
+			})
+			return
+		}
+
+		var err error
+		if levelName == "reset" {
+			err = logging.ResetLevel(component)
+		} else {
+			var level log.Level
+			if level, err = logging.ParseLevel(levelName); err == nil {
+				err = logging.SetLevel(component, level)
+			}
+		}
+		if err != nil {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error":      err.Error(),
+				"components": logging.Components,
+			})
+			return
+		}
+
+		target := component
+		if target == "" {
+			target = "global"
+		}
+		log.Infof("%s Log level for %s set to %s", logcolors.LogConfig, target, levelName)
+	}
+
+	Respond(w, r).JSON(logging.Levels())
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/logging"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestLogLevelHandler_Unauthorized(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	rr := httptest.NewRecorder()
+	logLevelHandler(rr, httptest.NewRequest("PUT", "/log-level?level=debug", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", rr.Code)
+	}
+}
+
+func TestLogLevelHandler_SetAndResetComponent(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+	defer logging.ResetLevel(logging.ComponentParser)
+
+	do := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/log-level?"+query, nil)
+		req.Header.Set("Authorization", "test-token")
+		rr := httptest.NewRecorder()
+		logLevelHandler(rr, req)
+		return rr
+	}
+
+	rr := do("level=trace&component=parser")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Components map[string]struct {
+			Level      string `json:"level"`
+			Overridden bool   `json:"overridden"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if parser := body.Components["parser"]; parser.Level != "trace" || !parser.Overridden {
+		t.Errorf("Expected parser overridden to trace, got %+v", parser)
+	}
+	if !logging.Enabled(logging.ComponentParser, log.TraceLevel) {
+		t.Error("Expected parser logger at trace")
+	}
+
+	if rr := do("level=reset&component=parser"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 on reset, got %d", rr.Code)
+	}
+	if logging.Enabled(logging.ComponentParser, log.TraceLevel) != log.IsLevelEnabled(log.TraceLevel) {
+		t.Error("Expected parser to follow the global level after reset")
+	}
+
+	for _, query := range []string{"", "level=loud", "level=debug&component=nope", "level=reset"} {
+		if rr := do(query); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %q, got %d", query, rr.Code)
+		}
+	}
+}
@@ -37,6 +37,7 @@ const (
 const (
 	LogRateLimit = Purple + "[RateLimit]" + Reset
 	LogAPIKey    = Purple + "[APIKey]" + Reset
+	LogPriority  = Purple + "[Priority]" + Reset
 )
 
 // CircuitBreakerPrefix returns a colored circuit breaker prefix with the given name
@@ -70,9 +71,12 @@ const (
 
 // Server/Init log prefixes
 const (
-	LogServer = Green + "[Server]" + Reset
-	LogConfig = Cyan + "[Config]" + Reset
-	LogStats  = Blue + "[Stats]" + Reset
+	LogServer      = Green + "[Server]" + Reset
+	LogConfig      = Cyan + "[Config]" + Reset
+	LogStats       = Blue + "[Stats]" + Reset
+	LogAudit       = BrightMagenta + "[Audit]" + Reset
+	LogJobs        = Blue + "[Jobs]" + Reset
+	LogIdempotency = BrightMagenta + "[Idempotency]" + Reset
 )
 
 // Notification log prefixes
@@ -0,0 +1,173 @@
+// Package logging manages the global log level and per-component overrides, and
+// throttles hot-path messages per component (see Throttled).
+//
+// Components get their own logrus.Logger that writes through the standard logger's
+// output and formatter, so FF_PRETTY_LOGS and test output capture apply to them
+// too. A component without an override follows the global level.
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Components that can be tuned independently of the global level
+const (
+	ComponentParser = "parser" // TTML parsing
+	ComponentHTTP   = "http"   // Per-request access log
+	ComponentCache  = "cache"  // Persistent cache
+	ComponentLyrics = "lyrics" // /getLyrics cache hits and misses
+	ComponentTTML   = "ttml"   // TTML API search and request flow
+)
+
+// Components lists every tunable component
+var Components = []string{ComponentParser, ComponentHTTP, ComponentCache, ComponentLyrics, ComponentTTML}
+
+var (
+	loggers   = make(map[string]*log.Logger)
+	overrides = make(map[string]log.Level)
+	mu        sync.Mutex
+)
+
+// stdWriter and stdFormatter delegate to the standard logger at write time, so
+// loggers created during package init pick up the formatter configured in main.
+type stdWriter struct{}
+
+func (stdWriter) Write(p []byte) (int, error) { return log.StandardLogger().Out.Write(p) }
+
+type stdFormatter struct{}
+
+func (stdFormatter) Format(entry *log.Entry) ([]byte, error) {
+	return log.StandardLogger().Formatter.Format(entry)
+}
+
+// For returns the logger for a component, creating it on first use
+func For(component string) *log.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	if l, ok := loggers[component]; ok {
+		return l
+	}
+	l := &log.Logger{
+		Out:       stdWriter{},
+		Formatter: stdFormatter{},
+		Hooks:     make(log.LevelHooks),
+		Level:     levelFor(component),
+		ExitFunc:  log.StandardLogger().ExitFunc,
+	}
+	loggers[component] = l
+	return l
+}
+
+// ParseLevel parses a level name (trace, debug, info, warn, error)
+func ParseLevel(name string) (log.Level, error) {
+	level, err := log.ParseLevel(name)
+	if err != nil {
+		return 0, fmt.Errorf("invalid log level %q", name)
+	}
+	return level, nil
+}
+
+// SetLevel sets the global level (component "") or a component override
+func SetLevel(component string, level log.Level) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if component == "" {
+		log.SetLevel(level)
+	} else {
+		if !isComponent(component) {
+			return fmt.Errorf("unknown component %q", component)
+		}
+		overrides[component] = level
+	}
+	syncLevels()
+	return nil
+}
+
+// ResetLevel drops a component override so it follows the global level again
+func ResetLevel(component string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if !isComponent(component) {
+		return fmt.Errorf("unknown component %q", component)
+	}
+	delete(overrides, component)
+	syncLevels()
+	return nil
+}
+
+// Enabled reports whether a component logs at level. For output that doesn't go
+// through logrus (e.g. the access log).
+func Enabled(component string, level log.Level) bool {
+	return For(component).IsLevelEnabled(level)
+}
+
+// Levels returns the global level and the effective level of each component
+func Levels() map[string]interface{} {
+	mu.Lock()
+	defer mu.Unlock()
+	components := make(map[string]interface{}, len(Components))
+	for _, name := range Components {
+		_, overridden := overrides[name]
+		components[name] = map[string]interface{}{
+			"level":      levelFor(name).String(),
+			"overridden": overridden,
+		}
+	}
+	return map[string]interface{}{
+		"global":     log.GetLevel().String(),
+		"components": components,
+	}
+}
+
+// levelFor returns the effective level for a component.
+// REQUIRES: caller holds mu.
+func levelFor(component string) log.Level {
+	if level, ok := overrides[component]; ok {
+		return level
+	}
+	return log.GetLevel()
+}
+
+// syncLevels pushes effective levels to every component logger.
+// REQUIRES: caller holds mu.
+func syncLevels() {
+	for name, l := range loggers {
+		l.SetLevel(levelFor(name))
+	}
+}
+
+func isComponent(name string) bool {
+	for _, c := range Components {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Configure applies a level spec such as "info" or "info,parser=debug,http=warn":
+// a bare level sets the global level, component=level sets an override
+func Configure(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		component, name, found := strings.Cut(part, "=")
+		if !found {
+			component, name = "", part
+		}
+		level, err := ParseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		if err := SetLevel(strings.TrimSpace(component), level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// resetLevels restores the global level and drops overrides after a test
+func resetLevels(t *testing.T) {
+	original := log.GetLevel()
+	t.Cleanup(func() {
+		for _, c := range Components {
+			ResetLevel(c)
+		}
+		SetLevel("", original)
+	})
+}
+
+func TestComponentFollowsGlobalUntilOverridden(t *testing.T) {
+	resetLevels(t)
+	parser := For(ComponentParser)
+
+	SetLevel("", log.WarnLevel)
+	if parser.IsLevelEnabled(log.InfoLevel) {
+		t.Fatal("expected parser to follow the global warn level")
+	}
+
+	SetLevel(ComponentParser, log.DebugLevel)
+	if !parser.IsLevelEnabled(log.DebugLevel) {
+		t.Fatal("expected parser override to enable debug")
+	}
+	if For(ComponentCache).IsLevelEnabled(log.InfoLevel) {
+		t.Error("expected cache to stay at the global level")
+	}
+
+	ResetLevel(ComponentParser)
+	if parser.IsLevelEnabled(log.InfoLevel) {
+		t.Error("expected parser to follow the global level after reset")
+	}
+}
+
+func TestComponentWritesThroughStandardLogger(t *testing.T) {
+	resetLevels(t)
+	var buf bytes.Buffer
+	original := log.StandardLogger().Out
+	log.SetOutput(&buf)
+	defer log.SetOutput(original)
+
+	SetLevel(ComponentCache, log.DebugLevel)
+	For(ComponentCache).Debugf("hello from cache")
+	if !strings.Contains(buf.String(), "hello from cache") {
+		t.Errorf("expected component output on the standard logger, got %q", buf.String())
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	resetLevels(t)
+	if err := Configure("warn, parser=debug"); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	if log.GetLevel() != log.WarnLevel {
+		t.Errorf("expected global warn, got %s", log.GetLevel())
+	}
+	if !Enabled(ComponentParser, log.DebugLevel) {
+		t.Error("expected parser debug override")
+	}
+
+	for _, spec := range []string{"loud", "parser=loud", "nope=debug"} {
+		if err := Configure(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
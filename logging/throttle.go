@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"fmt"
+	"lyrics-api-go/clock"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Throttler rate-limits hot-path messages: each message key logs at most N times per
+// second, and the next line that gets through notes how many were suppressed since.
+// Limits are per component (LOG_THROTTLE); a limit of 0 disables throttling.
+type Throttler struct {
+	component string
+	mu        sync.Mutex
+	keys      map[string]*throttleWindow
+}
+
+type throttleWindow struct {
+	start      time.Time
+	logged     int
+	suppressed int
+}
+
+var (
+	throttlers     = make(map[string]*Throttler)
+	throttleLimits = make(map[string]int)
+	defaultLimit   = 0
+	throttleMu     sync.Mutex
+
+	// throttleClock is swapped for a fake in tests
+	throttleClock clock.Clock = clock.Real{}
+)
+
+// Throttled returns the throttler for a component, creating it on first use
+func Throttled(component string) *Throttler {
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+	if t, ok := throttlers[component]; ok {
+		return t
+	}
+	t := &Throttler{component: component, keys: make(map[string]*throttleWindow)}
+	throttlers[component] = t
+	return t
+}
+
+// ConfigureThrottle applies a spec such as "5" or "5,lyrics=20,ttml=0": a bare number
+// is the per-second limit for every component, component=N overrides one
+func ConfigureThrottle(spec string) error {
+	limits := make(map[string]int)
+	fallback := 0
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		component, value, found := strings.Cut(part, "=")
+		if !found {
+			component, value = "", part
+		}
+		component = strings.TrimSpace(component)
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			return fmt.Errorf("invalid throttle limit %q", part)
+		}
+		if component == "" {
+			fallback = limit
+			continue
+		}
+		if !isComponent(component) {
+			return fmt.Errorf("unknown component %q", component)
+		}
+		limits[component] = limit
+	}
+
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+	throttleLimits = limits
+	defaultLimit = fallback
+	return nil
+}
+
+// limit returns the per-second limit for the throttler's component (0 = unlimited)
+func (t *Throttler) limit() int {
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+	if limit, ok := throttleLimits[t.component]; ok {
+		return limit
+	}
+	return defaultLimit
+}
+
+// allow reports whether a message under key may be logged now, and how many were
+// suppressed since the last one that was
+func (t *Throttler) allow(key string) (bool, int) {
+	limit := t.limit()
+	if limit <= 0 {
+		return true, 0
+	}
+	now := throttleClock.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.keys[key]
+	if !ok || now.Sub(w.start) >= time.Second {
+		suppressed := 0
+		if ok {
+			suppressed = w.suppressed
+		}
+		t.keys[key] = &throttleWindow{start: now, logged: 1}
+		return true, suppressed
+	}
+	if w.logged < limit {
+		w.logged++
+		return true, 0
+	}
+	w.suppressed++
+	return false, 0
+}
+
+// Infof logs at info level on the component's logger, subject to the key's limit
+func (t *Throttler) Infof(key, format string, args ...interface{}) {
+	t.logf(log.InfoLevel, key, format, args...)
+}
+
+// Warnf logs at warn level on the component's logger, subject to the key's limit
+func (t *Throttler) Warnf(key, format string, args ...interface{}) {
+	t.logf(log.WarnLevel, key, format, args...)
+}
+
+func (t *Throttler) logf(level log.Level, key, format string, args ...interface{}) {
+	logger := For(t.component)
+	if !logger.IsLevelEnabled(level) {
+		return
+	}
+	ok, suppressed := t.allow(key)
+	if !ok {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if suppressed > 0 {
+		msg += fmt.Sprintf(" (%d similar suppressed)", suppressed)
+	}
+	logger.Log(level, msg)
+}
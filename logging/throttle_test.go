@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"lyrics-api-go/clock"
+	"lyrics-api-go/internal/clocktest"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestThrottler_LimitsPerKeyAndReportsSuppressed(t *testing.T) {
+	resetLevels(t)
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	throttleClock = fake
+	defer func() { throttleClock = clock.Real{} }()
+	if err := ConfigureThrottle("2"); err != nil {
+		t.Fatal(err)
+	}
+	defer ConfigureThrottle("")
+
+	var buf bytes.Buffer
+	original := log.StandardLogger().Out
+	log.SetOutput(&buf)
+	defer log.SetOutput(original)
+	SetLevel("", log.InfoLevel)
+
+	throttled := Throttled(ComponentLyrics)
+	for i := 0; i < 5; i++ {
+		throttled.Infof("hit", "cache hit %d", i)
+	}
+	throttled.Infof("miss", "cache miss")
+	if got := strings.Count(buf.String(), "cache hit"); got != 2 {
+		t.Errorf("Expected 2 hit lines in the first second, got %d:\n%s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "cache miss") {
+		t.Error("Other keys have their own limit")
+	}
+
+	fake.Advance(time.Second)
+	throttled.Infof("hit", "cache hit again")
+	if !strings.Contains(buf.String(), "cache hit again (3 similar suppressed)") {
+		t.Errorf("Expected a suppressed-count summary, got:\n%s", buf.String())
+	}
+}
+
+func TestConfigureThrottle(t *testing.T) {
+	defer ConfigureThrottle("")
+
+	if err := ConfigureThrottle("5, ttml=0, lyrics=20"); err != nil {
+		t.Fatal(err)
+	}
+	if got := Throttled(ComponentTTML).limit(); got != 0 {
+		t.Errorf("ttml limit = %d, want 0", got)
+	}
+	if got := Throttled(ComponentLyrics).limit(); got != 20 {
+		t.Errorf("lyrics limit = %d, want 20", got)
+	}
+	if got := Throttled(ComponentCache).limit(); got != 5 {
+		t.Errorf("cache limit = %d, want the default 5", got)
+	}
+
+	for _, spec := range []string{"fast", "lyrics=-1", "nope=3"} {
+		if err := ConfigureThrottle(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
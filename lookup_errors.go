@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/redact"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// /errors lists the failed lookups captured for /debug/replay (see replay.go) by
+// error class, with the account and circuit breaker state at the time, so a wave
+// of failures can be triaged without grepping the JSON logs. With
+// PERSIST_FAILED_LOOKUPS=true the buffer is kept in the cache DB across restarts.
+
+// failedLookupsBucket holds the persisted failed lookups, keyed by FailedLookup.Key
+const failedLookupsBucket = "failed_lookups"
+
+// Error classes of a failed lookup
+const (
+	errorClassNotFound    = "not_found"    // No match, or the track has no lyrics (negatively cached)
+	errorClassNoAccounts  = "no_accounts"  // No TTML accounts configured
+	errorClassCircuitOpen = "circuit_open" // The circuit breaker held the request back
+	errorClassRateLimited = "rate_limited" // Upstream answered 429
+	errorClassAuth        = "auth"         // Bearer token, media user token or a 401/403
+	errorClassSchemaDrift = "schema_drift" // Upstream response we couldn't read
+	errorClassTimeout     = "timeout"      // Upstream request timed out
+	errorClassUpstream    = "upstream"     // Any other upstream error
+)
+
+var errorClasses = []string{
+	errorClassNotFound, errorClassNoAccounts, errorClassCircuitOpen, errorClassRateLimited,
+	errorClassAuth, errorClassSchemaDrift, errorClassTimeout, errorClassUpstream,
+}
+
+// errorClass sorts a lookup error into one of errorClasses
+func errorClass(err error) string {
+	errStr := err.Error()
+	switch {
+	case strings.Contains(errStr, "no TTML accounts configured"):
+		return errorClassNoAccounts
+	case strings.Contains(errStr, "circuit breaker is"):
+		return errorClassCircuitOpen
+	case strings.Contains(errStr, ttml.SchemaDriftPrefix):
+		return errorClassSchemaDrift
+	case shouldNegativeCache(err), strings.Contains(errStr, "lyrics not available"):
+		return errorClassNotFound
+	case strings.Contains(errStr, "status 429"):
+		return errorClassRateLimited
+	case strings.Contains(errStr, "bearer token"), strings.Contains(errStr, "media user token"),
+		strings.Contains(errStr, "status 401"), strings.Contains(errStr, "status 403"):
+		return errorClassAuth
+	case errors.Is(err, context.DeadlineExceeded), strings.Contains(errStr, "deadline exceeded"),
+		strings.Contains(errStr, "Timeout"), strings.Contains(errStr, "timed out"):
+		return errorClassTimeout
+	}
+	return errorClassUpstream
+}
+
+// initFailedLookupsBucket loads the persisted failed lookups into the buffer,
+// dropping those beyond REPLAY_CAPTURE_SIZE. Called during server startup after
+// persistentCache is initialized.
+func initFailedLookupsBucket() {
+	if !conf().Configuration.PersistFailedLookups || conf().Configuration.ReplayCaptureSize <= 0 {
+		return
+	}
+	if err := persistentCache.CreateBucket(failedLookupsBucket); err != nil {
+		log.Errorf("%s Failed to create failed lookups bucket: %v", logcolors.LogCache, err)
+		return
+	}
+
+	var lookups []FailedLookup
+	var stale []string
+	persistentCache.RangeBucket(failedLookupsBucket, func(k, v []byte) bool {
+		var lookup FailedLookup
+		if json.Unmarshal(v, &lookup) != nil {
+			stale = append(stale, string(k))
+		} else {
+			lookups = append(lookups, redactFailedLookup(lookup))
+		}
+		return true
+	})
+	sort.Slice(lookups, func(i, j int) bool { return lookups[i].CapturedAt.Before(lookups[j].CapturedAt) })
+	if over := len(lookups) - conf().Configuration.ReplayCaptureSize; over > 0 {
+		for _, lookup := range lookups[:over] {
+			stale = append(stale, lookup.Key)
+		}
+		lookups = lookups[over:]
+	}
+	for _, key := range stale {
+		persistentCache.DeleteFromBucket(failedLookupsBucket, key)
+	}
+
+	failedLookups.mu.Lock()
+	failedLookups.lookups = append(lookups, failedLookups.lookups...)
+	failedLookups.mu.Unlock()
+	if len(lookups) > 0 {
+		log.Infof("%s %d failed lookups carried over from the last run", logcolors.LogCache, len(lookups))
+	}
+}
+
+// redactFailedLookup masks secrets in a failed lookup's error and trace, which
+// carry upstream error text that can echo tokens back
+func redactFailedLookup(lookup FailedLookup) FailedLookup {
+	lookup.Error = redact.String(lookup.Error)
+	if lookup.Trace != nil {
+		steps := make([]ttml.TraceStep, len(lookup.Trace))
+		for i, step := range lookup.Trace {
+			step.Detail = redact.String(step.Detail)
+			steps[i] = step
+		}
+		lookup.Trace = steps
+	}
+	return lookup
+}
+
+// persist stores a new failed lookup (redacted on capture) and deletes the ones it
+// pushed out of the buffer. It runs in the background (goWrite) when
+// PERSIST_FAILED_LOOKUPS is on; a lookup that a later capture already dropped
+// isn't written, so it can't outlive its delete.
+func (f *failedLookupRing) persist(lookup FailedLookup, dropped []FailedLookup) {
+	f.persistMu.Lock()
+	defer f.persistMu.Unlock()
+	for _, old := range dropped {
+		persistentCache.DeleteFromBucket(failedLookupsBucket, old.Key)
+	}
+	if _, ok := f.get(lookup.Key); !ok {
+		return
+	}
+	data, err := json.Marshal(lookup)
+	if err != nil {
+		return
+	}
+	if err := persistentCache.SetInBucket(failedLookupsBucket, lookup.Key, data); err != nil {
+		log.Debugf("%s Failed to persist failed lookup %s: %v", logcolors.LogCache, lookup.Key, err)
+	}
+}
+
+// errorsHandler lists the captured failed lookups, newest first, with a count per
+// error class. Traces are left out unless asked for; /debug/replay?key=... has them.
+//
+// Query params:
+//   - class: Only these error classes, comma-separated (optional)
+//   - account: Only lookups that used this account (optional)
+//   - limit: Maximum entries to return (default: all)
+//   - trace: "true" to include each lookup's trace
+func errorsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	classes := map[string]bool{}
+	if param := query.Get("class"); param != "" {
+		for _, class := range strings.Split(param, ",") {
+			class = strings.TrimSpace(class)
+			if !slices.Contains(errorClasses, class) {
+				Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+					"error":   "Unknown error class: " + class,
+					"classes": errorClasses,
+				})
+				return
+			}
+			classes[class] = true
+		}
+	}
+	limit := 0
+	if param := query.Get("limit"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 1 {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": "limit must be a positive integer",
+			})
+			return
+		}
+		limit = n
+	}
+	account := query.Get("account")
+	withTrace := query.Get("trace") == "true"
+
+	byClass := map[string]int{}
+	matched := []FailedLookup{}
+	for _, lookup := range failedLookups.list() {
+		byClass[lookup.Class]++
+		if len(classes) > 0 && !classes[lookup.Class] {
+			continue
+		}
+		if account != "" && lookup.Account != account {
+			continue
+		}
+		if limit > 0 && len(matched) >= limit {
+			continue
+		}
+		if !withTrace {
+			lookup.Trace = nil
+		}
+		matched = append(matched, lookup)
+	}
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"capture_size": conf().Configuration.ReplayCaptureSize,
+		"persisted":    conf().Configuration.PersistFailedLookups,
+		"by_class":     byClass,
+		"count":        len(matched),
+		"errors":       matched,
+	})
+}
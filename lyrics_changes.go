@@ -0,0 +1,288 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/notifier"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Track lyrics blobs carry a hash of their TTML. When a refresh or re-fetch stores
+// different TTML for a track that was already cached, the change is recorded with
+// a diff summary (GET /cache/lyrics-changes), the lyricsRevision of every query key
+// pointing at the track is bumped, and with NOTIFY_LYRICS_CHANGES a lyrics_changed
+// event is sent. This shows how often upstream corrects lyrics, and so whether
+// refreshing cached lyrics is worth its upstream calls.
+
+// lyricsChangesBucket holds the recorded changes, keyed so they sort by time
+const lyricsChangesBucket = "lyrics_changes"
+
+// maxLyricsChanges bounds the recorded changes; the oldest are dropped first
+const maxLyricsChanges = 5000
+
+// LyricsDiff summarizes how a track's lyrics changed
+type LyricsDiff struct {
+	LinesBefore   int    `json:"lines_before"`
+	LinesAfter    int    `json:"lines_after"`
+	LinesAdded    int    `json:"lines_added"`
+	LinesRemoved  int    `json:"lines_removed"`
+	TimingChanged bool   `json:"timing_changed"` // Unchanged lines start or end at different times
+	TimingBefore  string `json:"timing_before,omitempty"`
+	TimingAfter   string `json:"timing_after,omitempty"`
+	FirstChange   string `json:"first_change,omitempty"`
+}
+
+// String is the one-line summary used in logs and notifications
+func (d LyricsDiff) String() string {
+	parts := []string{fmt.Sprintf("%d -> %d lines", d.LinesBefore, d.LinesAfter)}
+	if d.LinesAdded > 0 || d.LinesRemoved > 0 {
+		parts = append(parts, fmt.Sprintf("+%d/-%d lines", d.LinesAdded, d.LinesRemoved))
+	}
+	if d.TimingBefore != d.TimingAfter {
+		parts = append(parts, fmt.Sprintf("timing %s -> %s", d.TimingBefore, d.TimingAfter))
+	} else if d.TimingChanged {
+		parts = append(parts, "timing changed")
+	}
+	if d.LinesAdded == 0 && d.LinesRemoved == 0 && !d.TimingChanged && d.TimingBefore == d.TimingAfter {
+		parts = append(parts, "markup only")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LyricsChange is one recorded change of a track's lyrics
+type LyricsChange struct {
+	ID        string     `json:"id"`
+	TrackID   string     `json:"track_id"`
+	CacheKey  string     `json:"cache_key"` // The query key whose fetch found the change
+	ChangedAt time.Time  `json:"changed_at"`
+	OldHash   string     `json:"old_hash"`
+	NewHash   string     `json:"new_hash"`
+	Revision  int        `json:"revision"`
+	Diff      LyricsDiff `json:"diff"`
+}
+
+// lyricsContentHash is the hash stored with a track's lyrics blob
+func lyricsContentHash(lyrics string) string {
+	sum := sha256.Sum256([]byte(lyrics))
+	return hex.EncodeToString(sum[:])
+}
+
+// initLyricsChangesBucket creates the lyrics changes bucket. Called during server
+// startup after persistentCache is initialized.
+func initLyricsChangesBucket() {
+	if err := persistentCache.CreateBucket(lyricsChangesBucket); err != nil {
+		log.Errorf("%s Failed to create lyrics changes bucket: %v", logcolors.LogCacheLyrics, err)
+	}
+}
+
+// previousTrackLyrics returns the TTML and hash of a track blob's entry as it was
+// stored before being overwritten; ok is false when nothing usable was stored
+func previousTrackLyrics(previous cache.CacheEntry) (lyrics, hash string, ok bool) {
+	if !previous.Verify() {
+		return "", "", false
+	}
+	raw, err := previous.Decode()
+	if err != nil {
+		return "", "", false
+	}
+	var cached CachedLyrics
+	if err := json.Unmarshal([]byte(raw), &cached); err != nil || cached.TTML == "" || cached.TTML == NoLyricsSentinel {
+		return "", "", false
+	}
+	if cached.ContentHash == "" {
+		// Stored before hashes were
+		cached.ContentHash = lyricsContentHash(cached.TTML)
+	}
+	return cached.TTML, cached.ContentHash, true
+}
+
+// recordLyricsChange compares a track's previous blob with the lyrics that replaced
+// it and, when they differ, records the change, bumps the revision of the query
+// keys that point at the track and notifies. It scans the metadata bucket, so it
+// runs in the background (goWrite), off the lookup's response path.
+func recordLyricsChange(trackID, cacheKey string, previous cache.CacheEntry, newLyrics, newHash string) {
+	oldLyrics, oldHash, ok := previousTrackLyrics(previous)
+	if !ok || oldHash == newHash {
+		return
+	}
+
+	diff := diffLyrics(oldLyrics, newLyrics)
+	keys := queryKeysForTrack(trackID)
+	if !slices.Contains(keys, cacheKey) {
+		keys = append(keys, cacheKey)
+	}
+	revision := bumpLyricsRevision(keys)
+
+	now := time.Now()
+	change := LyricsChange{
+		ID:        fmt.Sprintf("%020d-%s", now.UnixNano(), trackID),
+		TrackID:   trackID,
+		CacheKey:  cacheKey,
+		ChangedAt: now,
+		OldHash:   oldHash,
+		NewHash:   newHash,
+		Revision:  revision,
+		Diff:      diff,
+	}
+	log.Infof("%s Lyrics of track %s changed upstream (revision %d): %s", logcolors.LogCacheLyrics, trackID, revision, diff)
+
+	if data, err := json.Marshal(change); err == nil {
+		if err := persistentCache.SetInBucket(lyricsChangesBucket, change.ID, data); err != nil {
+			log.Warnf("%s Failed to record lyrics change for track %s: %v", logcolors.LogCacheLyrics, trackID, err)
+		}
+		pruneLyricsChanges()
+	}
+
+	if conf().Configuration.NotifyLyricsChanges {
+		notifier.PublishLyricsChanged(trackID, cacheKey, revision, diff.String())
+	}
+}
+
+// pruneLyricsChanges drops the oldest changes beyond maxLyricsChanges (IDs sort by time)
+func pruneLyricsChanges() {
+	if _, err := persistentCache.TrimBucket(lyricsChangesBucket, maxLyricsChanges); err != nil {
+		log.Warnf("%s Failed to prune lyrics changes: %v", logcolors.LogCacheLyrics, err)
+	}
+}
+
+// bumpLyricsRevision increments the lyricsRevision of each key's metadata and
+// returns the highest revision now stored (1 when no key has metadata yet)
+func bumpLyricsRevision(keys []string) int {
+	metadataMu.Lock()
+	defer metadataMu.Unlock()
+
+	revision := 1
+	now := time.Now().Unix()
+	for _, key := range keys {
+		meta, ok := getSongMetadata(key)
+		if !ok {
+			continue
+		}
+		meta.LyricsRevision++
+		meta.LyricsChangedAt = now
+		revision = max(revision, meta.LyricsRevision)
+		data, err := json.Marshal(meta)
+		if err != nil {
+			continue
+		}
+		if err := metadataSet(metadataBucket, key, string(data)); err != nil {
+			log.Warnf("%s Failed to bump lyrics revision of %s: %v", logcolors.LogCache, key, err)
+		}
+	}
+	return revision
+}
+
+// diffLyrics compares the parsed lines of two TTML documents. Lines are matched by
+// text (longest common subsequence), so a corrected line counts as one removed and
+// one added, and matched lines are compared for timing.
+func diffLyrics(oldLyrics, newLyrics string) LyricsDiff {
+	oldLines, oldTiming, _ := ttml.ParseLines(oldLyrics)
+	newLines, newTiming, _ := ttml.ParseLines(newLyrics)
+	diff := LyricsDiff{
+		LinesBefore:  len(oldLines),
+		LinesAfter:   len(newLines),
+		TimingBefore: oldTiming,
+		TimingAfter:  newTiming,
+	}
+
+	// lcs[i][j] is the common subsequence length of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i].Words == newLines[j].Words {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i].Words == newLines[j].Words:
+			if oldLines[i].StartTimeMs != newLines[j].StartTimeMs || oldLines[i].EndTimeMs != newLines[j].EndTimeMs {
+				diff.TimingChanged = true
+			}
+			i, j = i+1, j+1
+		case j < len(newLines) && (i == len(oldLines) || lcs[i][j+1] >= lcs[i+1][j]):
+			if diff.FirstChange == "" {
+				diff.FirstChange = fmt.Sprintf("line %d added: %q", j+1, newLines[j].Words)
+			}
+			diff.LinesAdded++
+			j++
+		default:
+			if diff.FirstChange == "" {
+				diff.FirstChange = fmt.Sprintf("line %d removed: %q", i+1, oldLines[i].Words)
+			}
+			diff.LinesRemoved++
+			i++
+		}
+	}
+	return diff
+}
+
+// lyricsChangesHandler lists recorded lyrics changes, newest first.
+//
+// Query params:
+//   - track: Only this Apple track ID (optional)
+//   - limit: Maximum changes to return (default: 100)
+func lyricsChangesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	trackID := strings.TrimSpace(r.URL.Query().Get("track"))
+	limit := 100
+	if param := r.URL.Query().Get("limit"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 1 {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": "limit must be a positive integer",
+			})
+			return
+		}
+		limit = n
+	}
+
+	var changes []LyricsChange
+	tracks := map[string]bool{}
+	persistentCache.RangeBucket(lyricsChangesBucket, func(k, v []byte) bool {
+		var change LyricsChange
+		if json.Unmarshal(v, &change) != nil {
+			return true
+		}
+		tracks[change.TrackID] = true
+		if trackID == "" || change.TrackID == trackID {
+			changes = append(changes, change)
+		}
+		return true
+	})
+
+	total := len(changes)
+	list := make([]LyricsChange, 0, min(total, limit))
+	for i := total - 1; i >= 0 && len(list) < limit; i-- {
+		list = append(list, changes[i])
+	}
+	Respond(w, r).JSON(map[string]interface{}{
+		"total":          total,
+		"tracks_changed": len(tracks),
+		"count":          len(list),
+		"changes":        list,
+	})
+}
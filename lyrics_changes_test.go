@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiffLyrics(t *testing.T) {
+	before := `<tt timing="Line"><body><div>` +
+		`<p begin="1.0" end="2.0">Hello from the other side</p>` +
+		`<p begin="2.0" end="3.0">I must have called a thousand times</p>` +
+		`<p begin="3.0" end="4.0">To tell you I'm sorry</p>` +
+		`</div></body></tt>`
+	after := `<tt timing="Line"><body><div>` +
+		`<p begin="1.0" end="2.0">Hello from the other side</p>` +
+		`<p begin="2.0" end="3.0">I must've called a thousand times</p>` +
+		`<p begin="3.5" end="4.5">To tell you I'm sorry</p>` +
+		`<p begin="4.5" end="5.0">For everything that I've done</p>` +
+		`</div></body></tt>`
+
+	diff := diffLyrics(before, after)
+	if diff.LinesBefore != 3 || diff.LinesAfter != 4 || diff.LinesAdded != 2 || diff.LinesRemoved != 1 || !diff.TimingChanged {
+		t.Errorf("Unexpected diff %+v", diff)
+	}
+	if diff.FirstChange == "" {
+		t.Error("Expected the first change to be described")
+	}
+	if got := diff.String(); got != "3 -> 4 lines, +2/-1 lines, timing changed" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if got := diffLyrics(before, before+" ").String(); got != "3 -> 3 lines, markup only" {
+		t.Errorf("String() of a markup-only change = %q", got)
+	}
+}
+
+func TestSetCachedLyricsForTrack_RecordsLyricsChange(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+	initLyricsChangesBucket()
+	conf().Configuration.CacheAccessToken = "test-token"
+
+	original := `<tt><body><div><p begin="0.0" end="1.0">Hello from the other side</p></div></body></tt>`
+	corrected := `<tt><body><div><p begin="0.0" end="1.0">Hello from the outside</p></div></body></tt>`
+	setCachedLyricsForTrack("ttml_lyrics:hello adele", "1440", original, 295000, 0.95, "en", false)
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:hello adele", AppleTrackID: "1440"})
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:hello adele 25", AppleTrackID: "1440"})
+
+	// The same content again is not a change
+	setCachedLyricsForTrack("ttml_lyrics:hello adele 25", "1440", original, 295000, 0.9, "en", false)
+	setCachedLyricsForTrack("ttml_lyrics:hello adele", "1440", corrected, 295000, 0.95, "en", false)
+	waitForPendingWrites(t)
+
+	for _, key := range []string{"ttml_lyrics:hello adele", "ttml_lyrics:hello adele 25"} {
+		if meta, _ := getSongMetadata(key); meta == nil || meta.LyricsRevision != 1 || meta.LyricsChangedAt == 0 {
+			t.Errorf("Expected revision 1 for %s, got %+v", key, meta)
+		}
+	}
+	// A later metadata write keeps the revision
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:hello adele", AppleTrackID: "1440", TrackName: "Hello"})
+	if meta, _ := getSongMetadata("ttml_lyrics:hello adele"); meta.LyricsRevision != 1 {
+		t.Errorf("Expected setSongMetadata to keep revision 1, got %d", meta.LyricsRevision)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/cache/lyrics-changes?track=1440", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr := httptest.NewRecorder()
+	lyricsChangesHandler(rr, req)
+	var body struct {
+		Total   int            `json:"total"`
+		Changes []LyricsChange `json:"changes"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	if rr.Code != http.StatusOK || body.Total != 1 {
+		t.Fatalf("Expected one change, got %d: %s", rr.Code, rr.Body.String())
+	}
+	change := body.Changes[0]
+	if change.TrackID != "1440" || change.Revision != 1 || change.OldHash != lyricsContentHash(original) ||
+		change.NewHash != lyricsContentHash(corrected) || change.Diff.LinesAdded != 1 || change.Diff.LinesRemoved != 1 {
+		t.Errorf("Unexpected change %+v", change)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/cache/lyrics-changes", nil)
+	rr = httptest.NewRecorder()
+	lyricsChangesHandler(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without the token, got %d", rr.Code)
+	}
+}
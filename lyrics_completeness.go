@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/notifier"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// checkLyricsCompleteness compares fetched lyrics against the track length. Apple
+// now and then serves truncated TTML (a handful of lines for a four-minute song);
+// such a result is served but not cached, so a later request fetches it again.
+// reason says what looked truncated when complete is false.
+func checkLyricsCompleteness(ttmlContent string, trackDurationMs int) (reason string, complete bool) {
+	if trackDurationMs <= 0 {
+		return "", true
+	}
+	lines, timingType, err := ttml.ParseLines(ttmlContent)
+	if err != nil {
+		return "", true // Unparseable lyrics aren't a truncation; they fail later
+	}
+
+	trackLength := formatMs(int64(trackDurationMs))
+	minutes := float64(trackDurationMs) / float64(time.Minute/time.Millisecond)
+	if perMinute := conf().Configuration.MinLyricsLinesPerMinute; perMinute > 0 && float64(len(lines)) < perMinute*minutes {
+		return fmt.Sprintf("%d lines for a %s track (minimum %.1f per minute)", len(lines), trackLength, perMinute), false
+	}
+
+	ratio := conf().Configuration.MinLyricsCoverageRatio
+	if ratio <= 0 || timingType == "none" {
+		return "", true
+	}
+	var coveredMs int64
+	for _, line := range lines {
+		if end, err := strconv.ParseInt(line.EndTimeMs, 10, 64); err == nil && end > coveredMs {
+			coveredMs = end
+		}
+	}
+	if float64(coveredMs) < ratio*float64(trackDurationMs) {
+		return fmt.Sprintf("lyrics end at %s of a %s track (minimum %.0f%%)", formatMs(coveredMs), trackLength, ratio*100), false
+	}
+	return "", true
+}
+
+// reportDegradedLyrics logs and notifies about lyrics that were served uncached
+// because they looked truncated
+func reportDegradedLyrics(trackMeta *ttml.TrackMeta, query, reason string) {
+	trackID := ""
+	if trackMeta != nil {
+		trackID = trackMeta.TrackID
+	}
+	log.Warnf("%s Not caching truncated lyrics for %s (track %s): %s", logcolors.LogCacheLyrics, query, trackID, reason)
+	notifier.PublishTruncatedLyrics(trackID, query, reason)
+}
+
+func formatMs(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(time.Second).String()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// lineLevelTTML builds line-synced TTML with one line every intervalSecs seconds
+func lineLevelTTML(lines, intervalSecs int) string {
+	var sb strings.Builder
+	sb.WriteString(`<tt xmlns="http://www.w3.org/ns/ttml" timing="Line"><body><div>`)
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&sb, `<p begin="%d" end="%d">Line %d</p>`, i*intervalSecs, (i+1)*intervalSecs, i+1)
+	}
+	sb.WriteString(`</div></body></tt>`)
+	return sb.String()
+}
+
+func TestCheckLyricsCompleteness(t *testing.T) {
+	origPerMinute, origRatio := conf().Configuration.MinLyricsLinesPerMinute, conf().Configuration.MinLyricsCoverageRatio
+	conf().Configuration.MinLyricsLinesPerMinute = 2
+	conf().Configuration.MinLyricsCoverageRatio = 0.5
+	defer func() {
+		conf().Configuration.MinLyricsLinesPerMinute, conf().Configuration.MinLyricsCoverageRatio = origPerMinute, origRatio
+	}()
+
+	unsynced := `<tt xmlns="http://www.w3.org/ns/ttml" timing="None"><body><div>` +
+		strings.Repeat(`<p>Line</p>`, 8) + `</div></body></tt>`
+
+	tests := []struct {
+		name       string
+		ttml       string
+		durationMs int
+		complete   bool
+		reason     string
+	}{
+		{"full song", lineLevelTTML(40, 5), 240000, true, ""},
+		{"too few lines", lineLevelTTML(5, 40), 240000, false, "5 lines for a 4m0s track"},
+		{"ends early", lineLevelTTML(20, 5), 240000, false, "lyrics end at 1m40s of a 4m0s track"},
+		{"unsynced has no coverage", unsynced, 240000, true, ""},
+		{"unknown duration", lineLevelTTML(2, 5), 0, true, ""},
+		{"unparseable", "not ttml", 240000, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, complete := checkLyricsCompleteness(tt.ttml, tt.durationMs)
+			if complete != tt.complete || !strings.HasPrefix(reason, tt.reason) {
+				t.Errorf("checkLyricsCompleteness = (%q, %v), want (%q..., %v)", reason, complete, tt.reason, tt.complete)
+			}
+		})
+	}
+
+	conf().Configuration.MinLyricsLinesPerMinute = 0
+	conf().Configuration.MinLyricsCoverageRatio = 0
+	if _, complete := checkLyricsCompleteness(lineLevelTTML(2, 5), 240000); !complete {
+		t.Error("With both thresholds at 0 the check must be off")
+	}
+}
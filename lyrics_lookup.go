@@ -0,0 +1,470 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/bini"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"lyrics-api-go/services/proxy"
+	"lyrics-api-go/stats"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// lyricsLookup is a /getLyrics lookup once the transport has parsed and authorized
+// it. The HTTP handler, the gRPC server and /debug/replay all run theirs through
+// lookupLyrics, so caching, in-flight deduplication and negative caching behave the
+// same however a lookup comes in.
+type lyricsLookup struct {
+	song, artist, album string
+	duration            string // Whole seconds; "" when not given
+	videoID             string
+	preferExplicit      bool
+	ratingOverride      bool   // explicit= differs from PREFER_EXPLICIT (see content_rating.go)
+	tenant              string // See tenants.go
+	bypass              cacheBypass
+	priority            string // PriorityInteractive or PriorityLow
+	suggest             bool   // Offer the nearest cached track on a 404 (suggest=true)
+
+	// Cache-first gates set by the middleware: uncached lookups are refused without
+	// a valid API key, in the cache-only rate limit tier and while shedding load
+	apiKeyRequired, apiKeyInvalid, cacheOnly, shedding bool
+
+	hints    ttml.MatchHints
+	capture  *lookupCapture    // nil unless the lookup is captured (see replay.go)
+	timing   *serverTiming     // nil-safe
+	warnings *responseWarnings // nil-safe
+}
+
+// exactKey is the cache key of a lookup that only ever matches exactly (explicit=
+// overrides and tenants), or "" for the shared namespace of default releases
+func (q lyricsLookup) exactKey() string {
+	exactKey := ""
+	if q.ratingOverride {
+		exactKey = contentRatingCacheKey(buildNormalizedCacheKey(q.song, q.artist, q.album, q.duration), q.preferExplicit)
+	}
+	if q.tenant != "" {
+		exactKey = tenantCacheKey(cmp.Or(exactKey, buildNormalizedCacheKey(q.song, q.artist, q.album, q.duration)), q.tenant)
+	}
+	return exactKey
+}
+
+// lyricsOutcome is the answer to a lyricsLookup, for the transport to render
+type lyricsOutcome struct {
+	status      int
+	cacheStatus string
+	lyrics      *CachedLyrics // nil when there are no lyrics to serve
+	cacheKey    string        // Cache entry the lyrics came from; "" when fetched or coalesced
+	stale       bool
+	trackID     string // Track matched by the fetch
+	trackName   string
+	retryAfter  int                    // Seconds; 0 when not retryable
+	body        map[string]interface{} // Error body when lyrics is nil
+}
+
+// lyricsFailure is an outcome without lyrics
+func lyricsFailure(status int, cacheStatus string, body map[string]interface{}) lyricsOutcome {
+	return lyricsOutcome{status: status, cacheStatus: cacheStatus, body: body}
+}
+
+// errorMessage is the "error" of an outcome without lyrics
+func (o lyricsOutcome) errorMessage() string {
+	message, _ := o.body["error"].(string)
+	return message
+}
+
+// lookupLyrics answers a lookup from the cache, or fetches it upstream (at most one
+// fetch per cache key at a time) and caches the result
+func lookupLyrics(ctx context.Context, q lyricsLookup) lyricsOutcome {
+	exactKey := q.exactKey()
+	// Video aliases, stale fallbacks and durationless entries belong to the shared
+	// namespace of default releases
+	sharedNamespace := exactKey == ""
+
+	// Use normalized cache key for consistent cache hits regardless of input casing/whitespace
+	cacheKey := cmp.Or(exactKey, buildNormalizedCacheKey(q.song, q.artist, q.album, q.duration))
+
+	// For logging, use a clean query string
+	query := strings.ToLower(strings.TrimSpace(q.song)) + " " + strings.ToLower(strings.TrimSpace(q.artist))
+
+	// Check cache first with fuzzy duration matching (handles normalized + legacy keys)
+	// This allows cache hits when duration differs by up to DURATION_MATCH_DELTA_MS (default 2s)
+	cacheStart := time.Now()
+	cached, foundKey, ok := lookupCachedLyrics(q.song, q.artist, q.album, q.duration, exactKey)
+	q.timing.since("cache", cacheStart)
+	if ok && !q.bypass.skipCache {
+		// Check for no-lyrics sentinel — return 404 as if no lyrics exist
+		if cached.TTML == NoLyricsSentinel {
+			stats.Get().RecordCacheHit()
+			lyricsLog.Infof("cache_hit_sentinel", "%s No-lyrics marker found for: %s", logcolors.LogCacheLyrics, query)
+			return lyricsFailure(http.StatusNotFound, "HIT", map[string]interface{}{
+				"error": "No lyrics available for this track",
+			})
+		}
+		stats.Get().RecordCacheHit()
+		if foundKey != cacheKey {
+			lyricsLog.Infof("cache_hit_fuzzy", "%s Found cached TTML via fuzzy duration match: %s", logcolors.LogCacheLyrics, foundKey)
+		} else {
+			lyricsLog.Infof("cache_hit", "%s Found cached TTML", logcolors.LogCacheLyrics)
+		}
+		// Associate videoId on cache hits too
+		if q.videoID != "" {
+			goWrite(func() { addVideoID(foundKey, q.videoID) })
+			if sharedNamespace {
+				goWrite(func() { rememberVideoAlias(q.videoID, foundKey, "") })
+			}
+		}
+		q.warnings.noteCacheKey(foundKey)
+		return lyricsOutcome{status: http.StatusOK, cacheStatus: "HIT", lyrics: cached, cacheKey: foundKey}
+	}
+
+	// Check negative cache with fuzzy duration matching
+	negativeStart := time.Now()
+	reason, found := lookupNegativeCache(q.song, q.artist, q.album, q.duration, exactKey)
+	q.timing.since("cache", negativeStart)
+	if found && !q.bypass.noNegative {
+		stats.Get().RecordNegativeCacheHit()
+		lyricsLog.Infof("negative_hit", "%s Returning cached 'no lyrics' response for: %s", logcolors.LogCacheNegative, query)
+		return lyricsFailure(http.StatusNotFound, "NEGATIVE_HIT", withSuggestion(q.suggest, map[string]interface{}{
+			"error": reason,
+		}, q.song, q.artist))
+	}
+
+	// If API key is required for fresh fetch but not provided/invalid, return 401
+	// This allows cache hits to be served without API key
+	if q.apiKeyRequired {
+		stats.Get().RecordCacheMiss()
+		if q.apiKeyInvalid {
+			log.Warnf("%s Invalid API key for uncached query: %s", logcolors.LogAPIKey, query)
+			return lyricsFailure(http.StatusUnauthorized, "MISS", map[string]interface{}{
+				"error":   "Invalid API key",
+				"message": "The provided API key is not valid",
+			})
+		}
+		log.Warnf("%s API key required for uncached query: %s", logcolors.LogAPIKey, query)
+		return lyricsFailure(http.StatusUnauthorized, "MISS", map[string]interface{}{
+			"error":   "API key required",
+			"message": "Uncached queries require a valid API key via X-API-Key header",
+		})
+	}
+
+	// If in cache-only mode (rate limit tier 2) and no cache found, return 429
+	if q.cacheOnly {
+		stats.Get().RecordCacheMiss()
+		stats.Get().RecordRateLimit("exceeded")
+		log.Warnf("%s Cache-only mode but no cache found for: %s", logcolors.LogCacheLyrics, query)
+		outcome := lyricsFailure(http.StatusTooManyRequests, "MISS", map[string]interface{}{
+			"error":       "Rate limit exceeded. This request requires cached data, but no cache is available for this query.",
+			"message":     "Please try again later or reduce your request rate.",
+			"tier":        "normal",
+			"retry_after": 60,
+		})
+		outcome.retryAfter = 60
+		return outcome
+	}
+
+	// Shedding load: unauthenticated clients only get cached lyrics
+	if q.shedding {
+		stats.Get().RecordCacheMiss()
+		retryAfter, body := shedLookup()
+		outcome := lyricsFailure(http.StatusServiceUnavailable, "MISS", body)
+		outcome.retryAfter = retryAfter
+		return outcome
+	}
+
+	// If FF_CACHE_ONLY_MODE is enabled and no cache found, return 503
+	if conf().FeatureFlags.CacheOnlyMode {
+		stats.Get().RecordCacheMiss()
+		log.Warnf("%s FF_CACHE_ONLY_MODE enabled, no cache for: %s", logcolors.LogCacheLyrics, query)
+		return lyricsFailure(http.StatusServiceUnavailable, "MISS", map[string]interface{}{
+			"error": "Service running in cache-only mode. No cached lyrics available for this query.",
+		})
+	}
+
+	// A lookup for this query failed (or was cut off by a restart) moments ago: answer
+	// from the marker instead of sending the whole crowd upstream again
+	if _, running := inFlightReqs.Load(cacheKey); !running && !q.bypass.noNegative {
+		if attempt, retryIn, found := getRecentAttempt(cacheKey); found {
+			if sharedNamespace {
+				if cached, fallbackKey, ok := findStaleFallback(q.song, q.artist, q.album, q.duration, cacheKey); ok {
+					stats.Get().RecordStaleCacheHit()
+					log.Infof("%s Recently attempted, serving stale cache from key: %s", logcolors.LogCacheLyrics, fallbackKey)
+					q.warnings.noteCacheKey(fallbackKey)
+					return lyricsOutcome{status: http.StatusOK, cacheStatus: "STALE", lyrics: cached, cacheKey: fallbackKey, stale: true}
+				}
+			}
+			stats.Get().RecordCacheMiss()
+			reason := attempt.Error
+			if reason == "" {
+				reason = "lookup interrupted"
+			}
+			retryAfter := max(int(retryIn.Round(time.Second).Seconds()), 1)
+			lyricsLog.Infof("recent_attempt", "%s Recently attempted (%s), holding back: %s", logcolors.LogCacheNegative, reason, query)
+			outcome := lyricsFailure(http.StatusServiceUnavailable, "MISS", map[string]interface{}{
+				"error":       "Lookup for this track failed recently, retry later",
+				"reason":      reason,
+				"retry_after": retryAfter,
+			})
+			outcome.retryAfter = retryAfter
+			return outcome
+		}
+	}
+
+	// Low-priority (prefetch) traffic queues for an upstream slot before joining or
+	// leading an in-flight fetch, so interactive requests never wait behind the queue
+	lane := getPriorityLane()
+	release, err := lane.acquire(ctx, q.priority)
+	if err != nil {
+		log.Warnf("%s Low-priority request not admitted: %v", logcolors.LogPriority, err)
+		return upstreamBusyOutcome(err, lane.low.retryAfterSecs())
+	}
+	defer release()
+
+	// A replay (/debug/replay) runs its own lookup rather than joining one, or
+	// reading the lingering result of the failure it reproduces
+	var inFlight interface{} = newInFlightRequest()
+	loaded := false
+	if q.capture == nil || !q.capture.replay {
+		inFlight, loaded = inFlightReqs.LoadOrStore(cacheKey, inFlight)
+	}
+	req := inFlight.(*InFlightRequest)
+
+	if loaded {
+		lyricsLog.Infof("in_flight_wait", "%s Waiting for in-flight request to complete", logcolors.LogCacheLyrics)
+		waitStart := time.Now()
+		completed := req.wait(inFlightWaitTimeout())
+		q.timing.since("wait", waitStart)
+		if !completed {
+			stats.Get().RecordCacheMiss()
+			body := map[string]interface{}{}
+			outcome := lyricsFailure(http.StatusAccepted, "MISS", body)
+			outcome.retryAfter = inFlightPendingBody(body)
+			return outcome
+		}
+
+		if errors.Is(req.err, errUpstreamBusy) {
+			stats.Get().RecordCacheMiss()
+			return upstreamBusyOutcome(errUpstreamBusy, getUpstreamLimiter().retryAfterSecs())
+		}
+		if req.err != nil {
+			status := http.StatusInternalServerError
+			if shouldNegativeCache(req.err) {
+				status = http.StatusNotFound
+			}
+			return lyricsFailure(status, "MISS", map[string]interface{}{
+				"error": req.err.Error(),
+			})
+		}
+
+		return lyricsOutcome{
+			status:      http.StatusOK,
+			cacheStatus: "HIT",
+			lyrics: &CachedLyrics{
+				TTML:            req.result,
+				TrackDurationMs: req.durationMs,
+				Score:           req.score,
+			},
+			trackID:   req.trackID,
+			trackName: req.trackName,
+		}
+	}
+
+	defer func() {
+		req.finish()
+		time.AfterFunc(inFlightLinger(), func() {
+			inFlightReqs.CompareAndDelete(cacheKey, req)
+		})
+	}()
+
+	// Only the leader of a fetch counts against the global upstream limit
+	limiter := getUpstreamLimiter()
+	releaseUpstream, err := limiter.acquire(ctx)
+	if err != nil {
+		log.Warnf("%s Upstream lookup not admitted: %v", logcolors.LogPriority, err)
+		stats.Get().RecordCacheMiss()
+		req.err = errUpstreamBusy
+		return upstreamBusyOutcome(err, limiter.retryAfterSecs())
+	}
+	defer releaseUpstream()
+
+	markAttempt(cacheKey, "")
+
+	// Parse duration from seconds to milliseconds
+	var durationMs int
+	if q.duration != "" {
+		fmt.Sscanf(q.duration, "%d", &durationMs)
+		durationMs = durationMs * 1000 // Convert seconds to milliseconds
+	}
+
+	hints := q.hints
+	if q.capture != nil {
+		hints.Trace = q.capture.trace
+		q.capture.hints = hints
+	}
+
+	upstreamStart := time.Now()
+	ttmlString, trackDurationMs, score, trackMeta, err := fetchTTMLWithLearnedAliases(q.song, q.artist, q.album, durationMs, q.preferExplicit, !q.ratingOverride, hints)
+	q.timing.upstream(time.Since(upstreamStart), trackMeta)
+
+	req.err = err
+	if q.capture != nil {
+		q.capture.err = err
+		if err == nil && ttmlString == "" {
+			q.capture.err = errors.New("lyrics not available for this track")
+		}
+	}
+	if err == nil {
+		req.result = ttmlString
+		req.score = score
+		req.durationMs = trackDurationMs
+		if trackMeta != nil {
+			req.trackID, req.trackName = trackMeta.TrackID, trackMeta.Name
+		}
+	}
+
+	if err != nil {
+		log.Errorf("%s Error fetching TTML: %v", logcolors.LogLyrics, err)
+
+		// Try fallback cache keys before returning error. They hold the default
+		// release, so an explicit= override doesn't fall back to them.
+		if sharedNamespace && !q.bypass.skipCache {
+			if cached, fallbackKey, ok := findStaleFallback(q.song, q.artist, q.album, q.duration, cacheKey); ok {
+				markAttempt(cacheKey, err.Error())
+				stats.Get().RecordStaleCacheHit()
+				log.Warnf("%s Backend failed, serving stale cache from key: %s", logcolors.LogCacheLyrics, fallbackKey)
+				q.warnings.noteCacheKey(fallbackKey)
+				return lyricsOutcome{status: http.StatusOK, cacheStatus: "STALE", lyrics: cached, cacheKey: fallbackKey, stale: true}
+			}
+		}
+
+		// Cache permanent "no lyrics" errors to avoid repeated API calls; transient
+		// ones keep the recent-attempt marker so retries are held back briefly
+		isPermanentError := shouldNegativeCache(err)
+		if isPermanentError {
+			clearAttempt(cacheKey)
+			releaseDate := ""
+			hasTimeSyncedLyricsKnown := false
+			if trackMeta != nil {
+				releaseDate = trackMeta.ReleaseDate
+				hasTimeSyncedLyricsKnown = trackMeta.HasTimeSyncedLyrics != nil
+			}
+			setNegativeCache(cacheKey, err.Error(), releaseDate, hasTimeSyncedLyricsKnown)
+		} else {
+			markAttempt(cacheKey, err.Error())
+		}
+		// No fallback found (or skipped due to duration), return the error
+		stats.Get().RecordCacheMiss()
+		// Return 404 for permanent "not found" errors, 500 for transient errors
+		if isPermanentError {
+			return lyricsFailure(http.StatusNotFound, "MISS", withSuggestion(q.suggest, map[string]interface{}{
+				"error": err.Error(),
+			}, q.song, q.artist))
+		}
+		return lyricsFailure(http.StatusInternalServerError, "MISS", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if ttmlString == "" {
+		stats.Get().RecordCacheMiss()
+		log.Warnf("No TTML found for: %s", query)
+		// Cache this negative result to avoid repeated API calls
+		releaseDate := ""
+		hasTimeSyncedLyricsKnown := false
+		if trackMeta != nil {
+			releaseDate = trackMeta.ReleaseDate
+			hasTimeSyncedLyricsKnown = trackMeta.HasTimeSyncedLyrics != nil
+		}
+		clearAttempt(cacheKey)
+		setNegativeCache(cacheKey, "Lyrics not available for this track", releaseDate, hasTimeSyncedLyricsKnown)
+		return lyricsFailure(http.StatusNotFound, "MISS", withSuggestion(q.suggest, map[string]interface{}{
+			"error": "Lyrics not available for this track",
+		}, q.song, q.artist))
+	}
+
+	stats.Get().RecordCacheMiss()
+	if reason, complete := checkLyricsCompleteness(ttmlString, trackDurationMs); !complete {
+		clearAttempt(cacheKey)
+		reportDegradedLyrics(trackMeta, query, reason)
+		return lyricsOutcome{
+			status:      http.StatusOK,
+			cacheStatus: "DEGRADED",
+			lyrics: &CachedLyrics{
+				TTML:            ttmlString,
+				TrackDurationMs: trackDurationMs,
+				Score:           score,
+			},
+			trackID:   req.trackID,
+			trackName: req.trackName,
+		}
+	}
+	log.Infof("%s Caching TTML for: %s (trackDuration: %dms)", logcolors.LogCacheLyrics, query, trackDurationMs)
+	language, isRTL := ttml.DetectLanguage(ttmlString)
+	setCachedLyricsForTrack(cacheKey, trackMeta.TrackID, ttmlString, trackDurationMs, score, language, isRTL)
+	clearAttempt(cacheKey)
+	if q.duration != "" && sharedNamespace {
+		splitDurationlessEntry(q.song, q.artist, q.album, trackDurationMs)
+	}
+
+	go bini.PostLyrics(trackMeta.Name, trackMeta.ArtistName, trackMeta.AlbumName, trackDurationMs, ttmlString, trackMeta.ISRC)
+
+	// Store metadata and videoId first, then trigger proxy revalidation (which queries metadata).
+	// All writes happen in the same goroutine before revalidation to avoid race conditions.
+	if trackMeta != nil {
+		goWrite(func() {
+			meta := &SongMetadata{
+				CacheKey:      cacheKey,
+				AppleTrackID:  trackMeta.TrackID,
+				ISRC:          trackMeta.ISRC,
+				TrackName:     trackMeta.Name,
+				ArtistName:    trackMeta.ArtistName,
+				AlbumName:     trackMeta.AlbumName,
+				DurationMs:    trackDurationMs,
+				ReleaseDate:   trackMeta.ReleaseDate,
+				ContentRating: trackMeta.ContentRating,
+				RawAttributes: trackMeta.RawAttributes,
+			}
+			if q.videoID != "" {
+				meta.VideoIDs = []string{q.videoID}
+				if sharedNamespace {
+					rememberVideoAlias(q.videoID, cacheKey, trackMeta.TrackID)
+				}
+			}
+			setSongMetadata(meta)
+			proxy.RevalidateAllForSong(trackMeta.Name, trackMeta.ArtistName, trackMeta.AlbumName, trackDurationMs/1000, getAllVideoIDsForSong)
+		})
+	} else if q.videoID != "" {
+		goWrite(func() { addVideoID(cacheKey, q.videoID) })
+		if sharedNamespace {
+			goWrite(func() { rememberVideoAlias(q.videoID, cacheKey, "") })
+		}
+	}
+
+	return lyricsOutcome{
+		status:      http.StatusOK,
+		cacheStatus: "MISS",
+		lyrics: &CachedLyrics{
+			TTML:            ttmlString,
+			TrackDurationMs: trackDurationMs,
+			Score:           score,
+			Language:        language,
+			IsRTL:           isRTL,
+		},
+		trackID:   req.trackID,
+		trackName: req.trackName,
+	}
+}
+
+// upstreamBusyOutcome is the 503 of a lookup (or the in-flight lookup it joined)
+// that a limiter turned away, as respondUpstreamBusy writes it
+func upstreamBusyOutcome(err error, retryAfter int) lyricsOutcome {
+	outcome := lyricsFailure(http.StatusServiceUnavailable, "MISS", upstreamBusyBody(err, retryAfter))
+	outcome.retryAfter = retryAfter
+	return outcome
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lyrics-api-go/lyricsquery"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lyricsPostMaxBody caps the JSON body of POST /getLyrics
+const lyricsPostMaxBody = 64 << 10
+
+// isrcPattern is the ISRC format: country, registrant, year, designation
+var isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+
+// LyricsPostRequest is the JSON body of POST /getLyrics. Only song or artist is
+// required; the other fields refine the match when present.
+type LyricsPostRequest struct {
+	Song        string   `json:"song"`
+	Artist      string   `json:"artist,omitempty"`
+	Artists     []string `json:"artists,omitempty"` // Joined into one credit, after Artist
+	Album       string   `json:"album,omitempty"`
+	DurationMs  int      `json:"durationMs,omitempty"`
+	ISRC        string   `json:"isrc,omitempty"`
+	VideoID     string   `json:"videoId,omitempty"`
+	ReleaseYear int      `json:"releaseYear,omitempty"`
+}
+
+// getLyricsPost is POST /getLyrics: the track comes as JSON instead of query params,
+// so names with &, + or # arrive intact, and ISRC and release year can be sent
+// along. The body is mapped onto the GET parameters and served by getLyrics, so
+// caching, rate limiting and response formats are identical; query params such as
+// format or explicit still apply.
+func getLyricsPost(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, lyricsPostMaxBody)
+
+	var body LyricsPostRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "Invalid JSON body: " + err.Error(),
+		})
+		return
+	}
+	hints, err := body.matchHints()
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Rewritten in place, so the access log and usage dataset see the same
+	// parameters as for a GET
+	query := r.URL.Query()
+	for _, name := range lyricsquery.Params {
+		query.Del(name)
+	}
+	query.Set("s", body.Song)
+	query.Set("a", body.artistCredit())
+	if body.Album != "" {
+		query.Set("al", body.Album)
+	}
+	if body.DurationMs > 0 {
+		// Cache keys are in whole seconds
+		query.Set("d", strconv.Itoa((body.DurationMs+500)/1000))
+	}
+	if body.VideoID != "" {
+		query.Set("videoId", body.VideoID)
+	}
+	r.URL.RawQuery = query.Encode()
+
+	getLyrics(w, r.WithContext(context.WithValue(r.Context(), matchHintsKey, hints)))
+}
+
+// artistCredit joins Artist and Artists the way multi-artist credits are written,
+// which the matcher splits again (see splitArtists in the TTML provider)
+func (body LyricsPostRequest) artistCredit() string {
+	var artists []string
+	for _, artist := range append([]string{body.Artist}, body.Artists...) {
+		if artist = strings.TrimSpace(artist); artist != "" {
+			artists = append(artists, artist)
+		}
+	}
+	return strings.Join(artists, ", ")
+}
+
+func (body LyricsPostRequest) matchHints() (ttml.MatchHints, error) {
+	hints := ttml.MatchHints{ReleaseYear: body.ReleaseYear}
+	if body.DurationMs < 0 {
+		return hints, fmt.Errorf("durationMs must not be negative")
+	}
+	if body.ReleaseYear != 0 && (body.ReleaseYear < 1000 || body.ReleaseYear > 9999) {
+		return hints, fmt.Errorf("releaseYear must be a four-digit year")
+	}
+	if body.ISRC != "" {
+		hints.ISRC = strings.ToUpper(strings.ReplaceAll(body.ISRC, "-", ""))
+		if !isrcPattern.MatchString(hints.ISRC) {
+			return hints, fmt.Errorf("isrc must be a 12-character ISRC, e.g. GBBKS1500214")
+		}
+	}
+	return hints, nil
+}
+
+// matchHintsFrom returns the hints POST /getLyrics attached to the request, with
+// the storefront its Accept-Language prefers when none was set
+func matchHintsFrom(r *http.Request) ttml.MatchHints {
+	hints, _ := r.Context().Value(matchHintsKey).(ttml.MatchHints)
+	if hints.Storefront == "" {
+		hints.Storefront = preferredStorefront(r)
+	}
+	return hints
+}
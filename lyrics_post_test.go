@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetLyricsPost_ServesCachedLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// & and + would be split or turned into spaces in a hand-built query string
+	setCachedLyrics(buildNormalizedCacheKey("Rock & Roll + Soul", "Artist A, Artist B", "", "215"), formatTestTTML, 215000, 0.9, "", false)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/getLyrics", strings.NewReader(
+		`{"song": "Rock & Roll + Soul", "artist": "Artist A", "artists": ["Artist B"], "durationMs": 214600, "isrc": "GB-BKS-15-00214", "releaseYear": 2015}`))
+	getLyricsPost(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Cache-Status") != "HIT" {
+		t.Errorf("Expected a cache hit, got %q", w.Header().Get("X-Cache-Status"))
+	}
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["ttml"] != formatTestTTML {
+		t.Errorf("Unexpected body %v", body)
+	}
+	if got := r.URL.Query().Get("s"); got != "Rock & Roll + Soul" {
+		t.Errorf("Query should carry the song for logging, got %q", got)
+	}
+}
+
+func TestGetLyricsPost_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"invalid json", `{"song": `, http.StatusBadRequest},
+		{"invalid isrc", `{"song": "Hello", "isrc": "nope"}`, http.StatusBadRequest},
+		{"invalid year", `{"song": "Hello", "releaseYear": 15}`, http.StatusBadRequest},
+		{"negative duration", `{"song": "Hello", "durationMs": -1}`, http.StatusBadRequest},
+		{"no song or artist", `{"album": "25"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		getLyricsPost(w, httptest.NewRequest(http.MethodPost, "/getLyrics", strings.NewReader(tt.body)))
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestLyricsPostRequest_MatchHints(t *testing.T) {
+	body := LyricsPostRequest{Artist: " Adele ", Artists: []string{"", "Someone"}, ISRC: "gb-bks-15-00214", ReleaseYear: 2015}
+	if got := body.artistCredit(); got != "Adele, Someone" {
+		t.Errorf("artistCredit() = %q", got)
+	}
+	hints, err := body.matchHints()
+	if err != nil || hints.ISRC != "GBBKS1500214" || hints.ReleaseYear != 2015 {
+		t.Errorf("matchHints() = %+v, %v", hints, err)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/postprocess"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	postProcessors sync.Map // Step list ("whitespace,quotes") -> *postprocess.Pipeline
+
+	invalidPostProcessOnce sync.Once
+	profanityWordlistOnce  sync.Once
+	profanityWordlist      string
+)
+
+// parseCleanParam reads clean= (true or false); clean=true masks profanity
+func parseCleanParam(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("clean")
+	if value == "" {
+		return false, nil
+	}
+	clean, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid clean %q: use true or false", value)
+	}
+	return clean, nil
+}
+
+// requestPostProcessor returns the steps applied to a request's lyrics: those in
+// LYRICS_POSTPROCESS, plus profanity masking for clean=true. nil when there are none.
+func requestPostProcessor(r *http.Request) *postprocess.Pipeline {
+	steps, err := postprocess.ParseSteps(conf().Configuration.LyricsPostProcess)
+	if err != nil {
+		invalidPostProcessOnce.Do(func() {
+			log.Warnf("%s Ignoring LYRICS_POSTPROCESS: %v", logcolors.LogConfig, err)
+		})
+		steps = nil
+	}
+	if clean, _ := parseCleanParam(r); clean {
+		steps = append(steps, postprocess.StepProfanity)
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+
+	key := strings.Join(steps, ",")
+	if p, ok := postProcessors.Load(key); ok {
+		return p.(*postprocess.Pipeline)
+	}
+	p, _ := postProcessors.LoadOrStore(key, postprocess.New(steps, loadProfanityWordlist()))
+	return p.(*postprocess.Pipeline)
+}
+
+// requestsClean reports whether a request asked for profanity masking, whose output
+// differs from what other requests for the same lyrics get
+func requestsClean(r *http.Request) bool {
+	clean, _ := parseCleanParam(r)
+	return clean
+}
+
+// loadProfanityWordlist reads PROFANITY_WORDLIST_PATH once; "" selects the built-in list
+func loadProfanityWordlist() string {
+	profanityWordlistOnce.Do(func() {
+		path := conf().Configuration.ProfanityWordlistPath
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Warnf("%s Failed to read PROFANITY_WORDLIST_PATH, using the built-in list: %v", logcolors.LogConfig, err)
+			return
+		}
+		profanityWordlist = string(data)
+	})
+	return profanityWordlist
+}
+
+// postProcessTTML applies the request's post-processing to TTML served by
+// respondTTML, including the copy in the default JSON body
+func postProcessTTML(r *http.Request, ttmlContent string, body map[string]interface{}) string {
+	pipeline := requestPostProcessor(r)
+	if pipeline.Empty() {
+		return ttmlContent
+	}
+	processed := pipeline.TTML(ttmlContent)
+	if _, ok := body["ttml"].(string); ok {
+		body["ttml"] = processed
+	}
+	return processed
+}
+
+// postProcessRawLyrics applies the request's post-processing to lyrics from the
+// other providers (LRC or plain text)
+func postProcessRawLyrics(r *http.Request, lyrics string) string {
+	return requestPostProcessor(r).Text(lyrics)
+}
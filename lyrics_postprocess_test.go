@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const postProcessTestTTML = `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" timing="Line">
+  <body>
+    <div><p begin="0:00:01.000" end="0:00:03.000">Don’t  give a damn</p></div>
+  </body>
+</tt>`
+
+func TestParseCleanParam(t *testing.T) {
+	for query, want := range map[string]bool{"": false, "clean=true": true, "clean=0": false} {
+		got, err := parseCleanParam(httptest.NewRequest(http.MethodGet, "/getLyrics?"+query, nil))
+		if err != nil || got != want {
+			t.Errorf("%q: parseCleanParam() = %v, %v; want %v", query, got, err, want)
+		}
+	}
+	if _, err := parseCleanParam(httptest.NewRequest(http.MethodGet, "/getLyrics?clean=yes", nil)); err == nil {
+		t.Error("expected an error for clean=yes")
+	}
+}
+
+func TestRespondTTML_PostProcess(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	originalSteps := conf().Configuration.LyricsPostProcess
+	conf().Configuration.LyricsPostProcess = "whitespace,quotes"
+	defer func() { conf().Configuration.LyricsPostProcess = originalSteps }()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"format=text", "Don't give a damn"},
+		{"format=text&clean=true", "Don't give a d***"},
+		{"format=lines&clean=true", `"words":"Don't give a d***"`},
+		{"clean=true", "Don't give a d***"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/getLyrics?"+tt.query, nil)
+		format, _ := parseLyricsFormat(req)
+		rr := httptest.NewRecorder()
+		respondTTML(Respond(rr, req), format, postProcessTestTTML, map[string]interface{}{
+			"ttml": postProcessTestTTML,
+		})
+
+		got := rr.Body.String()
+		if format == formatTTML {
+			var body map[string]string
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			got = body["ttml"]
+		}
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%s: body %q doesn't contain %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestRespondCachedTTML_CleanSkipsVariants(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	key := "ttml_lyrics:postprocess clean"
+	for _, query := range []string{"format=text&clean=true", "format=text"} {
+		req := httptest.NewRequest(http.MethodGet, "/getLyrics?"+query, nil)
+		rr := httptest.NewRecorder()
+		respondCachedTTML(Respond(rr, req), formatText, key, postProcessTestTTML, map[string]interface{}{
+			"ttml": postProcessTestTTML,
+		})
+		if query == "format=text" && strings.Contains(rr.Body.String(), "d***") {
+			t.Errorf("the clean=true output was served to a request without clean: %q", rr.Body.String())
+		}
+	}
+}
+
+func TestPostProcessRawLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	originalSteps := conf().Configuration.LyricsPostProcess
+	conf().Configuration.LyricsPostProcess = "whitespace"
+	defer func() { conf().Configuration.LyricsPostProcess = originalSteps }()
+
+	req := httptest.NewRequest(http.MethodGet, "/kugou/getLyrics?s=x&clean=true", nil)
+	got := postProcessRawLyrics(req, "[00:01.00]Oh  shit\n[00:02.00]Fine")
+	if got != "[00:01.00]Oh s***\n[00:02.00]Fine" {
+		t.Errorf("postProcessRawLyrics() = %q", got)
+	}
+}
@@ -0,0 +1,873 @@
+// gRPC transport for internal consumers (cache warmer, QA tools). Served on
+// GRPC_PORT next to the HTTP API. Calls run the lookup and search code HTTP
+// uses (lookupLyrics, searchCachedLyrics), not the HTTP handlers themselves.
+//
+// Regenerate lyrics.pb.go and lyrics_grpc.pb.go after editing:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative lyricspb/lyrics.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.5
+// 	protoc        (unknown)
+// source: lyricspb/lyrics.proto
+
+package lyricspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LyricsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Song   string                 `protobuf:"bytes,1,opt,name=song,proto3" json:"song,omitempty"`
+	Artist string                 `protobuf:"bytes,2,opt,name=artist,proto3" json:"artist,omitempty"`
+	Album  string                 `protobuf:"bytes,3,opt,name=album,proto3" json:"album,omitempty"`
+	// Track duration in seconds (0 = unknown)
+	DurationSecs int32 `protobuf:"varint,4,opt,name=duration_secs,json=durationSecs,proto3" json:"duration_secs,omitempty"`
+	// Explicit (true) or clean (false) release; unset follows PREFER_EXPLICIT
+	Explicit      *bool `protobuf:"varint,5,opt,name=explicit,proto3,oneof" json:"explicit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LyricsRequest) Reset() {
+	*x = LyricsRequest{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LyricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LyricsRequest) ProtoMessage() {}
+
+func (x *LyricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LyricsRequest.ProtoReflect.Descriptor instead.
+func (*LyricsRequest) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LyricsRequest) GetSong() string {
+	if x != nil {
+		return x.Song
+	}
+	return ""
+}
+
+func (x *LyricsRequest) GetArtist() string {
+	if x != nil {
+		return x.Artist
+	}
+	return ""
+}
+
+func (x *LyricsRequest) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *LyricsRequest) GetDurationSecs() int32 {
+	if x != nil {
+		return x.DurationSecs
+	}
+	return 0
+}
+
+func (x *LyricsRequest) GetExplicit() bool {
+	if x != nil && x.Explicit != nil {
+		return *x.Explicit
+	}
+	return false
+}
+
+type LyricsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ttml  string                 `protobuf:"bytes,1,opt,name=ttml,proto3" json:"ttml,omitempty"`
+	Score float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	// Same values as X-Cache-Status over HTTP: HIT, MISS, STALE, ...
+	CacheStatus string `protobuf:"bytes,3,opt,name=cache_status,json=cacheStatus,proto3" json:"cache_status,omitempty"`
+	// Set on STALE responses: cached lyrics served because the upstream lookup failed
+	Stale bool `protobuf:"varint,4,opt,name=stale,proto3" json:"stale,omitempty"`
+	// Seconds since a stale entry was cached (0 for entries from before it was recorded)
+	AgeSeconds    int64 `protobuf:"varint,5,opt,name=age_seconds,json=ageSeconds,proto3" json:"age_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LyricsResponse) Reset() {
+	*x = LyricsResponse{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LyricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LyricsResponse) ProtoMessage() {}
+
+func (x *LyricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LyricsResponse.ProtoReflect.Descriptor instead.
+func (*LyricsResponse) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LyricsResponse) GetTtml() string {
+	if x != nil {
+		return x.Ttml
+	}
+	return ""
+}
+
+func (x *LyricsResponse) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *LyricsResponse) GetCacheStatus() string {
+	if x != nil {
+		return x.CacheStatus
+	}
+	return ""
+}
+
+func (x *LyricsResponse) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+func (x *LyricsResponse) GetAgeSeconds() int64 {
+	if x != nil {
+		return x.AgeSeconds
+	}
+	return 0
+}
+
+type BatchGetLyricsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Requests      []*LyricsRequest       `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetLyricsRequest) Reset() {
+	*x = BatchGetLyricsRequest{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetLyricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetLyricsRequest) ProtoMessage() {}
+
+func (x *BatchGetLyricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetLyricsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetLyricsRequest) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BatchGetLyricsRequest) GetRequests() []*LyricsRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type BatchGetLyricsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One result per request, in request order
+	Results       []*BatchLyricsResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetLyricsResponse) Reset() {
+	*x = BatchGetLyricsResponse{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetLyricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetLyricsResponse) ProtoMessage() {}
+
+func (x *BatchGetLyricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetLyricsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetLyricsResponse) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchGetLyricsResponse) GetResults() []*BatchLyricsResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchLyricsResult struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Request *LyricsRequest         `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	// Set when the lookup succeeded
+	Lyrics *LyricsResponse `protobuf:"bytes,2,opt,name=lyrics,proto3" json:"lyrics,omitempty"`
+	// gRPC status code and message of a failed lookup
+	Code          int32  `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
+	Error         string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchLyricsResult) Reset() {
+	*x = BatchLyricsResult{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchLyricsResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchLyricsResult) ProtoMessage() {}
+
+func (x *BatchLyricsResult) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchLyricsResult.ProtoReflect.Descriptor instead.
+func (*BatchLyricsResult) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchLyricsResult) GetRequest() *LyricsRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *BatchLyricsResult) GetLyrics() *LyricsResponse {
+	if x != nil {
+		return x.Lyrics
+	}
+	return nil
+}
+
+func (x *BatchLyricsResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *BatchLyricsResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type SearchTrackRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Words or phrase to find in cached lyrics
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Maximum tracks (default 20, max 100)
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchTrackRequest) Reset() {
+	*x = SearchTrackRequest{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchTrackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchTrackRequest) ProtoMessage() {}
+
+func (x *SearchTrackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchTrackRequest.ProtoReflect.Descriptor instead.
+func (*SearchTrackRequest) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SearchTrackRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchTrackRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type SearchTrackResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*TrackMatch          `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchTrackResponse) Reset() {
+	*x = SearchTrackResponse{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchTrackResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchTrackResponse) ProtoMessage() {}
+
+func (x *SearchTrackResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchTrackResponse.ProtoReflect.Descriptor instead.
+func (*SearchTrackResponse) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchTrackResponse) GetResults() []*TrackMatch {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type TrackMatch struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Key    string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Song   string                 `protobuf:"bytes,2,opt,name=song,proto3" json:"song,omitempty"`
+	Artist string                 `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
+	// A single line contains the query as typed
+	PhraseMatch   bool     `protobuf:"varint,4,opt,name=phrase_match,json=phraseMatch,proto3" json:"phrase_match,omitempty"`
+	Snippets      []string `protobuf:"bytes,5,rep,name=snippets,proto3" json:"snippets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackMatch) Reset() {
+	*x = TrackMatch{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackMatch) ProtoMessage() {}
+
+func (x *TrackMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackMatch.ProtoReflect.Descriptor instead.
+func (*TrackMatch) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TrackMatch) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TrackMatch) GetSong() string {
+	if x != nil {
+		return x.Song
+	}
+	return ""
+}
+
+func (x *TrackMatch) GetArtist() string {
+	if x != nil {
+		return x.Artist
+	}
+	return ""
+}
+
+func (x *TrackMatch) GetPhraseMatch() bool {
+	if x != nil {
+		return x.PhraseMatch
+	}
+	return false
+}
+
+func (x *TrackMatch) GetSnippets() []string {
+	if x != nil {
+		return x.Snippets
+	}
+	return nil
+}
+
+type LyricsLine struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	StartMs    int64                  `protobuf:"varint,1,opt,name=start_ms,json=startMs,proto3" json:"start_ms,omitempty"`
+	EndMs      int64                  `protobuf:"varint,2,opt,name=end_ms,json=endMs,proto3" json:"end_ms,omitempty"`
+	Text       string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	Agent      string                 `protobuf:"bytes,4,opt,name=agent,proto3" json:"agent,omitempty"`
+	Section    string                 `protobuf:"bytes,5,opt,name=section,proto3" json:"section,omitempty"`
+	Background bool                   `protobuf:"varint,6,opt,name=background,proto3" json:"background,omitempty"`
+	Syllables  []*Syllable            `protobuf:"bytes,7,rep,name=syllables,proto3" json:"syllables,omitempty"`
+	// Timing of the whole document: word, line or none
+	TimingType    string `protobuf:"bytes,8,opt,name=timing_type,json=timingType,proto3" json:"timing_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LyricsLine) Reset() {
+	*x = LyricsLine{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LyricsLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LyricsLine) ProtoMessage() {}
+
+func (x *LyricsLine) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LyricsLine.ProtoReflect.Descriptor instead.
+func (*LyricsLine) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LyricsLine) GetStartMs() int64 {
+	if x != nil {
+		return x.StartMs
+	}
+	return 0
+}
+
+func (x *LyricsLine) GetEndMs() int64 {
+	if x != nil {
+		return x.EndMs
+	}
+	return 0
+}
+
+func (x *LyricsLine) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *LyricsLine) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *LyricsLine) GetSection() string {
+	if x != nil {
+		return x.Section
+	}
+	return ""
+}
+
+func (x *LyricsLine) GetBackground() bool {
+	if x != nil {
+		return x.Background
+	}
+	return false
+}
+
+func (x *LyricsLine) GetSyllables() []*Syllable {
+	if x != nil {
+		return x.Syllables
+	}
+	return nil
+}
+
+func (x *LyricsLine) GetTimingType() string {
+	if x != nil {
+		return x.TimingType
+	}
+	return ""
+}
+
+type Syllable struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	StartMs       int64                  `protobuf:"varint,2,opt,name=start_ms,json=startMs,proto3" json:"start_ms,omitempty"`
+	EndMs         int64                  `protobuf:"varint,3,opt,name=end_ms,json=endMs,proto3" json:"end_ms,omitempty"`
+	Background    bool                   `protobuf:"varint,4,opt,name=background,proto3" json:"background,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Syllable) Reset() {
+	*x = Syllable{}
+	mi := &file_lyricspb_lyrics_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Syllable) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Syllable) ProtoMessage() {}
+
+func (x *Syllable) ProtoReflect() protoreflect.Message {
+	mi := &file_lyricspb_lyrics_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Syllable.ProtoReflect.Descriptor instead.
+func (*Syllable) Descriptor() ([]byte, []int) {
+	return file_lyricspb_lyrics_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Syllable) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Syllable) GetStartMs() int64 {
+	if x != nil {
+		return x.StartMs
+	}
+	return 0
+}
+
+func (x *Syllable) GetEndMs() int64 {
+	if x != nil {
+		return x.EndMs
+	}
+	return 0
+}
+
+func (x *Syllable) GetBackground() bool {
+	if x != nil {
+		return x.Background
+	}
+	return false
+}
+
+var File_lyricspb_lyrics_proto protoreflect.FileDescriptor
+
+var file_lyricspb_lyrics_proto_rawDesc = string([]byte{
+	0x0a, 0x15, 0x6c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x70, 0x62, 0x2f, 0x6c, 0x79, 0x72, 0x69, 0x63,
+	0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x6c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x2e,
+	0x76, 0x31, 0x22, 0xa4, 0x01, 0x0a, 0x0d, 0x4c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6f, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x73, 0x6f, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x72, 0x74, 0x69,
+	0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x72, 0x74, 0x69, 0x73, 0x74,
+	0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x61, 0x6c, 0x62, 0x75, 0x6d, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x73, 0x65, 0x63, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x64,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x63, 0x73, 0x12, 0x1f, 0x0a, 0x08, 0x65,
+	0x78, 0x70, 0x6c, 0x69, 0x63, 0x69, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52,
+	0x08, 0x65, 0x78, 0x70, 0x6c, 0x69, 0x63, 0x69, 0x74, 0x88, 0x01, 0x01, 0x42, 0x0b, 0x0a, 0x09,
+	0x5f, 0x65, 0x78, 0x70, 0x6c, 0x69, 0x63, 0x69, 0x74, 0x22, 0x94, 0x01, 0x0a, 0x0e, 0x4c, 0x79,
+	0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x74, 0x6d, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x74, 0x6d, 0x6c,
+	0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x61, 0x63, 0x68, 0x65, 0x5f,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x61,
+	0x63, 0x68, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61,
+	0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x12,
+	0x1f, 0x0a, 0x0b, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x61, 0x67, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x22, 0x4d, 0x0a, 0x15, 0x42, 0x61, 0x74, 0x63, 0x68, 0x47, 0x65, 0x74, 0x4c, 0x79, 0x72, 0x69,
+	0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x34, 0x0a, 0x08, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6c, 0x79,
+	0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x08, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x22,
+	0x50, 0x0a, 0x16, 0x42, 0x61, 0x74, 0x63, 0x68, 0x47, 0x65, 0x74, 0x4c, 0x79, 0x72, 0x69, 0x63,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x07, 0x72, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x6c, 0x79, 0x72,
+	0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x79, 0x72, 0x69,
+	0x63, 0x73, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x73, 0x22, 0xa4, 0x01, 0x0a, 0x11, 0x42, 0x61, 0x74, 0x63, 0x68, 0x4c, 0x79, 0x72, 0x69, 0x63,
+	0x73, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x32, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6c, 0x79, 0x72, 0x69, 0x63,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x31, 0x0a, 0x06, 0x6c,
+	0x79, 0x72, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6c, 0x79,
+	0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x06, 0x6c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x12, 0x12,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x40, 0x0a, 0x12, 0x53, 0x65, 0x61, 0x72,
+	0x63, 0x68, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71,
+	0x75, 0x65, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x46, 0x0a, 0x13, 0x53, 0x65,
+	0x61, 0x72, 0x63, 0x68, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x2f, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x72, 0x61, 0x63, 0x6b, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x73, 0x22, 0x89, 0x01, 0x0a, 0x0a, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x4d, 0x61, 0x74, 0x63,
+	0x68, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6f, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x73, 0x6f, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x72, 0x74, 0x69, 0x73,
+	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x72, 0x74, 0x69, 0x73, 0x74, 0x12,
+	0x21, 0x0a, 0x0c, 0x70, 0x68, 0x72, 0x61, 0x73, 0x65, 0x5f, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x70, 0x68, 0x72, 0x61, 0x73, 0x65, 0x4d, 0x61, 0x74,
+	0x63, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x6e, 0x69, 0x70, 0x70, 0x65, 0x74, 0x73, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x73, 0x6e, 0x69, 0x70, 0x70, 0x65, 0x74, 0x73, 0x22, 0xf6,
+	0x01, 0x0a, 0x0a, 0x4c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x4c, 0x69, 0x6e, 0x65, 0x12, 0x19, 0x0a,
+	0x08, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x73, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x73, 0x12, 0x15, 0x0a, 0x06, 0x65, 0x6e, 0x64, 0x5f,
+	0x6d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74,
+	0x65, 0x78, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x62, 0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f, 0x75, 0x6e,
+	0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x62, 0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f,
+	0x75, 0x6e, 0x64, 0x12, 0x31, 0x0a, 0x09, 0x73, 0x79, 0x6c, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x73,
+	0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x79, 0x6c, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x52, 0x09, 0x73, 0x79, 0x6c,
+	0x6c, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x69, 0x6d, 0x69, 0x6e, 0x67,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x69, 0x6d,
+	0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x22, 0x70, 0x0a, 0x08, 0x53, 0x79, 0x6c, 0x6c, 0x61,
+	0x62, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x5f, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x4d, 0x73, 0x12, 0x15, 0x0a, 0x06, 0x65, 0x6e, 0x64, 0x5f, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x65, 0x6e, 0x64, 0x4d, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x62, 0x61, 0x63,
+	0x6b, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x62,
+	0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x32, 0xb7, 0x02, 0x0a, 0x06, 0x4c, 0x79,
+	0x72, 0x69, 0x63, 0x73, 0x12, 0x40, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x4c, 0x79, 0x72, 0x69, 0x63,
+	0x73, 0x12, 0x18, 0x2e, 0x6c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x79,
+	0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6c, 0x79,
+	0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x55, 0x0a, 0x0e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x47,
+	0x65, 0x74, 0x4c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x12, 0x20, 0x2e, 0x6c, 0x79, 0x72, 0x69, 0x63,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x47, 0x65, 0x74, 0x4c, 0x79, 0x72,
+	0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x6c, 0x79, 0x72,
+	0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x47, 0x65, 0x74, 0x4c,
+	0x79, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a,
+	0x0b, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x12, 0x1d, 0x2e, 0x6c,
+	0x79, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x54,
+	0x72, 0x61, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6c, 0x79,
+	0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x54, 0x72,
+	0x61, 0x63, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x11, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x4c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x4c, 0x69, 0x6e, 0x65, 0x73,
+	0x12, 0x18, 0x2e, 0x6c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x79, 0x72,
+	0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x6c, 0x79, 0x72,
+	0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x4c, 0x69, 0x6e,
+	0x65, 0x30, 0x01, 0x42, 0x18, 0x5a, 0x16, 0x6c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x2d, 0x61, 0x70,
+	0x69, 0x2d, 0x67, 0x6f, 0x2f, 0x6c, 0x79, 0x72, 0x69, 0x63, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+})
+
+var (
+	file_lyricspb_lyrics_proto_rawDescOnce sync.Once
+	file_lyricspb_lyrics_proto_rawDescData []byte
+)
+
+func file_lyricspb_lyrics_proto_rawDescGZIP() []byte {
+	file_lyricspb_lyrics_proto_rawDescOnce.Do(func() {
+		file_lyricspb_lyrics_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_lyricspb_lyrics_proto_rawDesc), len(file_lyricspb_lyrics_proto_rawDesc)))
+	})
+	return file_lyricspb_lyrics_proto_rawDescData
+}
+
+var file_lyricspb_lyrics_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_lyricspb_lyrics_proto_goTypes = []any{
+	(*LyricsRequest)(nil),          // 0: lyrics.v1.LyricsRequest
+	(*LyricsResponse)(nil),         // 1: lyrics.v1.LyricsResponse
+	(*BatchGetLyricsRequest)(nil),  // 2: lyrics.v1.BatchGetLyricsRequest
+	(*BatchGetLyricsResponse)(nil), // 3: lyrics.v1.BatchGetLyricsResponse
+	(*BatchLyricsResult)(nil),      // 4: lyrics.v1.BatchLyricsResult
+	(*SearchTrackRequest)(nil),     // 5: lyrics.v1.SearchTrackRequest
+	(*SearchTrackResponse)(nil),    // 6: lyrics.v1.SearchTrackResponse
+	(*TrackMatch)(nil),             // 7: lyrics.v1.TrackMatch
+	(*LyricsLine)(nil),             // 8: lyrics.v1.LyricsLine
+	(*Syllable)(nil),               // 9: lyrics.v1.Syllable
+}
+var file_lyricspb_lyrics_proto_depIdxs = []int32{
+	0,  // 0: lyrics.v1.BatchGetLyricsRequest.requests:type_name -> lyrics.v1.LyricsRequest
+	4,  // 1: lyrics.v1.BatchGetLyricsResponse.results:type_name -> lyrics.v1.BatchLyricsResult
+	0,  // 2: lyrics.v1.BatchLyricsResult.request:type_name -> lyrics.v1.LyricsRequest
+	1,  // 3: lyrics.v1.BatchLyricsResult.lyrics:type_name -> lyrics.v1.LyricsResponse
+	7,  // 4: lyrics.v1.SearchTrackResponse.results:type_name -> lyrics.v1.TrackMatch
+	9,  // 5: lyrics.v1.LyricsLine.syllables:type_name -> lyrics.v1.Syllable
+	0,  // 6: lyrics.v1.Lyrics.GetLyrics:input_type -> lyrics.v1.LyricsRequest
+	2,  // 7: lyrics.v1.Lyrics.BatchGetLyrics:input_type -> lyrics.v1.BatchGetLyricsRequest
+	5,  // 8: lyrics.v1.Lyrics.SearchTrack:input_type -> lyrics.v1.SearchTrackRequest
+	0,  // 9: lyrics.v1.Lyrics.StreamLyricsLines:input_type -> lyrics.v1.LyricsRequest
+	1,  // 10: lyrics.v1.Lyrics.GetLyrics:output_type -> lyrics.v1.LyricsResponse
+	3,  // 11: lyrics.v1.Lyrics.BatchGetLyrics:output_type -> lyrics.v1.BatchGetLyricsResponse
+	6,  // 12: lyrics.v1.Lyrics.SearchTrack:output_type -> lyrics.v1.SearchTrackResponse
+	8,  // 13: lyrics.v1.Lyrics.StreamLyricsLines:output_type -> lyrics.v1.LyricsLine
+	10, // [10:14] is the sub-list for method output_type
+	6,  // [6:10] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_lyricspb_lyrics_proto_init() }
+func file_lyricspb_lyrics_proto_init() {
+	if File_lyricspb_lyrics_proto != nil {
+		return
+	}
+	file_lyricspb_lyrics_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_lyricspb_lyrics_proto_rawDesc), len(file_lyricspb_lyrics_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_lyricspb_lyrics_proto_goTypes,
+		DependencyIndexes: file_lyricspb_lyrics_proto_depIdxs,
+		MessageInfos:      file_lyricspb_lyrics_proto_msgTypes,
+	}.Build()
+	File_lyricspb_lyrics_proto = out.File
+	file_lyricspb_lyrics_proto_goTypes = nil
+	file_lyricspb_lyrics_proto_depIdxs = nil
+}
@@ -0,0 +1,256 @@
+// gRPC transport for internal consumers (cache warmer, QA tools). Served on
+// GRPC_PORT next to the HTTP API. Calls run the lookup and search code HTTP
+// uses (lookupLyrics, searchCachedLyrics), not the HTTP handlers themselves.
+//
+// Regenerate lyrics.pb.go and lyrics_grpc.pb.go after editing:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative lyricspb/lyrics.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: lyricspb/lyrics.proto
+
+package lyricspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Lyrics_GetLyrics_FullMethodName         = "/lyrics.v1.Lyrics/GetLyrics"
+	Lyrics_BatchGetLyrics_FullMethodName    = "/lyrics.v1.Lyrics/BatchGetLyrics"
+	Lyrics_SearchTrack_FullMethodName       = "/lyrics.v1.Lyrics/SearchTrack"
+	Lyrics_StreamLyricsLines_FullMethodName = "/lyrics.v1.Lyrics/StreamLyricsLines"
+)
+
+// LyricsClient is the client API for Lyrics service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LyricsClient interface {
+	// GetLyrics looks up one track as GET /getLyrics does: cache first, then upstream
+	GetLyrics(ctx context.Context, in *LyricsRequest, opts ...grpc.CallOption) (*LyricsResponse, error)
+	// BatchGetLyrics looks up several tracks; one failing doesn't fail the batch
+	BatchGetLyrics(ctx context.Context, in *BatchGetLyricsRequest, opts ...grpc.CallOption) (*BatchGetLyricsResponse, error)
+	// SearchTrack searches cached lyrics (full-text) as GET /cache/search does
+	SearchTrack(ctx context.Context, in *SearchTrackRequest, opts ...grpc.CallOption) (*SearchTrackResponse, error)
+	// StreamLyricsLines sends the parsed lines of a track one message at a time
+	StreamLyricsLines(ctx context.Context, in *LyricsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LyricsLine], error)
+}
+
+type lyricsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLyricsClient(cc grpc.ClientConnInterface) LyricsClient {
+	return &lyricsClient{cc}
+}
+
+func (c *lyricsClient) GetLyrics(ctx context.Context, in *LyricsRequest, opts ...grpc.CallOption) (*LyricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LyricsResponse)
+	err := c.cc.Invoke(ctx, Lyrics_GetLyrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lyricsClient) BatchGetLyrics(ctx context.Context, in *BatchGetLyricsRequest, opts ...grpc.CallOption) (*BatchGetLyricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetLyricsResponse)
+	err := c.cc.Invoke(ctx, Lyrics_BatchGetLyrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lyricsClient) SearchTrack(ctx context.Context, in *SearchTrackRequest, opts ...grpc.CallOption) (*SearchTrackResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchTrackResponse)
+	err := c.cc.Invoke(ctx, Lyrics_SearchTrack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lyricsClient) StreamLyricsLines(ctx context.Context, in *LyricsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LyricsLine], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Lyrics_ServiceDesc.Streams[0], Lyrics_StreamLyricsLines_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LyricsRequest, LyricsLine]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lyrics_StreamLyricsLinesClient = grpc.ServerStreamingClient[LyricsLine]
+
+// LyricsServer is the server API for Lyrics service.
+// All implementations must embed UnimplementedLyricsServer
+// for forward compatibility.
+type LyricsServer interface {
+	// GetLyrics looks up one track as GET /getLyrics does: cache first, then upstream
+	GetLyrics(context.Context, *LyricsRequest) (*LyricsResponse, error)
+	// BatchGetLyrics looks up several tracks; one failing doesn't fail the batch
+	BatchGetLyrics(context.Context, *BatchGetLyricsRequest) (*BatchGetLyricsResponse, error)
+	// SearchTrack searches cached lyrics (full-text) as GET /cache/search does
+	SearchTrack(context.Context, *SearchTrackRequest) (*SearchTrackResponse, error)
+	// StreamLyricsLines sends the parsed lines of a track one message at a time
+	StreamLyricsLines(*LyricsRequest, grpc.ServerStreamingServer[LyricsLine]) error
+	mustEmbedUnimplementedLyricsServer()
+}
+
+// UnimplementedLyricsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLyricsServer struct{}
+
+func (UnimplementedLyricsServer) GetLyrics(context.Context, *LyricsRequest) (*LyricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLyrics not implemented")
+}
+func (UnimplementedLyricsServer) BatchGetLyrics(context.Context, *BatchGetLyricsRequest) (*BatchGetLyricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetLyrics not implemented")
+}
+func (UnimplementedLyricsServer) SearchTrack(context.Context, *SearchTrackRequest) (*SearchTrackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchTrack not implemented")
+}
+func (UnimplementedLyricsServer) StreamLyricsLines(*LyricsRequest, grpc.ServerStreamingServer[LyricsLine]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamLyricsLines not implemented")
+}
+func (UnimplementedLyricsServer) mustEmbedUnimplementedLyricsServer() {}
+func (UnimplementedLyricsServer) testEmbeddedByValue()                {}
+
+// UnsafeLyricsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LyricsServer will
+// result in compilation errors.
+type UnsafeLyricsServer interface {
+	mustEmbedUnimplementedLyricsServer()
+}
+
+func RegisterLyricsServer(s grpc.ServiceRegistrar, srv LyricsServer) {
+	// If the following call pancis, it indicates UnimplementedLyricsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Lyrics_ServiceDesc, srv)
+}
+
+func _Lyrics_GetLyrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LyricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LyricsServer).GetLyrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lyrics_GetLyrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LyricsServer).GetLyrics(ctx, req.(*LyricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lyrics_BatchGetLyrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetLyricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LyricsServer).BatchGetLyrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lyrics_BatchGetLyrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LyricsServer).BatchGetLyrics(ctx, req.(*BatchGetLyricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lyrics_SearchTrack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchTrackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LyricsServer).SearchTrack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lyrics_SearchTrack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LyricsServer).SearchTrack(ctx, req.(*SearchTrackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lyrics_StreamLyricsLines_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LyricsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LyricsServer).StreamLyricsLines(m, &grpc.GenericServerStream[LyricsRequest, LyricsLine]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lyrics_StreamLyricsLinesServer = grpc.ServerStreamingServer[LyricsLine]
+
+// Lyrics_ServiceDesc is the grpc.ServiceDesc for Lyrics service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Lyrics_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "lyrics.v1.Lyrics",
+	HandlerType: (*LyricsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLyrics",
+			Handler:    _Lyrics_GetLyrics_Handler,
+		},
+		{
+			MethodName: "BatchGetLyrics",
+			Handler:    _Lyrics_BatchGetLyrics_Handler,
+		},
+		{
+			MethodName: "SearchTrack",
+			Handler:    _Lyrics_SearchTrack_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamLyricsLines",
+			Handler:       _Lyrics_StreamLyricsLines_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "lyricspb/lyrics.proto",
+}
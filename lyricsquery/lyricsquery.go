@@ -0,0 +1,102 @@
+// Package lyricsquery parses the query params that name the track of a lyrics
+// lookup. Each param has aliases (s, song and songName all name the song); every
+// endpoint that takes a track reads them through Parse, so the aliases mean the
+// same thing everywhere.
+package lyricsquery
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// The params of each field with their aliases, preferred name first
+var (
+	SongParams     = []string{"s", "song", "songName"}
+	ArtistParams   = []string{"a", "artist", "artistName"}
+	AlbumParams    = []string{"al", "album", "albumName"}
+	DurationParams = []string{"d", "duration"}
+	VideoIDParams  = []string{"v", "videoId"}
+)
+
+// Params lists every param Parse reads
+var Params = concat(SongParams, ArtistParams, AlbumParams, DurationParams, VideoIDParams)
+
+func concat(lists ...[]string) []string {
+	var all []string
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// ErrInvalidDuration is returned for a duration that isn't a number of seconds
+var ErrInvalidDuration = errors.New("duration (d) must be a non-negative number of seconds")
+
+// Query is the track a lookup request names
+type Query struct {
+	Song     string
+	Artist   string
+	Album    string
+	Duration string // Whole seconds; "" when not given
+	VideoID  string
+}
+
+// Parse reads the track params from a query string. For each field the first
+// alias with a non-blank value wins, trimmed of surrounding whitespace. A
+// fractional duration is truncated to whole seconds, as cache keys use them. On
+// ErrInvalidDuration the other fields are still filled in.
+func Parse(values url.Values) (Query, error) {
+	q := Query{
+		Song:     first(values, SongParams),
+		Artist:   first(values, ArtistParams),
+		Album:    first(values, AlbumParams),
+		Duration: first(values, DurationParams),
+		VideoID:  first(values, VideoIDParams),
+	}
+	if q.Duration != "" {
+		seconds, err := strconv.ParseFloat(q.Duration, 64)
+		if err != nil || seconds < 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+			q.Duration = ""
+			return q, ErrInvalidDuration
+		}
+		q.Duration = strconv.FormatInt(int64(seconds), 10)
+	}
+	return q, nil
+}
+
+func first(values url.Values, names []string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(values.Get(name)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// HasTrack reports whether a song or an artist was given
+func (q Query) HasTrack() bool {
+	return q.Song != "" || q.Artist != ""
+}
+
+// HasSongAndArtist reports whether both a song and an artist were given, as the
+// endpoints that change cache entries require
+func (q Query) HasSongAndArtist() bool {
+	return q.Song != "" && q.Artist != ""
+}
+
+// Values returns the song, artist, album and duration in their short param names,
+// leaving out empty fields. The video ID is left out: cache keys don't include it.
+func (q Query) Values() url.Values {
+	values := url.Values{}
+	for _, field := range []struct{ name, value string }{
+		{"s", q.Song}, {"a", q.Artist}, {"al", q.Album}, {"d", q.Duration},
+	} {
+		if field.value != "" {
+			values.Set(field.name, field.value)
+		}
+	}
+	return values
+}
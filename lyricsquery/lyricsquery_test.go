@@ -0,0 +1,48 @@
+package lyricsquery
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    Query
+		wantErr error
+	}{
+		{"short names", "s=Hello&a=Adele&al=25&d=295&v=YQHsXMglC9A", Query{"Hello", "Adele", "25", "295", "YQHsXMglC9A"}, nil},
+		{"long names", "songName=Hello&artistName=Adele&albumName=25&duration=295&videoId=abc", Query{"Hello", "Adele", "25", "295", "abc"}, nil},
+		{"first alias wins", "s=Hello&song=Someone+Like+You&a=Adele", Query{Song: "Hello", Artist: "Adele"}, nil},
+		{"blank alias skipped", "s=+&song=Hello&a=Adele", Query{Song: "Hello", Artist: "Adele"}, nil},
+		{"trimmed", "s=+Hello+&a=Adele+", Query{Song: "Hello", Artist: "Adele"}, nil},
+		{"fractional duration", "s=Hello&d=295.7", Query{Song: "Hello", Duration: "295"}, nil},
+		{"invalid duration", "s=Hello&d=abc", Query{Song: "Hello"}, ErrInvalidDuration},
+		{"negative duration", "s=Hello&d=-5", Query{Song: "Hello"}, ErrInvalidDuration},
+		{"empty", "", Query{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			got, err := Parse(values)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse(%q) = %+v, %v; want %+v, %v", tt.query, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryValues(t *testing.T) {
+	q := Query{Song: "Hello", Artist: "Adele", Duration: "295", VideoID: "abc"}
+	if got := q.Values().Encode(); got != "a=Adele&d=295&s=Hello" {
+		t.Errorf("Values() = %q", got)
+	}
+	if !q.HasTrack() || !q.HasSongAndArtist() {
+		t.Error("Expected a track with song and artist")
+	}
+	if (Query{Artist: "Adele"}).HasSongAndArtist() {
+		t.Error("An artist alone is not a song and artist")
+	}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"lyrics-api-go/cache"
+	"lyrics-api-go/clock"
 	"lyrics-api-go/config"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/logging"
 	"lyrics-api-go/middleware"
+	"lyrics-api-go/redact"
 	"lyrics-api-go/services/notifier"
 	"lyrics-api-go/services/providers/ttml"
 	"lyrics-api-go/stats"
@@ -21,13 +24,20 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-var conf = config.Get()
+// conf returns the active config. Read it at use time rather than keeping a copy:
+// a remote config reload swaps it.
+func conf() *config.Config {
+	return config.Current()
+}
 
 var (
 	persistentCache *cache.PersistentCache
 	cacheStats      *cache.StatsCache
 	statsStore      *stats.Store
 	inFlightReqs    sync.Map
+
+	// clk is the time source for negative cache TTLs; tests swap in a fake
+	clk clock.Clock = clock.Real{}
 )
 
 func init() {
@@ -41,61 +51,126 @@ func init() {
 	// Configure logger based on feature flag
 	cfg := config.Get()
 	if cfg.FeatureFlags.PrettyLogs {
-		log.SetFormatter(&log.TextFormatter{
+		log.SetFormatter(redact.Formatter{Formatter: &log.TextFormatter{
 			ForceColors:     true,
 			FullTimestamp:   true,
 			TimestampFormat: "15:04:05",
-		})
+		}})
 	} else {
-		log.SetFormatter(&log.JSONFormatter{})
+		log.SetFormatter(redact.Formatter{Formatter: &log.JSONFormatter{}})
 	}
+	registerSecrets(&cfg)
 	log.SetOutput(os.Stdout)
 	log.SetLevel(log.InfoLevel)
+	if err := logging.Configure(cfg.Configuration.LogLevel); err != nil {
+		log.Warnf("%s Ignoring LOG_LEVEL %q: %v", logcolors.LogConfig, cfg.Configuration.LogLevel, err)
+	}
+	if err := logging.ConfigureThrottle(cfg.Configuration.LogThrottle); err != nil {
+		log.Warnf("%s Ignoring LOG_THROTTLE %q: %v", logcolors.LogConfig, cfg.Configuration.LogThrottle, err)
+	}
 }
 
 func main() {
-	// Initialize persistent cache
+	// Initialize alert handler for system notifications first, so startup problems
+	// (cache lock contention, failed initialization) reach it
+	alertNotifiers := setupNotifiers()
+	if len(alertNotifiers) > 0 {
+		alertHandler := notifier.NewAlertHandler(notifier.AlertConfig{
+			Notifiers:        alertNotifiers,
+			CooldownDuration: 15 * time.Minute,
+		})
+		alertHandler.Start()
+		log.Infof("%s Alert handler initialized with %d notifier(s)", logcolors.LogNotifier, len(alertNotifiers))
+	}
+
+	// Initialize persistent cache. Opening waits for the file lock in bounded
+	// attempts, so a second instance holding cache.db fails startup loudly
+	// instead of hanging.
 	var err error
 	cachePath := getEnvOrDefault("CACHE_DB_PATH", "./cache.db")
 	backupPath := getEnvOrDefault("CACHE_BACKUP_PATH", "./backups")
-	persistentCache, err = cache.NewPersistentCache(cachePath, backupPath, conf.FeatureFlags.CacheCompression)
+	cache.SetLockWait(cache.LockWait{
+		Timeout:      time.Duration(conf().Configuration.CacheOpenTimeoutSecs) * time.Second,
+		Attempts:     conf().Configuration.CacheOpenAttempts,
+		Backoff:      2 * time.Second,
+		OnContention: notifier.PublishDatabaseLocked,
+	})
+	persistentCache, err = cache.NewPersistentCache(cachePath, backupPath, conf().FeatureFlags.CacheCompression)
 	if err != nil {
 		notifier.PublishServerStartupFailed("cache", err)
+		notifier.GetEventBus().Drain()
 		log.Fatalf("Failed to initialize cache: %v", err)
 	}
 	defer persistentCache.Close()
+	if err := configureBackupEncryption(persistentCache); err != nil {
+		notifier.PublishServerStartupFailed("backup_encryption", err)
+		notifier.GetEventBus().Drain()
+		log.Fatalf("Failed to set up backup encryption: %v", err)
+	}
+	persistentCache.SetCorruptionHandler(onCacheCorruption)
+	startStartupCacheVerify(conf().Configuration.CacheVerifyOnStartup)
 
 	// Initialize stats store (separate from cache to preserve stats across cache clears)
+	// and load persisted stats from previous runs. A corrupt stats DB is moved aside
+	// so startup continues with fresh stats; /stats/salvage recovers its counters.
 	statsPath := getEnvOrDefault("STATS_DB_PATH", "./stats.db")
-	statsStore, err = stats.NewStore(statsPath)
+	var recovery *stats.Recovery
+	statsStore, recovery, err = stats.OpenStore(statsPath)
 	if err != nil {
 		notifier.PublishServerStartupFailed("stats_store", err)
+		notifier.GetEventBus().Drain()
 		log.Fatalf("Failed to initialize stats store: %v", err)
 	}
-	defer statsStore.Close()
-
-	// Load persisted stats from previous runs
-	if err := statsStore.Load(); err != nil {
-		log.Warnf("%s Failed to load persisted stats: %v", logcolors.LogStats, err)
+	if recovery != nil {
+		notifier.PublishStatsDBRecovered(statsPath, recovery.MovedTo, recovery.Cause)
 	}
+	defer statsStore.Close()
 
 	// Start auto-saving stats every 5 minutes
 	statsStore.StartAutoSave(5 * time.Minute)
 
-	// Initialize alert handler for system notifications
-	alertNotifiers := setupNotifiers()
+	// Event bus consumers (stats, webhook)
+	registerEventSubscribers()
+
 	if len(alertNotifiers) > 0 {
-		alertHandler := notifier.NewAlertHandler(notifier.AlertConfig{
-			Notifiers:        alertNotifiers,
-			CooldownDuration: 15 * time.Minute,
-		})
-		alertHandler.Start()
-		log.Infof("%s Alert handler initialized with %d notifier(s)", logcolors.LogNotifier, len(alertNotifiers))
+		// Rolling-window monitors for cache hit rate, upstream error rate and schema drift
+		notifier.NewRateMonitor(notifier.RateMonitorConfig{
+			Window:               time.Duration(conf().Configuration.AlertWindowMinutes) * time.Minute,
+			Interval:             time.Minute,
+			MinCacheHitRate:      conf().Configuration.AlertMinCacheHitRate,
+			MaxUpstreamErrorRate: conf().Configuration.AlertMaxUpstreamErrorRate,
+			MaxMalformedRate:     conf().Configuration.AlertMaxMalformedRate,
+			MinSamples:           conf().Configuration.AlertMinSamples,
+			Read: func() notifier.RateCounters {
+				s := stats.Get()
+				return notifier.RateCounters{
+					CacheHits:        s.CacheHits.Load(),
+					CacheMisses:      s.CacheMisses.Load(),
+					UpstreamRequests: s.UpstreamRequests.Load(),
+					UpstreamErrors:   s.UpstreamErrors.Load(),
+					Malformed:        s.UpstreamMalformed.Load(),
+				}
+			},
+		}).Start()
 	}
 
 	// Initialize metadata and indexes buckets (separate from cache bucket)
 	initMetadataBuckets()
 
+	// Recent-attempt markers survive restarts; the grace period starts now
+	initRecentAttemptsBucket()
+	serverStartedAt = clk.Now()
+	initLearnedAliasesBucket()
+	initFailedLookupsBucket()
+	initLyricsChangesBucket()
+	initFormatVariantsBucket()
+
+	// Deleted entries stay restorable for TRASH_RETENTION_HOURS
+	startTrashPurger()
+
+	// Searches that resolve to an already-cached track reuse its lyrics blob
+	ttml.SetTrackLyricsLookup(getTrackLyrics)
+
 	// Counter reconciliation loop. Counters are live (updated transactionally with
 	// Set/Delete) so /stats is microseconds. The weekly reconcile only corrects
 	// drift from rare type-flips.
@@ -108,8 +183,32 @@ func main() {
 	// Start MUT health check scheduler (daily canary checks)
 	ttml.StartHealthCheckScheduler()
 
+	// Re-check account storefronts weekly so subscription region changes are picked up
+	ttml.StartStorefrontRevalidation()
+
+	// Re-enable disabled accounts once their MUT passes the canary again
+	ttml.StartDisabledAccountProbe()
+
+	// Centrally managed accounts and thresholds, shared by every replica
+	config.OnReload(applyReloadedConfig)
+	config.StartRemoteReload(conf().Configuration.RemoteConfigURL, conf().Configuration.RemoteConfigSecret,
+		time.Duration(conf().Configuration.RemoteConfigPollSecs)*time.Second)
+
+	// Refill a fresh or restored cache with the most requested lookups
+	startStartupCacheWarmup()
+
 	// Start memory monitor (logs RSS, alerts at threshold)
 	startMemoryMonitor(cachePath)
+	startDiskMonitor(cachePath, backupPath)
+
+	// Unauthenticated profiling port, meant to be bound to localhost only
+	startProfilingListener(conf().Configuration.PprofListenAddr)
+
+	// gRPC transport for internal consumers, sharing the lookup code of the HTTP handlers
+	startGRPCServer(conf().Configuration.GRPCPort)
+
+	// /setup/check reports this probe rather than writing to the disk on every hit
+	startupStorageCheck()
 
 	router := mux.NewRouter()
 	setupRoutes(router)
@@ -124,13 +223,14 @@ func main() {
 		AllowCredentials: true,
 	})
 
-	limiter := middleware.NewIPRateLimiter(
-		rate.Limit(conf.Configuration.RateLimitPerSecond),
-		conf.Configuration.RateLimitBurstLimit,
-		rate.Limit(conf.Configuration.CachedRateLimitPerSecond),
-		conf.Configuration.CachedRateLimitBurstLimit,
+	rateLimiter = middleware.NewIPRateLimiter(
+		rate.Limit(conf().Configuration.RateLimitPerSecond),
+		conf().Configuration.RateLimitBurstLimit,
+		rate.Limit(conf().Configuration.CachedRateLimitPerSecond),
+		conf().Configuration.CachedRateLimitBurstLimit,
 	)
-	limiter.StartCleanup(5*time.Minute, 10*time.Minute)
+	applyPersistedRateLimits(rateLimiter)
+	rateLimiter.StartCleanup(5*time.Minute, 10*time.Minute)
 
 	loggedRouter := middleware.LoggingMiddleware(router)
 	corsHandler := c.Handler(loggedRouter)
@@ -138,19 +238,20 @@ func main() {
 	// API key middleware - if API_KEY_REQUIRED is true, protected paths require API key
 	// for cache misses. Cache hits are served without API key (cache-first approach).
 	apiKeyHandler := middleware.APIKeyMiddleware(
-		conf.Configuration.APIKey,
-		conf.Configuration.APIKeyRequired,
+		validAPIKeys(),
+		conf().Configuration.APIKeyRequired,
 		config.APIKeyProtectedPaths,
 		apiKeyRequiredForFreshKey,
 		apiKeyAuthenticatedKey,
 		apiKeyInvalidKey,
-	)(corsHandler)
+	)(pressureMiddleware(corsHandler))
 
-	handler := limitMiddleware(apiKeyHandler, limiter)
+	handler := middleware.ClientVersionMiddleware(clientInfoKey)(apiV1Middleware(tenantMiddleware(limitMiddleware(apiKeyHandler, rateLimiter))))
+	handler = bandwidthMiddleware(router, handler)
 
 	// Get account info for startup notification
-	activeAccounts, _ := conf.GetTTMLAccounts()
-	allAccounts, _ := conf.GetAllTTMLAccounts()
+	activeAccounts, _ := conf().GetTTMLAccounts()
+	allAccounts, _ := conf().GetAllTTMLAccounts()
 
 	// Collect out-of-service account names
 	var outOfServiceNames []string
@@ -161,18 +262,18 @@ func main() {
 	}
 
 	// Log API key status
-	if conf.Configuration.APIKeyRequired {
-		if conf.Configuration.APIKey != "" {
+	if conf().Configuration.APIKeyRequired {
+		if conf().Configuration.APIKey != "" {
 			log.Infof("%s API key required for cache misses on paths: %v", logcolors.LogAPIKey, config.APIKeyProtectedPaths)
 		} else {
 			log.Warnf("%s API key required but not configured!", logcolors.LogAPIKey)
 		}
-	} else if conf.Configuration.APIKey != "" {
+	} else if conf().Configuration.APIKey != "" {
 		log.Infof("%s API key configured for rate limit bypass only", logcolors.LogAPIKey)
 	}
 
 	// Log cache-only mode status
-	if conf.FeatureFlags.CacheOnlyMode {
+	if conf().FeatureFlags.CacheOnlyMode {
 		log.Warnf("%s FF_CACHE_ONLY_MODE is enabled - all upstream requests are disabled, serving from cache only", logcolors.LogWarning)
 	}
 
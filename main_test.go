@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"lyrics-api-go/cache"
+	"lyrics-api-go/internal/clocktest"
+	"lyrics-api-go/middleware"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
@@ -26,12 +28,24 @@ func setupTestEnvironment(t *testing.T) func() {
 	if err != nil {
 		t.Fatalf("Failed to create test cache: %v", err)
 	}
+	initLyricsIndexBucket()
 
 	return func() {
 		persistentCache.Close()
 	}
 }
 
+// waitForPendingWrites waits for the background cache writes started by goWrite
+func waitForPendingWrites(t *testing.T) {
+	t.Helper()
+	for i := 0; i < 500 && pendingWrites.Load() != 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if pendingWrites.Load() != 0 {
+		t.Fatal("Background cache writes did not finish")
+	}
+}
+
 func TestShouldNegativeCache(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -83,6 +97,11 @@ func TestShouldNegativeCache(t *testing.T) {
 			err:      errors.New("context deadline exceeded"),
 			expected: false,
 		},
+		{
+			name:     "schema drift - should not cache",
+			err:      errors.New("failed to fetch TTML: upstream schema drift in lyrics response: empty data array on a 200 response"),
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -150,6 +169,54 @@ func TestNegativeCacheExpiration(t *testing.T) {
 	}
 }
 
+func TestNegativeCacheExpiration_FakeClock(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	fake := clocktest.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	savedClock := clk
+	clk = fake
+	defer func() { clk = savedClock }()
+
+	cacheKey := "ttml_lyrics:clock song artist"
+	setNegativeCache(cacheKey, "no track found", "", false)
+	ttl := time.Duration(conf().Configuration.NegativeCacheTTLInDays) * 24 * time.Hour
+
+	fake.Advance(ttl)
+	if _, found := getNegativeCache(cacheKey); !found {
+		t.Fatal("Expected entry to still be cached exactly at TTL")
+	}
+
+	fake.Advance(time.Second)
+	if _, found := getNegativeCache(cacheKey); found {
+		t.Error("Expected entry to expire one second past TTL")
+	}
+}
+
+func TestNegativeCacheTTL_NewSongTiers_FakeClock(t *testing.T) {
+	fake := clocktest.NewFake(time.Date(2025, 3, 10, 2, 0, 0, 0, time.UTC))
+	savedClock := clk
+	clk = fake
+	defer func() { clk = savedClock }()
+
+	tests := []struct {
+		releaseDate string
+		expected    int64
+	}{
+		{"2025-03-07", 6 * 60 * 60},      // 3 days
+		{"2025-03-06", 12 * 60 * 60},     // 4 days, even at 2am
+		{"2025-02-28", 24 * 60 * 60},     // 10 days
+		{"2025-02-20", 3 * 24 * 60 * 60}, // 18 days
+	}
+
+	for _, tt := range tests {
+		entry := NegativeCacheEntry{ReleaseDate: tt.releaseDate, HasTimeSyncedLyricsKnown: true}
+		if got := getNegativeCacheTTLSeconds(entry); got != tt.expected {
+			t.Errorf("release %s: TTL = %d, want %d", tt.releaseDate, got, tt.expected)
+		}
+	}
+}
+
 func TestNegativeCacheNotExpired(t *testing.T) {
 	cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -223,6 +290,65 @@ func TestNegativeCacheKeyFormat(t *testing.T) {
 	}
 }
 
+func TestFindStaleFallback_NoDurationVariant(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// Only the album-less, duration-less entry exists
+	setCachedLyrics(buildNormalizedCacheKey("Song", "Artist", "", ""), "<tt>fallback</tt>", 0, 0, "", false)
+
+	originalKey := buildNormalizedCacheKey("Song", "Artist", "Album", "200")
+	cached, key, ok := findStaleFallback("Song", "Artist", "Album", "200", originalKey)
+	if !ok {
+		t.Fatal("Expected a fallback hit")
+	}
+	if key != "ttml_lyrics:song artist" || cached.TTML != "<tt>fallback</tt>" {
+		t.Errorf("Got key %q, TTML %q", key, cached.TTML)
+	}
+
+	if _, _, ok := findStaleFallback("Other", "Artist", "Album", "200", buildNormalizedCacheKey("Other", "Artist", "Album", "200")); ok {
+		t.Error("Expected no fallback for an uncached song")
+	}
+}
+
+func TestFindStaleFallback_MaxStaleAge(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	origClk := clk
+	clk = fake
+	defer func() { clk = origClk }()
+	originalMaxAge := conf().Configuration.MaxStaleAgeDays
+	conf().Configuration.MaxStaleAgeDays = 7
+	defer func() { conf().Configuration.MaxStaleAgeDays = originalMaxAge }()
+
+	originalKey := buildNormalizedCacheKey("Song", "Artist", "Album", "200")
+	setCachedLyrics(buildNormalizedCacheKey("Song", "Artist", "", ""), "<tt>old</tt>", 0, 0, "", false)
+	fake.Advance(6 * 24 * time.Hour)
+	setCachedLyrics(buildNormalizedCacheKey("Song", "Artist", "", "200"), "<tt>recent</tt>", 0, 0, "", false)
+
+	fake.Advance(2 * 24 * time.Hour)
+	cached, _, ok := findStaleFallback("Song", "Artist", "Album", "200", originalKey)
+	if !ok || cached.TTML != "<tt>recent</tt>" {
+		t.Fatalf("Expected the recent entry, got %+v", cached)
+	}
+	if age := cacheEntryAge(cached); age != 2*24*time.Hour {
+		t.Errorf("Entry age = %v, want 48h", age)
+	}
+
+	// Once both are beyond the window nothing is served
+	fake.Advance(6 * 24 * time.Hour)
+	if cached, _, ok := findStaleFallback("Song", "Artist", "Album", "200", originalKey); ok {
+		t.Errorf("Expected no fallback beyond MAX_STALE_AGE_DAYS, got %+v", cached)
+	}
+
+	// Entries without a storedAt are served regardless
+	persistentCache.Set(buildNormalizedCacheKey("Song", "Artist", "", ""), `{"ttml":"<tt>legacy</tt>"}`)
+	if cached, _, ok := findStaleFallback("Song", "Artist", "Album", "200", originalKey); !ok || cached.TTML != "<tt>legacy</tt>" {
+		t.Errorf("Expected the entry without storedAt, got %+v", cached)
+	}
+}
+
 func TestBuildFallbackCacheKeys(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -240,7 +366,14 @@ func TestBuildFallbackCacheKeys(t *testing.T) {
 			albumName:   "Divide",
 			durationStr: "234",
 			originalKey: "ttml_lyrics:shape of you ed sheeran divide 234s",
-			expected:    []string{"ttml_lyrics:shape of you ed sheeran 234s"},
+			expected: []string{
+				"ttml_lyrics:shape of you ed sheeran 234s",
+				"ttml_lyrics:Shape of You Ed Sheeran  234s",
+				"ttml_lyrics:shape of you ed sheeran divide",
+				"ttml_lyrics:Shape of You Ed Sheeran Divide",
+				"ttml_lyrics:shape of you ed sheeran",
+				"ttml_lyrics:Shape of You Ed Sheeran ",
+			},
 		},
 		{
 			name:        "With album, no duration",
@@ -249,16 +382,16 @@ func TestBuildFallbackCacheKeys(t *testing.T) {
 			albumName:   "Divide",
 			durationStr: "",
 			originalKey: "ttml_lyrics:shape of you ed sheeran divide",
-			expected:    []string{"ttml_lyrics:shape of you ed sheeran"},
+			expected:    []string{"ttml_lyrics:shape of you ed sheeran", "ttml_lyrics:Shape of You Ed Sheeran "},
 		},
 		{
-			name:        "No album - no fallback",
+			name:        "No album - falls back to no duration",
 			songName:    "Shape of You",
 			artistName:  "Ed Sheeran",
 			albumName:   "",
 			durationStr: "234",
 			originalKey: "ttml_lyrics:shape of you ed sheeran 234s",
-			expected:    []string{},
+			expected:    []string{"ttml_lyrics:shape of you ed sheeran", "ttml_lyrics:Shape of You Ed Sheeran "},
 		},
 		{
 			name:        "No album, no duration - no fallback",
@@ -1103,3 +1236,113 @@ func TestGetLyrics_NoLyricsSentinelReturns404(t *testing.T) {
 		t.Errorf("Expected 'No lyrics available' error, got %q", body["error"])
 	}
 }
+
+func TestLimitMiddleware_HeadersOnEveryTier(t *testing.T) {
+	limiter := middleware.NewIPRateLimiter(0.001, 1, 0.001, 1)
+	var sawTier []string
+	handler := limitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sawTier = append(sawTier, r.Context().Value(rateLimitTypeKey).(string))
+	}), limiter)
+
+	expected := []string{"normal", "cached", "exceeded"}
+	for i, tier := range expected {
+		req := httptest.NewRequest("GET", "/getLyrics", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		for _, h := range []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"} {
+			if rr.Header().Get(h) == "" {
+				t.Errorf("Request %d: missing %s", i, h)
+			}
+		}
+		if got := rr.Header().Get("X-RateLimit-Type"); got != tier {
+			t.Errorf("Request %d: X-RateLimit-Type = %q, want %q", i, got, tier)
+		}
+	}
+	if len(sawTier) != 2 {
+		t.Errorf("Expected 2 requests to reach the handler, got %v", sawTier)
+	}
+}
+
+func TestLimitMiddleware_ExceededBody(t *testing.T) {
+	limiter := middleware.NewIPRateLimiter(0.001, 1, 0.001, 1)
+	handler := limitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), limiter)
+
+	var rr *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/getLyrics", nil)
+		req.RemoteAddr = "192.0.2.2:1234"
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+	}
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header")
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Expected JSON body: %v", err)
+	}
+	if body["tier"] != "cached" || body["error"] == nil || body["retry_after"] == nil {
+		t.Errorf("Unexpected 429 body: %v", body)
+	}
+}
+
+func TestGetDurationlessCachedLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	setCachedLyrics(buildNormalizedCacheKey("Song", "Artist", "", ""), "<tt>radio edit</tt>", 232000, 0.9, "en", false)
+	setCachedLyrics(buildNormalizedCacheKey("Unknown Length", "Artist", "", ""), "<tt>x</tt>", 0, 0.9, "en", false)
+
+	tests := []struct {
+		name     string
+		song     string
+		duration string
+		want     bool
+	}{
+		{"duration agrees", "Song", "233", true},
+		{"duration mismatch refetches", "Song", "260", false},
+		{"no duration requested", "Song", "", false},
+		{"cached duration unknown", "Unknown Length", "232", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cached, _, found := getDurationlessCachedLyrics(tt.song, "Artist", "", tt.duration)
+			if found != tt.want {
+				t.Fatalf("found = %v, want %v", found, tt.want)
+			}
+			if found && cached.TTML != "<tt>radio edit</tt>" {
+				t.Errorf("Unexpected TTML %q", cached.TTML)
+			}
+		})
+	}
+}
+
+func TestSplitDurationlessEntry(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	durationlessKey := buildNormalizedCacheKey("Song", "Artist", "", "")
+	setCachedLyricsForTrack(durationlessKey, "111", "<tt>radio edit</tt>", 232000, 0.9, "en", false)
+
+	// Same edit: nothing to split
+	splitDurationlessEntry("Song", "Artist", "", 233000)
+	if _, ok := getCachedLyrics(buildNormalizedCacheKey("Song", "Artist", "", "232")); ok {
+		t.Fatal("A matching refetch must not add a duration-scoped key")
+	}
+
+	// Refetch found a different (extended) edit: the old one gets its own duration key
+	splitDurationlessEntry("Song", "Artist", "", 301000)
+	cached, ok := getCachedLyrics(buildNormalizedCacheKey("Song", "Artist", "", "232"))
+	if !ok || cached.TTML != "<tt>radio edit</tt>" {
+		t.Fatalf("Expected the radio edit under its duration key, got %+v (%v)", cached, ok)
+	}
+	if cached, _ := getCachedLyrics(durationlessKey); cached.TTML != "<tt>radio edit</tt>" {
+		t.Errorf("Durationless entry must be left in place, got %q", cached.TTML)
+	}
+}
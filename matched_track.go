@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Successful lookups name the track they matched, in the body (trackId, trackName
+// next to score) and in X-Matched-Track-Id, X-Matched-Track-Name and X-Match-Score,
+// so a "wrong lyrics" report that only captured the headers says what was matched.
+
+// withMatchedTrack adds the matched Apple Music track to a lyrics body
+func withMatchedTrack(body map[string]interface{}, trackID, trackName string) map[string]interface{} {
+	if trackID != "" {
+		body["trackId"] = trackID
+	}
+	if trackName != "" {
+		body["trackName"] = trackName
+	}
+	return body
+}
+
+// withCachedMatchedTrack adds the track a cache entry was stored for, from its
+// metadata, to a lyrics body
+func withCachedMatchedTrack(body map[string]interface{}, cacheKey string) map[string]interface{} {
+	if meta, ok := getSongMetadata(cacheKey); ok {
+		withMatchedTrack(body, meta.AppleTrackID, meta.TrackName)
+	}
+	return body
+}
+
+// setMatchedTrackHeaders sets the match headers from a lyrics body. Track names are
+// percent-encoded, since header values are ASCII.
+func setMatchedTrackHeaders(w http.ResponseWriter, body map[string]interface{}) {
+	if trackID, ok := body["trackId"].(string); ok && trackID != "" {
+		w.Header().Set("X-Matched-Track-Id", trackID)
+	}
+	if trackName, ok := body["trackName"].(string); ok && trackName != "" {
+		w.Header().Set("X-Matched-Track-Name", url.PathEscape(trackName))
+	}
+	if score, ok := body["score"].(float64); ok && score > 0 {
+		w.Header().Set("X-Match-Score", strconv.FormatFloat(score, 'f', -1, 64))
+	}
+}
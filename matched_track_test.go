@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetLyrics_MatchedTrackHeaders(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	initMetadataBuckets()
+
+	matchedKey := buildNormalizedCacheKey("Matched", "Artist", "", "")
+	setCachedLyrics(matchedKey, testTTML, 0, 0.87, "", false)
+	setSongMetadata(&SongMetadata{CacheKey: matchedKey, AppleTrackID: "1440857781", TrackName: "Café del Mar"})
+	setCachedLyrics(buildNormalizedCacheKey("Unknown", "Artist", "", ""), testTTML, 0, 0.5, "", false)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantID    string
+		wantName  string
+		wantScore string
+	}{
+		{"with metadata", "s=Matched&a=Artist", "1440857781", "Caf%C3%A9%20del%20Mar", "0.87"},
+		{"without metadata", "s=Unknown&a=Artist", "", "", "0.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?"+tt.query, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+			}
+			h := rr.Header()
+			if h.Get("X-Matched-Track-Id") != tt.wantID || h.Get("X-Matched-Track-Name") != tt.wantName || h.Get("X-Match-Score") != tt.wantScore {
+				t.Errorf("headers id=%q name=%q score=%q", h.Get("X-Matched-Track-Id"), h.Get("X-Matched-Track-Name"), h.Get("X-Match-Score"))
+			}
+
+			var body map[string]interface{}
+			json.Unmarshal(rr.Body.Bytes(), &body)
+			if tt.wantID != "" && (body["trackId"] != tt.wantID || body["trackName"] != "Café del Mar") {
+				t.Errorf("body trackId=%v trackName=%v", body["trackId"], body["trackName"])
+			}
+			if _, ok := body["trackId"]; tt.wantID == "" && ok {
+				t.Errorf("Unexpected trackId %v", body["trackId"])
+			}
+		})
+	}
+}
@@ -18,7 +18,7 @@ const (
 	indexesBucket  = "indexes"
 )
 
-// initMetadataBuckets creates the metadata and indexes buckets if they don't exist.
+// initMetadataBuckets creates the metadata, indexes and lyrics index buckets if they don't exist.
 // Called during server startup after persistentCache is initialized.
 func initMetadataBuckets() {
 	if err := persistentCache.CreateBucket(metadataBucket); err != nil {
@@ -29,6 +29,7 @@ func initMetadataBuckets() {
 		log.Errorf("%s Failed to create indexes bucket: %v", logcolors.LogCache, err)
 		return
 	}
+	initLyricsIndexBucket()
 	log.Infof("%s Metadata and indexes buckets initialized", logcolors.LogCache)
 }
 
@@ -83,9 +84,12 @@ func setSongMetadata(meta *SongMetadata) {
 
 	now := time.Now().Unix()
 
-	// Check if existing metadata exists to preserve FirstSeen and merge VideoIDs
+	// Check if existing metadata exists to preserve FirstSeen, the lyrics revision and merge VideoIDs
 	if existing, ok := getSongMetadata(meta.CacheKey); ok {
 		meta.FirstSeen = existing.FirstSeen
+		if existing.LyricsRevision > meta.LyricsRevision {
+			meta.LyricsRevision, meta.LyricsChangedAt = existing.LyricsRevision, existing.LyricsChangedAt
+		}
 		// Merge video IDs
 		meta.VideoIDs = mergeStringSlice(existing.VideoIDs, meta.VideoIDs)
 	} else {
@@ -22,11 +22,11 @@ func setupTestMetadata(t *testing.T) func() {
 
 	// Neutralize auth config for tests. A non-empty CACHE_ACCESS_TOKEN loaded
 	// from the project's real .env would otherwise make every handler test 401.
-	origToken := conf.Configuration.CacheAccessToken
-	conf.Configuration.CacheAccessToken = ""
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = ""
 
 	return func() {
-		conf.Configuration.CacheAccessToken = origToken
+		conf().Configuration.CacheAccessToken = origToken
 		persistentCache.Close()
 		os.Remove(tmpFile)
 	}
@@ -46,7 +46,7 @@ func TestGetNegativeCacheTTLSeconds(t *testing.T) {
 				ReleaseDate:              time.Now().UTC().Format("2006-01-02"),
 				HasTimeSyncedLyricsKnown: false,
 			},
-			expected: int64(conf.Configuration.NegativeCacheTTLInDays * 24 * 60 * 60),
+			expected: int64(conf().Configuration.NegativeCacheTTLInDays * 24 * 60 * 60),
 		},
 		{
 			name: "default TTL when releaseDate is empty",
@@ -56,7 +56,7 @@ func TestGetNegativeCacheTTLSeconds(t *testing.T) {
 				ReleaseDate:              "",
 				HasTimeSyncedLyricsKnown: true,
 			},
-			expected: int64(conf.Configuration.NegativeCacheTTLInDays * 24 * 60 * 60),
+			expected: int64(conf().Configuration.NegativeCacheTTLInDays * 24 * 60 * 60),
 		},
 		{
 			name: "6 hour TTL for song released today",
@@ -116,7 +116,7 @@ func TestGetNegativeCacheTTLSeconds(t *testing.T) {
 				ReleaseDate:              time.Now().UTC().AddDate(0, 0, -60).Format("2006-01-02"),
 				HasTimeSyncedLyricsKnown: true,
 			},
-			expected: int64(conf.Configuration.NegativeCacheTTLInDays * 24 * 60 * 60),
+			expected: int64(conf().Configuration.NegativeCacheTTLInDays * 24 * 60 * 60),
 		},
 		{
 			name: "default TTL for invalid releaseDate",
@@ -126,7 +126,7 @@ func TestGetNegativeCacheTTLSeconds(t *testing.T) {
 				ReleaseDate:              "not-a-date",
 				HasTimeSyncedLyricsKnown: true,
 			},
-			expected: int64(conf.Configuration.NegativeCacheTTLInDays * 24 * 60 * 60),
+			expected: int64(conf().Configuration.NegativeCacheTTLInDays * 24 * 60 * 60),
 		},
 	}
 
@@ -329,8 +329,8 @@ func TestGetNegativeCacheTTLBoundaryDays(t *testing.T) {
 		{"day 14 (boundary)", 14, 24 * 60 * 60},
 		{"day 15 (into 3d tier)", 15, 3 * 24 * 60 * 60},
 		{"day 29 (last day in threshold)", 29, 3 * 24 * 60 * 60},
-		{"day 30 (at threshold)", 30, int64(conf.Configuration.NegativeCacheTTLInDays * 24 * 60 * 60)},
-		{"day 31 (past threshold)", 31, int64(conf.Configuration.NegativeCacheTTLInDays * 24 * 60 * 60)},
+		{"day 30 (at threshold)", 30, int64(conf().Configuration.NegativeCacheTTLInDays * 24 * 60 * 60)},
+		{"day 31 (past threshold)", 31, int64(conf().Configuration.NegativeCacheTTLInDays * 24 * 60 * 60)},
 	}
 
 	for _, tt := range tests {
@@ -601,9 +601,9 @@ func TestMetadataStatsHandler_Empty(t *testing.T) {
 	cleanup := setupTestMetadata(t)
 	defer cleanup()
 
-	origToken := conf.Configuration.CacheAccessToken
-	conf.Configuration.CacheAccessToken = "test-token"
-	defer func() { conf.Configuration.CacheAccessToken = origToken }()
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
 
 	req := httptest.NewRequest(http.MethodGet, "/metadata/stats", nil)
 	req.Header.Set("Authorization", "test-token")
@@ -632,9 +632,9 @@ func TestMetadataStatsHandler_WithEntries(t *testing.T) {
 	cleanup := setupTestMetadata(t)
 	defer cleanup()
 
-	origToken := conf.Configuration.CacheAccessToken
-	conf.Configuration.CacheAccessToken = "test-token"
-	defer func() { conf.Configuration.CacheAccessToken = origToken }()
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
 
 	setSongMetadata(&SongMetadata{
 		CacheKey:      "ttml_lyrics:rich song artist",
@@ -704,9 +704,9 @@ func TestMetadataStatsHandler_Unauthorized(t *testing.T) {
 	cleanup := setupTestMetadata(t)
 	defer cleanup()
 
-	origToken := conf.Configuration.CacheAccessToken
-	conf.Configuration.CacheAccessToken = "test-token"
-	defer func() { conf.Configuration.CacheAccessToken = origToken }()
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
 
 	req := httptest.NewRequest(http.MethodGet, "/metadata/stats", nil)
 	req.Header.Set("Authorization", "bad-token")
@@ -728,9 +728,9 @@ func TestMetadataSampleHandler(t *testing.T) {
 	cleanup := setupTestMetadata(t)
 	defer cleanup()
 
-	origToken := conf.Configuration.CacheAccessToken
-	conf.Configuration.CacheAccessToken = "test-token"
-	defer func() { conf.Configuration.CacheAccessToken = origToken }()
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
 
 	for i := 0; i < 5; i++ {
 		setSongMetadata(&SongMetadata{
@@ -777,9 +777,9 @@ func TestMetadataSampleHandler_CapsAtMax(t *testing.T) {
 	cleanup := setupTestMetadata(t)
 	defer cleanup()
 
-	origToken := conf.Configuration.CacheAccessToken
-	conf.Configuration.CacheAccessToken = "test-token"
-	defer func() { conf.Configuration.CacheAccessToken = origToken }()
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
 
 	req := httptest.NewRequest(http.MethodGet, "/metadata/sample?n=99999", nil)
 	req.Header.Set("Authorization", "test-token")
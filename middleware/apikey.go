@@ -15,16 +15,22 @@ import (
 //
 // Behavior:
 // - If required is false, all requests pass through
-// - If required is true but apiKey is empty, logs warning and allows (misconfiguration)
+// - If required is true but apiKeys is empty, logs warning and allows (misconfiguration)
 // - If path is protected and no API key provided: sets requiredContextKey=true and proceeds (cache-first)
 // - If path is protected and wrong API key provided: sets invalidContextKey=true and proceeds (cache-first, but marked invalid)
 // - If path is protected and valid API key provided: sets authenticatedContextKey=true and proceeds
-func APIKeyMiddleware(apiKey string, required bool, protectedPaths []string, requiredContextKey interface{}, authenticatedContextKey interface{}, invalidContextKey interface{}) func(http.Handler) http.Handler {
+func APIKeyMiddleware(apiKeys []string, required bool, protectedPaths []string, requiredContextKey interface{}, authenticatedContextKey interface{}, invalidContextKey interface{}) func(http.Handler) http.Handler {
 	// Build a map for O(1) lookup of protected paths
 	protectedPathMap := make(map[string]bool)
 	for _, path := range protectedPaths {
 		protectedPathMap[path] = true
 	}
+	validKeys := make(map[string]bool)
+	for _, key := range apiKeys {
+		if key != "" {
+			validKeys[key] = true
+		}
+	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -35,7 +41,7 @@ func APIKeyMiddleware(apiKey string, required bool, protectedPaths []string, req
 			}
 
 			// If required but no API key configured, warn and allow (misconfiguration)
-			if apiKey == "" {
+			if len(validKeys) == 0 {
 				log.Warnf("%s API key required but not configured, allowing request", logcolors.LogAPIKey)
 				next.ServeHTTP(w, r)
 				return
@@ -74,7 +80,7 @@ func APIKeyMiddleware(apiKey string, required bool, protectedPaths []string, req
 			}
 
 			// API key provided but invalid - set context flag and proceed (handler will check cache first)
-			if providedKey != apiKey {
+			if !validKeys[providedKey] {
 				log.Debugf("%s Invalid API key from %s for %s, setting cache-first mode", logcolors.LogAPIKey, r.RemoteAddr, path)
 				ctx := context.WithValue(r.Context(), invalidContextKey, true)
 				ctx = context.WithValue(ctx, requiredContextKey, true)
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"lyrics-api-go/stats"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ClientInfo is the client a request came from, as named in its User-Agent
+type ClientInfo struct {
+	Name    string // Lowercased product name, e.g. "betterlyrics"
+	Version string // As sent, without a leading "v"; "" when absent
+}
+
+// browserProducts are User-Agent product tokens that name the browser or runtime
+// rather than the client calling the API
+var browserProducts = map[string]bool{
+	"mozilla": true, "applewebkit": true, "gecko": true, "khtml": true, "chrome": true,
+	"chromium": true, "safari": true, "firefox": true, "edg": true, "edge": true,
+	"opr": true, "version": true, "mobile": true, "yabrowser": true, "samsungbrowser": true,
+}
+
+// ParseClientUserAgent finds the client in a User-Agent: the first product token
+// ("name/version", or a bare name) that isn't a browser token, outside comments.
+// An extension that appends "BetterLyrics/2.3.1" to the browser's User-Agent is
+// betterlyrics 2.3.1; a plain browser has no client.
+func ParseClientUserAgent(userAgent string) (ClientInfo, bool) {
+	depth := 0
+	for _, token := range strings.Fields(userAgent) {
+		// Skip (comments), which may contain spaces and nest
+		opens, closes := strings.Count(token, "("), strings.Count(token, ")")
+		if depth > 0 || opens > 0 {
+			depth += opens - closes
+			if depth < 0 {
+				depth = 0
+			}
+			continue
+		}
+
+		name, version, _ := strings.Cut(token, "/")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || browserProducts[name] {
+			continue
+		}
+		return ClientInfo{Name: name, Version: strings.TrimPrefix(strings.ToLower(version), "v")}, true
+	}
+	return ClientInfo{}, false
+}
+
+// ClientVersionMiddleware puts the ClientInfo of requests that name a client in the
+// context under contextKey, and counts requests per client version in stats
+func ClientVersionMiddleware(contextKey interface{}) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, ok := ParseClientUserAgent(r.UserAgent())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			stats.Get().RecordClientVersion(client.Name, client.Version)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey, client)))
+		})
+	}
+}
+
+// CompareVersions compares dotted versions numerically ("2.10.0" > "2.9.1"). Missing
+// parts count as 0, and a part's non-numeric suffix ("1-beta") is ignored.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		x, y := versionPart(as, i), versionPart(bs, i)
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	digits := parts[i]
+	for j, c := range digits {
+		if c < '0' || c > '9' {
+			digits = digits[:j]
+			break
+		}
+	}
+	n, _ := strconv.Atoi(digits)
+	return n
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseClientUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      ClientInfo
+		ok        bool
+	}{
+		{"BetterLyrics/2.3.1", ClientInfo{"betterlyrics", "2.3.1"}, true},
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36 BetterLyrics/v1.9", ClientInfo{"betterlyrics", "1.9"}, true},
+		{"BetterLyricsMobile/3.0.0 (iPhone; iOS 17.5)", ClientInfo{"betterlyricsmobile", "3.0.0"}, true},
+		{"curl/8.4.0", ClientInfo{"curl", "8.4.0"}, true},
+		{"SomeBot", ClientInfo{"somebot", ""}, true},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36 Edg/126.0", ClientInfo{}, false},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.5; rv:127.0) Gecko/20100101 Firefox/127.0", ClientInfo{}, false},
+		{"", ClientInfo{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseClientUserAgent(tt.userAgent)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseClientUserAgent(%q) = %+v, %v; want %+v, %v", tt.userAgent, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.0.0", "2.0.0", 0},
+		{"2.0", "2.0.0", 0},
+		{"2.10.0", "2.9.1", 1},
+		{"1.9", "2.0.0", -1},
+		{"2.1.0-beta", "2.1.0", 0},
+		{"", "1.0", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClientVersionMiddleware(t *testing.T) {
+	type key string
+	var seen ClientInfo
+	var found bool
+	handler := ClientVersionMiddleware(key("client"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, found = r.Context().Value(key("client")).(ClientInfo)
+	}))
+
+	before := stats.Get().ClientVersions()["testclient"]["4.2.0"]
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics", nil)
+	req.Header.Set("User-Agent", "TestClient/4.2.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !found || seen.Name != "testclient" || seen.Version != "4.2.0" {
+		t.Errorf("context client = %+v (found %v)", seen, found)
+	}
+	if got := stats.Get().ClientVersions()["testclient"]["4.2.0"]; got != before+1 {
+		t.Errorf("client version count = %d, want %d", got, before+1)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/getLyrics", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) Gecko/20100101 Firefox/127.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if found {
+		t.Error("a plain browser request got a client in its context")
+	}
+}
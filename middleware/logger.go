@@ -2,9 +2,15 @@ package middleware
 
 import (
 	"fmt"
+	"lyrics-api-go/logging"
+	"lyrics-api-go/lyricsquery"
+	"lyrics-api-go/redact"
 	"lyrics-api-go/stats"
 	"net/http"
+	"strings"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // ResponseRecorder is a custom response writer that captures the status code and response size
@@ -49,13 +55,24 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		s.RecordStatusCode(rec.StatusCode)
 		s.RecordResponseTime(duration, r.URL.Path)
 		s.RecordUserAgent(r.UserAgent())
+		if provider, ok := lyricsUsageProvider(r.URL.Path, rec.Header().Get("X-Provider")); ok {
+			s.RecordUsage(usageQuery(r), provider, rec.Header().Get("X-Cache-Status"), duration)
+			if r.URL.Path == "/getLyrics" && rec.StatusCode == http.StatusOK {
+				s.RecordLookup(lookupQuery(r))
+			}
+		}
+
+		// The access log is the "http" component; quiet it with LOG_LEVEL=info,http=warn
+		if !logging.Enabled(logging.ComponentHTTP, log.InfoLevel) {
+			return
+		}
 
 		statusColor := getStatusColor(rec.StatusCode)
 		resetColor := "\033[0m"
 
 		fmt.Printf("%s %s %s %s%d%s %d %s\n",
 			r.Method,
-			r.URL,
+			redact.String(r.URL.String()),
 			r.Proto,
 			statusColor, rec.StatusCode, resetColor,
 			rec.BodySize,
@@ -64,6 +81,38 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// lyricsUsageProvider reports whether path is a lyrics endpoint counted in the usage
+// dataset, and which provider served it (X-Provider when set, else from the path)
+func lyricsUsageProvider(path, providerHeader string) (string, bool) {
+	if path != "/getLyrics" && !strings.HasSuffix(path, "/getLyrics") {
+		return "", false
+	}
+	if providerHeader != "" {
+		return providerHeader, true
+	}
+	if provider := strings.Trim(strings.TrimSuffix(path, "/getLyrics"), "/"); provider != "" {
+		return provider, true
+	}
+	return "ttml", true
+}
+
+// usageQuery builds the "song artist" query recorded in the usage dataset.
+// Only the song and artist params are used: no IPs, user agents or other params.
+func usageQuery(r *http.Request) string {
+	q, _ := lyricsquery.Parse(r.URL.Query())
+	return q.Song + " " + q.Artist
+}
+
+// lookupQuery encodes the params that identify a TTML lookup (song, artist,
+// album, duration) so startup warmup can repeat it. Other params are dropped.
+func lookupQuery(r *http.Request) string {
+	q, err := lyricsquery.Parse(r.URL.Query())
+	if err != nil || !q.HasSongAndArtist() {
+		return ""
+	}
+	return q.Values().Encode()
+}
+
 // getStatusColor returns the color code for a given status code
 func getStatusColor(status int) string {
 	switch {
@@ -317,3 +317,43 @@ func TestResponseRecorder_DefaultStatusCode(t *testing.T) {
 		t.Errorf("Expected default status code %d, got %d", http.StatusOK, rec.StatusCode)
 	}
 }
+
+func TestLyricsUsageProvider(t *testing.T) {
+	tests := []struct {
+		path     string
+		header   string
+		expected string
+		ok       bool
+	}{
+		{"/getLyrics", "", "ttml", true},
+		{"/getLyrics", "kugou", "kugou", true},
+		{"/kugou/getLyrics", "", "kugou", true},
+		{"/stats", "", "", false},
+		{"/cache/search", "ttml", "", false},
+	}
+	for _, tt := range tests {
+		provider, ok := lyricsUsageProvider(tt.path, tt.header)
+		if provider != tt.expected || ok != tt.ok {
+			t.Errorf("lyricsUsageProvider(%q, %q) = (%q, %v), want (%q, %v)", tt.path, tt.header, provider, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestUsageQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/getLyrics?s=Hello&a=Adele&duration=295", nil)
+	if got := usageQuery(req); got != "Hello Adele" {
+		t.Errorf("Expected %q, got %q", "Hello Adele", got)
+	}
+}
+
+func TestLookupQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/getLyrics?song=Hello&artist=Adele&duration=295&format=lrc", nil)
+	if got := lookupQuery(req); got != "a=Adele&d=295&s=Hello" {
+		t.Errorf("Expected %q, got %q", "a=Adele&d=295&s=Hello", got)
+	}
+
+	req = httptest.NewRequest("GET", "/getLyrics?v=dQw4w9WgXcQ", nil)
+	if got := lookupQuery(req); got != "" {
+		t.Errorf("Lookups without song and artist can't be replayed, got %q", got)
+	}
+}
@@ -25,6 +25,46 @@ func (lp *LimiterPair) GetCachedTokens() int {
 	return int(math.Floor(lp.Cached.Tokens()))
 }
 
+// GetNormalReset returns the seconds until the normal tier is back to a full burst
+func (lp *LimiterPair) GetNormalReset() int {
+	return secondsUntilTokens(lp.Normal, float64(lp.Normal.Burst()))
+}
+
+// GetCachedReset returns the seconds until the cached tier is back to a full burst
+func (lp *LimiterPair) GetCachedReset() int {
+	return secondsUntilTokens(lp.Cached, float64(lp.Cached.Burst()))
+}
+
+// GetNormalRetryAfter returns the seconds until the normal tier has a token again (at least 1)
+func (lp *LimiterPair) GetNormalRetryAfter() int {
+	return max(secondsUntilTokens(lp.Normal, 1), 1)
+}
+
+// GetCachedRetryAfter returns the seconds until the cached tier has a token again (at least 1)
+func (lp *LimiterPair) GetCachedRetryAfter() int {
+	return max(secondsUntilTokens(lp.Cached, 1), 1)
+}
+
+// secondsUntilTokens rounds up the time the limiter needs to refill to n tokens
+func secondsUntilTokens(l *rate.Limiter, n float64) int {
+	missing := n - l.Tokens()
+	if missing <= 0 {
+		return 0
+	}
+	if l.Limit() <= 0 {
+		return math.MaxInt32
+	}
+	return int(math.Ceil(missing / float64(l.Limit())))
+}
+
+// RateLimits are the per-second rates and bursts of both tiers
+type RateLimits struct {
+	NormalPerSecond int `json:"normal_per_second"`
+	NormalBurst     int `json:"normal_burst"`
+	CachedPerSecond int `json:"cached_per_second"`
+	CachedBurst     int `json:"cached_burst"`
+}
+
 // IPRateLimiter manages two-tier rate limiting per IP
 type IPRateLimiter struct {
 	ips         map[string]*LimiterPair
@@ -37,14 +77,48 @@ type IPRateLimiter struct {
 
 // GetNormalLimit returns the normal tier burst limit
 func (i *IPRateLimiter) GetNormalLimit() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.normalBurst
 }
 
 // GetCachedLimit returns the cached tier burst limit
 func (i *IPRateLimiter) GetCachedLimit() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.cachedBurst
 }
 
+// Limits returns the rates and bursts in effect
+func (i *IPRateLimiter) Limits() RateLimits {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return RateLimits{
+		NormalPerSecond: int(i.normalRate),
+		NormalBurst:     i.normalBurst,
+		CachedPerSecond: int(i.cachedRate),
+		CachedBurst:     i.cachedBurst,
+	}
+}
+
+// SetLimits changes the rates and bursts of both tiers, for new and already
+// tracked IPs alike. Tokens an IP already holds are kept (up to the new burst).
+func (i *IPRateLimiter) SetLimits(limits RateLimits) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.normalRate = rate.Limit(limits.NormalPerSecond)
+	i.normalBurst = limits.NormalBurst
+	i.cachedRate = rate.Limit(limits.CachedPerSecond)
+	i.cachedBurst = limits.CachedBurst
+	for _, pair := range i.ips {
+		pair.Normal.SetLimit(i.normalRate)
+		pair.Normal.SetBurst(i.normalBurst)
+		pair.Cached.SetLimit(i.cachedRate)
+		pair.Cached.SetBurst(i.cachedBurst)
+	}
+}
+
 // NewIPRateLimiter creates a new two-tier rate limiter
 func NewIPRateLimiter(normalRate rate.Limit, normalBurst int, cachedRate rate.Limit, cachedBurst int) *IPRateLimiter {
 	i := &IPRateLimiter{
@@ -235,3 +235,61 @@ func TestGetLimits(t *testing.T) {
 		t.Errorf("Expected cached limit to be 20, got %d", cachedLimit)
 	}
 }
+
+// TestSetLimits tests that new limits apply to tracked and new IPs alike.
+func TestSetLimits(t *testing.T) {
+	rl := NewIPRateLimiter(rate.Limit(2), 5, rate.Limit(10), 20)
+	tracked := rl.GetLimiter("192.168.1.1")
+
+	want := RateLimits{NormalPerSecond: 1, NormalBurst: 2, CachedPerSecond: 3, CachedBurst: 4}
+	rl.SetLimits(want)
+
+	if got := rl.Limits(); got != want {
+		t.Errorf("Limits() = %+v, want %+v", got, want)
+	}
+	if rl.GetNormalLimit() != 2 || rl.GetCachedLimit() != 4 {
+		t.Errorf("Expected limits 2/4, got %d/%d", rl.GetNormalLimit(), rl.GetCachedLimit())
+	}
+	for _, pair := range []*LimiterPair{tracked, rl.GetLimiter("192.168.1.2")} {
+		if pair.Normal.Burst() != 2 || pair.Normal.Limit() != 1 || pair.Cached.Burst() != 4 || pair.Cached.Limit() != 3 {
+			t.Errorf("Limiter not updated: normal %v/%d, cached %v/%d", pair.Normal.Limit(), pair.Normal.Burst(), pair.Cached.Limit(), pair.Cached.Burst())
+		}
+	}
+	// A tracked IP with a full burst of 5 keeps at most the new burst
+	allowed := 0
+	for tracked.Normal.Allow() {
+		allowed++
+	}
+	if allowed > 2 {
+		t.Errorf("Expected at most 2 requests after lowering the burst, got %d", allowed)
+	}
+}
+
+// TestLimiterPairReset tests the reset and retry-after calculations.
+func TestLimiterPairReset(t *testing.T) {
+	rl := NewIPRateLimiter(1, 5, 2, 4)
+	pair := rl.GetLimiter("192.168.1.1")
+
+	if got := pair.GetNormalReset(); got != 0 {
+		t.Errorf("Expected 0s reset for a full bucket, got %d", got)
+	}
+
+	for i := 0; i < 5; i++ {
+		pair.Normal.Allow()
+	}
+	// 5 tokens missing at 1/s
+	if got := pair.GetNormalReset(); got < 4 || got > 5 {
+		t.Errorf("Expected ~5s normal reset, got %d", got)
+	}
+
+	for i := 0; i < 4; i++ {
+		pair.Cached.Allow()
+	}
+	// 4 tokens missing at 2/s; one token at 2/s rounds up to 1s
+	if got := pair.GetCachedReset(); got != 2 {
+		t.Errorf("Expected 2s cached reset, got %d", got)
+	}
+	if got := pair.GetCachedRetryAfter(); got != 1 {
+		t.Errorf("Expected 1s retry-after, got %d", got)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/logcolors"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// OVERSIZE_RESPONSE_ACTION values
+const (
+	oversizeDowngrade = "downgrade" // Drop syllable timing when that fits, else 413
+	oversizeReject    = "reject"    // Always 413
+)
+
+// reducedFormatHints are the requests a client can retry with after a 413
+var reducedFormatHints = []string{"compact=true", "format=lrc", "format=text", "fields=lines.words,lines.startTimeMs,lines.endTimeMs"}
+
+// writeLyricsJSON writes a lyrics JSON body within MAX_LYRICS_RESPONSE_BYTES. An
+// oversized body (e.g. the parsed lines of a DJ mix) is downgraded to line-level
+// timing, or answered with a 413 listing the reduced formats to ask for instead.
+func writeLyricsJSON(resp *APIResponse, data interface{}) {
+	limit := conf().Configuration.MaxLyricsResponseBytes
+	if limit <= 0 {
+		resp.JSON(data)
+		return
+	}
+	data = addResponseWarnings(resp.r, data)
+
+	filter, _ := parseFieldFilter(resp.r)
+	encoded, err := encodeFiltered(data, filter)
+	if err != nil {
+		resp.JSON(data)
+		return
+	}
+	if int64(len(encoded)) <= limit {
+		resp.writeEncodedJSON(encoded)
+		return
+	}
+	size := len(encoded)
+
+	if conf().Configuration.OversizeResponseAction != oversizeReject {
+		compact := &fieldFilter{exclude: buildFieldTree(compactExcludedFields)}
+		if filter != nil {
+			compact.include = filter.include
+		}
+		if encoded, err := encodeFiltered(data, compact); err == nil && int64(len(encoded)) <= limit {
+			log.Infof("%s Downgraded a %d-byte lyrics response to line timing (%d bytes)", logcolors.LogRequest, size, len(encoded))
+			resp.w.Header().Set("X-Lyrics-Downgraded", "compact")
+			resp.writeEncodedJSON(encoded)
+			return
+		}
+	}
+
+	log.Infof("%s Rejected a %d-byte lyrics response (limit %d)", logcolors.LogRequest, size, limit)
+	resp.Error(http.StatusRequestEntityTooLarge, map[string]interface{}{
+		"error":           "Lyrics response too large, request a reduced format",
+		"size_bytes":      size,
+		"limit_bytes":     limit,
+		"reduced_formats": reducedFormatHints,
+	})
+}
+
+// encodeFiltered encodes data with a request's sparse fieldset applied
+func encodeFiltered(data interface{}, filter *fieldFilter) ([]byte, error) {
+	if filter != nil {
+		data = filter.apply(data)
+	}
+	return json.Marshal(data)
+}
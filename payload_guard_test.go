@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetLyrics_PayloadSizeGuard(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	originalLimit, originalAction := conf().Configuration.MaxLyricsResponseBytes, conf().Configuration.OversizeResponseAction
+	defer func() {
+		conf().Configuration.MaxLyricsResponseBytes, conf().Configuration.OversizeResponseAction = originalLimit, originalAction
+	}()
+
+	setCachedLyrics(buildNormalizedCacheKey("song", "artist", "", ""), formatTestTTML, 0, 0, "", false)
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format=lines"+query, nil))
+		return rr
+	}
+
+	conf().Configuration.MaxLyricsResponseBytes = 0
+	fullSize := get("").Body.Len()
+	compactSize := get("&compact=true").Body.Len()
+	if compactSize >= fullSize {
+		t.Fatalf("Expected compact (%d bytes) to be smaller than full (%d bytes)", compactSize, fullSize)
+	}
+
+	// Between the two sizes: downgraded to line timing
+	conf().Configuration.MaxLyricsResponseBytes = int64(compactSize)
+	conf().Configuration.OversizeResponseAction = oversizeDowngrade
+	rr := get("")
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Lyrics-Downgraded") != "compact" || rr.Body.Len() != compactSize {
+		t.Errorf("Expected a downgraded 200, got %d (%q, %d bytes)", rr.Code, rr.Header().Get("X-Lyrics-Downgraded"), rr.Body.Len())
+	}
+
+	// Too large even without syllables
+	conf().Configuration.MaxLyricsResponseBytes = int64(compactSize - 10)
+	rr = get("")
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413, got %d", rr.Code)
+	}
+	var body map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	if body["size_bytes"] == nil || body["reduced_formats"] == nil {
+		t.Errorf("Expected size and reduced formats in the 413, got %v", body)
+	}
+
+	// reject never downgrades; text formats aren't capped
+	conf().Configuration.MaxLyricsResponseBytes = int64(compactSize)
+	conf().Configuration.OversizeResponseAction = oversizeReject
+	if rr = get(""); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 with reject, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	conf().Configuration.MaxLyricsResponseBytes = 1
+	getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format=lrc", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected LRC to be served, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,179 @@
+// Package postprocess cleans up lyric text before it is served: whitespace and
+// quote normalization, and optional profanity masking.
+//
+// Steps run on text only, never on markup or timing. TTML is processed one text
+// node at a time, so every format derived from it (text, LRC, parsed lines) comes
+// out the same, and raw lyrics from the other providers (LRC) one line at a time.
+package postprocess
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Post-processing steps, applied in this order
+const (
+	StepWhitespace = "whitespace" // Collapse runs of spaces and tabs to one space
+	StepQuotes     = "quotes"     // Curly quotes and primes to straight quotes
+	StepProfanity  = "profanity"  // Mask wordlist words, keeping their first letter
+)
+
+var stepOrder = []string{StepWhitespace, StepQuotes, StepProfanity}
+
+//go:embed wordlist.txt
+var defaultWordlist string
+
+var (
+	whitespaceRun = regexp.MustCompile(`[ \t\x{00A0}\x{3000}]{2,}|[\t\x{00A0}\x{3000}]`)
+	textNode      = regexp.MustCompile(`>([^<]+)<`)
+
+	quoteReplacer = strings.NewReplacer(
+		"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+		"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+	)
+)
+
+// Pipeline applies a fixed set of steps. The zero value and nil do nothing.
+type Pipeline struct {
+	steps     map[string]bool
+	profanity *regexp.Regexp
+}
+
+// ParseSteps splits a comma-separated step list ("whitespace,quotes"), rejecting
+// unknown names
+func ParseSteps(raw string) ([]string, error) {
+	var steps []string
+	for _, step := range strings.Split(raw, ",") {
+		step = strings.ToLower(strings.TrimSpace(step))
+		if step == "" {
+			continue
+		}
+		known := false
+		for _, s := range stepOrder {
+			known = known || s == step
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown post-processing step %q (supported: %s)", step, strings.Join(stepOrder, ", "))
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// New builds a pipeline. wordlist holds the words masked by the profanity step,
+// one per line ("#" comments allowed); "" uses the built-in list.
+func New(steps []string, wordlist string) *Pipeline {
+	p := &Pipeline{steps: make(map[string]bool)}
+	for _, step := range steps {
+		p.steps[step] = true
+	}
+	if p.steps[StepProfanity] {
+		if strings.TrimSpace(wordlist) == "" {
+			wordlist = defaultWordlist
+		}
+		p.profanity = wordPattern(wordlist)
+	}
+	return p
+}
+
+// Steps lists the pipeline's steps in the order they run
+func (p *Pipeline) Steps() []string {
+	var steps []string
+	if p == nil {
+		return steps
+	}
+	for _, step := range stepOrder {
+		if p.steps[step] {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// Empty reports whether the pipeline leaves text unchanged
+func (p *Pipeline) Empty() bool {
+	return p == nil || len(p.steps) == 0
+}
+
+// Line processes one line of plain lyric text
+func (p *Pipeline) Line(s string) string {
+	if p.Empty() {
+		return s
+	}
+	if p.steps[StepWhitespace] {
+		s = whitespaceRun.ReplaceAllString(s, " ")
+	}
+	if p.steps[StepQuotes] {
+		s = quoteReplacer.Replace(s)
+	}
+	if p.profanity != nil {
+		s = p.profanity.ReplaceAllStringFunc(s, mask)
+	}
+	return s
+}
+
+// Text processes multi-line lyrics (plain text or LRC) line by line. The whitespace
+// step also drops trailing spaces, and keeps at most one blank line in a row.
+func (p *Pipeline) Text(s string) string {
+	if p.Empty() {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	blank := false
+	for _, line := range lines {
+		line = p.Line(line)
+		if p.steps[StepWhitespace] {
+			line = strings.TrimRight(line, " \r")
+			if line == "" && blank {
+				continue
+			}
+			blank = line == ""
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// TTML processes the text nodes of a TTML document. Whitespace-only nodes (the
+// separators between word spans and the document's indentation) are left alone.
+// A word split across syllable spans is only masked if one span holds it whole.
+func (p *Pipeline) TTML(content string) string {
+	if p.Empty() {
+		return content
+	}
+	return textNode.ReplaceAllStringFunc(content, func(node string) string {
+		text := node[1 : len(node)-1]
+		if strings.TrimSpace(text) == "" {
+			return node
+		}
+		return ">" + p.Line(text) + "<"
+	})
+}
+
+// wordPattern matches any wordlist word as a whole word, case-insensitively
+func wordPattern(wordlist string) *regexp.Regexp {
+	var words []string
+	for _, line := range strings.Split(wordlist, "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, regexp.QuoteMeta(strings.ToLower(word)))
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	// Longest first, so a word containing another is masked whole
+	sort.Slice(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(words, "|") + `)\b`)
+}
+
+// mask keeps a word's first letter and stars the rest ("d***")
+func mask(word string) string {
+	runes := []rune(word)
+	return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+}
@@ -0,0 +1,78 @@
+package postprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSteps(t *testing.T) {
+	steps, err := ParseSteps(" Whitespace, quotes ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(steps, ",") != "whitespace,quotes" {
+		t.Errorf("ParseSteps() = %v", steps)
+	}
+	if _, err := ParseSteps("whitespace,emoji"); err == nil {
+		t.Error("expected an error for an unknown step")
+	}
+}
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		steps []string
+		input string
+		want  string
+	}{
+		{[]string{StepWhitespace}, "I  can't\tstop   now", "I can't stop now"},
+		{[]string{StepQuotes}, "“Don’t” ‘stop’", `"Don't" 'stop'`},
+		{[]string{StepProfanity}, "What the Fuck, fucking shitty day", "What the F***, f****** s***** day"},
+		{[]string{StepProfanity}, "Assassin's class", "Assassin's class"},
+		{nil, "  “untouched”  ", "  “untouched”  "},
+	}
+	for _, tt := range tests {
+		if got := New(tt.steps, "").Line(tt.input); got != tt.want {
+			t.Errorf("%v: Line(%q) = %q, want %q", tt.steps, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	p := New([]string{StepWhitespace, StepQuotes}, "")
+	input := "[00:01.00]Don’t  look back  \r\n\n\n\n[00:04.00]Never"
+	want := "[00:01.00]Don't look back\n\n[00:04.00]Never"
+	if got := p.Text(input); got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+}
+
+func TestTTML(t *testing.T) {
+	p := New([]string{StepWhitespace, StepQuotes, StepProfanity}, "")
+	input := `<tt xml:lang="en">
+  <body>
+    <p begin="1.0" end="2.0"><span begin="1.0" end="1.5">Don’t</span> <span begin="1.5" end="2.0">damn  it</span></p>
+  </body>
+</tt>`
+	want := `<tt xml:lang="en">
+  <body>
+    <p begin="1.0" end="2.0"><span begin="1.0" end="1.5">Don't</span> <span begin="1.5" end="2.0">d*** it</span></p>
+  </body>
+</tt>`
+	if got := p.TTML(input); got != want {
+		t.Errorf("TTML() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCustomWordlist(t *testing.T) {
+	p := New([]string{StepProfanity}, "# comment\nheck\n")
+	if got := p.Line("Oh heck, damn"); got != "Oh h***, damn" {
+		t.Errorf("Line() = %q", got)
+	}
+}
+
+func TestNilPipeline(t *testing.T) {
+	var p *Pipeline
+	if !p.Empty() || p.Line("a  b") != "a  b" || p.TTML("<p>a  b</p>") != "<p>a  b</p>" || len(p.Steps()) != 0 {
+		t.Error("a nil pipeline should leave text unchanged")
+	}
+}
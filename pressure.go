@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Under overload (PRESSURE_MAX_* exceeded) the API sheds load: unauthenticated
+// clients are served from the cache only, so the upstream and write paths are
+// left to admins and API key holders until the pressure drops. Each shedding
+// episode lasts at least pressureMinEpisode, so a gauge hovering at its threshold
+// doesn't flap between the two modes.
+
+const pressureMinEpisode = 10 * time.Second
+
+var (
+	coalescingWaiters atomic.Int64 // Requests waiting on another request's upstream lookup
+	pendingWrites     atomic.Int64 // Background cache writes started and not yet done
+
+	pressure = &pressureMonitor{}
+)
+
+// pressureGauges is one reading of the load indicators
+type pressureGauges struct {
+	Goroutines        int64 `json:"goroutines"`
+	UpstreamInFlight  int64 `json:"upstream_in_flight"`
+	CoalescingWaiters int64 `json:"coalescing_waiters"`
+	WriteQueueDepth   int64 `json:"write_queue_depth"`
+}
+
+// readPressureGauges reads the current load
+func readPressureGauges() pressureGauges {
+	return pressureGauges{
+		Goroutines:        int64(runtime.NumGoroutine()),
+		UpstreamInFlight:  getUpstreamLimiter().active.Load(),
+		CoalescingWaiters: coalescingWaiters.Load(),
+		WriteQueueDepth:   pendingWrites.Load(),
+	}
+}
+
+// exceeded lists the gauges above their PRESSURE_MAX_* threshold
+func (g pressureGauges) exceeded() []string {
+	var over []string
+	for _, gauge := range []struct {
+		name       string
+		value, max int64
+	}{
+		{"goroutines", g.Goroutines, int64(conf().Configuration.PressureMaxGoroutines)},
+		{"upstream_in_flight", g.UpstreamInFlight, int64(conf().Configuration.PressureMaxUpstreamInFlight)},
+		{"coalescing_waiters", g.CoalescingWaiters, int64(conf().Configuration.PressureMaxCoalescingWaiters)},
+		{"write_queue_depth", g.WriteQueueDepth, int64(conf().Configuration.PressureMaxWriteQueue)},
+	} {
+		if gauge.max > 0 && gauge.value > gauge.max {
+			over = append(over, fmt.Sprintf("%s %d > %d", gauge.name, gauge.value, gauge.max))
+		}
+	}
+	return over
+}
+
+// pressureMonitor tracks shedding episodes
+type pressureMonitor struct {
+	mu       sync.Mutex
+	shedding bool
+	started  time.Time // Start of the current episode
+	lastOver time.Time // Last check that found a gauge over its threshold
+	episodes int64
+	shed     atomic.Int64 // Requests answered cache-only because of shedding
+}
+
+// check updates the shedding state from the current gauges and reports whether
+// load is being shed. Entering and leaving an episode is logged.
+func (p *pressureMonitor) check(now time.Time) bool {
+	over := readPressureGauges().exceeded()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case len(over) > 0 && !p.shedding:
+		p.shedding = true
+		p.started, p.lastOver = now, now
+		p.episodes++
+		log.Warnf("%s Overloaded (%s), serving unauthenticated clients from cache only", logcolors.LogRateLimit, strings.Join(over, ", "))
+	case len(over) > 0:
+		p.lastOver = now // Extend the episode
+	case p.shedding && now.Sub(p.lastOver) >= pressureMinEpisode:
+		p.shedding = false
+		log.Infof("%s Pressure dropped, load shedding ended after %s", logcolors.LogRateLimit, now.Sub(p.started).Round(time.Second))
+	}
+	return p.shedding
+}
+
+// stats returns the gauges and shedding state for /stats
+func (p *pressureMonitor) stats() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := map[string]interface{}{
+		"gauges":   readPressureGauges(),
+		"shedding": p.shedding,
+		"episodes": p.episodes,
+		"shed":     p.shed.Load(),
+		"thresholds": pressureGauges{
+			Goroutines:        int64(conf().Configuration.PressureMaxGoroutines),
+			UpstreamInFlight:  int64(conf().Configuration.PressureMaxUpstreamInFlight),
+			CoalescingWaiters: int64(conf().Configuration.PressureMaxCoalescingWaiters),
+			WriteQueueDepth:   int64(conf().Configuration.PressureMaxWriteQueue),
+		},
+	}
+	if p.shedding {
+		result["shedding_since"] = p.started
+	}
+	return result
+}
+
+// goWrite runs a background cache write, counted in the write queue depth
+func goWrite(write func()) {
+	pendingWrites.Add(1)
+	go func() {
+		defer pendingWrites.Add(-1)
+		write()
+	}()
+}
+
+// pressureMiddleware marks requests from unauthenticated clients (no admin token,
+// no valid API key) as cache-only while load is being shed
+func pressureMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !pressure.check(time.Now()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if authenticated, _ := r.Context().Value(apiKeyAuthenticatedKey).(bool); authenticated ||
+			(conf().Configuration.CacheAccessToken != "" && r.Header.Get("Authorization") == conf().Configuration.CacheAccessToken) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sheddingLoadKey, true)))
+	})
+}
+
+// respondShedding answers a cache miss while load is being shed with a 503 and
+// Retry-After, and reports whether it did
+func respondShedding(w http.ResponseWriter, r *http.Request, provider string) bool {
+	if !requestShedding(r) {
+		return false
+	}
+	retryAfter, body := shedLookup()
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	resp := Respond(w, r).SetCacheStatus("MISS")
+	if provider != "" {
+		resp = resp.SetProvider(provider)
+		body["provider"] = provider
+	}
+	resp.Error(http.StatusServiceUnavailable, body)
+	return true
+}
+
+// requestShedding reports whether pressureMiddleware marked the request cache-only
+func requestShedding(r *http.Request) bool {
+	shedding, _ := r.Context().Value(sheddingLoadKey).(bool)
+	return shedding
+}
+
+// shedLookup counts a cache miss turned away while shedding load and returns the
+// Retry-After and body of the 503
+func shedLookup() (retryAfter int, body map[string]interface{}) {
+	pressure.shed.Add(1)
+	retryAfter = int(pressureMinEpisode.Seconds())
+	return retryAfter, map[string]interface{}{
+		"error":       "Server under heavy load, only cached lyrics are served. Retry later or authenticate with an API key.",
+		"retry_after": retryAfter,
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withPressureThreshold overloads the coalescing waiters gauge against a threshold
+// of 1, on a fresh monitor
+func withPressureThreshold(t *testing.T, waiters int64) {
+	t.Helper()
+	original := conf().Configuration.PressureMaxCoalescingWaiters
+	conf().Configuration.PressureMaxCoalescingWaiters = 1
+	savedMonitor := pressure
+	pressure = &pressureMonitor{}
+	coalescingWaiters.Add(waiters)
+	t.Cleanup(func() {
+		coalescingWaiters.Add(-waiters)
+		pressure = savedMonitor
+		conf().Configuration.PressureMaxCoalescingWaiters = original
+	})
+}
+
+func TestPressureMonitor_Episodes(t *testing.T) {
+	withPressureThreshold(t, 2)
+	now := time.Unix(1_700_000_000, 0)
+
+	if !pressure.check(now) {
+		t.Fatal("Expected shedding above the threshold")
+	}
+	coalescingWaiters.Add(-2)
+	defer coalescingWaiters.Add(2)
+	if !pressure.check(now.Add(5 * time.Second)) {
+		t.Error("An episode lasts at least pressureMinEpisode")
+	}
+	if pressure.check(now.Add(pressureMinEpisode)) {
+		t.Error("Expected shedding to end once the pressure dropped")
+	}
+
+	stats := pressure.stats()
+	if stats["episodes"] != int64(1) || stats["shedding"] != false {
+		t.Errorf("Unexpected stats %v", stats)
+	}
+}
+
+func TestPressureGauges_DisabledThresholds(t *testing.T) {
+	gauges := pressureGauges{Goroutines: 1 << 20, UpstreamInFlight: 1 << 20, CoalescingWaiters: 1 << 20, WriteQueueDepth: 1 << 20}
+	original := conf().Configuration
+	conf().Configuration.PressureMaxGoroutines = 0
+	conf().Configuration.PressureMaxUpstreamInFlight = 0
+	conf().Configuration.PressureMaxCoalescingWaiters = 0
+	conf().Configuration.PressureMaxWriteQueue = 0
+	defer func() { conf().Configuration = original }()
+
+	if over := gauges.exceeded(); len(over) != 0 {
+		t.Errorf("Thresholds of 0 are off, got %v", over)
+	}
+}
+
+func TestGoWrite_CountsPendingWrites(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan struct{})
+	before := pendingWrites.Load()
+	goWrite(func() {
+		<-release
+		close(done)
+	})
+	if got := pendingWrites.Load() - before; got != 1 {
+		t.Errorf("Write queue depth grew by %d, want 1", got)
+	}
+	close(release)
+	<-done
+	for i := 0; i < 100 && pendingWrites.Load() != before; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if pendingWrites.Load() != before {
+		t.Error("Finished write still counted")
+	}
+}
+
+func TestPressureMiddleware_ShedsUnauthenticatedMisses(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	withPressureThreshold(t, 5)
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+	// Uncached lookups that aren't shed answer 503 instead of going upstream
+	originalCacheOnly := conf().FeatureFlags.CacheOnlyMode
+	conf().FeatureFlags.CacheOnlyMode = true
+	defer func() { conf().FeatureFlags.CacheOnlyMode = originalCacheOnly }()
+
+	setCachedLyrics(buildNormalizedCacheKey("Cached", "Artist", "", ""), testTTML, 0, 0.9, "", false)
+	handler := pressureMiddleware(http.HandlerFunc(getLyrics))
+
+	tests := []struct {
+		name       string
+		query      string
+		admin      bool
+		wantStatus int
+		wantShed   bool
+	}{
+		{"cache hit", "s=Cached&a=Artist", false, http.StatusOK, false},
+		{"miss", "s=Uncached&a=Artist", false, http.StatusServiceUnavailable, true},
+		{"admin miss", "s=Uncached&a=Artist", true, http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/getLyrics?"+tt.query, nil)
+			if tt.admin {
+				r.Header.Set("Authorization", "test-token")
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if shed := strings.Contains(rr.Body.String(), "heavy load"); shed != tt.wantShed {
+				t.Errorf("shed = %v, want %v: %s", shed, tt.wantShed, rr.Body.String())
+			}
+			if tt.wantShed && rr.Header().Get("Retry-After") == "" {
+				t.Error("Shed response without Retry-After")
+			}
+		})
+	}
+	if got := pressure.stats()["shed"]; got != int64(1) {
+		t.Errorf("shed = %v, want 1", got)
+	}
+}
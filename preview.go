@@ -0,0 +1,52 @@
+package main
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+
+	ttml "lyrics-api-go/services/providers/ttml"
+)
+
+// maxPreviewLines caps the lines=N preview mode
+const maxPreviewLines = 50
+
+// previewOptions controls how /cache/lookup and /cache/debug render content previews
+type previewOptions struct {
+	stripTags bool // strip XML tags and unescape entities before truncating
+	lines     int  // > 0: show the first N parsed lyric lines instead of raw bytes
+}
+
+// parsePreviewOptions reads ?strip=true and ?lines=N from the request
+func parsePreviewOptions(r *http.Request) previewOptions {
+	opts := previewOptions{stripTags: r.URL.Query().Get("strip") == "true"}
+	if n, err := strconv.Atoi(r.URL.Query().Get("lines")); err == nil && n > 0 {
+		opts.lines = min(n, maxPreviewLines)
+	}
+	return opts
+}
+
+// addTTMLPreview sets ttml_preview (or ttml_preview_lines in lines=N mode) on result
+func addTTMLPreview(result map[string]interface{}, ttmlContent string, maxLen int, opts previewOptions) {
+	if opts.lines > 0 {
+		if lines, _, err := ttml.ParseLines(ttmlContent); err == nil {
+			preview := make([]string, 0, opts.lines)
+			for _, line := range lines {
+				if len(preview) == opts.lines {
+					break
+				}
+				preview = append(preview, html.UnescapeString(line.Words))
+			}
+			result["ttml_preview_lines"] = preview
+			result["ttml_line_count"] = len(lines)
+			return
+		}
+		// Unparseable TTML falls through to a stripped text preview
+		opts.stripTags = true
+	}
+
+	if opts.stripTags {
+		ttmlContent = ttml.StripMarkup(ttmlContent)
+	}
+	result["ttml_preview"] = truncateString(ttmlContent, maxLen)
+}
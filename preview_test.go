@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateString_RuneAware(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxLen   int
+		expected string
+	}{
+		{"short ascii", "hello", 10, "hello"},
+		{"ascii truncated", "hello world", 5, "hello..."},
+		{"japanese", "こんにちは世界", 5, "こんにちは..."},
+		{"emoji", "🎵🎶🎤🎧", 2, "🎵🎶..."},
+		{"exact rune count", "héllo", 5, "héllo"},
+		{"invalid utf-8 replaced", "ab\xffcd", 10, "ab�cd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateString(tt.input, tt.maxLen)
+			if got != tt.expected {
+				t.Errorf("truncateString(%q, %d) = %q, want %q", tt.input, tt.maxLen, got, tt.expected)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateString produced invalid UTF-8: %q", got)
+			}
+		})
+	}
+}
+
+func TestAddTTMLPreview(t *testing.T) {
+	t.Run("raw", func(t *testing.T) {
+		result := map[string]interface{}{}
+		addTTMLPreview(result, formatTestTTML, 20, previewOptions{})
+		if preview := result["ttml_preview"].(string); !strings.HasPrefix(preview, "<?xml") {
+			t.Errorf("raw preview should keep markup, got %q", preview)
+		}
+	})
+
+	t.Run("strip tags", func(t *testing.T) {
+		result := map[string]interface{}{}
+		addTTMLPreview(result, formatTestTTML, 200, previewOptions{stripTags: true})
+		if preview := result["ttml_preview"].(string); preview != "First line Second line" {
+			t.Errorf("stripped preview = %q", preview)
+		}
+	})
+
+	t.Run("lines", func(t *testing.T) {
+		result := map[string]interface{}{}
+		addTTMLPreview(result, formatTestTTML, 200, previewOptions{lines: 1})
+		lines, ok := result["ttml_preview_lines"].([]string)
+		if !ok || len(lines) != 1 || lines[0] != "First line" {
+			t.Errorf("lines preview = %v", result["ttml_preview_lines"])
+		}
+		if result["ttml_line_count"] != 2 {
+			t.Errorf("ttml_line_count = %v, want 2", result["ttml_line_count"])
+		}
+		if _, ok := result["ttml_preview"]; ok {
+			t.Error("lines mode should not include the raw preview")
+		}
+	})
+
+	t.Run("lines falls back to stripped text on bad TTML", func(t *testing.T) {
+		result := map[string]interface{}{}
+		addTTMLPreview(result, "<p>not <b>ttml", 200, previewOptions{lines: 3})
+		if preview := result["ttml_preview"]; preview != "not ttml" {
+			t.Errorf("fallback preview = %v", preview)
+		}
+	})
+}
+
+func TestParsePreviewOptions(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/cache/debug?strip=true&lines=500", nil)
+	opts := parsePreviewOptions(r)
+	if !opts.stripTags || opts.lines != maxPreviewLines {
+		t.Errorf("parsePreviewOptions = %+v", opts)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/cache/debug?lines=-2", nil)
+	if opts := parsePreviewOptions(r); opts.stripTags || opts.lines != 0 {
+		t.Errorf("parsePreviewOptions with bad values = %+v", opts)
+	}
+}
+
+func TestCacheDebug_LinesPreview(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	key := "ttml_lyrics:preview song artist"
+	setCachedLyrics(key, formatTestTTML, 180000, 0.9, "en", false)
+
+	w := httptest.NewRecorder()
+	cacheDebug(w, httptest.NewRequest(http.MethodGet, "/cache/debug?key="+strings.ReplaceAll(key, " ", "%20")+"&lines=2", nil))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	lines, ok := body["ttml_preview_lines"].([]interface{})
+	if !ok || len(lines) != 2 || lines[1] != "Second line" {
+		t.Errorf("ttml_preview_lines = %v", body["ttml_preview_lines"])
+	}
+}
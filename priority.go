@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Request priority lanes. Interactive traffic (the extension) goes straight to the
+// upstream; prefetch/batch/warmup traffic shares a small pool of upstream slots so
+// bulk jobs can't crowd interactive requests out of the shared account pool.
+const (
+	PriorityInteractive = "interactive"
+	PriorityLow         = "low"
+
+	requestPriorityHeader = "X-Request-Priority"
+)
+
+// lowPriorityValues are the header/param values routed to the low-priority lane
+var lowPriorityValues = map[string]bool{
+	"low":      true,
+	"prefetch": true,
+	"batch":    true,
+	"warmup":   true,
+}
+
+var errPriorityQueueTimeout = errors.New("low-priority queue is full, try again later")
+
+// priorityLane limits concurrent upstream fetches for low-priority requests
+type priorityLane struct {
+	low               *upstreamLimiter
+	interactiveActive atomic.Int64
+}
+
+var (
+	lanes     *priorityLane
+	lanesOnce sync.Once
+)
+
+// getPriorityLane returns the lane limiter, sized from config on first use
+func getPriorityLane() *priorityLane {
+	lanesOnce.Do(func() {
+		lanes = newPriorityLane(conf().Configuration.PrefetchMaxConcurrent,
+			time.Duration(conf().Configuration.PrefetchQueueTimeoutSecs)*time.Second)
+	})
+	return lanes
+}
+
+func newPriorityLane(maxConcurrent int, timeout time.Duration) *priorityLane {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &priorityLane{low: newUpstreamLimiter(max(maxConcurrent, 1), timeout, errPriorityQueueTimeout)}
+}
+
+// requestPriority reads X-Request-Priority (or the priority= param). Anything not
+// recognized as low priority is treated as interactive.
+func requestPriority(r *http.Request) string {
+	value := r.Header.Get(requestPriorityHeader)
+	if value == "" {
+		value = r.URL.Query().Get("priority")
+	}
+	return parsePriority(value)
+}
+
+// parsePriority maps a priority value to its lane
+func parsePriority(value string) string {
+	if lowPriorityValues[strings.ToLower(strings.TrimSpace(value))] {
+		return PriorityLow
+	}
+	return PriorityInteractive
+}
+
+// acquire reserves an upstream slot for the given priority. Interactive requests
+// never wait; low-priority requests queue until a slot frees up, the queue timeout
+// passes or the client goes away. The returned release func must be called when
+// the upstream fetch is done.
+func (l *priorityLane) acquire(ctx context.Context, priority string) (release func(), err error) {
+	if priority != PriorityLow {
+		l.interactiveActive.Add(1)
+		return func() { l.interactiveActive.Add(-1) }, nil
+	}
+	return l.low.acquire(ctx)
+}
+
+// stats returns a snapshot of lane usage for /stats
+func (l *priorityLane) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"interactive_active": l.interactiveActive.Load(),
+		"low_active":         l.low.active.Load(),
+		"low_queued":         l.low.queued.Load(),
+		"low_rejected":       l.low.rejected.Load(),
+		"low_max_concurrent": cap(l.low.slots),
+	}
+}
+
+// acquireUpstreamSlot wraps the lane limiter for handlers. On failure it writes a
+// 503 with Retry-After (one lane queue timeout) and returns ok=false.
+func acquireUpstreamSlot(w http.ResponseWriter, r *http.Request, provider string) (release func(), ok bool) {
+	lane := getPriorityLane()
+	release, err := lane.acquire(r.Context(), requestPriority(r))
+	if err == nil {
+		return release, true
+	}
+
+	log.Warnf("%s Low-priority request not admitted: %v", logcolors.LogPriority, err)
+	respondUpstreamBusy(w, r, provider, err, lane.low.retryAfterSecs())
+	return nil, false
+}
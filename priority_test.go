@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		query    string
+		expected string
+	}{
+		{"default", "", "", PriorityInteractive},
+		{"header prefetch", "prefetch", "", PriorityLow},
+		{"header case-insensitive", " Warmup ", "", PriorityLow},
+		{"query batch", "", "batch", PriorityLow},
+		{"header wins over query", "interactive", "low", PriorityInteractive},
+		{"unknown value", "urgent", "", PriorityInteractive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/getLyrics?priority="+tt.query, nil)
+			if tt.header != "" {
+				r.Header.Set(requestPriorityHeader, tt.header)
+			}
+			if got := requestPriority(r); got != tt.expected {
+				t.Errorf("requestPriority() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPriorityLane_LowQueueIsBounded(t *testing.T) {
+	lane := newPriorityLane(1, 50*time.Millisecond)
+	ctx := context.Background()
+
+	releaseLow, err := lane.acquire(ctx, PriorityLow)
+	if err != nil {
+		t.Fatalf("First low-priority acquire failed: %v", err)
+	}
+
+	// Interactive requests are never held back by the low lane
+	releaseInteractive, err := lane.acquire(ctx, PriorityInteractive)
+	if err != nil {
+		t.Fatalf("Interactive acquire failed: %v", err)
+	}
+	releaseInteractive()
+
+	// A second low-priority request times out while the only slot is held
+	if _, err := lane.acquire(ctx, PriorityLow); err != errPriorityQueueTimeout {
+		t.Fatalf("Expected queue timeout, got %v", err)
+	}
+	if got := lane.stats()["low_rejected"]; got != int64(1) {
+		t.Errorf("low_rejected = %v, want 1", got)
+	}
+
+	// Once released, the slot is reusable
+	releaseLow()
+	release, err := lane.acquire(ctx, PriorityLow)
+	if err != nil {
+		t.Fatalf("Acquire after release failed: %v", err)
+	}
+	release()
+}
+
+func TestPriorityLane_ClientGoesAway(t *testing.T) {
+	lane := newPriorityLane(1, time.Minute)
+	release, _ := lane.acquire(context.Background(), PriorityLow)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := lane.acquire(ctx, PriorityLow); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestAcquireUpstreamSlot_BusyResponse(t *testing.T) {
+	getPriorityLane()
+	original := lanes
+	lanes = newPriorityLane(1, 50*time.Millisecond)
+	defer func() { lanes = original }()
+
+	release, _ := lanes.acquire(context.Background(), PriorityLow)
+	defer release()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/getLyrics?s=Song&a=Artist", nil)
+	r.Header.Set(requestPriorityHeader, "prefetch")
+	if _, ok := acquireUpstreamSlot(w, r, ""); ok {
+		t.Fatal("Expected the full lane to turn the request away")
+	}
+	// Retry-After is one lane queue timeout, in the shared upstream-busy shape
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Got %d with Retry-After %q, want 503 with 1", w.Code, w.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(w.Body.String(), `"retry_after":1`) {
+		t.Errorf("Expected retry_after in the body, got %s", w.Body.String())
+	}
+}
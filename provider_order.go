@@ -0,0 +1,180 @@
+package main
+
+import (
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/lyricsquery"
+	"lyrics-api-go/services/providers"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// providerOrderRule is one PROVIDER_ORDER_RULES entry: the providers to try, in
+// order, for queries matching cond. cond is a script ("cjk", "han", "kana",
+// "hangul", "cyrillic", "arabic", "hebrew", "thai", "latin"), "lang:xx" for the
+// client's locale hint, or "default".
+type providerOrderRule struct {
+	cond      string
+	providers []string
+}
+
+// scriptTables are the scripts a rule can name; "cjk" matches han, kana and hangul
+var scriptTables = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"han", unicode.Han},
+	{"kana", unicode.Hiragana},
+	{"kana", unicode.Katakana},
+	{"hangul", unicode.Hangul},
+	{"cyrillic", unicode.Cyrillic},
+	{"arabic", unicode.Arabic},
+	{"hebrew", unicode.Hebrew},
+	{"thai", unicode.Thai},
+	{"latin", unicode.Latin},
+}
+
+var providerOrderCache struct {
+	mu    sync.Mutex
+	raw   string
+	rules []providerOrderRule
+}
+
+// providerOrderRules parses PROVIDER_ORDER_RULES, re-parsing only when it changes.
+// Format: "cond=provider,provider;cond=provider", first matching rule wins.
+func providerOrderRules() []providerOrderRule {
+	raw := conf().Configuration.ProviderOrderRules
+	c := &providerOrderCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rules != nil && c.raw == raw {
+		return c.rules
+	}
+
+	rules := []providerOrderRule{}
+	for _, entry := range strings.Split(raw, ";") {
+		cond, list, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				log.Warnf("%s Ignoring PROVIDER_ORDER_RULES entry %q: expected cond=providers", logcolors.LogConfig, entry)
+			}
+			continue
+		}
+		rule := providerOrderRule{cond: strings.ToLower(strings.TrimSpace(cond))}
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				rule.providers = append(rule.providers, name)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	c.raw, c.rules = raw, rules
+	return rules
+}
+
+// queryScripts returns the scripts of the letters in the query text
+func queryScripts(texts ...string) map[string]bool {
+	scripts := make(map[string]bool)
+	for _, text := range texts {
+		for _, r := range text {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			for _, script := range scriptTables {
+				if unicode.Is(script.table, r) {
+					scripts[script.name] = true
+					break
+				}
+			}
+		}
+	}
+	if scripts["han"] || scripts["kana"] || scripts["hangul"] {
+		scripts["cjk"] = true
+	}
+	return scripts
+}
+
+// resolveProviderOrder picks the providers to try for a query: the first rule whose
+// script appears in the song, artist or album, or whose lang: matches the locale
+// hint. Unregistered providers are dropped; DEFAULT_PROVIDER is the last resort.
+func resolveProviderOrder(locale string, texts ...string) []string {
+	scripts := queryScripts(texts...)
+	lang, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(locale, "_", "-")), "-")
+
+	var order []string
+	for _, rule := range providerOrderRules() {
+		matched := rule.cond == "default" || scripts[rule.cond]
+		if want, ok := strings.CutPrefix(rule.cond, "lang:"); ok {
+			matched = lang != "" && want == lang
+		}
+		if matched {
+			order = rule.providers
+			break
+		}
+	}
+
+	var resolved []string
+	seen := make(map[string]bool)
+	for _, name := range slices.Concat(order, []string{conf().Configuration.DefaultProvider}) {
+		if !seen[name] && providers.Has(name) {
+			seen[name] = true
+			resolved = append(resolved, name)
+		}
+	}
+	return resolved
+}
+
+// getLyricsAuto serves /auto/getLyrics: it tries each provider of the resolved order
+// with the same request, and returns the first one that has lyrics. When none
+// does, the first provider's answer is returned. An API key or rate limit refusal
+// ends the search, since every provider would give the same answer.
+func getLyricsAuto(w http.ResponseWriter, r *http.Request) {
+	q, err := lyricsquery.Parse(r.URL.Query())
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !q.HasTrack() {
+		http.Error(w, "Song name or artist name not provided", http.StatusUnprocessableEntity)
+		return
+	}
+
+	order := resolveProviderOrder(r.URL.Query().Get("locale"), q.Song, q.Artist, q.Album)
+	if len(order) == 0 {
+		Respond(w, r).Error(http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "No provider configured for this query",
+		})
+		return
+	}
+
+	var first *httptest.ResponseRecorder
+	for _, name := range order {
+		rec := httptest.NewRecorder()
+		getLyricsWithProvider(name)(rec, r)
+		if first == nil {
+			first = rec
+		}
+		if rec.Code == http.StatusOK || rec.Code == http.StatusUnauthorized || rec.Code == http.StatusTooManyRequests {
+			writeProviderResponse(w, rec, order)
+			return
+		}
+		log.Debugf("%s %s answered %d, trying the next provider", logcolors.LogFallback, name, rec.Code)
+	}
+	writeProviderResponse(w, first, order)
+}
+
+// writeProviderResponse copies a provider handler's response, adding the order tried
+func writeProviderResponse(w http.ResponseWriter, rec *httptest.ResponseRecorder, order []string) {
+	maps.Copy(w.Header(), rec.Header())
+	w.Header().Set("X-Provider-Order", strings.Join(order, ","))
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestResolveProviderOrder(t *testing.T) {
+	originalRules, originalDefault := conf().Configuration.ProviderOrderRules, conf().Configuration.DefaultProvider
+	defer func() {
+		conf().Configuration.ProviderOrderRules, conf().Configuration.DefaultProvider = originalRules, originalDefault
+	}()
+	conf().Configuration.DefaultProvider = "ttml"
+	conf().Configuration.ProviderOrderRules = "lang:ko=qq, ttml;cjk=kugou,ttml;bogus;default=ttml,kugou,missing"
+
+	tests := []struct {
+		name   string
+		locale string
+		texts  []string
+		want   []string
+	}{
+		{"latin", "", []string{"Shape of You", "Ed Sheeran"}, []string{"ttml", "kugou"}},
+		{"han in the title", "", []string{"晴天", "Jay Chou"}, []string{"kugou", "ttml"}},
+		{"kana in the album", "", []string{"Lemon", "Kenshi Yonezu", "レモン"}, []string{"kugou", "ttml"}},
+		{"locale hint wins", "ko_KR", []string{"Dynamite", "BTS"}, []string{"qq", "ttml"}},
+		{"other locale", "en-US", []string{"Dynamite", "BTS"}, []string{"ttml", "kugou"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveProviderOrder(tt.locale, tt.texts...); !slices.Equal(got, tt.want) {
+				t.Errorf("resolveProviderOrder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Rules are re-read when the setting changes; the default provider is always tried
+	conf().Configuration.ProviderOrderRules = "cjk=kugou"
+	if got := resolveProviderOrder("", "晴天"); !slices.Equal(got, []string{"kugou", "ttml"}) {
+		t.Errorf("After change = %v", got)
+	}
+	if got := resolveProviderOrder("", "Hello"); !slices.Equal(got, []string{"ttml"}) {
+		t.Errorf("No matching rule = %v", got)
+	}
+}
+
+func TestGetLyricsAuto_FallsBackToNextProvider(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	originalRules := conf().Configuration.ProviderOrderRules
+	conf().Configuration.ProviderOrderRules = "cjk=kugou,ttml;default=ttml,kugou"
+	defer func() { conf().Configuration.ProviderOrderRules = originalRules }()
+
+	// Kugou is tried first for the CJK title but only TTML has it cached
+	setNegativeCache(buildProviderCacheKey("kugou_lyrics", "晴天", "周杰伦", "", ""), "Lyrics not available", "", false)
+	setCachedLyrics(buildProviderCacheKey("ttml_lyrics", "晴天", "周杰伦", "", ""), testTTML, 0, 0, "zh", false)
+
+	rr := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/auto/getLyrics?s=晴天&a=周杰伦", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("X-Provider"); got != "ttml" {
+		t.Errorf("X-Provider = %q, want ttml", got)
+	}
+	if got := rr.Header().Get("X-Provider-Order"); got != "kugou,ttml" {
+		t.Errorf("X-Provider-Order = %q", got)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/middleware"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// rateLimitsSetting is the stats DB setting holding the live rate limit override
+const rateLimitsSetting = "rate_limits"
+
+// Bounds of a rate limit override, so a typo can't turn limiting off or lock
+// every client out
+const (
+	rateLimitMaxPerSecond = 1000
+	rateLimitMaxBurst     = 10000
+)
+
+// rateLimiter is the limiter of the public listener, set up in main
+var rateLimiter *middleware.IPRateLimiter
+
+// rateLimitsPatch is the body of PATCH /config/rate-limits; omitted fields keep
+// their effective value
+type rateLimitsPatch struct {
+	NormalPerSecond *int `json:"normal_per_second"`
+	NormalBurst     *int `json:"normal_burst"`
+	CachedPerSecond *int `json:"cached_per_second"`
+	CachedBurst     *int `json:"cached_burst"`
+}
+
+// configuredRateLimits returns the rate limits from RATE_LIMIT_* and CACHED_RATE_LIMIT_*
+func configuredRateLimits() middleware.RateLimits {
+	return middleware.RateLimits{
+		NormalPerSecond: conf().Configuration.RateLimitPerSecond,
+		NormalBurst:     conf().Configuration.RateLimitBurstLimit,
+		CachedPerSecond: conf().Configuration.CachedRateLimitPerSecond,
+		CachedBurst:     conf().Configuration.CachedRateLimitBurstLimit,
+	}
+}
+
+// effectiveRateLimits returns the rate limits in effect
+func effectiveRateLimits() middleware.RateLimits {
+	if rateLimiter == nil {
+		return configuredRateLimits()
+	}
+	return rateLimiter.Limits()
+}
+
+// validateRateLimits checks an override against the bounds
+func validateRateLimits(limits middleware.RateLimits) error {
+	for _, field := range []struct {
+		name       string
+		value, max int
+	}{
+		{"normal_per_second", limits.NormalPerSecond, rateLimitMaxPerSecond},
+		{"normal_burst", limits.NormalBurst, rateLimitMaxBurst},
+		{"cached_per_second", limits.CachedPerSecond, rateLimitMaxPerSecond},
+		{"cached_burst", limits.CachedBurst, rateLimitMaxBurst},
+	} {
+		if field.value < 1 || field.value > field.max {
+			return fmt.Errorf("%s must be between 1 and %d, got %d", field.name, field.max, field.value)
+		}
+	}
+	return nil
+}
+
+// persistedRateLimits returns the override stored in the stats DB, if any
+func persistedRateLimits() (middleware.RateLimits, bool) {
+	if statsStore == nil {
+		return middleware.RateLimits{}, false
+	}
+	data, ok := statsStore.GetSetting(rateLimitsSetting)
+	if !ok {
+		return middleware.RateLimits{}, false
+	}
+	var limits middleware.RateLimits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		log.Warnf("%s Ignoring stored rate limit override: %v", logcolors.LogRateLimit, err)
+		return middleware.RateLimits{}, false
+	}
+	if err := validateRateLimits(limits); err != nil {
+		log.Warnf("%s Ignoring stored rate limit override: %v", logcolors.LogRateLimit, err)
+		return middleware.RateLimits{}, false
+	}
+	return limits, true
+}
+
+// applyPersistedRateLimits puts a stored override into effect at startup
+func applyPersistedRateLimits(limiter *middleware.IPRateLimiter) {
+	if limits, ok := persistedRateLimits(); ok {
+		limiter.SetLimits(limits)
+		log.Infof("%s Applied stored rate limit override: %+v", logcolors.LogRateLimit, limits)
+	}
+}
+
+// rateLimitsStatus is the configured and effective rate limits, for /stats and
+// /config/rate-limits
+func rateLimitsStatus() map[string]interface{} {
+	_, overridden := persistedRateLimits()
+	return map[string]interface{}{
+		"configured": configuredRateLimits(),
+		"effective":  effectiveRateLimits(),
+		"override":   overridden,
+	}
+}
+
+// rateLimitsHandler reports (GET) or changes the rate limit tiers at runtime.
+// PATCH takes a JSON object with any of normal_per_second, normal_burst,
+// cached_per_second and cached_burst; the new limits apply to every client at
+// once and are stored in the stats DB, so they outlive a restart. DELETE drops
+// the override and goes back to the configured values.
+func rateLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPatch:
+		var patch rateLimitsPatch
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&patch); err != nil {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": "Invalid JSON body: " + err.Error(),
+			})
+			return
+		}
+
+		limits := effectiveRateLimits()
+		for _, field := range []struct {
+			value  *int
+			target *int
+		}{
+			{patch.NormalPerSecond, &limits.NormalPerSecond},
+			{patch.NormalBurst, &limits.NormalBurst},
+			{patch.CachedPerSecond, &limits.CachedPerSecond},
+			{patch.CachedBurst, &limits.CachedBurst},
+		} {
+			if field.value != nil {
+				*field.target = *field.value
+			}
+		}
+		if err := validateRateLimits(limits); err != nil {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if statsStore != nil {
+			data, _ := json.Marshal(limits)
+			if err := statsStore.PutSetting(rateLimitsSetting, data); err != nil {
+				Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+					"error": err.Error(),
+				})
+				return
+			}
+		}
+		if rateLimiter != nil {
+			rateLimiter.SetLimits(limits)
+		}
+		log.Infof("%s Rate limits set to %+v", logcolors.LogRateLimit, limits)
+
+	case http.MethodDelete:
+		if statsStore != nil {
+			if err := statsStore.DeleteSetting(rateLimitsSetting); err != nil {
+				Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+					"error": err.Error(),
+				})
+				return
+			}
+		}
+		if rateLimiter != nil {
+			rateLimiter.SetLimits(configuredRateLimits())
+		}
+		log.Infof("%s Rate limit override removed", logcolors.LogRateLimit)
+	}
+
+	Respond(w, r).JSON(rateLimitsStatus())
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRateLimitsHandler(t *testing.T) {
+	setupTestAuditLog(t)
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	configured := configuredRateLimits()
+	savedLimiter := rateLimiter
+	rateLimiter = middleware.NewIPRateLimiter(2, 5, 10, 20)
+	rateLimiter.SetLimits(configured)
+	defer func() { rateLimiter = savedLimiter }()
+
+	do := func(method, body string) (int, map[string]json.RawMessage) {
+		t.Helper()
+		r := httptest.NewRequest(method, "/config/rate-limits", strings.NewReader(body))
+		r.Header.Set("Authorization", "test-token")
+		rr := httptest.NewRecorder()
+		rateLimitsHandler(rr, r)
+		var resp map[string]json.RawMessage
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr.Code, resp
+	}
+
+	code, resp := do(http.MethodPatch, `{"normal_burst": 3, "cached_per_second": 50}`)
+	if code != http.StatusOK || string(resp["override"]) != "true" {
+		t.Fatalf("PATCH: %d %v", code, resp)
+	}
+	want := configured
+	want.NormalBurst, want.CachedPerSecond = 3, 50
+	if got := rateLimiter.Limits(); got != want {
+		t.Errorf("Effective limits %+v, want %+v", got, want)
+	}
+	var effective middleware.RateLimits
+	json.Unmarshal(resp["effective"], &effective)
+	if effective != want {
+		t.Errorf("Reported effective limits %+v, want %+v", effective, want)
+	}
+
+	// Persisted: a fresh limiter picks the override up at startup
+	restarted := middleware.NewIPRateLimiter(2, 5, 10, 20)
+	applyPersistedRateLimits(restarted)
+	if got := restarted.Limits(); got != want {
+		t.Errorf("Persisted limits %+v, want %+v", got, want)
+	}
+
+	for _, body := range []string{`{"normal_burst": 0}`, `{"cached_per_second": 1001}`, `{"burst": 3}`, `not json`} {
+		if code, _ := do(http.MethodPatch, body); code != http.StatusBadRequest {
+			t.Errorf("PATCH %s: status %d, want 400", body, code)
+		}
+	}
+	if got := rateLimiter.Limits(); got != want {
+		t.Errorf("Rejected PATCH changed the limits to %+v", got)
+	}
+
+	code, resp = do(http.MethodDelete, "")
+	if code != http.StatusOK || string(resp["override"]) != "false" || rateLimiter.Limits() != configured {
+		t.Errorf("DELETE: %d %v, limits %+v", code, resp, rateLimiter.Limits())
+	}
+
+	r := httptest.NewRequest(http.MethodPatch, "/config/rate-limits", strings.NewReader(`{"normal_burst": 3}`))
+	rr := httptest.NewRecorder()
+	rateLimitsHandler(rr, r)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d", rr.Code)
+	}
+}
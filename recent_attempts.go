@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/logcolors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// recentAttemptsBucket holds "recently attempted" markers for upstream lookups. They
+// outlive a restart, so a popular track whose lookup keeps failing (or was in flight
+// when the process died) doesn't send every client straight upstream again before
+// the caches repopulate.
+const recentAttemptsBucket = "recent_attempts"
+
+// serverStartedAt marks the start of the startup grace period (zero in tests, so the
+// grace period never applies there)
+var serverStartedAt time.Time
+
+// recentAttempt is one marker. Error is empty while the lookup is in flight.
+type recentAttempt struct {
+	Error     string `json:"error,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// initRecentAttemptsBucket creates the markers bucket and drops expired markers.
+// Called during server startup after persistentCache is initialized.
+func initRecentAttemptsBucket() {
+	if err := persistentCache.CreateBucket(recentAttemptsBucket); err != nil {
+		log.Errorf("%s Failed to create recent attempts bucket: %v", logcolors.LogCacheNegative, err)
+		return
+	}
+
+	var expired []string
+	live := 0
+	persistentCache.RangeBucket(recentAttemptsBucket, func(k, v []byte) bool {
+		var attempt recentAttempt
+		if json.Unmarshal(v, &attempt) != nil || recentAttemptRemaining(attempt) <= 0 {
+			expired = append(expired, string(k))
+		} else {
+			live++
+		}
+		return true
+	})
+	for _, key := range expired {
+		persistentCache.DeleteFromBucket(recentAttemptsBucket, key)
+	}
+	if live > 0 {
+		log.Infof("%s %d recent upstream attempts carried over from the last run", logcolors.LogCacheNegative, live)
+	}
+}
+
+// recentAttemptTTL is how long a marker holds back new lookups (0 = markers disabled)
+func recentAttemptTTL() time.Duration {
+	return time.Duration(conf().Configuration.RecentAttemptTTLSecs) * time.Second
+}
+
+// recentAttemptRemaining is how much longer attempt holds back new lookups
+func recentAttemptRemaining(attempt recentAttempt) time.Duration {
+	return time.Unix(attempt.Timestamp, 0).Add(recentAttemptTTL()).Sub(clk.Now())
+}
+
+// getRecentAttempt returns the unexpired marker for key and how long it has left
+func getRecentAttempt(key string) (recentAttempt, time.Duration, bool) {
+	if recentAttemptTTL() <= 0 {
+		return recentAttempt{}, 0, false
+	}
+	data, ok := persistentCache.GetFromBucket(recentAttemptsBucket, key)
+	if !ok {
+		return recentAttempt{}, 0, false
+	}
+	var attempt recentAttempt
+	if err := json.Unmarshal(data, &attempt); err != nil {
+		return recentAttempt{}, 0, false
+	}
+	remaining := recentAttemptRemaining(attempt)
+	if remaining <= 0 {
+		persistentCache.DeleteFromBucket(recentAttemptsBucket, key)
+		return recentAttempt{}, 0, false
+	}
+	return attempt, remaining, true
+}
+
+// markAttempt records that a lookup for key started (errMsg empty) or failed with a
+// transient error
+func markAttempt(key, errMsg string) {
+	if recentAttemptTTL() <= 0 {
+		return
+	}
+	data, err := json.Marshal(recentAttempt{Error: errMsg, Timestamp: clk.Now().Unix()})
+	if err != nil {
+		return
+	}
+	if err := persistentCache.SetInBucket(recentAttemptsBucket, key, data); err != nil {
+		log.Debugf("%s Failed to mark attempt for %s: %v", logcolors.LogCacheNegative, key, err)
+	}
+}
+
+// clearAttempt removes the marker once the lookup succeeded or was negatively cached
+func clearAttempt(key string) {
+	if recentAttemptTTL() <= 0 {
+		return
+	}
+	persistentCache.DeleteFromBucket(recentAttemptsBucket, key)
+}
+
+// inFlightLinger is how long a finished in-flight request keeps answering duplicates.
+// During the startup grace period it is stretched so the burst of clients reconnecting
+// after a restart shares one upstream lookup per query.
+func inFlightLinger() time.Duration {
+	grace := time.Duration(conf().Configuration.StartupGraceSecs) * time.Second
+	if !serverStartedAt.IsZero() && clk.Now().Sub(serverStartedAt) < grace {
+		return time.Duration(conf().Configuration.StartupCoalesceSecs) * time.Second
+	}
+	return time.Second
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/internal/clocktest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetLyrics_RecentAttemptHoldsBack(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	initRecentAttemptsBucket()
+
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	origClk := clk
+	clk = fake
+	defer func() { clk = origClk }()
+	originalTTL := conf().Configuration.RecentAttemptTTLSecs
+	conf().Configuration.RecentAttemptTTLSecs = 30
+	defer func() { conf().Configuration.RecentAttemptTTLSecs = originalTTL }()
+
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	markAttempt(cacheKey, "circuit breaker is open")
+	fake.Advance(10 * time.Second)
+
+	rr := httptest.NewRecorder()
+	getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 while the marker is fresh, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Retry-After"); got != "20" {
+		t.Errorf("Retry-After = %q, want 20", got)
+	}
+
+	// With a fallback entry (the query without its album) that is served instead
+	markAttempt(buildNormalizedCacheKey("song", "artist", "album", ""), "circuit breaker is open")
+	setCachedLyrics(cacheKey, testTTML, 0, 0, "", false)
+	fake.Advance(5 * time.Second)
+	rr = httptest.NewRecorder()
+	getLyrics(rr, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&al=album", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Cache-Status") != "STALE" {
+		t.Errorf("Expected a STALE 200 from the fallback key, got %d %s", rr.Code, rr.Header().Get("X-Cache-Status"))
+	}
+	var body struct {
+		Stale      bool  `json:"stale"`
+		AgeSeconds int64 `json:"age_seconds"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	if !body.Stale || body.AgeSeconds != 5 {
+		t.Errorf("Expected stale: true and age_seconds 5, got %s", rr.Body.String())
+	}
+
+	fake.Advance(20 * time.Second)
+	if _, _, found := getRecentAttempt(cacheKey); found {
+		t.Error("Marker must expire after RECENT_ATTEMPT_TTL_SECS")
+	}
+}
+
+func TestInitRecentAttemptsBucket_PrunesExpired(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	initRecentAttemptsBucket()
+
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	origClk := clk
+	clk = fake
+	defer func() { clk = origClk }()
+	originalTTL := conf().Configuration.RecentAttemptTTLSecs
+	conf().Configuration.RecentAttemptTTLSecs = 30
+	defer func() { conf().Configuration.RecentAttemptTTLSecs = originalTTL }()
+
+	markAttempt("ttml_lyrics:old", "")
+	fake.Advance(time.Minute)
+	markAttempt("ttml_lyrics:new", "")
+
+	// Simulates the restart: expired markers are dropped, live ones carried over
+	initRecentAttemptsBucket()
+	if _, ok := persistentCache.GetFromBucket(recentAttemptsBucket, "ttml_lyrics:old"); ok {
+		t.Error("Expired marker should be pruned at startup")
+	}
+	if _, _, found := getRecentAttempt("ttml_lyrics:new"); !found {
+		t.Error("Fresh marker should survive startup")
+	}
+}
+
+func TestInFlightLinger_StartupGrace(t *testing.T) {
+	fake := clocktest.NewFake(time.Unix(1_700_000_000, 0))
+	origClk := clk
+	clk = fake
+	defer func() { clk = origClk }()
+	originalStart := serverStartedAt
+	serverStartedAt = fake.Now()
+	defer func() { serverStartedAt = originalStart }()
+	originalGrace, originalCoalesce := conf().Configuration.StartupGraceSecs, conf().Configuration.StartupCoalesceSecs
+	conf().Configuration.StartupGraceSecs, conf().Configuration.StartupCoalesceSecs = 120, 10
+	defer func() {
+		conf().Configuration.StartupGraceSecs, conf().Configuration.StartupCoalesceSecs = originalGrace, originalCoalesce
+	}()
+
+	if got := inFlightLinger(); got != 10*time.Second {
+		t.Errorf("During the grace period linger = %v, want 10s", got)
+	}
+	fake.Advance(2 * time.Minute)
+	if got := inFlightLinger(); got != time.Second {
+		t.Errorf("After the grace period linger = %v, want 1s", got)
+	}
+}
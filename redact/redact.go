@@ -0,0 +1,133 @@
+// Package redact masks secrets in log output, error responses and notifications.
+//
+// Two layers: exact configured secret values (access tokens, MUTs, SMTP password,
+// the scraped bearer token) registered at startup or when they change, and
+// patterns for secrets that were never configured here (bearer headers, JWTs,
+// token=... pairs echoed back in upstream error bodies).
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Mask replaces every redacted value
+const Mask = "[REDACTED]"
+
+// minSecretLen keeps short values (ports, "true", usernames) from being registered
+// and masking ordinary words everywhere
+const minSecretLen = 8
+
+var (
+	secrets   []string // Longest first, so a secret containing another is masked whole
+	secretsMu sync.RWMutex
+
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	pairPattern   = regexp.MustCompile(`(?i)(\b(?:media-user-token|media_user_token|mut|password|passwd|secret|api[_-]?key|access[_-]?token|bot[_-]?token|token)["']?\s*[:=]\s*["']?)([^\s"'&,;}]+)`)
+)
+
+// Register adds secret values to mask. Empty and short values are ignored.
+func Register(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < minSecretLen || contains(secrets, v) {
+			continue
+		}
+		secrets = append(secrets, v)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// String masks registered secrets and known secret patterns in s
+func String(s string) string {
+	if s == "" {
+		return s
+	}
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, Mask)
+		}
+	}
+	secretsMu.RUnlock()
+
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Mask)
+	s = jwtPattern.ReplaceAllString(s, Mask)
+	s = pairPattern.ReplaceAllStringFunc(s, func(m string) string {
+		parts := pairPattern.FindStringSubmatch(m)
+		if parts[2] == Mask {
+			return m
+		}
+		return parts[1] + Mask
+	})
+	return s
+}
+
+// Value masks strings and errors inside a response body: plain strings, errors and
+// the map shapes handlers pass to Respond().Error. Other values are returned as is.
+func Value(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return String(val)
+	case error:
+		return String(val.Error())
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = Value(item)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for k, item := range val {
+			out[k] = String(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = String(item)
+		}
+		return out
+	}
+	return v
+}
+
+// Formatter wraps a logrus formatter and masks the message and string/error fields
+type Formatter struct {
+	log.Formatter
+}
+
+// Format redacts a copy of the entry and hands it to the wrapped formatter
+func (f Formatter) Format(entry *log.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Message = String(entry.Message)
+	if len(entry.Data) > 0 {
+		redacted.Data = make(log.Fields, len(entry.Data))
+		for k, v := range entry.Data {
+			switch v.(type) {
+			case string, error:
+				redacted.Data[k] = Value(v)
+			default:
+				redacted.Data[k] = v
+			}
+		}
+	}
+	return f.Formatter.Format(&redacted)
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"lyrics-api-go/lyricsquery"
+	"lyrics-api-go/middleware"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The last REPLAY_CAPTURE_SIZE /getLyrics requests whose upstream lookup failed are
+// kept in memory with their params and the trace of the lookup (account, search
+// results, match, lyrics fetch and the upstream error). POST /debug/replay?key=...
+// runs one again with a verbose trace, so a user-reported failure can be reproduced
+// without guessing the exact request.
+
+// lookupCapture is put in the context of a /getLyrics request by captureFailures.
+// The request that leads the upstream lookup fills it in.
+type lookupCapture struct {
+	trace  *ttml.Trace
+	hints  ttml.MatchHints
+	err    error
+	replay bool // Set by replayLookup
+}
+
+// lookupCaptureFrom returns the request's capture, or nil when it isn't captured
+func lookupCaptureFrom(r *http.Request) *lookupCapture {
+	capture, _ := r.Context().Value(lookupCaptureKey).(*lookupCapture)
+	return capture
+}
+
+// FailedLookup is a captured /getLyrics request whose upstream lookup failed
+type FailedLookup struct {
+	Key          string           `json:"key"`
+	CapturedAt   time.Time        `json:"captured_at"`
+	DurationMs   int64            `json:"duration_ms"`
+	Path         string           `json:"path"`
+	Params       url.Values       `json:"params"`
+	ISRC         string           `json:"isrc,omitempty"`
+	ReleaseYear  int              `json:"release_year,omitempty"`
+	Storefront   string           `json:"storefront,omitempty"` // Preferred storefront (see locale_storefront.go)
+	Status       int              `json:"status"`
+	Error        string           `json:"error"`
+	Class        string           `json:"class"` // See errorClass
+	Account      string           `json:"account,omitempty"`
+	BreakerState string           `json:"breaker_state"`
+	Trace        []ttml.TraceStep `json:"trace,omitempty"`
+}
+
+// failedLookupRing holds the captured failures, oldest first
+type failedLookupRing struct {
+	mu      sync.Mutex
+	lookups []FailedLookup
+	seq     atomic.Int64
+
+	persistMu sync.Mutex // Orders the background writes of captured lookups
+}
+
+var failedLookups = &failedLookupRing{}
+
+// add captures a failure, redacted, dropping the oldest beyond size
+func (f *failedLookupRing) add(lookup FailedLookup, size int) FailedLookup {
+	lookup = redactFailedLookup(lookup)
+	lookup.Key = fmt.Sprintf("%d-%d", lookup.CapturedAt.Unix(), f.seq.Add(1))
+	f.mu.Lock()
+	f.lookups = append(f.lookups, lookup)
+	var dropped []FailedLookup
+	if over := len(f.lookups) - size; over > 0 {
+		dropped = f.lookups[:over]
+		f.lookups = append([]FailedLookup(nil), f.lookups[over:]...)
+	}
+	f.mu.Unlock()
+
+	if conf().Configuration.PersistFailedLookups {
+		goWrite(func() { f.persist(lookup, dropped) })
+	}
+	return lookup
+}
+
+// list returns the captured failures, newest first
+func (f *failedLookupRing) list() []FailedLookup {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := make([]FailedLookup, 0, len(f.lookups))
+	for i := len(f.lookups) - 1; i >= 0; i-- {
+		list = append(list, f.lookups[i])
+	}
+	return list
+}
+
+// get returns the captured failure with the given key
+func (f *failedLookupRing) get(key string) (FailedLookup, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, lookup := range f.lookups {
+		if lookup.Key == key {
+			return lookup, true
+		}
+	}
+	return FailedLookup{}, false
+}
+
+// captureFailures wraps a /getLyrics handler so requests whose upstream lookup
+// fails are kept for /debug/replay and /errors. Cache hits, negative hits and requests that
+// waited on another request's lookup are not captured.
+func captureFailures(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		size := conf().Configuration.ReplayCaptureSize
+		if size <= 0 || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+
+		capture := &lookupCapture{trace: ttml.NewTrace(false)}
+		rec := middleware.NewResponseRecorder(w)
+		start := time.Now()
+		next(rec, r.WithContext(context.WithValue(r.Context(), lookupCaptureKey, capture)))
+		if capture.err == nil {
+			return
+		}
+		breakerState, _, _ := ttml.GetCircuitBreakerStats()
+
+		// POST /getLyrics rewrites the query in place, so this is what getLyrics saw
+		params := r.URL.Query()
+		for _, name := range []string{"skip_cache", "no_negative"} {
+			params.Del(name)
+		}
+		failedLookups.add(FailedLookup{
+			CapturedAt:   time.Now(),
+			DurationMs:   time.Since(start).Milliseconds(),
+			Path:         r.URL.Path,
+			Params:       params,
+			ISRC:         capture.hints.ISRC,
+			ReleaseYear:  capture.hints.ReleaseYear,
+			Storefront:   capture.hints.Storefront,
+			Status:       rec.StatusCode,
+			Error:        capture.err.Error(),
+			Class:        errorClass(capture.err),
+			Account:      capture.trace.Account(),
+			BreakerState: breakerState,
+			Trace:        capture.trace.Steps(),
+		}, size)
+	}
+}
+
+// replayHandler lists the captured failed lookups (GET) or runs one again (POST)
+// with a verbose trace. The replay skips the cache (as skip_cache=true does), so
+// it always goes upstream, and its outcome is not captured again.
+//
+// Query params:
+//   - key: The captured lookup (GET: show only this one; POST: required)
+func replayHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+	if r.Method == http.MethodGet && key == "" {
+		lookups := failedLookups.list()
+		Respond(w, r).JSON(map[string]interface{}{
+			"capture_size": conf().Configuration.ReplayCaptureSize,
+			"count":        len(lookups),
+			"lookups":      lookups,
+		})
+		return
+	}
+
+	if key == "" {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "key parameter is required (see GET /debug/replay)",
+		})
+		return
+	}
+	lookup, ok := failedLookups.get(key)
+	if !ok {
+		Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+			"error": "No captured lookup with this key (it may have been pushed out of the buffer)",
+			"key":   key,
+		})
+		return
+	}
+	if r.Method == http.MethodGet {
+		Respond(w, r).JSON(lookup)
+		return
+	}
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"original": lookup,
+		"replay":   replayLookup(r.Context(), lookup),
+	})
+}
+
+// ReplayResult is the outcome of replaying a captured lookup
+type ReplayResult struct {
+	Status      int              `json:"status"`
+	CacheStatus string           `json:"cache_status,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	MatchedID   string           `json:"matched_track_id,omitempty"`
+	DurationMs  int64            `json:"duration_ms"`
+	Trace       []ttml.TraceStep `json:"trace"`
+}
+
+// replayLookup runs a captured lookup through lookupLyrics again with a verbose trace
+func replayLookup(ctx context.Context, lookup FailedLookup) ReplayResult {
+	query, err := lyricsquery.Parse(lookup.Params)
+	if err == nil && !query.HasTrack() {
+		err = errors.New("Song name or artist name not provided")
+	}
+	var preferExplicit, ratingOverride bool
+	if err == nil {
+		preferExplicit, ratingOverride, err = parseExplicitValue(lookup.Params.Get("explicit"))
+	}
+	if err != nil {
+		return ReplayResult{Status: http.StatusBadRequest, Error: err.Error(), Trace: []ttml.TraceStep{}}
+	}
+
+	capture := &lookupCapture{trace: ttml.NewTrace(true), replay: true}
+	start := time.Now()
+	outcome := lookupLyrics(ctx, lyricsLookup{
+		song:           query.Song,
+		artist:         query.Artist,
+		album:          query.Album,
+		duration:       query.Duration,
+		videoID:        query.VideoID,
+		preferExplicit: preferExplicit,
+		ratingOverride: ratingOverride,
+		bypass:         cacheBypass{skipCache: true},
+		priority:       PriorityInteractive,
+		hints:          ttml.MatchHints{ISRC: lookup.ISRC, ReleaseYear: lookup.ReleaseYear, Storefront: lookup.Storefront},
+		capture:        capture,
+	})
+
+	result := ReplayResult{
+		Status:      outcome.status,
+		CacheStatus: outcome.cacheStatus,
+		MatchedID:   outcome.trackID,
+		DurationMs:  time.Since(start).Milliseconds(),
+		Trace:       capture.trace.Steps(),
+	}
+	if capture.err != nil {
+		result.Error = capture.err.Error()
+	} else if outcome.lyrics == nil {
+		result.Error = cmp.Or(outcome.errorMessage(), http.StatusText(outcome.status))
+	}
+	return result
+}
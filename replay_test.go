@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReplay_CapturesAndReplaysFailedLookups(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+	setupTestAuditLog(t)
+	conf().Configuration.CacheAccessToken = "test-token"
+	original := conf().Configuration.ReplayCaptureSize
+	conf().Configuration.ReplayCaptureSize = 2
+	defer func() { conf().Configuration.ReplayCaptureSize = original }()
+	failedLookups = &failedLookupRing{}
+	router := newTestRouter()
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "test-token")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// No accounts are configured in tests, so every upstream lookup fails
+	setCachedLyrics(buildNormalizedCacheKey("Hello", "Adele", "", ""), testTTML, 295000, 0.9, "", false)
+	for _, query := range []string{"s=Hello&a=Adele", "s=One&a=First", "s=Two&a=Second&d=200", "s=Three&a=Third&skip_cache=true"} {
+		serve(http.MethodGet, "/getLyrics?"+query)
+	}
+
+	var list struct {
+		Count   int            `json:"count"`
+		Lookups []FailedLookup `json:"lookups"`
+	}
+	rr := serve(http.MethodGet, "/debug/replay")
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if list.Count != 2 {
+		t.Fatalf("Expected the 2 newest failures, got %s", rr.Body.String())
+	}
+	newest := list.Lookups[0]
+	if newest.Params.Get("s") != "Three" || newest.Params.Has("skip_cache") || newest.Error == "" || newest.Status != http.StatusInternalServerError {
+		t.Errorf("Unexpected capture %+v", newest)
+	}
+	if len(newest.Trace) == 0 || newest.Trace[0].Step != "accounts" {
+		t.Errorf("Expected the lookup trace, got %+v", newest.Trace)
+	}
+
+	rr = serve(http.MethodPost, "/debug/replay?key="+list.Lookups[1].Key)
+	var replay struct {
+		Original FailedLookup `json:"original"`
+		Replay   ReplayResult `json:"replay"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &replay); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("Replay failed with %d: %s", rr.Code, rr.Body.String())
+	}
+	if replay.Original.Params.Get("d") != "200" || replay.Replay.Status != http.StatusInternalServerError ||
+		replay.Replay.Error != "no TTML accounts configured" || len(replay.Replay.Trace) == 0 {
+		t.Errorf("Unexpected replay %s", rr.Body.String())
+	}
+
+	// Replays are not captured again
+	json.Unmarshal(serve(http.MethodGet, "/debug/replay").Body.Bytes(), &list)
+	if list.Count != 2 {
+		t.Errorf("Expected 2 captures after the replay, got %d", list.Count)
+	}
+
+	if rr = serve(http.MethodPost, "/debug/replay?key=nope"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown key, got %d", rr.Code)
+	}
+}
@@ -2,7 +2,14 @@ package main
 
 import (
 	"encoding/json"
+	"lyrics-api-go/cache"
+	"lyrics-api-go/redact"
+	"lyrics-api-go/services/providers"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
 // APIResponse handles consistent header setting and JSON responses.
@@ -42,6 +49,10 @@ func (a *APIResponse) writeHeaders() {
 	if a.provider != "" {
 		a.w.Header().Set("X-Provider", a.provider)
 	}
+	if timing := requestTiming(a.r); timing != nil {
+		a.w.Header().Set("Server-Timing", timing.header(a.cacheStatus))
+	}
+	writeDeprecationHeaders(a.w, a.r)
 
 	// Auth mode from context
 	apiKeyAuthenticated, _ := a.r.Context().Value(apiKeyAuthenticatedKey).(bool)
@@ -64,13 +75,216 @@ func (a *APIResponse) writeHeaders() {
 
 // JSON writes headers and encodes data as JSON (200 OK)
 func (a *APIResponse) JSON(data interface{}) error {
+	data = addResponseWarnings(a.r, data)
 	a.writeHeaders()
+	// Sparse fieldsets (fields=, compact=true); getLyrics rejects invalid ones with a 400
+	if filter, err := parseFieldFilter(a.r); err == nil && filter != nil {
+		data = filter.apply(data)
+	}
 	return json.NewEncoder(a.w).Encode(data)
 }
 
+// writeEncodedJSON writes an already encoded (and field-filtered) JSON body (200 OK)
+func (a *APIResponse) writeEncodedJSON(body []byte) error {
+	a.writeHeaders()
+	_, err := a.w.Write(append(body, '\n'))
+	return err
+}
+
 // Error writes headers, sets status code, and encodes error response
 func (a *APIResponse) Error(statusCode int, data interface{}) error {
+	data = addResponseWarnings(a.r, data)
 	a.writeHeaders()
 	a.w.WriteHeader(statusCode)
-	return json.NewEncoder(a.w).Encode(data)
+	return json.NewEncoder(a.w).Encode(redact.Value(data))
+}
+
+// RateLimited writes a 429 in the documented format: the caller's error/message
+// plus the tier that triggered it and the retry delay (also sent as Retry-After).
+func (a *APIResponse) RateLimited(tier string, retryAfter int, body map[string]interface{}) error {
+	a.w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	body["tier"] = tier
+	body["retry_after"] = retryAfter
+	return a.Error(http.StatusTooManyRequests, body)
+}
+
+// Head writes headers and the status code without a body, for HEAD requests
+func (a *APIResponse) Head(statusCode int, contentType string) {
+	a.writeHeaders()
+	a.w.Header().Set("Content-Type", contentType)
+	a.w.WriteHeader(statusCode)
+}
+
+// Text writes headers and the body as plain text (200 OK)
+func (a *APIResponse) Text(body string) error {
+	a.writeHeaders()
+	a.w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err := a.w.Write([]byte(body))
+	return err
+}
+
+// apiEnvelope is the /v1 response shape shared by every JSON endpoint: data on
+// success, error on failure, and meta with the response headers clients branch on
+type apiEnvelope struct {
+	Data  interface{}       `json:"data"`
+	Error *apiEnvelopeError `json:"error"`
+	Meta  apiEnvelopeMeta   `json:"meta"`
+}
+
+type apiEnvelopeError struct {
+	Status  int                    `json:"status"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"` // Other fields of the legacy error body
+}
+
+type apiEnvelopeMeta struct {
+	APIVersion  string `json:"api_version"`
+	Status      int    `json:"status"`
+	CacheStatus string `json:"cache_status,omitempty"`
+	Provider    string `json:"provider,omitempty"`
+}
+
+// newAPIEnvelope wraps a legacy response in the /v1 envelope. payload is the decoded
+// legacy JSON body, or the message of a plain-text error. Legacy error bodies put
+// their message under "error"; the rest of their fields become details.
+func newAPIEnvelope(statusCode int, header http.Header, payload interface{}) apiEnvelope {
+	env := apiEnvelope{Meta: apiEnvelopeMeta{
+		APIVersion:  "v1",
+		Status:      statusCode,
+		CacheStatus: header.Get("X-Cache-Status"),
+		Provider:    header.Get("X-Provider"),
+	}}
+	payload = snakeCaseKeys(payload)
+	if statusCode < http.StatusBadRequest {
+		env.Data = payload
+		return env
+	}
+
+	env.Error = &apiEnvelopeError{Status: statusCode}
+	switch body := payload.(type) {
+	case map[string]interface{}:
+		if message, ok := body["error"].(string); ok {
+			env.Error.Message = message
+			delete(body, "error")
+		}
+		if len(body) > 0 {
+			env.Error.Details = body
+		}
+	case string:
+		env.Error.Message = strings.TrimSpace(body)
+	}
+	if env.Error.Message == "" {
+		env.Error.Message = http.StatusText(statusCode)
+	}
+	return env
+}
+
+// v1FieldNames maps the camelCase field names of legacy responses to their /v1
+// snake_case names: the JSON tags of the response types plus the keys handlers
+// write as map literals. Only these keys are renamed, so keys that are data rather
+// than field names (stats tenants, cache keys, language tags) keep their spelling.
+var v1FieldNames = jsonFieldNames(
+	[]interface{}{
+		CachedLyrics{}, NegativeCacheEntry{}, SongMetadata{}, VideoAlias{}, FormatVariant{},
+		LearnedAlias{}, durationMismatchWarning{}, providers.LyricsResult{},
+		cache.SnapshotDiff{}, cache.BackupInfo{},
+	},
+	"allCacheKeys", "allVideoIds", "byPrefix", "cacheKey", "durationMs", "isRTL",
+	"maxListKey", "maxListLen", "parsedEntries", "releaseYear", "richParseCap",
+	"richStatsComplete", "startTimeMs", "syncType", "timingType", "totalEntries",
+	"totalKeyBytes", "totalValBytes", "trackDurationMs", "trackId", "trackName",
+	"videoId", "wasNegativeCache", "withArtwork", "withISRC", "withRawAttributes",
+	"withVideoIds",
+)
+
+// jsonFieldNames collects the camelCase JSON field names of types (and of the
+// structs they contain) plus extra, keyed to their snake_case form
+func jsonFieldNames(types []interface{}, extra ...string) map[string]string {
+	names := make(map[string]string)
+	add := func(name string) {
+		if snake := snakeCase(name); snake != name {
+			names[name] = snake
+		}
+	}
+	seen := make(map[reflect.Type]bool)
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || seen[t] {
+			return
+		}
+		seen[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+				add(name)
+			}
+			walk(field.Type)
+		}
+	}
+	for _, v := range types {
+		walk(reflect.TypeOf(v))
+	}
+	for _, name := range extra {
+		add(name)
+	}
+	return names
+}
+
+// snakeCaseKeys renames the known camelCase field names (v1FieldNames) to
+// snake_case throughout a decoded JSON value. Every other key is left alone.
+func snakeCaseKeys(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			if snake, ok := v1FieldNames[key]; ok {
+				key = snake
+			}
+			renamed[key] = snakeCaseKeys(item)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range value {
+			value[i] = snakeCaseKeys(item)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// snakeCase converts a camelCase identifier ("trackId", "isRTL") to snake_case
+// ("track_id", "is_rtl"). Anything else is returned unchanged.
+func snakeCase(key string) string {
+	if key == "" || !unicode.IsLower(rune(key[0])) {
+		return key
+	}
+	hasUpper := false
+	for _, c := range key {
+		if c > unicode.MaxASCII || !(unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			return key
+		}
+		hasUpper = hasUpper || unicode.IsUpper(c)
+	}
+	if !hasUpper {
+		return key
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := rune(key[i])
+		if unicode.IsUpper(c) {
+			prev := rune(key[i-1])
+			nextLower := i+1 < len(key) && unicode.IsLower(rune(key[i+1]))
+			if !unicode.IsUpper(prev) || nextLower {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
 }
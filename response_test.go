@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -260,3 +261,22 @@ func TestAPIResponse_ErrorWithHeaders(t *testing.T) {
 		t.Errorf("status code = %d, want %d", w.Code, http.StatusInternalServerError)
 	}
 }
+
+func TestAPIResponse_ErrorRedactsSecrets(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/test", nil)
+
+	Respond(w, r).Error(http.StatusBadGateway, map[string]interface{}{
+		"error": "upstream 429: {\"detail\":\"throttled\",\"media-user-token\":\"AkQ0secretmut\"} Authorization: Bearer abc.def.ghi",
+	})
+
+	body := w.Body.String()
+	for _, leaked := range []string{"AkQ0secretmut", "abc.def.ghi"} {
+		if strings.Contains(body, leaked) {
+			t.Errorf("response leaked %q: %s", leaked, body)
+		}
+	}
+	if !strings.Contains(body, "throttled") {
+		t.Errorf("expected the rest of the message to survive, got %s", body)
+	}
+}
@@ -1,60 +1,179 @@
 package main
 
 import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
 	"github.com/gorilla/mux"
 )
 
-// setupRoutes configures all HTTP routes for the API
+// setupRoutes configures all HTTP routes for the API. Every route lists the methods
+// it accepts; destructive operations are POST or DELETE only, and honor an
+// Idempotency-Key header (see idempotent).
 func setupRoutes(router *mux.Router) {
 	// Default endpoint - backwards compatible, returns {"ttml": ...}
-	router.HandleFunc("/getLyrics", getLyrics)
+	router.HandleFunc("/getLyrics", captureFailures(getLyrics)).Methods("GET", "HEAD")
+	router.HandleFunc("/getLyrics", captureFailures(getLyricsPost)).Methods("POST")
 
 	// Revalidate endpoint - checks if cached lyrics are stale and updates if needed
-	router.HandleFunc("/revalidate", revalidateHandler)
+	router.HandleFunc("/revalidate", revalidateHandler).Methods("GET", "POST")
 
 	// Override endpoint - replace cached lyrics with content fetched by Apple Music track ID
-	router.HandleFunc("/override", overrideHandler)
+	router.HandleFunc("/override", audited("override.create", idempotent(overrideHandler))).Methods("POST")
 
 	// Provider-specific endpoints - return {"lyrics": ..., "provider": ...}
-	router.HandleFunc("/ttml/getLyrics", getLyricsWithProvider("ttml"))
-	router.HandleFunc("/kugou/getLyrics", getLyricsWithProvider("kugou"))
-	router.HandleFunc("/qq/getLyrics", getLyricsWithProvider("qq"))
-	router.HandleFunc("/legacy/getLyrics", getLyricsWithProvider("legacy"))
+	router.HandleFunc("/ttml/getLyrics", getLyricsWithProvider("ttml")).Methods("GET")
+	router.HandleFunc("/kugou/getLyrics", getLyricsWithProvider("kugou")).Methods("GET")
+	router.HandleFunc("/qq/getLyrics", getLyricsWithProvider("qq")).Methods("GET")
+	router.HandleFunc("/legacy/getLyrics", getLyricsWithProvider("legacy")).Methods("GET")
+	router.HandleFunc("/auto/getLyrics", getLyricsAuto).Methods("GET")
 
 	// Metadata endpoints
-	router.HandleFunc("/video-map", videoMapImportHandler).Methods("POST")
+	router.HandleFunc("/video-map", audited("metadata.import", videoMapImportHandler)).Methods("POST")
 	router.HandleFunc("/metadata", metadataLookupHandler).Methods("GET")
 	router.HandleFunc("/metadata/stats", metadataStatsHandler).Methods("GET")
 	router.HandleFunc("/metadata/sample", metadataSampleHandler).Methods("GET")
 
 	// Cache management endpoints
-	router.HandleFunc("/cache", getCacheDump)
-	router.HandleFunc("/cache/help", cacheHelp)
-	router.HandleFunc("/cache/backup", backupCache)
-	router.HandleFunc("/cache/backups", listBackups)
-	router.HandleFunc("/cache/restore", restoreCache)
-	router.HandleFunc("/cache/clear", clearCache)
-	router.HandleFunc("/cache/clear/{provider}", clearProviderCache)
-	router.HandleFunc("/cache/migrate", migrateCache)
-	router.HandleFunc("/cache/migrate/status", getMigrationStatus)
-	router.HandleFunc("/cache/lookup", cacheLookup)
-	router.HandleFunc("/cache/debug", cacheDebug)
-	router.HandleFunc("/cache/keys", cacheKeys)
-	router.HandleFunc("/cache/dump", cacheDump)
+	router.HandleFunc("/cache", getCacheDump).Methods("GET")
+	router.HandleFunc("/cache/help", cacheHelp).Methods("GET")
+	router.HandleFunc("/cache/backup", audited("cache.backup", backupCache)).Methods("POST")
+	router.HandleFunc("/cache/backups", listBackups).Methods("GET")
+	router.HandleFunc("/cache/backups/diff", diffBackups).Methods("GET")
+	router.HandleFunc("/cache/restore", audited("cache.restore", idempotent(restoreCache))).Methods("POST")
+	router.HandleFunc("/cache/clear", audited("cache.clear", idempotent(clearCache))).Methods("POST")
+	router.HandleFunc("/cache/clear/{provider}", audited("cache.clear_provider", idempotent(clearProviderCache))).Methods("POST")
+	router.HandleFunc("/cache/migrate", audited("cache.migrate", idempotent(migrateCache))).Methods("POST")
+	router.HandleFunc("/cache/migrate/status", getMigrationStatus).Methods("GET")
+	router.HandleFunc("/cache/analyze", analyzeCacheHandler).Methods("POST")
+	router.HandleFunc("/cache/analyze/status", getAnalysisStatus).Methods("GET")
+	router.HandleFunc("/cache/dedupe", audited("cache.dedupe", idempotent(dedupeCacheHandler))).Methods("POST")
+	router.HandleFunc("/cache/dedupe/status", getDedupeStatus).Methods("GET")
+	router.HandleFunc("/cache/verify", audited("cache.verify", idempotent(verifyCacheHandler))).Methods("POST")
+	router.HandleFunc("/cache/verify/status", getVerifyStatus).Methods("GET")
+	router.HandleFunc("/cache/backfill", audited("cache.backfill", idempotent(backfillCacheHandler))).Methods("POST")
+	router.HandleFunc("/cache/backfill/status", getBackfillStatus).Methods("GET")
+	router.HandleFunc("/cache/quarantine", quarantineHandler).Methods("GET")
+	router.HandleFunc("/cache/trash", trashHandler).Methods("GET")
+	router.HandleFunc("/cache/trash/restore", audited("cache.trash_restore", idempotent(trashRestoreHandler))).Methods("POST")
+	router.HandleFunc("/cache/trash/purge", audited("cache.trash_purge", idempotent(trashPurgeHandler))).Methods("POST")
+	router.HandleFunc("/cache/lookup", cacheLookup).Methods("GET")
+	router.HandleFunc("/cache/track", trackCacheHandler).Methods("GET")
+	router.HandleFunc("/cache/track", audited("cache.track_invalidate", idempotent(trackCacheHandler))).Methods("DELETE")
+	router.HandleFunc("/cache/lyrics-changes", lyricsChangesHandler).Methods("GET")
+	router.HandleFunc("/cache/video-alias", videoAliasHandler).Methods("GET")
+	router.HandleFunc("/cache/learned", learnedAliasesHandler).Methods("GET")
+	router.HandleFunc("/cache/learned", audited("cache.learned_prune", idempotent(learnedAliasesHandler))).Methods("DELETE")
+	router.HandleFunc("/cache/video-alias", audited("cache.video_alias_delete", idempotent(videoAliasHandler))).Methods("DELETE")
+	router.HandleFunc("/cache/debug", cacheDebug).Methods("GET")
+	router.HandleFunc("/cache/keys", cacheKeys).Methods("GET")
+	router.HandleFunc("/cache/keys/delete", audited("cache.bulk_delete", idempotent(bulkDeleteHandler))).Methods("POST")
+	router.HandleFunc("/cache/keys/delete/status", getBulkDeleteStatus).Methods("GET")
+	router.HandleFunc("/cache/dump", cacheDump).Methods("GET")
+	router.HandleFunc("/cache/search", cacheSearchHandler).Methods("GET")
+	router.HandleFunc("/cache/search/reindex", audited("cache.search_reindex", lyricsReindexHandler)).Methods("POST")
+	router.HandleFunc("/cache/search/reindex/status", getReindexStatus).Methods("GET")
+
+	// Async admin jobs (migrate, analyze, dedupe, bulk delete, verify, reindex)
+	router.HandleFunc("/jobs", jobsHandler).Methods("GET")
+	router.HandleFunc("/jobs/{id}", jobHandler).Methods("GET")
+	router.HandleFunc("/jobs/{id}/cancel", audited("jobs.cancel", cancelJobHandler)).Methods("POST")
 
 	// Health and stats endpoints
-	router.HandleFunc("/health", getHealthStatus)
-	router.HandleFunc("/health/mut", handleMUTHealth)
-	router.HandleFunc("/stats", getStats)
+	router.HandleFunc("/health", getHealthStatus).Methods("GET", "HEAD")
+	router.HandleFunc("/health/mut", handleMUTHealth).Methods("GET")
+	router.HandleFunc("/accounts/usage", accountUsageHandler).Methods("GET")
+	router.HandleFunc("/selftest", selfTestHandler).Methods("GET")
+	router.HandleFunc("/diagnose", diagnoseHandler).Methods("GET")
+	router.HandleFunc("/stats", getStats).Methods("GET")
+	router.HandleFunc("/stats/export", statsExportHandler).Methods("GET")
+	router.HandleFunc("/stats/duration", statsDurationHandler).Methods("GET")
+	router.HandleFunc("/stats/bandwidth", statsBandwidthHandler).Methods("GET")
+	router.HandleFunc("/stats/parse-warnings", statsParseWarningsHandler).Methods("GET")
+	router.HandleFunc("/stats/reset", audited("stats.reset", idempotent(statsResetHandler))).Methods("POST")
+	router.HandleFunc("/stats/archives", statsArchivesHandler).Methods("GET")
+	router.HandleFunc("/stats/salvage", statsSalvageHandler).Methods("GET")
+	router.HandleFunc("/stats/salvage", audited("stats.salvage", idempotent(statsSalvageHandler))).Methods("POST")
+	router.HandleFunc("/stats/archives/{id}", statsArchivesHandler).Methods("GET")
+	router.HandleFunc("/log-level", logLevelHandler).Methods("GET")
+	router.HandleFunc("/log-level", audited("log.level", logLevelHandler)).Methods("PUT")
+	router.HandleFunc("/config/rate-limits", rateLimitsHandler).Methods("GET")
+	router.HandleFunc("/config/rate-limits", audited("config.rate_limits", rateLimitsHandler)).Methods("PATCH", "DELETE")
 
 	// Circuit breaker endpoints
-	router.HandleFunc("/circuit-breaker", getCircuitBreakerStatus)
-	router.HandleFunc("/circuit-breaker/reset", resetCircuitBreaker)
-	router.HandleFunc("/circuit-breaker/simulate-failure", simulateCircuitBreakerFailure)
+	router.HandleFunc("/circuit-breaker", getCircuitBreakerStatus).Methods("GET")
+	router.HandleFunc("/circuit-breaker/reset", audited("circuit_breaker.reset", resetCircuitBreaker)).Methods("POST")
+	router.HandleFunc("/circuit-breaker/simulate-failure", audited("circuit_breaker.simulate_failure", simulateCircuitBreakerFailure)).Methods("POST")
+
+	// Audit log of admin and destructive operations (append-only)
+	router.HandleFunc("/audit", auditLogHandler).Methods("GET")
+
+	// Recent failed lookups by error class (the /debug/replay buffer)
+	router.HandleFunc("/errors", errorsHandler).Methods("GET")
 
 	// Test/debug endpoints
-	router.HandleFunc("/test-notifications", testNotifications)
+	router.HandleFunc("/test-notifications", testNotifications).Methods("POST")
+	router.HandleFunc("/debug/recording", audited("debug.recording", upstreamRecordingHandler)).Methods("GET", "POST")
+	router.HandleFunc("/debug/replay", replayHandler).Methods("GET")
+	router.HandleFunc("/debug/replay", audited("debug.replay", replayHandler)).Methods("POST")
+
+	// Memory and runtime profiling (net/http/pprof, expvar); also on PPROF_LISTEN_ADDR
+	router.HandleFunc("/debug/gc", audited("debug.gc", gcHandler)).Methods("GET", "POST")
+	for _, endpoint := range profilingHandlers() {
+		if endpoint.path == profilingIndexPath {
+			router.PathPrefix(endpoint.path).Handler(adminOnly(endpoint.handler)).Methods("GET")
+		} else {
+			router.Handle(endpoint.path, adminOnly(endpoint.handler)).Methods("GET", "POST")
+		}
+	}
+
+	// Self-host bootstrap endpoint - reports missing settings (unauthenticated)
+	router.HandleFunc("/setup/check", setupCheckHandler).Methods("GET")
+
+	// Conformance corpus - fixed lyrics fixtures for client tests (unauthenticated)
+	router.HandleFunc("/testdata", testCorpusHandler).Methods("GET")
+	router.HandleFunc("/testdata/{case}", testCorpusHandler).Methods("GET")
 
 	// Help endpoint
-	router.HandleFunc("/", helpHandler)
+	router.HandleFunc("/", helpHandler).Methods("GET")
+
+	// A known path with the wrong method gets 405 and the methods it does accept
+	router.MethodNotAllowedHandler = methodNotAllowedHandler(router)
+}
+
+// methodNotAllowedHandler answers 405 with an Allow header listing every method
+// registered for the request's path
+func methodNotAllowedHandler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(router, r)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		Respond(w, r).Error(http.StatusMethodNotAllowed, map[string]interface{}{
+			"error":   fmt.Sprintf("Method %s not allowed", r.Method),
+			"allowed": allowed,
+		})
+	})
+}
+
+// allowedMethods returns the sorted methods of every route whose path matches r
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	seen := make(map[string]bool)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		var match mux.RouteMatch
+		if route.Match(r, &match) || match.MatchErr == mux.ErrMethodMismatch {
+			methods, _ := route.GetMethods()
+			for _, method := range methods {
+				seen[method] = true
+			}
+		}
+		return nil
+	})
+
+	allowed := make([]string, 0, len(seen))
+	for method := range seen {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return allowed
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func newTestRouter() *mux.Router {
+	router := mux.NewRouter()
+	setupRoutes(router)
+	return router
+}
+
+func TestRoutes_MethodNotAllowed(t *testing.T) {
+	router := newTestRouter()
+
+	tests := []struct {
+		method string
+		path   string
+		allow  string
+	}{
+		{http.MethodGet, "/cache/clear", "POST"},
+		{http.MethodGet, "/cache/analyze", "POST"},
+		{http.MethodPost, "/stats", "GET"},
+		{http.MethodPut, "/cache/track", "DELETE, GET"},
+		{http.MethodDelete, "/getLyrics", "GET, HEAD, POST"},
+		{http.MethodPost, "/log-level", "GET, PUT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if rr.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("Expected 405, got %d", rr.Code)
+			}
+			if got := rr.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+
+	// Unknown paths are still 404
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/nope", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown path, got %d", rr.Code)
+	}
+}
+
+func TestGetLyrics_Head(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	router := newTestRouter()
+
+	setCachedLyrics(buildNormalizedCacheKey("Cached Song", "Artist", "", ""), testTTML, 0, 0, "", false)
+	setNegativeCache(buildNormalizedCacheKey("Missing Song", "Artist", "", ""), "No lyrics found", "", false)
+
+	tests := []struct {
+		query       string
+		status      int
+		cacheStatus string
+		contentType string
+	}{
+		{"s=Cached+Song&a=Artist", http.StatusOK, "HIT", "application/json"},
+		{"s=Cached+Song&a=Artist&format=lrc", http.StatusOK, "HIT", "text/plain; charset=utf-8"},
+		{"s=Missing+Song&a=Artist", http.StatusNotFound, "NEGATIVE_HIT", "application/json"},
+		{"s=Unknown+Song&a=Artist", http.StatusOK, "MISS", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/getLyrics?"+tt.query, nil))
+			if rr.Code != tt.status {
+				t.Errorf("Status = %d, want %d", rr.Code, tt.status)
+			}
+			if got := rr.Header().Get("X-Cache-Status"); got != tt.cacheStatus {
+				t.Errorf("X-Cache-Status = %q, want %q", got, tt.cacheStatus)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if rr.Body.Len() != 0 {
+				t.Errorf("HEAD must not write a body, got %q", rr.Body.String())
+			}
+		})
+	}
+}
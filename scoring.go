@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"lyrics-api-go/config"
+	"lyrics-api-go/logcolors"
+	"net/http"
+
+	ttml "lyrics-api-go/services/providers/ttml"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// getLyricsWithWeights serves the experimental ?weights=name,artist,album override on
+// /getLyrics. It is admin-only and always bypasses the cache in both directions, so a
+// tuning session can never serve or store a match picked with non-production weights.
+func getLyricsWithWeights(w http.ResponseWriter, r *http.Request, format, weightsParam, songName, artistName, albumName, durationStr string) {
+	if conf().Configuration.CacheAccessToken == "" || r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		Respond(w, r).Error(http.StatusUnauthorized, map[string]interface{}{
+			"error": "The weights override requires the admin Authorization header",
+		})
+		return
+	}
+
+	weights, err := config.ParseScoreWeights(weightsParam)
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "Invalid weights: " + err.Error(),
+		})
+		return
+	}
+
+	var durationMs int
+	if durationStr != "" {
+		fmt.Sscanf(durationStr, "%d", &durationMs)
+		durationMs = durationMs * 1000
+	}
+
+	log.Infof("%s Weights override for %s - %s: %s", logcolors.LogTrackScore, songName, artistName, weights)
+
+	ttmlString, trackDurationMs, score, trackMeta, err := ttml.FetchTTMLLyricsWithWeights(songName, artistName, albumName, durationMs, weights)
+	if err != nil || ttmlString == "" {
+		body := map[string]interface{}{
+			"error":   "Lyrics not available for this track",
+			"weights": weights,
+		}
+		if err != nil {
+			body["error"] = err.Error()
+		}
+		if trackMeta != nil {
+			body["trackId"] = trackMeta.TrackID
+			body["score"] = score
+		}
+		Respond(w, r).SetCacheStatus("BYPASS").Error(http.StatusNotFound, body)
+		return
+	}
+
+	respondTTML(Respond(w, r).SetCacheStatus("BYPASS"), format, ttmlString, map[string]interface{}{
+		"ttml":            ttmlString,
+		"score":           score,
+		"weights":         weights,
+		"trackId":         trackMeta.TrackID,
+		"trackName":       trackMeta.Name,
+		"trackDurationMs": trackDurationMs,
+	})
+}
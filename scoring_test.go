@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetLyricsWeightsOverride_RequiresAdmin(t *testing.T) {
+	savedToken := conf().Configuration.CacheAccessToken
+	defer func() { conf().Configuration.CacheAccessToken = savedToken }()
+
+	for _, tt := range []struct {
+		name  string
+		token string
+		auth  string
+	}{
+		{"no admin token configured", "", ""},
+		{"missing header", "test-token", ""},
+		{"wrong header", "test-token", "nope"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			conf().Configuration.CacheAccessToken = tt.token
+			r := httptest.NewRequest(http.MethodGet, "/getLyrics?s=Hello&a=Adele&weights=0.6,0.3,0.1", nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+
+			getLyrics(w, r)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestGetLyricsWeightsOverride_InvalidWeights(t *testing.T) {
+	savedToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = savedToken }()
+
+	r := httptest.NewRequest(http.MethodGet, "/getLyrics?s=Hello&a=Adele&weights=0.9,0.9,0.9", nil)
+	r.Header.Set("Authorization", "test-token")
+	w := httptest.NewRecorder()
+
+	getLyrics(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(w.Body.String(), "sum to 1.0") {
+		t.Errorf("expected validation message, got %s", w.Body.String())
+	}
+}
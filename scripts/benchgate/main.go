@@ -0,0 +1,169 @@
+// Command benchgate compares `go test -bench -benchmem` output against a stored
+// baseline and exits non-zero when a benchmark got slower or allocates more than
+// the tolerance allows. Run it through `make bench-check`; `make bench-baseline`
+// records a new baseline.
+//
+// With -count > 1 the best run of each benchmark is compared, which keeps one
+// noisy run from failing the gate.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// result is the best measurement of one benchmark
+type result struct {
+	NsPerOp     float64
+	AllocsPerOp float64
+}
+
+// regression is one metric of one benchmark past its tolerance
+type regression struct {
+	Name     string
+	Metric   string
+	Baseline float64
+	Current  float64
+}
+
+func (r regression) String() string {
+	return fmt.Sprintf("%s: %s %.0f -> %.0f (%+.1f%%)", r.Name, r.Metric, r.Baseline, r.Current, (r.Current/r.Baseline-1)*100)
+}
+
+// procsSuffix is the -GOMAXPROCS suffix go test appends to benchmark names
+var procsSuffix = regexp.MustCompile(`-\d+$`)
+
+func main() {
+	baselinePath := flag.String("baseline", "scripts/benchgate/baseline.txt", "stored benchmark output")
+	currentPath := flag.String("current", "bench_output.txt", "benchmark output to check")
+	nsTolerance := flag.Float64("ns-tolerance", 0.25, "allowed ns/op increase as a fraction (negative = don't check)")
+	allocsTolerance := flag.Float64("allocs-tolerance", 0.10, "allowed allocs/op increase as a fraction (negative = don't check)")
+	flag.Parse()
+
+	baseline, err := parseFile(*baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "benchgate: %v\n", err)
+		os.Exit(2)
+	}
+	current, err := parseFile(*currentPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "benchgate: %v\n", err)
+		os.Exit(2)
+	}
+
+	regressions, missing := compare(baseline, current, *nsTolerance, *allocsTolerance)
+	for _, name := range missing {
+		fmt.Printf("warning: %s is in the baseline but was not run\n", name)
+	}
+	if len(regressions) > 0 {
+		fmt.Printf("%d benchmark regression(s) against %s:\n", len(regressions), *baselinePath)
+		for _, r := range regressions {
+			fmt.Println("  " + r.String())
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("%d benchmarks within tolerance (ns/op +%.0f%%, allocs/op +%.0f%%)\n",
+		len(current), *nsTolerance*100, *allocsTolerance*100)
+}
+
+func parseFile(path string) (map[string]result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	results, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%s: no benchmark results", path)
+	}
+	return results, nil
+}
+
+// parse reads go test benchmark output. Names are qualified by the preceding
+// "pkg:" line so equally named benchmarks in different packages stay apart.
+func parse(r io.Reader) (map[string]result, error) {
+	results := make(map[string]result)
+	pkg := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "pkg: ") {
+			pkg = strings.TrimPrefix(line, "pkg: ")
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+
+		name := procsSuffix.ReplaceAllString(fields[0], "")
+		if pkg != "" {
+			name = pkg + "." + name
+		}
+		res := result{NsPerOp: -1, AllocsPerOp: -1}
+		// After the name and iteration count come value/unit pairs
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad value %q in %q", fields[i], line)
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				res.NsPerOp = value
+			case "allocs/op":
+				res.AllocsPerOp = value
+			}
+		}
+		if res.NsPerOp < 0 {
+			continue
+		}
+
+		if best, ok := results[name]; ok {
+			res.NsPerOp = min(res.NsPerOp, best.NsPerOp)
+			if best.AllocsPerOp >= 0 && (res.AllocsPerOp < 0 || best.AllocsPerOp < res.AllocsPerOp) {
+				res.AllocsPerOp = best.AllocsPerOp
+			}
+		}
+		results[name] = res
+	}
+	return results, scanner.Err()
+}
+
+// compare returns the regressions past tolerance, sorted by name, and the baseline
+// benchmarks that are missing from the current run. Benchmarks new since the
+// baseline are not checked.
+func compare(baseline, current map[string]result, nsTolerance, allocsTolerance float64) ([]regression, []string) {
+	var regressions []regression
+	var missing []string
+	for name, base := range baseline {
+		cur, ok := current[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if nsTolerance >= 0 && cur.NsPerOp > base.NsPerOp*(1+nsTolerance) {
+			regressions = append(regressions, regression{name, "ns/op", base.NsPerOp, cur.NsPerOp})
+		}
+		if allocsTolerance >= 0 && base.AllocsPerOp >= 0 && cur.AllocsPerOp > base.AllocsPerOp*(1+allocsTolerance) {
+			regressions = append(regressions, regression{name, "allocs/op", base.AllocsPerOp, cur.AllocsPerOp})
+		}
+	}
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].Name != regressions[j].Name {
+			return regressions[i].Name < regressions[j].Name
+		}
+		return regressions[i].Metric < regressions[j].Metric
+	})
+	sort.Strings(missing)
+	return regressions, missing
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleOutput = `goos: linux
+goarch: amd64
+pkg: lyrics-api-go/cache
+BenchmarkPersistentCacheGet-8   	    2170	    515684 ns/op	  71.92 MB/s	  148044 B/op	      17 allocs/op
+BenchmarkPersistentCacheGet-8   	    2301	    498000 ns/op	  71.92 MB/s	  148044 B/op	      17 allocs/op
+PASS
+ok  	lyrics-api-go/cache	5.465s
+pkg: lyrics-api-go/services/providers/ttml
+BenchmarkScoreTrack-8           	      74	  14537125 ns/op	 1286666 B/op	   33808 allocs/op
+some log line that is not a result
+PASS
+`
+
+func TestParse(t *testing.T) {
+	results, err := parse(strings.NewReader(sampleOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 benchmarks, got %v", results)
+	}
+	get := results["lyrics-api-go/cache.BenchmarkPersistentCacheGet"]
+	if get.NsPerOp != 498000 || get.AllocsPerOp != 17 {
+		t.Errorf("Expected the best of both runs, got %+v", get)
+	}
+	score := results["lyrics-api-go/services/providers/ttml.BenchmarkScoreTrack"]
+	if score.NsPerOp != 14537125 || score.AllocsPerOp != 33808 {
+		t.Errorf("Unexpected ScoreTrack result %+v", score)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := map[string]result{
+		"a":    {NsPerOp: 1000, AllocsPerOp: 10},
+		"b":    {NsPerOp: 1000, AllocsPerOp: 10},
+		"gone": {NsPerOp: 1000, AllocsPerOp: 10},
+	}
+	current := map[string]result{
+		"a":   {NsPerOp: 1200, AllocsPerOp: 11}, // within tolerance
+		"b":   {NsPerOp: 1300, AllocsPerOp: 12},
+		"new": {NsPerOp: 99999, AllocsPerOp: 999},
+	}
+
+	regressions, missing := compare(baseline, current, 0.25, 0.10)
+	if len(regressions) != 2 || regressions[0].Metric != "allocs/op" || regressions[1].Metric != "ns/op" {
+		t.Errorf("Expected ns/op and allocs/op regressions for b, got %v", regressions)
+	}
+	if len(missing) != 1 || missing[0] != "gone" {
+		t.Errorf("Expected gone to be reported missing, got %v", missing)
+	}
+
+	if regressions, _ := compare(baseline, current, -1, 0.10); len(regressions) != 1 {
+		t.Errorf("A negative tolerance must skip ns/op, got %v", regressions)
+	}
+}
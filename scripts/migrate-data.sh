@@ -21,7 +21,7 @@
 #   - Railway CLI installed (npm install -g @railway/cli)
 #   - Logged in to Railway (railway login)
 #   - Linked to project (railway link)
-#   - Backup created on preview via: curl -H "Authorization: <token>" <preview-url>/cache/backup
+#   - Backup created on preview via: curl -X POST -H "Authorization: <token>" <preview-url>/cache/backup
 #
 # What this script does:
 #   1. Downloads latest backup files from preview environment via SSH
@@ -132,7 +132,7 @@ if [ -z "$PREVIEW_ENV" ] && [ -z "$LOCAL_CACHE_FILE" ] && [ -z "$LOCAL_STATS_FIL
     echo ""
     echo "Examples:"
     echo "  # First, create backup on preview:"
-    echo "  curl -H 'Authorization: <token>' https://preview.example.com/cache/backup"
+    echo "  curl -X POST -H 'Authorization: <token>' https://preview.example.com/cache/backup"
     echo ""
     echo "  # Then run migration:"
     echo "  $0 pr-123"
@@ -433,7 +433,7 @@ else
         fi
     else
         echo -e "      ${YELLOW}⚠${NC} No cache backup found in $BACKUP_PATH/"
-        echo -e "      ${YELLOW}→${NC} Create one first: curl -H 'Authorization: <token>' <preview-url>/cache/backup"
+        echo -e "      ${YELLOW}→${NC} Create one first: curl -X POST -H 'Authorization: <token>' <preview-url>/cache/backup"
         LOCAL_CACHE=""
     fi
     fi  # End SKIP_CACHE else
@@ -484,7 +484,7 @@ if [ -z "$LOCAL_CACHE" ] && [ -z "$LOCAL_STATS" ] && [ -z "$CACHE_DOWNLOAD_URL"
     echo -e "${RED}Error: No backup files found to migrate${NC}"
     echo ""
     echo "Make sure you've created backups first:"
-    echo "  curl -H 'Authorization: <token>' <preview-url>/cache/backup"
+    echo "  curl -X POST -H 'Authorization: <token>' <preview-url>/cache/backup"
     exit 1
 fi
 
@@ -610,14 +610,14 @@ else
     if [ -z "$PROD_API_URL" ] || [ -z "$CACHE_ACCESS_TOKEN" ]; then
         echo -e "      ${YELLOW}⚠${NC} Missing PROD_API_URL or CACHE_ACCESS_TOKEN"
         echo -e "      ${YELLOW}→${NC} Manual restore required:"
-        [ -n "$LOCAL_CACHE" ] && echo "         curl -H 'Authorization: <token>' '<prod-url>/cache/restore?backup=$CACHE_BACKUP_NAME'"
+        [ -n "$LOCAL_CACHE" ] && echo "         curl -X POST -H 'Authorization: <token>' '<prod-url>/cache/restore?backup=$CACHE_BACKUP_NAME'"
         echo ""
     else
         RESTORE_FAILED=false
 
         if [ -n "$LOCAL_CACHE" ]; then
             echo -ne "      Restoring cache..."
-            RESTORE_RESULT=$(curl -s -H "Authorization: $CACHE_ACCESS_TOKEN" "$PROD_API_URL/cache/restore?backup=$CACHE_BACKUP_NAME" 2>/dev/null)
+            RESTORE_RESULT=$(curl -s -X POST -H "Authorization: $CACHE_ACCESS_TOKEN" "$PROD_API_URL/cache/restore?backup=$CACHE_BACKUP_NAME" 2>/dev/null)
             if echo "$RESTORE_RESULT" | grep -q "restored successfully"; then
                 KEYS_RESTORED=$(echo "$RESTORE_RESULT" | grep -oE '"keys_restored":[0-9]+' | grep -oE '[0-9]+')
                 echo -e "\r      ${GREEN}✓${NC} Cache restored ($KEYS_RESTORED keys)"
@@ -637,7 +637,7 @@ else
             echo ""
             echo -e "${RED}ERROR: Restore failed. Check the error above.${NC}"
             echo -e "${YELLOW}The backup file is on production at: $BACKUP_PATH/$CACHE_BACKUP_NAME${NC}"
-            echo -e "${YELLOW}You can retry manually: curl -H 'Authorization: <token>' '$PROD_API_URL/cache/restore?backup=$CACHE_BACKUP_NAME'${NC}"
+            echo -e "${YELLOW}You can retry manually: curl -X POST -H 'Authorization: <token>' '$PROD_API_URL/cache/restore?backup=$CACHE_BACKUP_NAME'${NC}"
             exit 1
         fi
     fi
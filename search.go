@@ -0,0 +1,368 @@
+package main
+
+import (
+	"html"
+	"lyrics-api-go/jobs"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// lyricsIndexBucket maps a lyric token to the ttml_lyrics keys whose text contains it.
+// Values are newline-separated keys (plain bytes, not compressed: postings are small
+// and rewritten often).
+const lyricsIndexBucket = "lyrics_index"
+
+const (
+	// maxPostingsPerToken caps a posting list. Tokens that appear in more songs than
+	// this are too common to narrow a search; once full they stop growing.
+	maxPostingsPerToken = 2000
+	// searchDefaultLimit / searchMaxLimit bound the number of tracks returned
+	searchDefaultLimit = 20
+	searchMaxLimit     = 100
+	// searchMaxSnippets is the number of matching lines returned per track
+	searchMaxSnippets = 3
+	// searchMaxScan bounds how many candidate entries are loaded per query
+	searchMaxScan = 500
+	// reindexFlushEvery is how many entries the reindex buffers before writing postings
+	reindexFlushEvery = 2000
+)
+
+// lyricsStopwords are skipped when indexing: they appear in nearly every song
+var lyricsStopwords = map[string]bool{
+	"the": true, "and": true, "you": true, "a": true, "i": true, "to": true, "it": true,
+	"me": true, "my": true, "in": true, "of": true, "on": true, "is": true, "oh": true,
+	"be": true, "that": true, "your": true, "we": true, "all": true, "for": true,
+	"so": true, "do": true, "no": true, "yeah": true, "can": true, "but": true,
+}
+
+// lyricsIndexMu serializes read-modify-write on posting lists
+var lyricsIndexMu sync.Mutex
+
+// LyricsSearchHit is one track matching a /cache/search query
+type LyricsSearchHit struct {
+	Key         string   `json:"key"`
+	Song        string   `json:"song,omitempty"`
+	Artist      string   `json:"artist,omitempty"`
+	PhraseMatch bool     `json:"phrase_match"` // A single line contains the query as typed
+	Snippets    []string `json:"snippets"`
+}
+
+// LyricsReindexResult contains the results of a /cache/search/reindex job
+type LyricsReindexResult struct {
+	Processed int `json:"processed"`
+	Indexed   int `json:"indexed"`
+}
+
+// initLyricsIndexBucket creates the lyrics index bucket if it doesn't exist
+func initLyricsIndexBucket() {
+	if err := persistentCache.CreateBucket(lyricsIndexBucket); err != nil {
+		log.Errorf("%s Failed to create lyrics index bucket: %v", logcolors.LogCache, err)
+	}
+}
+
+// tokenizeLyrics lowercases text and splits it on anything that isn't a letter or digit.
+// Stopwords and single characters are dropped; tokens are deduplicated in order.
+func tokenizeLyrics(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(html.UnescapeString(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	seen := make(map[string]bool, len(fields))
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = strings.Trim(f, "'")
+		if len([]rune(f)) < 2 || lyricsStopwords[f] || seen[f] {
+			continue
+		}
+		seen[f] = true
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
+
+// lyricLines returns the sung lines of a TTML document (section headers and blanks dropped)
+func lyricLines(ttmlContent string) []string {
+	text, err := ttml.ToPlainText(ttmlContent)
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || (strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")) {
+			continue
+		}
+		lines = append(lines, html.UnescapeString(line))
+	}
+	return lines
+}
+
+// indexCachedLyrics adds a lyrics entry to the posting lists of its tokens.
+// Called from setCachedLyrics; no-lyrics markers are skipped.
+func indexCachedLyrics(key, ttmlContent string) {
+	if ttmlContent == "" || ttmlContent == NoLyricsSentinel {
+		return
+	}
+	tokens := tokenizeLyrics(strings.Join(lyricLines(ttmlContent), "\n"))
+	if len(tokens) == 0 {
+		return
+	}
+	postings := make(map[string][]string, len(tokens))
+	for _, token := range tokens {
+		postings[token] = []string{key}
+	}
+	if err := mergePostings(postings); err != nil {
+		log.Warnf("%s Failed to index lyrics for %s: %v", logcolors.LogCache, key, err)
+	}
+}
+
+// mergePostings adds keys to posting lists and writes every touched list in one transaction
+func mergePostings(postings map[string][]string) error {
+	lyricsIndexMu.Lock()
+	defer lyricsIndexMu.Unlock()
+
+	updates := make(map[string][]byte, len(postings))
+	for token, keys := range postings {
+		existing := getPostings(token)
+		if len(existing) >= maxPostingsPerToken {
+			continue
+		}
+		merged := mergeStringSlice(existing, keys)
+		if len(merged) > maxPostingsPerToken {
+			merged = merged[:maxPostingsPerToken]
+		}
+		if len(merged) == len(existing) {
+			continue
+		}
+		updates[token] = []byte(strings.Join(merged, "\n"))
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return persistentCache.SetManyInBucket(lyricsIndexBucket, updates)
+}
+
+// getPostings returns the cache keys indexed under a token
+func getPostings(token string) []string {
+	data, ok := persistentCache.GetFromBucket(lyricsIndexBucket, token)
+	if !ok || len(data) == 0 {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// searchCachedLyrics returns tracks whose lyrics contain every query token.
+// Tracks where one line holds the whole phrase rank first.
+func searchCachedLyrics(query string, limit int) []LyricsSearchHit {
+	tokens := tokenizeLyrics(query)
+	hits := []LyricsSearchHit{}
+	if len(tokens) == 0 {
+		return hits
+	}
+
+	// Intersect posting lists, smallest first
+	lists := make([][]string, 0, len(tokens))
+	for _, token := range tokens {
+		keys := getPostings(token)
+		if len(keys) == 0 {
+			return hits
+		}
+		lists = append(lists, keys)
+	}
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	candidates := lists[0]
+	for _, list := range lists[1:] {
+		inList := make(map[string]bool, len(list))
+		for _, key := range list {
+			inList[key] = true
+		}
+		filtered := candidates[:0:0]
+		for _, key := range candidates {
+			if inList[key] {
+				filtered = append(filtered, key)
+			}
+		}
+		candidates = filtered
+	}
+
+	if len(candidates) > searchMaxScan {
+		candidates = candidates[:searchMaxScan]
+	}
+
+	phrase := strings.Join(tokenizeLyricsKeepAll(query), " ")
+	for _, key := range candidates {
+		cached, ok := getCachedLyrics(key)
+		if !ok || cached.TTML == NoLyricsSentinel {
+			continue // Deleted or overwritten since it was indexed
+		}
+		hit, ok := matchLyricLines(key, lyricLines(cached.TTML), tokens, phrase)
+		if !ok {
+			continue
+		}
+		if meta, ok := getSongMetadata(key); ok {
+			hit.Song, hit.Artist = meta.TrackName, meta.ArtistName
+		}
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].PhraseMatch && !hits[j].PhraseMatch })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// tokenizeLyricsKeepAll splits like tokenizeLyrics but keeps stopwords and order,
+// so phrase matching compares the query as typed
+func tokenizeLyricsKeepAll(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// matchLyricLines picks snippet lines: lines containing the phrase first, then lines
+// containing every token, then lines containing any token
+func matchLyricLines(key string, lines, tokens []string, phrase string) (LyricsSearchHit, bool) {
+	hit := LyricsSearchHit{Key: key, Snippets: []string{}}
+	var allTokens, anyToken []string
+	for _, line := range lines {
+		normalized := " " + strings.Join(tokenizeLyricsKeepAll(line), " ") + " "
+		if phrase != "" && strings.Contains(normalized, " "+phrase+" ") {
+			hit.PhraseMatch = true
+			if len(hit.Snippets) < searchMaxSnippets {
+				hit.Snippets = append(hit.Snippets, line)
+			}
+			continue
+		}
+		matched := 0
+		for _, token := range tokens {
+			if strings.Contains(normalized, " "+token+" ") {
+				matched++
+			}
+		}
+		switch {
+		case matched == len(tokens):
+			allTokens = append(allTokens, line)
+		case matched > 0:
+			anyToken = append(anyToken, line)
+		}
+	}
+	for _, line := range append(allTokens, anyToken...) {
+		if len(hit.Snippets) >= searchMaxSnippets {
+			break
+		}
+		hit.Snippets = append(hit.Snippets, line)
+	}
+	return hit, len(hit.Snippets) > 0
+}
+
+// cacheSearchHandler searches the text of cached lyrics.
+//
+// Query params:
+//   - q: words or phrase to find (required)
+//   - limit: max tracks (default 20, max 100)
+func cacheSearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len(tokenizeLyrics(query)) == 0 {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "q must contain at least one searchable word",
+		})
+		return
+	}
+
+	limit := searchDefaultLimit
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		limit = min(n, searchMaxLimit)
+	}
+
+	hits := searchCachedLyrics(query, limit)
+	Respond(w, r).JSON(map[string]interface{}{
+		"query":   query,
+		"count":   len(hits),
+		"results": hits,
+	})
+}
+
+// lyricsReindexHandler starts an async job that rebuilds the lyrics index from
+// every cached entry. Entries cached after the index existed are indexed as they
+// are written; this backfills everything older.
+//
+// Returns immediately with a job ID. Use /cache/search/reindex/status?job_id=xxx to check progress.
+func lyricsReindexHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	job, ok := startJob(w, r, jobKindReindex, nil, "/cache/search/reindex/status", "Reindex started", runLyricsReindex)
+	if ok {
+		log.Infof("%s Started lyrics index rebuild job %s", logcolors.LogCache, job.ID)
+	}
+}
+
+// getReindexStatus returns the status of a reindex job
+func getReindexStatus(w http.ResponseWriter, r *http.Request) {
+	writeJobStatus(w, r, jobKindReindex, nil)
+}
+
+// runLyricsReindex is the /cache/search/reindex job. It walks every ttml_lyrics
+// entry and merges its tokens into the index, flushing buffered postings every
+// reindexFlushEvery entries.
+func runLyricsReindex(t *jobs.Task) (interface{}, error) {
+	// Collect keys first: merging postings writes to the DB, which can't happen
+	// inside the read transaction RangeKeys holds
+	var keys []string
+	persistentCache.RangeKeys("ttml_lyrics:", func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	t.SetProgress(0, len(keys))
+
+	var result LyricsReindexResult
+	postings := make(map[string][]string)
+	flush := func() error {
+		err := mergePostings(postings)
+		postings = make(map[string][]string)
+		return err
+	}
+
+	for i, key := range keys {
+		if t.Cancelled() {
+			// What was buffered is still valid, keep it
+			flush()
+			return result, t.Context().Err()
+		}
+		if cached, ok := getCachedLyrics(key); ok && cached.TTML != NoLyricsSentinel {
+			for _, token := range tokenizeLyrics(strings.Join(lyricLines(cached.TTML), "\n")) {
+				postings[token] = append(postings[token], key)
+			}
+			result.Indexed++
+		}
+		result.Processed++
+		if (i+1)%reindexFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+		t.SetProgress(i+1, len(keys))
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	log.Infof("%s Lyrics reindex job %s complete: %d entries indexed", logcolors.LogCache, t.ID(), result.Indexed)
+	return result, nil
+}
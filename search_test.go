@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/jobs"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func searchTestTTML(lines ...string) string {
+	body := ""
+	for _, line := range lines {
+		body += `<p begin="0:00:01.000" end="0:00:02.000">` + line + `</p>`
+	}
+	return `<tt xmlns="http://www.w3.org/ns/ttml" timing="Line"><body><div songPart="Verse">` + body + `</div></body></tt>`
+}
+
+func TestTokenizeLyrics(t *testing.T) {
+	got := tokenizeLyrics("Hello, it's ME... I was wondering &amp; wondering")
+	want := []string{"hello", "it's", "was", "wondering"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenizeLyrics = %v, want %v", got, want)
+	}
+}
+
+func TestSearchCachedLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	setCachedLyrics("ttml_lyrics:hello adele", searchTestTTML("Hello, it's me", "I was wondering if after all these years"), 0, 1, "", false)
+	setCachedLyrics("ttml_lyrics:other song", searchTestTTML("Wondering the years away", "After the rain"), 0, 1, "", false)
+	setCachedLyrics("ttml_lyrics:instrumental x", NoLyricsSentinel, 0, 0, "", false)
+
+	hits := searchCachedLyrics("after all these years", 10)
+	if len(hits) != 1 || hits[0].Key != "ttml_lyrics:hello adele" || !hits[0].PhraseMatch {
+		t.Fatalf("Unexpected hits for phrase: %+v", hits)
+	}
+	if hits[0].Snippets[0] != "I was wondering if after all these years" {
+		t.Errorf("Unexpected snippet: %v", hits[0].Snippets)
+	}
+
+	// Both tracks contain "wondering" and "years"; the one with the phrase on one line ranks first
+	hits = searchCachedLyrics("wondering the years", 10)
+	if len(hits) != 2 || hits[0].Key != "ttml_lyrics:other song" || !hits[0].PhraseMatch || hits[1].PhraseMatch {
+		t.Fatalf("Unexpected ranking: %+v", hits)
+	}
+
+	if hits := searchCachedLyrics("nonexistentword", 10); len(hits) != 0 {
+		t.Errorf("Expected no hits, got %+v", hits)
+	}
+
+	// Overwritten entries are dropped at query time
+	setCachedLyrics("ttml_lyrics:hello adele", NoLyricsSentinel, 0, 0, "", false)
+	if hits := searchCachedLyrics("after all these years", 10); len(hits) != 0 {
+		t.Errorf("Expected stale posting to be skipped, got %+v", hits)
+	}
+}
+
+func TestLyricsReindex(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	// Written directly, bypassing setCachedLyrics, like entries cached before the index existed
+	data, _ := json.Marshal(CachedLyrics{TTML: searchTestTTML("Rolling in the deep")})
+	persistentCache.Set("ttml_lyrics:rolling in the deep adele", string(data))
+	if hits := searchCachedLyrics("rolling deep", 10); len(hits) != 0 {
+		t.Fatalf("Expected unindexed entry to be missing, got %+v", hits)
+	}
+
+	req := httptest.NewRequest("POST", "/cache/search/reindex", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr := httptest.NewRecorder()
+	lyricsReindexHandler(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", rr.Code)
+	}
+
+	job := waitForStartedJob(t, rr)
+	if job.Status != jobs.StatusCompleted || job.Kind != jobKindReindex {
+		t.Fatalf("Unexpected job: %+v", job)
+	}
+	if result, ok := job.Result.(LyricsReindexResult); !ok || result.Indexed != 1 || result.Processed != 1 {
+		t.Fatalf("Unexpected reindex result: %+v", job.Result)
+	}
+
+	req = httptest.NewRequest("GET", "/cache/search?q=rolling+deep", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	cacheSearchHandler(rr, req)
+
+	var body struct {
+		Count   int               `json:"count"`
+		Results []LyricsSearchHit `json:"results"`
+	}
+	json.NewDecoder(rr.Body).Decode(&body)
+	if body.Count != 1 || body.Results[0].Key != "ttml_lyrics:rolling in the deep adele" {
+		t.Errorf("Unexpected search response: %+v", body)
+	}
+}
+
+func TestCacheSearchHandler_Validation(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	rr := httptest.NewRecorder()
+	cacheSearchHandler(rr, httptest.NewRequest("GET", "/cache/search?q=hello", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", rr.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/cache/search?q=the+a", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	cacheSearchHandler(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for stopword-only query, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"sync"
+)
+
+// selfTestMu keeps canary runs from overlapping; each one costs two upstream requests
+var selfTestMu sync.Mutex
+
+// selfTestHandler runs the canary track through the live pipeline (search → lyrics
+// fetch → parse → checksum compare), bypassing every cache, and reports per-stage
+// timing. Responds 200 when every stage passes and 503 otherwise, so it can be
+// polled by external monitoring.
+//
+// Query params:
+//   - s, a: override the configured canary song/artist for this run
+func selfTestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !selfTestMu.TryLock() {
+		Respond(w, r).Error(http.StatusConflict, map[string]interface{}{
+			"error": "A self-test is already running",
+		})
+		return
+	}
+	defer selfTestMu.Unlock()
+
+	song, artist := conf().Configuration.SelfTestSong, conf().Configuration.SelfTestArtist
+	expectedChecksum := conf().Configuration.SelfTestChecksum
+	if s, a := r.URL.Query().Get("s"), r.URL.Query().Get("a"); s != "" || a != "" {
+		// The configured checksum belongs to the configured canary
+		song, artist, expectedChecksum = s, a, ""
+	}
+
+	report := ttml.RunSelfTest(song, artist, expectedChecksum)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !report.Passed {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
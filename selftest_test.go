@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSelfTestHandler_Unauthorized(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	rr := httptest.NewRecorder()
+	selfTestHandler(rr, httptest.NewRequest("GET", "/selftest", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", rr.Code)
+	}
+}
+
+func TestSelfTestHandler_Conflict(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	selfTestMu.Lock()
+	defer selfTestMu.Unlock()
+
+	req := httptest.NewRequest("GET", "/selftest", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr := httptest.NewRecorder()
+	selfTestHandler(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while a run is in progress, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// serverTiming collects where a /getLyrics request spent its time, sent back as a
+// Server-Timing header so the breakdown shows up in browser devtools:
+//
+//	cache;desc="MISS";dur=0.4, search;dur=412.3, fetch;dur=180.1, serialize;dur=2.0, total;dur=595.2
+//
+// cache covers the cache and negative-cache lookups, search and fetch the upstream
+// track search and lyrics request, wait the time spent behind an identical
+// in-flight request, and serialize the format conversion and encoding.
+type serverTiming struct {
+	started        time.Time
+	phases         []timingPhase // In the order they were first recorded
+	serializeStart time.Time
+}
+
+type timingPhase struct {
+	name string
+	dur  time.Duration
+}
+
+// withServerTiming returns r with a new timing collector in its context
+func withServerTiming(r *http.Request) (*http.Request, *serverTiming) {
+	timing := &serverTiming{started: time.Now()}
+	return r.WithContext(context.WithValue(r.Context(), serverTimingKey, timing)), timing
+}
+
+// requestTiming returns the request's timing collector, or nil when it has none.
+// Every method is a no-op on nil.
+func requestTiming(r *http.Request) *serverTiming {
+	timing, _ := r.Context().Value(serverTimingKey).(*serverTiming)
+	return timing
+}
+
+// add adds d to a phase; recording a phase again accumulates
+func (t *serverTiming) add(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	for i := range t.phases {
+		if t.phases[i].name == name {
+			t.phases[i].dur += d
+			return
+		}
+	}
+	t.phases = append(t.phases, timingPhase{name: name, dur: d})
+}
+
+// since adds the time elapsed since start to a phase
+func (t *serverTiming) since(name string, start time.Time) {
+	t.add(name, time.Since(start))
+}
+
+// upstream splits an upstream lookup into search and lyrics fetch. Without track
+// metadata the lookup failed in search, so all of it counts as search; a learned
+// alias skips search, so all of it counts as fetch.
+func (t *serverTiming) upstream(total time.Duration, meta *ttml.TrackMeta) {
+	if meta == nil {
+		t.add("search", total)
+		return
+	}
+	search := min(meta.SearchDuration, total)
+	if search > 0 {
+		t.add("search", search)
+	}
+	t.add("fetch", total-search)
+}
+
+// startSerialize marks the start of building the response body. The serialize
+// phase ends when the headers are written.
+func (t *serverTiming) startSerialize() {
+	if t == nil || !t.serializeStart.IsZero() {
+		return
+	}
+	t.serializeStart = time.Now()
+}
+
+// header renders the Server-Timing value, with the cache status as the cache
+// phase's description
+func (t *serverTiming) header(cacheStatus string) string {
+	if !t.serializeStart.IsZero() {
+		t.add("serialize", time.Since(t.serializeStart))
+		t.serializeStart = time.Time{}
+	}
+
+	parts := make([]string, 0, len(t.phases)+1)
+	for _, phase := range t.phases {
+		part := phase.name
+		if phase.name == "cache" && cacheStatus != "" {
+			part += fmt.Sprintf(";desc=%q", cacheStatus)
+		}
+		parts = append(parts, part+";dur="+formatTimingMs(phase.dur))
+	}
+	parts = append(parts, "total;dur="+formatTimingMs(time.Since(t.started)))
+	return strings.Join(parts, ", ")
+}
+
+// formatTimingMs formats a duration in milliseconds, as Server-Timing expects
+func formatTimingMs(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d.Microseconds())/1000)
+}
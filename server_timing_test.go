@@ -0,0 +1,95 @@
+package main
+
+import (
+	"lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerTiming_Header(t *testing.T) {
+	timing := &serverTiming{started: time.Now()}
+	timing.add("cache", 300*time.Microsecond)
+	timing.upstream(500*time.Millisecond, &ttml.TrackMeta{SearchDuration: 350 * time.Millisecond})
+	timing.add("cache", 200*time.Microsecond)
+
+	header := timing.header("MISS")
+	for _, want := range []string{`cache;desc="MISS";dur=0.5`, "search;dur=350.0", "fetch;dur=150.0", "total;dur="} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header %q is missing %q", header, want)
+		}
+	}
+	if !strings.HasPrefix(header, "cache;") || !strings.HasSuffix(header[:strings.LastIndex(header, ",")], "fetch;dur=150.0") {
+		t.Errorf("phases out of order: %q", header)
+	}
+}
+
+func TestServerTiming_UpstreamWithoutMeta(t *testing.T) {
+	timing := &serverTiming{started: time.Now()}
+	timing.upstream(80*time.Millisecond, nil)
+
+	header := timing.header("")
+	if !strings.HasPrefix(header, "search;dur=80.0, total;dur=") {
+		t.Errorf("header = %q, want all of a failed lookup counted as search", header)
+	}
+}
+
+func TestServerTiming_NilIsNoop(t *testing.T) {
+	var timing *serverTiming
+	timing.add("cache", time.Millisecond)
+	timing.upstream(time.Millisecond, nil)
+	timing.startSerialize()
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	rr := httptest.NewRecorder()
+	Respond(rr, req).JSON(map[string]interface{}{})
+	if got := rr.Header().Get("Server-Timing"); got != "" {
+		t.Errorf("Server-Timing = %q on a request without a collector", got)
+	}
+}
+
+func TestGetLyrics_ServerTimingOnCacheHit(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	setCachedLyrics(cacheKey, formatTestTTML, 0, 0, "", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist", nil)
+	rr := httptest.NewRecorder()
+	getLyrics(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	header := rr.Header().Get("Server-Timing")
+	for _, want := range []string{`cache;desc="HIT";dur=`, "serialize;dur=", "total;dur="} {
+		if !strings.Contains(header, want) {
+			t.Errorf("Server-Timing %q is missing %q", header, want)
+		}
+	}
+	if strings.Contains(header, "search;") || strings.Contains(header, "fetch;") {
+		t.Errorf("Server-Timing %q has upstream phases on a cache hit", header)
+	}
+}
+
+func TestGetLyrics_ServerTimingOnNegativeHit(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	cacheKey := buildNormalizedCacheKey("song", "artist", "", "")
+	setNegativeCache(cacheKey, "No lyrics", "", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist", nil)
+	rr := httptest.NewRecorder()
+	getLyrics(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if header := rr.Header().Get("Server-Timing"); !strings.HasPrefix(header, `cache;desc="NEGATIVE_HIT";dur=`) {
+		t.Errorf("Server-Timing = %q, want the negative hit as the cache phase", header)
+	}
+}
@@ -3,6 +3,7 @@ package notifier
 import (
 	"fmt"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/redact"
 	"strings"
 	"sync"
 	"time"
@@ -47,14 +48,18 @@ func NewAlertHandler(config AlertConfig) *AlertHandler {
 
 // Start subscribes the handler to the event bus
 func (h *AlertHandler) Start() {
-	bus := GetEventBus()
-	bus.SubscribeAll(h.handleEvent)
+	GetEventBus().Register(h)
 	log.Infof("%s Alert handler started (cooldown: %v, notifiers: %d)",
 		logcolors.LogNotifier, h.cooldownDuration, len(h.notifiers))
 }
 
-// handleEvent processes incoming events
-func (h *AlertHandler) handleEvent(event *Event) {
+// Name identifies the alert handler on the event bus
+func (h *AlertHandler) Name() string {
+	return "alerts"
+}
+
+// HandleEvent processes incoming events
+func (h *AlertHandler) HandleEvent(event *Event) {
 	// Check cooldown
 	if !h.shouldAlert(event.Type) {
 		log.Debugf("%s Skipping alert for %s (cooldown active)", logcolors.LogNotifier, event.Type)
@@ -145,6 +150,16 @@ func (h *AlertHandler) formatAlert(event *Event) (subject, message string) {
 		}
 		message += "\nThe process may be OOM-killed soon. Check Railway metrics."
 
+	case EventCacheReadOnly:
+		freeMB := event.Data["free_mb"].(uint64)
+		thresholdMB := event.Data["threshold_mb"].(uint64)
+		subject = "Cache Read-Only"
+		message = fmt.Sprintf(
+			"Only %d MB is free on the cache volume (read-only below %d MB).\n\n"+
+				"Cached lyrics are still served, but new lookups are no longer cached.\n\n"+
+				"Action: Purge the trash (/cache/trash/purge), delete old backups or grow the volume. Writes resume on their own once space is freed.",
+			freeMB, thresholdMB)
+
 	case EventServerStartupFailed:
 		component := event.Data["component"].(string)
 		errMsg := event.Data["error"].(string)
@@ -203,6 +218,102 @@ func (h *AlertHandler) formatAlert(event *Event) (subject, message string) {
 		}
 		message += "\nIf this account gets rate-limited, all active accounts will be quarantined."
 
+	case EventCacheHitRateLow:
+		hitRate := event.Data["hit_rate"].(float64)
+		threshold := event.Data["threshold"].(float64)
+		hits := event.Data["hits"].(int64)
+		lookups := event.Data["lookups"].(int64)
+		window := event.Data["window"].(string)
+		subject = "Cache Hit Rate Low"
+		message = fmt.Sprintf(
+			"Cache hit rate over the last %s is %.1f%% (threshold: %.0f%%).\n\n"+
+				"  • Hits: %d of %d lookups\n\n"+
+				"Action: Check for a cache clear/restore, key format changes or a traffic shift.",
+			window, hitRate, threshold, hits, lookups)
+
+	case EventUpstreamSchemaDrift:
+		malformedRate := event.Data["malformed_rate"].(float64)
+		threshold := event.Data["threshold"].(float64)
+		malformed := event.Data["malformed"].(int64)
+		requests := event.Data["requests"].(int64)
+		window := event.Data["window"].(string)
+		subject = "Upstream Schema Drift"
+		message = fmt.Sprintf(
+			"%.1f%% of upstream requests over the last %s returned a response shape the API doesn't recognize (threshold: %.0f%%).\n\n"+
+				"  • Malformed: %d of %d requests\n\n"+
+				"These lookups are failing as upstream errors and are not negative-cached.\n"+
+				"Action: Compare a live search/lyrics response with the fields in services/providers/ttml/types.go and schema.go.",
+			malformedRate, window, threshold, malformed, requests)
+
+	case EventUpstreamErrorRateHigh:
+		errorRate := event.Data["error_rate"].(float64)
+		threshold := event.Data["threshold"].(float64)
+		errors := event.Data["errors"].(int64)
+		requests := event.Data["requests"].(int64)
+		window := event.Data["window"].(string)
+		subject = "Upstream Error Rate High"
+		message = fmt.Sprintf(
+			"Upstream error rate over the last %s is %.1f%% (threshold: %.0f%%).\n\n"+
+				"  • Errors: %d of %d requests (transport errors, 401, 429, 5xx)\n\n"+
+				"Action: Check TTML API status, account health and the bearer token.",
+			window, errorRate, threshold, errors, requests)
+
+	case EventCacheCorruption:
+		count := event.Data["count"].(int)
+		window := event.Data["window"].(string)
+		lastKey := event.Data["last_key"].(string)
+		lastReason := event.Data["last_reason"].(string)
+		subject = "Cache Corruption Detected"
+		message = fmt.Sprintf(
+			"%d corrupt cache entries were found in the last %s and deleted.\n\n"+
+				"  • Latest: %s (%s)\n\n"+
+				"Action: Check the disk backing the cache DB and run /cache/verify to find other bad entries; restore from a backup if corruption keeps appearing.",
+			count, window, lastKey, lastReason)
+
+	case EventTruncatedLyrics:
+		trackID := event.Data["track_id"].(string)
+		query := event.Data["query"].(string)
+		reason := event.Data["reason"].(string)
+		subject = "Truncated Lyrics Served"
+		message = fmt.Sprintf(
+			"Lyrics fetched for \"%s\" (Apple track %s) look truncated and were not cached.\n\n"+
+				"  • %s\n\n"+
+				"Action: Check the track's TTML upstream. Further truncated results are only logged until the cooldown ends.",
+			query, trackID, reason)
+
+	case EventDiskSpaceLow:
+		role := event.Data["role"].(string)
+		path := event.Data["path"].(string)
+		freeMB := event.Data["free_mb"].(uint64)
+		thresholdMB := event.Data["threshold_mb"].(uint64)
+		subject = "Disk Space Low"
+		message = fmt.Sprintf(
+			"Only %d MB is free on the volume holding the %s directory (%s), below %d MB.\n\n"+
+				"Action: Free up space before cache writes and backups start failing.",
+			freeMB, role, path, thresholdMB)
+
+	case EventDatabaseLocked:
+		path := event.Data["path"].(string)
+		attempt := event.Data["attempt"].(int)
+		attempts := event.Data["attempts"].(int)
+		subject = "Database Locked at Startup"
+		message = fmt.Sprintf(
+			"Could not get the lock on %s (attempt %d of %d).\n\n"+
+				"Another process has the file open, usually a previous instance that hasn't exited or a tool inspecting the file. Startup fails after the last attempt.\n\n"+
+				"Action: Stop the other process, or point CACHE_DB_PATH at another file.",
+			path, attempt, attempts)
+
+	case EventStatsDBRecovered:
+		path := event.Data["path"].(string)
+		movedTo := event.Data["moved_to"].(string)
+		errMsg := event.Data["error"].(string)
+		subject = "Stats Database Corrupt"
+		message = fmt.Sprintf(
+			"The stats database %s could not be opened or loaded and was moved to %s. The server started with fresh stats.\n\n"+
+				"  • Error: %s\n\n"+
+				"Action: Check the disk backing the stats DB, then POST /stats/salvage to add the counters that can still be read from the moved file.",
+			path, movedTo, errMsg)
+
 	case EventCacheBackupFailed:
 		errMsg := event.Data["error"].(string)
 		subject = "Cache Backup Failed"
@@ -234,11 +345,45 @@ func (h *AlertHandler) formatAlert(event *Event) (subject, message string) {
 			message = fmt.Sprintf("Server started successfully on port %s with %d account(s).", port, activeCount)
 		}
 
+	case EventCacheWritable:
+		freeMB := event.Data["free_mb"].(uint64)
+		subject = "Cache Writable Again"
+		message = fmt.Sprintf("Cache left read-only mode with %d MB free on its volume.", freeMB)
+
+	case EventConfigReloaded:
+		source := event.Data["source"].(string)
+		keys := event.Data["keys"].([]string)
+		subject = "Configuration Reloaded"
+		message = fmt.Sprintf("Settings changed by the remote config at %s:\n\n  • %s",
+			source, strings.Join(keys, "\n  • "))
+
 	case EventCacheCleared:
 		backupPath := event.Data["backup_path"].(string)
 		subject = "Cache Cleared"
 		message = fmt.Sprintf("Cache has been cleared.\n\nBackup saved to: %s", backupPath)
 
+	case EventLyricsChanged:
+		trackID := event.Data["track_id"].(string)
+		query := event.Data["query"].(string)
+		revision := event.Data["revision"].(int)
+		summary := event.Data["summary"].(string)
+		subject = "Lyrics Changed Upstream"
+		message = fmt.Sprintf(
+			"A re-fetch of \"%s\" (Apple track %s) returned different lyrics than were cached (revision %d).\n\n"+
+				"  • %s\n\n"+
+				"The new lyrics are cached. GET /cache/lyrics-changes?track=%s lists the changes.",
+			query, trackID, revision, summary, trackID)
+
+	case EventStorefrontChanged:
+		account := event.Data["account"].(string)
+		oldStorefront := event.Data["old_storefront"].(string)
+		newStorefront := event.Data["new_storefront"].(string)
+		subject = "Account Storefront Changed"
+		message = fmt.Sprintf(
+			"Account %s's subscription storefront changed from %s to %s.\n\n"+
+				"Requests through this account now use the %s catalog.",
+			account, oldStorefront, newStorefront, newStorefront)
+
 	default:
 		return "", ""
 	}
@@ -267,7 +412,7 @@ func (h *AlertHandler) sendAlert(subject, message string, event *Event) {
 
 	successCount := 0
 	for _, n := range h.notifiers {
-		if err := n.Send(subject, message); err != nil {
+		if err := n.Send(redact.String(subject), redact.String(message)); err != nil {
 			log.Errorf("%s Failed to send alert via notifier: %v", logcolors.LogNotifier, err)
 		} else {
 			successCount++
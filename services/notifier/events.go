@@ -1,8 +1,13 @@
 package notifier
 
 import (
+	"lyrics-api-go/logcolors"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // EventType represents the type of event
@@ -17,17 +22,32 @@ const (
 	EventMUTHealthCheckFailed  EventType = "mut_health_check_failed"
 
 	EventMemoryThresholdExceeded EventType = "memory_threshold_exceeded"
+	EventCacheReadOnly           EventType = "cache_read_only"
+	EventUpstreamSchemaDrift     EventType = "upstream_schema_drift"
+	EventStatsDBRecovered        EventType = "stats_db_recovered"
 
 	// Warning events
 	EventHighFailureRate        EventType = "high_failure_rate"
 	EventHalfAccountsQuarantine EventType = "half_accounts_quarantined"
 	EventOneAwayFromQuarantine  EventType = "one_away_from_quarantine"
 	EventCacheBackupFailed      EventType = "cache_backup_failed"
+	EventAccountDisabled        EventType = "account_disabled"
+	EventCacheHitRateLow        EventType = "cache_hit_rate_low"
+	EventUpstreamErrorRateHigh  EventType = "upstream_error_rate_high"
+	EventCacheCorruption        EventType = "cache_corruption"
+	EventTruncatedLyrics        EventType = "truncated_lyrics"
+	EventDiskSpaceLow           EventType = "disk_space_low"
+	EventDatabaseLocked         EventType = "database_locked"
 
 	// Info events
 	EventCircuitBreakerRecovered EventType = "circuit_breaker_recovered"
 	EventServerStarted           EventType = "server_started"
 	EventCacheCleared            EventType = "cache_cleared"
+	EventAccountEnabled          EventType = "account_enabled"
+	EventStorefrontChanged       EventType = "account_storefront_changed"
+	EventCacheWritable           EventType = "cache_writable"
+	EventConfigReloaded          EventType = "config_reloaded"
+	EventLyricsChanged           EventType = "lyrics_changed"
 )
 
 // Severity represents the severity level of an event
@@ -41,11 +61,11 @@ const (
 
 // Event represents a system event
 type Event struct {
-	Type      EventType
-	Severity  Severity
-	Message   string
-	Data      map[string]interface{}
-	Timestamp time.Time
+	Type      EventType              `json:"type"`
+	Severity  Severity               `json:"severity"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
 }
 
 // NewEvent creates a new event with the current timestamp
@@ -68,11 +88,49 @@ func (e *Event) WithData(key string, value interface{}) *Event {
 // EventHandler is a function that handles events
 type EventHandler func(event *Event)
 
-// EventBus manages event publishing and subscription
+// Subscriber is a named event consumer (alerts, stats, audit log, webhook...).
+// Publish sites never reference subscribers, so adding a consumer only means
+// registering it at startup.
+type Subscriber interface {
+	Name() string
+	HandleEvent(event *Event)
+}
+
+// handlerSubscriber adapts a plain EventHandler to Subscriber
+type handlerSubscriber struct {
+	name    string
+	handler EventHandler
+}
+
+func (h handlerSubscriber) Name() string             { return h.name }
+func (h handlerSubscriber) HandleEvent(event *Event) { h.handler(event) }
+
+// subscription is a registered subscriber plus its event filter (nil = all events)
+type subscription struct {
+	id         uint64
+	subscriber Subscriber
+	types      map[EventType]bool
+	delivered  atomic.Int64
+	panics     atomic.Int64
+}
+
+// SubscriptionInfo describes a registered subscriber for introspection
+type SubscriptionInfo struct {
+	Name      string      `json:"name"`
+	Types     []EventType `json:"types,omitempty"` // empty = all events
+	Delivered int64       `json:"delivered"`
+	Panics    int64       `json:"panics"`
+}
+
+// EventBus manages event publishing and subscription.
+// Delivery is asynchronous (one goroutine per subscriber per event) and a
+// panicking subscriber is recovered so it can't take the process down.
 type EventBus struct {
-	handlers    map[EventType][]EventHandler
-	allHandlers []EventHandler // handlers that receive all events
-	mu          sync.RWMutex
+	subs      []*subscription
+	nextID    uint64
+	published map[EventType]int64
+	pending   sync.WaitGroup
+	mu        sync.RWMutex
 }
 
 // Global event bus instance
@@ -82,44 +140,121 @@ var busOnce sync.Once
 // GetEventBus returns the global event bus instance
 func GetEventBus() *EventBus {
 	busOnce.Do(func() {
-		globalBus = &EventBus{
-			handlers:    make(map[EventType][]EventHandler),
-			allHandlers: make([]EventHandler, 0),
-		}
+		globalBus = NewEventBus()
 	})
 	return globalBus
 }
 
-// Subscribe adds a handler for a specific event type
-func (b *EventBus) Subscribe(eventType EventType, handler EventHandler) {
+// NewEventBus creates an empty event bus (the server uses GetEventBus; tests use their own)
+func NewEventBus() *EventBus {
+	return &EventBus{published: make(map[EventType]int64)}
+}
+
+// Register adds a subscriber for the given event types (none = all events).
+// Returns a function that removes the subscription.
+func (b *EventBus) Register(sub Subscriber, types ...EventType) (unsubscribe func()) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.handlers[eventType] = append(b.handlers[eventType], handler)
+
+	b.nextID++
+	s := &subscription{id: b.nextID, subscriber: sub}
+	if len(types) > 0 {
+		s.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+	b.subs = append(b.subs, s)
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, existing := range b.subs {
+			if existing.id == s.id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Subscribe adds a handler for a specific event type
+func (b *EventBus) Subscribe(eventType EventType, handler EventHandler) {
+	b.Register(handlerSubscriber{name: string(eventType) + "_handler", handler: handler}, eventType)
 }
 
 // SubscribeAll adds a handler that receives all events
 func (b *EventBus) SubscribeAll(handler EventHandler) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	b.allHandlers = append(b.allHandlers, handler)
+	b.Register(handlerSubscriber{name: "all_events_handler", handler: handler})
 }
 
 // Publish sends an event to all subscribed handlers
 func (b *EventBus) Publish(event *Event) {
+	b.mu.Lock()
+	b.published[event.Type]++
+	targets := make([]*subscription, 0, len(b.subs))
+	for _, s := range b.subs {
+		if s.types == nil || s.types[event.Type] {
+			targets = append(targets, s)
+		}
+	}
+	b.pending.Add(len(targets))
+	b.mu.Unlock()
+
+	for _, s := range targets {
+		go b.deliver(s, event)
+	}
+}
+
+// deliver runs one subscriber, recovering from panics
+func (b *EventBus) deliver(s *subscription, event *Event) {
+	defer b.pending.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			s.panics.Add(1)
+			log.Errorf("%s Subscriber %s panicked on %s: %v", logcolors.LogNotifier, s.subscriber.Name(), event.Type, r)
+		}
+	}()
+	s.subscriber.HandleEvent(event)
+	s.delivered.Add(1)
+}
+
+// Drain blocks until every event published so far has been delivered
+func (b *EventBus) Drain() {
+	b.pending.Wait()
+}
+
+// Subscriptions lists registered subscribers with delivery counters
+func (b *EventBus) Subscriptions() []SubscriptionInfo {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	// Call specific handlers
-	if handlers, ok := b.handlers[event.Type]; ok {
-		for _, handler := range handlers {
-			go handler(event)
+	infos := make([]SubscriptionInfo, 0, len(b.subs))
+	for _, s := range b.subs {
+		info := SubscriptionInfo{
+			Name:      s.subscriber.Name(),
+			Delivered: s.delivered.Load(),
+			Panics:    s.panics.Load(),
 		}
+		for t := range s.types {
+			info.Types = append(info.Types, t)
+		}
+		sort.Slice(info.Types, func(i, j int) bool { return info.Types[i] < info.Types[j] })
+		infos = append(infos, info)
 	}
+	return infos
+}
 
-	// Call handlers subscribed to all events
-	for _, handler := range b.allHandlers {
-		go handler(event)
+// PublishedCounts returns how many events of each type have been published
+func (b *EventBus) PublishedCounts() map[EventType]int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	counts := make(map[EventType]int64, len(b.published))
+	for t, n := range b.published {
+		counts[t] = n
 	}
+	return counts
 }
 
 // Helper functions for publishing common events
@@ -191,6 +326,169 @@ func PublishAccountAuthFailure(accountName string, statusCode int) {
 	GetEventBus().Publish(event)
 }
 
+// PublishAccountDisabled publishes when an account is taken out of rotation
+func PublishAccountDisabled(accountName, reason string) {
+	event := NewEvent(EventAccountDisabled, SeverityWarning,
+		"API account disabled").
+		WithData("account", accountName).
+		WithData("reason", reason)
+	GetEventBus().Publish(event)
+}
+
+// PublishAccountEnabled publishes when a disabled account is put back into rotation
+func PublishAccountEnabled(accountName, reason string) {
+	event := NewEvent(EventAccountEnabled, SeverityInfo,
+		"API account re-enabled").
+		WithData("account", accountName).
+		WithData("reason", reason)
+	GetEventBus().Publish(event)
+}
+
+// PublishStorefrontChanged publishes when revalidation finds an account's subscription
+// storefront has changed
+func PublishStorefrontChanged(accountName, oldStorefront, newStorefront string) {
+	event := NewEvent(EventStorefrontChanged, SeverityInfo,
+		"Account storefront changed").
+		WithData("account", accountName).
+		WithData("old_storefront", oldStorefront).
+		WithData("new_storefront", newStorefront)
+	GetEventBus().Publish(event)
+}
+
+// PublishCacheHitRateLow publishes when the rolling cache hit rate drops below the threshold
+func PublishCacheHitRateLow(hitRate, threshold float64, hits, lookups int64, window time.Duration) {
+	event := NewEvent(EventCacheHitRateLow, SeverityWarning,
+		"Cache hit rate dropped below threshold").
+		WithData("hit_rate", hitRate).
+		WithData("threshold", threshold).
+		WithData("hits", hits).
+		WithData("lookups", lookups).
+		WithData("window", window.String())
+	GetEventBus().Publish(event)
+}
+
+// PublishUpstreamErrorRateHigh publishes when the rolling upstream error rate exceeds the threshold
+func PublishUpstreamErrorRateHigh(errorRate, threshold float64, errors, requests int64, window time.Duration) {
+	event := NewEvent(EventUpstreamErrorRateHigh, SeverityWarning,
+		"Upstream error rate exceeded threshold").
+		WithData("error_rate", errorRate).
+		WithData("threshold", threshold).
+		WithData("errors", errors).
+		WithData("requests", requests).
+		WithData("window", window.String())
+	GetEventBus().Publish(event)
+}
+
+// PublishUpstreamSchemaDrift publishes when the rolling rate of upstream responses
+// failing the schema checks exceeds the threshold
+func PublishUpstreamSchemaDrift(malformedRate, threshold float64, malformed, requests int64, window time.Duration) {
+	event := NewEvent(EventUpstreamSchemaDrift, SeverityCritical,
+		"Upstream responses no longer match the expected schema").
+		WithData("malformed_rate", malformedRate).
+		WithData("threshold", threshold).
+		WithData("malformed", malformed).
+		WithData("requests", requests).
+		WithData("window", window.String())
+	GetEventBus().Publish(event)
+}
+
+// PublishCacheCorruption publishes when corrupt cache entries found within the window
+// reach the threshold
+func PublishCacheCorruption(count, threshold int, window time.Duration, lastKey, lastReason string) {
+	event := NewEvent(EventCacheCorruption, SeverityWarning,
+		"Corrupt cache entries exceeded threshold").
+		WithData("count", count).
+		WithData("threshold", threshold).
+		WithData("window", window.String()).
+		WithData("last_key", lastKey).
+		WithData("last_reason", lastReason)
+	GetEventBus().Publish(event)
+}
+
+// PublishTruncatedLyrics publishes when fetched lyrics look truncated and are
+// served without being cached
+func PublishTruncatedLyrics(trackID, query, reason string) {
+	event := NewEvent(EventTruncatedLyrics, SeverityWarning,
+		"Fetched lyrics look truncated").
+		WithData("track_id", trackID).
+		WithData("query", query).
+		WithData("reason", reason)
+	GetEventBus().Publish(event)
+}
+
+// PublishLyricsChanged publishes when a re-fetch stored different lyrics for a
+// track that was already cached
+func PublishLyricsChanged(trackID, query string, revision int, summary string) {
+	event := NewEvent(EventLyricsChanged, SeverityInfo,
+		"Lyrics changed upstream").
+		WithData("track_id", trackID).
+		WithData("query", query).
+		WithData("revision", revision).
+		WithData("summary", summary)
+	GetEventBus().Publish(event)
+}
+
+// PublishDiskSpaceLow publishes when a data directory's volume drops below the
+// free space threshold
+func PublishDiskSpaceLow(role, path string, freeMB, thresholdMB uint64) {
+	event := NewEvent(EventDiskSpaceLow, SeverityWarning,
+		"Free disk space is low").
+		WithData("role", role).
+		WithData("path", path).
+		WithData("free_mb", freeMB).
+		WithData("threshold_mb", thresholdMB)
+	GetEventBus().Publish(event)
+}
+
+// PublishCacheReadOnly publishes when the cache stops writing because its volume
+// is almost full
+func PublishCacheReadOnly(freeMB, thresholdMB uint64) {
+	event := NewEvent(EventCacheReadOnly, SeverityCritical,
+		"Cache switched to read-only mode").
+		WithData("free_mb", freeMB).
+		WithData("threshold_mb", thresholdMB)
+	GetEventBus().Publish(event)
+}
+
+// PublishCacheWritable publishes when the cache leaves read-only mode
+func PublishCacheWritable(freeMB uint64) {
+	event := NewEvent(EventCacheWritable, SeverityInfo,
+		"Cache left read-only mode").
+		WithData("free_mb", freeMB)
+	GetEventBus().Publish(event)
+}
+
+// PublishConfigReloaded publishes when a remote config document changed settings
+func PublishConfigReloaded(source string, keys []string) {
+	event := NewEvent(EventConfigReloaded, SeverityInfo,
+		"Configuration reloaded").
+		WithData("source", source).
+		WithData("keys", keys)
+	GetEventBus().Publish(event)
+}
+
+// PublishDatabaseLocked publishes when opening a database timed out on its file
+// lock, usually because another instance still has it open
+func PublishDatabaseLocked(path string, attempt, attempts int) {
+	event := NewEvent(EventDatabaseLocked, SeverityWarning,
+		"Database file is locked by another process").
+		WithData("path", path).
+		WithData("attempt", attempt).
+		WithData("attempts", attempts)
+	GetEventBus().Publish(event)
+}
+
+// PublishStatsDBRecovered publishes when a corrupt stats DB was moved aside at
+// startup and the server started with fresh stats
+func PublishStatsDBRecovered(path, movedTo string, cause error) {
+	event := NewEvent(EventStatsDBRecovered, SeverityCritical,
+		"Stats database was corrupt and has been reset").
+		WithData("path", path).
+		WithData("moved_to", movedTo).
+		WithData("error", cause.Error())
+	GetEventBus().Publish(event)
+}
+
 // PublishCacheBackupFailed publishes when cache backup fails
 func PublishCacheBackupFailed(err error) {
 	event := NewEvent(EventCacheBackupFailed, SeverityWarning,
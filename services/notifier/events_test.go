@@ -0,0 +1,141 @@
+package notifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordingSubscriber collects the event types it receives
+type recordingSubscriber struct {
+	name   string
+	mu     sync.Mutex
+	events []EventType
+}
+
+func (r *recordingSubscriber) Name() string { return r.name }
+
+func (r *recordingSubscriber) HandleEvent(event *Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event.Type)
+}
+
+func (r *recordingSubscriber) received() []EventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]EventType(nil), r.events...)
+}
+
+type panickingSubscriber struct{}
+
+func (panickingSubscriber) Name() string       { return "panicky" }
+func (panickingSubscriber) HandleEvent(*Event) { panic("boom") }
+
+func TestEventBus_RegisterFiltersAndUnsubscribes(t *testing.T) {
+	bus := NewEventBus()
+	all := &recordingSubscriber{name: "all"}
+	breakerOnly := &recordingSubscriber{name: "breaker"}
+
+	bus.Register(all)
+	unsubscribe := bus.Register(breakerOnly, EventCircuitBreakerOpen, EventCircuitBreakerRecovered)
+
+	bus.Publish(NewEvent(EventCircuitBreakerOpen, SeverityCritical, "open"))
+	bus.Publish(NewEvent(EventCacheCleared, SeverityInfo, "cleared"))
+	bus.Drain()
+
+	if got := all.received(); len(got) != 2 {
+		t.Errorf("Catch-all subscriber got %v, want 2 events", got)
+	}
+	if got := breakerOnly.received(); len(got) != 1 || got[0] != EventCircuitBreakerOpen {
+		t.Errorf("Filtered subscriber got %v, want [%s]", got, EventCircuitBreakerOpen)
+	}
+
+	unsubscribe()
+	bus.Publish(NewEvent(EventCircuitBreakerRecovered, SeverityInfo, "recovered"))
+	bus.Drain()
+	if got := breakerOnly.received(); len(got) != 1 {
+		t.Errorf("Unsubscribed subscriber still received events: %v", got)
+	}
+
+	counts := bus.PublishedCounts()
+	if counts[EventCircuitBreakerOpen] != 1 || counts[EventCacheCleared] != 1 || counts[EventCircuitBreakerRecovered] != 1 {
+		t.Errorf("Unexpected published counts: %v", counts)
+	}
+}
+
+func TestEventBus_RecoversFromSubscriberPanic(t *testing.T) {
+	bus := NewEventBus()
+	healthy := &recordingSubscriber{name: "healthy"}
+	bus.Register(panickingSubscriber{})
+	bus.Register(healthy)
+
+	bus.Publish(NewEvent(EventCacheCleared, SeverityInfo, "cleared"))
+	bus.Drain()
+
+	if len(healthy.received()) != 1 {
+		t.Error("Healthy subscriber should still receive the event")
+	}
+	for _, info := range bus.Subscriptions() {
+		if info.Name == "panicky" && (info.Panics != 1 || info.Delivered != 0) {
+			t.Errorf("Unexpected counters for panicking subscriber: %+v", info)
+		}
+		if info.Name == "healthy" && info.Delivered != 1 {
+			t.Errorf("Unexpected counters for healthy subscriber: %+v", info)
+		}
+	}
+}
+
+func TestEventBus_SubscribeHelpers(t *testing.T) {
+	bus := NewEventBus()
+	var mu sync.Mutex
+	var typed, all int
+	bus.Subscribe(EventServerStarted, func(*Event) { mu.Lock(); typed++; mu.Unlock() })
+	bus.SubscribeAll(func(*Event) { mu.Lock(); all++; mu.Unlock() })
+
+	bus.Publish(NewEvent(EventServerStarted, SeverityInfo, "started"))
+	bus.Publish(NewEvent(EventCacheCleared, SeverityInfo, "cleared"))
+	bus.Drain()
+
+	if typed != 1 || all != 2 {
+		t.Errorf("typed=%d all=%d, want 1 and 2", typed, all)
+	}
+}
+
+func TestWebhookSubscriber_SendsSignedJSON(t *testing.T) {
+	var gotBody []byte
+	var gotSignature, gotType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get("X-Signature-256")
+		gotType = r.Header.Get("X-Event-Type")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook := &WebhookSubscriber{URL: server.URL, Secret: "s3cret"}
+	event := NewEvent(EventCacheCleared, SeverityInfo, "cleared").WithData("backup_path", "/tmp/b.db")
+	if err := webhook.send(event); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	var decoded Event
+	if err := json.Unmarshal(gotBody, &decoded); err != nil {
+		t.Fatalf("Body is not an event: %v", err)
+	}
+	if decoded.Type != EventCacheCleared || decoded.Data["backup_path"] != "/tmp/b.db" || gotType != string(EventCacheCleared) {
+		t.Errorf("Unexpected payload: %+v (type header %q)", decoded, gotType)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(gotBody)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); gotSignature != want {
+		t.Errorf("Signature = %q, want %q", gotSignature, want)
+	}
+}
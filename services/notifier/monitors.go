@@ -0,0 +1,131 @@
+package notifier
+
+import (
+	"lyrics-api-go/logcolors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RateCounters are cumulative counters sampled by the RateMonitor. The monitor
+// works on deltas between samples, so the source can be plain process-lifetime totals.
+type RateCounters struct {
+	CacheHits        int64
+	CacheMisses      int64
+	UpstreamRequests int64
+	UpstreamErrors   int64
+	Malformed        int64 // Upstream 200 responses that failed the schema checks
+}
+
+// RateMonitorConfig configures the rolling-window monitors
+type RateMonitorConfig struct {
+	Window               time.Duration       // Rolling window the rates are computed over
+	Interval             time.Duration       // How often counters are sampled
+	MinCacheHitRate      float64             // Percent; alert when the hit rate drops below (0 = off)
+	MaxUpstreamErrorRate float64             // Percent; alert when the error rate rises above (0 = off)
+	MaxMalformedRate     float64             // Percent of upstream requests; alert on schema drift above (0 = off)
+	MinSamples           int64               // Ignore a window with fewer lookups/requests than this
+	Read                 func() RateCounters // Current cumulative counters
+}
+
+type rateSample struct {
+	at       time.Time
+	counters RateCounters
+}
+
+// RateMonitor samples counters on an interval and publishes an alert when the
+// cache hit rate, upstream error rate or malformed response rate over the window
+// crosses its threshold.
+// Each breach is published once; the monitor re-arms when the rate recovers.
+type RateMonitor struct {
+	cfg     RateMonitorConfig
+	samples []rateSample
+	breach  map[EventType]bool
+	mu      sync.Mutex
+}
+
+// NewRateMonitor creates a monitor; call Start to begin sampling
+func NewRateMonitor(cfg RateMonitorConfig) *RateMonitor {
+	if cfg.Window <= 0 {
+		cfg.Window = 15 * time.Minute
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	return &RateMonitor{cfg: cfg, breach: make(map[EventType]bool)}
+}
+
+// Start samples counters in the background
+func (m *RateMonitor) Start() {
+	log.Infof("%s Rate monitor started (window: %v, min hit rate: %.0f%%, max upstream error rate: %.0f%%, max malformed rate: %.0f%%)",
+		logcolors.LogNotifier, m.cfg.Window, m.cfg.MinCacheHitRate, m.cfg.MaxUpstreamErrorRate, m.cfg.MaxMalformedRate)
+	go func() {
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		m.check(time.Now())
+		for now := range ticker.C {
+			m.check(now)
+		}
+	}()
+}
+
+// check records a sample and evaluates both monitors over the window. Nothing is
+// evaluated until the samples span a full window, so a cold start doesn't alert.
+func (m *RateMonitor) check(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples = append(m.samples, rateSample{at: now, counters: m.cfg.Read()})
+
+	// Keep the newest sample at or before the window start as the baseline
+	cutoff := now.Add(-m.cfg.Window)
+	drop := 0
+	for drop+1 < len(m.samples) && !m.samples[drop+1].at.After(cutoff) {
+		drop++
+	}
+	m.samples = m.samples[drop:]
+
+	oldest, newest := m.samples[0], m.samples[len(m.samples)-1]
+	if newest.at.Sub(oldest.at) < m.cfg.Window {
+		return
+	}
+
+	hits := newest.counters.CacheHits - oldest.counters.CacheHits
+	lookups := hits + newest.counters.CacheMisses - oldest.counters.CacheMisses
+	if m.cfg.MinCacheHitRate > 0 && lookups >= max(m.cfg.MinSamples, 1) {
+		hitRate := float64(hits) / float64(lookups) * 100
+		m.evaluate(EventCacheHitRateLow, hitRate < m.cfg.MinCacheHitRate, func() {
+			PublishCacheHitRateLow(hitRate, m.cfg.MinCacheHitRate, hits, lookups, m.cfg.Window)
+		})
+	}
+
+	requests := newest.counters.UpstreamRequests - oldest.counters.UpstreamRequests
+	errors := newest.counters.UpstreamErrors - oldest.counters.UpstreamErrors
+	if m.cfg.MaxUpstreamErrorRate > 0 && requests >= max(m.cfg.MinSamples, 1) {
+		errorRate := float64(errors) / float64(requests) * 100
+		m.evaluate(EventUpstreamErrorRateHigh, errorRate > m.cfg.MaxUpstreamErrorRate, func() {
+			PublishUpstreamErrorRateHigh(errorRate, m.cfg.MaxUpstreamErrorRate, errors, requests, m.cfg.Window)
+		})
+	}
+
+	malformed := newest.counters.Malformed - oldest.counters.Malformed
+	if m.cfg.MaxMalformedRate > 0 && requests >= max(m.cfg.MinSamples, 1) {
+		malformedRate := float64(malformed) / float64(requests) * 100
+		m.evaluate(EventUpstreamSchemaDrift, malformedRate > m.cfg.MaxMalformedRate, func() {
+			PublishUpstreamSchemaDrift(malformedRate, m.cfg.MaxMalformedRate, malformed, requests, m.cfg.Window)
+		})
+	}
+}
+
+// evaluate publishes on the transition into breach and re-arms on recovery.
+// REQUIRES: caller holds m.mu.
+func (m *RateMonitor) evaluate(eventType EventType, breached bool, publish func()) {
+	if breached && !m.breach[eventType] {
+		publish()
+	}
+	if breached != m.breach[eventType] {
+		log.Infof("%s Rate monitor %s: breached=%v", logcolors.LogNotifier, eventType, breached)
+	}
+	m.breach[eventType] = breached
+}
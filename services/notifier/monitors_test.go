@@ -0,0 +1,136 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateMonitor_AlertsOnceAndRearms(t *testing.T) {
+	bus := GetEventBus()
+	rec := &recordingSubscriber{name: "rate"}
+	unsubscribe := bus.Register(rec, EventCacheHitRateLow, EventUpstreamErrorRateHigh)
+	defer unsubscribe()
+
+	var counters RateCounters
+	m := NewRateMonitor(RateMonitorConfig{
+		Window:               15 * time.Minute,
+		Interval:             time.Minute,
+		MinCacheHitRate:      50,
+		MaxUpstreamErrorRate: 20,
+		MinSamples:           10,
+		Read:                 func() RateCounters { return counters },
+	})
+
+	start := time.Now()
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	// Bad traffic before a full window has been observed: no alert
+	m.check(at(0))
+	counters = RateCounters{CacheHits: 10, CacheMisses: 90, UpstreamRequests: 100, UpstreamErrors: 50}
+	m.check(at(10))
+	bus.Drain()
+	if got := rec.received(); len(got) != 0 {
+		t.Fatalf("expected no alerts before a full window, got %v", got)
+	}
+
+	// Full window of bad traffic: one alert per monitor
+	m.check(at(15))
+	counters.CacheMisses += 10
+	m.check(at(16))
+	bus.Drain()
+	if got := rec.received(); len(got) != 2 {
+		t.Fatalf("expected one alert per monitor, got %v", got)
+	}
+
+	// Recovery: the window slides past the bad traffic
+	counters.CacheHits += 1000
+	counters.UpstreamRequests += 1000
+	m.check(at(31))
+	bus.Drain()
+	if m.breach[EventCacheHitRateLow] || m.breach[EventUpstreamErrorRateHigh] {
+		t.Fatal("expected both monitors to re-arm after recovery")
+	}
+
+	// Breaching again alerts again
+	counters.CacheMisses += 5000
+	m.check(at(47))
+	bus.Drain()
+	if got := rec.received(); len(got) != 3 || got[2] != EventCacheHitRateLow {
+		t.Fatalf("expected a second hit rate alert after re-arming, got %v", got)
+	}
+}
+
+func TestRateMonitor_SchemaDrift(t *testing.T) {
+	bus := GetEventBus()
+	rec := &recordingSubscriber{name: "drift"}
+	unsubscribe := bus.Register(rec, EventUpstreamSchemaDrift, EventUpstreamErrorRateHigh)
+	defer unsubscribe()
+
+	var counters RateCounters
+	m := NewRateMonitor(RateMonitorConfig{
+		Window:               15 * time.Minute,
+		MaxUpstreamErrorRate: 20,
+		MaxMalformedRate:     5,
+		MinSamples:           10,
+		Read:                 func() RateCounters { return counters },
+	})
+
+	// Malformed 200s aren't upstream errors, so only the drift monitor fires
+	start := time.Now()
+	m.check(start)
+	counters = RateCounters{UpstreamRequests: 100, Malformed: 30}
+	m.check(start.Add(15 * time.Minute))
+	bus.Drain()
+	if got := rec.received(); len(got) != 1 || got[0] != EventUpstreamSchemaDrift {
+		t.Fatalf("expected one schema drift alert, got %v", got)
+	}
+
+	counters.UpstreamRequests += 1000
+	m.check(start.Add(31 * time.Minute))
+	if m.breach[EventUpstreamSchemaDrift] {
+		t.Error("expected the drift monitor to re-arm after recovery")
+	}
+}
+
+func TestRateMonitor_IgnoresLowVolume(t *testing.T) {
+	var counters RateCounters
+	m := NewRateMonitor(RateMonitorConfig{
+		Window:          15 * time.Minute,
+		MinCacheHitRate: 50,
+		MinSamples:      50,
+		Read:            func() RateCounters { return counters },
+	})
+
+	start := time.Now()
+	m.check(start)
+	counters = RateCounters{CacheMisses: 20}
+	m.check(start.Add(15 * time.Minute))
+	if m.breach[EventCacheHitRateLow] {
+		t.Error("expected a window below MinSamples not to be judged")
+	}
+}
+
+func TestAlertHandler_FormatsRateAlerts(t *testing.T) {
+	h := NewAlertHandler(AlertConfig{})
+
+	subject, message := h.formatAlert(NewEvent(EventCacheHitRateLow, SeverityWarning, "").
+		WithData("hit_rate", 12.5).
+		WithData("threshold", 50.0).
+		WithData("hits", int64(5)).
+		WithData("lookups", int64(40)).
+		WithData("window", "15m0s"))
+	if !strings.Contains(subject, "Cache Hit Rate Low") || !strings.Contains(message, "12.5%") {
+		t.Errorf("unexpected hit rate alert: %q / %q", subject, message)
+	}
+
+	subject, message = h.formatAlert(NewEvent(EventUpstreamErrorRateHigh, SeverityWarning, "").
+		WithData("error_rate", 35.0).
+		WithData("threshold", 20.0).
+		WithData("errors", int64(35)).
+		WithData("requests", int64(100)).
+		WithData("window", "15m0s"))
+	if !strings.Contains(subject, "Upstream Error Rate High") || !strings.Contains(message, "35 of 100") {
+		t.Errorf("unexpected error rate alert: %q / %q", subject, message)
+	}
+}
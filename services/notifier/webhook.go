@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/redact"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WebhookSubscriber POSTs every event it receives to a URL as JSON. Unlike the
+// alert handler there is no cooldown: the receiver gets the raw event stream.
+type WebhookSubscriber struct {
+	URL    string
+	Secret string // Optional: signs the body as hex HMAC-SHA256 in X-Signature-256
+	Client *http.Client
+}
+
+// Name identifies the webhook on the event bus
+func (w *WebhookSubscriber) Name() string {
+	return "webhook"
+}
+
+// HandleEvent sends the event, logging (not retrying) failures
+func (w *WebhookSubscriber) HandleEvent(event *Event) {
+	if err := w.send(event); err != nil {
+		log.Warnf("%s Webhook delivery of %s failed: %v", logcolors.LogNotifier, event.Type, err)
+	}
+}
+
+func (w *WebhookSubscriber) send(event *Event) error {
+	// Error strings in the message/data can echo upstream bodies; mask secrets first
+	redacted := *event
+	redacted.Message = redact.String(event.Message)
+	redacted.Data = redact.Value(event.Data).(map[string]interface{})
+	body, err := json.Marshal(&redacted)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", string(event.Type))
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"fmt"
+	"io"
+	"lyrics-api-go/config"
+	"lyrics-api-go/stats"
+)
+
+// Upstream payload kinds, each with its own size cap
+const (
+	PayloadLyrics = "lyrics" // Lyrics responses (TTML, LRC)
+	PayloadSearch = "search" // Search results
+	PayloadOther  = "other"  // Everything else (token source, account info)
+)
+
+// maxErrorBodyBytes caps bodies of non-200 responses, which are only read to be
+// quoted in an error message
+const maxErrorBodyBytes = 4096
+
+// PayloadTooLargeError is returned when an upstream body exceeds its cap
+type PayloadTooLargeError struct {
+	Kind  string
+	Limit int64
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("upstream %s payload too large (limit %d bytes)", e.Kind, e.Limit)
+}
+
+// PayloadLimit returns the configured size cap for a payload kind
+func PayloadLimit(kind string) int64 {
+	conf := config.Get()
+	switch kind {
+	case PayloadLyrics:
+		return conf.Configuration.UpstreamMaxTTMLBytes
+	case PayloadSearch:
+		return conf.Configuration.UpstreamMaxSearchBytes
+	}
+	return conf.Configuration.UpstreamMaxBodyBytes
+}
+
+// ReadBody reads an upstream body up to the cap for its kind. A body over the cap
+// is rejected with *PayloadTooLargeError (counted in stats) rather than truncated,
+// since a truncated JSON or TTML document is useless anyway.
+func ReadBody(r io.Reader, kind string) ([]byte, error) {
+	limit := PayloadLimit(kind)
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		stats.Get().RecordPayloadTooLarge()
+		return nil, &PayloadTooLargeError{Kind: kind, Limit: limit}
+	}
+	return body, nil
+}
+
+// ReadErrorBody reads the start of a non-200 body for use in an error message
+func ReadErrorBody(r io.Reader) string {
+	body, _ := io.ReadAll(io.LimitReader(r, maxErrorBodyBytes))
+	return string(body)
+}
@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"errors"
+	"lyrics-api-go/stats"
+	"strings"
+	"testing"
+)
+
+func TestReadBody_WithinLimit(t *testing.T) {
+	body, err := ReadBody(strings.NewReader(`{"results":{}}`), PayloadSearch)
+	if err != nil {
+		t.Fatalf("ReadBody: %v", err)
+	}
+	if string(body) != `{"results":{}}` {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestReadBody_TooLarge(t *testing.T) {
+	limit := PayloadLimit(PayloadSearch)
+	before := stats.Get().PayloadTooLarge.Load()
+
+	_, err := ReadBody(strings.NewReader(strings.Repeat("x", int(limit)+1)), PayloadSearch)
+	var tooLarge *PayloadTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("expected *PayloadTooLargeError, got %v", err)
+	}
+	if tooLarge.Kind != PayloadSearch || tooLarge.Limit != limit {
+		t.Errorf("unexpected error fields: %+v", tooLarge)
+	}
+	if got := stats.Get().PayloadTooLarge.Load(); got != before+1 {
+		t.Errorf("expected payload_too_large to be counted, got %d -> %d", before, got)
+	}
+
+	// Exactly at the limit is fine
+	if _, err := ReadBody(strings.NewReader(strings.Repeat("x", int(limit))), PayloadSearch); err != nil {
+		t.Errorf("expected a body at the limit to be accepted, got %v", err)
+	}
+}
+
+func TestReadErrorBody_Truncates(t *testing.T) {
+	if got := ReadErrorBody(strings.NewReader(strings.Repeat("e", 10*maxErrorBodyBytes))); len(got) != maxErrorBodyBytes {
+		t.Errorf("expected error body truncated to %d bytes, got %d", maxErrorBodyBytes, len(got))
+	}
+}
@@ -3,8 +3,8 @@ package kugou
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/providers"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -69,7 +69,7 @@ func SearchLyrics(song, artist string, durationMs int, hash string) ([]LyricsCan
 		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadSearch)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
@@ -115,7 +115,7 @@ func DownloadLyrics(id, accessKey string) (string, error) {
 		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadLyrics)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
@@ -178,7 +178,7 @@ func SearchSongs(song, artist string, pageSize int) ([]SongInfo, error) {
 		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadSearch)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
@@ -294,6 +294,18 @@ func filterSongsByDuration(songs []SongInfo, durationMs, deltaMs int) []SongInfo
 	return filtered
 }
 
+// closestDurationDelta is the smallest |song − target| duration in ms, for
+// recording how far off the duration filter's rejections were
+func closestDurationDelta(songs []SongInfo, durationMs int) int {
+	closest := -1
+	for _, s := range songs {
+		if diff := abs(s.Duration*1000 - durationMs); closest < 0 || diff < closest {
+			closest = diff
+		}
+	}
+	return closest
+}
+
 // SelectBestSong selects the best song from search results based on matching criteria
 // Returns the best song and a normalized score (0.0 to 1.0)
 func SelectBestSong(songs []SongInfo, song, artist string, durationMs int) (*SongInfo, float64) {
@@ -6,6 +6,7 @@ import (
 	"lyrics-api-go/config"
 	"lyrics-api-go/logcolors"
 	"lyrics-api-go/services/providers"
+	"lyrics-api-go/stats"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -62,6 +63,7 @@ func (p *KugouProvider) FetchLyrics(ctx context.Context, song, artist, album str
 		deltaMs := conf.Configuration.DurationMatchDeltaMs
 		filteredSongs = filterSongsByDuration(songs, durationMs, deltaMs)
 		if len(filteredSongs) == 0 {
+			stats.Get().RecordDurationRejection(ProviderName, closestDurationDelta(songs, durationMs))
 			return nil, providers.NewProviderError(ProviderName,
 				fmt.Sprintf("no songs within %dms of duration %dms", deltaMs, durationMs), nil)
 		}
@@ -82,6 +84,9 @@ func (p *KugouProvider) FetchLyrics(ctx context.Context, song, artist, album str
 			fmt.Sprintf("best match score %.2f below threshold %.2f for: %s - %s",
 				songScore, minScore, song, artist), nil)
 	}
+	if durationMs > 0 {
+		stats.Get().RecordDurationMatch(ProviderName, abs(bestSong.Duration*1000-durationMs))
+	}
 
 	hashPreview := bestSong.Hash
 	if len(hashPreview) > 16 {
@@ -4,9 +4,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"lyrics-api-go/config"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/providers"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -81,7 +81,7 @@ func getOAuthAccessToken() (string, error) {
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadOther)
 	if err != nil {
 		return "", fmt.Errorf("error reading token response: %w", err)
 	}
@@ -133,7 +133,7 @@ func getValidAccessToken() (string, error) {
 		return "", fmt.Errorf("token request failed with status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadOther)
 	if err != nil {
 		return "", fmt.Errorf("error reading response: %w", err)
 	}
@@ -187,7 +187,7 @@ func SearchTrack(query string) (*TrackItem, error) {
 		return nil, fmt.Errorf("search request failed with status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadSearch)
 	if err != nil {
 		return nil, fmt.Errorf("error reading response: %w", err)
 	}
@@ -240,7 +240,7 @@ func FetchLyrics(trackID string) (*LyricsData, error) {
 		return nil, fmt.Errorf("lyrics request failed with status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadLyrics)
 	if err != nil {
 		return nil, fmt.Errorf("error reading response: %w", err)
 	}
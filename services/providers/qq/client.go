@@ -6,8 +6,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/providers"
 	"math/rand"
 	"net/http"
 	"strings"
@@ -163,7 +163,8 @@ func doAPIRequest(module, method string, param interface{}) (json.RawMessage, er
 		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
 
-	respBody, err := io.ReadAll(resp.Body)
+	// One endpoint serves both search and lyrics, so use the larger cap
+	respBody, err := providers.ReadBody(resp.Body, providers.PayloadLyrics)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
@@ -405,3 +406,15 @@ func filterSongsByDuration(songs []SongItem, durationMs, deltaMs int) []SongItem
 	}
 	return filtered
 }
+
+// closestDurationDelta is the smallest |song − target| duration in ms, for
+// recording how far off the duration filter's rejections were
+func closestDurationDelta(songs []SongItem, durationMs int) int {
+	closest := -1
+	for _, s := range songs {
+		if diff := abs(s.Interval*1000 - durationMs); closest < 0 || diff < closest {
+			closest = diff
+		}
+	}
+	return closest
+}
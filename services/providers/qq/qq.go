@@ -6,6 +6,7 @@ import (
 	"lyrics-api-go/config"
 	"lyrics-api-go/logcolors"
 	"lyrics-api-go/services/providers"
+	"lyrics-api-go/stats"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -58,6 +59,7 @@ func (p *QQProvider) FetchLyrics(ctx context.Context, song, artist, album string
 		deltaMs := conf.Configuration.DurationMatchDeltaMs
 		filteredSongs = filterSongsByDuration(songs, durationMs, deltaMs)
 		if len(filteredSongs) == 0 {
+			stats.Get().RecordDurationRejection(ProviderName, closestDurationDelta(songs, durationMs))
 			return nil, providers.NewProviderError(ProviderName,
 				fmt.Sprintf("no songs within %dms of duration %dms", deltaMs, durationMs), nil)
 		}
@@ -77,6 +79,9 @@ func (p *QQProvider) FetchLyrics(ctx context.Context, song, artist, album string
 			fmt.Sprintf("best match score %.2f below threshold %.2f for: %s - %s",
 				songScore, minScore, song, artist), nil)
 	}
+	if durationMs > 0 {
+		stats.Get().RecordDurationMatch(ProviderName, abs(bestSong.Interval*1000-durationMs))
+	}
 
 	log.Infof("%s [QQ] Found song: %s - %s (score: %.2f, mid: %s)",
 		logcolors.LogMatch, bestSong.Title, bestSong.SingerNames(), songScore, bestSong.MID)
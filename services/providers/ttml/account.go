@@ -5,7 +5,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -15,9 +14,11 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"lyrics-api-go/clock"
 	"lyrics-api-go/config"
 	"lyrics-api-go/logcolors"
 	"lyrics-api-go/services/notifier"
+	"lyrics-api-go/services/providers"
 )
 
 const (
@@ -29,18 +30,45 @@ const (
 )
 
 var (
-	accountManager   *AccountManager
-	quarantineMutex  sync.RWMutex            // Protects quarantineTime map
-	disabledAccounts = make(map[string]bool) // Permanently disabled accounts (stale MUT)
-	disabledMutex    sync.RWMutex            // Protects disabledAccounts map
+	accountPool      atomic.Pointer[AccountManager] // The active pool, see getAccountManager
+	quarantineMutex  sync.RWMutex                   // Protects quarantineTime map
+	disabledAccounts = make(map[string]bool)        // Permanently disabled accounts (stale MUT)
+	disabledMutex    sync.RWMutex                   // Protects disabledAccounts map
 
 	// Storefront cache: maps MUT hash -> storefront code
 	storefrontCache     = make(map[string]string)
 	storefrontCachePath string
 	storefrontMutex     sync.RWMutex
+	storefrontSaveMutex sync.Mutex // Serializes cache file writes from the init workers
+
+	// storefrontOverrides holds storefronts fetched in the background after startup,
+	// keyed by account name. Accounts are copied by value into every request, so the
+	// workers can't update account.Storefront safely; readers use accountStorefront.
+	// Protected by storefrontMutex.
+	storefrontOverrides = make(map[string]string)
+
+	// clk drives quarantine expiry, token refresh and the circuit breaker; tests swap in a fake
+	clk clock.Clock = clock.Real{}
 )
 
+// getAccountManager returns the active account pool, building it from the config on
+// first use. ReloadAccounts swaps in a new pool while requests may still hold the
+// old one, which stays usable: a pool's accounts are not modified once it is active.
+func getAccountManager() *AccountManager {
+	if m := accountPool.Load(); m != nil {
+		return m
+	}
+	accountPool.CompareAndSwap(nil, newAccountManager())
+	return accountPool.Load()
+}
+
+// initAccountManager makes a pool built from the current config the active one
 func initAccountManager() {
+	accountPool.Store(newAccountManager())
+}
+
+// newAccountManager builds an account pool from the current config
+func newAccountManager() *AccountManager {
 	conf := config.Get()
 	configAccounts, err := conf.GetTTMLAccounts()
 	if err != nil {
@@ -49,12 +77,11 @@ func initAccountManager() {
 
 	if len(configAccounts) == 0 {
 		log.Warn("No TTML accounts configured")
-		accountManager = &AccountManager{
+		return &AccountManager{
 			accounts:       []MusicAccount{},
 			currentIndex:   0,
 			quarantineTime: make(map[int]int64),
 		}
-		return
 	}
 
 	storefront := conf.Configuration.TTMLStorefront
@@ -71,13 +98,12 @@ func initAccountManager() {
 		}
 	}
 
-	accountManager = &AccountManager{
+	log.Infof("Initialized %d TTML account(s) with round-robin load balancing", len(accounts))
+	return &AccountManager{
 		accounts:       accounts,
 		currentIndex:   0,
 		quarantineTime: make(map[int]int64),
 	}
-
-	log.Infof("Initialized %d TTML account(s) with round-robin load balancing", len(accounts))
 }
 
 // getNextAccount returns the next non-quarantined, non-disabled account in round-robin fashion (thread-safe)
@@ -87,10 +113,12 @@ func (m *AccountManager) getNextAccount() MusicAccount {
 		return MusicAccount{}
 	}
 
-	now := time.Now().Unix()
+	now := clk.Now().Unix()
 	numAccounts := len(m.accounts)
 
-	// Try to find a non-quarantined, non-disabled account
+	// Try to find a non-quarantined, non-disabled account. Accounts whose token is
+	// about to expire are only used if nothing else is available.
+	expiringIdx := -1
 	for i := 0; i < numAccounts; i++ {
 		idx := atomic.AddUint64(&m.currentIndex, 1) - 1
 		accountIdx := int(idx % uint64(numAccounts))
@@ -102,10 +130,22 @@ func (m *AccountManager) getNextAccount() MusicAccount {
 		}
 
 		// Skip quarantined accounts (rate limited - temporary)
-		if !m.isQuarantined(accountIdx, now) {
-			return m.accounts[accountIdx]
+		if m.isQuarantined(accountIdx, now) {
+			log.Debugf("%s Skipping %s (quarantined)", logcolors.LogQuarantine, logcolors.Account(m.accounts[accountIdx].NameID))
+			continue
+		}
+
+		if IsAccountExpiring(m.accounts[accountIdx].NameID) {
+			if expiringIdx == -1 {
+				expiringIdx = accountIdx
+			}
+			continue
 		}
-		log.Debugf("%s Skipping %s (quarantined)", logcolors.LogQuarantine, logcolors.Account(m.accounts[accountIdx].NameID))
+		return m.accounts[accountIdx]
+	}
+	if expiringIdx != -1 {
+		log.Debugf("%s Using %s (token expiring, no other account available)", logcolors.LogQuarantine, logcolors.Account(m.accounts[expiringIdx].NameID))
+		return m.accounts[expiringIdx]
 	}
 
 	// All accounts quarantined or disabled - find the one with shortest remaining time
@@ -149,6 +189,63 @@ func (m *AccountManager) getNextAccount() MusicAccount {
 	return m.accounts[shortestIdx]
 }
 
+// getAccountForStorefront returns the next available account whose storefront is
+// storefront, so a regional catalog is searched where the client is. Falls back to
+// getNextAccount when storefront is empty or no such account is available.
+func (m *AccountManager) getAccountForStorefront(storefront string) MusicAccount {
+	if storefront == "" || len(m.accounts) == 0 {
+		return m.getNextAccount()
+	}
+
+	now := clk.Now().Unix()
+	numAccounts := len(m.accounts)
+	// Start where round-robin is, so requests spread over the matching accounts
+	start := atomic.AddUint64(&m.currentIndex, 1) - 1
+	for i := 0; i < numAccounts; i++ {
+		accountIdx := int((start + uint64(i)) % uint64(numAccounts))
+		account := m.accounts[accountIdx]
+		if accountStorefront(account) != storefront {
+			continue
+		}
+		if m.IsAccountDisabled(account.NameID) || m.isQuarantined(accountIdx, now) || IsAccountExpiring(account.NameID) {
+			continue
+		}
+		return account
+	}
+	return m.getNextAccount()
+}
+
+// healthiestAccount picks the account for a circuit breaker probe: not disabled,
+// not quarantined, not expiring, and the longest since its last 429 (never rate
+// limited wins). Falls back to round-robin selection when no account is currently available.
+func (m *AccountManager) healthiestAccount() MusicAccount {
+	now := clk.Now().Unix()
+	bestIdx := -1
+	var bestLast int64
+	bestExpiring := false
+
+	for i, acc := range m.accounts {
+		if m.IsAccountDisabled(acc.NameID) || m.isQuarantined(i, now) {
+			continue
+		}
+		expiring := IsAccountExpiring(acc.NameID)
+		quarantineMutex.RLock()
+		last := m.lastRateLimited[i]
+		quarantineMutex.RUnlock()
+		better := bestIdx == -1 ||
+			(bestExpiring && !expiring) ||
+			(bestExpiring == expiring && last < bestLast)
+		if better {
+			bestIdx, bestLast, bestExpiring = i, last, expiring
+		}
+	}
+
+	if bestIdx == -1 {
+		return m.getNextAccount()
+	}
+	return m.accounts[bestIdx]
+}
+
 // isQuarantined checks if an account is currently quarantined
 func (m *AccountManager) isQuarantined(accountIdx int, now int64) bool {
 	quarantineMutex.RLock()
@@ -178,10 +275,15 @@ func (m *AccountManager) quarantineAccount(account MusicAccount) {
 	}
 
 	quarantineMutex.Lock()
-	m.quarantineTime[accountIdx] = time.Now().Add(QuarantineDuration).Unix()
+	m.quarantineTime[accountIdx] = clk.Now().Add(QuarantineDuration).Unix()
+	if m.lastRateLimited == nil {
+		m.lastRateLimited = make(map[int]int64)
+	}
+	m.lastRateLimited[accountIdx] = clk.Now().Unix()
 	quarantineMutex.Unlock()
 
 	log.Warnf("%s Account %s quarantined for %v due to rate limit", logcolors.LogQuarantine, logcolors.Account(account.NameID), QuarantineDuration)
+	recomputeCircuitBreakerThreshold()
 
 	// Check quarantine thresholds and emit events
 	m.checkQuarantineThresholds()
@@ -211,7 +313,7 @@ func (m *AccountManager) checkQuarantineThresholds() {
 	// One account away from all quarantined
 	if quarantined == total-1 {
 		// Find the remaining healthy account
-		now := time.Now().Unix()
+		now := clk.Now().Unix()
 		for i, acc := range m.accounts {
 			if !m.isQuarantined(i, now) {
 				notifier.PublishOneAwayFromQuarantine(acc.NameID, status, outOfServiceNames)
@@ -259,16 +361,21 @@ func (m *AccountManager) clearQuarantine(account MusicAccount) {
 	}
 
 	quarantineMutex.Lock()
-	if _, exists := m.quarantineTime[accountIdx]; exists {
+	_, exists := m.quarantineTime[accountIdx]
+	if exists {
 		delete(m.quarantineTime, accountIdx)
 		log.Infof("%s Account %s quarantine cleared (successful request)", logcolors.LogQuarantine, logcolors.Account(account.NameID))
 	}
 	quarantineMutex.Unlock()
+
+	if exists {
+		recomputeCircuitBreakerThreshold()
+	}
 }
 
 // getQuarantineStatus returns a map of account names to remaining quarantine seconds
 func (m *AccountManager) getQuarantineStatus() map[string]int64 {
-	now := time.Now().Unix()
+	now := clk.Now().Unix()
 	status := make(map[string]int64)
 
 	quarantineMutex.RLock()
@@ -294,7 +401,7 @@ func (m *AccountManager) accountCount() int {
 
 // availableAccountCount returns the number of non-quarantined, non-disabled accounts
 func (m *AccountManager) availableAccountCount() int {
-	now := time.Now().Unix()
+	now := clk.Now().Unix()
 	count := 0
 	for i, acc := range m.accounts {
 		// Skip disabled accounts (stale MUT)
@@ -310,7 +417,7 @@ func (m *AccountManager) availableAccountCount() int {
 
 // IsAccountQuarantinedByName checks if an account is quarantined by its name ID
 func (m *AccountManager) IsAccountQuarantinedByName(nameID string) bool {
-	now := time.Now().Unix()
+	now := clk.Now().Unix()
 	for i, acc := range m.accounts {
 		if acc.NameID == nameID {
 			return m.isQuarantined(i, now)
@@ -329,16 +436,36 @@ func (m *AccountManager) IsAccountDisabled(nameID string) bool {
 // DisableAccount permanently disables an account (called when MUT is detected as stale via 404 on canary)
 func (m *AccountManager) DisableAccount(account MusicAccount) {
 	disabledMutex.Lock()
+	wasDisabled := disabledAccounts[account.NameID]
 	disabledAccounts[account.NameID] = true
 	disabledMutex.Unlock()
 
 	log.Errorf("%s Account %s PERMANENTLY DISABLED (stale MUT - 404 on canary)",
 		logcolors.LogQuarantine, logcolors.Account(account.NameID))
+	recomputeCircuitBreakerThreshold()
+	if !wasDisabled {
+		notifier.PublishAccountDisabled(account.NameID, "stale MUT (404 on canary)")
+	}
 
 	// Check if this triggers circuit breaker (all accounts unavailable)
 	m.checkQuarantineThresholds()
 }
 
+// EnableAccount re-enables a previously disabled account (canary succeeded again)
+func (m *AccountManager) EnableAccount(account MusicAccount) {
+	disabledMutex.Lock()
+	wasDisabled := disabledAccounts[account.NameID]
+	delete(disabledAccounts, account.NameID)
+	disabledMutex.Unlock()
+
+	if !wasDisabled {
+		return
+	}
+	log.Infof("%s Account %s re-enabled (canary succeeded)", logcolors.LogQuarantine, logcolors.Account(account.NameID))
+	recomputeCircuitBreakerThreshold()
+	notifier.PublishAccountEnabled(account.NameID, "canary succeeded")
+}
+
 // =============================================================================
 // STOREFRONT CACHE
 // =============================================================================
@@ -381,6 +508,9 @@ func loadStorefrontCache() {
 
 // saveStorefrontCache persists the storefront cache to disk
 func saveStorefrontCache() {
+	storefrontSaveMutex.Lock()
+	defer storefrontSaveMutex.Unlock()
+
 	storefrontMutex.RLock()
 	data, err := json.MarshalIndent(storefrontCache, "", "  ")
 	storefrontMutex.RUnlock()
@@ -419,6 +549,21 @@ func setCachedStorefront(mut, storefront string) {
 	storefrontCache[hashMUT(mut)] = storefront
 }
 
+// accountStorefront returns the storefront to use for an account: a background-fetched
+// value if one has arrived, else the account's own, else "us"
+func accountStorefront(account MusicAccount) string {
+	storefrontMutex.RLock()
+	storefront, ok := storefrontOverrides[account.NameID]
+	storefrontMutex.RUnlock()
+	if !ok {
+		storefront = account.Storefront
+	}
+	if storefront == "" {
+		storefront = "us"
+	}
+	return storefront
+}
+
 // =============================================================================
 // STOREFRONT FETCHING
 // =============================================================================
@@ -450,7 +595,7 @@ func fetchAccountStorefront(account MusicAccount) (string, error) {
 	req.Header.Set("Origin", "https://music.apple.com")
 	req.Header.Set("Referer", "https://music.apple.com/")
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := &http.Client{Timeout: time.Duration(conf.Configuration.StorefrontFetchTimeoutSecs) * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
@@ -458,11 +603,11 @@ func fetchAccountStorefront(account MusicAccount) (string, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		body := providers.ReadErrorBody(resp.Body)
+		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, body)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadOther)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
@@ -480,19 +625,21 @@ func fetchAccountStorefront(account MusicAccount) (string, error) {
 	return storefront, nil
 }
 
-// InitializeAccountStorefronts fetches and sets the storefront for each account.
-// Uses persistent cache to avoid refetching storefronts when MUT hasn't changed.
-// This should be called after the bearer token is available.
-// On failure, accounts retain their default storefront from config.
-func InitializeAccountStorefronts() {
-	// Ensure account manager is initialized
-	if accountManager == nil {
-		initAccountManager()
-	}
+// InitializeAccountStorefronts sets the storefront for each account. Cached storefronts
+// (keyed by MUT hash, so a changed MUT is refetched) are applied before returning;
+// the rest are fetched in the background by a bounded worker pool, each applied and
+// persisted as it arrives, so startup doesn't wait on the account API. Until then an
+// account keeps its default storefront from config. The returned channel is closed
+// once every fetch has finished.
+func InitializeAccountStorefronts() <-chan struct{} {
+	done := make(chan struct{})
 
-	if accountManager == nil || len(accountManager.accounts) == 0 {
+	// Ensure account manager is initialized
+	accountManager := getAccountManager()
+	if len(accountManager.accounts) == 0 {
 		log.Warnf("%s No accounts to initialize storefronts for", logcolors.LogAccountInit)
-		return
+		close(done)
+		return done
 	}
 
 	// Load cached storefronts from disk
@@ -500,9 +647,8 @@ func InitializeAccountStorefronts() {
 
 	log.Infof("%s Initializing storefronts for %d account(s)...", logcolors.LogAccountInit, len(accountManager.accounts))
 
-	cacheUpdated := false
-	for i := range accountManager.accounts {
-		account := &accountManager.accounts[i]
+	var toFetch []MusicAccount
+	for _, account := range accountManager.accounts {
 
 		// Skip accounts with empty MUT (out-of-service)
 		if account.MediaUserToken == "" {
@@ -516,7 +662,10 @@ func InitializeAccountStorefronts() {
 			if cachedStorefront != account.Storefront {
 				log.Infof("%s %s storefront: %s → %s (from cache)",
 					logcolors.LogAccountInit, logcolors.Account(account.NameID), account.Storefront, cachedStorefront)
-				account.Storefront = cachedStorefront
+				// The pool may already be serving requests (see ReloadAccounts)
+				storefrontMutex.Lock()
+				storefrontOverrides[account.NameID] = cachedStorefront
+				storefrontMutex.Unlock()
 			} else {
 				log.Infof("%s %s storefront: %s (cached)",
 					logcolors.LogAccountInit, logcolors.Account(account.NameID), cachedStorefront)
@@ -524,33 +673,84 @@ func InitializeAccountStorefronts() {
 			continue
 		}
 
-		// Not in cache - fetch from API
-		storefront, err := fetchAccountStorefront(*account)
-		if err != nil {
-			log.Warnf("%s Failed to fetch storefront for %s, keeping default %q: %v",
-				logcolors.LogAccountInit, logcolors.Account(account.NameID), account.Storefront, err)
-			continue
-		}
+		toFetch = append(toFetch, account)
+	}
 
-		// Update account and cache
-		if storefront != account.Storefront {
-			log.Infof("%s %s storefront: %s → %s (fetched)",
-				logcolors.LogAccountInit, logcolors.Account(account.NameID), account.Storefront, storefront)
-			account.Storefront = storefront
-		} else {
-			log.Infof("%s %s storefront: %s (fetched)",
-				logcolors.LogAccountInit, logcolors.Account(account.NameID), storefront)
+	if len(toFetch) == 0 {
+		log.Infof("%s Storefront initialization complete", logcolors.LogAccountInit)
+		close(done)
+		return done
+	}
+
+	workers := config.Get().Configuration.StorefrontInitWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(toFetch) {
+		workers = len(toFetch)
+	}
+	log.Infof("%s Fetching %d storefront(s) in the background (%d workers)",
+		logcolors.LogAccountInit, len(toFetch), workers)
+
+	jobs := make(chan MusicAccount)
+	var wg sync.WaitGroup
+	var fetched atomic.Int64
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for account := range jobs {
+				if fetchAndApplyStorefront(account) {
+					fetched.Add(1)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for _, account := range toFetch {
+			jobs <- account
 		}
+		close(jobs)
+		wg.Wait()
+		log.Infof("%s Storefront initialization complete (%d/%d fetched)",
+			logcolors.LogAccountInit, fetched.Load(), len(toFetch))
+		close(done)
+	}()
+
+	return done
+}
 
-		// Cache the fetched storefront
-		setCachedStorefront(account.MediaUserToken, storefront)
-		cacheUpdated = true
+// fetchAndApplyStorefront fetches one account's storefront, applies it and writes the
+// cache file immediately so a restart mid-initialization keeps what was fetched.
+// On failure the account keeps its default storefront.
+func fetchAndApplyStorefront(account MusicAccount) bool {
+	storefront, err := fetchStorefront(account)
+	if err != nil {
+		log.Warnf("%s Failed to fetch storefront for %s, keeping default %q: %v",
+			logcolors.LogAccountInit, logcolors.Account(account.NameID), account.Storefront, err)
+		return false
 	}
 
-	// Save cache if updated
-	if cacheUpdated {
-		saveStorefrontCache()
+	if storefront != account.Storefront {
+		log.Infof("%s %s storefront: %s → %s (fetched)",
+			logcolors.LogAccountInit, logcolors.Account(account.NameID), account.Storefront, storefront)
+	} else {
+		log.Infof("%s %s storefront: %s (fetched)",
+			logcolors.LogAccountInit, logcolors.Account(account.NameID), storefront)
 	}
 
-	log.Infof("%s Storefront initialization complete", logcolors.LogAccountInit)
+	applyStorefront(account, storefront)
+	return true
+}
+
+// applyStorefront makes a fetched storefront the one used for an account and persists
+// it to the storefront cache
+func applyStorefront(account MusicAccount, storefront string) {
+	storefrontMutex.Lock()
+	storefrontOverrides[account.NameID] = storefront
+	storefrontMutex.Unlock()
+
+	setCachedStorefront(account.MediaUserToken, storefront)
+	saveStorefrontCache()
 }
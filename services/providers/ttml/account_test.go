@@ -2,6 +2,7 @@ package ttml
 
 import (
 	"encoding/json"
+	"lyrics-api-go/internal/clocktest"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -211,6 +212,124 @@ func TestAccountManager_Quarantine(t *testing.T) {
 	}
 }
 
+func TestAccountManager_QuarantineExpires_FakeClock(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	savedClock := clk
+	clk = fake
+	defer func() { clk = savedClock }()
+
+	accounts := []MusicAccount{
+		{NameID: "Account1", MediaUserToken: "mut1"},
+		{NameID: "Account2", MediaUserToken: "mut2"},
+	}
+	manager := &AccountManager{
+		accounts:       accounts,
+		quarantineTime: make(map[int]int64),
+	}
+
+	manager.quarantineAccount(accounts[0])
+	if got := manager.availableAccountCount(); got != 1 {
+		t.Fatalf("Expected 1 available account while quarantined, got %d", got)
+	}
+	if remaining := manager.getQuarantineStatus()["Account1"]; remaining != int64(QuarantineDuration.Seconds()) {
+		t.Errorf("Expected %ds remaining, got %d", int64(QuarantineDuration.Seconds()), remaining)
+	}
+
+	fake.Advance(QuarantineDuration - time.Second)
+	if got := manager.availableAccountCount(); got != 1 {
+		t.Errorf("Expected quarantine to hold until it expires, got %d available", got)
+	}
+
+	fake.Advance(time.Second)
+	if got := manager.availableAccountCount(); got != 2 {
+		t.Errorf("Expected both accounts available after quarantine expired, got %d", got)
+	}
+}
+
+func TestAccountManager_HealthiestAccount(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	savedClock := clk
+	clk = fake
+	defer func() { clk = savedClock }()
+
+	disabledMutex.Lock()
+	originalDisabled := disabledAccounts
+	disabledAccounts = map[string]bool{"Account4": true}
+	disabledMutex.Unlock()
+	defer func() {
+		disabledMutex.Lock()
+		disabledAccounts = originalDisabled
+		disabledMutex.Unlock()
+	}()
+
+	accounts := []MusicAccount{
+		{NameID: "Account1", MediaUserToken: "mut1"},
+		{NameID: "Account2", MediaUserToken: "mut2"},
+		{NameID: "Account3", MediaUserToken: "mut3"},
+		{NameID: "Account4", MediaUserToken: "mut4"},
+	}
+	manager := &AccountManager{
+		accounts:       accounts,
+		quarantineTime: make(map[int]int64),
+	}
+
+	// Account1 hit a 429 long ago, Account2 more recently, Account3 is quarantined now
+	manager.quarantineAccount(accounts[0])
+	fake.Advance(QuarantineDuration + time.Minute)
+	manager.quarantineAccount(accounts[1])
+	fake.Advance(QuarantineDuration + time.Minute)
+	manager.quarantineAccount(accounts[2])
+
+	if got := manager.healthiestAccount(); got.NameID != "Account1" {
+		t.Errorf("Expected Account1 (oldest 429), got %q", got.NameID)
+	}
+
+	// An account that was never rate limited beats all of them
+	manager.accounts = append(manager.accounts, MusicAccount{NameID: "Account5", MediaUserToken: "mut5"})
+	if got := manager.healthiestAccount(); got.NameID != "Account5" {
+		t.Errorf("Expected Account5 (never rate limited), got %q", got.NameID)
+	}
+}
+
+func TestAccountManager_GetAccountForStorefront(t *testing.T) {
+	accounts := []MusicAccount{
+		{NameID: "US1", MediaUserToken: "mut1", Storefront: "us"},
+		{NameID: "JP1", MediaUserToken: "mut2", Storefront: "jp"},
+		{NameID: "US2", MediaUserToken: "mut3", Storefront: "us"},
+		{NameID: "JP2", MediaUserToken: "mut4", Storefront: "jp"},
+	}
+	manager := &AccountManager{
+		accounts:       accounts,
+		quarantineTime: make(map[int]int64),
+	}
+
+	// Requests for a storefront rotate over the accounts in it
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		got := manager.getAccountForStorefront("jp")
+		if got.Storefront != "jp" {
+			t.Fatalf("Expected a jp account, got %q", got.NameID)
+		}
+		seen[got.NameID]++
+	}
+	if seen["JP1"] != 2 || seen["JP2"] != 2 {
+		t.Errorf("Expected both jp accounts used twice, got %v", seen)
+	}
+
+	// A quarantined account is skipped; with none left, any account is used
+	manager.quarantineAccount(accounts[1])
+	if got := manager.getAccountForStorefront("jp"); got.NameID != "JP2" {
+		t.Errorf("Expected JP2 while JP1 is quarantined, got %q", got.NameID)
+	}
+	manager.quarantineAccount(accounts[3])
+	if got := manager.getAccountForStorefront("jp"); got.Storefront != "us" {
+		t.Errorf("Expected a fallback us account, got %q", got.NameID)
+	}
+	if got := manager.getAccountForStorefront("de"); got.NameID == "" {
+		t.Error("Expected a fallback account for a storefront without accounts")
+	}
+}
+
 func TestAccountManager_QuarantineAllAccounts(t *testing.T) {
 	accounts := []MusicAccount{
 		{NameID: "Account1", MediaUserToken: "mut1"},
@@ -796,27 +915,28 @@ func TestAccountResponse_MissingFields(t *testing.T) {
 
 func TestInitializeAccountStorefronts_NoAccounts(t *testing.T) {
 	// Save and restore original account manager
-	originalManager := accountManager
+	originalManager := accountPool.Load()
 	defer func() {
-		accountManager = originalManager
+		accountPool.Store(originalManager)
 	}()
 
 	// Test with nil account manager
-	accountManager = nil
+	accountPool.Store(nil)
 	InitializeAccountStorefronts() // Should not panic
 
 	// Test with empty accounts
-	accountManager = &AccountManager{
+	accountManager := &AccountManager{
 		accounts:       []MusicAccount{},
 		currentIndex:   0,
 		quarantineTime: make(map[int]int64),
 	}
+	accountPool.Store(accountManager)
 	InitializeAccountStorefronts() // Should not panic
 }
 
 func TestInitializeAccountStorefronts_SkipsEmptyMUT(t *testing.T) {
 	// Save and restore original state
-	originalManager := accountManager
+	originalManager := accountPool.Load()
 	tokenMu.Lock()
 	originalToken := bearerToken
 	originalExpiry := tokenExpiry
@@ -825,7 +945,7 @@ func TestInitializeAccountStorefronts_SkipsEmptyMUT(t *testing.T) {
 	tokenMu.Unlock()
 
 	defer func() {
-		accountManager = originalManager
+		accountPool.Store(originalManager)
 		tokenMu.Lock()
 		bearerToken = originalToken
 		tokenExpiry = originalExpiry
@@ -833,7 +953,7 @@ func TestInitializeAccountStorefronts_SkipsEmptyMUT(t *testing.T) {
 	}()
 
 	// Create manager with one account with empty MUT
-	accountManager = &AccountManager{
+	accountManager := &AccountManager{
 		accounts: []MusicAccount{
 			{NameID: "Account1", MediaUserToken: "", Storefront: "us"},
 			{NameID: "Account2", MediaUserToken: "valid_mut", Storefront: "us"},
@@ -841,14 +961,15 @@ func TestInitializeAccountStorefronts_SkipsEmptyMUT(t *testing.T) {
 		currentIndex:   0,
 		quarantineTime: make(map[int]int64),
 	}
+	accountPool.Store(accountManager)
 
 	// This will attempt to fetch but fail (no real API)
 	// The important thing is it doesn't panic and skips empty MUT
 	InitializeAccountStorefronts()
 
 	// Account with empty MUT should still have default storefront
-	if accountManager.accounts[0].Storefront != "us" {
-		t.Errorf("Account with empty MUT should keep default storefront, got %q", accountManager.accounts[0].Storefront)
+	if got := accountStorefront(accountManager.accounts[0]); got != "us" {
+		t.Errorf("Account with empty MUT should keep default storefront, got %q", got)
 	}
 }
 
@@ -1004,7 +1125,7 @@ func TestInitializeAccountStorefronts_UsesCache(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	// Save and restore original state
-	originalManager := accountManager
+	originalManager := accountPool.Load()
 	storefrontMutex.Lock()
 	originalCache := storefrontCache
 	originalPath := storefrontCachePath
@@ -1020,7 +1141,7 @@ func TestInitializeAccountStorefronts_UsesCache(t *testing.T) {
 	tokenMu.Unlock()
 
 	defer func() {
-		accountManager = originalManager
+		accountPool.Store(originalManager)
 		storefrontMutex.Lock()
 		storefrontCache = originalCache
 		storefrontCachePath = originalPath
@@ -1042,19 +1163,103 @@ func TestInitializeAccountStorefronts_UsesCache(t *testing.T) {
 	storefrontMutex.Unlock()
 
 	// Create account manager with the same MUT
-	accountManager = &AccountManager{
+	accountManager := &AccountManager{
 		accounts: []MusicAccount{
 			{NameID: "CachedAccount", MediaUserToken: testMut, Storefront: "us"},
 		},
 		currentIndex:   0,
 		quarantineTime: make(map[int]int64),
 	}
+	accountPool.Store(accountManager)
 
 	// Initialize - should use cached value without API call
 	InitializeAccountStorefronts()
 
 	// Account should have the cached storefront
-	if accountManager.accounts[0].Storefront != "jp" {
-		t.Errorf("Expected storefront 'jp' from cache, got %q", accountManager.accounts[0].Storefront)
+	if got := accountStorefront(accountManager.accounts[0]); got != "jp" {
+		t.Errorf("Expected storefront 'jp' from cache, got %q", got)
+	}
+}
+
+func TestInitializeAccountStorefronts_FetchesInBackground(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalManager := accountPool.Load()
+	storefrontMutex.Lock()
+	originalCache := storefrontCache
+	originalPath := storefrontCachePath
+	storefrontCache = make(map[string]string)
+	storefrontCachePath = filepath.Join(tmpDir, StorefrontCacheFile)
+	storefrontMutex.Unlock()
+
+	tokenMu.Lock()
+	originalToken := bearerToken
+	originalExpiry := tokenExpiry
+	bearerToken = "test_bearer_token"
+	tokenExpiry = time.Now().Add(1 * time.Hour)
+	tokenMu.Unlock()
+
+	defer func() {
+		accountPool.Store(originalManager)
+		storefrontMutex.Lock()
+		storefrontCache = originalCache
+		storefrontCachePath = originalPath
+		storefrontMutex.Unlock()
+		tokenMu.Lock()
+		bearerToken = originalToken
+		tokenExpiry = originalExpiry
+		tokenMu.Unlock()
+	}()
+
+	setCachedStorefront("cached_mut", "jp")
+	saveStorefrontCache()
+
+	accountManager := &AccountManager{
+		accounts: []MusicAccount{
+			{NameID: "Cached", MediaUserToken: "cached_mut", Storefront: "us"},
+			{NameID: "Uncached1", MediaUserToken: "uncached_mut_1", Storefront: "gb"},
+			{NameID: "Uncached2", MediaUserToken: "uncached_mut_2", Storefront: ""},
+		},
+		quarantineTime: make(map[int]int64),
+	}
+	accountPool.Store(accountManager)
+
+	done := InitializeAccountStorefronts()
+
+	// Cached storefronts are applied before returning
+	if got := accountStorefront(accountManager.accounts[0]); got != "jp" {
+		t.Errorf("Expected cached storefront 'jp' applied synchronously, got %q", got)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("Background storefront fetches did not finish")
+	}
+
+	// Fetches fail without the real API; accounts keep their defaults
+	if got := accountStorefront(accountManager.accounts[1]); got != "gb" {
+		t.Errorf("Expected failed fetch to keep default 'gb', got %q", got)
+	}
+	if got := accountStorefront(accountManager.accounts[2]); got != "us" {
+		t.Errorf("Expected empty storefront to fall back to 'us', got %q", got)
+	}
+}
+
+func TestAccountStorefront_PrefersBackgroundResult(t *testing.T) {
+	storefrontMutex.Lock()
+	storefrontOverrides["OverrideAccount"] = "de"
+	storefrontMutex.Unlock()
+	defer func() {
+		storefrontMutex.Lock()
+		delete(storefrontOverrides, "OverrideAccount")
+		storefrontMutex.Unlock()
+	}()
+
+	if got := accountStorefront(MusicAccount{NameID: "OverrideAccount", Storefront: "us"}); got != "de" {
+		t.Errorf("Expected background-fetched storefront 'de', got %q", got)
+	}
+	if got := accountStorefront(MusicAccount{NameID: "Other", Storefront: "fr"}); got != "fr" {
+		t.Errorf("Expected account storefront 'fr', got %q", got)
 	}
 }
@@ -3,21 +3,28 @@ package ttml
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"lyrics-api-go/circuitbreaker"
 	"lyrics-api-go/config"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/logging"
 	"lyrics-api-go/services/notifier"
+	"lyrics-api-go/services/providers"
 	"lyrics-api-go/stats"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-var apiCircuitBreaker *circuitbreaker.CircuitBreaker
+var (
+	apiCircuitBreaker *circuitbreaker.CircuitBreaker
+	baseThreshold     atomic.Int64 // CIRCUIT_BREAKER_THRESHOLD before scaling by healthy accounts; swapped on reload
+)
 
 func initCircuitBreaker() {
 	if apiCircuitBreaker != nil {
@@ -25,31 +32,57 @@ func initCircuitBreaker() {
 	}
 
 	// Ensure account manager is initialized to get account count
-	if accountManager == nil {
-		initAccountManager()
-	}
+	accountManager := getAccountManager()
 
 	conf := config.Get()
-	baseThreshold := conf.Configuration.CircuitBreakerThreshold
-
-	// Scale threshold by number of accounts for fair distribution
-	// With round-robin, each account may fail independently, so we need
-	// a higher threshold to avoid premature circuit opening
-	numAccounts := max(accountManager.accountCount(), 1)
-	scaledThreshold := baseThreshold * numAccounts
+	base := conf.Configuration.CircuitBreakerThreshold
+	baseThreshold.Store(int64(base))
+	healthyAccounts := max(accountManager.availableAccountCount(), 1)
+	effective := effectiveThreshold(base, healthyAccounts)
 
 	apiCircuitBreaker = circuitbreaker.New(circuitbreaker.Config{
 		Name:      "TTML-API",
-		Threshold: scaledThreshold,
+		Threshold: effective,
 		Cooldown:  time.Duration(conf.Configuration.CircuitBreakerCooldownSecs) * time.Second,
+		Clock:     clk,
 	})
-	log.Infof("%s Initialized with threshold=%d (base=%d × %d accounts), cooldown=%ds", logcolors.LogCircuitBreaker,
-		scaledThreshold,
-		baseThreshold,
-		numAccounts,
+	log.Infof("%s Initialized with threshold=%d (base=%d × %d healthy accounts), cooldown=%ds", logcolors.LogCircuitBreaker,
+		effective,
+		base,
+		healthyAccounts,
 		conf.Configuration.CircuitBreakerCooldownSecs)
 }
 
+// effectiveThreshold scales the base threshold by the number of healthy accounts.
+// With round-robin, each account may fail independently, so more accounts need
+// a higher threshold to avoid premature circuit opening; as accounts drop out
+// (disabled or quarantined) the threshold shrinks so a degraded pool trips sooner.
+func effectiveThreshold(base, healthyAccounts int) int {
+	return max(base, 1) * max(healthyAccounts, 1)
+}
+
+// recomputeCircuitBreakerThreshold rescales the breaker threshold to the current
+// number of healthy accounts. Called whenever an account is disabled, re-enabled,
+// quarantined or released from quarantine.
+func recomputeCircuitBreakerThreshold() {
+	accountManager := accountPool.Load()
+	if apiCircuitBreaker == nil || accountManager == nil {
+		return
+	}
+	apiCircuitBreaker.SetThreshold(effectiveThreshold(int(baseThreshold.Load()), accountManager.availableAccountCount()))
+}
+
+// GetCircuitBreakerThresholds returns the configured base threshold and the
+// effective (health-weighted) threshold. The effective value is refreshed first
+// so quarantines that expired passively are reflected.
+func GetCircuitBreakerThresholds() (base, effective int) {
+	if apiCircuitBreaker == nil {
+		return config.Get().Configuration.CircuitBreakerThreshold, 0
+	}
+	recomputeCircuitBreakerThreshold()
+	return int(baseThreshold.Load()), apiCircuitBreaker.Threshold()
+}
+
 // GetCircuitBreakerStats returns circuit breaker statistics for monitoring
 func GetCircuitBreakerStats() (state string, failures int, timeUntilRetry time.Duration) {
 	if apiCircuitBreaker == nil {
@@ -59,6 +92,14 @@ func GetCircuitBreakerStats() (state string, failures int, timeUntilRetry time.D
 	return s.String(), f, apiCircuitBreaker.TimeUntilRetry()
 }
 
+// GetCircuitBreakerProbeStats returns half-open test request statistics
+func GetCircuitBreakerProbeStats() circuitbreaker.ProbeStats {
+	if apiCircuitBreaker == nil {
+		return circuitbreaker.ProbeStats{}
+	}
+	return apiCircuitBreaker.Probes()
+}
+
 // ResetCircuitBreaker manually resets the circuit breaker (for admin use)
 func ResetCircuitBreaker() {
 	if apiCircuitBreaker != nil {
@@ -97,6 +138,10 @@ func SimulateFailure() {
 	apiCircuitBreaker.RecordFailure()
 }
 
+// ttmlLog throttles the per-lookup request/search/match lines (LOG_THROTTLE,
+// component "ttml")
+var ttmlLog = logging.Throttled(logging.ComponentTTML)
+
 // =============================================================================
 // STRING SIMILARITY & SCORING
 // =============================================================================
@@ -158,6 +203,57 @@ func stringSimilarity(s1, s2 string) float64 {
 	return float64(overlap*2) / float64(totalChars)
 }
 
+// artistSeparatorRegex matches the separators used to join collaborating artists,
+// e.g. "A & B", "A, B and C", "A feat. B", "A x B"
+var artistSeparatorRegex = regexp.MustCompile(`(?i)\s*(?:[,&;]|\s(?:and|x|with|vs\.?|feat\.?|ft\.?|featuring)\s)\s*`)
+
+// splitArtists splits a collaboration credit into its individual artists.
+// A single artist comes back as a one-element slice.
+func splitArtists(s string) []string {
+	var artists []string
+	for _, part := range artistSeparatorRegex.Split(s, -1) {
+		if part = strings.TrimSpace(part); part != "" {
+			artists = append(artists, part)
+		}
+	}
+	return artists
+}
+
+// artistSimilarity compares artist credits allowing for collaborations. Apple often
+// lists only the primary artist (or a different ordering), so besides the full
+// strings every artist in the query is compared with every artist on the track and
+// the best score wins.
+func artistSimilarity(trackArtist, targetArtist string) float64 {
+	best := stringSimilarity(trackArtist, targetArtist)
+	if best == 1.0 {
+		return best
+	}
+	trackArtists := splitArtists(trackArtist)
+	targetArtists := splitArtists(targetArtist)
+	if len(trackArtists) <= 1 && len(targetArtists) <= 1 {
+		return best
+	}
+	for _, target := range targetArtists {
+		for _, candidate := range trackArtists {
+			best = max(best, stringSimilarity(candidate, target))
+		}
+	}
+	return best
+}
+
+// contentRatingBonus is added to the track whose explicit/clean release matches the
+// preference. It only decides between otherwise near-identical candidates.
+const contentRatingBonus = 0.02
+
+// contentRatingAdjustment returns contentRatingBonus when the track is the preferred
+// release: explicit when preferExplicit, otherwise anything not marked explicit
+func contentRatingAdjustment(track *Track, preferExplicit bool) float64 {
+	if track.IsExplicit() == preferExplicit {
+		return contentRatingBonus
+	}
+	return 0
+}
+
 // TrackScore represents the scoring breakdown for a track
 type TrackScore struct {
 	Track       *Track
@@ -165,33 +261,84 @@ type TrackScore struct {
 	NameScore   float64
 	ArtistScore float64
 	AlbumScore  float64
+
+	// VersionAdjust is the live/acoustic/remix qualifier bonus or penalty, already
+	// included in TotalScore
+	VersionAdjust float64
+
+	// RatingAdjust is the explicit/clean preference bonus, set by pickBestTrack and
+	// included in TotalScore
+	RatingAdjust float64
+
+	// HintsAdjust is the ISRC/release year bonus from MatchHints, set by pickBestTrack
+	// and included in TotalScore
+	HintsAdjust float64
 }
 
-// scoreTrack calculates a weighted score for a track based on multiple factors
+// scoreTrack calculates a weighted score for a track using the configured weights
 // Duration filtering is handled separately as a strict requirement, not a weight
 func scoreTrack(track *Track, targetSongName, targetArtistName, targetAlbumName string) TrackScore {
-	// Weights for each factor (must sum to 1.0)
-	const (
-		nameWeight   = 0.50  // 50% weight for song name match
-		artistWeight = 0.375 // 37.5% weight for artist name match
-		albumWeight  = 0.125 // 12.5% weight for album name match
-	)
+	return scoreTrackWithWeights(track, targetSongName, targetArtistName, targetAlbumName, configuredScoreWeights())
+}
 
+// scoreTrackWithWeights calculates a weighted score for a track with an explicit weight set
+func scoreTrackWithWeights(track *Track, targetSongName, targetArtistName, targetAlbumName string, weights config.ScoreWeights) TrackScore {
 	score := TrackScore{Track: track}
 
 	// Calculate individual scores
-	score.NameScore = stringSimilarity(track.Attributes.Name, targetSongName)
-	score.ArtistScore = stringSimilarity(track.Attributes.ArtistName, targetArtistName)
+	score.NameScore = titleSimilarity(track.Attributes.Name, targetSongName)
+	score.ArtistScore = artistSimilarity(track.Attributes.ArtistName, targetArtistName)
 	score.AlbumScore = stringSimilarity(track.Attributes.AlbumName, targetAlbumName)
 
+	score.VersionAdjust = versionAdjustment(track.Attributes.Name, targetSongName)
+
 	// Calculate weighted total score
-	score.TotalScore = (score.NameScore * nameWeight) +
-		(score.ArtistScore * artistWeight) +
-		(score.AlbumScore * albumWeight)
+	score.TotalScore = (score.NameScore * weights.Name) +
+		(score.ArtistScore * weights.Artist) +
+		(score.AlbumScore * weights.Album) +
+		score.VersionAdjust
 
 	return score
 }
 
+var invalidWeightsOnce sync.Once
+
+// configuredScoreWeights returns the weights from config, falling back to the
+// defaults (and warning once) if they fail validation
+func configuredScoreWeights() config.ScoreWeights {
+	conf := config.Get()
+	weights, err := conf.GetScoreWeights()
+	if err != nil {
+		invalidWeightsOnce.Do(func() {
+			log.Warnf("%s Invalid SCORE_WEIGHT_* config (%v), using defaults: %s",
+				logcolors.LogTrackScore, err, config.DefaultScoreWeights)
+		})
+		return config.DefaultScoreWeights
+	}
+	return weights
+}
+
+var invalidMatrixOnce sync.Once
+
+// minScoreFor returns the minimum score a match needs given which query fields were
+// supplied (a QueryFieldsKey). Combinations missing from MIN_SCORE_MATRIX use
+// MIN_SIMILARITY_SCORE; an invalid matrix is ignored with a one-time warning.
+func minScoreFor(fields string) float64 {
+	conf := config.Get()
+	matrix, err := conf.GetMinScoreMatrix()
+	if err != nil {
+		invalidMatrixOnce.Do(func() {
+			log.Warnf("%s Invalid MIN_SCORE_MATRIX (%v), using MIN_SIMILARITY_SCORE for all queries",
+				logcolors.LogBestMatch, err)
+		})
+		return conf.Configuration.MinSimilarityScore
+	}
+	if score, ok := matrix[fields]; ok {
+		return score
+	}
+	return conf.Configuration.MinSimilarityScore
+}
+
 // =============================================================================
 // HTTP REQUEST HANDLING
 // =============================================================================
@@ -202,9 +349,11 @@ func makeAPIRequestWithAccount(urlStr string, account MusicAccount, retries int)
 	if apiCircuitBreaker == nil {
 		initCircuitBreaker()
 	}
+	accountManager := getAccountManager()
 
 	// Check circuit breaker before making request
-	if !apiCircuitBreaker.Allow() {
+	allowed, probe := apiCircuitBreaker.AllowRequest()
+	if !allowed {
 		timeUntilRetry := apiCircuitBreaker.TimeUntilRetry()
 		if apiCircuitBreaker.IsHalfOpen() {
 			log.Warnf("%s Request blocked, circuit is HALF-OPEN, waiting for test request (retry in %v)", logcolors.LogCircuitBreaker, timeUntilRetry)
@@ -214,8 +363,18 @@ func makeAPIRequestWithAccount(urlStr string, account MusicAccount, retries int)
 		return nil, account, fmt.Errorf("circuit breaker is open, API temporarily unavailable (retry in %v)", timeUntilRetry)
 	}
 
+	// The half-open probe decides whether the circuit closes, so don't spend it on
+	// whatever round-robin handed us (possibly a just-quarantined account)
+	if probe {
+		if healthiest := accountManager.healthiestAccount(); healthiest.NameID != "" && healthiest.NameID != account.NameID {
+			log.Infof("%s Half-open probe: using %s instead of %s", logcolors.LogCircuitBreaker,
+				logcolors.Account(healthiest.NameID), logcolors.Account(account.NameID))
+			account = healthiest
+		}
+	}
+
 	attemptNum := retries + 1
-	log.Infof("%s Making request via %s (attempt %d)...", logcolors.LogHTTP, logcolors.Account(account.NameID), attemptNum)
+	ttmlLog.Infof("request_attempt", "%s Making request via %s (attempt %d)...", logcolors.LogHTTP, logcolors.Account(account.NameID), attemptNum)
 
 	req, err := http.NewRequest("GET", urlStr, nil)
 	if err != nil {
@@ -231,23 +390,28 @@ func makeAPIRequestWithAccount(urlStr string, account MusicAccount, retries int)
 	}
 
 	// Set headers for web auth
+	userAgent := userAgentFor(account)
 	req.Header.Set("Authorization", "Bearer "+bearerToken)
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", userAgent)
 	req.Header.Set("Origin", "https://music.apple.com")
 	req.Header.Set("Referer", "https://music.apple.com")
 	if account.MediaUserToken != "" {
 		req.Header.Set("media-user-token", account.MediaUserToken)
 	}
 
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := newUpstreamClient().Do(req)
 	if err != nil {
+		stats.Get().RecordUpstreamResponse(0, err)
+		stats.Get().RecordAccountAttempt(account.NameID, 0, err, retries > 0)
 		apiCircuitBreaker.RecordFailure()
 		log.Errorf("%s Request failed via %s: %v", logcolors.LogHTTP, logcolors.Account(account.NameID), err)
 		return nil, account, err
 	}
 
-	log.Infof("%s Response from %s: status %d", logcolors.LogHTTP, logcolors.Account(account.NameID), resp.StatusCode)
+	ttmlLog.Infof("response_status", "%s Response from %s: status %d", logcolors.LogHTTP, logcolors.Account(account.NameID), resp.StatusCode)
+	log.Debugf("%s %s used User-Agent %q (status %d)", logcolors.LogHTTP, logcolors.Account(account.NameID), userAgent, resp.StatusCode)
+	stats.Get().RecordUpstreamResponse(resp.StatusCode, nil)
+	stats.Get().RecordAccountAttempt(account.NameID, resp.StatusCode, nil, retries > 0)
 
 	// Calculate max retries based on account count (capped at 3)
 	maxRetries := min(accountManager.accountCount(), 3)
@@ -287,10 +451,10 @@ func makeAPIRequestWithAccount(urlStr string, account MusicAccount, retries int)
 			return makeAPIRequestWithAccount(urlStr, nextAccount, retries+1)
 		}
 
-		body, _ := io.ReadAll(resp.Body)
+		body := providers.ReadErrorBody(resp.Body)
 		resp.Body.Close()
 		log.Errorf("%s All %d retries exhausted, last account: %s", logcolors.LogRateLimit, maxRetries, logcolors.Account(account.NameID))
-		return nil, account, fmt.Errorf("TTML API returned status 429: %s", string(body))
+		return nil, account, fmt.Errorf("TTML API returned status 429: %s", body)
 	}
 
 	// Handle auth errors - since bearer is auto-refreshed, 401 indicates MUT issue
@@ -314,18 +478,18 @@ func makeAPIRequestWithAccount(urlStr string, account MusicAccount, retries int)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
+		body := providers.ReadErrorBody(resp.Body)
 		resp.Body.Close()
 		apiCircuitBreaker.RecordFailure()
-		log.Errorf("%s Unexpected status %d from %s: %s", logcolors.LogHTTP, resp.StatusCode, logcolors.Account(account.NameID), string(body))
-		return nil, account, fmt.Errorf("TTML API returned status %d: %s", resp.StatusCode, string(body))
+		log.Errorf("%s Unexpected status %d from %s: %s", logcolors.LogHTTP, resp.StatusCode, logcolors.Account(account.NameID), body)
+		return nil, account, fmt.Errorf("TTML API returned status %d: %s", resp.StatusCode, body)
 	}
 
 	// Success! Record it and clear any quarantine
 	apiCircuitBreaker.RecordSuccess()
 	accountManager.clearQuarantine(account)
 	stats.Get().RecordAccountUsage(account.NameID)
-	log.Infof("%s Request successful via %s", logcolors.LogHTTP, logcolors.Account(account.NameID))
+	ttmlLog.Infof("request_success", "%s Request successful via %s", logcolors.LogHTTP, logcolors.Account(account.NameID))
 	return resp, account, nil
 }
 
@@ -335,7 +499,7 @@ func makeAPIRequestWithAccount(urlStr string, account MusicAccount, retries int)
 
 // searchTrack searches for a track and returns the best match, score, the account that succeeded, and any error.
 // The returned account may differ from the input if a retry occurred due to rate limiting.
-func searchTrack(query string, storefront string, songName, artistName, albumName string, durationMs int, account MusicAccount) (*Track, float64, MusicAccount, error) {
+func searchTrack(query string, storefront string, songName, artistName, albumName string, durationMs int, weights config.ScoreWeights, preferExplicit bool, hints MatchHints, account MusicAccount) (*Track, float64, MusicAccount, error) {
 	if query == "" {
 		return nil, 0.0, account, fmt.Errorf("empty search query")
 	}
@@ -351,32 +515,70 @@ func searchTrack(query string, storefront string, songName, artistName, albumNam
 		url.QueryEscape(query),
 	)
 
-	log.Infof("%s Querying TTML API via %s: %s", logcolors.LogSearch, logcolors.Account(account.NameID), query)
+	// Duration/album variants of the same query reuse recent results and only fetch lyrics
+	if tracks, ok := getCachedSearch(query, storefront); ok {
+		ttmlLog.Infof("search_cache_hit", "%s Search cache hit (%d results): %s", logcolors.LogSearch, len(tracks), query)
+		hints.Trace.Add("search", "Search cache hit, %d results", len(tracks))
+		return pickBestTrack(tracks, query, songName, artistName, albumName, durationMs, weights, preferExplicit, hints, account)
+	}
+
+	tracks, successAccount, err := fetchSearchTracks(searchURL, query, account)
+	if err != nil {
+		return nil, 0.0, successAccount, err
+	}
+	hints.Trace.Add("search", "%d results from storefront %s via account %s", len(tracks), storefront, successAccount.NameID)
+	setCachedSearch(query, storefront, tracks)
+	return pickBestTrack(tracks, query, songName, artistName, albumName, durationMs, weights, preferExplicit, hints, successAccount)
+}
+
+// searchTrackURL runs a search request against searchURL and picks the best match.
+// Split from searchTrack so recorded fixtures can be replayed against a fixed URL.
+func searchTrackURL(searchURL, query, songName, artistName, albumName string, durationMs int, weights config.ScoreWeights, preferExplicit bool, hints MatchHints, account MusicAccount) (*Track, float64, MusicAccount, error) {
+	tracks, successAccount, err := fetchSearchTracks(searchURL, query, account)
+	if err != nil {
+		return nil, 0.0, successAccount, err
+	}
+	return pickBestTrack(tracks, query, songName, artistName, albumName, durationMs, weights, preferExplicit, hints, successAccount)
+}
+
+// fetchSearchTracks runs a search request against searchURL and returns the raw song results
+func fetchSearchTracks(searchURL, query string, account MusicAccount) ([]Track, MusicAccount, error) {
+	ttmlLog.Infof("search_query", "%s Querying TTML API via %s: %s", logcolors.LogSearch, logcolors.Account(account.NameID), query)
 	resp, successAccount, err := makeAPIRequestWithAccount(searchURL, account, 0)
 	if err != nil {
-		return nil, 0.0, successAccount, fmt.Errorf("search request failed: %v", err)
+		return nil, successAccount, fmt.Errorf("search request failed: %v", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadSearch)
 	if err != nil {
-		return nil, 0.0, successAccount, fmt.Errorf("failed to read search response: %v", err)
+		return nil, successAccount, fmt.Errorf("failed to read search response: %v", err)
 	}
 
 	if len(body) == 0 {
-		return nil, 0.0, successAccount, fmt.Errorf("empty search response body")
+		return nil, successAccount, fmt.Errorf("empty search response body")
 	}
 
 	var searchResp SearchResponse
 	if err := json.Unmarshal(body, &searchResp); err != nil {
-		return nil, 0.0, successAccount, fmt.Errorf("failed to parse search response: %v", err)
+		return nil, successAccount, fmt.Errorf("failed to parse search response: %v", err)
+	}
+	if err := checkSearchSchema(body, &searchResp); err != nil {
+		return nil, successAccount, err
 	}
 
 	if len(searchResp.Results.Songs.Data) == 0 {
-		return nil, 0.0, successAccount, fmt.Errorf("no tracks found for query: %s", query)
+		return nil, successAccount, fmt.Errorf("no tracks found for query: %s", query)
 	}
 
-	tracks := searchResp.Results.Songs.Data
+	return searchResp.Results.Songs.Data, successAccount, nil
+}
+
+// pickBestTrack applies the duration filter and scoring to search results.
+// preferExplicit breaks near-ties between the explicit and clean releases of a song;
+// hints (ISRC, release year) favor the candidates they identify.
+// tracks is not modified, so cached results can be passed in directly.
+func pickBestTrack(tracks []Track, query, songName, artistName, albumName string, durationMs int, weights config.ScoreWeights, preferExplicit bool, hints MatchHints, successAccount MusicAccount) (*Track, float64, MusicAccount, error) {
 
 	// If duration is provided, apply strict duration filter first
 	if durationMs > 0 {
@@ -413,8 +615,10 @@ func searchTrack(query string, storefront string, songName, artistName, albumNam
 			}
 		}
 
+		hints.Trace.Add("duration_filter", "%d/%d results within %dms of %dms", len(filteredTracks), len(tracks), deltaMs, durationMs)
 		if len(filteredTracks) == 0 {
 			if closestTrack != nil {
+				stats.Get().RecordDurationRejection(ProviderName, closestDiff)
 				return nil, 0.0, successAccount, fmt.Errorf("no tracks within %dms of duration %dms (closest: %s - %s at %dms, diff: %dms)",
 					deltaMs, durationMs,
 					closestTrack.Attributes.Name,
@@ -425,7 +629,7 @@ func searchTrack(query string, storefront string, songName, artistName, albumNam
 			return nil, 0.0, successAccount, fmt.Errorf("no tracks found within %dms of requested duration %dms", deltaMs, durationMs)
 		}
 
-		log.Infof("%s %d/%d tracks passed duration filter (delta: %dms)", logcolors.LogDurationFilter, len(filteredTracks), len(tracks), deltaMs)
+		ttmlLog.Infof("duration_filter", "%s %d/%d tracks passed duration filter (delta: %dms)", logcolors.LogDurationFilter, len(filteredTracks), len(tracks), deltaMs)
 		tracks = filteredTracks
 	}
 
@@ -436,10 +640,19 @@ func searchTrack(query string, storefront string, songName, artistName, albumNam
 
 		for i := range tracks {
 			track := &tracks[i]
-			score := scoreTrack(track, songName, artistName, albumName)
+			score := scoreTrackWithWeights(track, songName, artistName, albumName, weights)
+			score.RatingAdjust = contentRatingAdjustment(track, preferExplicit)
+			score.TotalScore += score.RatingAdjust
+			score.HintsAdjust = hintsAdjustment(track, hints)
+			score.TotalScore += score.HintsAdjust
+			if hints.Trace.verbose() {
+				hints.Trace.Add("candidate", "%s - %s (ID: %s, %dms): %.3f (name %.3f, artist %.3f, album %.3f, version %+.2f, rating %+.2f, hints %+.2f)",
+					track.Attributes.Name, track.Attributes.ArtistName, track.ID, track.Attributes.DurationInMillis,
+					score.TotalScore, score.NameScore, score.ArtistScore, score.AlbumScore, score.VersionAdjust, score.RatingAdjust, score.HintsAdjust)
+			}
 
 			// Log detailed scoring for debugging
-			log.Debugf("%s %s - %s | Total: %.3f (Name: %.3f, Artist: %.3f, Album: %.3f) | Duration: %dms",
+			log.Debugf("%s %s - %s | Total: %.3f (Name: %.3f, Artist: %.3f, Album: %.3f, Version: %+.2f, Rating: %+.2f, Hints: %+.2f) | Duration: %dms | Weights: %s",
 				logcolors.LogTrackScore,
 				track.Attributes.Name,
 				track.Attributes.ArtistName,
@@ -447,7 +660,11 @@ func searchTrack(query string, storefront string, songName, artistName, albumNam
 				score.NameScore,
 				score.ArtistScore,
 				score.AlbumScore,
-				track.Attributes.DurationInMillis)
+				score.VersionAdjust,
+				score.RatingAdjust,
+				score.HintsAdjust,
+				track.Attributes.DurationInMillis,
+				weights)
 
 			if score.TotalScore > bestScore.TotalScore {
 				bestScore = score
@@ -455,34 +672,52 @@ func searchTrack(query string, storefront string, songName, artistName, albumNam
 		}
 
 		if bestScore.Track != nil {
-			conf := config.Get()
-			minScore := conf.Configuration.MinSimilarityScore
+			fields := config.QueryFieldsKey(songName != "", artistName != "", albumName != "", durationMs > 0)
+			minScore := minScoreFor(fields)
 
 			// Check if the best score meets the minimum threshold
 			if bestScore.TotalScore < minScore {
-				log.Warnf("%s Score %.3f below threshold %.3f for: %s - %s",
+				log.Warnf("%s Score %.3f below threshold %.3f (fields: %s) for: %s - %s",
 					logcolors.LogBestMatch,
 					bestScore.TotalScore,
 					minScore,
+					fields,
 					bestScore.Track.Attributes.Name,
 					bestScore.Track.Attributes.ArtistName)
+				hints.Trace.Add("best_match", "%s - %s (ID: %s) scored %.3f, below the %s threshold %.3f",
+					bestScore.Track.Attributes.Name, bestScore.Track.Attributes.ArtistName, bestScore.Track.ID, bestScore.TotalScore, fields, minScore)
 				return nil, 0.0, successAccount, fmt.Errorf("no matching tracks found (best match score %.3f below threshold %.3f)", bestScore.TotalScore, minScore)
 			}
 
-			log.Infof("%s %s - %s (Score: %.3f)",
+			ttmlLog.Infof("best_match", "%s %s - %s (Score: %.3f, Weights: %s)",
 				logcolors.LogBestMatch,
 				bestScore.Track.Attributes.Name,
 				bestScore.Track.Attributes.ArtistName,
-				bestScore.TotalScore)
+				bestScore.TotalScore,
+				weights)
+			hints.Trace.Add("best_match", "%s - %s (ID: %s) scored %.3f (threshold %.3f)",
+				bestScore.Track.Attributes.Name, bestScore.Track.Attributes.ArtistName, bestScore.Track.ID, bestScore.TotalScore, minScore)
+			recordDurationMatch(bestScore.Track, durationMs)
 			return bestScore.Track, bestScore.TotalScore, successAccount, nil
 		}
 	}
 
 	// Fallback: return the first (best) match from API (no score calculated)
 	log.Debugf("%s Using first search result", logcolors.LogFallback)
+	hints.Trace.Add("best_match", "No names to score, using the first result %s (ID: %s)", tracks[0].Attributes.Name, tracks[0].ID)
+	recordDurationMatch(&tracks[0], durationMs)
 	return &tracks[0], 1.0, successAccount, nil
 }
 
+// recordDurationMatch feeds /stats/duration when the request gave a duration
+func recordDurationMatch(track *Track, durationMs int) {
+	if durationMs <= 0 {
+		return
+	}
+	diff := track.Attributes.DurationInMillis - durationMs
+	stats.Get().RecordDurationMatch(ProviderName, max(diff, -diff))
+}
+
 func fetchLyricsTTML(trackID string, storefront string, account MusicAccount) (string, error) {
 	conf := config.Get()
 	lyricsURL := conf.Configuration.TTMLBaseURL + fmt.Sprintf(
@@ -491,14 +726,14 @@ func fetchLyricsTTML(trackID string, storefront string, account MusicAccount) (s
 		trackID,
 	)
 
-	log.Infof("%s Fetching TTML via %s for track: %s", logcolors.LogLyrics, logcolors.Account(account.NameID), trackID)
+	ttmlLog.Infof("lyrics_fetch", "%s Fetching TTML via %s for track: %s", logcolors.LogLyrics, logcolors.Account(account.NameID), trackID)
 	resp, _, err := makeAPIRequestWithAccount(lyricsURL, account, 0)
 	if err != nil {
 		return "", fmt.Errorf("lyrics request failed: %v", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadBody(resp.Body, providers.PayloadLyrics)
 	if err != nil {
 		return "", fmt.Errorf("failed to read lyrics response: %v", err)
 	}
@@ -507,6 +742,9 @@ func fetchLyricsTTML(trackID string, storefront string, account MusicAccount) (s
 	if err := json.Unmarshal(body, &lyricsResp); err != nil {
 		return "", fmt.Errorf("failed to parse lyrics response: %v", err)
 	}
+	if err := checkLyricsSchema(body); err != nil {
+		return "", err
+	}
 
 	log.Debugf("%s Parsed lyrics response, data entries: %d", logcolors.LogLyrics, len(lyricsResp.Data))
 
@@ -527,5 +765,6 @@ func fetchLyricsTTML(trackID string, storefront string, account MusicAccount) (s
 	}
 
 	log.Debugf("%s Successfully fetched TTML content, length: %d bytes", logcolors.LogLyrics, len(ttml))
+	recordParseWarnings(trackID, ttml)
 	return ttml, nil
 }
@@ -1,7 +1,13 @@
 package ttml
 
 import (
+	"fmt"
+	"io"
+	"lyrics-api-go/config"
+	"strings"
 	"testing"
+
+	log "github.com/sirupsen/logrus"
 )
 
 func TestNormalizeString(t *testing.T) {
@@ -178,6 +184,7 @@ func TestScoreTrack(t *testing.T) {
 			GenreNames          []string `json:"genreNames,omitempty"`
 			ComposerName        string   `json:"composerName,omitempty"`
 			HasCredits          *bool    `json:"hasCredits,omitempty"`
+			ContentRating       string   `json:"contentRating,omitempty"`
 		}{
 			Name:             "Shape of You",
 			ArtistName:       "Ed Sheeran",
@@ -301,6 +308,7 @@ func TestScoreTrack_PerfectMatch(t *testing.T) {
 			GenreNames          []string `json:"genreNames,omitempty"`
 			ComposerName        string   `json:"composerName,omitempty"`
 			HasCredits          *bool    `json:"hasCredits,omitempty"`
+			ContentRating       string   `json:"contentRating,omitempty"`
 		}{
 			Name:             "Test Song",
 			ArtistName:       "Test Artist",
@@ -335,6 +343,7 @@ func TestScoreTrack_ComponentScores(t *testing.T) {
 			GenreNames          []string `json:"genreNames,omitempty"`
 			ComposerName        string   `json:"composerName,omitempty"`
 			HasCredits          *bool    `json:"hasCredits,omitempty"`
+			ContentRating       string   `json:"contentRating,omitempty"`
 		}{
 			Name:             "Test Song",
 			ArtistName:       "The Beatles",
@@ -436,6 +445,7 @@ func TestScoreTrack_Comparison(t *testing.T) {
 			GenreNames          []string `json:"genreNames,omitempty"`
 			ComposerName        string   `json:"composerName,omitempty"`
 			HasCredits          *bool    `json:"hasCredits,omitempty"`
+			ContentRating       string   `json:"contentRating,omitempty"`
 		}{
 			Name:             "Shape of You",
 			ArtistName:       "Ed Sheeran",
@@ -461,6 +471,7 @@ func TestScoreTrack_Comparison(t *testing.T) {
 			GenreNames          []string `json:"genreNames,omitempty"`
 			ComposerName        string   `json:"composerName,omitempty"`
 			HasCredits          *bool    `json:"hasCredits,omitempty"`
+			ContentRating       string   `json:"contentRating,omitempty"`
 		}{
 			Name:             "Shape of My Heart",
 			ArtistName:       "Sting",
@@ -595,3 +606,252 @@ func TestTripCircuitBreakerOnFullQuarantine_NilCircuitBreaker(t *testing.T) {
 		ResetCircuitBreaker()
 	}
 }
+
+func TestCircuitBreakerThreshold_FollowsAccountHealth(t *testing.T) {
+	disabledMutex.Lock()
+	originalDisabled := disabledAccounts
+	disabledAccounts = make(map[string]bool)
+	disabledMutex.Unlock()
+
+	savedManager, savedCB := accountPool.Load(), apiCircuitBreaker
+	defer func() {
+		disabledMutex.Lock()
+		disabledAccounts = originalDisabled
+		disabledMutex.Unlock()
+		accountPool.Store(savedManager)
+		apiCircuitBreaker = savedCB
+	}()
+
+	accounts := []MusicAccount{
+		{NameID: "Account1", MediaUserToken: "mut1"},
+		{NameID: "Account2", MediaUserToken: "mut2"},
+		{NameID: "Account3", MediaUserToken: "mut3"},
+	}
+	accountManager := &AccountManager{accounts: accounts, quarantineTime: make(map[int]int64)}
+	accountPool.Store(accountManager)
+	apiCircuitBreaker = nil
+	initCircuitBreaker()
+
+	base, effective := GetCircuitBreakerThresholds()
+	if effective != base*3 {
+		t.Fatalf("Expected effective threshold %d (3 accounts), got %d", base*3, effective)
+	}
+
+	steps := []struct {
+		name    string
+		action  func()
+		healthy int
+	}{
+		{"disable", func() { accountManager.DisableAccount(accounts[0]) }, 2},
+		{"quarantine", func() { accountManager.quarantineAccount(accounts[1]) }, 1},
+		{"clear quarantine", func() { accountManager.clearQuarantine(accounts[1]) }, 2},
+		{"re-enable", func() { accountManager.EnableAccount(accounts[0]) }, 3},
+	}
+	for _, step := range steps {
+		step.action()
+		if got := apiCircuitBreaker.Threshold(); got != base*step.healthy {
+			t.Errorf("After %s: threshold = %d, want %d", step.name, got, base*step.healthy)
+		}
+	}
+}
+
+func TestEffectiveThreshold_NeverZero(t *testing.T) {
+	if got := effectiveThreshold(5, 0); got != 5 {
+		t.Errorf("effectiveThreshold(5, 0) = %d, want 5", got)
+	}
+	if got := effectiveThreshold(0, 3); got != 3 {
+		t.Errorf("effectiveThreshold(0, 3) = %d, want 3", got)
+	}
+}
+
+func TestScoreTrackWithWeights(t *testing.T) {
+	track := &Track{}
+	track.Attributes.Name = "Hello"
+	track.Attributes.ArtistName = "Someone Else"
+	track.Attributes.AlbumName = "25"
+
+	nameOnly := scoreTrackWithWeights(track, "Hello", "Adele", "25", config.ScoreWeights{Name: 1})
+	if nameOnly.TotalScore != nameOnly.NameScore {
+		t.Errorf("Name-only weights should score by name alone: total %.3f, name %.3f", nameOnly.TotalScore, nameOnly.NameScore)
+	}
+
+	artistHeavy := scoreTrackWithWeights(track, "Hello", "Adele", "25", config.ScoreWeights{Name: 0.2, Artist: 0.7, Album: 0.1})
+	if artistHeavy.TotalScore >= nameOnly.TotalScore {
+		t.Errorf("Artist-heavy weights should penalize the artist mismatch: %.3f >= %.3f", artistHeavy.TotalScore, nameOnly.TotalScore)
+	}
+
+	// scoreTrack uses the configured weights (defaults in tests)
+	if got, want := scoreTrack(track, "Hello", "Adele", "25").TotalScore,
+		scoreTrackWithWeights(track, "Hello", "Adele", "25", config.DefaultScoreWeights).TotalScore; got != want {
+		t.Errorf("scoreTrack = %.3f, want %.3f with default weights", got, want)
+	}
+}
+
+func TestMinScoreFor(t *testing.T) {
+	conf := config.Get()
+
+	// Without a matrix every combination uses the global threshold
+	if got := minScoreFor(config.QueryFieldsKey(true, true, true, false)); got != conf.Configuration.MinSimilarityScore {
+		t.Errorf("Default threshold = %.3f, want global %.3f", got, conf.Configuration.MinSimilarityScore)
+	}
+
+	// Combinations not in the matrix fall back to the global threshold
+	if got := minScoreFor("song+album"); got != conf.Configuration.MinSimilarityScore {
+		t.Errorf("Unlisted combination threshold = %.3f, want global %.3f", got, conf.Configuration.MinSimilarityScore)
+	}
+}
+
+func TestSplitArtists(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"Adele", []string{"Adele"}},
+		{"Simon & Garfunkel", []string{"Simon", "Garfunkel"}},
+		{"A, B & C", []string{"A", "B", "C"}},
+		{"Calvin Harris feat. Rihanna", []string{"Calvin Harris", "Rihanna"}},
+		{"Lil Nas X", []string{"Lil Nas X"}},
+		{"Rosalía x The Weeknd", []string{"Rosalía", "The Weeknd"}},
+		{"Ed Sheeran and Justin Bieber", []string{"Ed Sheeran", "Justin Bieber"}},
+		{"AC/DC", []string{"AC/DC"}},
+	}
+	for _, tt := range tests {
+		got := splitArtists(tt.input)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitArtists(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestArtistSimilarity_Collaborations(t *testing.T) {
+	tests := []struct {
+		name        string
+		trackArtist string
+		query       string
+		min         float64
+	}{
+		{"query lists extra artists", "Calvin Harris", "Calvin Harris & Rihanna", 0.99},
+		{"track lists extra artists", "Daft Punk, Pharrell Williams & Nile Rodgers", "Pharrell Williams", 0.99},
+		{"different separator and order", "Ed Sheeran & Justin Bieber", "Justin Bieber, Ed Sheeran", 0.99},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := artistSimilarity(tt.trackArtist, tt.query); got < tt.min {
+				t.Errorf("artistSimilarity(%q, %q) = %.3f, want >= %.2f", tt.trackArtist, tt.query, got, tt.min)
+			}
+			if plain := stringSimilarity(tt.trackArtist, tt.query); plain >= tt.min {
+				t.Errorf("plain similarity %.3f already matches; case doesn't exercise splitting", plain)
+			}
+		})
+	}
+
+	if got := artistSimilarity("The Beatles", "Drake & Future"); got > 0.5 {
+		t.Errorf("Unrelated collaboration should still score low, got %.3f", got)
+	}
+}
+
+func TestPickBestTrack_PrefersContentRating(t *testing.T) {
+	tracks := make([]Track, 2)
+	for i, rating := range []string{ContentRatingClean, ContentRatingExplicit} {
+		tracks[i].ID = rating
+		tracks[i].Attributes.Name = "Song"
+		tracks[i].Attributes.ArtistName = "Artist"
+		tracks[i].Attributes.ContentRating = rating
+	}
+
+	for _, preferExplicit := range []bool{true, false} {
+		track, _, _, err := pickBestTrack(tracks, "song artist", "Song", "Artist", "", 0, config.DefaultScoreWeights, preferExplicit, MatchHints{}, MusicAccount{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if track.IsExplicit() != preferExplicit {
+			t.Errorf("preferExplicit=%v picked the %s release", preferExplicit, track.ID)
+		}
+	}
+}
+
+func TestPickBestTrack_MatchHints(t *testing.T) {
+	tracks := make([]Track, 3)
+	for i, isrc := range []string{"GBBKS1500214", "GBBKS1500999", "USUM71900001"} {
+		tracks[i].ID = isrc
+		tracks[i].Attributes.Name = "Hello"
+		tracks[i].Attributes.ArtistName = "Adele"
+		tracks[i].Attributes.ISRC = isrc
+		tracks[i].Attributes.ReleaseDate = fmt.Sprintf("%d-10-23", 2013+i)
+	}
+	tracks[2].Attributes.Name = "Hello (Live)"
+
+	tests := []struct {
+		name  string
+		hints MatchHints
+		want  string
+	}{
+		{"isrc", MatchHints{ISRC: "gbbks1500999"}, "GBBKS1500999"},
+		{"isrc beats name", MatchHints{ISRC: "USUM71900001"}, "USUM71900001"},
+		{"release year", MatchHints{ReleaseYear: 2014}, "GBBKS1500999"},
+		{"no hints", MatchHints{}, "GBBKS1500214"},
+	}
+	for _, tt := range tests {
+		track, _, _, err := pickBestTrack(tracks, "hello adele", "Hello", "Adele", "", 0, config.DefaultScoreWeights, false, tt.hints, MusicAccount{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if track.ID != tt.want {
+			t.Errorf("%s: picked %s, want %s", tt.name, track.ID, tt.want)
+		}
+	}
+}
+
+// quietLogs discards log output for the rest of a benchmark, so log lines don't
+// split the result lines the bench gate parses
+func quietLogs(tb testing.TB) {
+	out := log.StandardLogger().Out
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(out) })
+}
+
+// benchmarkCandidates is a search result page far larger than Apple returns, with
+// versions, collaborations and near-miss titles so every scoring path runs
+func benchmarkCandidates(n int) []Track {
+	names := []string{"Blinding Lights", "Blinding Lights (Live)", "Blinding Lights - Remix", "Blinding Light", "Lights Out (feat. Someone)"}
+	artists := []string{"The Weeknd", "The Weeknd & Rosalía", "Weekend", "The Weeknd feat. Daft Punk", "Someone Else"}
+	albums := []string{"After Hours", "After Hours (Deluxe)", "Live at SoFi Stadium", "Remixes", ""}
+	tracks := make([]Track, n)
+	for i := range tracks {
+		tracks[i].ID = fmt.Sprintf("%d", i)
+		tracks[i].Attributes.Name = names[i%len(names)]
+		tracks[i].Attributes.ArtistName = artists[i/len(names)%len(artists)]
+		tracks[i].Attributes.AlbumName = albums[i/7%len(albums)]
+		tracks[i].Attributes.DurationInMillis = 200000 + (i%40)*500
+		if i%3 == 0 {
+			tracks[i].Attributes.ContentRating = ContentRatingExplicit
+		}
+	}
+	return tracks
+}
+
+func BenchmarkScoreTrack(b *testing.B) {
+	quietLogs(b)
+	tracks := benchmarkCandidates(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := range tracks {
+			scoreTrackWithWeights(&tracks[j], "Blinding Lights", "The Weeknd", "After Hours", config.DefaultScoreWeights)
+		}
+	}
+}
+
+func BenchmarkPickBestTrack(b *testing.B) {
+	quietLogs(b)
+	tracks := benchmarkCandidates(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := pickBestTrack(tracks, "blinding lights the weeknd", "Blinding Lights", "The Weeknd", "After Hours", 0, config.DefaultScoreWeights, true, MatchHints{}, MusicAccount{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package ttml
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"lyrics-api-go/config"
+	"lyrics-api-go/logcolors"
+)
+
+// fetchCanary fetches the canary song with an account's MUT (replaced in tests)
+var fetchCanary = func(account MusicAccount) error {
+	_, err := fetchLyricsTTML(HealthCheckSongID, accountStorefront(account), account)
+	return err
+}
+
+var (
+	// probeStreaks maps a disabled account to its consecutive canary successes
+	probeStreaks   = make(map[string]int)
+	probeStreaksMu sync.Mutex
+)
+
+// StartDisabledAccountProbe canary-checks disabled accounts every
+// DISABLED_PROBE_MINUTES, so an account whose MUT works again (e.g. after
+// a re-login upstream) is re-enabled without a restart
+func StartDisabledAccountProbe() {
+	cfg := config.Get().Configuration
+	interval := time.Duration(cfg.DisabledProbeMinutes) * time.Minute
+	if interval <= 0 {
+		return
+	}
+	required := max(cfg.DisabledProbeSuccesses, 1)
+	log.Infof("%s Probing disabled accounts every %v", logcolors.LogHealthCheck, interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			probeDisabledAccounts(required)
+		}
+	}()
+}
+
+// probeDisabledAccounts runs one canary check per disabled account. An account is
+// re-enabled after required successes in a row (DISABLED_PROBE_SUCCESSES); a 404
+// (MUT still stale) resets the streak, other errors leave it as it was.
+func probeDisabledAccounts(required int) {
+	accountManager := getAccountManager()
+
+	for _, account := range accountManager.getAllAccounts() {
+		if account.MediaUserToken == "" || !accountManager.IsAccountDisabled(account.NameID) {
+			continue
+		}
+		if accountManager.IsAccountQuarantinedByName(account.NameID) {
+			continue
+		}
+
+		err := fetchCanary(account)
+		status := &MUTHealthStatus{AccountName: account.NameID, LastChecked: time.Now()}
+
+		probeStreaksMu.Lock()
+		switch {
+		case err == nil:
+			probeStreaks[account.NameID]++
+		case strings.Contains(err.Error(), "404"):
+			probeStreaks[account.NameID] = 0
+		}
+		streak := probeStreaks[account.NameID]
+		reenable := streak >= required
+		if reenable {
+			delete(probeStreaks, account.NameID)
+		}
+		probeStreaksMu.Unlock()
+
+		if err != nil {
+			status.LastError = err.Error()
+			log.Debugf("%s Disabled account %s: canary failed (%d/%d) - %v",
+				logcolors.LogHealthCheck, logcolors.Account(account.NameID), streak, required, err)
+		} else {
+			log.Infof("%s Disabled account %s: canary succeeded (%d/%d)",
+				logcolors.LogHealthCheck, logcolors.Account(account.NameID), streak, required)
+		}
+
+		if reenable {
+			status.Healthy = true
+			accountManager.EnableAccount(account)
+		} else {
+			status.ProbeStreak = streak
+		}
+		healthMu.Lock()
+		healthStatuses[account.NameID] = status
+		healthMu.Unlock()
+	}
+}
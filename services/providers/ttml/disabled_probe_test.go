@@ -0,0 +1,77 @@
+package ttml
+
+import (
+	"errors"
+	"testing"
+
+	"lyrics-api-go/services/notifier"
+)
+
+func TestProbeDisabledAccounts_ReenablesAfterConsecutiveSuccesses(t *testing.T) {
+	stale := MusicAccount{NameID: "ProbeStale", MediaUserToken: "stale_mut", Storefront: "us"}
+	healthy := MusicAccount{NameID: "ProbeHealthy", MediaUserToken: "healthy_mut", Storefront: "us"}
+	testManager := &AccountManager{
+		accounts:       []MusicAccount{stale, healthy},
+		quarantineTime: make(map[int]int64),
+	}
+	originalManager := accountPool.Load()
+	accountPool.Store(testManager)
+	defer func() { accountPool.Store(originalManager) }()
+
+	disabledMutex.Lock()
+	originalDisabled := disabledAccounts
+	disabledAccounts = map[string]bool{stale.NameID: true}
+	disabledMutex.Unlock()
+	defer func() {
+		disabledMutex.Lock()
+		disabledAccounts = originalDisabled
+		disabledMutex.Unlock()
+	}()
+
+	var probed []string
+	results := []error{nil, errors.New("HTTP 404: lyrics not found"), nil, errors.New("HTTP 429"), nil}
+	originalFetch := fetchCanary
+	fetchCanary = func(account MusicAccount) error {
+		probed = append(probed, account.NameID)
+		err := results[0]
+		results = results[1:]
+		return err
+	}
+	defer func() { fetchCanary = originalFetch }()
+
+	bus := notifier.GetEventBus()
+	rec := &storefrontRecorder{}
+	unsubscribe := bus.Register(rec, notifier.EventAccountEnabled)
+	defer unsubscribe()
+
+	// success, 404 (reset), success, 429 (no change): still disabled at 1/2
+	for range 4 {
+		probeDisabledAccounts(2)
+	}
+	if !testManager.IsAccountDisabled(stale.NameID) {
+		t.Fatal("Account should stay disabled until two successes in a row")
+	}
+	if got := GetHealthStatuses()[stale.NameID]; got == nil || got.ProbeStreak != 1 || got.Healthy {
+		t.Errorf("Expected a 1-success streak in the health status, got %+v", got)
+	}
+
+	probeDisabledAccounts(2)
+	bus.Drain()
+	if testManager.IsAccountDisabled(stale.NameID) {
+		t.Error("Account should be re-enabled after two successes in a row")
+	}
+	if len(rec.events) != 1 || rec.events[0].Data["account"] == "" {
+		t.Errorf("Expected one account_enabled event, got %+v", rec.events)
+	}
+	for _, name := range probed {
+		if name != stale.NameID {
+			t.Errorf("Only disabled accounts should be probed, got %s", name)
+		}
+	}
+
+	// Once enabled, the probe leaves the account alone
+	probeDisabledAccounts(2)
+	if len(probed) != 5 {
+		t.Errorf("Expected 5 probes, got %d", len(probed))
+	}
+}
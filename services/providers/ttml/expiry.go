@@ -0,0 +1,95 @@
+package ttml
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"lyrics-api-go/config"
+	"lyrics-api-go/logcolors"
+)
+
+// Account states, in order of preference for selection
+const (
+	AccountStateHealthy     = "healthy"
+	AccountStateExpiring    = "expiring"    // Token expires within the warning window; used only when nothing healthy is free
+	AccountStateQuarantined = "quarantined" // Rate limited, temporary
+	AccountStateDisabled    = "disabled"    // Stale MUT, until the canary succeeds again
+)
+
+var (
+	// accountTokenExpiry maps account name -> token expiry, fed by the token monitor.
+	// Accounts with an opaque (non-JWT) MUT have no entry and are never "expiring".
+	accountTokenExpiry = make(map[string]time.Time)
+	expiringAccounts   = make(map[string]bool) // As of the last refresh, to log each transition once
+	accountExpiryMu    sync.RWMutex
+)
+
+// expiringWindow is how far ahead of expiry an account is deprioritized
+func expiringWindow() time.Duration {
+	return time.Duration(config.Get().Configuration.AccountExpiringWindowHours) * time.Hour
+}
+
+// refreshAccountTokenExpiries re-reads token expiry for every account. MUTs that
+// are JWTs carry an exp claim; opaque MUTs are skipped.
+func (m *AccountManager) refreshAccountTokenExpiries() {
+	if m == nil {
+		return
+	}
+	expiries := make(map[string]time.Time, len(m.accounts))
+	for _, acc := range m.accounts {
+		if acc.MediaUserToken == "" {
+			continue
+		}
+		if expiry, err := parseJWTExpiry(acc.MediaUserToken); err == nil {
+			expiries[acc.NameID] = expiry
+		}
+	}
+
+	window := expiringWindow()
+	accountExpiryMu.Lock()
+	expiring := make(map[string]bool)
+	for name, expiry := range expiries {
+		if clk.Until(expiry) >= window {
+			continue
+		}
+		expiring[name] = true
+		if !expiringAccounts[name] {
+			log.Warnf("%s Account %s token expires in %v, deprioritizing",
+				logcolors.LogQuarantine, logcolors.Account(name), clk.Until(expiry).Round(time.Minute))
+		}
+	}
+	accountTokenExpiry = expiries
+	expiringAccounts = expiring
+	accountExpiryMu.Unlock()
+}
+
+// GetAccountTokenExpiry returns the known token expiry for an account
+func GetAccountTokenExpiry(nameID string) (time.Time, bool) {
+	accountExpiryMu.RLock()
+	defer accountExpiryMu.RUnlock()
+	expiry, ok := accountTokenExpiry[nameID]
+	return expiry, ok
+}
+
+// IsAccountExpiring reports whether an account's token expires within the warning window
+func IsAccountExpiring(nameID string) bool {
+	expiry, ok := GetAccountTokenExpiry(nameID)
+	return ok && clk.Until(expiry) < expiringWindow()
+}
+
+// GetAccountState returns the selection state of an account: disabled, quarantined,
+// expiring or healthy (most to least severe)
+func GetAccountState(nameID string) string {
+	accountManager := getAccountManager()
+	switch {
+	case accountManager.IsAccountDisabled(nameID):
+		return AccountStateDisabled
+	case accountManager.IsAccountQuarantinedByName(nameID):
+		return AccountStateQuarantined
+	case IsAccountExpiring(nameID):
+		return AccountStateExpiring
+	}
+	return AccountStateHealthy
+}
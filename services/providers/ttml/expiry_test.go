@@ -0,0 +1,75 @@
+package ttml
+
+import (
+	"lyrics-api-go/internal/clocktest"
+	"testing"
+	"time"
+)
+
+func TestRefreshAccountTokenExpiries(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	savedClock := clk
+	clk = fake
+	defer func() { clk = savedClock }()
+	defer func() {
+		accountTokenExpiry = make(map[string]time.Time)
+		expiringAccounts = make(map[string]bool)
+	}()
+
+	manager := &AccountManager{
+		accounts: []MusicAccount{
+			{NameID: "Soon", MediaUserToken: createTestJWT(fake.Now().Add(time.Hour))},
+			{NameID: "Later", MediaUserToken: createTestJWT(fake.Now().Add(30 * 24 * time.Hour))},
+			{NameID: "Opaque", MediaUserToken: "opaque-mut"},
+		},
+		quarantineTime: make(map[int]int64),
+	}
+	manager.refreshAccountTokenExpiries()
+
+	if !IsAccountExpiring("Soon") {
+		t.Error("Expected Soon to be expiring")
+	}
+	if IsAccountExpiring("Later") {
+		t.Error("Expected Later not to be expiring yet")
+	}
+	if _, ok := GetAccountTokenExpiry("Opaque"); ok {
+		t.Error("Expected no expiry for an opaque MUT")
+	}
+
+	// Later enters the window as time passes
+	fake.Advance(30*24*time.Hour - expiringWindow() + time.Minute)
+	manager.refreshAccountTokenExpiries()
+	if !IsAccountExpiring("Later") {
+		t.Error("Expected Later to be expiring once inside the window")
+	}
+}
+
+func TestAccountManager_GetNextAccount_SkipsExpiring(t *testing.T) {
+	defer func() { accountTokenExpiry = make(map[string]time.Time) }()
+
+	manager := &AccountManager{
+		accounts: []MusicAccount{
+			{NameID: "Account1", MediaUserToken: "mut1"},
+			{NameID: "Account2", MediaUserToken: "mut2"},
+		},
+		quarantineTime: make(map[int]int64),
+	}
+	accountExpiryMu.Lock()
+	accountTokenExpiry = map[string]time.Time{"Account1": clk.Now().Add(time.Minute)}
+	accountExpiryMu.Unlock()
+
+	for i := 0; i < 4; i++ {
+		if acc := manager.getNextAccount(); acc.NameID != "Account2" {
+			t.Errorf("Iteration %d: expected Account2, got %q", i, acc.NameID)
+		}
+	}
+	if acc := manager.healthiestAccount(); acc.NameID != "Account2" {
+		t.Errorf("Expected probe to use Account2, got %q", acc.NameID)
+	}
+
+	// With the healthy account quarantined, the expiring one is still used
+	manager.quarantineTime[1] = clk.Now().Add(time.Hour).Unix()
+	if acc := manager.getNextAccount(); acc.NameID != "Account1" {
+		t.Errorf("Expected fallback to expiring Account1, got %q", acc.NameID)
+	}
+}
@@ -26,6 +26,7 @@ type MUTHealthStatus struct {
 	Healthy     bool      `json:"healthy"`
 	LastChecked time.Time `json:"last_checked"`
 	LastError   string    `json:"last_error,omitempty"`
+	ProbeStreak int       `json:"probe_streak,omitempty"` // Consecutive canary successes of a disabled account
 }
 
 var (
@@ -44,7 +45,7 @@ func CheckMUTHealth(account MusicAccount) *MUTHealthStatus {
 	}
 
 	// Attempt to fetch lyrics for canary song
-	_, err := fetchLyricsTTML(HealthCheckSongID, account.Storefront, account)
+	err := fetchCanary(account)
 
 	if err == nil {
 		status.Healthy = true
@@ -58,7 +59,7 @@ func CheckMUTHealth(account MusicAccount) *MUTHealthStatus {
 			log.Warnf("%s Account %s: STALE MUT (404 on canary) - %v", logcolors.LogHealthCheck, logcolors.Account(account.NameID), err)
 
 			// Permanently disable this account
-			accountManager.DisableAccount(account)
+			getAccountManager().DisableAccount(account)
 		} else {
 			// 429, 401, network errors don't mean the MUT is stale
 			status.Healthy = true
@@ -77,9 +78,7 @@ func CheckMUTHealth(account MusicAccount) *MUTHealthStatus {
 // Skips out-of-service accounts (empty MUT), quarantined accounts (rate limited),
 // and already disabled accounts (stale MUT detected previously).
 func CheckAllMUTHealth() []*MUTHealthStatus {
-	if accountManager == nil {
-		initAccountManager()
-	}
+	accountManager := getAccountManager()
 
 	accounts := accountManager.getAllAccounts()
 	results := make([]*MUTHealthStatus, 0, len(accounts))
@@ -55,9 +55,9 @@ func TestCheckAllMUTHealth_SkipsQuarantined(t *testing.T) {
 	}
 
 	// Store original and replace
-	originalManager := accountManager
-	accountManager = testManager
-	defer func() { accountManager = originalManager }()
+	originalManager := accountPool.Load()
+	accountPool.Store(testManager)
+	defer func() { accountPool.Store(originalManager) }()
 
 	// Quarantine Account1
 	testManager.quarantineTime[0] = time.Now().Add(5 * time.Minute).Unix()
@@ -94,9 +94,9 @@ func TestCheckAllMUTHealth_SkipsDisabled(t *testing.T) {
 		quarantineTime: make(map[int]int64),
 	}
 
-	originalManager := accountManager
-	accountManager = testManager
-	defer func() { accountManager = originalManager }()
+	originalManager := accountPool.Load()
+	accountPool.Store(testManager)
+	defer func() { accountPool.Store(originalManager) }()
 
 	// Reset and setup disabled accounts
 	disabledMutex.Lock()
@@ -130,9 +130,9 @@ func TestCheckAllMUTHealth_SkipsEmptyMUT(t *testing.T) {
 		quarantineTime: make(map[int]int64),
 	}
 
-	originalManager := accountManager
-	accountManager = testManager
-	defer func() { accountManager = originalManager }()
+	originalManager := accountPool.Load()
+	accountPool.Store(testManager)
+	defer func() { accountPool.Store(originalManager) }()
 
 	// Reset disabled accounts
 	disabledMutex.Lock()
@@ -218,9 +218,9 @@ func TestHealthCheck_401DoesNotDisable(t *testing.T) {
 		quarantineTime: make(map[int]int64),
 	}
 
-	originalManager := accountManager
-	accountManager = testManager
-	defer func() { accountManager = originalManager }()
+	originalManager := accountPool.Load()
+	accountPool.Store(testManager)
+	defer func() { accountPool.Store(originalManager) }()
 
 	// Reset disabled accounts
 	disabledMutex.Lock()
@@ -259,9 +259,9 @@ func TestHealthCheck_404DisablesAccount(t *testing.T) {
 		quarantineTime: make(map[int]int64),
 	}
 
-	originalManager := accountManager
-	accountManager = testManager
-	defer func() { accountManager = originalManager }()
+	originalManager := accountPool.Load()
+	accountPool.Store(testManager)
+	defer func() { accountPool.Store(originalManager) }()
 
 	// Reset disabled accounts
 	disabledMutex.Lock()
@@ -300,9 +300,9 @@ func TestHealthCheck_429DoesNotDisable(t *testing.T) {
 		quarantineTime: make(map[int]int64),
 	}
 
-	originalManager := accountManager
-	accountManager = testManager
-	defer func() { accountManager = originalManager }()
+	originalManager := accountPool.Load()
+	accountPool.Store(testManager)
+	defer func() { accountPool.Store(originalManager) }()
 
 	// Reset disabled accounts
 	disabledMutex.Lock()
@@ -341,9 +341,9 @@ func TestHealthCheck_NetworkErrorDoesNotDisable(t *testing.T) {
 		quarantineTime: make(map[int]int64),
 	}
 
-	originalManager := accountManager
-	accountManager = testManager
-	defer func() { accountManager = originalManager }()
+	originalManager := accountPool.Load()
+	accountPool.Store(testManager)
+	defer func() { accountPool.Store(originalManager) }()
 
 	// Reset disabled accounts
 	disabledMutex.Lock()
@@ -0,0 +1,52 @@
+package ttml
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MatchHints are identifiers a client may know about the track beyond its name,
+// artist, album and duration. Empty fields are ignored.
+type MatchHints struct {
+	ISRC        string
+	ReleaseYear int
+
+	// Storefront, when an available account is in it, picks that account, so the
+	// search runs against the client's regional catalog
+	Storefront string
+
+	// Trace, when set, receives each step of the lookup (see /debug/replay)
+	Trace *Trace
+}
+
+const (
+	// isrcMatchBonus is added to a candidate with the requested ISRC. The ISRC names
+	// the exact recording, so it outweighs any difference in name scoring.
+	isrcMatchBonus = 0.5
+
+	// releaseYearBonus is added to a candidate released in the requested year. Like
+	// contentRatingBonus it only decides between near-identical candidates, e.g.
+	// an original and its remaster.
+	releaseYearBonus = 0.03
+)
+
+// hintsAdjustment returns the score bonus a candidate earns from hints
+func hintsAdjustment(track *Track, hints MatchHints) float64 {
+	adjust := 0.0
+	if hints.ISRC != "" && strings.EqualFold(track.Attributes.ISRC, hints.ISRC) {
+		adjust += isrcMatchBonus
+	}
+	if hints.ReleaseYear > 0 && releaseYear(track.Attributes.ReleaseDate) == hints.ReleaseYear {
+		adjust += releaseYearBonus
+	}
+	return adjust
+}
+
+// releaseYear returns the year of an ISO 8601 release date, or 0
+func releaseYear(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	year, _ := strconv.Atoi(date[:4])
+	return year
+}
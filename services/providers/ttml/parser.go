@@ -4,13 +4,15 @@ import (
 	"encoding/xml"
 	"fmt"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/logging"
 	"regexp"
 	"strconv"
 	"strings"
-
-	log "github.com/sirupsen/logrus"
 )
 
+// parserLog is tunable separately via PUT /log-level?component=parser
+var parserLog = logging.For(logging.ComponentParser)
+
 // parseTTMLTime parses TTML timestamp to milliseconds
 func parseTTMLTime(timeStr string) (int64, error) {
 	// Format: "0:00:12.34" or "12.34" or "12"
@@ -49,15 +51,84 @@ func parseTTMLTime(timeStr string) (int64, error) {
 	return int64(totalSeconds * 1000), nil
 }
 
+// buildBackgroundVocal describes the background-vocal group formed by syllables[start:].
+// Leading gap syllables (whitespace between the main vocal and the group) are excluded.
+func buildBackgroundVocal(syllables []Syllable, start int, startMs, endMs int64) (BackgroundVocal, bool) {
+	for start < len(syllables) && strings.TrimSpace(syllables[start].Text) == "" {
+		start++
+	}
+	if start >= len(syllables) || startMs == -1 {
+		return BackgroundVocal{}, false
+	}
+
+	var sb strings.Builder
+	for _, syl := range syllables[start:] {
+		sb.WriteString(syl.Text)
+	}
+	text := strings.TrimSpace(sb.String())
+
+	return BackgroundVocal{
+		Text:          text,
+		StartTimeMs:   strconv.FormatInt(startMs, 10),
+		EndTimeMs:     strconv.FormatInt(endMs, 10),
+		SyllableStart: start,
+		SyllableEnd:   len(syllables),
+		Parenthesized: strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")"),
+	}, true
+}
+
+// allBackground reports whether every timed (non-gap) syllable is a background vocal
+func allBackground(syllables []Syllable) bool {
+	timed := 0
+	for _, syl := range syllables {
+		if syl.StartTime == syl.EndTime {
+			continue // Gap text carries no timing of its own
+		}
+		timed++
+		if !syl.IsBackground {
+			return false
+		}
+	}
+	return timed > 0
+}
+
+// ParseLines parses TTML into Lines for callers outside this package
+// Returns: lines, timingType, error
+func ParseLines(ttmlContent string) ([]Line, string, error) {
+	return parseTTMLToLines(ttmlContent)
+}
+
+// ParseLinesWithWarnings is ParseLines that also reports what was skipped to get
+// there: malformed paragraphs, bad timings, etc. The lines are everything that
+// parsed, so a partly broken document still yields its good lines.
+func ParseLinesWithWarnings(ttmlContent string) ([]Line, string, []ParseWarning, error) {
+	warnings := &parseWarnings{}
+	lines, timingType, err := parseTTML(ttmlContent, warnings)
+	return lines, timingType, warnings.list, err
+}
+
 // Parse TTML directly to Lines (handles word-level TTML)
 // Returns: lines, timingType, error
 func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
-	log.Debugf("%s Starting to parse TTML content (length: %d bytes)", logcolors.LogTTMLParser, len(ttmlContent))
+	return parseTTML(ttmlContent, &parseWarnings{})
+}
+
+// parseTTML parses TTML to Lines, counting skipped paragraphs and spans in warnings.
+// A document that isn't valid XML is recovered paragraph by paragraph; it is only
+// an error when nothing could be recovered.
+func parseTTML(ttmlContent string, warnings *parseWarnings) ([]Line, string, error) {
+	parserLog.Debugf("%s Starting to parse TTML content (length: %d bytes)", logcolors.LogTTMLParser, len(ttmlContent))
 
 	var ttml TTML
-	if err := xml.Unmarshal([]byte(ttmlContent), &ttml); err != nil {
-		log.Errorf("%s Failed to unmarshal XML: %v", logcolors.LogTTMLParser, err)
-		return nil, "", fmt.Errorf("failed to parse TTML XML: %v", err)
+	xmlErr := xml.Unmarshal([]byte(ttmlContent), &ttml)
+	if xmlErr != nil {
+		recovered, ok := recoverTTML(ttmlContent, warnings)
+		if !ok {
+			parserLog.Errorf("%s Failed to unmarshal XML: %v", logcolors.LogTTMLParser, xmlErr)
+			return nil, "", fmt.Errorf("failed to parse TTML XML: %v", xmlErr)
+		}
+		parserLog.Warnf("%s Invalid XML (%v), parsing paragraphs one by one", logcolors.LogTTMLParser, xmlErr)
+		ttml = *recovered
 	}
 
 	// Check both timing attributes (regular and itunes namespace)
@@ -68,24 +139,24 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 	if timingType == "" {
 		timingType = "line" // Default to line if not specified
 	}
-	log.Debugf("%s Timing type: %s", logcolors.LogTTMLParser, timingType)
+	parserLog.Debugf("%s Timing type: %s", logcolors.LogTTMLParser, timingType)
 
 	agentMap := make(map[string]string)
 	for _, agent := range ttml.Head.Metadata.Agents {
 		agentMap[agent.ID] = agent.Type
 	}
-	log.Debugf("%s Found %d agents in metadata", logcolors.LogTTMLParser, len(agentMap))
+	parserLog.Debugf("%s Found %d agents in metadata", logcolors.LogTTMLParser, len(agentMap))
 
-	log.Debugf("%s Successfully parsed XML structure", logcolors.LogTTMLParser)
-	log.Debugf("%s Number of div sections found: %d", logcolors.LogTTMLParser, len(ttml.Body.Divs))
+	parserLog.Debugf("%s Successfully parsed XML structure", logcolors.LogTTMLParser)
+	parserLog.Debugf("%s Number of div sections found: %d", logcolors.LogTTMLParser, len(ttml.Body.Divs))
 
 	var lines []Line
 
 	// Handle unsynced lyrics (timing="none")
 	if timingType == "none" {
-		log.Debugf("%s Processing unsynced lyrics", logcolors.LogTTMLParser)
+		parserLog.Debugf("%s Processing unsynced lyrics", logcolors.LogTTMLParser)
 		for divIdx, div := range ttml.Body.Divs {
-			log.Debugf("%s Processing div %d with %d paragraphs", logcolors.LogTTMLParser, divIdx, len(div.Paragraphs))
+			parserLog.Debugf("%s Processing div %d with %d paragraphs", logcolors.LogTTMLParser, divIdx, len(div.Paragraphs))
 
 			for i, para := range div.Paragraphs {
 				// Remove HTML tags from paragraph text
@@ -94,7 +165,7 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 				lineText = strings.TrimSpace(lineText)
 
 				if lineText == "" {
-					log.Debugf("%s Skipping empty paragraph %d", logcolors.LogTTMLParser, i)
+					parserLog.Debugf("%s Skipping empty paragraph %d", logcolors.LogTTMLParser, i)
 					continue
 				}
 
@@ -105,22 +176,26 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 					DurationMs:  "0",
 					Words:       lineText,
 					Syllables:   []Syllable{}, // Empty for unsynced lyrics
+					Section:     div.SongPart,
 				}
 
-				log.Debugf("%s Created unsynced line %d: '%s'", logcolors.LogTTMLParser, i, lineText)
+				parserLog.Debugf("%s Created unsynced line %d: '%s'", logcolors.LogTTMLParser, i, lineText)
 				lines = append(lines, line)
 			}
 		}
-		log.Infof("%s Successfully extracted %d unsynced lines from TTML", logcolors.LogTTMLParser, len(lines))
+		if xmlErr != nil && len(lines) == 0 {
+			return nil, "", fmt.Errorf("failed to parse TTML XML: %v", xmlErr)
+		}
+		parserLog.Infof("%s Successfully extracted %d unsynced lines from TTML", logcolors.LogTTMLParser, len(lines))
 		return lines, timingType, nil
 	}
 
 	// Handle synced lyrics (word-level or line-level)
 	for divIdx, div := range ttml.Body.Divs {
-		log.Debugf("%s Processing div %d (songPart: %s) with %d paragraphs", logcolors.LogTTMLParser, divIdx, div.SongPart, len(div.Paragraphs))
+		parserLog.Debugf("%s Processing div %d (songPart: %s) with %d paragraphs", logcolors.LogTTMLParser, divIdx, div.SongPart, len(div.Paragraphs))
 
 		for i, para := range div.Paragraphs {
-			log.Debugf("%s   Processing paragraph %d: begin=%s, end=%s, spans=%d", logcolors.LogTTMLParser, i, para.Begin, para.End, len(para.Spans))
+			parserLog.Debugf("%s   Processing paragraph %d: begin=%s, end=%s, spans=%d", logcolors.LogTTMLParser, i, para.Begin, para.End, len(para.Spans))
 
 			if len(para.Spans) > 0 {
 				// Extract full paragraph text (with HTML tags removed)
@@ -132,11 +207,14 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 				var earliestTime int64 = -1
 				var latestEndTime int64 = 0
 				var wordsIndex int = 0
+				var backgroundVocals []BackgroundVocal
 
 				for j, span := range para.Spans {
 					// Check if this span has nested spans (background vocals structure)
 					if len(span.NestedSpans) > 0 && span.Role == "x-bg" {
-						// Process nested spans with background flag
+						// Process nested spans with background flag, remembering the group boundaries
+						groupStart := len(syllables)
+						var groupStartMs, groupEndMs int64 = -1, 0
 						for k, nestedSpan := range span.NestedSpans {
 							syllableText := strings.TrimSpace(nestedSpan.Text)
 							if syllableText == "" {
@@ -145,13 +223,15 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 
 							startMs, err := parseTTMLTime(nestedSpan.Begin)
 							if err != nil {
-								log.Warnf("%s Failed to parse nested span start time %s: %v", logcolors.LogTTMLParser, nestedSpan.Begin, err)
+								parserLog.Warnf("%s Failed to parse nested span start time %s: %v", logcolors.LogTTMLParser, nestedSpan.Begin, err)
+								warnings.add(warnInvalidSpanTime)
 								continue
 							}
 
 							endMs, err := parseTTMLTime(nestedSpan.End)
 							if err != nil {
-								log.Warnf("%s Failed to parse nested span end time %s: %v", logcolors.LogTTMLParser, nestedSpan.End, err)
+								parserLog.Warnf("%s Failed to parse nested span end time %s: %v", logcolors.LogTTMLParser, nestedSpan.End, err)
+								warnings.add(warnInvalidSpanTime)
 								continue
 							}
 
@@ -161,11 +241,18 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 							if endMs > latestEndTime {
 								latestEndTime = endMs
 							}
+							if groupStartMs == -1 || startMs < groupStartMs {
+								groupStartMs = startMs
+							}
+							if endMs > groupEndMs {
+								groupEndMs = endMs
+							}
 
 							// Find where this syllable appears in the full text
 							nextWordIndex := strings.Index(fullText[wordsIndex:], syllableText)
 							if nextWordIndex < 0 {
-								log.Errorf("%s Error parsing timings in paragraph %d, span %d, nested %d: syllable '%s' not found in remaining text starting at index %d", logcolors.LogTTMLParser, i, j, k, syllableText, wordsIndex)
+								parserLog.Errorf("%s Error parsing timings in paragraph %d, span %d, nested %d: syllable '%s' not found in remaining text starting at index %d", logcolors.LogTTMLParser, i, j, k, syllableText, wordsIndex)
+								warnings.add(warnSyllableNotFound)
 								break
 							}
 							nextWordIndex += wordsIndex // Convert relative index to absolute
@@ -173,7 +260,7 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 							// If there's gap text before this syllable, add it as zero-duration
 							if nextWordIndex-wordsIndex > 0 {
 								extraText := fullText[wordsIndex:nextWordIndex]
-								log.Debugf("%s   Found gap text: '%s'", logcolors.LogTTMLParser, extraText)
+								parserLog.Debugf("%s   Found gap text: '%s'", logcolors.LogTTMLParser, extraText)
 
 								// Use timing and background status from first syllable or current if first
 								var gapStartTime int64
@@ -198,7 +285,7 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 								syllables = append(syllables, gapSyllable)
 								wordsIndex = nextWordIndex
 							} else {
-								log.Debugf("%s   No gap text before syllable", logcolors.LogTTMLParser)
+								parserLog.Debugf("%s   No gap text before syllable", logcolors.LogTTMLParser)
 							}
 
 							// Add the actual syllable with background flag
@@ -211,7 +298,10 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 							syllables = append(syllables, syllable)
 							wordsIndex += len(syllableText)
 
-							log.Debugf("%s   Nested span %d.%d: '%s' [%s - %s] bg=true", logcolors.LogTTMLParser, j, k, syllableText, nestedSpan.Begin, nestedSpan.End)
+							parserLog.Debugf("%s   Nested span %d.%d: '%s' [%s - %s] bg=true", logcolors.LogTTMLParser, j, k, syllableText, nestedSpan.Begin, nestedSpan.End)
+						}
+						if group, ok := buildBackgroundVocal(syllables, groupStart, groupStartMs, groupEndMs); ok {
+							backgroundVocals = append(backgroundVocals, group)
 						}
 						continue
 					}
@@ -224,13 +314,15 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 
 					startMs, err := parseTTMLTime(span.Begin)
 					if err != nil {
-						log.Warnf("%s Failed to parse span start time %s: %v", logcolors.LogTTMLParser, span.Begin, err)
+						parserLog.Warnf("%s Failed to parse span start time %s: %v", logcolors.LogTTMLParser, span.Begin, err)
+						warnings.add(warnInvalidSpanTime)
 						continue
 					}
 
 					endMs, err := parseTTMLTime(span.End)
 					if err != nil {
-						log.Warnf("%s Failed to parse span end time %s: %v", logcolors.LogTTMLParser, span.End, err)
+						parserLog.Warnf("%s Failed to parse span end time %s: %v", logcolors.LogTTMLParser, span.End, err)
+						warnings.add(warnInvalidSpanTime)
 						continue
 					}
 
@@ -247,7 +339,8 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 					// Find where this syllable appears in the full text
 					nextWordIndex := strings.Index(fullText[wordsIndex:], syllableText)
 					if nextWordIndex < 0 {
-						log.Errorf("%s Error parsing timings in paragraph %d, span %d: syllable '%s' not found in remaining text starting at index %d", logcolors.LogTTMLParser, i, j, syllableText, wordsIndex)
+						parserLog.Errorf("%s Error parsing timings in paragraph %d, span %d: syllable '%s' not found in remaining text starting at index %d", logcolors.LogTTMLParser, i, j, syllableText, wordsIndex)
+						warnings.add(warnSyllableNotFound)
 						break
 					}
 					nextWordIndex += wordsIndex // Convert relative index to absolute
@@ -255,7 +348,7 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 					// If there's gap text before this syllable, add it as zero-duration
 					if nextWordIndex-wordsIndex > 0 {
 						extraText := fullText[wordsIndex:nextWordIndex]
-						log.Debugf("%s   Found gap text: '%s'", logcolors.LogTTMLParser, extraText)
+						parserLog.Debugf("%s   Found gap text: '%s'", logcolors.LogTTMLParser, extraText)
 
 						// Use timing and background status from first syllable or current if first
 						var gapStartTime int64
@@ -280,7 +373,7 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 						syllables = append(syllables, gapSyllable)
 						wordsIndex = nextWordIndex
 					} else {
-						log.Debugf("%s   No gap text before syllable", logcolors.LogTTMLParser)
+						parserLog.Debugf("%s   No gap text before syllable", logcolors.LogTTMLParser)
 					}
 
 					// Add the actual syllable
@@ -293,11 +386,12 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 					syllables = append(syllables, syllable)
 					wordsIndex += len(syllableText)
 
-					log.Debugf("%s   Span %d: '%s' [%s - %s] role='%s' bg=%v", logcolors.LogTTMLParser, j, syllableText, span.Begin, span.End, span.Role, isBackground)
+					parserLog.Debugf("%s   Span %d: '%s' [%s - %s] role='%s' bg=%v", logcolors.LogTTMLParser, j, syllableText, span.Begin, span.End, span.Role, isBackground)
 				}
 
 				if len(syllables) == 0 {
-					log.Warnf("%s Skipping paragraph %d - no valid syllables extracted", logcolors.LogTTMLParser, i)
+					parserLog.Warnf("%s Skipping paragraph %d - no valid syllables extracted", logcolors.LogTTMLParser, i)
+					warnings.add(warnNoSyllables)
 					continue
 				}
 
@@ -311,15 +405,18 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 				}
 
 				line := Line{
-					StartTimeMs: strconv.FormatInt(earliestTime, 10),
-					EndTimeMs:   strconv.FormatInt(latestEndTime, 10),
-					DurationMs:  strconv.FormatInt(duration, 10),
-					Words:       fullText,
-					Syllables:   syllables,
-					Agent:       agent,
+					StartTimeMs:      strconv.FormatInt(earliestTime, 10),
+					EndTimeMs:        strconv.FormatInt(latestEndTime, 10),
+					DurationMs:       strconv.FormatInt(duration, 10),
+					Words:            fullText,
+					Syllables:        syllables,
+					Agent:            agent,
+					Section:          div.SongPart,
+					IsBackground:     allBackground(syllables),
+					BackgroundVocals: backgroundVocals,
 				}
 
-				log.Debugf("%s   Created line %d: startMs=%s, endMs=%s, words='%s', syllables=%d, agent=%s", logcolors.LogTTMLParser, i, line.StartTimeMs, line.EndTimeMs, line.Words, len(line.Syllables), agent)
+				parserLog.Debugf("%s   Created line %d: startMs=%s, endMs=%s, words='%s', syllables=%d, agent=%s", logcolors.LogTTMLParser, i, line.StartTimeMs, line.EndTimeMs, line.Words, len(line.Syllables), agent)
 				lines = append(lines, line)
 			} else {
 				// Line-level TTML without spans
@@ -328,19 +425,21 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 				lineText = strings.TrimSpace(lineText)
 
 				if lineText == "" {
-					log.Warnf("%s Skipping paragraph %d - empty text", logcolors.LogTTMLParser, i)
+					parserLog.Warnf("%s Skipping paragraph %d - empty text", logcolors.LogTTMLParser, i)
 					continue
 				}
 
 				startMs, err := parseTTMLTime(para.Begin)
 				if err != nil {
-					log.Warnf("%s Failed to parse line start time %s: %v", logcolors.LogTTMLParser, para.Begin, err)
+					parserLog.Warnf("%s Failed to parse line start time %s: %v", logcolors.LogTTMLParser, para.Begin, err)
+					warnings.add(warnInvalidLineTime)
 					continue
 				}
 
 				endMs, err := parseTTMLTime(para.End)
 				if err != nil {
-					log.Warnf("%s Failed to parse line end time %s: %v", logcolors.LogTTMLParser, para.End, err)
+					parserLog.Warnf("%s Failed to parse line end time %s: %v", logcolors.LogTTMLParser, para.End, err)
+					warnings.add(warnInvalidLineTime)
 					continue
 				}
 
@@ -360,14 +459,18 @@ func parseTTMLToLines(ttmlContent string) ([]Line, string, error) {
 					Words:       lineText,
 					Syllables:   []Syllable{}, // Empty for line-level lyrics
 					Agent:       agent,
+					Section:     div.SongPart,
 				}
 
-				log.Debugf("%s   Created line-level line %d: startMs=%s, endMs=%s, words='%s', agent=%s", logcolors.LogTTMLParser, i, line.StartTimeMs, line.EndTimeMs, line.Words, agent)
+				parserLog.Debugf("%s   Created line-level line %d: startMs=%s, endMs=%s, words='%s', agent=%s", logcolors.LogTTMLParser, i, line.StartTimeMs, line.EndTimeMs, line.Words, agent)
 				lines = append(lines, line)
 			}
 		}
 	}
 
-	log.Infof("%s Successfully extracted %d lines from TTML (type: %s)", logcolors.LogTTMLParser, len(lines), timingType)
+	if xmlErr != nil && len(lines) == 0 {
+		return nil, "", fmt.Errorf("failed to parse TTML XML: %v", xmlErr)
+	}
+	parserLog.Infof("%s Successfully extracted %d lines from TTML (type: %s)", logcolors.LogTTMLParser, len(lines), timingType)
 	return lines, timingType, nil
 }
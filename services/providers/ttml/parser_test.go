@@ -1,6 +1,8 @@
 package ttml
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -483,3 +485,183 @@ func TestParseTTMLToLines_DefaultTimingType(t *testing.T) {
 		t.Errorf("Expected default timing type 'line', got %q", timingType)
 	}
 }
+
+func TestParseTTMLToLines_SongPartSection(t *testing.T) {
+	ttml := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" timing="word">
+	<body>
+		<div songPart="Verse">
+			<p begin="0:00:01.000" end="0:00:02.000"><span begin="0:00:01.000" end="0:00:02.000">Verse</span></p>
+		</div>
+		<div songPart="Chorus">
+			<p begin="0:00:03.000" end="0:00:04.000">Chorus line</p>
+		</div>
+		<div>
+			<p begin="0:00:05.000" end="0:00:06.000">Unlabeled</p>
+		</div>
+	</body>
+</tt>`
+
+	lines, _, err := parseTTMLToLines(ttml)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(lines))
+	}
+
+	expected := []string{"Verse", "Chorus", ""}
+	for i, section := range expected {
+		if lines[i].Section != section {
+			t.Errorf("Line %d: expected section %q, got %q", i, section, lines[i].Section)
+		}
+	}
+}
+
+func TestParseTTMLToLines_BackgroundVocalGroups(t *testing.T) {
+	ttml := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" timing="word">
+	<body>
+		<div>
+			<p begin="0:00:01.000" end="0:00:04.000"><span begin="0:00:01.000" end="0:00:02.000">Main</span> <span role="x-bg"><span begin="0:00:02.000" end="0:00:03.000">(Ooh</span> <span begin="0:00:03.000" end="0:00:04.000">yeah)</span></span></p>
+			<p begin="0:00:05.000" end="0:00:06.000"><span role="x-bg"><span begin="0:00:05.000" end="0:00:06.000">(Echo)</span></span></p>
+		</div>
+	</body>
+</tt>`
+
+	lines, _, err := parseTTMLToLines(ttml)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+
+	mixed := lines[0]
+	if mixed.IsBackground {
+		t.Error("Expected line with main vocals to not be marked background")
+	}
+	if len(mixed.BackgroundVocals) != 1 {
+		t.Fatalf("Expected 1 background vocal group, got %d", len(mixed.BackgroundVocals))
+	}
+	group := mixed.BackgroundVocals[0]
+	if group.Text != "(Ooh yeah)" {
+		t.Errorf("Expected group text %q, got %q", "(Ooh yeah)", group.Text)
+	}
+	if !group.Parenthesized {
+		t.Error("Expected group to be parenthesized")
+	}
+	if group.StartTimeMs != "2000" || group.EndTimeMs != "4000" {
+		t.Errorf("Expected group timing 2000-4000, got %s-%s", group.StartTimeMs, group.EndTimeMs)
+	}
+	if got := mixed.Syllables[group.SyllableStart].Text; got != "(Ooh" {
+		t.Errorf("Expected group to start at syllable '(Ooh', got %q", got)
+	}
+	if group.SyllableEnd != len(mixed.Syllables) {
+		t.Errorf("Expected group to end at the last syllable, got %d of %d", group.SyllableEnd, len(mixed.Syllables))
+	}
+
+	if !lines[1].IsBackground {
+		t.Error("Expected line made only of background vocals to be marked background")
+	}
+}
+
+// largeWordLevelTTML builds a word-timed document the size of a long track: lines of
+// words spans, alternating agents and a background vocal on every fourth line
+func largeWordLevelTTML(lines, words int) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" xmlns:itunes="http://ttml-endpoint.com/" itunes:timing="word">
+	<head><metadata><ttm:agent type="person" id="v1"/><ttm:agent type="person" id="v2"/></metadata></head>
+	<body>
+		<div>
+`)
+	ms := 1000
+	stamp := func(ms int) string {
+		return fmt.Sprintf("%d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+	}
+	for l := 0; l < lines; l++ {
+		start := ms
+		var spans strings.Builder
+		for w := 0; w < words; w++ {
+			if w > 0 {
+				spans.WriteString(" ")
+			}
+			fmt.Fprintf(&spans, `<span begin="%s" end="%s">word%d</span>`, stamp(ms), stamp(ms+250), w)
+			ms += 300
+		}
+		if l%4 == 3 {
+			fmt.Fprintf(&spans, `<span ttm:role="x-bg"><span begin="%s" end="%s">(echo)</span></span>`, stamp(ms), stamp(ms+400))
+			ms += 500
+		}
+		fmt.Fprintf(&b, "\t\t\t<p begin=\"%s\" end=\"%s\" ttm:agent=\"v%d\">%s</p>\n", stamp(start), stamp(ms), l%2+1, spans.String())
+		ms += 200
+	}
+	b.WriteString("\t\t</div>\n\t</body>\n</tt>")
+	return b.String()
+}
+
+func BenchmarkParseTTMLToLines_WordLevel(b *testing.B) {
+	quietLogs(b)
+	ttml := largeWordLevelTTML(120, 10)
+	b.SetBytes(int64(len(ttml)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, _, err := parseTTMLToLines(ttml); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLargeWordLevelTTML_Parses(t *testing.T) {
+	lines, timingType, err := parseTTMLToLines(largeWordLevelTTML(8, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timingType != "word" || len(lines) != 8 || len(lines[0].Syllables) < 5 {
+		t.Fatalf("Benchmark fixture no longer exercises word-level parsing: %s, %d lines", timingType, len(lines))
+	}
+}
+
+func TestParseLinesWithWarnings_SkipsMalformedParagraph(t *testing.T) {
+	// The second paragraph has an unclosed span, which breaks the whole document
+	ttml := `<tt xmlns="http://www.w3.org/ns/ttml" timing="Line">
+	<body>
+		<div songPart="Verse">
+			<p begin="1.0" end="2.0">First line</p>
+			<p begin="2.0" end="3.0">Broken <span>line</p>
+			<p begin="x" end="4.0">Bad timing</p>
+			<p begin="4.0" end="5.0">Last line</p>
+		</div>
+	</body>
+</tt>`
+
+	lines, timingType, warnings, err := ParseLinesWithWarnings(ttml)
+	if err != nil {
+		t.Fatalf("Expected the good lines, got error: %v", err)
+	}
+	if timingType != "line" || len(lines) != 2 || lines[0].Words != "First line" || lines[1].Words != "Last line" {
+		t.Fatalf("Unexpected lines (%s): %+v", timingType, lines)
+	}
+	if lines[0].Section != "Verse" {
+		t.Errorf("Expected the recovered div's section, got %q", lines[0].Section)
+	}
+	want := []ParseWarning{{warnInvalidXML, 1}, {warnMalformedParagraph, 1}, {warnInvalidLineTime, 1}}
+	if len(warnings) != len(want) {
+		t.Fatalf("warnings = %+v, want %+v", warnings, want)
+	}
+	for i := range want {
+		if warnings[i] != want[i] {
+			t.Errorf("warnings[%d] = %+v, want %+v", i, warnings[i], want[i])
+		}
+	}
+}
+
+func TestParseLinesWithWarnings_ValidDocumentHasNone(t *testing.T) {
+	_, _, warnings, err := ParseLinesWithWarnings(largeWordLevelTTML(2, 3))
+	if err != nil || len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %+v (%v)", warnings, err)
+	}
+}
@@ -0,0 +1,100 @@
+package ttml
+
+import (
+	"encoding/xml"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/stats"
+	"regexp"
+)
+
+// Reasons the parser skips part of a document, as reported in ParseWarning
+const (
+	warnInvalidXML         = "invalid document XML, recovered per paragraph"
+	warnMalformedParagraph = "malformed paragraph"
+	warnInvalidSpanTime    = "invalid span time"
+	warnSyllableNotFound   = "syllable not found in paragraph text"
+	warnNoSyllables        = "paragraph without timed syllables"
+	warnInvalidLineTime    = "invalid line time"
+	warnUnparseable        = "unparseable document"
+)
+
+// ParseWarning counts the parts of a document skipped for one reason
+type ParseWarning struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// parseWarnings collects skip reasons in the order they first occur
+type parseWarnings struct {
+	list []ParseWarning
+}
+
+func (w *parseWarnings) add(reason string) {
+	for i := range w.list {
+		if w.list[i].Reason == reason {
+			w.list[i].Count++
+			return
+		}
+	}
+	w.list = append(w.list, ParseWarning{Reason: reason, Count: 1})
+}
+
+var (
+	ttRootPattern    = regexp.MustCompile(`<tt\b[^>]*>`)
+	ttHeadPattern    = regexp.MustCompile(`(?s)<head\b.*?</head>`)
+	ttDivPattern     = regexp.MustCompile(`(?s)(<div\b[^>]*>)(.*?)</div>`)
+	ttParagraphRegex = regexp.MustCompile(`(?s)<p\b.*?</p>`)
+)
+
+// recoverTTML rebuilds a document that failed to parse as a whole from the pieces
+// that do parse: the root timing attributes, the head, and each paragraph on its
+// own. Paragraphs that still fail are skipped and counted. ok is false when no
+// div could be found at all.
+func recoverTTML(ttmlContent string, warnings *parseWarnings) (*TTML, bool) {
+	var doc TTML
+	if root := ttRootPattern.FindString(ttmlContent); root != "" {
+		// Self-closing roots can't be reopened; their attributes are lost
+		xml.Unmarshal([]byte(root+"</tt>"), &doc)
+	}
+	if head := ttHeadPattern.FindString(ttmlContent); head != "" {
+		xml.Unmarshal([]byte(head), &doc.Head)
+	}
+
+	divs := ttDivPattern.FindAllStringSubmatch(ttmlContent, -1)
+	if len(divs) == 0 {
+		return nil, false
+	}
+	warnings.add(warnInvalidXML)
+	for _, match := range divs {
+		var div TTMLDiv
+		xml.Unmarshal([]byte(match[1]+"</div>"), &div)
+		for _, raw := range ttParagraphRegex.FindAllString(match[2], -1) {
+			var para TTMLParagraph
+			if err := xml.Unmarshal([]byte(raw), &para); err != nil {
+				parserLog.Warnf("%s Skipping malformed paragraph: %v", logcolors.LogTTMLParser, err)
+				warnings.add(warnMalformedParagraph)
+				continue
+			}
+			div.Paragraphs = append(div.Paragraphs, para)
+		}
+		doc.Body.Divs = append(doc.Body.Divs, div)
+	}
+	return &doc, true
+}
+
+// recordParseWarnings parses freshly fetched lyrics and records what the parser
+// had to skip, so tracks with broken TTML show up in /stats/parse-warnings
+func recordParseWarnings(trackID, ttmlContent string) {
+	warnings := &parseWarnings{}
+	if _, _, err := parseTTML(ttmlContent, warnings); err != nil {
+		warnings.add(warnUnparseable)
+	}
+	if len(warnings.list) == 0 {
+		return
+	}
+	reasons := make(map[string]int, len(warnings.list))
+	for _, w := range warnings.list {
+		reasons[w.Reason] = w.Count
+	}
+	stats.Get().RecordParseWarnings(trackID, reasons)
+}
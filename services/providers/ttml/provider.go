@@ -54,6 +54,7 @@ func (p *TTMLProvider) FetchLyrics(ctx context.Context, song, artist, album stri
 
 	// Include parsed lines if parsing succeeded
 	if parseErr == nil {
+		result.Vocalists = AssignVocalists(lines)
 		result.Lines = lines
 	}
 
@@ -0,0 +1,255 @@
+package ttml
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/providers"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Fixture kinds recorded from upstream traffic
+const (
+	FixtureKindSearch = "search"
+	FixtureKindLyrics = "lyrics"
+)
+
+// UpstreamFixture is a sanitized upstream response written to disk while recording.
+// Request headers (bearer token, media-user-token) and the upstream host are never
+// stored: fixtures are keyed by path + query only so they replay against any base URL.
+type UpstreamFixture struct {
+	Kind        string `json:"kind"`
+	Path        string `json:"path"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+	RecordedAt  int64  `json:"recordedAt"`
+}
+
+// RecordingStatus reports the state of upstream response recording
+type RecordingStatus struct {
+	Enabled  bool   `json:"enabled"`
+	Dir      string `json:"dir,omitempty"`
+	Recorded int    `json:"recorded"`
+}
+
+var (
+	transportMu       sync.RWMutex
+	upstreamTransport http.RoundTripper = http.DefaultTransport
+	recorder          *recordingTransport
+)
+
+// newUpstreamClient returns the HTTP client used for search and lyrics requests.
+// The transport is swappable so requests can be recorded (debug) or replayed (tests).
+func newUpstreamClient() *http.Client {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return &http.Client{Timeout: 15 * time.Second, Transport: upstreamTransport}
+}
+
+// SetUpstreamTransport replaces the transport used for upstream API requests and
+// returns a function that restores the previous one. Intended for tests.
+func SetUpstreamTransport(rt http.RoundTripper) (restore func()) {
+	transportMu.Lock()
+	prev := upstreamTransport
+	upstreamTransport = rt
+	transportMu.Unlock()
+
+	return func() {
+		transportMu.Lock()
+		upstreamTransport = prev
+		transportMu.Unlock()
+	}
+}
+
+// StartRecording wraps the upstream transport so successful search and lyrics
+// responses are written to dir as fixtures. Calling it while already recording
+// is a no-op that returns the current status.
+func StartRecording(dir string) (RecordingStatus, error) {
+	if dir == "" {
+		return RecordingStatus{}, fmt.Errorf("fixtures directory is not configured")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return RecordingStatus{}, fmt.Errorf("failed to create fixtures directory: %v", err)
+	}
+
+	transportMu.Lock()
+	if recorder == nil {
+		recorder = &recordingTransport{base: upstreamTransport, dir: dir}
+		upstreamTransport = recorder
+		log.Infof("%s Recording upstream responses to %s", logcolors.LogHTTP, dir)
+	}
+	transportMu.Unlock()
+
+	return GetRecordingStatus(), nil
+}
+
+// StopRecording restores the original transport. Fixtures already written are kept.
+func StopRecording() RecordingStatus {
+	transportMu.Lock()
+	status := RecordingStatus{}
+	if recorder != nil {
+		status.Dir = recorder.dir
+		status.Recorded = recorder.count()
+		upstreamTransport = recorder.base
+		recorder = nil
+		log.Infof("%s Stopped recording upstream responses (%d fixtures)", logcolors.LogHTTP, status.Recorded)
+	}
+	transportMu.Unlock()
+	return status
+}
+
+// GetRecordingStatus returns whether upstream responses are currently being recorded
+func GetRecordingStatus() RecordingStatus {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	if recorder == nil {
+		return RecordingStatus{}
+	}
+	return RecordingStatus{Enabled: true, Dir: recorder.dir, Recorded: recorder.count()}
+}
+
+// recordingTransport passes requests through to base and saves 200 responses
+// for search and lyrics endpoints as fixtures
+type recordingTransport struct {
+	base     http.RoundTripper
+	dir      string
+	mu       sync.Mutex
+	recorded int
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	kind := fixtureKind(req.URL.Path)
+	if kind == "" {
+		return resp, nil
+	}
+
+	body, err := providers.ReadBody(resp.Body, providers.PayloadOther)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := UpstreamFixture{
+		Kind:        kind,
+		Path:        req.URL.RequestURI(),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+		RecordedAt:  time.Now().Unix(),
+	}
+	if err := writeFixture(t.dir, fixture); err != nil {
+		log.Warnf("%s Failed to record fixture for %s: %v", logcolors.LogHTTP, fixture.Path, err)
+		return resp, nil
+	}
+
+	t.mu.Lock()
+	t.recorded++
+	t.mu.Unlock()
+	return resp, nil
+}
+
+func (t *recordingTransport) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.recorded
+}
+
+// fixtureKind classifies an upstream path; empty means the response is not recorded
+func fixtureKind(path string) string {
+	switch {
+	case strings.Contains(path, "/search"):
+		return FixtureKindSearch
+	case strings.Contains(path, "lyrics"):
+		return FixtureKindLyrics
+	}
+	return ""
+}
+
+// fixtureFileName derives a stable file name from the fixture kind and request path
+func fixtureFileName(kind, path string) string {
+	sum := sha1.Sum([]byte(path))
+	return kind + "_" + hex.EncodeToString(sum[:])[:16] + ".json"
+}
+
+func writeFixture(dir string, fixture UpstreamFixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, fixtureFileName(fixture.Kind, fixture.Path)), data, 0644)
+}
+
+// ReplayTransport serves recorded fixtures instead of calling the upstream API.
+// Requests are matched by path + query; the host is ignored. Unknown requests fail
+// with an error so tests never fall through to the network.
+type ReplayTransport struct {
+	fixtures map[string]UpstreamFixture
+}
+
+// NewReplayTransport loads every *.json fixture in dir
+func NewReplayTransport(dir string) (*ReplayTransport, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	rt := &ReplayTransport{fixtures: make(map[string]UpstreamFixture, len(files))}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var fixture UpstreamFixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %v", filepath.Base(file), err)
+		}
+		rt.fixtures[fixture.Path] = fixture
+	}
+	return rt, nil
+}
+
+// Len returns the number of loaded fixtures
+func (rt *ReplayTransport) Len() int {
+	return len(rt.fixtures)
+}
+
+func (rt *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fixture, ok := rt.fixtures[req.URL.RequestURI()]
+	if !ok {
+		return nil, fmt.Errorf("no fixture recorded for %s", req.URL.RequestURI())
+	}
+
+	header := make(http.Header)
+	if fixture.ContentType != "" {
+		header.Set("Content-Type", fixture.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package ttml
+
+import (
+	"io"
+	"lyrics-api-go/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useReplayFixtures points the upstream client at the recorded fixtures and
+// installs a non-expiring bearer token so no request reaches the network
+func useReplayFixtures(t *testing.T, dir string) {
+	t.Helper()
+
+	replay, err := NewReplayTransport(dir)
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+	if replay.Len() == 0 {
+		t.Fatalf("No fixtures found in %s", dir)
+	}
+	t.Cleanup(SetUpstreamTransport(replay))
+
+	tokenMu.Lock()
+	savedToken, savedExpiry := bearerToken, tokenExpiry
+	bearerToken, tokenExpiry = "replay-token", time.Now().Add(time.Hour)
+	tokenMu.Unlock()
+	t.Cleanup(func() {
+		tokenMu.Lock()
+		bearerToken, tokenExpiry = savedToken, savedExpiry
+		tokenMu.Unlock()
+	})
+
+	apiCircuitBreaker = nil
+	initCircuitBreaker()
+	t.Cleanup(ResetCircuitBreaker)
+}
+
+func TestSearchTrackURL_ReplayFixtures(t *testing.T) {
+	useReplayFixtures(t, filepath.Join("testdata", "upstream"))
+	// A song-only query can't reach MIN_SIMILARITY_SCORE without a lower bar
+	cfg := config.Current()
+	originalMatrix := cfg.Configuration.MinScoreMatrix
+	cfg.Configuration.MinScoreMatrix = "song:0.45"
+	t.Cleanup(func() { cfg.Configuration.MinScoreMatrix = originalMatrix })
+
+	const searchURL = "https://upstream.invalid/v1/catalog/us/search?term=hello+adele&types=songs"
+	account := MusicAccount{NameID: "Replay"}
+
+	tests := []struct {
+		name       string
+		song       string
+		artist     string
+		album      string
+		durationMs int
+		expectedID string
+	}{
+		{"Studio version wins on album", "Hello", "Adele", "25", 0, "1051394215"},
+		{"Artist disambiguates same title", "Hello", "Lionel Richie", "", 0, "1544494115"},
+		{"Duration filter picks live cut", "Hello", "Adele", "", 281500, "1544495001"},
+		{"Song-only query clears the sparse threshold", "Hello", "", "", 0, "1544494115"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track, score, _, err := searchTrackURL(searchURL, "hello adele", tt.song, tt.artist, tt.album, tt.durationMs, config.DefaultScoreWeights, true, MatchHints{}, account)
+			if err != nil {
+				t.Fatalf("searchTrackURL failed: %v", err)
+			}
+			if track.ID != tt.expectedID {
+				t.Errorf("Expected track %s, got %s (%s - %s, score %.3f)",
+					tt.expectedID, track.ID, track.Attributes.Name, track.Attributes.ArtistName, score)
+			}
+		})
+	}
+}
+
+func TestReplayTransport_UnknownRequest(t *testing.T) {
+	replay, err := NewReplayTransport(filepath.Join("testdata", "upstream"))
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "https://upstream.invalid/v1/catalog/us/search?term=missing", nil)
+	if _, err := replay.RoundTrip(req); err == nil {
+		t.Error("Expected error for request without a fixture")
+	}
+}
+
+func TestRecording_WritesSanitizedFixtures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":{"songs":{"data":[]}}}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	status, err := StartRecording(dir)
+	if err != nil {
+		t.Fatalf("StartRecording failed: %v", err)
+	}
+	defer StopRecording()
+	if !status.Enabled || status.Dir != dir {
+		t.Errorf("Unexpected status after start: %+v", status)
+	}
+
+	for _, path := range []string{"/v1/catalog/us/search?term=test", "/v1/other"} {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer secret-bearer")
+		req.Header.Set("media-user-token", "secret-mut")
+		resp, err := newUpstreamClient().Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(string(body), "results") {
+			t.Errorf("Response body was not passed through: %q", body)
+		}
+	}
+
+	stopped := StopRecording()
+	if stopped.Recorded != 1 {
+		t.Errorf("Expected 1 recorded fixture (search only), got %d", stopped.Recorded)
+	}
+	if GetRecordingStatus().Enabled {
+		t.Error("Recording should be disabled after StopRecording")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 fixture file, got %d", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	for _, secret := range []string{"secret-bearer", "secret-mut", "127.0.0.1"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Fixture leaks %q: %s", secret, data)
+		}
+	}
+
+	// The recorded fixture replays against any host
+	replay, err := NewReplayTransport(dir)
+	if err != nil {
+		t.Fatalf("Failed to load recorded fixtures: %v", err)
+	}
+	req := httptest.NewRequest("GET", "https://elsewhere.invalid/v1/catalog/us/search?term=test", nil)
+	resp, err := replay.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `{"results":{"songs":{"data":[]}}}` {
+		t.Errorf("Unexpected replay: %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestStartRecording_RequiresDir(t *testing.T) {
+	if _, err := StartRecording(""); err == nil {
+		t.Error("Expected error for empty fixtures directory")
+	}
+}
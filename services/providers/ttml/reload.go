@@ -0,0 +1,60 @@
+package ttml
+
+import (
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+
+	"lyrics-api-go/config"
+	"lyrics-api-go/logcolors"
+)
+
+func init() {
+	config.OnReload(applyReloadedConfig)
+}
+
+// applyReloadedConfig picks up remote config changes that the package doesn't
+// read at use time: the account list and the circuit breaker base threshold
+func applyReloadedConfig(c config.Config, changed []string) {
+	if slices.Contains(changed, "TTML_MEDIA_USER_TOKENS") || slices.Contains(changed, "TTML_MEDIA_USER_TOKEN") {
+		ReloadAccounts()
+	}
+	if slices.Contains(changed, "CIRCUIT_BREAKER_THRESHOLD") {
+		baseThreshold.Store(int64(c.Configuration.CircuitBreakerThreshold))
+		recomputeCircuitBreakerThreshold()
+	}
+}
+
+// ReloadAccounts rebuilds the account pool from the current config and swaps it in;
+// requests already holding the old pool finish with it. An account whose MUT
+// changed (rotated centrally) starts fresh: it is no longer disabled and its
+// storefront is fetched again. Quarantines are reset with the pool.
+func ReloadAccounts() {
+	previous := make(map[string]string)
+	for _, account := range accountPool.Load().getAllAccounts() {
+		previous[account.NameID] = account.MediaUserToken
+	}
+
+	accountManager := newAccountManager()
+
+	disabledMutex.Lock()
+	for _, account := range accountManager.accounts {
+		if mut, ok := previous[account.NameID]; ok && mut != account.MediaUserToken {
+			delete(disabledAccounts, account.NameID)
+		}
+	}
+	disabledMutex.Unlock()
+
+	storefrontMutex.Lock()
+	for _, account := range accountManager.accounts {
+		if previous[account.NameID] != account.MediaUserToken {
+			delete(storefrontOverrides, account.NameID)
+		}
+	}
+	storefrontMutex.Unlock()
+
+	accountPool.Store(accountManager)
+	log.Infof("%s Account pool reloaded: %d active account(s)", logcolors.LogAccountInit, len(accountManager.accounts))
+	recomputeCircuitBreakerThreshold()
+	InitializeAccountStorefronts()
+}
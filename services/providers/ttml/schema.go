@@ -0,0 +1,96 @@
+package ttml
+
+import (
+	"encoding/json"
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/stats"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// When Apple changes a response shape, json.Unmarshal doesn't fail: the fields we
+// read just come back empty, which looks exactly like "no tracks found" or "no
+// lyrics" and would be negative-cached for every lookup. The checks here look at
+// the raw envelope of a 200 response instead, and report a shape we don't
+// recognize as a SchemaError, which is an upstream error and never cached.
+
+// SchemaDriftPrefix starts the message of every SchemaError, so callers that only
+// see the error text (negative-cache classification) can still recognize it
+const SchemaDriftPrefix = "upstream schema drift"
+
+// SchemaError is a 200 response whose shape doesn't match what we parse
+type SchemaError struct {
+	Endpoint string // "search" or "lyrics"
+	Reason   string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s in %s response: %s", SchemaDriftPrefix, e.Endpoint, e.Reason)
+}
+
+// schemaDrift records a malformed response and returns its error
+func schemaDrift(endpoint, reason string) error {
+	err := &SchemaError{Endpoint: endpoint, Reason: reason}
+	stats.Get().RecordUpstreamMalformed()
+	log.Warnf("%s %v", logcolors.LogWarning, err)
+	return err
+}
+
+// checkSearchSchema validates a search response. A search without matches still
+// has a results object (empty, or with an empty songs.data), so a missing one
+// means the envelope changed; songs must carry their ID and name.
+func checkSearchSchema(body []byte, resp *SearchResponse) error {
+	var envelope struct {
+		Results *struct {
+			Songs *struct {
+				Data *[]json.RawMessage `json:"data"`
+			} `json:"songs"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil // Reported as a parse error by the caller
+	}
+	if envelope.Results == nil {
+		return schemaDrift("search", "no results object")
+	}
+	if envelope.Results.Songs != nil && envelope.Results.Songs.Data == nil {
+		return schemaDrift("search", "results.songs has no data array")
+	}
+	for i, track := range resp.Results.Songs.Data {
+		if track.ID == "" || track.Attributes.Name == "" {
+			return schemaDrift("search", fmt.Sprintf("song %d has no id or attributes.name", i))
+		}
+	}
+	return nil
+}
+
+// checkLyricsSchema validates a lyrics response. Tracks without lyrics get a 404,
+// so a 200 must hold at least one entry whose attributes carry a ttml (or
+// ttmlLocalizations) field, even if it is empty.
+func checkLyricsSchema(body []byte) error {
+	var envelope struct {
+		Data *[]struct {
+			Attributes map[string]json.RawMessage `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil // Reported as a parse error by the caller
+	}
+	if envelope.Data == nil {
+		return schemaDrift("lyrics", "no data array")
+	}
+	if len(*envelope.Data) == 0 {
+		return schemaDrift("lyrics", "empty data array on a 200 response")
+	}
+	attributes := (*envelope.Data)[0].Attributes
+	if attributes == nil {
+		return schemaDrift("lyrics", "data[0] has no attributes")
+	}
+	_, hasTTML := attributes["ttml"]
+	_, hasLocalizations := attributes["ttmlLocalizations"]
+	if !hasTTML && !hasLocalizations {
+		return schemaDrift("lyrics", "data[0].attributes has no ttml or ttmlLocalizations")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package ttml
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"lyrics-api-go/stats"
+)
+
+func TestCheckSearchSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		drift bool
+	}{
+		{"songs", `{"results":{"songs":{"data":[{"id":"1","attributes":{"name":"Song"}}]}}}`, false},
+		{"no matches, empty results", `{"results":{},"meta":{"results":{"order":[]}}}`, false},
+		{"no matches, empty songs", `{"results":{"songs":{"data":[]}}}`, false},
+		{"renamed envelope", `{"result":{"songs":{"data":[{"id":"1"}]}}}`, true},
+		{"empty object", `{}`, true},
+		{"songs without data", `{"results":{"songs":{"items":[{"id":"1"}]}}}`, true},
+		{"renamed attributes", `{"results":{"songs":{"data":[{"id":"1","attrs":{"name":"Song"}}]}}}`, true},
+		{"song without id", `{"results":{"songs":{"data":[{"attributes":{"name":"Song"}}]}}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp SearchResponse
+			if err := json.Unmarshal([]byte(tt.body), &resp); err != nil {
+				t.Fatal(err)
+			}
+			err := checkSearchSchema([]byte(tt.body), &resp)
+			if (err != nil) != tt.drift {
+				t.Fatalf("checkSearchSchema() = %v, want drift %v", err, tt.drift)
+			}
+			var schemaErr *SchemaError
+			if tt.drift && (!errors.As(err, &schemaErr) || schemaErr.Endpoint != "search") {
+				t.Errorf("error %v is not a search SchemaError", err)
+			}
+		})
+	}
+}
+
+func TestCheckLyricsSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		drift bool
+	}{
+		{"ttml", `{"data":[{"id":"1","attributes":{"ttml":"<tt/>"}}]}`, false},
+		{"localizations only", `{"data":[{"id":"1","attributes":{"ttmlLocalizations":"<tt/>"}}]}`, false},
+		{"empty ttml field", `{"data":[{"id":"1","attributes":{"ttml":""}}]}`, false},
+		{"no data", `{"results":[]}`, true},
+		{"empty data", `{"data":[]}`, true},
+		{"no attributes", `{"data":[{"id":"1"}]}`, true},
+		{"renamed ttml field", `{"data":[{"id":"1","attributes":{"lyricsTtml":"<tt/>"}}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLyricsSchema([]byte(tt.body))
+			if (err != nil) != tt.drift {
+				t.Fatalf("checkLyricsSchema() = %v, want drift %v", err, tt.drift)
+			}
+		})
+	}
+}
+
+func TestSchemaDrift_CountsAndIsRecognizable(t *testing.T) {
+	before := stats.Get().UpstreamMalformed.Load()
+	err := checkLyricsSchema([]byte(`{"data":[]}`))
+	if got := stats.Get().UpstreamMalformed.Load() - before; got != 1 {
+		t.Errorf("UpstreamMalformed grew by %d, want 1", got)
+	}
+	if !strings.HasPrefix(err.Error(), SchemaDriftPrefix) {
+		t.Errorf("error %q doesn't start with SchemaDriftPrefix", err)
+	}
+	// Must not read as the permanent "no lyrics" errors that get negative-cached
+	for _, permanent := range []string{"no lyrics data found", "TTML content is empty", "no tracks found"} {
+		if strings.Contains(err.Error(), permanent) {
+			t.Errorf("error %q contains %q", err, permanent)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package ttml
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"lyrics-api-go/config"
+)
+
+// searchCacheMaxEntries bounds memory; the oldest entry is evicted when full
+const searchCacheMaxEntries = 5000
+
+// searchCacheEntry holds the raw search results for one query. Duration filtering
+// and scoring run per request on top of it, so duration/album variants of the same
+// query share an entry and only the lyrics fetch goes upstream.
+type searchCacheEntry struct {
+	tracks    []Track
+	expiresAt time.Time
+}
+
+// SearchCacheStats reports search result cache effectiveness
+type SearchCacheStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	TTLSecs int   `json:"ttl_secs"`
+}
+
+var (
+	searchCache       = make(map[string]searchCacheEntry)
+	searchCacheMutex  sync.RWMutex
+	searchCacheHits   atomic.Int64
+	searchCacheMisses atomic.Int64
+)
+
+// searchCacheTTL returns the configured TTL; 0 disables the cache
+func searchCacheTTL() time.Duration {
+	return time.Duration(config.Get().Configuration.TTMLSearchCacheTTLSecs) * time.Second
+}
+
+// searchCacheKey normalizes the query so case/whitespace variants share an entry.
+// Storefront is part of the key since results differ per region.
+func searchCacheKey(query, storefront string) string {
+	return storefront + "|" + strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// getCachedSearch returns unexpired search results for query in storefront
+func getCachedSearch(query, storefront string) ([]Track, bool) {
+	if searchCacheTTL() <= 0 {
+		return nil, false
+	}
+	searchCacheMutex.RLock()
+	entry, ok := searchCache[searchCacheKey(query, storefront)]
+	searchCacheMutex.RUnlock()
+
+	if !ok || !clk.Now().Before(entry.expiresAt) {
+		searchCacheMisses.Add(1)
+		return nil, false
+	}
+	searchCacheHits.Add(1)
+	return entry.tracks, true
+}
+
+// setCachedSearch stores search results. Empty results are not cached so a track
+// that shows up in the catalog later is found on the next request.
+func setCachedSearch(query, storefront string, tracks []Track) {
+	ttl := searchCacheTTL()
+	if ttl <= 0 || len(tracks) == 0 {
+		return
+	}
+	now := clk.Now()
+
+	searchCacheMutex.Lock()
+	defer searchCacheMutex.Unlock()
+
+	if len(searchCache) >= searchCacheMaxEntries {
+		evictSearchCacheLocked(now)
+	}
+	searchCache[searchCacheKey(query, storefront)] = searchCacheEntry{
+		tracks:    tracks,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// evictSearchCacheLocked drops expired entries, or the one closest to expiry if none
+// have expired. REQUIRES: caller holds searchCacheMutex.
+func evictSearchCacheLocked(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range searchCache {
+		if !now.Before(entry.expiresAt) {
+			delete(searchCache, key)
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, entry.expiresAt
+		}
+	}
+	if len(searchCache) >= searchCacheMaxEntries && oldestKey != "" {
+		delete(searchCache, oldestKey)
+	}
+}
+
+// ClearSearchCache drops all cached search results
+func ClearSearchCache() {
+	searchCacheMutex.Lock()
+	defer searchCacheMutex.Unlock()
+	searchCache = make(map[string]searchCacheEntry)
+}
+
+// GetSearchCacheStats returns entry count and hit/miss counters since startup
+func GetSearchCacheStats() SearchCacheStats {
+	searchCacheMutex.RLock()
+	entries := len(searchCache)
+	searchCacheMutex.RUnlock()
+	return SearchCacheStats{
+		Entries: entries,
+		Hits:    searchCacheHits.Load(),
+		Misses:  searchCacheMisses.Load(),
+		TTLSecs: config.Get().Configuration.TTMLSearchCacheTTLSecs,
+	}
+}
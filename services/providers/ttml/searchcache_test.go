@@ -0,0 +1,80 @@
+package ttml
+
+import (
+	"testing"
+	"time"
+
+	"lyrics-api-go/config"
+	"lyrics-api-go/internal/clocktest"
+)
+
+func testSearchTrack(id, name, artist string, durationMs int) Track {
+	var track Track
+	track.ID = id
+	track.Attributes.Name = name
+	track.Attributes.ArtistName = artist
+	track.Attributes.DurationInMillis = durationMs
+	return track
+}
+
+func TestSearchCache_KeyAndExpiry(t *testing.T) {
+	if searchCacheTTL() <= 0 {
+		t.Skip("search cache disabled by TTML_SEARCH_CACHE_TTL_SECS")
+	}
+	fake := clocktest.NewFake(time.Now())
+	savedClock := clk
+	clk = fake
+	defer func() { clk = savedClock }()
+	ClearSearchCache()
+	defer ClearSearchCache()
+
+	tracks := []Track{testSearchTrack("1", "Hello", "Adele", 295000)}
+	setCachedSearch("Hello Adele", "us", tracks)
+
+	if got, ok := getCachedSearch("  hello   ADELE ", "us"); !ok || len(got) != 1 {
+		t.Errorf("Expected a hit for a case/whitespace variant, got %v %v", got, ok)
+	}
+	if _, ok := getCachedSearch("Hello Adele", "gb"); ok {
+		t.Error("Expected a miss for another storefront")
+	}
+
+	fake.Advance(searchCacheTTL())
+	if _, ok := getCachedSearch("Hello Adele", "us"); ok {
+		t.Error("Expected the entry to expire after the TTL")
+	}
+}
+
+func TestSearchCache_SkipsEmptyResults(t *testing.T) {
+	ClearSearchCache()
+	defer ClearSearchCache()
+
+	setCachedSearch("Nothing Here", "us", nil)
+	if stats := GetSearchCacheStats(); stats.Entries != 0 {
+		t.Errorf("Expected empty results not to be cached, got %d entries", stats.Entries)
+	}
+}
+
+func TestSearchTrack_UsesCachedResults(t *testing.T) {
+	if searchCacheTTL() <= 0 {
+		t.Skip("search cache disabled by TTML_SEARCH_CACHE_TTL_SECS")
+	}
+	ClearSearchCache()
+	defer ClearSearchCache()
+
+	setCachedSearch("Hello Adele", "us", []Track{
+		testSearchTrack("1", "Hello", "Adele", 295000),
+		testSearchTrack("2", "Hello (Live)", "Adele", 330000),
+	})
+
+	// No upstream is configured, so only a cache hit can succeed.
+	// Two duration variants resolve to different tracks from the same results.
+	weights := config.ScoreWeights{Name: 0.5, Artist: 0.375, Album: 0.125}
+	track, _, _, err := searchTrack("Hello Adele", "us", "Hello", "Adele", "", 295000, weights, true, MatchHints{}, MusicAccount{NameID: "Test"})
+	if err != nil || track.ID != "1" {
+		t.Fatalf("Expected track 1 from cache, got %v, %v", track, err)
+	}
+	track, _, _, err = searchTrack("Hello Adele", "us", "Hello", "Adele", "", 330000, weights, true, MatchHints{}, MusicAccount{NameID: "Test"})
+	if err != nil || track.ID != "2" {
+		t.Fatalf("Expected track 2 from cache, got %v, %v", track, err)
+	}
+}
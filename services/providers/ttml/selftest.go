@@ -0,0 +1,147 @@
+package ttml
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"lyrics-api-go/config"
+	"lyrics-api-go/logcolors"
+)
+
+// SelfTestStage is the outcome of one pipeline stage
+type SelfTestStage struct {
+	Name       string                 `json:"name"`
+	OK         bool                   `json:"ok"`
+	Skipped    bool                   `json:"skipped,omitempty"`
+	DurationMs int64                  `json:"duration_ms"`
+	Error      string                 `json:"error,omitempty"`
+	Detail     map[string]interface{} `json:"detail,omitempty"`
+}
+
+// SelfTestReport is the result of a full canary run
+type SelfTestReport struct {
+	Passed   bool            `json:"passed"`
+	Song     string          `json:"song"`
+	Artist   string          `json:"artist"`
+	TrackID  string          `json:"track_id,omitempty"`
+	Checksum string          `json:"checksum,omitempty"` // sha256 of the plain-text lyrics
+	TotalMs  int64           `json:"total_ms"`
+	Stages   []SelfTestStage `json:"stages"`
+}
+
+// RunSelfTest runs a canary query through search → lyrics fetch → parse → checksum
+// compare. Every cache is bypassed (search cache, track lyrics lookup) so the run
+// exercises the live upstream and catches response shape changes. Stages after a
+// failure are reported as skipped. An empty expectedChecksum skips the compare.
+func RunSelfTest(songName, artistName, expectedChecksum string) *SelfTestReport {
+	report := &SelfTestReport{Song: songName, Artist: artistName}
+	started := time.Now()
+	defer func() { report.TotalMs = time.Since(started).Milliseconds() }()
+
+	failed := false
+	run := func(name string, fn func(stage *SelfTestStage) error) {
+		stage := SelfTestStage{Name: name}
+		if failed {
+			stage.Skipped = true
+			report.Stages = append(report.Stages, stage)
+			return
+		}
+		stageStart := time.Now()
+		err := fn(&stage)
+		stage.DurationMs = time.Since(stageStart).Milliseconds()
+		if err != nil {
+			stage.Error = err.Error()
+			failed = true
+		} else {
+			stage.OK = true
+		}
+		report.Stages = append(report.Stages, stage)
+	}
+
+	var account MusicAccount
+	var storefront string
+	var track *Track
+	var ttml string
+
+	run("search", func(stage *SelfTestStage) error {
+		accountManager := getAccountManager()
+		if !accountManager.hasAccounts() {
+			return fmt.Errorf("no TTML accounts configured")
+		}
+		account = accountManager.getNextAccount()
+		storefront = accountStorefront(account)
+
+		conf := config.Get()
+		query := songName + " " + artistName
+		searchURL := conf.Configuration.TTMLBaseURL + fmt.Sprintf(conf.Configuration.TTMLSearchPath, storefront, url.QueryEscape(query))
+		tracks, successAccount, err := fetchSearchTracks(searchURL, query, account)
+		if err != nil {
+			return err
+		}
+		account = successAccount
+		track, _, _, err = pickBestTrack(tracks, query, songName, artistName, "", 0, configuredScoreWeights(), config.Get().Configuration.PreferExplicit, MatchHints{}, account)
+		if err != nil {
+			return err
+		}
+		report.TrackID = track.ID
+		stage.Detail = map[string]interface{}{
+			"results":  len(tracks),
+			"track_id": track.ID,
+			"match":    track.Attributes.Name + " - " + track.Attributes.ArtistName,
+			"account":  account.NameID,
+		}
+		return nil
+	})
+
+	run("lyrics_fetch", func(stage *SelfTestStage) error {
+		var err error
+		ttml, err = fetchLyricsTTML(track.ID, storefront, account)
+		if err != nil {
+			return err
+		}
+		stage.Detail = map[string]interface{}{"bytes": len(ttml)}
+		return nil
+	})
+
+	run("parse", func(stage *SelfTestStage) error {
+		lines, timingType, err := ParseLines(ttml)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return fmt.Errorf("no lines parsed from TTML")
+		}
+		text, err := ToPlainText(ttml)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256([]byte(text))
+		report.Checksum = hex.EncodeToString(sum[:])
+		stage.Detail = map[string]interface{}{"lines": len(lines), "timing": timingType}
+		return nil
+	})
+
+	run("checksum", func(stage *SelfTestStage) error {
+		if expectedChecksum == "" {
+			stage.Detail = map[string]interface{}{"note": "no expected checksum configured"}
+			return nil
+		}
+		if report.Checksum != expectedChecksum {
+			return fmt.Errorf("checksum mismatch: got %s, expected %s", report.Checksum, expectedChecksum)
+		}
+		return nil
+	})
+
+	report.Passed = !failed
+	if report.Passed {
+		log.Infof("%s Self-test passed (%s - %s, track %s)", logcolors.LogHealthCheck, songName, artistName, report.TrackID)
+	} else {
+		log.Warnf("%s Self-test FAILED (%s - %s)", logcolors.LogHealthCheck, songName, artistName)
+	}
+	return report
+}
@@ -0,0 +1,25 @@
+package ttml
+
+import "testing"
+
+func TestRunSelfTest_NoAccountsSkipsLaterStages(t *testing.T) {
+	saved := accountPool.Load()
+	accountPool.Store(&AccountManager{quarantineTime: make(map[int]int64)})
+	defer func() { accountPool.Store(saved) }()
+
+	report := RunSelfTest("Breathe (In the Air)", "Pink Floyd", "")
+	if report.Passed {
+		t.Fatal("Expected self-test to fail without accounts")
+	}
+	if len(report.Stages) != 4 {
+		t.Fatalf("Expected 4 stages, got %d", len(report.Stages))
+	}
+	if report.Stages[0].Name != "search" || report.Stages[0].OK || report.Stages[0].Error == "" {
+		t.Errorf("Expected search stage to fail with an error, got %+v", report.Stages[0])
+	}
+	for _, stage := range report.Stages[1:] {
+		if !stage.Skipped || stage.OK {
+			t.Errorf("Expected %s to be skipped, got %+v", stage.Name, stage)
+		}
+	}
+}
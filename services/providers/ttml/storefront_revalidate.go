@@ -0,0 +1,81 @@
+package ttml
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"lyrics-api-go/config"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/services/notifier"
+)
+
+// fetchStorefront is the storefront lookup used by initialization and revalidation;
+// tests swap it out
+var fetchStorefront = fetchAccountStorefront
+
+// StartStorefrontRevalidation re-checks each account's storefront once per
+// STOREFRONT_REVALIDATE_HOURS. Cached storefronts are otherwise trusted forever,
+// so an account whose subscription region changes would keep querying the wrong
+// catalog. Checks are spread evenly over the interval (one account per tick)
+// rather than bursting every account at once.
+func StartStorefrontRevalidation() {
+	interval := time.Duration(config.Get().Configuration.StorefrontRevalidateHours) * time.Hour
+	if interval <= 0 {
+		return
+	}
+
+	accounts := activeStorefrontAccounts()
+	if len(accounts) == 0 {
+		return
+	}
+	tick := interval / time.Duration(len(accounts))
+	log.Infof("%s Storefront revalidation every %v (%d accounts, one every %v)",
+		logcolors.LogAccountInit, interval, len(accounts), tick.Round(time.Minute))
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		next := 0
+		for range ticker.C {
+			revalidateStorefront(accounts[next%len(accounts)])
+			next++
+		}
+	}()
+}
+
+// activeStorefrontAccounts returns the accounts that have a storefront to check
+func activeStorefrontAccounts() []MusicAccount {
+	var accounts []MusicAccount
+	for _, account := range getAccountManager().getAllAccounts() {
+		if account.MediaUserToken != "" {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
+
+// revalidateStorefront fetches an account's storefront and, if it differs from the one
+// in use, applies and persists the new value and publishes a notification. Fetch
+// failures keep the current storefront. Reports whether the storefront changed.
+func revalidateStorefront(account MusicAccount) bool {
+	current := accountStorefront(account)
+	storefront, err := fetchStorefront(account)
+	if err != nil {
+		log.Warnf("%s Storefront revalidation failed for %s, keeping %q: %v",
+			logcolors.LogAccountInit, logcolors.Account(account.NameID), current, err)
+		return false
+	}
+
+	if storefront == current {
+		log.Debugf("%s %s storefront still %s", logcolors.LogAccountInit, logcolors.Account(account.NameID), current)
+		return false
+	}
+
+	log.Warnf("%s %s storefront changed: %s → %s (revalidation)",
+		logcolors.LogAccountInit, logcolors.Account(account.NameID), current, storefront)
+
+	applyStorefront(account, storefront)
+	notifier.PublishStorefrontChanged(account.NameID, current, storefront)
+	return true
+}
@@ -0,0 +1,83 @@
+package ttml
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"lyrics-api-go/services/notifier"
+)
+
+// storefrontRecorder collects storefront change events
+type storefrontRecorder struct {
+	events []*notifier.Event
+}
+
+func (r *storefrontRecorder) Name() string                      { return "storefront_recorder" }
+func (r *storefrontRecorder) HandleEvent(event *notifier.Event) { r.events = append(r.events, event) }
+
+func withStorefrontState(t *testing.T) {
+	t.Helper()
+	storefrontMutex.Lock()
+	originalCache := storefrontCache
+	originalPath := storefrontCachePath
+	originalOverrides := storefrontOverrides
+	storefrontCache = make(map[string]string)
+	storefrontCachePath = filepath.Join(t.TempDir(), StorefrontCacheFile)
+	storefrontOverrides = make(map[string]string)
+	storefrontMutex.Unlock()
+
+	originalFetch := fetchStorefront
+	t.Cleanup(func() {
+		fetchStorefront = originalFetch
+		storefrontMutex.Lock()
+		storefrontCache = originalCache
+		storefrontCachePath = originalPath
+		storefrontOverrides = originalOverrides
+		storefrontMutex.Unlock()
+	})
+}
+
+func TestRevalidateStorefront_AppliesChange(t *testing.T) {
+	withStorefrontState(t)
+	fetchStorefront = func(MusicAccount) (string, error) { return "gb", nil }
+
+	bus := notifier.GetEventBus()
+	rec := &storefrontRecorder{}
+	unsubscribe := bus.Register(rec, notifier.EventStorefrontChanged)
+	defer unsubscribe()
+
+	account := MusicAccount{NameID: "Mover", MediaUserToken: "mover_mut", Storefront: "us"}
+	if !revalidateStorefront(account) {
+		t.Fatal("Expected a storefront change to be reported")
+	}
+	bus.Drain()
+
+	if got := accountStorefront(account); got != "gb" {
+		t.Errorf("Expected new storefront 'gb' in use, got %q", got)
+	}
+	if got := getCachedStorefront("mover_mut"); got != "gb" {
+		t.Errorf("Expected new storefront persisted to cache, got %q", got)
+	}
+	if len(rec.events) != 1 || rec.events[0].Data["old_storefront"] != "us" || rec.events[0].Data["new_storefront"] != "gb" {
+		t.Errorf("Expected one us → gb change event, got %+v", rec.events)
+	}
+}
+
+func TestRevalidateStorefront_UnchangedOrFailed(t *testing.T) {
+	withStorefrontState(t)
+	account := MusicAccount{NameID: "Stayer", MediaUserToken: "stayer_mut", Storefront: "jp"}
+
+	fetchStorefront = func(MusicAccount) (string, error) { return "jp", nil }
+	if revalidateStorefront(account) {
+		t.Error("Expected no change when the storefront is the same")
+	}
+
+	fetchStorefront = func(MusicAccount) (string, error) { return "", fmt.Errorf("timeout") }
+	if revalidateStorefront(account) {
+		t.Error("Expected no change when the fetch fails")
+	}
+	if got := accountStorefront(account); got != "jp" {
+		t.Errorf("Expected storefront to stay 'jp', got %q", got)
+	}
+}
@@ -0,0 +1,120 @@
+package ttml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	htmlTagRegex    = regexp.MustCompile(`<[^>]+>`)
+	blockEndRegex   = regexp.MustCompile(`(?i)</(p|div)>|<br\s*/?>`)
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// ToPlainText converts TTML into a plain-text lyrics sheet: one lyric line per row,
+// with a "[Section]" header taken from each div's songPart (e.g. [Verse], [Chorus]).
+// Divs are separated by a blank line. Timing information is dropped.
+func ToPlainText(ttmlContent string) (string, error) {
+	var ttml TTML
+	if err := xml.Unmarshal([]byte(ttmlContent), &ttml); err != nil {
+		return "", fmt.Errorf("failed to parse TTML XML: %v", err)
+	}
+
+	var sb strings.Builder
+	for _, div := range ttml.Body.Divs {
+		var rows []string
+		for _, para := range div.Paragraphs {
+			if text := paragraphPlainText(para); text != "" {
+				rows = append(rows, text)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		if section := formatSongPart(div.SongPart); section != "" {
+			sb.WriteString("[" + section + "]\n")
+		}
+
+		for _, row := range rows {
+			sb.WriteString(row + "\n")
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// ToLRC converts TTML into line-synced LRC ([mm:ss.xx]text). Section boundaries are
+// written as "# Section" comment lines before the first line of each section so
+// clients can render labels or jump to a section. Unsynced lyrics are emitted without
+// timestamps.
+func ToLRC(ttmlContent string) (string, error) {
+	lines, timingType, err := parseTTMLToLines(ttmlContent)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	lastSection := ""
+	for i, line := range lines {
+		if line.Section != "" && (i == 0 || line.Section != lastSection) {
+			sb.WriteString("# " + formatSongPart(line.Section) + "\n")
+		}
+		lastSection = line.Section
+
+		words := html.UnescapeString(line.Words)
+		if timingType == "none" {
+			sb.WriteString(words + "\n")
+			continue
+		}
+		startMs, _ := strconv.ParseInt(line.StartTimeMs, 10, 64)
+		sb.WriteString(formatLRCTimestamp(startMs) + words + "\n")
+	}
+
+	return sb.String(), nil
+}
+
+// formatLRCTimestamp formats milliseconds as an LRC timestamp: [mm:ss.xx]
+func formatLRCTimestamp(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	minutes := ms / 60000
+	seconds := (ms % 60000) / 1000
+	hundredths := (ms % 1000) / 10
+	return fmt.Sprintf("[%02d:%02d.%02d]", minutes, seconds, hundredths)
+}
+
+// paragraphPlainText strips markup from a paragraph so word-level spans read as a normal sentence
+func paragraphPlainText(para TTMLParagraph) string {
+	return StripMarkup(para.Text)
+}
+
+// StripMarkup removes XML/HTML tags, unescapes entities and collapses whitespace.
+// Paragraph and line breaks become spaces; adjacent syllable spans stay joined.
+func StripMarkup(s string) string {
+	text := blockEndRegex.ReplaceAllString(s, " ")
+	text = htmlTagRegex.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	return strings.TrimSpace(whitespaceRegex.ReplaceAllString(text, " "))
+}
+
+// formatSongPart turns a songPart attribute like "PreChorus" into "Pre-Chorus".
+// Unknown values are returned as-is.
+func formatSongPart(songPart string) string {
+	songPart = strings.TrimSpace(songPart)
+	switch strings.ToLower(songPart) {
+	case "prechorus":
+		return "Pre-Chorus"
+	case "postchorus":
+		return "Post-Chorus"
+	}
+	return songPart
+}
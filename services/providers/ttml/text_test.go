@@ -0,0 +1,102 @@
+package ttml
+
+import (
+	"testing"
+)
+
+func TestToPlainText_WithSections(t *testing.T) {
+	ttmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" itunes:timing="Word" xmlns:itunes="http://music.apple.com/lyric-ttml-internal">
+  <body>
+    <div songPart="Verse">
+      <p begin="0:00:01.000" end="0:00:03.000"><span begin="0:00:01.000" end="0:00:02.000">Hello</span> <span begin="0:00:02.000" end="0:00:03.000">world</span></p>
+      <p begin="0:00:03.000" end="0:00:05.000"><span begin="0:00:03.000" end="0:00:05.000">Don&apos;t stop</span></p>
+    </div>
+    <div songPart="PreChorus">
+      <p begin="0:00:05.000" end="0:00:07.000">Almost there</p>
+    </div>
+    <div songPart="Chorus">
+      <p begin="0:00:07.000" end="0:00:09.000">Sing it loud</p>
+      <p begin="0:00:09.000" end="0:00:10.000">   </p>
+    </div>
+  </body>
+</tt>`
+
+	got, err := ToPlainText(ttmlContent)
+	if err != nil {
+		t.Fatalf("ToPlainText error: %v", err)
+	}
+
+	expected := "[Verse]\nHello world\nDon't stop\n\n[Pre-Chorus]\nAlmost there\n\n[Chorus]\nSing it loud\n"
+	if got != expected {
+		t.Errorf("ToPlainText() =\n%q\nwant\n%q", got, expected)
+	}
+}
+
+func TestToPlainText_NoSections(t *testing.T) {
+	ttmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" timing="none">
+  <body>
+    <div>
+      <p>First line</p>
+      <p>Second line</p>
+    </div>
+  </body>
+</tt>`
+
+	got, err := ToPlainText(ttmlContent)
+	if err != nil {
+		t.Fatalf("ToPlainText error: %v", err)
+	}
+
+	expected := "First line\nSecond line\n"
+	if got != expected {
+		t.Errorf("ToPlainText() = %q, want %q", got, expected)
+	}
+}
+
+func TestToPlainText_InvalidXML(t *testing.T) {
+	if _, err := ToPlainText("<tt><body>"); err == nil {
+		t.Error("Expected error for invalid XML")
+	}
+}
+
+func TestToLRC_SectionComments(t *testing.T) {
+	ttmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" timing="Line">
+  <body>
+    <div songPart="Verse">
+      <p begin="0:00:01.000" end="0:00:03.000">First line</p>
+      <p begin="0:01:03.456" end="0:01:05.000">Second line</p>
+    </div>
+    <div songPart="Chorus">
+      <p begin="0:01:07.000" end="0:01:09.000">Sing it</p>
+    </div>
+  </body>
+</tt>`
+
+	got, err := ToLRC(ttmlContent)
+	if err != nil {
+		t.Fatalf("ToLRC error: %v", err)
+	}
+
+	expected := "# Verse\n[00:01.00]First line\n[01:03.45]Second line\n# Chorus\n[01:07.00]Sing it\n"
+	if got != expected {
+		t.Errorf("ToLRC() =\n%q\nwant\n%q", got, expected)
+	}
+}
+
+func TestToLRC_Unsynced(t *testing.T) {
+	ttmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" timing="none">
+  <body><div><p>Just words</p></div></body>
+</tt>`
+
+	got, err := ToLRC(ttmlContent)
+	if err != nil {
+		t.Fatalf("ToLRC error: %v", err)
+	}
+	if got != "Just words\n" {
+		t.Errorf("ToLRC() = %q, want %q", got, "Just words\n")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strings"
@@ -14,6 +13,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"lyrics-api-go/config"
 	"lyrics-api-go/logcolors"
+	"lyrics-api-go/redact"
+	"lyrics-api-go/services/providers"
 )
 
 var (
@@ -45,7 +46,7 @@ func GetBearerToken() (string, error) {
 // isTokenExpiringSoon checks if the token will expire within the refresh threshold.
 // Note: This function does not acquire locks - caller must hold at least a read lock.
 func isTokenExpiringSoon() bool {
-	return tokenExpiry.IsZero() || time.Now().Add(refreshThreshold).After(tokenExpiry)
+	return tokenExpiry.IsZero() || clk.Now().Add(refreshThreshold).After(tokenExpiry)
 }
 
 // GetTokenStatus returns the current token's expiry status for monitoring
@@ -57,7 +58,7 @@ func GetTokenStatus() (expiry time.Time, remaining time.Duration, needsRefresh b
 		return time.Time{}, 0, true
 	}
 
-	remaining = time.Until(tokenExpiry)
+	remaining = clk.Until(tokenExpiry)
 	needsRefresh = isTokenExpiringSoon()
 	return tokenExpiry, remaining, needsRefresh
 }
@@ -83,13 +84,14 @@ func refreshBearerToken() (string, error) {
 	if err != nil {
 		// If we can't parse expiry, use a conservative default (1 hour)
 		log.Warnf("%s Could not parse JWT expiry, using 1h default: %v", logcolors.LogBearerToken, err)
-		expiry = time.Now().Add(1 * time.Hour)
+		expiry = clk.Now().Add(1 * time.Hour)
 	}
 
 	bearerToken = token
 	tokenExpiry = expiry
+	redact.Register(token)
 
-	remaining := time.Until(expiry)
+	remaining := clk.Until(expiry)
 	log.Infof("%s Bearer token refreshed, expires in %v (at %s)",
 		logcolors.LogBearerToken, remaining.Round(time.Minute), expiry.Format(time.RFC3339))
 
@@ -168,7 +170,7 @@ func scrapeToken() (string, error) {
 		return "", fmt.Errorf("token source returned status %d", resp.StatusCode)
 	}
 
-	html, err := io.ReadAll(resp.Body)
+	html, err := providers.ReadBody(resp.Body, providers.PayloadOther)
 	if err != nil {
 		return "", fmt.Errorf("failed to read token source response: %w", err)
 	}
@@ -195,7 +197,7 @@ func scrapeToken() (string, error) {
 	}
 	defer jsResp.Body.Close()
 
-	jsContent, err := io.ReadAll(jsResp.Body)
+	jsContent, err := providers.ReadBody(jsResp.Body, providers.PayloadOther)
 	if err != nil {
 		return "", fmt.Errorf("failed to read JS bundle: %w", err)
 	}
@@ -219,10 +221,11 @@ func scrapeToken() (string, error) {
 	return "", fmt.Errorf("could not extract JWT from JS bundle")
 }
 
-// StartBearerTokenMonitor fetches the initial bearer token and storefronts synchronously,
-// then starts a background goroutine that proactively refreshes the token before it expires.
+// StartBearerTokenMonitor fetches the initial bearer token synchronously, applies cached
+// storefronts (uncached ones are fetched in the background), then starts a background
+// goroutine that proactively refreshes the token before it expires.
 func StartBearerTokenMonitor() {
-	// Initial fetch - synchronous to ensure storefronts are available before server starts
+	// Initial fetch - synchronous so the first requests have a token
 	_, err := GetBearerToken()
 	if err != nil {
 		log.Errorf("%s Initial token fetch failed: %v", logcolors.LogBearerToken, err)
@@ -231,12 +234,18 @@ func StartBearerTokenMonitor() {
 		InitializeAccountStorefronts()
 	}
 
+	// Per-account token expiry feeds account selection (expiring accounts are used last)
+	accountManager := getAccountManager()
+	accountManager.refreshAccountTokenExpiries()
+
 	// Background monitor for proactive refresh
 	go func() {
 		ticker := time.NewTicker(1 * time.Minute)
 		defer ticker.Stop()
 
 		for range ticker.C {
+			getAccountManager().refreshAccountTokenExpiries()
+
 			tokenMu.RLock()
 			needsRefresh := isTokenExpiringSoon()
 			tokenMu.RUnlock()
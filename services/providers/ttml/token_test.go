@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"lyrics-api-go/internal/clocktest"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -687,3 +688,40 @@ func BenchmarkIsTokenExpiringSoon(b *testing.B) {
 		tokenMu.RUnlock()
 	}
 }
+
+func TestIsTokenExpiringSoon_FakeClock(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	savedClock := clk
+	clk = fake
+
+	tokenMu.Lock()
+	originalExpiry := tokenExpiry
+	tokenExpiry = fake.Now().Add(time.Hour)
+	tokenMu.Unlock()
+
+	defer func() {
+		clk = savedClock
+		tokenMu.Lock()
+		tokenExpiry = originalExpiry
+		tokenMu.Unlock()
+	}()
+
+	if isTokenExpiringSoon() {
+		t.Fatal("Token with 1h left should not need refresh")
+	}
+
+	fake.Advance(time.Hour - refreshThreshold - time.Second)
+	if isTokenExpiringSoon() {
+		t.Error("Token should not need refresh just before the threshold")
+	}
+
+	fake.Advance(2 * time.Second)
+	if !isTokenExpiringSoon() {
+		t.Error("Token should need refresh once inside the threshold")
+	}
+
+	_, remaining, _ := GetTokenStatus()
+	if remaining != refreshThreshold-time.Second {
+		t.Errorf("Expected remaining %v, got %v", refreshThreshold-time.Second, remaining)
+	}
+}
@@ -0,0 +1,87 @@
+package ttml
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Trace records the steps of one lookup: the account used, search results, the
+// candidates scored and the lyrics fetch, with the upstream error of a failed step.
+// It travels in MatchHints.Trace; a nil *Trace records nothing.
+type Trace struct {
+	Verbose bool // Also record every candidate's score, not only the best match
+
+	mu      sync.Mutex
+	start   time.Time
+	steps   []TraceStep
+	account string
+}
+
+// TraceStep is one recorded step of a lookup
+type TraceStep struct {
+	AtMs   int64  `json:"at_ms"` // Since the trace started
+	Step   string `json:"step"`
+	Detail string `json:"detail"`
+}
+
+// maxTraceSteps bounds a trace, so a verbose trace of a wide search stays small
+const maxTraceSteps = 200
+
+// NewTrace starts a trace
+func NewTrace(verbose bool) *Trace {
+	return &Trace{Verbose: verbose, start: time.Now()}
+}
+
+// Add records a step
+func (t *Trace) Add(step, format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.steps) >= maxTraceSteps {
+		return
+	}
+	t.steps = append(t.steps, TraceStep{
+		AtMs:   time.Since(t.start).Milliseconds(),
+		Step:   step,
+		Detail: fmt.Sprintf(format, args...),
+	})
+}
+
+// SetAccount records the account the lookup is using; a retry on another account
+// replaces it
+func (t *Trace) SetAccount(name string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.account = name
+}
+
+// Account returns the last account the lookup used, if any
+func (t *Trace) Account() string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.account
+}
+
+// verbose reports whether per-candidate steps should be recorded
+func (t *Trace) verbose() bool {
+	return t != nil && t.Verbose
+}
+
+// Steps returns the steps recorded so far
+func (t *Trace) Steps() []TraceStep {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TraceStep(nil), t.steps...)
+}
@@ -3,7 +3,10 @@ package ttml
 import (
 	"encoding/json"
 	"fmt"
+	"lyrics-api-go/config"
 	"lyrics-api-go/logcolors"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -11,9 +14,7 @@ import (
 // FetchLyricsByTrackID fetches TTML lyrics directly by Apple Music track ID, skipping search.
 // Used by the /override endpoint to correct cached lyrics with a known-good track ID.
 func FetchLyricsByTrackID(trackID string) (string, error) {
-	if accountManager == nil {
-		initAccountManager()
-	}
+	accountManager := getAccountManager()
 
 	if !accountManager.hasAccounts() {
 		return "", fmt.Errorf("no TTML accounts configured")
@@ -30,10 +31,7 @@ func FetchLyricsByTrackID(trackID string) (string, error) {
 	}
 
 	account := accountManager.getNextAccount()
-	storefront := account.Storefront
-	if storefront == "" {
-		storefront = "us"
-	}
+	storefront := accountStorefront(account)
 
 	log.Infof("%s Fetching lyrics by track ID %s via %s", logcolors.LogRequest, trackID, logcolors.Account(account.NameID))
 
@@ -56,11 +54,58 @@ func FetchLyricsByTrackID(trackID string) (string, error) {
 // durationMs is optional (0 means no duration filter), used to find closest matching track by duration
 // Returns: raw TTML string, track duration in ms, similarity score, track metadata, error
 func FetchTTMLLyrics(songName, artistName, albumName string, durationMs int) (string, int, float64, *TrackMeta, error) {
-	if accountManager == nil {
-		initAccountManager()
+	return FetchTTMLLyricsWithWeights(songName, artistName, albumName, durationMs, configuredScoreWeights())
+}
+
+// FetchTTMLLyricsWithHints is FetchTTMLLyrics with an explicit/clean preference that
+// overrides PREFER_EXPLICIT (the explicit= parameter on /getLyrics) and identifiers
+// the client sent along (POST /getLyrics), used to favor the search result they point at
+func FetchTTMLLyricsWithHints(songName, artistName, albumName string, durationMs int, preferExplicit bool, hints MatchHints) (string, int, float64, *TrackMeta, error) {
+	return fetchTTMLLyrics(songName, artistName, albumName, durationMs, configuredScoreWeights(), preferExplicit, hints, true)
+}
+
+// FetchTTMLLyricsFresh is FetchTTMLLyrics without the track lyrics lookup: the lyrics
+// are always fetched upstream. Used by revalidation, which needs the current content.
+func FetchTTMLLyricsFresh(songName, artistName, albumName string, durationMs int) (string, int, float64, *TrackMeta, error) {
+	return fetchTTMLLyrics(songName, artistName, albumName, durationMs, configuredScoreWeights(), config.Get().Configuration.PreferExplicit, MatchHints{}, false)
+}
+
+// FetchTTMLLyricsWithWeights is FetchTTMLLyrics with an explicit scoring weight set.
+// Used by the admin-only weights override on /getLyrics to tune matching.
+func FetchTTMLLyricsWithWeights(songName, artistName, albumName string, durationMs int, weights config.ScoreWeights) (string, int, float64, *TrackMeta, error) {
+	return fetchTTMLLyrics(songName, artistName, albumName, durationMs, weights, config.Get().Configuration.PreferExplicit, MatchHints{}, true)
+}
+
+var (
+	trackLyricsMu     sync.RWMutex
+	trackLyricsLookup func(trackID string) (string, bool)
+)
+
+// SetTrackLyricsLookup registers a lookup of already-stored lyrics by track ID.
+// When it hits, the lyrics fetch is skipped after search, so different query
+// phrasings that resolve to the same track cost one upstream lyrics request.
+func SetTrackLyricsLookup(fn func(trackID string) (string, bool)) {
+	trackLyricsMu.Lock()
+	defer trackLyricsMu.Unlock()
+	trackLyricsLookup = fn
+}
+
+// lookupTrackLyrics returns stored lyrics for trackID, if a lookup is registered
+func lookupTrackLyrics(trackID string) (string, bool) {
+	trackLyricsMu.RLock()
+	fn := trackLyricsLookup
+	trackLyricsMu.RUnlock()
+	if fn == nil || trackID == "" {
+		return "", false
 	}
+	return fn(trackID)
+}
+
+func fetchTTMLLyrics(songName, artistName, albumName string, durationMs int, weights config.ScoreWeights, preferExplicit bool, hints MatchHints, useTrackLookup bool) (string, int, float64, *TrackMeta, error) {
+	accountManager := getAccountManager()
 
 	if !accountManager.hasAccounts() {
+		hints.Trace.Add("accounts", "No TTML accounts configured")
 		return "", 0, 0.0, nil, fmt.Errorf("no TTML accounts configured")
 	}
 
@@ -73,16 +118,17 @@ func FetchTTMLLyrics(songName, artistName, albumName string, durationMs int) (st
 	if apiCircuitBreaker.IsOpen() {
 		timeUntilRetry := apiCircuitBreaker.TimeUntilRetry()
 		if timeUntilRetry > 0 {
+			hints.Trace.Add("circuit_breaker", "Open, retry in %v", timeUntilRetry.Round(time.Second))
 			return "", 0, 0.0, nil, fmt.Errorf("circuit breaker is open, API temporarily unavailable (retry in %v)", timeUntilRetry)
 		}
 		// Cooldown passed - let it through, Allow() will handle the HALF-OPEN transition
 	}
 
 	// Select initial account for the request (only if circuit breaker allows)
-	account := accountManager.getNextAccount()
-	storefront := account.Storefront
-	if storefront == "" {
-		storefront = "us"
+	account := accountManager.getAccountForStorefront(hints.Storefront)
+	storefront := accountStorefront(account)
+	if hints.Storefront != "" && storefront != hints.Storefront {
+		hints.Trace.Add("storefront", "No available account in preferred storefront %s", hints.Storefront)
 	}
 
 	if songName == "" && artistName == "" {
@@ -94,19 +140,28 @@ func FetchTTMLLyrics(songName, artistName, albumName string, durationMs int) (st
 		query += " " + albumName
 	}
 
+	hints.Trace.Add("lookup", "Query %q via account %s (storefront %s, duration %dms)", query, account.NameID, storefront, durationMs)
+	hints.Trace.SetAccount(account.NameID)
 	if durationMs > 0 {
-		log.Infof("%s Starting with account %s | Query: %s (duration: %dms)", logcolors.LogRequest, logcolors.Account(account.NameID), query, durationMs)
+		ttmlLog.Infof("lookup_start", "%s Starting with account %s | Query: %s (duration: %dms)", logcolors.LogRequest, logcolors.Account(account.NameID), query, durationMs)
 	} else {
-		log.Infof("%s Starting with account %s | Query: %s", logcolors.LogRequest, logcolors.Account(account.NameID), query)
+		ttmlLog.Infof("lookup_start", "%s Starting with account %s | Query: %s", logcolors.LogRequest, logcolors.Account(account.NameID), query)
 	}
 
 	// Search returns the account that succeeded (may differ if retry occurred)
-	track, score, workingAccount, err := searchTrack(query, storefront, songName, artistName, albumName, durationMs, account)
+	searchStart := time.Now()
+	track, score, workingAccount, err := searchTrack(query, storefront, songName, artistName, albumName, durationMs, weights, preferExplicit, hints, account)
+	searchDuration := time.Since(searchStart)
+	if workingAccount.NameID != "" {
+		hints.Trace.SetAccount(workingAccount.NameID)
+	}
 	if err != nil {
+		hints.Trace.Add("search_failed", "%v", err)
 		return "", 0, 0.0, nil, fmt.Errorf("search failed: %v", err)
 	}
 
 	if track == nil {
+		hints.Trace.Add("search_failed", "No track found")
 		return "", 0, 0.0, nil, fmt.Errorf("no track found for query: %s", query)
 	}
 
@@ -117,11 +172,11 @@ func FetchTTMLLyrics(songName, artistName, albumName string, durationMs int) (st
 		if durationDiff < 0 {
 			durationDiff = -durationDiff
 		}
-		log.Infof("%s %s - %s (ID: %s, duration: %dms, diff: %dms, score: %.3f)",
+		ttmlLog.Infof("match", "%s %s - %s (ID: %s, duration: %dms, diff: %dms, score: %.3f)",
 			logcolors.LogMatch, track.Attributes.Name, track.Attributes.ArtistName, track.ID,
 			trackDurationMs, durationDiff, score)
 	} else {
-		log.Infof("%s %s - %s (ID: %s, duration: %dms, score: %.3f)",
+		ttmlLog.Infof("match", "%s %s - %s (ID: %s, duration: %dms, score: %.3f)",
 			logcolors.LogMatch, track.Attributes.Name, track.Attributes.ArtistName, track.ID, trackDurationMs, score)
 	}
 
@@ -135,7 +190,9 @@ func FetchTTMLLyrics(songName, artistName, albumName string, durationMs int) (st
 		ISRC:                track.Attributes.ISRC,
 		ReleaseDate:         track.Attributes.ReleaseDate,
 		HasTimeSyncedLyrics: track.Attributes.HasTimeSyncedLyrics,
+		ContentRating:       track.Attributes.ContentRating,
 		RawAttributes:       string(rawAttrsJSON),
+		SearchDuration:      searchDuration,
 	}
 
 	// Check hasTimeSyncedLyrics to potentially skip the lyrics fetch
@@ -147,21 +204,34 @@ func FetchTTMLLyrics(songName, artistName, albumName string, durationMs int) (st
 		// Explicitly false — skip lyrics fetch entirely (saves an API call)
 		log.Infof("%s Skipping lyrics fetch: hasTimeSyncedLyrics=false for %s - %s",
 			logcolors.LogLyrics, track.Attributes.Name, track.Attributes.ArtistName)
+		hints.Trace.Add("lyrics_skipped", "Track %s has no time-synced lyrics (hasTimeSyncedLyrics=false)", track.ID)
 		return "", trackDurationMs, score, trackMeta, fmt.Errorf("no lyrics data found (hasTimeSyncedLyrics=false)")
 	}
 
+	if useTrackLookup {
+		if ttml, ok := lookupTrackLyrics(track.ID); ok {
+			ttmlLog.Infof("track_cached", "%s Track %s already cached, skipping lyrics fetch for: %s - %s",
+				logcolors.LogCacheLyrics, track.ID, track.Attributes.Name, track.Attributes.ArtistName)
+			hints.Trace.Add("lyrics_cached", "Track %s already cached (%d bytes), lyrics fetch skipped", track.ID, len(ttml))
+			return ttml, trackDurationMs, score, trackMeta, nil
+		}
+	}
+
 	// Use the same account that succeeded for search to fetch lyrics
 	// This ensures we don't hit a quarantined account
 	ttml, err := fetchLyricsTTML(track.ID, storefront, workingAccount)
 	if err != nil {
+		hints.Trace.Add("lyrics_failed", "Track %s via account %s: %v", track.ID, workingAccount.NameID, err)
 		return "", trackDurationMs, score, trackMeta, fmt.Errorf("failed to fetch TTML: %v", err)
 	}
 
 	if ttml == "" {
+		hints.Trace.Add("lyrics_failed", "Track %s via account %s: TTML content is empty", track.ID, workingAccount.NameID)
 		return "", trackDurationMs, score, trackMeta, fmt.Errorf("TTML content is empty")
 	}
+	hints.Trace.Add("lyrics_fetched", "Track %s via account %s (%d bytes)", track.ID, workingAccount.NameID, len(ttml))
 
-	log.Infof("%s Fetched TTML via %s for: %s - %s (%d bytes)",
+	ttmlLog.Infof("lyrics_fetched", "%s Fetched TTML via %s for: %s - %s (%d bytes)",
 		logcolors.LogSuccess, logcolors.Account(workingAccount.NameID), track.Attributes.Name, track.Attributes.ArtistName, len(ttml))
 
 	return ttml, trackDurationMs, score, trackMeta, nil
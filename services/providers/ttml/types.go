@@ -2,6 +2,7 @@ package ttml
 
 import (
 	"encoding/xml"
+	"time"
 
 	"lyrics-api-go/services/providers"
 )
@@ -16,6 +17,15 @@ type Line = providers.Line
 // Syllable is an alias for the shared Syllable type
 type Syllable = providers.Syllable
 
+// BackgroundVocal is an alias for the shared BackgroundVocal type
+type BackgroundVocal = providers.BackgroundVocal
+
+// Apple Music contentRating values
+const (
+	ContentRatingExplicit = "explicit"
+	ContentRatingClean    = "clean"
+)
+
 // TrackMeta contains metadata about the matched track from Apple Music
 type TrackMeta struct {
 	TrackID             string // Apple Music track ID
@@ -24,8 +34,10 @@ type TrackMeta struct {
 	AlbumName           string
 	ISRC                string
 	ReleaseDate         string
-	HasTimeSyncedLyrics *bool  // nil = field absent from API, false = no synced lyrics, true = has synced lyrics
-	RawAttributes       string // JSON string of full Apple Music attributes
+	HasTimeSyncedLyrics *bool         // nil = field absent from API, false = no synced lyrics, true = has synced lyrics
+	ContentRating       string        // "explicit", "clean", or empty when unrated
+	RawAttributes       string        // JSON string of full Apple Music attributes
+	SearchDuration      time.Duration `json:"-"` // Time spent in search for this lookup (Server-Timing)
 }
 
 // =============================================================================
@@ -41,9 +53,10 @@ type MusicAccount struct {
 }
 
 type AccountManager struct {
-	accounts       []MusicAccount
-	currentIndex   uint64        // Use uint64 for atomic operations
-	quarantineTime map[int]int64 // account index -> unix timestamp when quarantine ends
+	accounts        []MusicAccount
+	currentIndex    uint64        // Use uint64 for atomic operations
+	quarantineTime  map[int]int64 // account index -> unix timestamp when quarantine ends
+	lastRateLimited map[int]int64 // account index -> unix timestamp of the most recent 429
 }
 
 // =============================================================================
@@ -87,9 +100,15 @@ type Track struct {
 		GenreNames          []string `json:"genreNames,omitempty"` // e.g. ["Pop", "Alternative"]
 		ComposerName        string   `json:"composerName,omitempty"`
 		HasCredits          *bool    `json:"hasCredits,omitempty"`
+		ContentRating       string   `json:"contentRating,omitempty"` // "explicit", "clean", or empty when unrated
 	} `json:"attributes"`
 }
 
+// IsExplicit reports whether the track is the explicit release of a song
+func (t *Track) IsExplicit() bool {
+	return t.Attributes.ContentRating == ContentRatingExplicit
+}
+
 type LyricsResponse struct {
 	Data []struct {
 		ID         string `json:"id"`
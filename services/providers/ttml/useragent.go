@@ -0,0 +1,42 @@
+package ttml
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"lyrics-api-go/config"
+)
+
+// defaultUserAgents is the pool used when TTML_USER_AGENTS is empty: current
+// desktop browsers that can use the web player
+var defaultUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Safari/605.1.15",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:143.0) Gecko/20100101 Firefox/143.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36 Edg/141.0.0.0",
+}
+
+// userAgentPool returns the configured TTML_USER_AGENTS, or the default pool
+func userAgentPool(raw string) []string {
+	var pool []string
+	for _, ua := range strings.Split(raw, "|") {
+		if ua = strings.TrimSpace(ua); ua != "" {
+			pool = append(pool, ua)
+		}
+	}
+	if len(pool) == 0 {
+		return defaultUserAgents
+	}
+	return pool
+}
+
+// userAgentFor picks an account's User-Agent from the pool. The choice is a hash of
+// the account name, so an account keeps presenting the same browser across
+// requests and restarts while different accounts spread over the pool.
+func userAgentFor(account MusicAccount) string {
+	pool := userAgentPool(config.Get().Configuration.TTMLUserAgents)
+	h := fnv.New32a()
+	h.Write([]byte(account.NameID))
+	return pool[h.Sum32()%uint32(len(pool))]
+}
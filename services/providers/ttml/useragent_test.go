@@ -0,0 +1,39 @@
+package ttml
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUserAgentPool(t *testing.T) {
+	if got := userAgentPool(""); !slices.Equal(got, defaultUserAgents) {
+		t.Errorf("Empty setting should use the default pool, got %v", got)
+	}
+	got := userAgentPool(" UA/1 (x; y) | |UA/2, like Gecko ")
+	if !slices.Equal(got, []string{"UA/1 (x; y)", "UA/2, like Gecko"}) {
+		t.Errorf("userAgentPool = %q", got)
+	}
+}
+
+func TestUserAgentFor_StablePerAccount(t *testing.T) {
+	account := MusicAccount{NameID: "Stable", MediaUserToken: "mut"}
+	first := userAgentFor(account)
+	for range 10 {
+		if got := userAgentFor(account); got != first {
+			t.Fatalf("User-Agent changed between requests: %q then %q", first, got)
+		}
+	}
+
+	// A new token keeps the account's choice; different accounts spread over the pool
+	account.MediaUserToken = "rotated"
+	if got := userAgentFor(account); got != first {
+		t.Errorf("User-Agent should depend only on the account name, got %q", got)
+	}
+	seen := make(map[string]bool)
+	for _, name := range []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo", "Foxtrot", "Golf", "Hotel"} {
+		seen[userAgentFor(MusicAccount{NameID: name})] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Expected accounts to use more than one User-Agent, got %v", seen)
+	}
+}
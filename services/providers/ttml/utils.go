@@ -32,3 +32,19 @@ func DetectLanguage(ttml string) (language string, isRTL bool) {
 	lang := detectLanguageFromTTML(ttml)
 	return lang, providers.IsRTLLanguage(lang)
 }
+
+var bodyDurRegex = regexp.MustCompile(`<body\b[^>]*\bdur="([^"]+)"`)
+
+// ContentDurationMs returns the timeline length a TTML document declares on its
+// <body dur="..."> (Apple sets it to the track length), or 0 when absent
+func ContentDurationMs(ttml string) int {
+	matches := bodyDurRegex.FindStringSubmatch(ttml)
+	if len(matches) < 2 {
+		return 0
+	}
+	ms, err := parseTTMLTime(matches[1])
+	if err != nil || ms < 0 {
+		return 0
+	}
+	return int(ms)
+}
@@ -211,3 +211,20 @@ func TestDetectLanguage(t *testing.T) {
 		})
 	}
 }
+
+func TestContentDurationMs(t *testing.T) {
+	tests := []struct {
+		ttml     string
+		expected int
+	}{
+		{`<tt><body dur="3:45.588"><div></div></body></tt>`, 225588},
+		{`<tt><body xmlns:x="y" dur="12.5">`, 12500},
+		{`<tt><body><div dur="1:00"></div></body></tt>`, 0},
+		{`<tt><body dur="bogus">`, 0},
+	}
+	for _, tt := range tests {
+		if got := ContentDurationMs(tt.ttml); got != tt.expected {
+			t.Errorf("ContentDurationMs(%q) = %d, want %d", tt.ttml, got, tt.expected)
+		}
+	}
+}
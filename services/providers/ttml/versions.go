@@ -0,0 +1,104 @@
+package ttml
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Score adjustments for version qualifiers. A mismatch costs more than a match earns,
+// so "Song (Live)" prefers any live cut over the studio one, and a plain "Song" query
+// prefers the studio cut over a remix with a closer-looking title.
+const (
+	versionMatchBonus      = 0.05
+	versionMismatchPenalty = 0.1
+)
+
+var (
+	// versionSegmentRegex captures the parts of a title that may carry a version:
+	// bracketed groups and anything after a " - " separator
+	versionSegmentRegex = regexp.MustCompile(`\(([^)]*)\)|\[([^\]]*)\]|\s-\s(.+)$`)
+
+	// versionKeywordRegexes maps each qualifier to the words that signal it.
+	// Remasters, feature credits and edition labels are deliberately absent: they
+	// don't change the recording's lyrics.
+	versionKeywordRegexes = []struct {
+		qualifier string
+		regex     *regexp.Regexp
+	}{
+		{"live", regexp.MustCompile(`(?i)\b(live|unplugged|in concert)\b`)},
+		{"acoustic", regexp.MustCompile(`(?i)\b(acoustic|unplugged|stripped)\b`)},
+		{"remix", regexp.MustCompile(`(?i)\b(remix|rmx|mix|rework|vip)\b`)},
+		{"instrumental", regexp.MustCompile(`(?i)\b(instrumental|karaoke|backing track)\b`)},
+		{"a cappella", regexp.MustCompile(`(?i)\b(a ?cappella|acapella|vocals only)\b`)},
+		{"demo", regexp.MustCompile(`(?i)\bdemo\b`)},
+		{"sped up", regexp.MustCompile(`(?i)\b(sped up|speed up|nightcore)\b`)},
+		{"slowed", regexp.MustCompile(`(?i)\b(slowed|reverb)\b`)},
+		{"orchestral", regexp.MustCompile(`(?i)\b(orchestral|symphonic|orchestra)\b`)},
+	}
+
+	// releaseNoteRegex matches title parts that describe the release rather than the
+	// recording; baseTitle drops them along with version qualifiers
+	releaseNoteRegex = regexp.MustCompile(`(?i)\b(remaster(ed)?|feat\.?|ft\.?|featuring|with|deluxe|edition|explicit|clean|mono|stereo|single|radio edit|album version|bonus track)\b`)
+)
+
+// versionQualifiers extracts the version qualifiers (live, acoustic, remix, ...) from
+// a title's parenthetical, bracketed or " - " suffixed parts. The base title is never
+// inspected, so "Live Forever" has none.
+func versionQualifiers(title string) map[string]bool {
+	qualifiers := make(map[string]bool)
+	for _, match := range versionSegmentRegex.FindAllStringSubmatch(title, -1) {
+		segment := strings.Join(match[1:], " ")
+		for _, keyword := range versionKeywordRegexes {
+			if keyword.regex.MatchString(segment) {
+				qualifiers[keyword.qualifier] = true
+			}
+		}
+	}
+	return qualifiers
+}
+
+// baseTitle strips the bracketed and " - " suffixed parts of a title that only
+// describe the release: version qualifiers, feature credits, remasters and editions.
+// Other parts ("(Part 2)", "(Interlude)") are kept since they tell songs apart.
+func baseTitle(title string) string {
+	base := versionSegmentRegex.ReplaceAllStringFunc(title, func(segment string) string {
+		if len(versionQualifiers(segment)) > 0 || releaseNoteRegex.MatchString(segment) {
+			return ""
+		}
+		return segment
+	})
+	if base = strings.Join(strings.Fields(base), " "); base != "" {
+		return base
+	}
+	return title
+}
+
+// titleSimilarity compares song titles by their base titles, so a long
+// "(Live at ...)" or "- 2011 Remaster" suffix doesn't drag the right recording below
+// a worse one; versionAdjustment scores the qualifiers themselves.
+func titleSimilarity(trackName, targetName string) float64 {
+	return stringSimilarity(baseTitle(trackName), baseTitle(targetName))
+}
+
+// versionAdjustment is the score change for a candidate title given the queried one:
+// a bonus for every qualifier both share and a penalty for every qualifier only one of
+// them has. Titles without qualifiers on either side get 0.
+func versionAdjustment(trackName, targetName string) float64 {
+	wanted := versionQualifiers(targetName)
+	found := versionQualifiers(trackName)
+
+	adjustment := 0.0
+	for qualifier := range wanted {
+		if found[qualifier] {
+			adjustment += versionMatchBonus
+		} else {
+			adjustment -= versionMismatchPenalty
+		}
+	}
+	for qualifier := range found {
+		if !wanted[qualifier] {
+			adjustment -= versionMismatchPenalty
+		}
+	}
+	return adjustment
+}
@@ -0,0 +1,108 @@
+package ttml
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// versionCorpus is testdata/version_qualifiers.json: titles with their expected
+// qualifiers, and queries with the candidate title scoring should pick
+type versionCorpus struct {
+	Qualifiers []struct {
+		Title string   `json:"title"`
+		Want  []string `json:"want"`
+	} `json:"qualifiers"`
+	Matches []struct {
+		Query      string   `json:"query"`
+		Candidates []string `json:"candidates"`
+		Want       string   `json:"want"`
+	} `json:"matches"`
+}
+
+func loadVersionCorpus(t *testing.T) versionCorpus {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "version_qualifiers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var corpus versionCorpus
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		t.Fatal(err)
+	}
+	return corpus
+}
+
+func TestVersionQualifiers_Corpus(t *testing.T) {
+	for _, tt := range loadVersionCorpus(t).Qualifiers {
+		t.Run(tt.Title, func(t *testing.T) {
+			var got []string
+			for qualifier := range versionQualifiers(tt.Title) {
+				got = append(got, qualifier)
+			}
+			sort.Strings(got)
+			want := append([]string(nil), tt.Want...)
+			sort.Strings(want)
+			if len(got) != len(want) {
+				t.Fatalf("versionQualifiers(%q) = %v, want %v", tt.Title, got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("versionQualifiers(%q) = %v, want %v", tt.Title, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionScoring_Corpus(t *testing.T) {
+	for _, tt := range loadVersionCorpus(t).Matches {
+		t.Run(tt.Query, func(t *testing.T) {
+			best := TrackScore{TotalScore: -1}
+			for _, name := range tt.Candidates {
+				track := &Track{}
+				track.Attributes.Name = name
+				track.Attributes.ArtistName = "Artist"
+				if score := scoreTrack(track, tt.Query, "Artist", ""); score.TotalScore > best.TotalScore {
+					best = score
+				}
+			}
+			if best.Track.Attributes.Name != tt.Want {
+				t.Errorf("Query %q picked %q (%.3f), want %q", tt.Query, best.Track.Attributes.Name, best.TotalScore, tt.Want)
+			}
+		})
+	}
+}
+
+func TestVersionAdjustment(t *testing.T) {
+	if got := versionAdjustment("Hello", "Hello"); got != 0 {
+		t.Errorf("No qualifiers on either side should be neutral, got %.2f", got)
+	}
+	if got := versionAdjustment("Hello (Live)", "Hello (Live)"); got != versionMatchBonus {
+		t.Errorf("Matching qualifier should earn the bonus, got %.2f", got)
+	}
+	if got := versionAdjustment("Hello", "Hello (Live)"); got != -versionMismatchPenalty {
+		t.Errorf("Missing qualifier should cost the penalty, got %.2f", got)
+	}
+	if got := versionAdjustment("Hello (Live)", "Hello"); got != -versionMismatchPenalty {
+		t.Errorf("Unwanted qualifier should cost the penalty, got %.2f", got)
+	}
+}
+
+func TestBaseTitle(t *testing.T) {
+	tests := map[string]string{
+		"Hello":                                 "Hello",
+		"Hello (Live at the Royal Albert Hall)": "Hello",
+		"Bohemian Rhapsody - Remastered 2011":   "Bohemian Rhapsody",
+		"Get Lucky (feat. Pharrell Williams)":   "Get Lucky",
+		"Another Brick in the Wall (Part 2)":    "Another Brick in the Wall (Part 2)",
+		"(Live)":                                "(Live)",
+	}
+	for title, want := range tests {
+		if got := baseTitle(title); got != want {
+			t.Errorf("baseTitle(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
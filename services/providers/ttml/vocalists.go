@@ -0,0 +1,71 @@
+package ttml
+
+import (
+	"strings"
+
+	"lyrics-api-go/services/providers"
+)
+
+// Vocalist is an alias for the shared Vocalist type
+type Vocalist = providers.Vocalist
+
+// Suggested display sides for duet-style rendering
+const (
+	SideLeft   = "left"
+	SideRight  = "right"
+	SideCenter = "center"
+)
+
+// AssignVocalists builds a vocalist summary from line agents and sets each line's
+// VocalistIndex. Vocalists are ordered by first appearance. Individual singers
+// alternate left/right (first singer on the left); groups are centered so
+// "everyone sings" lines stand apart from the duet.
+func AssignVocalists(lines []Line) []Vocalist {
+	var vocalists []Vocalist
+	indexByAgent := make(map[string]int)
+	persons := 0
+
+	for i := range lines {
+		agent := lines[i].Agent
+		if agent == "" {
+			lines[i].VocalistIndex = nil
+			continue
+		}
+
+		idx, ok := indexByAgent[agent]
+		if !ok {
+			agentType, agentID := splitAgent(agent)
+			side := SideCenter
+			if agentType != "group" {
+				side = SideLeft
+				if persons%2 == 1 {
+					side = SideRight
+				}
+				persons++
+			}
+
+			idx = len(vocalists)
+			indexByAgent[agent] = idx
+			vocalists = append(vocalists, Vocalist{
+				Index: idx,
+				ID:    agentID,
+				Type:  agentType,
+				Side:  side,
+			})
+		}
+
+		vocalists[idx].LineCount++
+		lineIdx := idx
+		lines[i].VocalistIndex = &lineIdx
+	}
+
+	return vocalists
+}
+
+// splitAgent splits a parsed agent ("person:v1" or bare "v1") into type and ID
+func splitAgent(agent string) (string, string) {
+	if agentType, agentID, ok := strings.Cut(agent, ":"); ok {
+		return agentType, agentID
+	}
+	return "", agent
+}
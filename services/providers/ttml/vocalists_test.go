@@ -0,0 +1,66 @@
+package ttml
+
+import (
+	"testing"
+)
+
+func TestAssignVocalists_Duet(t *testing.T) {
+	lines := []Line{
+		{Words: "a", Agent: "person:v1"},
+		{Words: "b", Agent: "person:v2"},
+		{Words: "c", Agent: "group:v1000"},
+		{Words: "d", Agent: "person:v1"},
+		{Words: "e"},
+	}
+
+	vocalists := AssignVocalists(lines)
+
+	if len(vocalists) != 3 {
+		t.Fatalf("Expected 3 vocalists, got %d", len(vocalists))
+	}
+
+	expected := []struct {
+		id        string
+		agentType string
+		side      string
+		lineCount int
+	}{
+		{"v1", "person", SideLeft, 2},
+		{"v2", "person", SideRight, 1},
+		{"v1000", "group", SideCenter, 1},
+	}
+	for i, e := range expected {
+		v := vocalists[i]
+		if v.Index != i || v.ID != e.id || v.Type != e.agentType || v.Side != e.side || v.LineCount != e.lineCount {
+			t.Errorf("Vocalist %d = %+v, want id=%s type=%s side=%s lines=%d", i, v, e.id, e.agentType, e.side, e.lineCount)
+		}
+	}
+
+	expectedIndexes := []int{0, 1, 2, 0}
+	for i, want := range expectedIndexes {
+		if lines[i].VocalistIndex == nil || *lines[i].VocalistIndex != want {
+			t.Errorf("Line %d: expected vocalist index %d, got %v", i, want, lines[i].VocalistIndex)
+		}
+	}
+	if lines[4].VocalistIndex != nil {
+		t.Errorf("Line without agent should have nil vocalist index, got %d", *lines[4].VocalistIndex)
+	}
+}
+
+func TestAssignVocalists_BareAgentID(t *testing.T) {
+	lines := []Line{{Words: "a", Agent: "v1"}}
+
+	vocalists := AssignVocalists(lines)
+
+	if len(vocalists) != 1 || vocalists[0].ID != "v1" || vocalists[0].Type != "" || vocalists[0].Side != SideLeft {
+		t.Errorf("Unexpected vocalists: %+v", vocalists)
+	}
+}
+
+func TestAssignVocalists_NoAgents(t *testing.T) {
+	lines := []Line{{Words: "a"}, {Words: "b"}}
+
+	if vocalists := AssignVocalists(lines); len(vocalists) != 0 {
+		t.Errorf("Expected no vocalists, got %+v", vocalists)
+	}
+}
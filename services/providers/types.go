@@ -16,6 +16,34 @@ type Line struct {
 	Syllables   []Syllable `json:"syllables"`
 	EndTimeMs   string     `json:"endTimeMs"`
 	Agent       string     `json:"agent,omitempty"`
+	Section     string     `json:"section,omitempty"` // Song section from the TTML div songPart (e.g. Verse, Chorus)
+	// VocalistIndex points into the Vocalists summary (nil when the line has no agent)
+	VocalistIndex *int `json:"vocalistIndex,omitempty"`
+	// IsBackground is true when every timed syllable in the line is a background vocal
+	IsBackground bool `json:"isBackground,omitempty"`
+	// BackgroundVocals preserves the source grouping of background vocals within the line
+	BackgroundVocals []BackgroundVocal `json:"backgroundVocals,omitempty"`
+}
+
+// BackgroundVocal is one background-vocal group as delivered by the source
+// (a TTML x-bg span), so clients can render it smaller/offset as a unit
+type BackgroundVocal struct {
+	Text          string `json:"text"` // Group text as written in the source (may include parentheses)
+	StartTimeMs   string `json:"startTimeMs"`
+	EndTimeMs     string `json:"endTimeMs"`
+	SyllableStart int    `json:"syllableStart"` // Index of the first syllable of the group in Syllables
+	SyllableEnd   int    `json:"syllableEnd"`   // Index after the last syllable of the group (exclusive)
+	Parenthesized bool   `json:"parenthesized"` // Source text is wrapped in "(...)"; clients may strip the parentheses when styling
+}
+
+// Vocalist summarizes one agent (singer or group) across all lines,
+// with a suggested display side for duet-style rendering
+type Vocalist struct {
+	Index     int    `json:"index"`
+	ID        string `json:"id"`             // Agent ID from the source (e.g. "v1")
+	Type      string `json:"type,omitempty"` // Agent type (person, group, other)
+	LineCount int    `json:"lineCount"`
+	Side      string `json:"side"` // Suggested layout: left, right or center
 }
 
 // LyricsResult is the standardized result from any lyrics provider
@@ -40,6 +68,9 @@ type LyricsResult struct {
 
 	// IsRTL indicates if the lyrics are in a right-to-left language
 	IsRTL bool `json:"isRtlLanguage,omitempty"`
+
+	// Vocalists summarizes the agents referenced by Lines (empty when lyrics have no agents)
+	Vocalists []Vocalist `json:"vocalists,omitempty"`
 }
 
 // ProviderError represents an error from a provider with additional context
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"lyrics-api-go/config"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SetupIssue describes a missing or misconfigured setting reported by /setup/check
+type SetupIssue struct {
+	Setting string `json:"setting"`
+	Message string `json:"message"`
+	Hint    string `json:"hint"`
+}
+
+// SetupReport is the result of checkSetup
+type SetupReport struct {
+	Profile  string       `json:"profile"`
+	Ready    bool         `json:"ready"`
+	Missing  []SetupIssue `json:"missing"`
+	Warnings []SetupIssue `json:"warnings"`
+}
+
+// storageCheck is the outcome of probing the storage directories for checkSetup
+type storageCheck struct {
+	missing  []SetupIssue
+	warnings []SetupIssue
+}
+
+var (
+	startupStorage     storageCheck
+	startupStorageOnce sync.Once
+)
+
+// startupStorageCheck probes the storage directories on first use (at startup) and
+// returns that result from then on, so /setup/check never touches the disk
+func startupStorageCheck() storageCheck {
+	startupStorageOnce.Do(func() {
+		startupStorage = checkStorage()
+	})
+	return startupStorage
+}
+
+// checkStorage verifies that the cache and stats directories are writable
+func checkStorage() storageCheck {
+	var check storageCheck
+	for _, p := range []struct{ setting, path string }{
+		{"CACHE_DB_PATH", getEnvOrDefault("CACHE_DB_PATH", "./cache.db")},
+		{"STATS_DB_PATH", getEnvOrDefault("STATS_DB_PATH", "./stats.db")},
+	} {
+		if issue, ok := checkWritableDir(p.setting, filepath.Dir(p.path)); !ok {
+			check.missing = append(check.missing, issue)
+		}
+	}
+	// The backup directory is created on first backup, so only its parent must be writable
+	backupPath := getEnvOrDefault("CACHE_BACKUP_PATH", "./backups")
+	if issue, ok := checkWritableDir("CACHE_BACKUP_PATH", filepath.Dir(filepath.Clean(backupPath))); !ok {
+		check.warnings = append(check.warnings, issue)
+	}
+	return check
+}
+
+// checkSetup inspects the loaded configuration and reports which required settings
+// are missing, along with remediation hints. Secrets are only checked for presence,
+// never echoed back.
+func checkSetup(cfg config.Config, storage storageCheck) SetupReport {
+	report := SetupReport{
+		Profile:  cfg.Configuration.Profile,
+		Missing:  []SetupIssue{},
+		Warnings: []SetupIssue{},
+	}
+
+	// Upstream TTML API
+	if cfg.Configuration.TTMLTokenSourceURL == "" {
+		report.Missing = append(report.Missing, SetupIssue{
+			Setting: "TTML_TOKEN_SOURCE_URL",
+			Message: "Token source URL is not set, bearer tokens cannot be scraped",
+			Hint:    "Set TTML_TOKEN_SOURCE_URL to the web frontend URL that serves the bearer token",
+		})
+	}
+	for _, s := range []struct{ name, value string }{
+		{"TTML_BASE_URL", cfg.Configuration.TTMLBaseURL},
+		{"TTML_SEARCH_PATH", cfg.Configuration.TTMLSearchPath},
+		{"TTML_LYRICS_PATH", cfg.Configuration.TTMLLyricsPath},
+	} {
+		if s.value == "" {
+			report.Missing = append(report.Missing, SetupIssue{
+				Setting: s.name,
+				Message: s.name + " is not set",
+				Hint:    "Copy the upstream API endpoints into .env (see .env.example)",
+			})
+		}
+	}
+
+	// Accounts
+	accounts, _ := cfg.GetTTMLAccounts()
+	allAccounts, _ := cfg.GetAllTTMLAccounts()
+	if len(accounts) == 0 {
+		report.Missing = append(report.Missing, SetupIssue{
+			Setting: "TTML_MEDIA_USER_TOKENS",
+			Message: "No active accounts configured",
+			Hint:    "Set TTML_MEDIA_USER_TOKENS to a comma-separated list of media user tokens (or TTML_MEDIA_USER_TOKEN for a single account)",
+		})
+	} else if len(allAccounts) > len(accounts) {
+		report.Warnings = append(report.Warnings, SetupIssue{
+			Setting: "TTML_MEDIA_USER_TOKENS",
+			Message: "Some accounts have an empty media user token and are excluded from rotation",
+			Hint:    "Remove the empty entries or fill in their tokens",
+		})
+	}
+
+	// Storage paths
+	report.Missing = append(report.Missing, storage.missing...)
+	report.Warnings = append(report.Warnings, storage.warnings...)
+
+	// Track scoring
+	if _, err := cfg.GetScoreWeights(); err != nil {
+		report.Warnings = append(report.Warnings, SetupIssue{
+			Setting: "SCORE_WEIGHT_*",
+			Message: "Scoring weights are invalid (" + err.Error() + "), defaults are used instead",
+			Hint:    "Set SCORE_WEIGHT_NAME, SCORE_WEIGHT_ARTIST and SCORE_WEIGHT_ALBUM to values in [0, 1] that sum to 1.0",
+		})
+	}
+
+	if _, err := cfg.GetMinScoreMatrix(); err != nil {
+		report.Warnings = append(report.Warnings, SetupIssue{
+			Setting: "MIN_SCORE_MATRIX",
+			Message: "Score threshold matrix is invalid (" + err.Error() + "), MIN_SIMILARITY_SCORE is used for all queries",
+			Hint:    "Use comma-separated fields:score entries, e.g. song:0.45,song+artist+album:0.65",
+		})
+	}
+
+	// Admin access
+	if cfg.Configuration.CacheAccessToken == "" {
+		report.Warnings = append(report.Warnings, SetupIssue{
+			Setting: "CACHE_ACCESS_TOKEN",
+			Message: "Admin access token is not set",
+			Hint:    "Set CACHE_ACCESS_TOKEN to a random secret to protect the /cache/* and /stats endpoints",
+		})
+	}
+
+	report.Ready = len(report.Missing) == 0
+	return report
+}
+
+// checkWritableDir verifies that dir exists and that a file can be created in it
+func checkWritableDir(setting, dir string) (SetupIssue, bool) {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return SetupIssue{
+			Setting: setting,
+			Message: "Directory " + dir + " does not exist",
+			Hint:    "Create the directory or mount a volume there (the selfhost profile expects a volume at /data)",
+		}, false
+	}
+
+	f, err := os.CreateTemp(dir, ".setup-check-*")
+	if err != nil {
+		return SetupIssue{
+			Setting: setting,
+			Message: "Directory " + dir + " is not writable",
+			Hint:    "Fix the directory permissions for the user running the API",
+		}, false
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	return SetupIssue{}, true
+}
+
+// setupCheckHandler reports missing settings for first-run self-hosting. Until
+// CACHE_ACCESS_TOKEN is set it is open, as it is most useful before the token
+// exists; from then on it requires the token like the other admin endpoints.
+// It only reports presence of settings, never their values.
+func setupCheckHandler(w http.ResponseWriter, r *http.Request) {
+	if token := conf().Configuration.CacheAccessToken; token != "" && r.Header.Get("Authorization") != token {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	report := checkSetup(*conf(), startupStorageCheck())
+	if !report.Ready {
+		Respond(w, r).Error(http.StatusServiceUnavailable, report)
+		return
+	}
+	Respond(w, r).JSON(report)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/config"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckSetup_ReportsMissingSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CACHE_DB_PATH", filepath.Join(tmpDir, "cache.db"))
+	t.Setenv("STATS_DB_PATH", filepath.Join(tmpDir, "missing", "stats.db"))
+	t.Setenv("CACHE_BACKUP_PATH", filepath.Join(tmpDir, "backups"))
+
+	var cfg config.Config
+	cfg.Configuration.Profile = config.ProfileSelfHost
+
+	report := checkSetup(cfg, checkStorage())
+
+	if report.Ready {
+		t.Error("Expected report to not be ready with empty config")
+	}
+	if report.Profile != config.ProfileSelfHost {
+		t.Errorf("Profile = %q, want %q", report.Profile, config.ProfileSelfHost)
+	}
+
+	missing := make(map[string]bool)
+	for _, issue := range report.Missing {
+		missing[issue.Setting] = true
+		if issue.Hint == "" {
+			t.Errorf("Missing setting %s has no hint", issue.Setting)
+		}
+	}
+	for _, setting := range []string{"TTML_TOKEN_SOURCE_URL", "TTML_BASE_URL", "TTML_MEDIA_USER_TOKENS", "STATS_DB_PATH"} {
+		if !missing[setting] {
+			t.Errorf("Expected %s to be reported missing", setting)
+		}
+	}
+	if missing["CACHE_DB_PATH"] {
+		t.Error("CACHE_DB_PATH points at a writable directory and should not be reported")
+	}
+}
+
+func TestCheckSetup_Ready(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CACHE_DB_PATH", filepath.Join(tmpDir, "cache.db"))
+	t.Setenv("STATS_DB_PATH", filepath.Join(tmpDir, "stats.db"))
+	t.Setenv("CACHE_BACKUP_PATH", filepath.Join(tmpDir, "backups"))
+
+	var cfg config.Config
+	cfg.Configuration.TTMLTokenSourceURL = "https://example.com"
+	cfg.Configuration.TTMLBaseURL = "https://api.example.com"
+	cfg.Configuration.TTMLSearchPath = "/search"
+	cfg.Configuration.TTMLLyricsPath = "/lyrics"
+	cfg.Configuration.TTMLMediaUserTokens = "mut1,,mut3"
+	cfg.Configuration.CacheAccessToken = "secret"
+
+	report := checkSetup(cfg, checkStorage())
+
+	if !report.Ready {
+		t.Errorf("Expected report to be ready, missing: %+v", report.Missing)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Setting != "TTML_MEDIA_USER_TOKENS" {
+		t.Errorf("Expected a single warning about empty account tokens, got %+v", report.Warnings)
+	}
+}
+
+func TestSetupCheckHandler_NoAuthRequired(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = ""
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/setup/check", nil)
+
+	setupCheckHandler(w, r)
+
+	if w.Code != http.StatusOK && w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 200 or 503", w.Code)
+	}
+
+	var body SetupReport
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Ready != (w.Code == http.StatusOK) {
+		t.Errorf("ready = %v does not match status %d", body.Ready, w.Code)
+	}
+}
+
+func TestSetupCheckHandler_RequiresTokenOnceSet(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "secret"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	w := httptest.NewRecorder()
+	setupCheckHandler(w, httptest.NewRequest(http.MethodGet, "/setup/check", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d without the token, want 401", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/setup/check", nil)
+	r.Header.Set("Authorization", "secret")
+	setupCheckHandler(w, r)
+	if w.Code != http.StatusOK && w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d with the token, want 200 or 503", w.Code)
+	}
+}
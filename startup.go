@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"fmt"
+	"lyrics-api-go/config"
 	"lyrics-api-go/logcolors"
 	"lyrics-api-go/middleware"
+	"lyrics-api-go/redact"
 	"lyrics-api-go/services/notifier"
 	"lyrics-api-go/stats"
 	"net/http"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -20,6 +23,28 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// registerSecrets hands every configured secret to the redaction layer so it is
+// masked in logs, error responses and notifications. The scraped bearer token is
+// registered by the TTML provider each time it is refreshed.
+func registerSecrets(cfg *config.Config) {
+	c := cfg.Configuration
+	redact.Register(
+		c.CacheAccessToken, c.APIKey, c.BiniAPIKey, c.BiniSecretKey, c.ProxyAPIKey,
+		c.TTMLMediaUserToken, c.CookieValue, c.ClientSecret, c.RemoteConfigSecret,
+		os.Getenv("NOTIFIER_SMTP_PASSWORD"),
+		os.Getenv("NOTIFIER_TELEGRAM_BOT_TOKEN"),
+		os.Getenv("NOTIFIER_WEBHOOK_SECRET"),
+	)
+	redact.Register(strings.Split(c.TTMLMediaUserTokens, ",")...)
+}
+
+// applyReloadedConfig masks any tokens a remote config change rotated in. The
+// main package reads the config through conf(), so it sees the change already.
+func applyReloadedConfig(c config.Config, changed []string) {
+	registerSecrets(&c)
+	notifier.PublishConfigReloaded(c.Configuration.RemoteConfigURL, changed)
+}
+
 func getNotifierTypeName(n notifier.Notifier) string {
 	switch n.(type) {
 	case *notifier.EmailNotifier:
@@ -70,11 +95,53 @@ func setupNotifiers() []notifier.Notifier {
 	return notifiers
 }
 
+// eventStatsRecorder counts every event on the bus so /stats shows how often
+// breakers tripped, accounts were quarantined, etc.
+type eventStatsRecorder struct{}
+
+func (eventStatsRecorder) Name() string { return "stats" }
+
+func (eventStatsRecorder) HandleEvent(event *notifier.Event) {
+	stats.Get().RecordEvent(string(event.Type))
+}
+
+// registerEventSubscribers attaches the always-on consumers (stats, audit log) to the event bus.
+// The alert handler registers itself separately since it needs notifiers configured.
+func registerEventSubscribers() {
+	bus := notifier.GetEventBus()
+	bus.Register(eventStatsRecorder{})
+	bus.Register(auditEventRecorder{}, notifier.EventAccountDisabled, notifier.EventAccountEnabled)
+
+	if url := os.Getenv("NOTIFIER_WEBHOOK_URL"); url != "" {
+		bus.Register(&notifier.WebhookSubscriber{
+			URL:    url,
+			Secret: os.Getenv("NOTIFIER_WEBHOOK_SECRET"),
+		})
+		log.Infof("%s Webhook event subscriber enabled", logcolors.LogNotifier)
+	}
+}
+
+// setRateLimitHeaders reports the tier that admitted (or rejected) the request.
+// X-RateLimit-Reset is the number of seconds until that tier is back to a full burst.
+func setRateLimitHeaders(w http.ResponseWriter, tier string, limit, remaining, reset int) {
+	w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
+	w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+	w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", reset))
+	w.Header().Set("X-RateLimit-Type", tier)
+}
+
 func limitMiddleware(next http.Handler, limiter *middleware.IPRateLimiter) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Tenants have their own quota instead (see tenantMiddleware)
+		if requestTenant(r) != "" {
+			ctx := context.WithValue(r.Context(), rateLimitTypeKey, "tenant")
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// Check for API key to bypass rate limits
 		apiKey := r.Header.Get("X-API-Key")
-		if apiKey != "" && conf.Configuration.APIKey != "" && apiKey == conf.Configuration.APIKey {
+		if apiKey != "" && conf().Configuration.APIKey != "" && apiKey == conf().Configuration.APIKey {
 			w.Header().Set("X-RateLimit-Bypass", "true")
 			ctx := context.WithValue(r.Context(), rateLimitTypeKey, "bypass")
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -87,10 +154,7 @@ func limitMiddleware(next http.Handler, limiter *middleware.IPRateLimiter) http.
 		if limiters.Normal.Allow() {
 			// Normal tier allows this request
 			stats.Get().RecordRateLimit("normal")
-			remainingNormal := limiters.GetNormalTokens()
-			w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.GetNormalLimit()))
-			w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remainingNormal))
-			w.Header().Set("X-RateLimit-Type", "normal")
+			setRateLimitHeaders(w, "normal", limiter.GetNormalLimit(), limiters.GetNormalTokens(), limiters.GetNormalReset())
 			ctx := context.WithValue(r.Context(), rateLimitTypeKey, "normal")
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
@@ -100,10 +164,7 @@ func limitMiddleware(next http.Handler, limiter *middleware.IPRateLimiter) http.
 		if limiters.Cached.Allow() {
 			// Cached tier allows, but only for cached responses
 			stats.Get().RecordRateLimit("cached")
-			remainingCached := limiters.GetCachedTokens()
-			w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.GetCachedLimit()))
-			w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remainingCached))
-			w.Header().Set("X-RateLimit-Type", "cached")
+			setRateLimitHeaders(w, "cached", limiter.GetCachedLimit(), limiters.GetCachedTokens(), limiters.GetCachedReset())
 			log.Debugf("%s IP %s exceeded normal tier, using cached tier", logcolors.LogRateLimit, r.RemoteAddr)
 			ctx := context.WithValue(r.Context(), cacheOnlyModeKey, true)
 			ctx = context.WithValue(ctx, rateLimitTypeKey, "cached")
@@ -114,10 +175,10 @@ func limitMiddleware(next http.Handler, limiter *middleware.IPRateLimiter) http.
 		// Both tiers exceeded
 		stats.Get().RecordRateLimit("exceeded")
 		log.Warnf("%s IP %s exceeded both rate limit tiers", logcolors.LogRateLimit, r.RemoteAddr)
-		w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.GetCachedLimit()))
-		w.Header().Set("X-RateLimit-Remaining", "0")
-		w.Header().Set("X-RateLimit-Type", "exceeded")
-		w.Header().Set("Retry-After", "1")
-		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		setRateLimitHeaders(w, "exceeded", limiter.GetCachedLimit(), 0, limiters.GetCachedReset())
+		Respond(w, r).RateLimited("cached", limiters.GetCachedRetryAfter(), map[string]interface{}{
+			"error":   "Rate limit exceeded",
+			"message": "Both the normal and cached tiers are exhausted for this IP. Retry after the indicated delay.",
+		})
 	})
 }
@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// Per-account upstream attempts are kept in 10-minute buckets covering 7 days, so
+// /accounts/usage can report any window up to a week. Not persisted: the lifetime
+// success count (RecordAccountUsage) is what survives restarts.
+const (
+	accountBucketWidth = 10 * time.Minute
+	accountBucketCount = 7 * 24 * 6
+
+	// MaxAccountWindow is the longest window AccountAttempts can answer
+	MaxAccountWindow = accountBucketCount * accountBucketWidth
+)
+
+// AccountAttemptCounts are upstream attempts made with one account over a window
+type AccountAttemptCounts struct {
+	Requests    int64 `json:"requests"`
+	RateLimited int64 `json:"rate_limited"` // 429s
+	Errors      int64 `json:"errors"`       // Transport errors, 401 and 5xx
+	Retries     int64 `json:"retries"`      // Attempts that were a retry after another account failed
+}
+
+type accountBucket struct {
+	slot int64 // Bucket start / width; a stale slot means the bucket is reused
+	AccountAttemptCounts
+}
+
+type accountAttempts struct {
+	mu      sync.Mutex
+	buckets [accountBucketCount]accountBucket
+}
+
+// RecordAccountAttempt records one upstream request made with an account. status is
+// 0 when the request failed before a response; retry marks attempts made after a
+// previous account failed the same request.
+func (s *Stats) RecordAccountAttempt(accountName string, status int, err error, retry bool) {
+	s.recordAccountAttemptAt(time.Now(), accountName, status, err, retry)
+}
+
+func (s *Stats) recordAccountAttemptAt(now time.Time, accountName string, status int, err error, retry bool) {
+	value, _ := s.accountAttempts.LoadOrStore(accountName, &accountAttempts{})
+	a := value.(*accountAttempts)
+
+	slot := now.UnixNano() / int64(accountBucketWidth)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b := &a.buckets[slot%accountBucketCount]
+	if b.slot != slot {
+		*b = accountBucket{slot: slot}
+	}
+	b.Requests++
+	switch {
+	case status == 429:
+		b.RateLimited++
+	case err != nil || status == 401 || status >= 500:
+		b.Errors++
+	}
+	if retry {
+		b.Retries++
+	}
+}
+
+// AccountAttempts returns per-account attempt counts over the last window (capped at
+// MaxAccountWindow, rounded to whole 10-minute buckets)
+func (s *Stats) AccountAttempts(window time.Duration) map[string]AccountAttemptCounts {
+	return s.accountAttemptsAt(time.Now(), window)
+}
+
+func (s *Stats) accountAttemptsAt(now time.Time, window time.Duration) map[string]AccountAttemptCounts {
+	window = min(window, MaxAccountWindow)
+	newest := now.UnixNano() / int64(accountBucketWidth)
+	oldest := newest - int64((window+accountBucketWidth-1)/accountBucketWidth) + 1
+
+	result := make(map[string]AccountAttemptCounts)
+	s.accountAttempts.Range(func(key, value interface{}) bool {
+		a := value.(*accountAttempts)
+		var total AccountAttemptCounts
+		a.mu.Lock()
+		for i := range a.buckets {
+			b := &a.buckets[i]
+			if b.slot < oldest || b.slot > newest {
+				continue
+			}
+			total.Requests += b.Requests
+			total.RateLimited += b.RateLimited
+			total.Errors += b.Errors
+			total.Retries += b.Retries
+		}
+		a.mu.Unlock()
+		result[key.(string)] = total
+		return true
+	})
+	return result
+}
@@ -0,0 +1,45 @@
+package stats
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAccountAttempts_Classification(t *testing.T) {
+	s := newStats()
+	now := time.Now()
+	s.recordAccountAttemptAt(now, "Billie", 200, nil, false)
+	s.recordAccountAttemptAt(now, "Billie", 429, nil, false)
+	s.recordAccountAttemptAt(now, "Billie", 503, nil, true)
+	s.recordAccountAttemptAt(now, "Billie", 0, fmt.Errorf("timeout"), true)
+	s.recordAccountAttemptAt(now, "Billie", 404, nil, false)
+
+	got := s.accountAttemptsAt(now, time.Hour)["Billie"]
+	want := AccountAttemptCounts{Requests: 5, RateLimited: 1, Errors: 2, Retries: 2}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestAccountAttempts_Window(t *testing.T) {
+	s := newStats()
+	now := time.Now()
+	s.recordAccountAttemptAt(now.Add(-3*time.Hour), "Dua", 200, nil, false)
+	s.recordAccountAttemptAt(now.Add(-30*time.Minute), "Dua", 200, nil, false)
+	s.recordAccountAttemptAt(now, "Dua", 200, nil, false)
+
+	if got := s.accountAttemptsAt(now, time.Hour)["Dua"].Requests; got != 2 {
+		t.Errorf("1h window: expected 2 requests, got %d", got)
+	}
+	if got := s.accountAttemptsAt(now, 6*time.Hour)["Dua"].Requests; got != 3 {
+		t.Errorf("6h window: expected 3 requests, got %d", got)
+	}
+
+	// A week later the ring has wrapped; old buckets must not leak into new ones
+	later := now.Add(MaxAccountWindow)
+	s.recordAccountAttemptAt(later, "Dua", 200, nil, false)
+	if got := s.accountAttemptsAt(later, MaxAccountWindow)["Dua"].Requests; got != 1 {
+		t.Errorf("after wrap: expected 1 request, got %d", got)
+	}
+}
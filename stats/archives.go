@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// archivesBucketName holds the stats snapshots taken by ArchiveAndReset, keyed by
+// a sortable timestamp ID
+const archivesBucketName = "stats_archives"
+
+// archiveIDLayout sorts chronologically as a byte string
+const archiveIDLayout = "20060102T150405.000000000Z"
+
+// StatsArchiveInfo describes one archived measurement window
+type StatsArchiveInfo struct {
+	ID          string    `json:"id"`
+	WindowStart time.Time `json:"window_start"`
+	ArchivedAt  time.Time `json:"archived_at"`
+	Note        string    `json:"note,omitempty"`
+}
+
+// StatsArchive is an archived window with the /stats snapshot taken at its end
+type StatsArchive struct {
+	StatsArchiveInfo
+	Snapshot json.RawMessage `json:"snapshot"`
+}
+
+// ArchiveAndReset stores the current snapshot as an archive, then resets the
+// counters and persists the zeroed stats, so a restart doesn't bring them back
+func (s *Store) ArchiveAndReset(note string) (StatsArchiveInfo, error) {
+	stats := Get()
+	now := time.Now().UTC()
+	info := StatsArchiveInfo{
+		ID:          now.Format(archiveIDLayout),
+		WindowStart: stats.StatsSince().UTC(),
+		ArchivedAt:  now,
+		Note:        note,
+	}
+	snapshot, err := json.Marshal(stats.Snapshot())
+	if err != nil {
+		return info, fmt.Errorf("failed to marshal stats snapshot: %v", err)
+	}
+	data, err := json.Marshal(StatsArchive{StatsArchiveInfo: info, Snapshot: snapshot})
+	if err != nil {
+		return info, err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(archivesBucketName))
+		if b == nil {
+			return fmt.Errorf("archives bucket not found")
+		}
+		return b.Put([]byte(info.ID), data)
+	})
+	if err != nil {
+		return info, fmt.Errorf("failed to archive stats: %v", err)
+	}
+
+	stats.Reset(now)
+	return info, s.Save()
+}
+
+// StatsArchives lists the archived windows, newest first
+func (s *Store) StatsArchives() ([]StatsArchiveInfo, error) {
+	archives := []StatsArchiveInfo{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(archivesBucketName))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var archive StatsArchive
+			if json.Unmarshal(v, &archive) == nil {
+				archives = append(archives, archive.StatsArchiveInfo)
+			}
+		}
+		return nil
+	})
+	return archives, err
+}
+
+// StatsArchive returns the archive stored under id
+func (s *Store) StatsArchive(id string) (StatsArchive, bool, error) {
+	var archive StatsArchive
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(archivesBucketName))
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &archive)
+	})
+	return archive, found, err
+}
+
+// PruneStatsArchives deletes archives taken before cutoff and returns how many went
+func (s *Store) PruneStatsArchives(cutoff time.Time) (int, error) {
+	pruned := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(archivesBucketName))
+		if b == nil {
+			return nil
+		}
+		limit := cutoff.UTC().Format(archiveIDLayout)
+		var expired [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && string(k) < limit; k, _ = c.Next() {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+			pruned++
+		}
+		return nil
+	})
+	return pruned, err
+}
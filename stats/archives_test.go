@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestArchiveAndReset(t *testing.T) {
+	store := newTestStore(t)
+	s := Get()
+	s.Reset(time.Now())
+	s.RecordRequest("/getLyrics")
+	s.RecordRequest("/getLyrics")
+	s.RecordCacheHit()
+	s.RecordAccountUsage("Alpha")
+	s.RecordUserAgent("test-agent")
+	before := s.StatsSince()
+
+	info, err := store.ArchiveAndReset("new scoring weights")
+	if err != nil {
+		t.Fatalf("ArchiveAndReset: %v", err)
+	}
+	if info.Note != "new scoring weights" || !info.WindowStart.Equal(before.UTC()) {
+		t.Errorf("Unexpected archive info: %+v", info)
+	}
+	if s.TotalRequests.Load() != 0 || s.CacheHits.Load() != 0 || len(s.AccountUsageSnapshot()) != 0 || len(s.UserAgentSnapshot()) != 0 {
+		t.Error("Counters should be zero after the reset")
+	}
+	if !s.StatsSince().After(before) {
+		t.Errorf("StatsSince should move to the reset, got %v (was %v)", s.StatsSince(), before)
+	}
+
+	list, err := store.StatsArchives()
+	if err != nil || len(list) != 1 || list[0].ID != info.ID {
+		t.Fatalf("StatsArchives = %+v, %v", list, err)
+	}
+	archive, ok, err := store.StatsArchive(info.ID)
+	if err != nil || !ok {
+		t.Fatalf("StatsArchive(%s) = %v, %v", info.ID, ok, err)
+	}
+	var snapshot struct {
+		Requests struct {
+			Total int64 `json:"total"`
+		} `json:"requests"`
+	}
+	if err := json.Unmarshal(archive.Snapshot, &snapshot); err != nil || snapshot.Requests.Total != 2 {
+		t.Errorf("Archived snapshot should keep the old counts, got %s", archive.Snapshot)
+	}
+	if _, ok, _ := store.StatsArchive("missing"); ok {
+		t.Error("Unknown archive ID should not be found")
+	}
+
+	// The reset survives a reload
+	s.StartTime = time.Now().Add(-time.Hour)
+	if err := store.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if s.TotalRequests.Load() != 0 || !s.StatsSince().Equal(info.ArchivedAt) {
+		t.Errorf("Reload should keep the reset, got total %d since %v", s.TotalRequests.Load(), s.StatsSince())
+	}
+}
+
+func TestPruneStatsArchives(t *testing.T) {
+	store := newTestStore(t)
+	first, _ := store.ArchiveAndReset("")
+	time.Sleep(time.Millisecond)
+	second, _ := store.ArchiveAndReset("")
+
+	pruned, err := store.PruneStatsArchives(second.ArchivedAt)
+	if err != nil || pruned != 1 {
+		t.Fatalf("PruneStatsArchives = %d, %v", pruned, err)
+	}
+	list, _ := store.StatsArchives()
+	if len(list) != 1 || list[0].ID != second.ID || list[0].ID == first.ID {
+		t.Errorf("Expected only the newer archive left, got %+v", list)
+	}
+}
@@ -0,0 +1,143 @@
+package stats
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// auditBucketName lives in the stats DB (not the cache DB) so the trail survives
+// cache clears and restores - the operations it is most likely asked about.
+const auditBucketName = "audit"
+
+// Audit roles
+const (
+	AuditRoleAdmin  = "admin"   // CACHE_ACCESS_TOKEN
+	AuditRoleAPIKey = "api_key" // X-API-Key
+	AuditRoleSystem = "system"  // Internal actions (health checks, schedulers)
+)
+
+// AuditEntry is one recorded admin or destructive operation
+type AuditEntry struct {
+	ID        uint64            `json:"id"`
+	Timestamp time.Time         `json:"timestamp"`
+	Action    string            `json:"action"`
+	Role      string            `json:"role"`
+	RemoteIP  string            `json:"remote_ip,omitempty"`
+	Method    string            `json:"method,omitempty"`
+	Path      string            `json:"path,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	Status    int               `json:"status,omitempty"`
+}
+
+// AuditFilter narrows QueryAudit results. Zero values match everything.
+type AuditFilter struct {
+	Action   string // Exact action, or a dotted prefix ("cache" matches "cache.clear")
+	Role     string
+	RemoteIP string
+	Since    time.Time
+	Until    time.Time
+	Limit    int // 0 = no limit
+}
+
+func (f AuditFilter) matches(e *AuditEntry) bool {
+	if f.Action != "" && e.Action != f.Action && !strings.HasPrefix(e.Action, f.Action+".") {
+		return false
+	}
+	if f.Role != "" && e.Role != f.Role {
+		return false
+	}
+	if f.RemoteIP != "" && e.RemoteIP != f.RemoteIP {
+		return false
+	}
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// AppendAudit stores an entry under the next bucket sequence. The log is append-only:
+// there is no update or delete, and IDs are assigned here (any ID on the input is ignored).
+func (s *Store) AppendAudit(entry AuditEntry) (AuditEntry, error) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(auditBucketName))
+		if b == nil {
+			return fmt.Errorf("audit bucket not found")
+		}
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		entry.ID = id
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return b.Put(auditKey(id), data)
+	})
+	if err != nil {
+		return AuditEntry{}, fmt.Errorf("failed to append audit entry: %v", err)
+	}
+	return entry, nil
+}
+
+// QueryAudit returns matching entries, newest first
+func (s *Store) QueryAudit(filter AuditFilter) ([]AuditEntry, error) {
+	entries := []AuditEntry{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(auditBucketName))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var entry AuditEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				continue
+			}
+			if !filter.matches(&entry) {
+				continue
+			}
+			entries = append(entries, entry)
+			if filter.Limit > 0 && len(entries) >= filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return entries, nil
+}
+
+// AuditCount returns the total number of audit entries
+func (s *Store) AuditCount() int {
+	count := 0
+	s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(auditBucketName)); b != nil {
+			count = b.Stats().KeyN
+		}
+		return nil
+	})
+	return count
+}
+
+// auditKey encodes the sequence big-endian so cursor order is insertion order
+func auditKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStore(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestAppendAudit_AssignsSequentialIDs(t *testing.T) {
+	store := newTestStore(t)
+
+	for i := 0; i < 3; i++ {
+		entry, err := store.AppendAudit(AuditEntry{ID: 99, Action: "cache.clear", Role: AuditRoleAdmin})
+		if err != nil {
+			t.Fatalf("AppendAudit failed: %v", err)
+		}
+		if entry.ID != uint64(i+1) {
+			t.Errorf("Expected ID %d, got %d", i+1, entry.ID)
+		}
+		if entry.Timestamp.IsZero() {
+			t.Error("Expected timestamp to be set")
+		}
+	}
+	if got := store.AuditCount(); got != 3 {
+		t.Errorf("Expected 3 entries, got %d", got)
+	}
+}
+
+func TestQueryAudit_Filters(t *testing.T) {
+	store := newTestStore(t)
+	base := time.Unix(1700000000, 0)
+
+	seed := []AuditEntry{
+		{Timestamp: base, Action: "cache.clear", Role: AuditRoleAdmin, RemoteIP: "10.0.0.1"},
+		{Timestamp: base.Add(time.Minute), Action: "cache.restore", Role: AuditRoleAdmin, RemoteIP: "10.0.0.2"},
+		{Timestamp: base.Add(2 * time.Minute), Action: "override.create", Role: AuditRoleAPIKey, RemoteIP: "10.0.0.1"},
+		{Timestamp: base.Add(3 * time.Minute), Action: "account.disable", Role: AuditRoleSystem},
+		{Timestamp: base.Add(4 * time.Minute), Action: "cachex.other", Role: AuditRoleAdmin},
+	}
+	for _, e := range seed {
+		if _, err := store.AppendAudit(e); err != nil {
+			t.Fatalf("AppendAudit failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		filter  AuditFilter
+		actions []string
+	}{
+		{"All newest first", AuditFilter{}, []string{"cachex.other", "account.disable", "override.create", "cache.restore", "cache.clear"}},
+		{"Action prefix", AuditFilter{Action: "cache"}, []string{"cache.restore", "cache.clear"}},
+		{"Exact action", AuditFilter{Action: "cache.clear"}, []string{"cache.clear"}},
+		{"Role", AuditFilter{Role: AuditRoleSystem}, []string{"account.disable"}},
+		{"IP", AuditFilter{RemoteIP: "10.0.0.1"}, []string{"override.create", "cache.clear"}},
+		{"Time window", AuditFilter{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute)}, []string{"override.create", "cache.restore"}},
+		{"Limit", AuditFilter{Limit: 2}, []string{"cachex.other", "account.disable"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := store.QueryAudit(tt.filter)
+			if err != nil {
+				t.Fatalf("QueryAudit failed: %v", err)
+			}
+			if len(entries) != len(tt.actions) {
+				t.Fatalf("Expected %d entries, got %d: %+v", len(tt.actions), len(entries), entries)
+			}
+			for i, action := range tt.actions {
+				if entries[i].Action != action {
+					t.Errorf("Entry %d: expected %s, got %s", i, action, entries[i].Action)
+				}
+			}
+		})
+	}
+}
+
+func TestAudit_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.db")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	store.AppendAudit(AuditEntry{Action: "cache.clear", Params: map[string]string{"backup": "x.db"}})
+	store.Close()
+
+	store, err = NewStore(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer store.Close()
+
+	entry, _ := store.AppendAudit(AuditEntry{Action: "cache.restore"})
+	if entry.ID != 2 {
+		t.Errorf("Expected sequence to continue at 2, got %d", entry.ID)
+	}
+	entries, _ := store.QueryAudit(AuditFilter{Action: "cache.clear"})
+	if len(entries) != 1 || entries[0].Params["backup"] != "x.db" {
+		t.Errorf("Persisted entry not found: %+v", entries)
+	}
+}
@@ -0,0 +1,273 @@
+package stats
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"lyrics-api-go/config"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// bandwidthBucketName holds the daily bandwidth rollup: one key per (day, endpoint,
+// API key, cache status) with the request count and response bytes sent
+const bandwidthBucketName = "bandwidth"
+
+// BandwidthRow is one row of the daily bandwidth rollup
+type BandwidthRow struct {
+	Day         string `json:"day"`
+	Endpoint    string `json:"endpoint"`
+	APIKey      string `json:"api_key"`
+	CacheStatus string `json:"cache_status"`
+	Requests    int64  `json:"requests"`
+	Bytes       int64  `json:"bytes"`
+}
+
+func (r BandwidthRow) key() string {
+	return strings.Join([]string{r.Day, r.Endpoint, r.APIKey, r.CacheStatus}, usageKeySep)
+}
+
+func parseBandwidthKey(key string) (BandwidthRow, bool) {
+	parts := strings.SplitN(key, usageKeySep, 4)
+	if len(parts) != 4 {
+		return BandwidthRow{}, false
+	}
+	return BandwidthRow{Day: parts[0], Endpoint: parts[1], APIKey: parts[2], CacheStatus: parts[3]}, true
+}
+
+// BandwidthCount is the traffic of one endpoint, API key or cache status
+type BandwidthCount struct {
+	Requests int64 `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+}
+
+func (c *BandwidthCount) add(requests, bytes int64) {
+	c.Requests += requests
+	c.Bytes += bytes
+}
+
+// BandwidthUsage is the response bytes sent since the last reset, broken down
+// three ways
+type BandwidthUsage struct {
+	Total         BandwidthCount            `json:"total"`
+	ByEndpoint    map[string]BandwidthCount `json:"by_endpoint"`
+	ByAPIKey      map[string]BandwidthCount `json:"by_api_key"`
+	ByCacheStatus map[string]BandwidthCount `json:"by_cache_status"`
+}
+
+// bandwidthTotals backs BandwidthUsage
+type bandwidthTotals struct {
+	mu            sync.Mutex
+	total         BandwidthCount
+	byEndpoint    map[string]*BandwidthCount
+	byAPIKey      map[string]*BandwidthCount
+	byCacheStatus map[string]*BandwidthCount
+}
+
+func (t *bandwidthTotals) record(endpoint, apiKey, cacheStatus string, bytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total.add(1, bytes)
+	for _, dim := range []struct {
+		counts *map[string]*BandwidthCount
+		label  string
+	}{
+		{&t.byEndpoint, endpoint},
+		{&t.byAPIKey, apiKey},
+		{&t.byCacheStatus, cacheStatus},
+	} {
+		if *dim.counts == nil {
+			*dim.counts = make(map[string]*BandwidthCount)
+		}
+		count, ok := (*dim.counts)[dim.label]
+		if !ok {
+			count = &BandwidthCount{}
+			(*dim.counts)[dim.label] = count
+		}
+		count.add(1, bytes)
+	}
+}
+
+func (t *bandwidthTotals) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total = BandwidthCount{}
+	t.byEndpoint, t.byAPIKey, t.byCacheStatus = nil, nil, nil
+}
+
+func copyBandwidthCounts(counts map[string]*BandwidthCount) map[string]BandwidthCount {
+	result := make(map[string]BandwidthCount, len(counts))
+	for label, count := range counts {
+		result[label] = *count
+	}
+	return result
+}
+
+// bandwidthBuffer collects rollup rows between flushes to the stats DB
+type bandwidthBuffer struct {
+	mu   sync.Mutex
+	rows map[string]BandwidthCount
+}
+
+var pendingBandwidth = &bandwidthBuffer{rows: make(map[string]BandwidthCount)}
+
+// RecordBandwidth counts the response bytes of one request. apiKey is a label for
+// the caller's key (a tenant name, never the key itself); an empty cacheStatus is
+// recorded as NONE.
+func (s *Stats) RecordBandwidth(endpoint, apiKey, cacheStatus string, bytes int64) {
+	if cacheStatus == "" {
+		cacheStatus = "NONE"
+	}
+	s.bandwidth.record(endpoint, apiKey, cacheStatus, bytes)
+
+	row := BandwidthRow{
+		Day:         time.Now().UTC().Format(time.DateOnly),
+		Endpoint:    strings.ReplaceAll(endpoint, usageKeySep, " "),
+		APIKey:      strings.ReplaceAll(apiKey, usageKeySep, " "),
+		CacheStatus: strings.ReplaceAll(cacheStatus, usageKeySep, " "),
+	}
+	pendingBandwidth.mu.Lock()
+	defer pendingBandwidth.mu.Unlock()
+	key := row.key()
+	if _, exists := pendingBandwidth.rows[key]; !exists && len(pendingBandwidth.rows) >= maxPendingUsageRows {
+		row.Endpoint = usageOverflowQuery
+		key = row.key()
+	}
+	count := pendingBandwidth.rows[key]
+	count.add(1, bytes)
+	pendingBandwidth.rows[key] = count
+}
+
+// BandwidthUsage returns the bytes sent since the last reset
+func (s *Stats) BandwidthUsage() BandwidthUsage {
+	t := &s.bandwidth
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return BandwidthUsage{
+		Total:         t.total,
+		ByEndpoint:    copyBandwidthCounts(t.byEndpoint),
+		ByAPIKey:      copyBandwidthCounts(t.byAPIKey),
+		ByCacheStatus: copyBandwidthCounts(t.byCacheStatus),
+	}
+}
+
+// drainPendingBandwidth takes the buffered rows, leaving an empty buffer
+func drainPendingBandwidth() map[string]BandwidthCount {
+	pendingBandwidth.mu.Lock()
+	defer pendingBandwidth.mu.Unlock()
+	rows := pendingBandwidth.rows
+	pendingBandwidth.rows = make(map[string]BandwidthCount)
+	return rows
+}
+
+// requeuePendingBandwidth puts rows back after a failed flush
+func requeuePendingBandwidth(rows map[string]BandwidthCount) {
+	pendingBandwidth.mu.Lock()
+	defer pendingBandwidth.mu.Unlock()
+	for key, count := range rows {
+		pending := pendingBandwidth.rows[key]
+		pending.add(count.Requests, count.Bytes)
+		pendingBandwidth.rows[key] = pending
+	}
+}
+
+func encodeBandwidthCount(count BandwidthCount) []byte {
+	value := make([]byte, 16)
+	binary.BigEndian.PutUint64(value, uint64(count.Requests))
+	binary.BigEndian.PutUint64(value[8:], uint64(count.Bytes))
+	return value
+}
+
+func decodeBandwidthCount(value []byte) (BandwidthCount, bool) {
+	if len(value) != 16 {
+		return BandwidthCount{}, false
+	}
+	return BandwidthCount{
+		Requests: int64(binary.BigEndian.Uint64(value)),
+		Bytes:    int64(binary.BigEndian.Uint64(value[8:])),
+	}, true
+}
+
+// flushBandwidth merges buffered rows into the bandwidth bucket and drops days past
+// USAGE_RETENTION_DAYS. REQUIRES: caller holds s.mu.
+func (s *Store) flushBandwidth() error {
+	rows := drainPendingBandwidth()
+	retentionDays := config.Get().Configuration.UsageRetentionDays
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bandwidthBucketName))
+		if b == nil {
+			return fmt.Errorf("bandwidth bucket not found")
+		}
+		for key, count := range rows {
+			if existing, ok := decodeBandwidthCount(b.Get([]byte(key))); ok {
+				count.add(existing.Requests, existing.Bytes)
+			}
+			if err := b.Put([]byte(key), encodeBandwidthCount(count)); err != nil {
+				return err
+			}
+		}
+
+		if retentionDays <= 0 {
+			return nil
+		}
+		// Keys start with the day, so expired rows are a prefix of the bucket
+		cutoff := time.Now().UTC().AddDate(0, 0, -retentionDays).Format(time.DateOnly)
+		var expired [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && string(k[:min(len(k), len(cutoff))]) < cutoff; k, _ = c.Next() {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		requeuePendingBandwidth(rows)
+		return fmt.Errorf("failed to flush bandwidth: %v", err)
+	}
+	return nil
+}
+
+// ExportBandwidth returns the daily bandwidth rows for days in [from, to]
+// (YYYY-MM-DD, inclusive). Buffered rows are flushed first.
+func (s *Store) ExportBandwidth(from, to string) ([]BandwidthRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.flushBandwidth(); err != nil {
+		return nil, err
+	}
+
+	rows := []BandwidthRow{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bandwidthBucketName))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Seek([]byte(from)); k != nil; k, v = c.Next() {
+			row, ok := parseBandwidthKey(string(k))
+			if !ok {
+				continue
+			}
+			if row.Day > to {
+				break
+			}
+			count, ok := decodeBandwidthCount(v)
+			if !ok {
+				continue
+			}
+			row.Requests, row.Bytes = count.Requests, count.Bytes
+			rows = append(rows, row)
+		}
+		return nil
+	})
+	return rows, err
+}
@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordBandwidth(t *testing.T) {
+	store := newTestStore(t)
+	drainPendingBandwidth()
+	s := newStats()
+
+	s.RecordBandwidth("/getLyrics", "anonymous", "HIT", 1000)
+	s.RecordBandwidth("/getLyrics", "tenant:extension", "HIT", 3000)
+	s.RecordBandwidth("/getLyrics", "tenant:extension", "MISS", 500)
+	s.RecordBandwidth("/health", "anonymous", "", 20)
+
+	usage := s.BandwidthUsage()
+	if usage.Total != (BandwidthCount{Requests: 4, Bytes: 4520}) {
+		t.Errorf("Total = %+v", usage.Total)
+	}
+	if got := usage.ByEndpoint["/getLyrics"]; got != (BandwidthCount{Requests: 3, Bytes: 4500}) {
+		t.Errorf("ByEndpoint[/getLyrics] = %+v", got)
+	}
+	if got := usage.ByAPIKey["tenant:extension"]; got != (BandwidthCount{Requests: 2, Bytes: 3500}) {
+		t.Errorf("ByAPIKey[tenant:extension] = %+v", got)
+	}
+	if got := usage.ByCacheStatus["NONE"]; got != (BandwidthCount{Requests: 1, Bytes: 20}) {
+		t.Errorf("ByCacheStatus[NONE] = %+v", got)
+	}
+
+	// The daily rollup survives a reset of the counters
+	s.Reset(time.Now())
+	if usage := s.BandwidthUsage(); usage.Total.Requests != 0 || len(usage.ByEndpoint) != 0 {
+		t.Errorf("Reset left %+v", usage)
+	}
+	today := time.Now().UTC().Format(time.DateOnly)
+	rows, err := store.ExportBandwidth(today, today)
+	if err != nil {
+		t.Fatalf("ExportBandwidth failed: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Expected 4 rows, got %+v", rows)
+	}
+	for _, row := range rows {
+		if row.Endpoint == "/getLyrics" && row.APIKey == "tenant:extension" && row.CacheStatus == "HIT" {
+			if row.Requests != 1 || row.Bytes != 3000 || row.Day != today {
+				t.Errorf("Unexpected row %+v", row)
+			}
+		}
+	}
+
+	// Flushed rows add up with later ones
+	s.RecordBandwidth("/getLyrics", "tenant:extension", "HIT", 2000)
+	rows, _ = store.ExportBandwidth(today, today)
+	for _, row := range rows {
+		if row.Endpoint == "/getLyrics" && row.APIKey == "tenant:extension" && row.CacheStatus == "HIT" && row.Bytes != 5000 {
+			t.Errorf("Merged row %+v, want 5000 bytes", row)
+		}
+	}
+}
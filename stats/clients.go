@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// maxClientVersions caps the distinct client/version pairs tracked; later ones are
+// counted as "(other)/(other)"
+const maxClientVersions = 500
+
+// RecordClientVersion records a request from a client identified in its User-Agent
+// (see middleware.ParseClientUserAgent). version is "" when the client sent none.
+func (s *Stats) RecordClientVersion(client, version string) {
+	if version == "" {
+		version = "(none)"
+	}
+	key := client + "/" + version
+
+	if counter, ok := s.clientVersions.Load(key); ok {
+		counter.(*atomic.Int64).Add(1)
+		return
+	}
+
+	s.clientVersionsMu.Lock()
+	counter, ok := s.clientVersions.Load(key)
+	if !ok {
+		if s.clientVersionCount >= maxClientVersions {
+			key = "(other)/(other)"
+		} else {
+			s.clientVersionCount++
+		}
+		counter, _ = s.clientVersions.LoadOrStore(key, &atomic.Int64{})
+	}
+	s.clientVersionsMu.Unlock()
+	counter.(*atomic.Int64).Add(1)
+}
+
+// ClientVersions returns request counts by client, then by version
+func (s *Stats) ClientVersions() map[string]map[string]int64 {
+	result := make(map[string]map[string]int64)
+	s.clientVersions.Range(func(key, value interface{}) bool {
+		client, version, _ := strings.Cut(key.(string), "/") // Client names have no slash
+		if result[client] == nil {
+			result[client] = make(map[string]int64)
+		}
+		result[client][version] = value.(*atomic.Int64).Load()
+		return true
+	})
+	return result
+}
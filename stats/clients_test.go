@@ -0,0 +1,35 @@
+package stats
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRecordClientVersion(t *testing.T) {
+	s := newStats()
+	s.RecordClientVersion("betterlyrics", "2.3.1")
+	s.RecordClientVersion("betterlyrics", "2.3.1")
+	s.RecordClientVersion("betterlyrics", "")
+	s.RecordClientVersion("curl", "8.4.0")
+
+	got := s.ClientVersions()
+	if got["betterlyrics"]["2.3.1"] != 2 || got["betterlyrics"]["(none)"] != 1 || got["curl"]["8.4.0"] != 1 {
+		t.Errorf("ClientVersions() = %v", got)
+	}
+}
+
+func TestRecordClientVersion_Capped(t *testing.T) {
+	s := newStats()
+	for i := 0; i < maxClientVersions+10; i++ {
+		s.RecordClientVersion("client", fmt.Sprintf("1.%d", i))
+	}
+	s.RecordClientVersion("client", "1.0") // Tracked before the cap
+
+	got := s.ClientVersions()
+	if got["(other)"]["(other)"] != 10 {
+		t.Errorf("(other) = %d, want 10", got["(other)"]["(other)"])
+	}
+	if got["client"]["1.0"] != 2 {
+		t.Errorf("client/1.0 = %d, want 2", got["client"]["1.0"])
+	}
+}
@@ -0,0 +1,163 @@
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// durationBucketBoundsMs are the upper bounds of the duration delta histogram
+// buckets. Deltas above the last bound land in an overflow bucket.
+var durationBucketBoundsMs = []int{100, 250, 500, 1000, 1500, 2000, 3000, 5000, 10000, 30000}
+
+// durationHistogram counts |requested − track| duration deltas for one provider
+type durationHistogram struct {
+	buckets [11]atomic.Int64 // len(durationBucketBoundsMs) + overflow
+	count   atomic.Int64
+	sumMs   atomic.Int64
+	maxMs   atomic.Int64
+}
+
+func (h *durationHistogram) record(deltaMs int) {
+	if deltaMs < 0 {
+		deltaMs = -deltaMs
+	}
+	i := len(durationBucketBoundsMs)
+	for j, bound := range durationBucketBoundsMs {
+		if deltaMs <= bound {
+			i = j
+			break
+		}
+	}
+	h.buckets[i].Add(1)
+	h.count.Add(1)
+	h.sumMs.Add(int64(deltaMs))
+	for {
+		cur := h.maxMs.Load()
+		if int64(deltaMs) <= cur || h.maxMs.CompareAndSwap(cur, int64(deltaMs)) {
+			break
+		}
+	}
+}
+
+// DurationBucket is one histogram bucket. LeMs is its upper bound, or -1 for the
+// overflow bucket; Cumulative counts every delta up to LeMs.
+type DurationBucket struct {
+	LeMs       int   `json:"le_ms"`
+	Count      int64 `json:"count"`
+	Cumulative int64 `json:"cumulative"`
+}
+
+// DurationHistogram is a snapshot of one provider's duration deltas. Percentiles
+// are the upper bound of the bucket they fall in (MaxMs for the overflow bucket).
+type DurationHistogram struct {
+	Count   int64            `json:"count"`
+	MeanMs  int64            `json:"mean_ms"`
+	MaxMs   int64            `json:"max_ms"`
+	P50Ms   int64            `json:"p50_ms"`
+	P90Ms   int64            `json:"p90_ms"`
+	P99Ms   int64            `json:"p99_ms"`
+	Buckets []DurationBucket `json:"buckets"`
+}
+
+func (h *durationHistogram) snapshot() DurationHistogram {
+	snap := DurationHistogram{
+		Count:   h.count.Load(),
+		MaxMs:   h.maxMs.Load(),
+		Buckets: make([]DurationBucket, len(h.buckets)),
+	}
+	if snap.Count > 0 {
+		snap.MeanMs = h.sumMs.Load() / snap.Count
+	}
+
+	var cumulative int64
+	for i := range h.buckets {
+		le := -1
+		if i < len(durationBucketBoundsMs) {
+			le = durationBucketBoundsMs[i]
+		}
+		count := h.buckets[i].Load()
+		cumulative += count
+		snap.Buckets[i] = DurationBucket{LeMs: le, Count: count, Cumulative: cumulative}
+	}
+	// Buckets are read one by one while recording continues, so percentiles go by
+	// the bucket total rather than the separately loaded count
+	snap.P50Ms = snap.percentile(0.50, cumulative)
+	snap.P90Ms = snap.percentile(0.90, cumulative)
+	snap.P99Ms = snap.percentile(0.99, cumulative)
+	return snap
+}
+
+func (snap DurationHistogram) percentile(p float64, total int64) int64 {
+	if total == 0 {
+		return 0
+	}
+	rank := int64(p*float64(total) + 0.5)
+	rank = max(rank, 1)
+	for _, b := range snap.Buckets {
+		if b.Cumulative >= rank {
+			if b.LeMs < 0 {
+				return snap.MaxMs
+			}
+			return int64(b.LeMs)
+		}
+	}
+	return snap.MaxMs
+}
+
+// durationStats holds the duration histograms, keyed by provider
+type durationStats struct {
+	matches    sync.Map // map[string]*durationHistogram
+	rejections sync.Map // map[string]*durationHistogram
+	cacheHits  sync.Map // map[string]*durationHistogram
+}
+
+func histogramFor(m *sync.Map, provider string) *durationHistogram {
+	value, _ := m.LoadOrStore(provider, &durationHistogram{})
+	return value.(*durationHistogram)
+}
+
+func snapshotHistograms(m *sync.Map) map[string]DurationHistogram {
+	result := make(map[string]DurationHistogram)
+	m.Range(func(key, value interface{}) bool {
+		result[key.(string)] = value.(*durationHistogram).snapshot()
+		return true
+	})
+	return result
+}
+
+// RecordDurationMatch records the duration delta of a track a provider matched for
+// a request that gave a duration
+func (s *Stats) RecordDurationMatch(provider string, deltaMs int) {
+	histogramFor(&s.duration.matches, provider).record(deltaMs)
+}
+
+// RecordDurationRejection records the delta of the closest candidate when the
+// duration filter rejected every search result
+func (s *Stats) RecordDurationRejection(provider string, closestDeltaMs int) {
+	histogramFor(&s.duration.rejections, provider).record(closestDeltaMs)
+}
+
+// RecordCacheHitDuration records the delta between the requested duration and the
+// track length stored with a cache hit. mismatch marks hits beyond the match delta,
+// which served lyrics for another edit of the song.
+func (s *Stats) RecordCacheHitDuration(provider string, deltaMs int, mismatch bool) {
+	histogramFor(&s.duration.cacheHits, provider).record(deltaMs)
+	if mismatch {
+		s.DurationMismatches.Add(1)
+	}
+}
+
+// DurationMatches returns the matched duration deltas per provider
+func (s *Stats) DurationMatches() map[string]DurationHistogram {
+	return snapshotHistograms(&s.duration.matches)
+}
+
+// DurationRejections returns the closest rejected duration deltas per provider
+func (s *Stats) DurationRejections() map[string]DurationHistogram {
+	return snapshotHistograms(&s.duration.rejections)
+}
+
+// DurationCacheHits returns the duration deltas of cache hits per provider
+func (s *Stats) DurationCacheHits() map[string]DurationHistogram {
+	return snapshotHistograms(&s.duration.cacheHits)
+}
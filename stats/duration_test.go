@@ -0,0 +1,52 @@
+package stats
+
+import "testing"
+
+func TestDurationHistogram_Buckets(t *testing.T) {
+	s := newStats()
+	for _, delta := range []int{0, 80, 400, 450, -1200, 1900, 45000} {
+		s.RecordDurationMatch("ttml", delta)
+	}
+	s.RecordDurationRejection("kugou", 2600)
+
+	h := s.DurationMatches()["ttml"]
+	if h.Count != 7 || h.MaxMs != 45000 || h.MeanMs != 49030/7 {
+		t.Errorf("Unexpected summary %+v", h)
+	}
+	counts := map[int]int64{}
+	for _, b := range h.Buckets {
+		counts[b.LeMs] = b.Count
+	}
+	if counts[100] != 2 || counts[500] != 2 || counts[1500] != 1 || counts[2000] != 1 || counts[-1] != 1 {
+		t.Errorf("Unexpected buckets %+v", h.Buckets)
+	}
+	if last := h.Buckets[len(h.Buckets)-1]; last.LeMs != -1 || last.Cumulative != 7 {
+		t.Errorf("Overflow bucket should close the cumulative count, got %+v", last)
+	}
+	if h.P50Ms != 500 || h.P90Ms != 2000 || h.P99Ms != 45000 {
+		t.Errorf("Unexpected percentiles p50=%d p90=%d p99=%d", h.P50Ms, h.P90Ms, h.P99Ms)
+	}
+
+	if _, ok := s.DurationRejections()["ttml"]; ok {
+		t.Error("Matches and rejections must be kept apart")
+	}
+	if r := s.DurationRejections()["kugou"]; r.Count != 1 || r.P99Ms != 3000 {
+		t.Errorf("Unexpected rejections %+v", r)
+	}
+}
+
+func TestRecordCacheHitDuration(t *testing.T) {
+	s := newStats()
+	s.RecordCacheHitDuration("ttml", 300, false)
+	s.RecordCacheHitDuration("ttml", 40000, true)
+
+	if h := s.DurationCacheHits()["ttml"]; h.Count != 2 || h.MaxMs != 40000 {
+		t.Errorf("Unexpected cache hit histogram %+v", h)
+	}
+	if got := s.DurationMismatches.Load(); got != 1 {
+		t.Errorf("DurationMismatches = %d, want 1", got)
+	}
+	if _, ok := s.DurationMatches()["ttml"]; ok {
+		t.Error("Cache hits must be kept apart from matches")
+	}
+}
@@ -0,0 +1,42 @@
+package stats
+
+import "sync/atomic"
+
+// FormatVariantCounts is how often a derived format (lrc, text, lines) was served
+// from its precomputed cache entry rather than converted from TTML
+type FormatVariantCounts struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+type formatVariantCounters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// RecordFormatVariant records a cache hit on lyrics requested in a derived format:
+// hit when the converted output was stored, miss when it had to be converted
+func (s *Stats) RecordFormatVariant(format string, hit bool) {
+	counters, _ := s.formatVariants.LoadOrStore(format, &formatVariantCounters{})
+	if hit {
+		counters.(*formatVariantCounters).hits.Add(1)
+	} else {
+		counters.(*formatVariantCounters).misses.Add(1)
+	}
+}
+
+// FormatVariants returns the variant hits and misses per format
+func (s *Stats) FormatVariants() map[string]FormatVariantCounts {
+	result := make(map[string]FormatVariantCounts)
+	s.formatVariants.Range(func(key, value interface{}) bool {
+		counters := value.(*formatVariantCounters)
+		counts := FormatVariantCounts{Hits: counters.hits.Load(), Misses: counters.misses.Load()}
+		if total := counts.Hits + counts.Misses; total > 0 {
+			counts.HitRate = float64(counts.Hits) / float64(total) * 100
+		}
+		result[key.(string)] = counts
+		return true
+	})
+	return result
+}
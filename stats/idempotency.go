@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// idempotencyBucketName lives in the stats DB so a replayed /cache/restore or
+// /cache/clear still finds its record after the cache DB has been replaced.
+const idempotencyBucketName = "idempotency"
+
+// IdempotencyRecord is the stored outcome of a request sent with an Idempotency-Key
+type IdempotencyRecord struct {
+	Fingerprint string    `json:"fingerprint"` // Method, path and query of the original request
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        []byte    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GetIdempotency returns the record stored under id
+func (s *Store) GetIdempotency(id string) (IdempotencyRecord, bool) {
+	var record IdempotencyRecord
+	found := false
+	s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(idempotencyBucketName))
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		found = json.Unmarshal(data, &record) == nil
+		return nil
+	})
+	return record, found
+}
+
+// PutIdempotency stores a record under id, replacing any previous one
+func (s *Store) PutIdempotency(id string, record IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(idempotencyBucketName))
+		if b == nil {
+			return fmt.Errorf("idempotency bucket not found")
+		}
+		return b.Put([]byte(id), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store idempotency record: %v", err)
+	}
+	return nil
+}
+
+// PruneIdempotency deletes records created before cutoff and returns how many went
+func (s *Store) PruneIdempotency(cutoff time.Time) (int, error) {
+	pruned := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(idempotencyBucketName))
+		if b == nil {
+			return nil
+		}
+		var expired [][]byte
+		b.ForEach(func(k, v []byte) error {
+			var record IdempotencyRecord
+			if json.Unmarshal(v, &record) != nil || record.CreatedAt.Before(cutoff) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune idempotency records: %v", err)
+	}
+	return pruned, nil
+}
@@ -0,0 +1,34 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdempotency_PutGetPrune(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Unix(1_700_000_000, 0)
+
+	if _, ok := store.GetIdempotency("missing"); ok {
+		t.Fatal("Expected no record for an unknown key")
+	}
+
+	store.PutIdempotency("old", IdempotencyRecord{Fingerprint: "POST /cache/clear", Status: 200, CreatedAt: now.Add(-25 * time.Hour)})
+	store.PutIdempotency("new", IdempotencyRecord{Fingerprint: "POST /cache/clear", Status: 200, Body: []byte(`{"ok":true}`), CreatedAt: now})
+
+	record, ok := store.GetIdempotency("new")
+	if !ok || record.Status != 200 || string(record.Body) != `{"ok":true}` {
+		t.Fatalf("Unexpected record: %+v (%v)", record, ok)
+	}
+
+	pruned, err := store.PruneIdempotency(now.Add(-24 * time.Hour))
+	if err != nil || pruned != 1 {
+		t.Fatalf("Expected 1 pruned record, got %d (%v)", pruned, err)
+	}
+	if _, ok := store.GetIdempotency("old"); ok {
+		t.Error("Expired record must be pruned")
+	}
+	if _, ok := store.GetIdempotency("new"); !ok {
+		t.Error("Fresh record must be kept")
+	}
+}
@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"lyrics-api-go/config"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// lookupsBucketName counts exact /getLyrics lookups per day: one key per
+// (day, lookup) where the lookup is the encoded song/artist/album/duration params.
+// Unlike the usage dataset it keeps every param needed to repeat the lookup, which
+// is what startup cache warmup replays.
+const lookupsBucketName = "lookups"
+
+// lookupBuffer collects lookup counts between flushes to the stats DB. Once it
+// holds maxPendingUsageRows lookups, new ones for the period are dropped.
+type lookupBuffer struct {
+	mu   sync.Mutex
+	rows map[string]int64
+}
+
+var pendingLookups = &lookupBuffer{rows: make(map[string]int64)}
+
+// LookupCount is how often a lookup was requested
+type LookupCount struct {
+	Lookup string `json:"lookup"`
+	Count  int64  `json:"count"`
+}
+
+// RecordLookup counts one /getLyrics lookup (an encoded query string)
+func (s *Stats) RecordLookup(lookup string) {
+	if lookup == "" || strings.Contains(lookup, usageKeySep) {
+		return
+	}
+	key := time.Now().UTC().Format(time.DateOnly) + usageKeySep + lookup
+
+	pendingLookups.mu.Lock()
+	defer pendingLookups.mu.Unlock()
+	if _, exists := pendingLookups.rows[key]; !exists && len(pendingLookups.rows) >= maxPendingUsageRows {
+		return
+	}
+	pendingLookups.rows[key]++
+}
+
+// drainPendingLookups takes the buffered lookups, leaving an empty buffer
+func drainPendingLookups() map[string]int64 {
+	pendingLookups.mu.Lock()
+	defer pendingLookups.mu.Unlock()
+	rows := pendingLookups.rows
+	pendingLookups.rows = make(map[string]int64)
+	return rows
+}
+
+// flushLookups merges buffered lookups into the lookups bucket and drops days past
+// USAGE_RETENTION_DAYS. REQUIRES: caller holds s.mu.
+func (s *Store) flushLookups() error {
+	rows := drainPendingLookups()
+	retentionDays := config.Get().Configuration.UsageRetentionDays
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(lookupsBucketName))
+		if b == nil {
+			return fmt.Errorf("lookups bucket not found")
+		}
+		for key, count := range rows {
+			total := count
+			if existing := b.Get([]byte(key)); len(existing) == 8 {
+				total += int64(binary.BigEndian.Uint64(existing))
+			}
+			value := make([]byte, 8)
+			binary.BigEndian.PutUint64(value, uint64(total))
+			if err := b.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+
+		if retentionDays <= 0 {
+			return nil
+		}
+		cutoff := time.Now().UTC().AddDate(0, 0, -retentionDays).Format(time.DateOnly)
+		var expired [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && string(k[:min(len(k), len(cutoff))]) < cutoff; k, _ = c.Next() {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Lookups only feed warmup, so a failed flush drops them rather than
+		// letting the buffer grow
+		return fmt.Errorf("failed to flush lookups: %v", err)
+	}
+	return nil
+}
+
+// TopLookups returns the n most requested lookups on days from `from` (YYYY-MM-DD)
+// onwards, most requested first. Buffered lookups are flushed first.
+func (s *Store) TopLookups(from string, n int) ([]LookupCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.flushLookups(); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int64)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(lookupsBucketName))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Seek([]byte(from)); k != nil; k, v = c.Next() {
+			_, lookup, ok := strings.Cut(string(k), usageKeySep)
+			if !ok || len(v) != 8 {
+				continue
+			}
+			totals[lookup] += int64(binary.BigEndian.Uint64(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lookups: %v", err)
+	}
+
+	top := make([]LookupCount, 0, len(totals))
+	for lookup, count := range totals {
+		top = append(top, LookupCount{Lookup: lookup, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Lookup < top[j].Lookup
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top, nil
+}
@@ -0,0 +1,43 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTopLookups_MostRequestedFirst(t *testing.T) {
+	store := newTestStore(t)
+	drainPendingLookups()
+	s := Get()
+
+	for i := 0; i < 3; i++ {
+		s.RecordLookup("a=Adele&s=Hello")
+	}
+	s.RecordLookup("a=Queen&s=Bohemian+Rhapsody")
+	s.RecordLookup("a=Queen&s=Bohemian+Rhapsody")
+	s.RecordLookup("a=Nobody&s=Unknown")
+	s.RecordLookup("") // ignored
+
+	// Part of the counts already on disk
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	s.RecordLookup("a=Nobody&s=Unknown")
+	s.RecordLookup("a=Nobody&s=Unknown")
+	s.RecordLookup("a=Nobody&s=Unknown")
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	top, err := store.TopLookups(today, 2)
+	if err != nil {
+		t.Fatalf("TopLookups failed: %v", err)
+	}
+	want := []LookupCount{{"a=Nobody&s=Unknown", 4}, {"a=Adele&s=Hello", 3}}
+	if len(top) != len(want) || top[0] != want[0] || top[1] != want[1] {
+		t.Errorf("TopLookups = %+v, want %+v", top, want)
+	}
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+	if top, _ := store.TopLookups(tomorrow, 10); len(top) != 0 {
+		t.Errorf("Expected no lookups from tomorrow on, got %+v", top)
+	}
+}
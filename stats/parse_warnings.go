@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxParseWarningTracks bounds the tracks kept with parse warnings. Once full, new
+// tracks are only counted in the per-reason totals.
+const maxParseWarningTracks = 1000
+
+// TrackParseWarnings is the parse warnings seen for one track
+type TrackParseWarnings struct {
+	Track    string           `json:"track"`
+	Fetches  int64            `json:"fetches"` // Fetches of the track that had warnings
+	Reasons  map[string]int64 `json:"reasons"`
+	LastSeen int64            `json:"last_seen"`
+}
+
+// parseWarningStats holds parse warnings per track and per reason
+type parseWarningStats struct {
+	mu       sync.Mutex
+	tracks   map[string]*TrackParseWarnings
+	byReason map[string]int64
+}
+
+// RecordParseWarnings records the parts of a track's lyrics the parser skipped,
+// as reason -> count
+func (s *Stats) RecordParseWarnings(track string, reasons map[string]int) {
+	if len(reasons) == 0 {
+		return
+	}
+	p := &s.parseWarnings
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.byReason == nil {
+		p.byReason = make(map[string]int64)
+		p.tracks = make(map[string]*TrackParseWarnings)
+	}
+	for reason, count := range reasons {
+		p.byReason[reason] += int64(count)
+	}
+
+	entry, ok := p.tracks[track]
+	if !ok {
+		if len(p.tracks) >= maxParseWarningTracks {
+			return
+		}
+		entry = &TrackParseWarnings{Track: track, Reasons: make(map[string]int64)}
+		p.tracks[track] = entry
+	}
+	entry.Fetches++
+	entry.LastSeen = time.Now().Unix()
+	for reason, count := range reasons {
+		entry.Reasons[reason] += int64(count)
+	}
+}
+
+// ParseWarnings returns the warning totals per reason and the tracks with
+// warnings, most recent first
+func (s *Stats) ParseWarnings() (map[string]int64, []TrackParseWarnings) {
+	p := &s.parseWarnings
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	byReason := make(map[string]int64, len(p.byReason))
+	for reason, count := range p.byReason {
+		byReason[reason] = count
+	}
+	tracks := make([]TrackParseWarnings, 0, len(p.tracks))
+	for _, entry := range p.tracks {
+		track := *entry
+		track.Reasons = make(map[string]int64, len(entry.Reasons))
+		for reason, count := range entry.Reasons {
+			track.Reasons[reason] = count
+		}
+		tracks = append(tracks, track)
+	}
+	sort.Slice(tracks, func(i, j int) bool {
+		if tracks[i].LastSeen != tracks[j].LastSeen {
+			return tracks[i].LastSeen > tracks[j].LastSeen
+		}
+		return tracks[i].Track < tracks[j].Track
+	})
+	return byReason, tracks
+}
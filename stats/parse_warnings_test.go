@@ -0,0 +1,24 @@
+package stats
+
+import "testing"
+
+func TestRecordParseWarnings(t *testing.T) {
+	s := newStats()
+	s.RecordParseWarnings("123", map[string]int{"malformed paragraph": 2})
+	s.RecordParseWarnings("123", map[string]int{"malformed paragraph": 1, "invalid span time": 4})
+	s.RecordParseWarnings("456", map[string]int{"invalid span time": 1})
+	s.RecordParseWarnings("789", nil) // nothing skipped
+
+	byReason, tracks := s.ParseWarnings()
+	if byReason["malformed paragraph"] != 3 || byReason["invalid span time"] != 5 {
+		t.Errorf("Unexpected totals %v", byReason)
+	}
+	if len(tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %+v", tracks)
+	}
+	for _, track := range tracks {
+		if track.Track == "123" && (track.Fetches != 2 || track.Reasons["malformed paragraph"] != 3) {
+			t.Errorf("Unexpected track %+v", track)
+		}
+	}
+}
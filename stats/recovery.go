@@ -0,0 +1,323 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"lyrics-api-go/logcolors"
+
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+	bbolterrors "go.etcd.io/bbolt/errors"
+)
+
+// A stats.db that bolt can't open, or whose stats record doesn't decode, is moved
+// aside to <path>.corrupt-<timestamp> and the server starts with fresh stats. The
+// counters can be salvaged from the moved file later (Store.Salvage), which adds
+// them on top of whatever was counted since the restart.
+
+const (
+	corruptSuffix  = ".corrupt-"
+	salvagedSuffix = ".salvaged"
+)
+
+// errCorruptStats marks open and load failures that mean the file itself is bad,
+// as opposed to e.g. a permission problem that moving the file wouldn't fix
+var errCorruptStats = errors.New("stats database is corrupt")
+
+// Recovery describes a corrupt stats DB that OpenStore moved aside
+type Recovery struct {
+	MovedTo string // Path of the moved file
+	Cause   error  // Why the file was considered corrupt
+}
+
+// isCorruption reports whether err means the stats DB file is unusable
+func isCorruption(err error) bool {
+	for _, target := range []error{errCorruptStats, bbolterrors.ErrInvalid, bbolterrors.ErrInvalidMapping, bbolterrors.ErrVersionMismatch, bbolterrors.ErrChecksum} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// openAndLoad opens the store and loads its counters. bolt panics on some
+// damaged pages, so panics are turned into corruption errors.
+func openAndLoad(dbPath string) (store *Store, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if store != nil {
+				store.db.Close()
+			}
+			store, err = nil, fmt.Errorf("%w: %v", errCorruptStats, r)
+		}
+	}()
+
+	store, err = NewStore(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Load(); err != nil {
+		store.db.Close()
+		return nil, fmt.Errorf("%w: %v", errCorruptStats, err)
+	}
+	return store, nil
+}
+
+// OpenStore opens the stats DB at dbPath and loads the persisted stats. If the
+// file is corrupt it is moved aside, a fresh store is created in its place and
+// the returned Recovery says where the old file went; otherwise Recovery is nil.
+func OpenStore(dbPath string) (*Store, *Recovery, error) {
+	store, err := openAndLoad(dbPath)
+	if err == nil {
+		return store, nil, nil
+	}
+	if !isCorruption(err) {
+		return nil, nil, err
+	}
+
+	movedTo := dbPath + corruptSuffix + time.Now().UTC().Format("20060102T150405Z")
+	if renameErr := os.Rename(dbPath, movedTo); renameErr != nil {
+		return nil, nil, fmt.Errorf("stats database is corrupt (%v) and could not be moved aside: %v", err, renameErr)
+	}
+	log.Errorf("%s Stats database %s is corrupt (%v), moved it to %s and starting with fresh stats",
+		logcolors.LogStats, dbPath, err, movedTo)
+
+	store, openErr := NewStore(dbPath)
+	if openErr != nil {
+		return nil, nil, openErr
+	}
+	return store, &Recovery{MovedTo: movedTo, Cause: err}, nil
+}
+
+// CorruptFile is a stats DB that was moved aside and not yet salvaged
+type CorruptFile struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	MovedAt time.Time `json:"moved_at"`
+}
+
+// CorruptFiles lists the moved-aside stats DBs next to this store, oldest first
+func (s *Store) CorruptFiles() ([]CorruptFile, error) {
+	matches, err := filepath.Glob(s.dbPath + corruptSuffix + "*")
+	if err != nil {
+		return nil, err
+	}
+	files := []CorruptFile{}
+	for _, path := range matches {
+		if strings.HasSuffix(path, salvagedSuffix) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		files = append(files, CorruptFile{Name: filepath.Base(path), Size: info.Size(), MovedAt: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// SalvageResult reports what Salvage recovered from a corrupt stats DB
+type SalvageResult struct {
+	File          string    `json:"file"`
+	Method        string    `json:"method"` // "bolt" when the file still opened, "scan" when the record was found in the raw bytes
+	LastSaved     time.Time `json:"last_saved"`
+	TotalRequests int64     `json:"total_requests"`
+	Accounts      int       `json:"accounts"`
+	UserAgents    int       `json:"user_agents"`
+	SalvagedTo    string    `json:"salvaged_to"`
+}
+
+// ErrCorruptFileNotFound is returned by Salvage for a name CorruptFiles doesn't list
+var ErrCorruptFileNotFound = errors.New("no such corrupt stats file")
+
+// ErrNothingSalvaged is returned when no readable stats record was found
+var ErrNothingSalvaged = errors.New("no readable stats record found")
+
+// Salvage reads the stats record from a moved-aside stats DB (name as listed by
+// CorruptFiles) and adds its counters to the live stats, which are then saved.
+// The file is first claimed by renaming it with a .salvaged suffix, so it can't
+// be counted twice; if no record can be read it gets its old name back.
+func (s *Store) Salvage(name string) (*SalvageResult, error) {
+	s.salvageMu.Lock()
+	defer s.salvageMu.Unlock()
+
+	files, err := s.CorruptFiles()
+	if err != nil {
+		return nil, err
+	}
+	var path string
+	for _, f := range files {
+		if f.Name == name {
+			path = filepath.Join(filepath.Dir(s.dbPath), f.Name)
+		}
+	}
+	if path == "" {
+		return nil, fmt.Errorf("%w: %s", ErrCorruptFileNotFound, name)
+	}
+
+	salvagedTo := path + salvagedSuffix
+	if err := os.Rename(path, salvagedTo); err != nil {
+		return nil, fmt.Errorf("could not claim %s for salvage: %v", name, err)
+	}
+
+	method := "bolt"
+	persisted, err := readPersistedBolt(salvagedTo)
+	if err != nil {
+		log.Warnf("%s Could not read %s with bolt (%v), scanning the raw file", logcolors.LogStats, name, err)
+		method = "scan"
+		persisted, err = scanPersisted(salvagedTo)
+		if err != nil {
+			if renameErr := os.Rename(salvagedTo, path); renameErr != nil {
+				log.Warnf("%s Failed to restore %s after a failed salvage: %v", logcolors.LogStats, name, renameErr)
+			}
+			return nil, err
+		}
+	}
+
+	// The file is already marked, so a failed save can't lead to counting it again;
+	// the counters are written by the next auto-save instead
+	Get().addPersisted(persisted)
+	if err := s.Save(); err != nil {
+		log.Warnf("%s Salvaged counters from %s were applied but not saved yet: %v", logcolors.LogStats, name, err)
+	}
+	log.Infof("%s Salvaged stats from %s via %s (total requests: %d, last saved: %s)",
+		logcolors.LogStats, name, method, persisted.TotalRequests, persisted.LastSaved.Format(time.RFC3339))
+
+	return &SalvageResult{
+		File:          name,
+		Method:        method,
+		LastSaved:     persisted.LastSaved,
+		TotalRequests: persisted.TotalRequests,
+		Accounts:      len(persisted.AccountUsage),
+		UserAgents:    len(persisted.UserAgentUsage),
+		SalvagedTo:    filepath.Base(salvagedTo),
+	}, nil
+}
+
+// readPersistedBolt reads the stats record through bolt, read-only
+func readPersistedBolt(path string) (persisted *PersistedStats, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			persisted, err = nil, fmt.Errorf("%w: %v", errCorruptStats, r)
+		}
+	}()
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(statsBucketName))
+		if b == nil {
+			return ErrNothingSalvaged
+		}
+		data := b.Get([]byte(statsKey))
+		if data == nil {
+			return ErrNothingSalvaged
+		}
+		persisted = &PersistedStats{}
+		return json.Unmarshal(data, persisted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return persisted, nil
+}
+
+// scanPersisted looks for the stats record in the raw file. Bolt stores small
+// values contiguously in their page, and freed pages keep older copies, so every
+// decodable record is tried and the most recently saved one wins.
+func scanPersisted(path string) (*PersistedStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	marker := []byte(`{"total_requests":`)
+	var best *PersistedStats
+	for offset := 0; ; {
+		i := bytes.Index(data[offset:], marker)
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		offset = start + len(marker)
+
+		var candidate PersistedStats
+		if err := json.NewDecoder(bytes.NewReader(data[start:])).Decode(&candidate); err != nil {
+			continue
+		}
+		if best == nil || candidate.LastSaved.After(best.LastSaved) {
+			best = &candidate
+		}
+	}
+	if best == nil {
+		return nil, ErrNothingSalvaged
+	}
+	return best, nil
+}
+
+// addPersisted adds salvaged counters to the live ones. The response time bounds
+// are widened, and the first start time moves back if the salvaged one is earlier.
+func (s *Stats) addPersisted(p *PersistedStats) {
+	s.TotalRequests.Add(p.TotalRequests)
+	s.LyricsRequests.Add(p.LyricsRequests)
+	s.CacheRequests.Add(p.CacheRequests)
+	s.StatsRequests.Add(p.StatsRequests)
+	s.HealthRequests.Add(p.HealthRequests)
+	s.OtherRequests.Add(p.OtherRequests)
+	s.CacheHits.Add(p.CacheHits)
+	s.CacheMisses.Add(p.CacheMisses)
+	s.NegativeCacheHits.Add(p.NegativeCacheHits)
+	s.StaleCacheHits.Add(p.StaleCacheHits)
+	s.RateLimitNormal.Add(p.RateLimitNormal)
+	s.RateLimitCached.Add(p.RateLimitCached)
+	s.RateLimitExceeded.Add(p.RateLimitExceeded)
+	s.Status2xx.Add(p.Status2xx)
+	s.Status4xx.Add(p.Status4xx)
+	s.Status5xx.Add(p.Status5xx)
+	s.totalResponseTime.Add(p.TotalResponseTime)
+	s.responseCount.Add(p.ResponseCount)
+	s.lyricsResponseTime.Add(p.LyricsResponseTime)
+	s.lyricsResponseCount.Add(p.LyricsResponseCount)
+
+	if p.MinResponseTime > 0 {
+		for {
+			current := s.minResponseTime.Load()
+			if p.MinResponseTime >= current || s.minResponseTime.CompareAndSwap(current, p.MinResponseTime) {
+				break
+			}
+		}
+	}
+	for {
+		current := s.maxResponseTime.Load()
+		if p.MaxResponseTime <= current || s.maxResponseTime.CompareAndSwap(current, p.MaxResponseTime) {
+			break
+		}
+	}
+
+	for name, count := range applyAccountMigrations(p.AccountUsage) {
+		counter, _ := s.accountUsage.LoadOrStore(name, &atomic.Int64{})
+		counter.(*atomic.Int64).Add(count)
+	}
+	for ua, count := range p.UserAgentUsage {
+		counter, _ := s.userAgentUsage.LoadOrStore(ua, &atomic.Int64{})
+		counter.(*atomic.Int64).Add(count)
+	}
+
+	if !p.FirstStarted.IsZero() && p.FirstStarted.Before(s.StartTime) {
+		s.StartTime = p.FirstStarted
+	}
+}
@@ -0,0 +1,172 @@
+package stats
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeStatsDB saves stats with the given request count to a new stats DB
+func writeStatsDB(t *testing.T, path string, totalRequests int64) {
+	t.Helper()
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	Get().Reset(time.Now())
+	Get().TotalRequests.Store(totalRequests)
+	Get().RecordAccountUsage("account-1")
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	store.Close()
+}
+
+func TestOpenStore_MovesCorruptFileAsideAndSalvages(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "stats.db")
+	writeStatsDB(t, dbPath, 42)
+
+	// Wipe both meta pages; the stats record further in the file survives
+	f, err := os.OpenFile(dbPath, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt(make([]byte, 2*os.Getpagesize()), 0)
+	f.Close()
+
+	Get().Reset(time.Now())
+	store, recovery, err := OpenStore(dbPath)
+	if err != nil {
+		t.Fatalf("OpenStore failed: %v", err)
+	}
+	defer store.Close()
+	if recovery == nil || !isCorruption(recovery.Cause) {
+		t.Fatalf("Expected a recovery, got %+v", recovery)
+	}
+	if _, err := os.Stat(recovery.MovedTo); err != nil {
+		t.Errorf("Moved file missing: %v", err)
+	}
+	if got := Get().TotalRequests.Load(); got != 0 {
+		t.Errorf("Expected fresh stats, got %d total requests", got)
+	}
+
+	files, err := store.CorruptFiles()
+	if err != nil || len(files) != 1 || files[0].Name != filepath.Base(recovery.MovedTo) {
+		t.Fatalf("CorruptFiles() = %+v, %v", files, err)
+	}
+
+	Get().TotalRequests.Add(5)
+	result, err := store.Salvage(files[0].Name)
+	if err != nil {
+		t.Fatalf("Salvage failed: %v", err)
+	}
+	if result.Method != "scan" || result.TotalRequests != 42 || result.Accounts != 1 {
+		t.Errorf("Unexpected salvage result %+v", result)
+	}
+	if got := Get().TotalRequests.Load(); got != 47 {
+		t.Errorf("Expected salvaged counters added to live ones (47), got %d", got)
+	}
+
+	// A salvaged file is not listed or salvaged again
+	if files, _ := store.CorruptFiles(); len(files) != 0 {
+		t.Errorf("Expected no corrupt files left, got %+v", files)
+	}
+	if _, err := store.Salvage(result.File); !errors.Is(err, ErrCorruptFileNotFound) {
+		t.Errorf("Expected ErrCorruptFileNotFound, got %v", err)
+	}
+}
+
+func TestOpenStore_HealthyFile(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "stats.db")
+	writeStatsDB(t, dbPath, 7)
+
+	Get().Reset(time.Now())
+	store, recovery, err := OpenStore(dbPath)
+	if err != nil || recovery != nil {
+		t.Fatalf("OpenStore() = %+v, %v", recovery, err)
+	}
+	defer store.Close()
+	if got := Get().TotalRequests.Load(); got != 7 {
+		t.Errorf("Expected persisted stats loaded, got %d", got)
+	}
+}
+
+func TestSalvage_ReadableFileUsesBolt(t *testing.T) {
+	dir := t.TempDir()
+	writeStatsDB(t, filepath.Join(dir, "stats.db.corrupt-20260101T000000Z"), 10)
+
+	Get().Reset(time.Now())
+	store, _, err := OpenStore(filepath.Join(dir, "stats.db"))
+	if err != nil {
+		t.Fatalf("OpenStore failed: %v", err)
+	}
+	defer store.Close()
+
+	result, err := store.Salvage("stats.db.corrupt-20260101T000000Z")
+	if err != nil {
+		t.Fatalf("Salvage failed: %v", err)
+	}
+	if result.Method != "bolt" || Get().TotalRequests.Load() != 10 {
+		t.Errorf("Unexpected salvage %+v (total %d)", result, Get().TotalRequests.Load())
+	}
+	if _, err := store.Salvage("../stats.db"); !errors.Is(err, ErrCorruptFileNotFound) {
+		t.Errorf("Expected only listed files to be salvageable, got %v", err)
+	}
+}
+
+func TestSalvage_ConcurrentCallsCountOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeStatsDB(t, filepath.Join(dir, "stats.db.corrupt-20260101T000000Z"), 10)
+
+	Get().Reset(time.Now())
+	store, _, err := OpenStore(filepath.Join(dir, "stats.db"))
+	if err != nil {
+		t.Fatalf("OpenStore failed: %v", err)
+	}
+	defer store.Close()
+
+	var wg sync.WaitGroup
+	var salvaged atomic.Int32
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Salvage("stats.db.corrupt-20260101T000000Z"); err == nil {
+				salvaged.Add(1)
+			} else if !errors.Is(err, ErrCorruptFileNotFound) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if salvaged.Load() != 1 || Get().TotalRequests.Load() != 10 {
+		t.Errorf("Expected one salvage adding 10 requests, got %d salvages and %d requests", salvaged.Load(), Get().TotalRequests.Load())
+	}
+}
+
+func TestSalvage_UnreadableFileKeepsItsName(t *testing.T) {
+	dir := t.TempDir()
+	name := "stats.db.corrupt-20260101T000000Z"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("not a stats db"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	Get().Reset(time.Now())
+	store, _, err := OpenStore(filepath.Join(dir, "stats.db"))
+	if err != nil {
+		t.Fatalf("OpenStore failed: %v", err)
+	}
+	defer store.Close()
+
+	if _, err := store.Salvage(name); !errors.Is(err, ErrNothingSalvaged) {
+		t.Fatalf("Expected ErrNothingSalvaged, got %v", err)
+	}
+	if files, _ := store.CorruptFiles(); len(files) != 1 || files[0].Name != name {
+		t.Errorf("Expected the file to stay listed, got %+v", files)
+	}
+}
@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// StatsSince returns the start of the current measurement window: the last Reset,
+// or the first start when stats were never reset
+func (s *Stats) StatsSince() time.Time {
+	if nanos := s.lastReset.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return s.StartTime
+}
+
+// Reset zeroes every counter and tracked breakdown, starting a new measurement
+// window at now. Uptime and the usage and lookup datasets are not affected.
+func (s *Stats) Reset(now time.Time) {
+	for _, counter := range []*atomic.Int64{
+		&s.TotalRequests, &s.LyricsRequests, &s.CacheRequests, &s.StatsRequests, &s.HealthRequests, &s.OtherRequests,
+		&s.CacheHits, &s.CacheMisses, &s.NegativeCacheHits, &s.StaleCacheHits, &s.DurationMismatches, &s.CorruptEntries,
+		&s.UpstreamRequests, &s.UpstreamErrors, &s.PayloadTooLarge, &s.UpstreamMalformed,
+		&s.RateLimitNormal, &s.RateLimitCached, &s.RateLimitExceeded,
+		&s.Status2xx, &s.Status4xx, &s.Status5xx,
+		&s.totalResponseTime, &s.responseCount, &s.maxResponseTime,
+		&s.lyricsResponseTime, &s.lyricsResponseCount,
+	} {
+		counter.Store(0)
+	}
+	s.minResponseTime.Store(int64(^uint64(0) >> 1))
+
+	s.requestTimesMu.Lock()
+	s.requestTimes = nil
+	s.requestTimesMu.Unlock()
+
+	s.accountUsage.Clear()
+	s.accountAttempts.Clear()
+	s.eventCounts.Clear()
+	s.formatVariants.Clear()
+	s.tenants.Clear()
+	s.bandwidth.reset()
+	s.duration.matches.Clear()
+	s.duration.rejections.Clear()
+	s.duration.cacheHits.Clear()
+
+	s.clientVersionsMu.Lock()
+	s.clientVersions.Clear()
+	s.clientVersionCount = 0
+	s.clientVersionsMu.Unlock()
+
+	s.uaMu.Lock()
+	s.userAgentUsage.Clear()
+	s.uniqueUACount.Store(0)
+	s.uaMu.Unlock()
+
+	s.parseWarnings.mu.Lock()
+	s.parseWarnings.tracks = nil
+	s.parseWarnings.byReason = nil
+	s.parseWarnings.mu.Unlock()
+
+	s.lastReset.Store(now.UnixNano())
+}
@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// settingsBucketName holds runtime overrides of configuration made through the
+// admin API (e.g. rate limits), as JSON under the setting's name. It lives in the
+// stats DB so an override survives restarts and cache restores alike.
+const settingsBucketName = "settings"
+
+// GetSetting returns the JSON stored under name
+func (s *Store) GetSetting(name string) ([]byte, bool) {
+	var data []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(settingsBucketName))
+		if b == nil {
+			return nil
+		}
+		if v := b.Get([]byte(name)); v != nil {
+			data = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	return data, data != nil
+}
+
+// PutSetting stores data under name, replacing any previous value
+func (s *Store) PutSetting(name string, data []byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(settingsBucketName))
+		if b == nil {
+			return fmt.Errorf("settings bucket not found")
+		}
+		return b.Put([]byte(name), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store setting %s: %v", name, err)
+	}
+	return nil
+}
+
+// DeleteSetting removes the value stored under name, if any
+func (s *Store) DeleteSetting(name string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(settingsBucketName))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(name))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete setting %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package stats
+
+import "testing"
+
+func TestSettings_PutGetDelete(t *testing.T) {
+	store := newTestStore(t)
+
+	if _, ok := store.GetSetting("rate_limits"); ok {
+		t.Fatal("Expected no value for an unset setting")
+	}
+	if err := store.PutSetting("rate_limits", []byte(`{"normal_burst":3}`)); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := store.GetSetting("rate_limits"); !ok || string(data) != `{"normal_burst":3}` {
+		t.Errorf("GetSetting() = %s, %v", data, ok)
+	}
+	if err := store.DeleteSetting("rate_limits"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.GetSetting("rate_limits"); ok {
+		t.Error("Deleted setting must be gone")
+	}
+}
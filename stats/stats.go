@@ -11,6 +11,7 @@ import (
 type Stats struct {
 	// Server info
 	StartTime time.Time
+	lastReset atomic.Int64 // Unix nanoseconds of the last Reset (0 = never reset)
 
 	// Request counters
 	TotalRequests  atomic.Int64
@@ -21,10 +22,18 @@ type Stats struct {
 	OtherRequests  atomic.Int64
 
 	// Cache performance
-	CacheHits         atomic.Int64
-	CacheMisses       atomic.Int64
-	NegativeCacheHits atomic.Int64
-	StaleCacheHits    atomic.Int64
+	CacheHits          atomic.Int64
+	CacheMisses        atomic.Int64
+	NegativeCacheHits  atomic.Int64
+	StaleCacheHits     atomic.Int64
+	DurationMismatches atomic.Int64 // Hits whose stored track length is beyond the match delta of the requested duration
+	CorruptEntries     atomic.Int64 // Entries that failed checksum or decompression on read (deleted)
+
+	// Upstream (TTML API) requests and failed ones (transport errors, 401, 429, 5xx)
+	UpstreamRequests  atomic.Int64
+	UpstreamErrors    atomic.Int64
+	PayloadTooLarge   atomic.Int64 // Upstream bodies rejected for exceeding the size cap
+	UpstreamMalformed atomic.Int64 // 200 responses whose shape failed the schema checks
 
 	// Rate limiting
 	RateLimitNormal   atomic.Int64 // Requests served under normal rate limit
@@ -52,7 +61,31 @@ type Stats struct {
 	requestTimesMu sync.Mutex
 
 	// Account usage tracking
-	accountUsage sync.Map // map[string]*atomic.Int64
+	accountUsage    sync.Map // map[string]*atomic.Int64
+	accountAttempts sync.Map // map[string]*accountAttempts, windowed (see accounts.go)
+
+	// Duration filter deltas per provider (see duration.go)
+	duration durationStats
+
+	// Skipped lyrics parts per track (see parse_warnings.go)
+	parseWarnings parseWarningStats
+
+	// Precomputed format variant hits and misses, by format (see formats.go)
+	formatVariants sync.Map // map[string]*formatVariantCounters
+
+	// Internal events seen on the event bus, by type
+	eventCounts sync.Map // map[string]*atomic.Int64
+
+	// Client versions parsed from User-Agent, "client/version" -> count (see clients.go)
+	clientVersions     sync.Map // map[string]*atomic.Int64
+	clientVersionCount int
+	clientVersionsMu   sync.Mutex
+
+	// Requests per tenant namespace (see tenants.go)
+	tenants sync.Map // map[string]*tenantCounters
+
+	// Response bytes sent (see bandwidth.go)
+	bandwidth bandwidthTotals
 
 	// User agent tracking
 	userAgentUsage sync.Map // map[string]*atomic.Int64
@@ -114,6 +147,22 @@ func (s *Stats) RecordAccountUsage(accountName string) {
 	counter.(*atomic.Int64).Add(1)
 }
 
+// RecordEvent records an internal event (circuit breaker open, quarantine...) by type
+func (s *Stats) RecordEvent(eventType string) {
+	counter, _ := s.eventCounts.LoadOrStore(eventType, &atomic.Int64{})
+	counter.(*atomic.Int64).Add(1)
+}
+
+// EventCountsSnapshot returns a map of event types to occurrence counts
+func (s *Stats) EventCountsSnapshot() map[string]int64 {
+	result := make(map[string]int64)
+	s.eventCounts.Range(func(key, value interface{}) bool {
+		result[key.(string)] = value.(*atomic.Int64).Load()
+		return true
+	})
+	return result
+}
+
 // RequestsPerMinute returns the number of requests in the last minute
 func (s *Stats) RequestsPerMinute() int64 {
 	s.requestTimesMu.Lock()
@@ -221,6 +270,30 @@ func (s *Stats) RecordStaleCacheHit() {
 	s.StaleCacheHits.Add(1)
 }
 
+// RecordCorruptEntry records a cache entry found corrupt on read
+func (s *Stats) RecordCorruptEntry() {
+	s.CorruptEntries.Add(1)
+}
+
+// RecordUpstreamResponse records one upstream API call. status is 0 when the request
+// failed before a response. 404 is a normal "not found", not an upstream error.
+func (s *Stats) RecordUpstreamResponse(status int, err error) {
+	s.UpstreamRequests.Add(1)
+	if err != nil || status == 401 || status == 429 || status >= 500 {
+		s.UpstreamErrors.Add(1)
+	}
+}
+
+// RecordPayloadTooLarge records an upstream body that exceeded its size cap
+func (s *Stats) RecordPayloadTooLarge() {
+	s.PayloadTooLarge.Add(1)
+}
+
+// RecordUpstreamMalformed records an upstream response that failed the schema checks
+func (s *Stats) RecordUpstreamMalformed() {
+	s.UpstreamMalformed.Add(1)
+}
+
 // RecordRateLimit records rate limit tier usage
 func (s *Stats) RecordRateLimit(tier string) {
 	switch tier {
@@ -330,6 +403,7 @@ func (s *Stats) Snapshot() map[string]interface{} {
 	return map[string]interface{}{
 		"server": map[string]interface{}{
 			"start_time":     s.StartTime.Format(time.RFC3339),
+			"stats_since":    s.StatsSince().Format(time.RFC3339),
 			"uptime":         uptime.String(),
 			"uptime_seconds": int64(uptime.Seconds()),
 		},
@@ -344,11 +418,20 @@ func (s *Stats) Snapshot() map[string]interface{} {
 			"per_hour":   reqPerHour,
 		},
 		"cache": map[string]interface{}{
-			"hits":          s.CacheHits.Load(),
-			"misses":        s.CacheMisses.Load(),
-			"negative_hits": s.NegativeCacheHits.Load(),
-			"stale_hits":    s.StaleCacheHits.Load(),
-			"hit_rate":      s.CacheHitRate(),
+			"hits":                s.CacheHits.Load(),
+			"misses":              s.CacheMisses.Load(),
+			"negative_hits":       s.NegativeCacheHits.Load(),
+			"stale_hits":          s.StaleCacheHits.Load(),
+			"duration_mismatches": s.DurationMismatches.Load(),
+			"corrupt":             s.CorruptEntries.Load(),
+			"hit_rate":            s.CacheHitRate(),
+			"formats":             s.FormatVariants(),
+		},
+		"upstream": map[string]interface{}{
+			"requests":          s.UpstreamRequests.Load(),
+			"errors":            s.UpstreamErrors.Load(),
+			"payload_too_large": s.PayloadTooLarge.Load(),
+			"malformed":         s.UpstreamMalformed.Load(),
 		},
 		"rate_limiting": map[string]interface{}{
 			"normal_tier": s.RateLimitNormal.Load(),
@@ -367,5 +450,6 @@ func (s *Stats) Snapshot() map[string]interface{} {
 			"avg_lyrics": s.AvgLyricsResponseTime().String(),
 		},
 		"accounts": s.AccountUsageSnapshot(),
+		"events":   s.EventCountsSnapshot(),
 	}
 }
@@ -194,6 +194,22 @@ func TestRecordRequest_SliceDoesNotRetainOldMemory(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// RecordEvent & EventCountsSnapshot
+// ---------------------------------------------------------------------------
+
+func TestRecordEvent(t *testing.T) {
+	s := newStats()
+	s.RecordEvent("circuit_breaker_open")
+	s.RecordEvent("circuit_breaker_open")
+	s.RecordEvent("cache_cleared")
+
+	snap := s.EventCountsSnapshot()
+	if snap["circuit_breaker_open"] != 2 || snap["cache_cleared"] != 1 {
+		t.Fatalf("unexpected event counts: %v", snap)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // RecordAccountUsage & AccountUsageSnapshot
 // ---------------------------------------------------------------------------
@@ -426,7 +442,7 @@ func TestSnapshot_ContainsExpectedKeys(t *testing.T) {
 
 	snap := s.Snapshot()
 
-	expectedTopLevel := []string{"server", "requests", "cache", "rate_limiting", "responses", "response_times", "accounts"}
+	expectedTopLevel := []string{"server", "requests", "cache", "rate_limiting", "responses", "response_times", "accounts", "events"}
 	for _, key := range expectedTopLevel {
 		if _, ok := snap[key]; !ok {
 			t.Fatalf("snapshot missing top-level key %q", key)
@@ -586,3 +602,24 @@ func TestRecordUserAgent_OtherStringBeforeCap(t *testing.T) {
 		t.Fatalf("expected uniqueUACount=1, got %d", s.uniqueUACount.Load())
 	}
 }
+
+// ---------------------------------------------------------------------------
+// RecordUpstreamResponse
+// ---------------------------------------------------------------------------
+
+func TestRecordUpstreamResponse_CountsFailures(t *testing.T) {
+	s := newStats()
+	s.RecordUpstreamResponse(200, nil)
+	s.RecordUpstreamResponse(404, nil)
+	s.RecordUpstreamResponse(401, nil)
+	s.RecordUpstreamResponse(429, nil)
+	s.RecordUpstreamResponse(503, nil)
+	s.RecordUpstreamResponse(0, fmt.Errorf("connection reset"))
+
+	if got := s.UpstreamRequests.Load(); got != 6 {
+		t.Errorf("expected 6 upstream requests, got %d", got)
+	}
+	if got := s.UpstreamErrors.Load(); got != 4 {
+		t.Errorf("expected 4 upstream errors (401, 429, 503, transport), got %d", got)
+	}
+}
@@ -28,6 +28,8 @@ type Store struct {
 	mu       sync.Mutex
 	stopChan chan struct{}
 	wg       sync.WaitGroup
+
+	salvageMu sync.Mutex // Serializes Salvage so a file is only counted once
 }
 
 // PersistedStats represents the stats data that gets persisted to disk
@@ -67,6 +69,7 @@ type PersistedStats struct {
 	// Metadata
 	LastSaved    time.Time `json:"last_saved"`
 	FirstStarted time.Time `json:"first_started"`
+	LastReset    time.Time `json:"last_reset,omitempty"`
 }
 
 // NewStore creates a new stats store with a dedicated BoltDB file
@@ -79,17 +82,21 @@ func NewStore(dbPath string) (*Store, error) {
 
 	db, err := bolt.Open(dbPath, 0600, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open stats database: %v", err)
+		return nil, fmt.Errorf("failed to open stats database: %w", err)
 	}
 
-	// Create bucket if it doesn't exist
+	// Create buckets if they don't exist
 	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(statsBucketName))
-		return err
+		for _, name := range []string{statsBucketName, auditBucketName, usageBucketName, lookupsBucketName, bandwidthBucketName, idempotencyBucketName, archivesBucketName, settingsBucketName} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to create stats bucket: %v", err)
+		return nil, fmt.Errorf("failed to create stats buckets: %v", err)
 	}
 
 	store := &Store{
@@ -179,6 +186,9 @@ func (s *Store) Load() error {
 	if !persisted.FirstStarted.IsZero() {
 		stats.StartTime = persisted.FirstStarted
 	}
+	if !persisted.LastReset.IsZero() {
+		stats.lastReset.Store(persisted.LastReset.UnixNano())
+	}
 
 	log.Infof("%s Loaded persisted stats (total requests: %d, first started: %s)",
 		logcolors.LogStats, persisted.TotalRequests, persisted.FirstStarted.Format(time.RFC3339))
@@ -221,6 +231,9 @@ func (s *Store) Save() error {
 		LastSaved:           time.Now(),
 		FirstStarted:        stats.StartTime,
 	}
+	if nanos := stats.lastReset.Load(); nanos != 0 {
+		persisted.LastReset = time.Unix(0, nanos)
+	}
 
 	data, err := json.Marshal(persisted)
 	if err != nil {
@@ -239,7 +252,13 @@ func (s *Store) Save() error {
 		return fmt.Errorf("failed to save stats: %v", err)
 	}
 
-	return nil
+	if err := s.flushUsage(); err != nil {
+		return err
+	}
+	if err := s.flushBandwidth(); err != nil {
+		return err
+	}
+	return s.flushLookups()
 }
 
 // StartAutoSave begins periodic saving of stats
@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// TenantUsage is the traffic of one tenant namespace (API_KEY_TENANTS)
+type TenantUsage struct {
+	Requests    int64            `json:"requests"`
+	CacheStatus map[string]int64 `json:"cache_status"` // HIT, MISS, STALE, NEGATIVE_HIT, ...
+	RateLimited int64            `json:"rate_limited"` // 429s, from the tenant quota
+	Errors      int64            `json:"errors"`       // 5xx
+}
+
+type tenantCounters struct {
+	requests    atomic.Int64
+	rateLimited atomic.Int64
+	errors      atomic.Int64
+	cacheStatus sync.Map // map[string]*atomic.Int64
+}
+
+// RecordTenantRequest records a request made with a tenant's API key. cacheStatus
+// is the response's X-Cache-Status ("" when it has none).
+func (s *Stats) RecordTenantRequest(tenant, cacheStatus string, status int) {
+	value, _ := s.tenants.LoadOrStore(tenant, &tenantCounters{})
+	counters := value.(*tenantCounters)
+	counters.requests.Add(1)
+	switch {
+	case status == 429:
+		counters.rateLimited.Add(1)
+	case status >= 500:
+		counters.errors.Add(1)
+	}
+	if cacheStatus = strings.ToUpper(cacheStatus); cacheStatus != "" {
+		counter, _ := counters.cacheStatus.LoadOrStore(cacheStatus, &atomic.Int64{})
+		counter.(*atomic.Int64).Add(1)
+	}
+}
+
+// TenantUsage returns the usage of every tenant seen since the last reset
+func (s *Stats) TenantUsage() map[string]TenantUsage {
+	result := make(map[string]TenantUsage)
+	s.tenants.Range(func(key, value interface{}) bool {
+		counters := value.(*tenantCounters)
+		usage := TenantUsage{
+			Requests:    counters.requests.Load(),
+			CacheStatus: make(map[string]int64),
+			RateLimited: counters.rateLimited.Load(),
+			Errors:      counters.errors.Load(),
+		}
+		counters.cacheStatus.Range(func(status, count interface{}) bool {
+			usage.CacheStatus[status.(string)] = count.(*atomic.Int64).Load()
+			return true
+		})
+		result[key.(string)] = usage
+		return true
+	})
+	return result
+}
@@ -0,0 +1,32 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordTenantRequest(t *testing.T) {
+	s := newStats()
+	s.RecordTenantRequest("partner", "HIT", 200)
+	s.RecordTenantRequest("partner", "miss", 200)
+	s.RecordTenantRequest("partner", "", 429)
+	s.RecordTenantRequest("partner", "MISS", 503)
+	s.RecordTenantRequest("extension", "HIT", 200)
+
+	got := s.TenantUsage()
+	partner := got["partner"]
+	if partner.Requests != 4 || partner.RateLimited != 1 || partner.Errors != 1 {
+		t.Errorf("Unexpected partner usage %+v", partner)
+	}
+	if partner.CacheStatus["HIT"] != 1 || partner.CacheStatus["MISS"] != 2 || len(partner.CacheStatus) != 2 {
+		t.Errorf("Unexpected cache statuses %v", partner.CacheStatus)
+	}
+	if got["extension"].Requests != 1 {
+		t.Errorf("Tenants must be counted apart, got %+v", got)
+	}
+
+	s.Reset(time.Now())
+	if len(s.TenantUsage()) != 0 {
+		t.Error("Reset must clear tenant usage")
+	}
+}
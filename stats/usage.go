@@ -0,0 +1,209 @@
+package stats
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"lyrics-api-go/config"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// usageBucketName holds the anonymized usage dataset: one key per
+// (day, provider, cache status, latency bucket, normalized query) with a count.
+// IPs and user agents are never part of a row.
+const usageBucketName = "usage"
+
+// maxPendingUsageRows bounds the in-memory buffer between flushes. Once full,
+// new queries for the period are counted under usageOverflowQuery.
+const maxPendingUsageRows = 100000
+
+const usageOverflowQuery = "(other)"
+
+// usageKeySep separates key fields; it can't appear in a normalized query
+const usageKeySep = "\x1f"
+
+// UsageRow is one aggregated row of the usage dataset
+type UsageRow struct {
+	Day           string `json:"day"`
+	Query         string `json:"query"`
+	Provider      string `json:"provider"`
+	CacheStatus   string `json:"cache_status"`
+	LatencyBucket string `json:"latency_bucket"`
+	Count         int64  `json:"count"`
+}
+
+func (r UsageRow) key() string {
+	return strings.Join([]string{r.Day, r.Provider, r.CacheStatus, r.LatencyBucket, r.Query}, usageKeySep)
+}
+
+func parseUsageKey(key string) (UsageRow, bool) {
+	parts := strings.SplitN(key, usageKeySep, 5)
+	if len(parts) != 5 {
+		return UsageRow{}, false
+	}
+	return UsageRow{Day: parts[0], Provider: parts[1], CacheStatus: parts[2], LatencyBucket: parts[3], Query: parts[4]}, true
+}
+
+// usageBuffer collects rows between flushes to the stats DB
+type usageBuffer struct {
+	mu   sync.Mutex
+	rows map[string]int64
+}
+
+var pendingUsage = &usageBuffer{rows: make(map[string]int64)}
+
+// LatencyBucket maps a response time to a coarse bucket label
+func LatencyBucket(d time.Duration) string {
+	switch {
+	case d < 100*time.Millisecond:
+		return "<100ms"
+	case d < 500*time.Millisecond:
+		return "100-500ms"
+	case d < time.Second:
+		return "500ms-1s"
+	case d < 3*time.Second:
+		return "1-3s"
+	case d < 10*time.Second:
+		return "3-10s"
+	}
+	return ">=10s"
+}
+
+// NormalizeUsageQuery lowercases, trims and collapses whitespace (and strips the key separator)
+func NormalizeUsageQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(query, usageKeySep, " "))), " ")
+}
+
+// RecordUsage counts one lyrics request in the anonymized usage dataset
+func (s *Stats) RecordUsage(query, provider, cacheStatus string, latency time.Duration) {
+	query = NormalizeUsageQuery(query)
+	if query == "" {
+		return
+	}
+	if cacheStatus == "" {
+		cacheStatus = "NONE"
+	}
+	row := UsageRow{
+		Day:           time.Now().UTC().Format(time.DateOnly),
+		Query:         query,
+		Provider:      provider,
+		CacheStatus:   cacheStatus,
+		LatencyBucket: LatencyBucket(latency),
+	}
+
+	pendingUsage.mu.Lock()
+	defer pendingUsage.mu.Unlock()
+	key := row.key()
+	if _, exists := pendingUsage.rows[key]; !exists && len(pendingUsage.rows) >= maxPendingUsageRows {
+		row.Query = usageOverflowQuery
+		key = row.key()
+	}
+	pendingUsage.rows[key]++
+}
+
+// drainPendingUsage takes the buffered rows, leaving an empty buffer
+func drainPendingUsage() map[string]int64 {
+	pendingUsage.mu.Lock()
+	defer pendingUsage.mu.Unlock()
+	rows := pendingUsage.rows
+	pendingUsage.rows = make(map[string]int64)
+	return rows
+}
+
+// requeuePendingUsage puts rows back after a failed flush
+func requeuePendingUsage(rows map[string]int64) {
+	pendingUsage.mu.Lock()
+	defer pendingUsage.mu.Unlock()
+	for key, count := range rows {
+		pendingUsage.rows[key] += count
+	}
+}
+
+// flushUsage merges buffered rows into the usage bucket and drops days past the
+// retention window. REQUIRES: caller holds s.mu.
+func (s *Store) flushUsage() error {
+	rows := drainPendingUsage()
+	retentionDays := config.Get().Configuration.UsageRetentionDays
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(usageBucketName))
+		if b == nil {
+			return fmt.Errorf("usage bucket not found")
+		}
+		for key, count := range rows {
+			total := count
+			if existing := b.Get([]byte(key)); len(existing) == 8 {
+				total += int64(binary.BigEndian.Uint64(existing))
+			}
+			value := make([]byte, 8)
+			binary.BigEndian.PutUint64(value, uint64(total))
+			if err := b.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+
+		if retentionDays <= 0 {
+			return nil
+		}
+		// Keys start with the day, so expired rows are a prefix of the bucket
+		cutoff := time.Now().UTC().AddDate(0, 0, -retentionDays).Format(time.DateOnly)
+		var expired [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && string(k[:min(len(k), len(cutoff))]) < cutoff; k, _ = c.Next() {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		requeuePendingUsage(rows)
+		return fmt.Errorf("failed to flush usage: %v", err)
+	}
+	return nil
+}
+
+// ExportUsage returns usage rows for days in [from, to] (YYYY-MM-DD, inclusive)
+// with at least minCount requests. Buffered rows are flushed first.
+func (s *Store) ExportUsage(from, to string, minCount int64) ([]UsageRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.flushUsage(); err != nil {
+		return nil, err
+	}
+
+	rows := []UsageRow{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(usageBucketName))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Seek([]byte(from)); k != nil; k, v = c.Next() {
+			row, ok := parseUsageKey(string(k))
+			if !ok || len(v) != 8 {
+				continue
+			}
+			if row.Day > to {
+				break
+			}
+			row.Count = int64(binary.BigEndian.Uint64(v))
+			if row.Count >= minCount {
+				rows = append(rows, row)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %v", err)
+	}
+	return rows, nil
+}
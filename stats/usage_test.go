@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyBucket(t *testing.T) {
+	tests := []struct {
+		latency  time.Duration
+		expected string
+	}{
+		{50 * time.Millisecond, "<100ms"},
+		{100 * time.Millisecond, "100-500ms"},
+		{700 * time.Millisecond, "500ms-1s"},
+		{2 * time.Second, "1-3s"},
+		{5 * time.Second, "3-10s"},
+		{time.Minute, ">=10s"},
+	}
+	for _, tt := range tests {
+		if got := LatencyBucket(tt.latency); got != tt.expected {
+			t.Errorf("LatencyBucket(%v) = %q, want %q", tt.latency, got, tt.expected)
+		}
+	}
+}
+
+func TestNormalizeUsageQuery(t *testing.T) {
+	if got := NormalizeUsageQuery("  Hello   ADELE\x1f "); got != "hello adele" {
+		t.Errorf("Expected %q, got %q", "hello adele", got)
+	}
+}
+
+func TestExportUsage_AggregatesRecordedRequests(t *testing.T) {
+	store := newTestStore(t)
+	drainPendingUsage()
+	s := Get()
+
+	s.RecordUsage("Hello Adele", "ttml", "HIT", 10*time.Millisecond)
+	s.RecordUsage("hello  adele", "ttml", "HIT", 20*time.Millisecond)
+	s.RecordUsage("Unknown Song Nobody", "ttml", "", 2*time.Second)
+	s.RecordUsage("   ", "ttml", "MISS", time.Second) // empty query is ignored
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	rows, err := store.ExportUsage(today, today, 1)
+	if err != nil {
+		t.Fatalf("ExportUsage failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d: %+v", len(rows), rows)
+	}
+
+	counts := map[string]UsageRow{}
+	for _, row := range rows {
+		counts[row.Query] = row
+	}
+	if hit := counts["hello adele"]; hit.Count != 2 || hit.CacheStatus != "HIT" || hit.LatencyBucket != "<100ms" {
+		t.Errorf("Unexpected hit row: %+v", hit)
+	}
+	if miss := counts["unknown song nobody"]; miss.Count != 1 || miss.CacheStatus != "NONE" || miss.Day != today {
+		t.Errorf("Unexpected miss row: %+v", miss)
+	}
+
+	// Counts accumulate across flushes; min_count filters
+	s.RecordUsage("Hello Adele", "ttml", "HIT", 10*time.Millisecond)
+	rows, err = store.ExportUsage(today, today, 3)
+	if err != nil {
+		t.Fatalf("ExportUsage failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Count != 3 {
+		t.Errorf("Expected one row with count 3, got %+v", rows)
+	}
+
+	// Range outside today is empty
+	rows, err = store.ExportUsage("2000-01-01", "2000-01-31", 1)
+	if err != nil {
+		t.Fatalf("ExportUsage failed: %v", err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("Expected no rows for an old range, got %d", len(rows))
+	}
+}
+
+func TestFlushUsage_PrunesExpiredDays(t *testing.T) {
+	store := newTestStore(t)
+	drainPendingUsage()
+
+	old := UsageRow{Day: "2000-01-01", Query: "old song", Provider: "ttml", CacheStatus: "HIT", LatencyBucket: "<100ms"}
+	requeuePendingUsage(map[string]int64{old.key(): 5})
+	Get().RecordUsage("new song", "ttml", "HIT", time.Millisecond)
+
+	rows, err := store.ExportUsage("0000-00-00", "9999-12-31", 1)
+	if err != nil {
+		t.Fatalf("ExportUsage failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Query != "new song" {
+		t.Errorf("Expected only the recent row to survive, got %+v", rows)
+	}
+}
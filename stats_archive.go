@@ -0,0 +1,98 @@
+package main
+
+import (
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// statsResetHandler archives the current /stats snapshot, then zeroes the counters
+// so a new measurement window starts, e.g. after a config change. Archives older
+// than STATS_ARCHIVE_RETENTION_DAYS are pruned.
+//
+// Query params:
+//   - note: Free text stored with the archive (e.g. what changed)
+func statsResetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if statsStore == nil {
+		Respond(w, r).Error(http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "Stats store is not available",
+		})
+		return
+	}
+
+	archive, err := statsStore.ArchiveAndReset(r.URL.Query().Get("note"))
+	if err != nil {
+		log.Errorf("%s Failed to archive and reset stats: %v", logcolors.LogStats, err)
+		Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	log.Infof("%s Stats reset, previous window archived as %s", logcolors.LogStats, archive.ID)
+
+	if days := conf().Configuration.StatsArchiveRetentionDays; days > 0 {
+		if pruned, err := statsStore.PruneStatsArchives(time.Now().AddDate(0, 0, -days)); err != nil {
+			log.Warnf("%s Failed to prune stats archives: %v", logcolors.LogStats, err)
+		} else if pruned > 0 {
+			log.Infof("%s Pruned %d stats archives older than %d days", logcolors.LogStats, pruned, days)
+		}
+	}
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"message": "Stats reset",
+		"archive": archive,
+	})
+}
+
+// statsArchivesHandler lists the archived stats windows, newest first, or returns
+// one archive with its snapshot when the path has an ID
+func statsArchivesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if statsStore == nil {
+		Respond(w, r).Error(http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "Stats store is not available",
+		})
+		return
+	}
+
+	if id := mux.Vars(r)["id"]; id != "" {
+		archive, ok, err := statsStore.StatsArchive(id)
+		if err != nil {
+			Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+		if !ok {
+			Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+				"error": "Archive not found",
+				"id":    id,
+			})
+			return
+		}
+		Respond(w, r).JSON(archive)
+		return
+	}
+
+	archives, err := statsStore.StatsArchives()
+	if err != nil {
+		Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	Respond(w, r).JSON(map[string]interface{}{
+		"archives": archives,
+		"count":    len(archives),
+	})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatsResetAndArchives(t *testing.T) {
+	setupTestAuditLog(t)
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+	router := newTestRouter()
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "test-token")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	stats.Get().RecordCacheHit()
+	rr := serve(http.MethodPost, "/stats/reset?note=new+weights")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var reset struct {
+		Archive stats.StatsArchiveInfo `json:"archive"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &reset)
+	if reset.Archive.ID == "" || reset.Archive.Note != "new weights" {
+		t.Errorf("Unexpected reset response: %s", rr.Body.String())
+	}
+	if got := stats.Get().CacheHits.Load(); got != 0 {
+		t.Errorf("Expected cache hits reset, got %d", got)
+	}
+
+	rr = serve(http.MethodGet, "/stats/archives")
+	var list struct {
+		Archives []stats.StatsArchiveInfo `json:"archives"`
+		Count    int                      `json:"count"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if list.Count != 1 || list.Archives[0].ID != reset.Archive.ID {
+		t.Errorf("Unexpected archive list: %s", rr.Body.String())
+	}
+
+	rr = serve(http.MethodGet, "/stats/archives/"+reset.Archive.ID)
+	var archive stats.StatsArchive
+	if err := json.Unmarshal(rr.Body.Bytes(), &archive); err != nil || rr.Code != http.StatusOK || len(archive.Snapshot) == 0 {
+		t.Errorf("Expected the archived snapshot, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr = serve(http.MethodGet, "/stats/archives/nope"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown archive, got %d", rr.Code)
+	}
+
+	// Resetting requires the admin token
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/stats/reset", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", rr.Code)
+	}
+}
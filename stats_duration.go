@@ -0,0 +1,49 @@
+package main
+
+import (
+	"lyrics-api-go/stats"
+	"net/http"
+)
+
+// statsDurationHandler reports how far matched tracks were from the requested
+// duration and how far off the closest candidate was when the duration filter
+// rejected every result, per provider. Matches that cluster well below
+// DURATION_MATCH_DELTA_MS suggest the filter can be tightened; the cumulative
+// rejection counts show how many failures a wider delta would have let through.
+// Cache hits show how far stored track lengths were from the requested duration;
+// the ones beyond the delta are served with a duration_mismatch warning.
+//
+// Query params:
+//   - provider: Only this provider (e.g. ttml)
+func statsDurationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	s := stats.Get()
+	matches := s.DurationMatches()
+	rejections := s.DurationRejections()
+	cacheHits := s.DurationCacheHits()
+	if provider := r.URL.Query().Get("provider"); provider != "" {
+		matches = onlyProvider(matches, provider)
+		rejections = onlyProvider(rejections, provider)
+		cacheHits = onlyProvider(cacheHits, provider)
+	}
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"delta_ms":            conf().Configuration.DurationMatchDeltaMs,
+		"matches":             matches,
+		"rejections":          rejections,
+		"cache_hits":          cacheHits,
+		"duration_mismatches": s.DurationMismatches.Load(),
+	})
+}
+
+func onlyProvider(histograms map[string]stats.DurationHistogram, provider string) map[string]stats.DurationHistogram {
+	result := make(map[string]stats.DurationHistogram)
+	if h, ok := histograms[provider]; ok {
+		result[provider] = h
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatsDurationHandler(t *testing.T) {
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
+
+	stats.Get().RecordDurationMatch("duration_test", 300)
+	stats.Get().RecordDurationRejection("duration_test", 4000)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/stats/duration?provider=duration_test", nil)
+	r.Header.Set("Authorization", "test-token")
+	statsDurationHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+
+	var body struct {
+		DeltaMs    int                                `json:"delta_ms"`
+		Matches    map[string]stats.DurationHistogram `json:"matches"`
+		Rejections map[string]stats.DurationHistogram `json:"rejections"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.DeltaMs != conf().Configuration.DurationMatchDeltaMs || len(body.Matches) != 1 || len(body.Rejections) != 1 {
+		t.Fatalf("Unexpected body %+v", body)
+	}
+	if body.Matches["duration_test"].P50Ms != 500 || body.Rejections["duration_test"].MaxMs != 4000 {
+		t.Errorf("Unexpected histograms %+v", body)
+	}
+
+	w = httptest.NewRecorder()
+	statsDurationHandler(w, httptest.NewRequest(http.MethodGet, "/stats/duration", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d", w.Code)
+	}
+}
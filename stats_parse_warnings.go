@@ -0,0 +1,50 @@
+package main
+
+import (
+	"lyrics-api-go/stats"
+	"net/http"
+	"strconv"
+)
+
+// parseWarningsListLimit is the default number of tracks /stats/parse-warnings returns
+const parseWarningsListLimit = 100
+
+// statsParseWarningsHandler lists tracks whose fetched TTML the parser could only
+// partly parse (malformed paragraphs, bad timings), with counts per reason. Those
+// tracks are served with the lines that parsed plus a warnings list.
+//
+// Query params:
+//   - track: Only this Apple track ID
+//   - limit: Maximum tracks to return (default 100), most recently seen first
+func statsParseWarningsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit := parseWarningsListLimit
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	byReason, tracks := stats.Get().ParseWarnings()
+	total := len(tracks)
+	if track := r.URL.Query().Get("track"); track != "" {
+		filtered := []stats.TrackParseWarnings{}
+		for _, entry := range tracks {
+			if entry.Track == track {
+				filtered = append(filtered, entry)
+			}
+		}
+		tracks = filtered
+	}
+	if len(tracks) > limit {
+		tracks = tracks[:limit]
+	}
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"by_reason": byReason,
+		"tracks":    tracks,
+		"count":     len(tracks),
+		"total":     total,
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetLyrics_FormatLinesReturnsPartialLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	partialTTML := `<tt xmlns="http://www.w3.org/ns/ttml" timing="Line"><body><div>` +
+		`<p begin="1.0" end="2.0">Good line</p><p begin="2.0" end="3.0">Bad <span>line</p>` +
+		`</div></body></tt>`
+	setCachedLyrics(buildNormalizedCacheKey("song", "artist", "", ""), partialTTML, 0, 0, "", false)
+
+	req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format=lines", nil)
+	rr := httptest.NewRecorder()
+	getLyrics(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var body struct {
+		Lines []struct {
+			Words string `json:"words"`
+		} `json:"lines"`
+		Warnings []struct {
+			Reason string `json:"reason"`
+			Count  int    `json:"count"`
+		} `json:"warnings"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Lines) != 1 || body.Lines[0].Words != "Good line" {
+		t.Errorf("Expected the line that parsed, got %+v", body.Lines)
+	}
+	if len(body.Warnings) != 2 || body.Warnings[1].Reason != "malformed paragraph" || body.Warnings[1].Count != 1 {
+		t.Errorf("Unexpected warnings %+v", body.Warnings)
+	}
+}
+
+func TestStatsParseWarningsHandler(t *testing.T) {
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
+
+	stats.Get().RecordParseWarnings("parse-warnings-test", map[string]int{"malformed paragraph": 2})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/stats/parse-warnings?track=parse-warnings-test", nil)
+	r.Header.Set("Authorization", "test-token")
+	statsParseWarningsHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+
+	var body struct {
+		ByReason map[string]int64           `json:"by_reason"`
+		Tracks   []stats.TrackParseWarnings `json:"tracks"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Tracks) != 1 || body.Tracks[0].Reasons["malformed paragraph"] != 2 || body.ByReason["malformed paragraph"] < 2 {
+		t.Errorf("Unexpected body %+v", body)
+	}
+}
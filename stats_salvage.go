@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/stats"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// statsSalvageHandler lists the corrupt stats DBs that were moved aside at startup
+// (GET) or adds the counters still readable from one of them to the live stats
+// (POST). A salvaged file is renamed with a .salvaged suffix and no longer listed.
+//
+// Query params (POST):
+//   - file: Name of the moved file, as listed by GET (optional when there is only one)
+func statsSalvageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if statsStore == nil {
+		Respond(w, r).Error(http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "Stats store is not available",
+		})
+		return
+	}
+
+	files, err := statsStore.CorruptFiles()
+	if err != nil {
+		Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if r.Method == http.MethodGet {
+		Respond(w, r).JSON(map[string]interface{}{
+			"files": files,
+			"count": len(files),
+		})
+		return
+	}
+
+	name := r.URL.Query().Get("file")
+	if name == "" {
+		if len(files) != 1 {
+			Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+				"error": "Specify which file to salvage with the file parameter",
+				"files": files,
+			})
+			return
+		}
+		name = files[0].Name
+	}
+
+	result, err := statsStore.Salvage(name)
+	switch {
+	case errors.Is(err, stats.ErrCorruptFileNotFound):
+		Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+			"error": err.Error(),
+			"files": files,
+		})
+		return
+	case errors.Is(err, stats.ErrNothingSalvaged):
+		Respond(w, r).Error(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error": err.Error(),
+			"file":  name,
+		})
+		return
+	case err != nil:
+		log.Errorf("%s Failed to salvage stats from %s: %v", logcolors.LogStats, name, err)
+		Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	Respond(w, r).JSON(map[string]interface{}{
+		"message": "Stats salvaged",
+		"salvage": result,
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatsSalvageHandler(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	// A stats DB that is too damaged to open becomes a corrupt file at startup
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "stats.db")
+	os.WriteFile(dbPath, []byte(`garbage {"total_requests":12,"cache_hits":3} garbage`), 0600)
+	stats.Get().Reset(time.Now())
+	store, recovery, err := stats.OpenStore(dbPath)
+	if err != nil || recovery == nil {
+		t.Fatalf("OpenStore() = %+v, %v", recovery, err)
+	}
+	saved := statsStore
+	statsStore = store
+	defer func() {
+		statsStore = saved
+		store.Close()
+	}()
+	router := newTestRouter()
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "test-token")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var list struct {
+		Files []stats.CorruptFile `json:"files"`
+		Count int                 `json:"count"`
+	}
+	rr := serve(http.MethodGet, "/stats/salvage")
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if list.Count != 1 || list.Files[0].Name != filepath.Base(recovery.MovedTo) {
+		t.Fatalf("Unexpected listing: %s", rr.Body.String())
+	}
+
+	if rr = serve(http.MethodPost, "/stats/salvage?file=other.db"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unlisted file, got %d", rr.Code)
+	}
+
+	rr = serve(http.MethodPost, "/stats/salvage")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := stats.Get().CacheHits.Load(); got != 3 {
+		t.Errorf("Expected salvaged cache hits, got %d", got)
+	}
+
+	rr = serve(http.MethodGet, "/stats/salvage")
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if list.Count != 0 {
+		t.Errorf("Expected the salvaged file to be unlisted: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/stats/salvage", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// suggestMinScore is the lowest title similarity worth offering as "did you mean"
+	suggestMinScore = 0.6
+	// suggestMaxChecks bounds how many candidates are loaded to skip no-lyrics markers
+	suggestMaxChecks = 5
+)
+
+// LyricsSuggestion is a cached track offered on a 404 when the client passes suggest=true
+type LyricsSuggestion struct {
+	Song   string  `json:"song"`
+	Artist string  `json:"artist"`
+	Key    string  `json:"key"`
+	Score  float64 `json:"score"` // Title similarity, 0-1
+}
+
+// withSuggestion adds the nearest cached track to a 404 body when the lookup asked
+// for one (suggest=true). The body is returned unchanged when the flag is off or
+// nothing is close enough.
+func withSuggestion(suggest bool, body map[string]interface{}, songName, artistName string) map[string]interface{} {
+	if !suggest {
+		return body
+	}
+	if suggestion := findNearestCachedLyrics(songName, artistName); suggestion != nil {
+		body["suggestion"] = suggestion
+	}
+	return body
+}
+
+// findNearestCachedLyrics scans ttml_lyrics keys (names only) for entries by the same
+// artist and returns the one whose title is most similar to songName.
+// Keys are "ttml_lyrics:{song} {artist}[ {album}][ {duration}s]", so the title is
+// whatever precedes the artist.
+func findNearestCachedLyrics(songName, artistName string) *LyricsSuggestion {
+	song := strings.ToLower(strings.TrimSpace(songName))
+	artist := strings.ToLower(strings.TrimSpace(artistName))
+	if song == "" || artist == "" {
+		return nil
+	}
+
+	const prefix = "ttml_lyrics:"
+	var candidates []LyricsSuggestion
+	persistentCache.RangeKeys(prefix, func(key string) bool {
+		title, ok := titleBeforeArtist(key[len(prefix):], artist)
+		if !ok {
+			return true
+		}
+		if score := titleSimilarity(song, title); score >= suggestMinScore {
+			candidates = append(candidates, LyricsSuggestion{Song: title, Artist: artist, Key: key, Score: score})
+		}
+		return true
+	})
+
+	// Best score first; among equals prefer the plainest key (no album/duration)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return len(candidates[i].Key) < len(candidates[j].Key)
+	})
+
+	for i := 0; i < len(candidates) && i < suggestMaxChecks; i++ {
+		if cached, ok := getCachedLyrics(candidates[i].Key); ok && cached.TTML != NoLyricsSentinel {
+			return &candidates[i]
+		}
+	}
+	return nil
+}
+
+// titleBeforeArtist returns the part of a normalized query that precedes " {artist}",
+// where the artist is followed by the end of the query or a space
+func titleBeforeArtist(query, artist string) (string, bool) {
+	needle := " " + artist
+	offset := 0
+	for {
+		idx := strings.Index(query[offset:], needle)
+		if idx < 0 {
+			return "", false
+		}
+		idx += offset
+		end := idx + len(needle)
+		if idx > 0 && (end == len(query) || query[end] == ' ') {
+			return query[:idx], true
+		}
+		offset = idx + 1
+	}
+}
+
+// titleSimilarity is 1 - (edit distance / longer length), on runes
+func titleSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longer := max(len(ra), len(rb))
+	if longer == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longer)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTitleBeforeArtist(t *testing.T) {
+	tests := []struct {
+		query, artist, title string
+		ok                   bool
+	}{
+		{"hello adele", "adele", "hello", true},
+		{"hello adele 25 295s", "adele", "hello", true},
+		{"someone like you adele", "adele", "someone like you", true},
+		{"hello adeles", "adele", "", false},
+		{"adele", "adele", "", false},
+		{"hello lionel richie", "lionel richie", "hello", true},
+	}
+	for _, tt := range tests {
+		title, ok := titleBeforeArtist(tt.query, tt.artist)
+		if ok != tt.ok || title != tt.title {
+			t.Errorf("titleBeforeArtist(%q, %q) = %q, %v; want %q, %v", tt.query, tt.artist, title, ok, tt.title, tt.ok)
+		}
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	if got := titleSimilarity("hello", "hello"); got != 1 {
+		t.Errorf("Identical titles: got %v", got)
+	}
+	if got := titleSimilarity("someone like yuo", "someone like you"); got < 0.8 {
+		t.Errorf("Typo should be similar, got %v", got)
+	}
+	if got := titleSimilarity("hello", "rolling in the deep"); got >= suggestMinScore {
+		t.Errorf("Unrelated titles should be below threshold, got %v", got)
+	}
+}
+
+func TestFindNearestCachedLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	setCachedLyrics(buildNormalizedCacheKey("Someone Like You", "Adele", "21", ""), "<tt>a</tt>", 0, 0.9, "", false)
+	setCachedLyrics(buildNormalizedCacheKey("Someone Like You", "Adele", "", ""), "<tt>b</tt>", 0, 0.9, "", false)
+	setCachedLyrics(buildNormalizedCacheKey("Someone Like You", "Other Artist", "", ""), "<tt>c</tt>", 0, 0.9, "", false)
+	setCachedLyrics(buildNormalizedCacheKey("Someone Like Yo", "Adele", "", ""), NoLyricsSentinel, 0, 0, "", false)
+
+	suggestion := findNearestCachedLyrics("someone like yuo", "ADELE")
+	if suggestion == nil {
+		t.Fatal("Expected a suggestion")
+	}
+	if suggestion.Key != "ttml_lyrics:someone like you adele" || suggestion.Song != "someone like you" {
+		t.Errorf("Unexpected suggestion: %+v", suggestion)
+	}
+
+	if got := findNearestCachedLyrics("rolling in the deep", "adele"); got != nil {
+		t.Errorf("Expected no suggestion for an unrelated title, got %+v", got)
+	}
+	if got := findNearestCachedLyrics("someone like you", "nobody"); got != nil {
+		t.Errorf("Expected no suggestion for another artist, got %+v", got)
+	}
+}
+
+func TestGetLyrics_SuggestOnNegativeHit(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	setCachedLyrics(buildNormalizedCacheKey("Hello", "Adele", "", ""), "<tt>hello</tt>", 0, 0.9, "", false)
+	setNegativeCache(buildNormalizedCacheKey("Helo", "Adele", "", ""), "no track found", "", false)
+
+	for _, tc := range []struct {
+		url        string
+		suggestion bool
+	}{
+		{"/getLyrics?s=helo&a=adele", false},
+		{"/getLyrics?s=helo&a=adele&suggest=true", true},
+	} {
+		req := httptest.NewRequest("GET", tc.url, nil)
+		rr := httptest.NewRecorder()
+		getLyrics(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", tc.url, rr.Code)
+		}
+		var body map[string]interface{}
+		json.Unmarshal(rr.Body.Bytes(), &body)
+		suggestion, ok := body["suggestion"].(map[string]interface{})
+		if ok != tc.suggestion {
+			t.Fatalf("%s: suggestion present = %v, want %v (%v)", tc.url, ok, tc.suggestion, body)
+		}
+		if ok && suggestion["song"] != "hello" {
+			t.Errorf("%s: unexpected suggestion %v", tc.url, suggestion)
+		}
+	}
+}
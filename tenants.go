@@ -0,0 +1,120 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/middleware"
+	"lyrics-api-go/stats"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// A hosted instance can serve several frontends (the extension, a partner app) as
+// tenants: each API_KEY_TENANTS key is bound to a tenant namespace. Its requests
+// read and write cache entries under their own keys, share one quota
+// (TENANT_RATE_LIMIT_PER_SECOND) instead of the per-IP limits, and are counted
+// apart in /stats. Requests without a tenant key use the shared cache as before.
+
+var (
+	invalidAPIKeyTenantsOnce sync.Once
+	tenantQuotas             sync.Map // "rate/burst" -> *middleware.IPRateLimiter keyed by tenant
+)
+
+// apiKeyTenants returns API_KEY_TENANTS as parsed at load, or none when it is invalid
+func apiKeyTenants() map[string]string {
+	tenants, err := conf().GetAPIKeyTenants()
+	if err != nil {
+		invalidAPIKeyTenantsOnce.Do(func() {
+			log.Warnf("%s Ignoring API_KEY_TENANTS: %v", logcolors.LogConfig, err)
+		})
+		return nil
+	}
+	return tenants
+}
+
+// validAPIKeys returns API_KEY and every tenant key
+func validAPIKeys() []string {
+	keys := []string{conf().Configuration.APIKey}
+	for key := range apiKeyTenants() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// isValidAPIKey reports whether key is API_KEY or a tenant key
+func isValidAPIKey(key string) bool {
+	if _, ok := apiKeyTenants()[key]; ok {
+		return true
+	}
+	return key == conf().Configuration.APIKey
+}
+
+// requestTenant returns the tenant namespace of the request, or "" for the shared one
+func requestTenant(r *http.Request) string {
+	tenant, _ := r.Context().Value(tenantKey).(string)
+	return tenant
+}
+
+// tenantCacheKey scopes a lyrics cache key to a tenant namespace. Like explicit=
+// overrides, tenant entries are only ever looked up by their exact key.
+func tenantCacheKey(cacheKey, tenant string) string {
+	return cacheKey + " [tenant:" + tenant + "]"
+}
+
+// tenantQuota returns the limiter enforcing TENANT_RATE_LIMIT_PER_SECOND, or nil
+// when tenants have no quota
+func tenantQuota() *middleware.IPRateLimiter {
+	perSecond := conf().Configuration.TenantRateLimitPerSecond
+	if perSecond <= 0 {
+		return nil
+	}
+	burst := cmp.Or(conf().Configuration.TenantRateLimitBurst, perSecond)
+	key := fmt.Sprintf("%d/%d", perSecond, burst)
+	if limiter, ok := tenantQuotas.Load(key); ok {
+		return limiter.(*middleware.IPRateLimiter)
+	}
+	limiter, _ := tenantQuotas.LoadOrStore(key, middleware.NewIPRateLimiter(rate.Limit(perSecond), burst, rate.Limit(perSecond), burst))
+	return limiter.(*middleware.IPRateLimiter)
+}
+
+// tenantMiddleware puts the tenant of requests made with an API_KEY_TENANTS key in
+// the context, applies the tenant quota (the per-IP limits are skipped for them,
+// see limitMiddleware) and counts the request in the tenant's usage
+func tenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-API-Key")
+		tenant := ""
+		if apiKey != "" {
+			tenant = apiKeyTenants()[apiKey]
+		}
+		if tenant == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := middleware.NewResponseRecorder(w)
+		defer func() {
+			stats.Get().RecordTenantRequest(tenant, rec.Header().Get("X-Cache-Status"), rec.StatusCode)
+		}()
+
+		if quota := tenantQuota(); quota != nil {
+			limiters := quota.GetLimiter(tenant)
+			if !limiters.Normal.Allow() {
+				log.Warnf("%s Tenant %s exceeded its quota", logcolors.LogRateLimit, tenant)
+				setRateLimitHeaders(rec, "tenant", quota.GetNormalLimit(), 0, limiters.GetNormalReset())
+				Respond(rec, r).RateLimited("tenant", limiters.GetNormalRetryAfter(), map[string]interface{}{
+					"error":   "Tenant quota exceeded",
+					"message": "Requests for this API key exceed TENANT_RATE_LIMIT_PER_SECOND.",
+				})
+				return
+			}
+			setRateLimitHeaders(rec, "tenant", quota.GetNormalLimit(), limiters.GetNormalTokens(), limiters.GetNormalReset())
+		}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), tenantKey, tenant)))
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withTenants binds API keys to tenants for the duration of a test
+func withTenants(t *testing.T, tenants string, perSecond int) {
+	t.Helper()
+	original := conf().Configuration
+	conf().Configuration.APIKeyTenants = tenants
+	conf().Configuration.TenantRateLimitPerSecond = perSecond
+	conf().Configuration.TenantRateLimitBurst = perSecond
+	t.Cleanup(func() { conf().Configuration = original })
+}
+
+func TestGetLyrics_TenantCacheIsolation(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	stats.Get().Reset(time.Now())
+	withTenants(t, "ext-key:extension,partner-key:partner", 0)
+	// Uncached lookups answer 503 instead of going upstream
+	originalCacheOnly := conf().FeatureFlags.CacheOnlyMode
+	conf().FeatureFlags.CacheOnlyMode = true
+	defer func() { conf().FeatureFlags.CacheOnlyMode = originalCacheOnly }()
+
+	sharedKey := buildNormalizedCacheKey("Shared", "Artist", "", "")
+	setCachedLyrics(sharedKey, testTTML, 0, 0.9, "", false)
+	setCachedLyrics(tenantCacheKey(buildNormalizedCacheKey("Own", "Artist", "", ""), "extension"), testTTML, 0, 0.9, "", false)
+
+	handler := tenantMiddleware(http.HandlerFunc(getLyrics))
+	tests := []struct {
+		name       string
+		query      string
+		apiKey     string
+		wantStatus int
+	}{
+		{"shared client sees shared entry", "s=Shared&a=Artist", "", http.StatusOK},
+		{"tenant doesn't see shared entry", "s=Shared&a=Artist", "ext-key", http.StatusServiceUnavailable},
+		{"tenant sees its own entry", "s=Own&a=Artist", "ext-key", http.StatusOK},
+		{"other tenant doesn't", "s=Own&a=Artist", "partner-key", http.StatusServiceUnavailable},
+		{"shared client doesn't", "s=Own&a=Artist", "", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/getLyrics?"+tt.query, nil)
+			if tt.apiKey != "" {
+				r.Header.Set("X-API-Key", tt.apiKey)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+
+	usage := stats.Get().TenantUsage()
+	if got := usage["extension"]; got.Requests != 2 || got.CacheStatus["HIT"] != 1 || got.CacheStatus["MISS"] != 1 || got.Errors != 1 {
+		t.Errorf("Unexpected extension usage %+v", got)
+	}
+	if got := usage["partner"]; got.Requests != 1 || got.CacheStatus["HIT"] != 0 {
+		t.Errorf("Unexpected partner usage %+v", got)
+	}
+}
+
+func TestTenantMiddleware_Quota(t *testing.T) {
+	stats.Get().Reset(time.Now())
+	withTenants(t, "ext-key:extension,partner-key:partner", 2)
+	var seenTenant string
+	handler := tenantMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenTenant = requestTenant(r)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/getLyrics?s=x", nil)
+		r.Header.Set("X-API-Key", apiKey)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := do("ext-key"); rr.Code != http.StatusOK || seenTenant != "extension" {
+			t.Fatalf("Request %d: status %d, tenant %q", i, rr.Code, seenTenant)
+		}
+	}
+	rr := do("ext-key")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Over quota: status %d, Retry-After %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	// The quota is per tenant
+	if rr := do("partner-key"); rr.Code != http.StatusOK || seenTenant != "partner" {
+		t.Errorf("Other tenant: status %d, tenant %q", rr.Code, seenTenant)
+	}
+	// Keys that aren't tenant keys pass through untouched
+	seenTenant = "unset"
+	if rr := do("unknown-key"); rr.Code != http.StatusOK || seenTenant != "" {
+		t.Errorf("Unknown key: status %d, tenant %q", rr.Code, seenTenant)
+	}
+
+	if got := stats.Get().TenantUsage()["extension"]; got.Requests != 3 || got.RateLimited != 1 {
+		t.Errorf("Unexpected extension usage %+v", got)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"lyrics-api-go/conformance"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+)
+
+// testCorpusHandler serves the conformance corpus (see package conformance):
+// GET /testdata lists the cases with a URL per format, and GET /testdata/{case}
+// renders one exactly as /getLyrics would render cached lyrics, so client tests
+// can compare against it. format= and client= work as on /getLyrics.
+func testCorpusHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["case"]
+	if name == "" {
+		cases := make([]map[string]interface{}, 0)
+		for _, c := range conformance.Cases() {
+			formats := make(map[string]string, len(supportedLyricsFormats))
+			for _, format := range supportedLyricsFormats {
+				formats[format] = "/testdata/" + url.PathEscape(c.Name) + "?format=" + format
+			}
+			cases = append(cases, map[string]interface{}{
+				"name":        c.Name,
+				"description": c.Description,
+				"formats":     formats,
+			})
+		}
+		Respond(w, r).JSON(map[string]interface{}{
+			"cases":   cases,
+			"count":   len(cases),
+			"formats": supportedLyricsFormats,
+		})
+		return
+	}
+
+	content, ok := conformance.Lookup(name)
+	if !ok {
+		Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+			"error": "Unknown test case",
+			"case":  name,
+		})
+		return
+	}
+	format, err := parseLyricsFormat(r)
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := parseResponseClient(r); err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := parseFieldFilter(r); err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := parseCleanParam(r); err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondTTML(Respond(w, r), format, content, lyricsBody(&CachedLyrics{TTML: content}))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/conformance"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTestCorpus_List(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	rr := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/testdata", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var body struct {
+		Cases []struct {
+			Name    string            `json:"name"`
+			Formats map[string]string `json:"formats"`
+		} `json:"cases"`
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Count != len(conformance.Cases()) || len(body.Cases) != body.Count {
+		t.Fatalf("count = %d with %d cases, want %d", body.Count, len(body.Cases), len(conformance.Cases()))
+	}
+	if got := body.Cases[0].Formats[formatLRC]; got != "/testdata/"+body.Cases[0].Name+"?format=lrc" {
+		t.Errorf("lrc URL = %q", got)
+	}
+}
+
+// Every case renders in every format through the same path as /getLyrics
+func TestTestCorpus_EveryCaseEveryFormat(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	router := newTestRouter()
+
+	for _, c := range conformance.Cases() {
+		for _, format := range supportedLyricsFormats {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/testdata/"+c.Name+"?format="+format, nil))
+			if rr.Code != http.StatusOK {
+				t.Errorf("%s as %s: status %d: %s", c.Name, format, rr.Code, rr.Body.String())
+				continue
+			}
+			if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, strings.Split(formatContentType(format), ";")[0]) {
+				t.Errorf("%s as %s: Content-Type = %q", c.Name, format, got)
+			}
+			if rr.Body.Len() == 0 {
+				t.Errorf("%s as %s: empty body", c.Name, format)
+			}
+		}
+	}
+}
+
+func TestTestCorpus_MatchesGetLyrics(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	content, _ := conformance.Lookup("duet")
+	setCachedLyrics(buildNormalizedCacheKey("song", "artist", "", ""), content, 0, 0, "", false)
+
+	for _, format := range supportedLyricsFormats {
+		corpus := httptest.NewRecorder()
+		newTestRouter().ServeHTTP(corpus, httptest.NewRequest(http.MethodGet, "/testdata/duet?format="+format, nil))
+		lyrics := httptest.NewRecorder()
+		getLyrics(lyrics, httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist&format="+format, nil))
+
+		if corpus.Body.String() != lyrics.Body.String() {
+			t.Errorf("format %s: /testdata body differs from /getLyrics:\n%s\nvs\n%s", format, corpus.Body.String(), lyrics.Body.String())
+		}
+	}
+}
+
+func TestTestCorpus_Errors(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	router := newTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/testdata/missing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown case: status %d, want 404", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/testdata/duet?format=xml", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d, want 400", rr.Code)
+	}
+}
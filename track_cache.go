@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/logcolors"
+	"lyrics-api-go/utils"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// trackLyricsPrefix namespaces lyrics blobs keyed by Apple track ID. Query-keyed
+// ttml_lyrics entries are aliases of these (see resolveCacheAlias), so every phrasing
+// of a song shares one blob, and invalidating the track invalidates all of them.
+const trackLyricsPrefix = "ttml_track:"
+
+func trackLyricsKey(trackID string) string {
+	return trackLyricsPrefix + trackID
+}
+
+// getTrackLyrics returns the stored TTML for a track ID. Registered with the TTML
+// provider so a search that resolves to an already-cached track skips the lyrics fetch.
+func getTrackLyrics(trackID string) (string, bool) {
+	raw, ok := persistentCache.Get(trackLyricsKey(trackID))
+	if !ok {
+		return "", false
+	}
+	var cached CachedLyrics
+	if err := json.Unmarshal([]byte(raw), &cached); err != nil || cached.TTML == "" || cached.TTML == NoLyricsSentinel {
+		return "", false
+	}
+	return cached.TTML, true
+}
+
+// setCachedLyricsForTrack stores the lyrics blob under the track ID and points the
+// query key at it. Without a track ID it falls back to a plain query-keyed entry.
+func setCachedLyricsForTrack(key, trackID, lyrics string, trackDurationMs int, score float64, language string, isRTL bool) {
+	if trackID == "" {
+		setCachedLyrics(key, lyrics, trackDurationMs, score, language, isRTL)
+		return
+	}
+
+	hash := lyricsContentHash(lyrics)
+	storedAt := clk.Now().Unix()
+	blob, err := json.Marshal(CachedLyrics{
+		TTML:            lyrics,
+		TrackDurationMs: trackDurationMs,
+		Language:        language,
+		IsRTL:           isRTL,
+		ContentHash:     hash,
+		StoredAt:        storedAt,
+	})
+	if err != nil {
+		log.Errorf("%s Error marshaling track lyrics: %v", logcolors.LogCacheLyrics, err)
+		return
+	}
+	trackKey := trackLyricsKey(trackID)
+	// Read as stored before the overwrite; it's decoded and compared in the background
+	previous, hadPrevious := persistentCache.GetEntry(trackKey)
+	if err := persistentCache.Set(trackKey, string(blob)); err != nil {
+		log.Errorf("%s Error setting track lyrics %s: %v", logcolors.LogCacheLyrics, trackKey, err)
+		return
+	}
+	if hadPrevious {
+		goWrite(func() { recordLyricsChange(trackID, key, previous, lyrics, hash) })
+	}
+
+	alias, err := json.Marshal(CachedLyrics{
+		AliasOf:         trackKey,
+		TrackDurationMs: trackDurationMs,
+		Score:           score,
+		Language:        language,
+		IsRTL:           isRTL,
+		StoredAt:        storedAt,
+	})
+	if err != nil {
+		log.Errorf("%s Error marshaling lyrics alias: %v", logcolors.LogCacheLyrics, err)
+		return
+	}
+	if err := persistentCache.Set(key, string(alias)); err != nil {
+		log.Errorf("%s Error setting cache value: %v", logcolors.LogCacheLyrics, err)
+		return
+	}
+	indexCachedLyrics(key, lyrics)
+}
+
+// queryKeysForTrack returns the query keys whose metadata points at a track ID
+func queryKeysForTrack(trackID string) []string {
+	var keys []string
+	persistentCache.RangeBucket(metadataBucket, func(k, v []byte) bool {
+		raw, err := utils.DecompressString(string(v))
+		if err != nil {
+			raw = string(v)
+		}
+		var meta SongMetadata
+		if err := json.Unmarshal([]byte(raw), &meta); err == nil && meta.AppleTrackID == trackID {
+			keys = append(keys, string(k))
+		}
+		return true
+	})
+	return keys
+}
+
+// invalidateTrackLyrics deletes the blob for a track and every query key whose
+// metadata points at it. Returns the query keys removed.
+func invalidateTrackLyrics(trackID string) ([]string, error) {
+	keys := queryKeysForTrack(trackID)
+	for _, key := range keys {
+		if err := deleteCacheEntry(key, trashReasonTrackInvalidate); err != nil {
+			return nil, err
+		}
+	}
+	if err := deleteCacheEntry(trackLyricsKey(trackID), trashReasonTrackInvalidate); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// trackCacheHandler inspects (GET) or invalidates (DELETE) the lyrics stored for an
+// Apple track ID.
+//
+// Query params:
+//   - id: Apple Music track ID (required)
+func trackCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	trackID := strings.TrimSpace(r.URL.Query().Get("id"))
+	if trackID == "" {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "id parameter is required",
+		})
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		keys, err := invalidateTrackLyrics(trackID)
+		if err != nil {
+			Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+		log.Infof("%s Invalidated track %s (%d query keys)", logcolors.LogCacheLyrics, trackID, len(keys))
+		Respond(w, r).JSON(map[string]interface{}{
+			"trackId": trackID,
+			"deleted": len(keys),
+			"keys":    keys,
+		})
+		return
+	}
+
+	ttml, ok := getTrackLyrics(trackID)
+	if !ok {
+		Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+			"error":   "no lyrics cached for this track",
+			"trackId": trackID,
+		})
+		return
+	}
+	Respond(w, r).JSON(map[string]interface{}{
+		"trackId": trackID,
+		"key":     trackLyricsKey(trackID),
+		"bytes":   len(ttml),
+		"ttml":    ttml,
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetCachedLyricsForTrack_SharesOneBlob(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	ttmlContent := `<tt><body><div><p begin="0.0" end="1.0">Hello from the other side</p></div></body></tt>`
+	setCachedLyricsForTrack("ttml_lyrics:hello adele", "1440", ttmlContent, 295000, 0.95, "en", false)
+	setCachedLyricsForTrack("ttml_lyrics:hello adele 25", "1440", ttmlContent, 295000, 0.9, "en", false)
+
+	raw, ok := persistentCache.Get("ttml_lyrics:hello adele 25")
+	if !ok {
+		t.Fatal("Expected query key to be stored")
+	}
+	var alias CachedLyrics
+	if err := json.Unmarshal([]byte(raw), &alias); err != nil || alias.AliasOf != trackLyricsKey("1440") || alias.TTML != "" {
+		t.Errorf("Expected an alias of the track blob, got %s", raw)
+	}
+
+	cached, ok := getCachedLyrics("ttml_lyrics:hello adele 25")
+	if !ok || cached.TTML != ttmlContent {
+		t.Fatalf("Expected alias to resolve to the track blob, got %+v", cached)
+	}
+	if cached.Score != 0.9 {
+		t.Errorf("Expected the alias to keep its own score, got %v", cached.Score)
+	}
+
+	if got, ok := getTrackLyrics("1440"); !ok || got != ttmlContent {
+		t.Errorf("Expected track lookup to hit, got %q %v", got, ok)
+	}
+	if _, ok := getTrackLyrics("9999"); ok {
+		t.Error("Expected unknown track to miss")
+	}
+}
+
+func TestSetCachedLyricsForTrack_NoTrackID(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	setCachedLyricsForTrack("ttml_lyrics:song artist", "", "<tt>lyrics</tt>", 0, 0, "", false)
+	cached, ok := getCachedLyrics("ttml_lyrics:song artist")
+	if !ok || cached.TTML != "<tt>lyrics</tt>" || cached.AliasOf != "" {
+		t.Errorf("Expected a plain entry without a track ID, got %+v", cached)
+	}
+}
+
+func TestTrackCacheHandler_Invalidate(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	setCachedLyricsForTrack("ttml_lyrics:hello adele", "1440", "<tt>hello</tt>", 295000, 0.95, "", false)
+	setCachedLyricsForTrack("ttml_lyrics:hello adele 295s", "1440", "<tt>hello</tt>", 295000, 0.95, "", false)
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:hello adele", AppleTrackID: "1440"})
+	setSongMetadata(&SongMetadata{CacheKey: "ttml_lyrics:hello adele 295s", AppleTrackID: "1440"})
+
+	req := httptest.NewRequest("GET", "/cache/track?id=1440", nil)
+	rr := httptest.NewRecorder()
+	trackCacheHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest("DELETE", "/cache/track?id=1440", nil)
+	rr = httptest.NewRecorder()
+	trackCacheHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Deleted int `json:"deleted"`
+	}
+	json.NewDecoder(rr.Body).Decode(&body)
+	if body.Deleted != 2 {
+		t.Errorf("Expected 2 query keys deleted, got %d", body.Deleted)
+	}
+
+	for _, key := range []string{"ttml_lyrics:hello adele", "ttml_lyrics:hello adele 295s", trackLyricsKey("1440")} {
+		if _, ok := persistentCache.Get(key); ok {
+			t.Errorf("Expected %s to be deleted", key)
+		}
+	}
+}
+
+func TestTrackCacheHandler_MissingID(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	rr := httptest.NewRecorder()
+	trackCacheHandler(rr, httptest.NewRequest("GET", "/cache/track", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rr.Code)
+	}
+}
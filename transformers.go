@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"lyrics-api-go/logcolors"
+	ttml "lyrics-api-go/services/providers/ttml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// responseTransformer reshapes the default /getLyrics JSON body for one client.
+// body is what the default shape would send (see lyricsBody).
+type responseTransformer func(ttmlContent string, body map[string]interface{}) (interface{}, error)
+
+var (
+	responseTransformersMu sync.RWMutex
+	responseTransformers   = make(map[string]responseTransformer)
+)
+
+// registerResponseTransformer makes a payload shape available as client=<name>.
+// Shipped clients pin a name, so the internal body can change without breaking them.
+func registerResponseTransformer(client string, transform responseTransformer) {
+	responseTransformersMu.Lock()
+	defer responseTransformersMu.Unlock()
+	responseTransformers[client] = transform
+}
+
+// lookupResponseTransformer returns the transformer for client, nil for the default shape
+func lookupResponseTransformer(client string) responseTransformer {
+	responseTransformersMu.RLock()
+	defer responseTransformersMu.RUnlock()
+	return responseTransformers[client]
+}
+
+// responseClientNames lists the registered clients, sorted
+func responseClientNames() []string {
+	responseTransformersMu.RLock()
+	defer responseTransformersMu.RUnlock()
+	names := make([]string, 0, len(responseTransformers))
+	for name := range responseTransformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	registerResponseTransformer("extension", extensionTransformer)
+	registerResponseTransformer("v2", parsedLinesBody)
+	registerResponseTransformer("overlay", overlayTransformer)
+}
+
+// parseResponseClient validates the client= query parameter
+func parseResponseClient(r *http.Request) (string, error) {
+	client := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("client")))
+	if client != "" && lookupResponseTransformer(client) == nil {
+		return "", fmt.Errorf("unsupported client %q (supported: %s)", client, strings.Join(responseClientNames(), ", "))
+	}
+	return client, nil
+}
+
+var invalidAPIKeyClientsOnce sync.Once
+
+// responseClient picks the payload shape for a request: the client= parameter if set,
+// else the API_KEY_CLIENTS entry for its X-API-Key, else "" (the default shape)
+func responseClient(r *http.Request) string {
+	if client, err := parseResponseClient(r); err == nil && client != "" {
+		return client
+	}
+	apiKey := r.Header.Get("X-API-Key")
+	if apiKey == "" {
+		return ""
+	}
+	clients, err := conf().GetAPIKeyClients()
+	if err != nil {
+		invalidAPIKeyClientsOnce.Do(func() {
+			log.Warnf("%s Ignoring API_KEY_CLIENTS: %v", logcolors.LogConfig, err)
+		})
+		return ""
+	}
+	return clients[apiKey]
+}
+
+// extensionTransformer is the legacy better-lyrics extension shape: {"ttml": ...} only
+func extensionTransformer(ttmlContent string, body map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{"ttml": ttmlContent}, nil
+}
+
+// parsedLinesBody is the format=lines body, also served as client=v2. When the
+// parser had to skip malformed parts, the lines that did parse are returned with
+// a warnings list.
+func parsedLinesBody(ttmlContent string, body map[string]interface{}) (interface{}, error) {
+	lines, timingType, warnings, err := ttml.ParseLinesWithWarnings(ttmlContent)
+	if err != nil {
+		return nil, err
+	}
+	vocalists := ttml.AssignVocalists(lines)
+	linesBody := map[string]interface{}{
+		"lines":      lines,
+		"timingType": timingType,
+		"vocalists": map[string]interface{}{
+			"count":  countSingers(vocalists),
+			"agents": vocalists,
+		},
+	}
+	if score, ok := body["score"]; ok {
+		linesBody["score"] = score
+	}
+	if len(warnings) > 0 {
+		linesBody["warnings"] = warnings
+	}
+	return linesBody, nil
+}
+
+// overlayLine is one lyric line for streaming overlays: integer milliseconds and text
+type overlayLine struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// overlayTransformer is a flat line list for OBS-style overlays, which only show the
+// current line and don't need syllables, agents or sections
+func overlayTransformer(ttmlContent string, body map[string]interface{}) (interface{}, error) {
+	lines, timingType, warnings, err := ttml.ParseLinesWithWarnings(ttmlContent)
+	if err != nil {
+		return nil, err
+	}
+	overlay := make([]overlayLine, 0, len(lines))
+	for _, line := range lines {
+		start, _ := strconv.Atoi(line.StartTimeMs)
+		end, _ := strconv.Atoi(line.EndTimeMs)
+		overlay = append(overlay, overlayLine{Start: start, End: end, Text: line.Words})
+	}
+	overlayBody := map[string]interface{}{
+		"lines":  overlay,
+		"synced": timingType != "none",
+	}
+	if len(warnings) > 0 {
+		overlayBody["warnings"] = warnings
+	}
+	return overlayBody, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetLyrics_ClientTransformers(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	originalClients := conf().Configuration.APIKeyClients
+	conf().Configuration.APIKeyClients = "overlay-key:overlay"
+	defer func() { conf().Configuration.APIKeyClients = originalClients }()
+
+	setCachedLyrics(buildNormalizedCacheKey("song", "artist", "", ""), formatTestTTML, 0, 0, "", false)
+
+	get := func(query, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/getLyrics?s=song&a=artist"+query, nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rr := httptest.NewRecorder()
+		getLyrics(rr, req)
+		return rr
+	}
+	decode := func(rr *httptest.ResponseRecorder) map[string]json.RawMessage {
+		t.Helper()
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return body
+	}
+
+	if body := decode(get("&client=extension", "")); len(body) != 1 || body["ttml"] == nil {
+		t.Errorf("client=extension should return only ttml, got keys %v", body)
+	}
+
+	if body := decode(get("&client=v2", "")); body["lines"] == nil || body["timingType"] == nil || body["ttml"] != nil {
+		t.Errorf("client=v2 should return parsed lines, got %v", body)
+	}
+
+	// API key profile applies without client=, and client= wins over it
+	var overlay struct {
+		Lines []overlayLine `json:"lines"`
+	}
+	json.Unmarshal(get("", "overlay-key").Body.Bytes(), &overlay)
+	if len(overlay.Lines) != 2 || overlay.Lines[0] != (overlayLine{Start: 1000, End: 3000, Text: "First line"}) {
+		t.Errorf("API key profile should select the overlay shape, got %+v", overlay.Lines)
+	}
+	if body := decode(get("&client=extension", "overlay-key")); len(body) != 1 || body["ttml"] == nil {
+		t.Errorf("client= should override the API key profile, got %v", body)
+	}
+
+	// Non-default formats are unaffected
+	if rr := get("&format=text", "overlay-key"); !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("format=text should win over the client shape, got %s", rr.Header().Get("Content-Type"))
+	}
+
+	if rr := get("&client=unknown", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown client, got %d", rr.Code)
+	}
+}
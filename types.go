@@ -1,9 +1,5 @@
 package main
 
-import (
-	"sync"
-)
-
 type contextKey string
 
 const (
@@ -12,20 +8,31 @@ const (
 	apiKeyRequiredForFreshKey contextKey = "apiKeyRequiredForFresh"
 	apiKeyAuthenticatedKey    contextKey = "apiKeyAuthenticated"
 	apiKeyInvalidKey          contextKey = "apiKeyInvalid"
+	matchHintsKey             contextKey = "matchHints"
+	serverTimingKey           contextKey = "serverTiming"
+	clientInfoKey             contextKey = "clientInfo"
+	responseWarningsKey       contextKey = "warnings"
+	sheddingLoadKey           contextKey = "sheddingLoad"
+	tenantKey                 contextKey = "tenant"
+	lookupCaptureKey          contextKey = "lookupCapture"
 )
 
 // NoLyricsSentinel is stored as TTML content to permanently mark a track as having no lyrics.
 // Unlike negative cache entries (which expire), this is stored in the positive cache and persists indefinitely.
 const NoLyricsSentinel = "__NO_LYRICS__"
 
-// InFlightRequest tracks concurrent requests for the same query
+// InFlightRequest tracks concurrent requests for the same query. done is closed
+// once the leader has filled in the result; see newInFlightRequest.
 type InFlightRequest struct {
-	wg       sync.WaitGroup
-	result   string
-	score    float64
-	language string
-	isRTL    bool
-	err      error
+	done       chan struct{}
+	result     string
+	score      float64
+	language   string
+	isRTL      bool
+	durationMs int
+	trackID    string
+	trackName  string
+	err        error
 }
 
 // CachedLyrics stores lyrics with track metadata
@@ -35,6 +42,9 @@ type CachedLyrics struct {
 	Score           float64 `json:"score,omitempty"`
 	Language        string  `json:"language,omitempty"`
 	IsRTL           bool    `json:"isRTL,omitempty"`
+	AliasOf         string  `json:"aliasOf,omitempty"`     // Set on lightweight alias entries: the canonical key holding the TTML
+	ContentHash     string  `json:"contentHash,omitempty"` // SHA-256 of TTML, set on track blobs (see lyrics_changes.go)
+	StoredAt        int64   `json:"storedAt,omitempty"`    // Unix seconds the entry was written (0 for entries from before it was recorded)
 }
 
 // NegativeCacheEntry stores info about failed lyrics lookups
@@ -61,44 +71,23 @@ type SongMetadata struct {
 	DurationMs  int    `json:"durationMs,omitempty"`
 	ReleaseDate string `json:"releaseDate,omitempty"`
 
+	// Which release was cached when both exist: "explicit", "clean", or empty when unrated
+	ContentRating string `json:"contentRating,omitempty"`
+
 	// Raw Apple Music attributes JSON for future querying
 	RawAttributes string `json:"rawAttributes,omitempty"`
 
+	// Times the track's lyrics changed upstream after they were first cached, and when
+	// they last did (see lyrics_changes.go)
+	LyricsRevision  int   `json:"lyricsRevision,omitempty"`
+	LyricsChangedAt int64 `json:"lyricsChangedAt,omitempty"`
+
 	// Timestamps
 	FirstSeen   int64 `json:"firstSeen"`
 	LastUpdated int64 `json:"lastUpdated"`
 }
 
-// MigrationJobStatus represents the status of an async migration job
-type MigrationJobStatus string
-
-const (
-	JobStatusPending   MigrationJobStatus = "pending"
-	JobStatusRunning   MigrationJobStatus = "running"
-	JobStatusCompleted MigrationJobStatus = "completed"
-	JobStatusFailed    MigrationJobStatus = "failed"
-)
-
-// MigrationJob tracks an async cache migration
-type MigrationJob struct {
-	ID          string             `json:"id"`
-	Status      MigrationJobStatus `json:"status"`
-	StartedAt   int64              `json:"started_at"`
-	CompletedAt int64              `json:"completed_at,omitempty"`
-	Recompress  bool               `json:"recompress"`
-	Progress    MigrationProgress  `json:"progress"`
-	Result      *MigrationResult   `json:"result,omitempty"`
-	Error       string             `json:"error,omitempty"`
-}
-
-// MigrationProgress tracks migration progress
-type MigrationProgress struct {
-	TotalKeys     int `json:"total_keys"`
-	ProcessedKeys int `json:"processed_keys"`
-	Percent       int `json:"percent"`
-}
-
-// MigrationResult contains the final migration results
+// MigrationResult contains the results of a /cache/migrate job
 type MigrationResult struct {
 	Migrated     int      `json:"migrated"`
 	Recompressed int      `json:"recompressed"`
@@ -107,10 +96,89 @@ type MigrationResult struct {
 	Failed       int      `json:"failed"`
 	BytesSaved   int64    `json:"bytes_saved"`
 	MigratedKeys []string `json:"migrated_keys,omitempty"`
+	Applied      []string `json:"applied,omitempty"` // Migrations completed, in run order
 }
 
-// migrationJobs stores active and completed migration jobs
-var migrationJobs = struct {
-	sync.RWMutex
-	jobs map[string]*MigrationJob
-}{jobs: make(map[string]*MigrationJob)}
+// CacheAnalysis contains the results of a cache analysis run
+type CacheAnalysis struct {
+	TotalKeys              int              `json:"total_keys"`
+	TotalRawBytes          int64            `json:"total_raw_bytes"`
+	TotalDecompressedBytes int64            `json:"total_decompressed_bytes"`
+	KeysByPrefix           map[string]int   `json:"keys_by_prefix"`
+	SizeHistogram          []HistogramBin   `json:"size_histogram"`
+	CompressionHistogram   []HistogramBin   `json:"compression_ratio_histogram"`
+	Uncompressed           int              `json:"uncompressed_entries"`
+	LegacyFormatKeys       int              `json:"legacy_format_keys"`
+	Largest                []CacheKeySize   `json:"largest_entries"`
+	Duplicates             DuplicateSummary `json:"near_duplicates"`
+}
+
+// HistogramBin is a single histogram bucket
+type HistogramBin struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+	Bytes int64  `json:"bytes"`
+}
+
+// CacheKeySize pairs a cache key with its stored size
+type CacheKeySize struct {
+	Key   string `json:"key"`
+	Bytes int    `json:"bytes"`
+}
+
+// DuplicateSummary reports near-duplicate keys (query variants cached for the same title, artist and duration)
+type DuplicateSummary struct {
+	Groups           int              `json:"groups"`
+	Keys             int              `json:"keys"`
+	ReclaimableBytes int64            `json:"reclaimable_bytes"`
+	TopGroups        []DuplicateGroup `json:"top_groups"`
+}
+
+// DuplicateGroup is a set of keys that likely point at the same song
+type DuplicateGroup struct {
+	Track            string         `json:"track"`    // Normalized "title - artist (duration)"
+	BaseKey          string         `json:"base_key"` // The largest entry, which would be kept
+	Keys             []CacheKeySize `json:"keys"`
+	ReclaimableBytes int64          `json:"reclaimable_bytes"`
+}
+
+// DedupeResult contains the results of a deduplication run
+type DedupeResult struct {
+	DryRun          bool  `json:"dry_run"`
+	TrackGroups     int   `json:"track_groups"`     // Track IDs cached under more than one key
+	Aliased         int   `json:"aliased"`          // Keys turned into alias entries
+	AlreadyAliased  int   `json:"already_aliased"`  // Keys that were already aliases
+	ContentMismatch int   `json:"content_mismatch"` // Same track ID but different TTML - left untouched
+	Failed          int   `json:"failed"`
+	BytesBefore     int64 `json:"bytes_before"`
+	BytesAfter      int64 `json:"bytes_after"`
+	BytesSaved      int64 `json:"bytes_saved"`
+}
+
+// CacheVerifyResult contains the results of a /cache/verify job
+type CacheVerifyResult struct {
+	Repair          string            `json:"repair"` // What was done with corrupt entries: report, delete or quarantine
+	TotalKeys       int               `json:"total_keys"`
+	Healthy         int               `json:"healthy"`
+	Legacy          int               `json:"legacy"` // Readable but in an old format (no checksum, plain TTML), or with a codec this build doesn't know
+	Corrupt         int               `json:"corrupt"`
+	Deleted         int               `json:"deleted"`
+	Quarantined     int               `json:"quarantined"`
+	RepairFailed    int               `json:"repair_failed"`
+	LegacyByReason  map[string]int    `json:"legacy_by_reason"`
+	CorruptByReason map[string]int    `json:"corrupt_by_reason"`
+	CorruptKeys     []CorruptCacheKey `json:"corrupt_keys"` // First verifySampleSize corrupt entries
+}
+
+// CorruptCacheKey is a corrupt cache entry found by /cache/verify
+type CorruptCacheKey struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// BulkDeleteResult contains the results of a bulk key deletion
+type BulkDeleteResult struct {
+	Matched int `json:"matched"`
+	Deleted int `json:"deleted"`
+	Failed  int `json:"failed"`
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// errUpstreamBusy is the in-flight error of a lookup that never got an upstream
+// slot, so requests waiting on it answer 503 as well
+var errUpstreamBusy = errors.New("too many upstream lookups in progress, try again later")
+
+// upstreamLimiter caps concurrent upstream fetches. Beyond the limit requests queue
+// until a slot frees up or the queue timeout passes, when they are turned away with
+// the limiter's busy error.
+//
+// The global limiter caps fetches across all clients and priority lanes,
+// independent of the per-IP rate limits. Only the request leading a fetch takes a
+// slot; requests joining an in-flight fetch don't. Its queue is short, so a
+// cache-cold restart can't open hundreds of connections to Apple at once and trip
+// account-wide throttling. The low-priority lane (see priority.go) is another one.
+type upstreamLimiter struct {
+	slots   chan struct{} // nil = unlimited
+	timeout time.Duration
+	busy    error
+
+	active   atomic.Int64
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+var (
+	upstreamLimit     *upstreamLimiter
+	upstreamLimitOnce sync.Once
+)
+
+// getUpstreamLimiter returns the global limiter, sized from config on first use
+func getUpstreamLimiter() *upstreamLimiter {
+	upstreamLimitOnce.Do(func() {
+		timeout := time.Duration(conf().Configuration.UpstreamQueueTimeoutSecs) * time.Second
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		upstreamLimit = newUpstreamLimiter(conf().Configuration.UpstreamMaxConcurrent, timeout, errUpstreamBusy)
+	})
+	return upstreamLimit
+}
+
+// newUpstreamLimiter returns a limiter of maxConcurrent slots (0 = unlimited)
+func newUpstreamLimiter(maxConcurrent int, timeout time.Duration, busy error) *upstreamLimiter {
+	l := &upstreamLimiter{timeout: timeout, busy: busy}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// acquire reserves an upstream slot, queueing until one frees up, the queue timeout
+// passes or the client goes away. The returned release func must be called when
+// the upstream fetch is done.
+func (l *upstreamLimiter) acquire(ctx context.Context) (release func(), err error) {
+	if l.slots == nil {
+		l.active.Add(1)
+		return func() { l.active.Add(-1) }, nil
+	}
+
+	release = func() {
+		l.active.Add(-1)
+		<-l.slots
+	}
+	// Fast path: no queueing when a slot is free
+	select {
+	case l.slots <- struct{}{}:
+		l.active.Add(1)
+		return release, nil
+	default:
+	}
+
+	l.queued.Add(1)
+	defer l.queued.Add(-1)
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		l.active.Add(1)
+		return release, nil
+	case <-timer.C:
+		l.rejected.Add(1)
+		return nil, l.busy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// retryAfterSecs is the Retry-After sent with a rejection: one queue timeout
+func (l *upstreamLimiter) retryAfterSecs() int {
+	return max(int(l.timeout.Seconds()), 1)
+}
+
+// stats returns a snapshot of limiter usage for /stats
+func (l *upstreamLimiter) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"active":         l.active.Load(),
+		"queued":         l.queued.Load(),
+		"rejected":       l.rejected.Load(),
+		"max_concurrent": cap(l.slots), // 0 = unlimited
+	}
+}
+
+// acquireGlobalUpstreamSlot wraps the global limiter for handlers. On failure it
+// writes a 503 with Retry-After and returns ok=false.
+func acquireGlobalUpstreamSlot(w http.ResponseWriter, r *http.Request, provider string) (release func(), ok bool) {
+	limiter := getUpstreamLimiter()
+	release, err := limiter.acquire(r.Context())
+	if err == nil {
+		return release, true
+	}
+	log.Warnf("%s Upstream lookup not admitted: %v", logcolors.LogPriority, err)
+	respondUpstreamBusy(w, r, provider, err, limiter.retryAfterSecs())
+	return nil, false
+}
+
+// respondUpstreamBusy answers 503 with Retry-After for a lookup (or the in-flight
+// lookup it joined) that a limiter turned away
+func respondUpstreamBusy(w http.ResponseWriter, r *http.Request, provider string, err error, retryAfter int) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	resp := Respond(w, r).SetCacheStatus("MISS")
+	if provider != "" {
+		resp = resp.SetProvider(provider)
+	}
+	resp.Error(http.StatusServiceUnavailable, upstreamBusyBody(err, retryAfter))
+}
+
+// upstreamBusyBody is the body of respondUpstreamBusy
+func upstreamBusyBody(err error, retryAfter int) map[string]interface{} {
+	return map[string]interface{}{
+		"error":       err.Error(),
+		"retry_after": retryAfter,
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withUpstreamLimiter swaps the global upstream limiter for one test
+func withUpstreamLimiter(t *testing.T, l *upstreamLimiter) {
+	t.Helper()
+	getUpstreamLimiter()
+	orig := upstreamLimit
+	upstreamLimit = l
+	t.Cleanup(func() { upstreamLimit = orig })
+}
+
+func TestUpstreamLimiter_QueuesThenRejects(t *testing.T) {
+	l := newUpstreamLimiter(1, 200*time.Millisecond, errUpstreamBusy)
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A queued request gets the slot once it frees up
+	done := make(chan error)
+	go func() {
+		r, err := l.acquire(context.Background())
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if got := l.stats()["queued"]; got != int64(1) {
+		t.Errorf("queued = %v, want 1", got)
+	}
+	release()
+	if err := <-done; err != nil {
+		t.Fatalf("Queued request should get the freed slot, got %v", err)
+	}
+
+	// A request still queued at the timeout is rejected
+	release, _ = l.acquire(context.Background())
+	defer release()
+	if _, err := l.acquire(context.Background()); err != errUpstreamBusy {
+		t.Errorf("Expected errUpstreamBusy, got %v", err)
+	}
+	if got := l.stats()["rejected"]; got != int64(1) {
+		t.Errorf("rejected = %v, want 1", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.acquire(ctx); err != context.Canceled {
+		t.Errorf("Expected the client's cancellation, got %v", err)
+	}
+}
+
+func TestUpstreamLimiter_Unlimited(t *testing.T) {
+	l := newUpstreamLimiter(0, time.Second, errUpstreamBusy)
+	for i := 0; i < 100; i++ {
+		if _, err := l.acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := l.stats()["active"]; got != int64(100) {
+		t.Errorf("active = %v, want 100", got)
+	}
+}
+
+func TestGetLyrics_UpstreamBusy(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	l := newUpstreamLimiter(1, 50*time.Millisecond, errUpstreamBusy)
+	withUpstreamLimiter(t, l)
+
+	release, _ := l.acquire(context.Background())
+	defer release()
+
+	w := httptest.NewRecorder()
+	getLyrics(w, httptest.NewRequest(http.MethodGet, "/getLyrics?s=Busy+Song&a=Nobody", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, want 1", w.Header().Get("Retry-After"))
+	}
+	if _, _, found := getRecentAttempt(buildNormalizedCacheKey("Busy Song", "Nobody", "", "")); found {
+		t.Error("A lookup that never went upstream must not leave a recent-attempt marker")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// usageExportDefaultDays is the window exported when from is omitted (inclusive of to)
+const usageExportDefaultDays = 7
+
+// parseExportDays reads the from and to days (YYYY-MM-DD, inclusive) of a dataset
+// export, defaulting to the last usageExportDefaultDays days (UTC)
+func parseExportDays(r *http.Request) (string, string, error) {
+	query := r.URL.Query()
+	to := time.Now().UTC()
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return "", "", errors.New("to must be a date (YYYY-MM-DD)")
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -(usageExportDefaultDays - 1))
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return "", "", errors.New("from must be a date (YYYY-MM-DD)")
+		}
+		from = parsed
+	}
+	fromDay, toDay := from.Format(time.DateOnly), to.Format(time.DateOnly)
+	if fromDay > toDay {
+		return "", "", errors.New("from must not be after to")
+	}
+	return fromDay, toDay, nil
+}
+
+// statsExportHandler exports the anonymized usage dataset for offline analysis
+// (catalog coverage gaps, hit rates per provider). Rows are aggregated per
+// day/query/provider/cache status/latency bucket; IPs and user agents are never recorded.
+//
+// Query params:
+//   - format: json (default) or csv
+//   - from, to: YYYY-MM-DD, inclusive (default: the last 7 days, UTC)
+//   - min_count: drop rows with fewer requests (default 1)
+func statsExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if statsStore == nil {
+		Respond(w, r).Error(http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "Usage dataset is not available",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "format must be json or csv",
+		})
+		return
+	}
+
+	fromDay, toDay, err := parseExportDays(r)
+	if err != nil {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	minCount := int64(1)
+	if raw := query.Get("min_count"); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n > 0 {
+			minCount = n
+		}
+	}
+
+	rows, err := statsStore.ExportUsage(fromDay, toDay, minCount)
+	if err != nil {
+		Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=usage_"+fromDay+"_"+toDay+".csv")
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"day", "query", "provider", "cache_status", "latency_bucket", "count"})
+		for _, row := range rows {
+			cw.Write([]string{row.Day, row.Query, row.Provider, row.CacheStatus, row.LatencyBucket, strconv.FormatInt(row.Count, 10)})
+		}
+		cw.Flush()
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":  fromDay,
+		"to":    toDay,
+		"count": len(rows),
+		"rows":  rows,
+	})
+}
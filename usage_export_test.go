@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/stats"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsExportHandler_Unauthorized(t *testing.T) {
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	rr := httptest.NewRecorder()
+	statsExportHandler(rr, httptest.NewRequest("GET", "/stats/export", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", rr.Code)
+	}
+}
+
+func TestStatsExportHandler_Formats(t *testing.T) {
+	setupTestAuditLog(t)
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	stats.Get().RecordUsage("Hello Adele", "ttml", "HIT", 5*time.Millisecond)
+
+	req := httptest.NewRequest("GET", "/stats/export?format=csv", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr := httptest.NewRecorder()
+	statsExportHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Expected CSV content type, got %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if lines[0] != "day,query,provider,cache_status,latency_bucket,count" {
+		t.Errorf("Unexpected CSV header: %q", lines[0])
+	}
+	if !strings.Contains(rr.Body.String(), "hello adele,ttml,HIT,<100ms,1") {
+		t.Errorf("Expected the recorded row in CSV, got:\n%s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/stats/export", nil)
+	req.Header.Set("Authorization", "test-token")
+	rr = httptest.NewRecorder()
+	statsExportHandler(rr, req)
+	var body struct {
+		From  string           `json:"from"`
+		To    string           `json:"to"`
+		Count int              `json:"count"`
+		Rows  []stats.UsageRow `json:"rows"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode JSON: %v", err)
+	}
+	if body.To != time.Now().UTC().Format(time.DateOnly) || body.From > body.To {
+		t.Errorf("Unexpected default range %s..%s", body.From, body.To)
+	}
+	if body.Count != len(body.Rows) {
+		t.Errorf("count %d does not match %d rows", body.Count, len(body.Rows))
+	}
+}
+
+func TestStatsExportHandler_BadParams(t *testing.T) {
+	setupTestAuditLog(t)
+	originalToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = originalToken }()
+
+	for _, query := range []string{"format=xml", "from=yesterday", "from=2024-02-01&to=2024-01-01"} {
+		req := httptest.NewRequest("GET", "/stats/export?"+query, nil)
+		req.Header.Set("Authorization", "test-token")
+		rr := httptest.NewRecorder()
+		statsExportHandler(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
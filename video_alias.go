@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"lyrics-api-go/logcolors"
+	"net/http"
+	"regexp"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// videoAliasPrefix namespaces videoId aliases in the indexes bucket. Unlike the
+// "video:" index (every cache key a video was ever seen with), an alias names the
+// one key the video resolved to, so later lookups can skip fuzzy matching.
+const videoAliasPrefix = "videoalias:"
+
+// videoIDPattern matches YouTube video IDs
+var videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// VideoAlias maps a YouTube video ID to the cache entry its first successful lookup
+// resolved to
+type VideoAlias struct {
+	CacheKey  string `json:"cacheKey"`
+	TrackID   string `json:"trackId,omitempty"` // Apple track ID, when the lookup went upstream
+	CreatedAt int64  `json:"createdAt"`
+}
+
+func getVideoAlias(videoID string) (*VideoAlias, bool) {
+	raw, ok := metadataGet(indexesBucket, videoAliasPrefix+videoID)
+	if !ok {
+		return nil, false
+	}
+	var alias VideoAlias
+	if err := json.Unmarshal([]byte(raw), &alias); err != nil || alias.CacheKey == "" {
+		return nil, false
+	}
+	return &alias, true
+}
+
+// rememberVideoAlias records where a video resolved to. The first resolution wins:
+// it is never overwritten by lookups, only removed through /cache/video-alias.
+func rememberVideoAlias(videoID, cacheKey, trackID string) {
+	if !videoIDPattern.MatchString(videoID) || cacheKey == "" {
+		return
+	}
+
+	metadataMu.Lock()
+	defer metadataMu.Unlock()
+
+	if _, ok := getVideoAlias(videoID); ok {
+		return
+	}
+	data, err := json.Marshal(VideoAlias{
+		CacheKey:  cacheKey,
+		TrackID:   trackID,
+		CreatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return
+	}
+	if err := metadataSet(indexesBucket, videoAliasPrefix+videoID, string(data)); err != nil {
+		log.Errorf("%s Error setting video alias %s: %v", logcolors.LogCache, videoID, err)
+	}
+}
+
+// lookupVideoAlias returns the cached lyrics a video was resolved to. When the query
+// key is gone but the track blob is still there, the blob is served.
+func lookupVideoAlias(videoID string) (*CachedLyrics, string, bool) {
+	if !videoIDPattern.MatchString(videoID) {
+		return nil, "", false
+	}
+	alias, ok := getVideoAlias(videoID)
+	if !ok {
+		return nil, "", false
+	}
+	if cached, ok := getCachedLyrics(alias.CacheKey); ok {
+		return cached, alias.CacheKey, true
+	}
+	if alias.TrackID != "" {
+		trackKey := trackLyricsKey(alias.TrackID)
+		if cached, ok := getCachedLyrics(trackKey); ok {
+			return cached, trackKey, true
+		}
+	}
+	return nil, "", false
+}
+
+// videoAliasHandler shows (GET) or removes (DELETE) the alias of a video, e.g. when
+// its first lookup matched the wrong song.
+//
+// Query params:
+//   - v: YouTube video ID (required)
+func videoAliasHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != conf().Configuration.CacheAccessToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	videoID := r.URL.Query().Get("v")
+	if !videoIDPattern.MatchString(videoID) {
+		Respond(w, r).Error(http.StatusBadRequest, map[string]interface{}{
+			"error": "v must be an 11-character YouTube video ID",
+		})
+		return
+	}
+	alias, ok := getVideoAlias(videoID)
+	if !ok {
+		Respond(w, r).Error(http.StatusNotFound, map[string]interface{}{
+			"error": "No alias for this video",
+			"v":     videoID,
+		})
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		metadataMu.Lock()
+		err := persistentCache.DeleteFromBucket(indexesBucket, videoAliasPrefix+videoID)
+		metadataMu.Unlock()
+		if err != nil {
+			Respond(w, r).Error(http.StatusInternalServerError, map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+		log.Infof("%s Removed video alias %s -> %s", logcolors.LogCacheClear, videoID, alias.CacheKey)
+		Respond(w, r).JSON(map[string]interface{}{
+			"deleted": true,
+			"v":       videoID,
+			"alias":   alias,
+		})
+		return
+	}
+
+	_, _, cached := lookupVideoAlias(videoID)
+	Respond(w, r).JSON(map[string]interface{}{
+		"v":      videoID,
+		"alias":  alias,
+		"cached": cached,
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testVideoID = "YQHsXMglC9A"
+
+func TestGetLyrics_VideoAlias(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	setCachedLyrics(buildNormalizedCacheKey("Hello", "Adele", "", ""), formatTestTTML, 295000, 0.9, "", false)
+
+	// Unknown video and no title: nothing to go on
+	w := httptest.NewRecorder()
+	getLyrics(w, httptest.NewRequest(http.MethodGet, "/getLyrics?v="+testVideoID, nil))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("unknown video: status = %d", w.Code)
+	}
+
+	// A hit with the title resolves the video
+	w = httptest.NewRecorder()
+	getLyrics(w, httptest.NewRequest(http.MethodGet, "/getLyrics?s=Hello&a=Adele&v="+testVideoID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("by title: status = %d", w.Code)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := getVideoAlias(testVideoID); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Alias was not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Later the scraped title differs, but the video still finds its entry
+	w = httptest.NewRecorder()
+	getLyrics(w, httptest.NewRequest(http.MethodGet, "/getLyrics?s=Hello+(Official+Music+Video)&a=AdeleVEVO&v="+testVideoID, nil))
+	if w.Code != http.StatusOK || w.Header().Get("X-Cache-Status") != "HIT" {
+		t.Errorf("by alias: status = %d, cache = %q", w.Code, w.Header().Get("X-Cache-Status"))
+	}
+	w = httptest.NewRecorder()
+	getLyrics(w, httptest.NewRequest(http.MethodGet, "/getLyrics?v="+testVideoID, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("video only: status = %d", w.Code)
+	}
+}
+
+func TestRememberVideoAlias_FirstWinsAndTrackFallback(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+
+	key := buildNormalizedCacheKey("Hello", "Adele", "", "")
+	setCachedLyricsForTrack(key, "1051394215", formatTestTTML, 295000, 0.9, "", false)
+	rememberVideoAlias(testVideoID, key, "1051394215")
+	rememberVideoAlias(testVideoID, "ttml_lyrics:something else", "")
+	rememberVideoAlias("not a video", key, "")
+
+	alias, ok := getVideoAlias(testVideoID)
+	if !ok || alias.CacheKey != key {
+		t.Fatalf("First resolution should win, got %+v", alias)
+	}
+	if _, ok := getVideoAlias("not a video"); ok {
+		t.Error("Invalid video IDs must not be aliased")
+	}
+
+	// The query key is gone, the track blob is not
+	persistentCache.Delete(key)
+	if cached, found, ok := lookupVideoAlias(testVideoID); !ok || found != trackLyricsKey("1051394215") || cached.TTML != formatTestTTML {
+		t.Errorf("Expected the track blob, got %q, %v", found, ok)
+	}
+}
+
+func TestVideoAliasHandler(t *testing.T) {
+	cleanup := setupTestMetadata(t)
+	defer cleanup()
+	origToken := conf().Configuration.CacheAccessToken
+	conf().Configuration.CacheAccessToken = "test-token"
+	defer func() { conf().Configuration.CacheAccessToken = origToken }()
+
+	rememberVideoAlias(testVideoID, buildNormalizedCacheKey("Hello", "Adele", "", ""), "")
+
+	serve := func(method, target string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, target, nil)
+		r.Header.Set("Authorization", "test-token")
+		videoAliasHandler(w, r)
+		return w.Code
+	}
+	if code := serve(http.MethodGet, "/cache/video-alias?v=bad"); code != http.StatusBadRequest {
+		t.Errorf("bad id: status = %d", code)
+	}
+	if code := serve(http.MethodGet, "/cache/video-alias?v="+testVideoID); code != http.StatusOK {
+		t.Errorf("get: status = %d", code)
+	}
+	if code := serve(http.MethodDelete, "/cache/video-alias?v="+testVideoID); code != http.StatusOK {
+		t.Errorf("delete: status = %d", code)
+	}
+	if _, ok := getVideoAlias(testVideoID); ok {
+		t.Error("Alias should be gone after DELETE")
+	}
+	if code := serve(http.MethodGet, "/cache/video-alias?v="+testVideoID); code != http.StatusNotFound {
+		t.Errorf("get after delete: status = %d", code)
+	}
+}